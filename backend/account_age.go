@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Anti-abuse rejections for accounts that are too new to create or join events
+const (
+	ErrCodeAccountTooNew   = "ACCOUNT_TOO_NEW"
+	ErrCodeNewAccountLimit = "NEW_ACCOUNT_LIMIT"
+)
+
+// newAccountPeriod is how long an account counts as new for NewAccountEventLimit and ReviewFirstEvent
+const newAccountPeriod = 24 * time.Hour
+
+// NewAccountError reports an action refused because the account is too young. AllowedAt is when
+// the action becomes possible.
+type NewAccountError struct {
+	Code      string // ErrCodeAccountTooNew or ErrCodeNewAccountLimit
+	Action    string // "create" or "join"
+	AllowedAt time.Time
+	Limit     int // NewAccountEventLimit, for ErrCodeNewAccountLimit
+}
+
+func (e *NewAccountError) Error() string {
+	if e.Code == ErrCodeNewAccountLimit {
+		return fmt.Sprintf("New accounts can create at most %d events in their first 24 hours", e.Limit)
+	}
+	return fmt.Sprintf("Your account is too new to %s events yet. Please try again later", e.Action)
+}
+
+func (e *NewAccountError) respond(c *gin.Context) {
+	retryAfter := int(time.Until(e.AllowedAt).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	body := gin.H{"error": e.Error(), "code": e.Code, "allowed_at": e.AllowedAt.UTC().Format(time.RFC3339)}
+	if e.Code == ErrCodeNewAccountLimit {
+		body["limit"] = e.Limit
+	}
+	c.JSON(http.StatusForbidden, body)
+}
+
+// accountCreatedAt reads when the user registered
+func accountCreatedAt(q sqlQueryRower, userID int) (time.Time, error) {
+	var createdAt time.Time
+	err := q.QueryRow(`SELECT created_at FROM users WHERE id = ?`, userID).Scan(&createdAt)
+	return createdAt, err
+}
+
+// checkNewAccountJoin returns a *NewAccountError while the account is younger than MinAccountAgeToJoin
+func checkNewAccountJoin(q sqlQueryRower, userID int, now time.Time) error {
+	if appConfig.MinAccountAgeToJoin <= 0 {
+		return nil
+	}
+	createdAt, err := accountCreatedAt(q, userID)
+	if err != nil {
+		return err
+	}
+	if allowedAt := createdAt.Add(appConfig.MinAccountAgeToJoin); now.Before(allowedAt) {
+		return &NewAccountError{Code: ErrCodeAccountTooNew, Action: "join", AllowedAt: allowedAt}
+	}
+	return nil
+}
+
+// checkNewAccountCreate returns a *NewAccountError while the account is younger than
+// MinAccountAgeToCreate or has used up NewAccountEventLimit in its first 24 hours. review is true
+// when ReviewFirstEvent is on and this would be a new account's first event.
+func checkNewAccountCreate(q sqlQueryRower, userID int, now time.Time) (review bool, err error) {
+	if appConfig.MinAccountAgeToCreate <= 0 && appConfig.NewAccountEventLimit <= 0 && !appConfig.ReviewFirstEvent {
+		return false, nil
+	}
+	createdAt, err := accountCreatedAt(q, userID)
+	if err != nil {
+		return false, err
+	}
+	if allowedAt := createdAt.Add(appConfig.MinAccountAgeToCreate); now.Before(allowedAt) {
+		return false, &NewAccountError{Code: ErrCodeAccountTooNew, Action: "create", AllowedAt: allowedAt}
+	}
+
+	newUntil := createdAt.Add(newAccountPeriod)
+	if !now.Before(newUntil) {
+		return false, nil
+	}
+	// Every event a new account has is one from its first 24 hours
+	var created int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM events WHERE user_id = ?`, userID).Scan(&created); err != nil {
+		return false, err
+	}
+	if appConfig.NewAccountEventLimit > 0 && created >= appConfig.NewAccountEventLimit {
+		return false, &NewAccountError{Code: ErrCodeNewAccountLimit, Action: "create", AllowedAt: newUntil, Limit: appConfig.NewAccountEventLimit}
+	}
+	return appConfig.ReviewFirstEvent && created == 0, nil
+}
+
+// applyNewAccountRules enforces the new-account rules on an event about to be created, adding a
+// flag to moderation when it must be reviewed first. On rejection or failure it writes the
+// response and returns false. Admins are exempt.
+func applyNewAccountRules(c *gin.Context, moderation *ModerationResult, userID int, isAdmin bool) bool {
+	if isAdmin {
+		return true
+	}
+	review, err := checkNewAccountCreate(db, userID, time.Now())
+	var newAccountErr *NewAccountError
+	if errors.As(err, &newAccountErr) {
+		log.Printf("🛡️  User %d can't create events yet: %s", userID, newAccountErr.Code)
+		newAccountErr.respond(c)
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ Failed to check account age for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return false
+	}
+	if review {
+		moderation.Violations = append(moderation.Violations, ModerationViolation{
+			Rule: ModerationRuleNewAccount, Action: ModerationFlag, Detail: "first event from a new account",
+		})
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccountRules(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MinAccountAgeToCreate = 30 * time.Minute
+		cfg.MinAccountAgeToJoin = 10 * time.Minute
+		cfg.NewAccountEventLimit = 2
+		cfg.ReviewFirstEvent = true
+	})
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createTestEvent(t, testDB, organizerID, "Board Games")
+
+	var seq int
+	newUser := func(age time.Duration, isAdmin bool) (int64, string) {
+		t.Helper()
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, "User", "password123", isAdmin)
+		_, err := testDB.Exec(`UPDATE users SET created_at = ? WHERE id = ?`, time.Now().Add(-age).UTC(), id)
+		require.NoError(t, err)
+		token, _ := generateToken(User{ID: int(id), Email: email, IsAdmin: isAdmin, EmailVerified: true})
+		return id, token
+	}
+	create := func(token, title string) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", "/api/events", token, gin.H{
+			"title": title, "description": "A friendly meetup for the account age tests",
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		})
+	}
+	join := func(token string) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), token, nil)
+	}
+	rejection := func(w *httptest.ResponseRecorder, code string) map[string]interface{} {
+		t.Helper()
+		require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, code, body["code"])
+		assert.NotEmpty(t, body["allowed_at"])
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		return body
+	}
+	created := func(w *httptest.ResponseRecorder) Event {
+		t.Helper()
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+
+	t.Run("Accounts younger than the minimum age can't create or join", func(t *testing.T) {
+		_, token := newUser(5*time.Minute, false)
+		rejection(create(token, "Too Soon"), ErrCodeAccountTooNew)
+		rejection(join(token), ErrCodeAccountTooNew)
+
+		_, token = newUser(20*time.Minute, false)
+		rejection(create(token, "Still Too Soon"), ErrCodeAccountTooNew)
+		assert.Equal(t, http.StatusOK, join(token).Code, "old enough to join")
+	})
+
+	t.Run("A new account's first event waits for review", func(t *testing.T) {
+		userID, token := newUser(time.Hour, false)
+		first := created(create(token, "First Meetup"))
+		assert.True(t, first.HiddenPendingReview)
+		var violations string
+		require.NoError(t, testDB.QueryRow(`SELECT violations FROM moderation_queue WHERE content_type = 'event' AND content_id = ?`, first.ID).Scan(&violations))
+		assert.Contains(t, violations, ModerationRuleNewAccount)
+
+		second := created(create(token, "Second Meetup"))
+		assert.False(t, second.HiddenPendingReview, "only the first event is held")
+
+		body := rejection(create(token, "Third Meetup"), ErrCodeNewAccountLimit)
+		assert.Equal(t, float64(2), body["limit"])
+
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE user_id = ?`, userID).Scan(&count))
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("After the first 24 hours only the regular rules apply", func(t *testing.T) {
+		_, token := newUser(25*time.Hour, false)
+		for i := 0; i < 3; i++ {
+			event := created(create(token, fmt.Sprintf("Weekly Meetup %d", i)))
+			assert.False(t, event.HiddenPendingReview)
+		}
+	})
+
+	t.Run("Admins are exempt", func(t *testing.T) {
+		_, token := newUser(time.Minute, true)
+		event := created(create(token, "Admin Meetup"))
+		assert.False(t, event.HiddenPendingReview)
+		assert.Equal(t, http.StatusOK, join(token).Code)
+	})
+
+	t.Run("Rules are off by default", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { *cfg = *DefaultConfig() })
+		_, token := newUser(0, false)
+		event := created(create(token, "Brand New Meetup"))
+		assert.False(t, event.HiddenPendingReview)
+		assert.Equal(t, http.StatusOK, join(token).Code)
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Activity verbs. Each row reads as "<actor> <verb> <event>"; the feed owner is either the actor
+// ("you joined X") or someone the action concerns ("Z joined your event").
+const (
+	ActivityJoined    = "joined"
+	ActivityLeft      = "left"
+	ActivityCommented = "commented"
+	ActivityCancelled = "cancelled"
+	ActivityPosted    = "posted"    // A new event in a group, sent to its members
+	ActivityTookOver  = "took_over" // The actor accepted the event's transfer and now organizes it
+)
+
+// activityRetention is how long feed entries are kept (see runCleanup)
+const activityRetention = 90 * 24 * time.Hour
+
+// ActivityEntry is one item of a user's activity feed
+type ActivityEntry struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actor_id"`
+	ActorName string    `json:"actor_name"`
+	IsSelf    bool      `json:"is_self"` // The viewer did this themselves
+	Verb      string    `json:"verb"`
+	EventID   int       `json:"event_id"`
+	Title     string    `json:"event_title"`
+	Slug      string    `json:"event_slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// activityRecipients returns the query selecting whose feeds get an entry for verb, with its args.
+// The result column is named recipient.
+func activityRecipients(verb string, actorID int, eventID interface{}) (string, []interface{}) {
+	switch verb {
+	case ActivityJoined, ActivityLeft:
+		// The actor and the organizer (UNION drops the duplicate when they're the same person)
+		return `SELECT ? AS recipient UNION SELECT user_id FROM events WHERE id = ?`, []interface{}{actorID, eventID}
+	case ActivityCommented:
+		return `SELECT user_id AS recipient FROM events WHERE id = ? AND user_id != ?`, []interface{}{eventID, actorID}
+	case ActivityCancelled, ActivityTookOver:
+		return `SELECT user_id AS recipient FROM event_participants WHERE event_id = ? UNION SELECT user_id FROM events WHERE id = ?`, []interface{}{eventID, eventID}
+	case ActivityPosted:
+		// Members of the event's group who haven't turned notifications off
+		return `SELECT m.user_id AS recipient FROM group_members m JOIN events e ON e.group_id = m.group_id
+			WHERE e.id = ? AND m.status = 'member' AND m.notify = 1 AND m.user_id != ?`, []interface{}{eventID, actorID}
+	}
+	return "", nil
+}
+
+// recordActivity writes feed entries for an action. Pass the transaction the action runs in so
+// the entries commit (or roll back) with it. Failures are logged, never surfaced: the feed is
+// a convenience and must not break joins or comments.
+func recordActivity(exec sqlExecer, actorID int, verb string, eventID interface{}) {
+	recipients, args := activityRecipients(verb, actorID, eventID)
+	if recipients == "" {
+		log.Printf("⚠️  Unknown activity verb %q", verb)
+		return
+	}
+	_, err := exec.Exec(`
+		INSERT INTO activity_log (user_id, actor_id, verb, event_id)
+		SELECT recipient, ?, ?, ? FROM (`+recipients+`) WHERE recipient IS NOT NULL
+	`, append([]interface{}{actorID, verb, eventID}, args...)...)
+	if err != nil {
+		log.Printf("⚠️  Failed to record %s activity for event %v: %v", verb, eventID, err)
+	}
+}
+
+// getOwnActivity returns the viewer's activity feed, newest first (GET /api/profile/activity?page=)
+// Entries whose actor is blocked by or blocking the viewer are left out.
+func getOwnActivity(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	page, perPage := parsePagination(c)
+	log.Printf("📰 GET /api/profile/activity - User %d fetching page %d", userID, page)
+
+	const visible = `
+		FROM activity_log a
+		JOIN events e ON e.id = a.event_id
+		LEFT JOIN users actor ON actor.id = a.actor_id
+		WHERE a.user_id = ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = a.user_id AND b.blocked_id = a.actor_id)
+			   OR (b.blocker_id = a.actor_id AND b.blocked_id = a.user_id)
+		  )`
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) `+visible, userID).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count activity: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.id, a.actor_id, COALESCE(actor.name, ''), a.verb, e.id, e.title, COALESCE(e.slug, ''), a.created_at
+		`+visible+`
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?
+	`, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Printf("❌ Failed to fetch activity: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
+		return
+	}
+	defer rows.Close()
+
+	entries := []ActivityEntry{}
+	for rows.Next() {
+		var a ActivityEntry
+		if err := rows.Scan(&a.ID, &a.ActorID, &a.ActorName, &a.Verb, &a.EventID, &a.Title, &a.Slug, &a.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning activity: %v", err)
+			continue
+		}
+		a.IsSelf = a.ActorID == userID
+		entries = append(entries, a)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activity": entries,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityFeed(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.POST("/events/:id/comments", createEventComment)
+	protected.GET("/profile/activity", getOwnActivity)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.POST("/events/bulk", adminBulkEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Board Games")
+	otherID := createTestEvent(t, testDB, organizerID, "Pub Quiz")
+
+	type feed struct {
+		Activity []ActivityEntry `json:"activity"`
+		Total    int             `json:"total"`
+	}
+	fetch := func(token, query string) feed {
+		w := doJSON(router, "GET", "/api/profile/activity"+query, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var f feed
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &f))
+		return f
+	}
+	summarize := func(f feed) []string {
+		var lines []string
+		for _, a := range f.Activity {
+			lines = append(lines, fmt.Sprintf("%s %s %s", a.ActorName, a.Verb, a.Title))
+		}
+		return lines
+	}
+
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), aliceToken, nil).Code)
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", otherID), aliceToken, nil).Code)
+	require.Equal(t, http.StatusOK, doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d/leave", otherID), aliceToken, nil).Code)
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), bobToken, nil).Code)
+	require.Equal(t, http.StatusCreated, doJSON(router, "POST", fmt.Sprintf("/api/events/%d/comments", eventID), bobToken, gin.H{"comment": "Bringing Catan"}).Code)
+	require.Equal(t, http.StatusCreated, doJSON(router, "POST", fmt.Sprintf("/api/events/%d/comments", eventID), organizerToken, gin.H{"comment": "Great!"}).Code)
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", "/api/admin/events/bulk", adminToken, gin.H{"ids": []int64{eventID}, "action": "cancel"}).Code)
+
+	t.Run("Each writer fans out to the right feeds", func(t *testing.T) {
+		assert.Equal(t, []string{
+			" cancelled Board Games",
+			"Bob commented Board Games",
+			"Bob joined Board Games",
+			"Alice left Pub Quiz",
+			"Alice joined Pub Quiz",
+			"Alice joined Board Games",
+		}, summarize(fetch(organizerToken, "")), "organizer's own comment isn't in their feed")
+
+		alice := fetch(aliceToken, "")
+		assert.Equal(t, []string{
+			" cancelled Board Games",
+			"Alice left Pub Quiz",
+			"Alice joined Pub Quiz",
+			"Alice joined Board Games",
+		}, summarize(alice))
+		assert.True(t, alice.Activity[1].IsSelf)
+		assert.Zero(t, alice.Activity[0].ActorID, "moderators stay anonymous")
+
+		assert.Equal(t, []string{" cancelled Board Games", "Bob joined Board Games"}, summarize(fetch(bobToken, "")))
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		first := fetch(organizerToken, "?page=1&per_page=4")
+		second := fetch(organizerToken, "?page=2&per_page=4")
+		assert.Equal(t, 6, first.Total)
+		assert.Len(t, first.Activity, 4)
+		assert.Len(t, second.Activity, 2)
+		assert.Equal(t, "Alice joined Board Games", summarize(second)[1])
+	})
+
+	t.Run("Blocked actors are filtered out", func(t *testing.T) {
+		_, err := testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, bobID, organizerID)
+		require.NoError(t, err)
+		f := fetch(organizerToken, "")
+		assert.Equal(t, 4, f.Total)
+		for _, a := range f.Activity {
+			assert.NotEqual(t, int(bobID), a.ActorID)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx so write helpers can run inside a transaction
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// bulkAction applies fn to one ID and reports whether a row was affected
+type bulkAction func(tx *sql.Tx, id int) (bool, error)
+
+// adminBulkUsers applies block/unblock/verify_email to many users in one transaction (POST /api/admin/users/bulk)
+func adminBulkUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req BulkUserActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must contain 1-100 ids and a valid action"})
+		return
+	}
+
+	log.Printf("👥 POST /api/admin/users/bulk - Admin %d applying %s to %d users", c.GetInt("user_id"), req.Action, len(req.IDs))
+
+	// Blocks go through applyUserBlock so they are permanent, replace any suspension and are audited
+	if req.Action == "block" || req.Action == "unblock" {
+		adminID := c.GetInt("user_id")
+		runBulk(c, req.IDs, func(tx *sql.Tx, id int) (bool, error) {
+			err := applyUserBlock(tx, adminID, id, req.Action == "block", nil, "")
+			if errors.Is(err, errUserNotFound) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		return
+	}
+
+	adminID := c.GetInt("user_id")
+	committed := runBulk(c, req.IDs, func(tx *sql.Tx, id int) (bool, error) {
+		result, err := tx.ExecContext(ctx, "UPDATE users SET email_verified = 1 WHERE id = ?", id)
+		if err != nil {
+			return false, err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return false, nil
+		}
+		return true, recordAdminAudit(tx, adminID, AuditEmailVerified, id, gin.H{"bulk": true})
+	})
+	if committed && req.Action == "verify_email" {
+		for _, id := range req.IDs {
+			publishDraftsAfterVerification(ctx, id)
+		}
+	}
+}
+
+// adminBulkEvents deletes or cancels many events in one transaction (POST /api/admin/events/bulk)
+func adminBulkEvents(c *gin.Context) {
+	var req BulkEventActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must contain 1-100 ids and a valid action"})
+		return
+	}
+
+	log.Printf("📋 POST /api/admin/events/bulk - Admin %d applying %s to %d events", c.GetInt("user_id"), req.Action, len(req.IDs))
+
+	adminID := c.GetInt("user_id")
+	switch req.Action {
+	case "delete":
+		runBulk(c, req.IDs, func(tx *sql.Tx, id int) (bool, error) {
+			return auditEventAction(tx, adminID, AuditEventDeleted, id, gin.H{"bulk": true}, func() (bool, error) {
+				return deleteEventRecord(c.Request.Context(), tx, id)
+			})
+		})
+	case "cancel":
+		cancelled := []int{}
+		committed := runBulk(c, req.IDs, func(tx *sql.Tx, id int) (bool, error) {
+			found, err := auditEventAction(tx, adminID, AuditEventCancelled, id, gin.H{"bulk": true}, func() (bool, error) {
+				return cancelEventRecord(tx, id, 0)
+			})
+			if found {
+				cancelled = append(cancelled, id)
+			}
+			return found, err
+		})
+		if committed {
+			for _, id := range cancelled {
+				webhookDispatch.Dispatch(WebhookEventCancelled, id, 0)
+				broadcastEvent(WebhookEventCancelled, id)
+			}
+		}
+	}
+}
+
+// auditEventAction runs apply on an event and, when it changed something, records action in the
+// admin audit log against the organizer. The title is read up front because a delete leaves
+// nothing else to go by; details gains event_id and title.
+func auditEventAction(tx *sql.Tx, adminID int, action string, eventID int, details gin.H, apply func() (bool, error)) (bool, error) {
+	var organizerID sql.NullInt64
+	var title string
+	err := tx.QueryRow(`SELECT user_id, title FROM events WHERE id = ?`, eventID).Scan(&organizerID, &title)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	applied, err := apply()
+	if err != nil || !applied {
+		return applied, err
+	}
+	details["event_id"] = eventID
+	details["title"] = title
+	return true, recordAdminAudit(tx, adminID, action, int(organizerID.Int64), details)
+}
+
+// cancelEventRecord soft-cancels an event and tells its participants via their activity feeds and inboxes;
+// already cancelled events count as not found. actorID is 0 for moderation so admins stay anonymous.
+func cancelEventRecord(exec sqlExecer, id interface{}, actorID int) (bool, error) {
+	result, err := exec.Exec("UPDATE events SET cancelled_at = CURRENT_TIMESTAMP, updated_at = ? WHERE id = ? AND cancelled_at IS NULL",
+		time.Now().UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected > 0 {
+		recordActivity(exec, actorID, ActivityCancelled, id)
+		notifyEventParticipants(exec, id, NotificationEventCancelled, actorID)
+	}
+	return rowsAffected > 0, nil
+}
+
+// runBulk executes action for every ID inside a single transaction and writes the per-ID report.
+// Missing IDs are reported as not_found and events with a pending transfer as transfer_pending;
+// any database error rolls back the whole batch.
+// Returns whether the batch was committed.
+func runBulk(c *gin.Context, ids []int, action bulkAction) bool {
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run bulk operation"})
+		return false
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	results := make([]BulkResult, 0, len(ids))
+	succeeded := 0
+	for i, id := range ids {
+		found, err := action(tx, id)
+		// Events someone may be taking over are skipped, not a reason to undo the batch
+		if errors.Is(err, errTransferPending) {
+			results = append(results, BulkResult{ID: id, Status: BulkResultTransferPending})
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Bulk operation failed on id %d, rolling back: %v", id, err)
+			results = append(results, BulkResult{ID: id, Status: BulkResultError})
+			for _, remaining := range ids[i+1:] {
+				results = append(results, BulkResult{ID: remaining, Status: BulkResultRolledBack})
+			}
+			// Earlier successes are undone by the rollback
+			for j := 0; j < i; j++ {
+				if results[j].Status == BulkResultSuccess {
+					results[j].Status = BulkResultRolledBack
+				}
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":     "Bulk operation failed, no changes were applied",
+				"results":   results,
+				"succeeded": 0,
+			})
+			return false
+		}
+
+		if found {
+			succeeded++
+			results = append(results, BulkResult{ID: id, Status: BulkResultSuccess})
+		} else {
+			results = append(results, BulkResult{ID: id, Status: BulkResultNotFound})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing bulk operation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run bulk operation"})
+		return false
+	}
+
+	eventListCache.Invalidate()
+	log.Printf("✅ Bulk operation applied to %d/%d ids", succeeded, len(ids))
+	c.JSON(http.StatusOK, gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+	})
+	return true
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+}
+
+func setupBulkRouter() *gin.Engine {
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.POST("/users/bulk", adminBulkUsers)
+	admin.POST("/events/bulk", adminBulkEvents)
+	return router
+}
+
+func TestAdminBulkUsers(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupBulkRouter()
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	spam1 := createTestUser(t, testDB, "spam1@example.com", "Spam One", "password123", false)
+	spam2 := createTestUser(t, testDB, "spam2@example.com", "Spam Two", "password123", false)
+
+	t.Run("Mixed valid and missing IDs", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/users/bulk", adminToken,
+			gin.H{"ids": []int64{spam1, 9999, spam2}, "action": "block"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp bulkResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, []BulkResult{
+			{ID: int(spam1), Status: BulkResultSuccess},
+			{ID: 9999, Status: BulkResultNotFound},
+			{ID: int(spam2), Status: BulkResultSuccess},
+		}, resp.Results)
+
+		var blocked int
+		testDB.QueryRow(`SELECT COUNT(*) FROM users WHERE is_blocked = 1`).Scan(&blocked)
+		assert.Equal(t, 2, blocked)
+	})
+
+	t.Run("Forced error rolls back the whole batch", func(t *testing.T) {
+		_, err := testDB.Exec(fmt.Sprintf(`
+			CREATE TRIGGER fail_unblock BEFORE UPDATE ON users WHEN NEW.id = %d
+			BEGIN SELECT RAISE(ABORT, 'forced failure'); END`, spam2))
+		require.NoError(t, err)
+		defer testDB.Exec(`DROP TRIGGER fail_unblock`)
+
+		w := doJSON(router, "POST", "/api/admin/users/bulk", adminToken,
+			gin.H{"ids": []int64{spam1, spam2, 9999}, "action": "unblock"})
+		require.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+
+		var resp bulkResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []BulkResult{
+			{ID: int(spam1), Status: BulkResultRolledBack},
+			{ID: int(spam2), Status: BulkResultError},
+			{ID: 9999, Status: BulkResultRolledBack},
+		}, resp.Results)
+
+		var blocked bool
+		testDB.QueryRow(`SELECT is_blocked FROM users WHERE id = ?`, spam1).Scan(&blocked)
+		assert.True(t, blocked, "first user's unblock must be rolled back")
+	})
+
+	t.Run("Verifying emails is audited per user", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/users/bulk", adminToken,
+			gin.H{"ids": []int64{spam1, 9999, spam2}, "action": "verify_email"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		for _, id := range []int64{spam1, spam2} {
+			assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND admin_id = ? AND target_user_id = ?`,
+				AuditEmailVerified, adminID, id))
+		}
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ?`, AuditEmailVerified),
+			"missing IDs leave no entry")
+	})
+
+	t.Run("Batch size capped at 100", func(t *testing.T) {
+		ids := make([]int, 101)
+		for i := range ids {
+			ids[i] = i + 1
+		}
+		w := doJSON(router, "POST", "/api/admin/users/bulk", adminToken, gin.H{"ids": ids, "action": "block"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown action rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/users/bulk", adminToken, gin.H{"ids": []int64{spam1}, "action": "delete"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Non-admin forbidden", func(t *testing.T) {
+		token, _ := generateToken(User{ID: int(spam1), Email: "spam1@example.com"})
+		w := doJSON(router, "POST", "/api/admin/users/bulk", token, gin.H{"ids": []int64{spam2}, "action": "block"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAdminBulkEvents(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupBulkRouter()
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	event1 := createTestEvent(t, testDB, organizerID, "Spam Event 1")
+	event2 := createTestEvent(t, testDB, organizerID, "Spam Event 2")
+	event3 := createTestEvent(t, testDB, organizerID, "Legit Event")
+
+	t.Run("Cancel keeps the event but marks it cancelled", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/events/bulk", adminToken,
+			gin.H{"ids": []int64{event3, 4242}, "action": "cancel"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp bulkResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, BulkResultNotFound, resp.Results[1].Status)
+
+		var cancelled bool
+		testDB.QueryRow(`SELECT cancelled_at IS NOT NULL FROM events WHERE id = ?`, event3).Scan(&cancelled)
+		assert.True(t, cancelled)
+	})
+
+	t.Run("Delete reports missing IDs", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/events/bulk", adminToken,
+			gin.H{"ids": []int64{event1, 4242, event2}, "action": "delete"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp bulkResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+
+		var remaining int
+		testDB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&remaining)
+		assert.Equal(t, 1, remaining)
+	})
+
+	t.Run("Each affected event is audited against its organizer", func(t *testing.T) {
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND admin_id = ? AND target_user_id = ? AND details LIKE ?`,
+			AuditEventCancelled, adminID, organizerID, fmt.Sprintf(`%%"event_id":%d%%`, event3)))
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND target_user_id = ?`,
+			AuditEventDeleted, organizerID))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND details LIKE '%"title":"Spam Event 2"%'`,
+			AuditEventDeleted), "deleted events are identified by title")
+	})
+}
+
+func TestAdminDeleteEventAudit(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.DELETE("/api/admin/events/:id", authMiddleware(), adminMiddleware(), adminDeleteEvent)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createTestEvent(t, testDB, organizerID, "Takedown")
+
+	w := doJSON(router, "DELETE", fmt.Sprintf("/api/admin/events/%d", eventID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND admin_id = ? AND target_user_id = ?`,
+		AuditEventDeleted, adminID, organizerID))
+
+	w = doJSON(router, "DELETE", fmt.Sprintf("/api/admin/events/%d", eventID), adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log`), "nothing deleted, nothing audited")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditIntegrityRepaired is the admin audit log action of adminRepairIntegrity
+const AuditIntegrityRepaired = "integrity_repaired"
+
+// IntegrityResponse is the answer of GET /api/admin/integrity and POST /api/admin/integrity/repair
+type IntegrityResponse struct {
+	Problems []string      `json:"problems"` // PRAGMA integrity_check messages; only repairable by hand
+	Orphans  []OrphanClass `json:"orphans"`
+	Total    int           `json:"total"` // orphaned rows over all classes
+	DryRun   bool          `json:"dry_run,omitempty"`
+}
+
+// RepairIntegrityRequest is the optional body of POST /api/admin/integrity/repair
+type RepairIntegrityRequest struct {
+	DryRun bool `json:"dry_run"` // report what would be repaired without changing anything
+}
+
+// loadIntegrityReport runs both integrity checks and plans the orphan repairs
+func loadIntegrityReport() (IntegrityResponse, []orphanRepair, error) {
+	resp := IntegrityResponse{Problems: []string{}, Orphans: []OrphanClass{}}
+	problems, err := integrityProblems(db)
+	if err != nil {
+		return resp, nil, err
+	}
+	if problems != nil {
+		resp.Problems = problems
+	}
+	violations, err := foreignKeyViolations(db)
+	if err != nil {
+		return resp, nil, err
+	}
+	plan := planOrphanRepairs(db, violations)
+	resp.Orphans = summarizeOrphans(plan)
+	resp.Total = len(violations)
+	return resp, plan, nil
+}
+
+// adminGetIntegrity reports rows whose foreign key points at a missing row, grouped by relationship
+// with counts, sample rowids and what a repair would do (GET /api/admin/integrity)
+func adminGetIntegrity(c *gin.Context) {
+	log.Printf("🩺 GET /api/admin/integrity - Admin %d checking database integrity", c.GetInt("user_id"))
+	resp, _, err := loadIntegrityReport()
+	if err != nil {
+		log.Printf("❌ Integrity check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check database integrity"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// adminRepairIntegrity repairs orphans by the rules of planOrphanRepairs: rows are deleted, ON DELETE
+// SET NULL keys cleared, and events of missing organizers hidden pending review rather than deleted
+// (POST /api/admin/integrity/repair). With dry_run nothing changes. Both are audit-logged.
+func adminRepairIntegrity(c *gin.Context) {
+	adminID := c.GetInt("user_id")
+	var req RepairIntegrityRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	log.Printf("🩺 POST /api/admin/integrity/repair - Admin %d repairing orphans (dry run: %v)", adminID, req.DryRun)
+
+	resp, plan, err := loadIntegrityReport()
+	if err != nil {
+		log.Printf("❌ Integrity check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check database integrity"})
+		return
+	}
+	resp.DryRun = req.DryRun
+	if !req.DryRun {
+		repairOrphans(db, plan, resp.Orphans)
+	}
+
+	counts := map[string]gin.H{}
+	repaired := 0
+	for _, class := range resp.Orphans {
+		counts[class.Table+" -> "+class.Parent] = gin.H{"action": class.Action, "count": class.Count, "repaired": class.Repaired}
+		repaired += class.Repaired
+	}
+	if err := recordAdminAudit(db, adminID, AuditIntegrityRepaired, 0, gin.H{
+		"dry_run": req.DryRun,
+		"total":   resp.Total,
+		"orphans": counts,
+	}); err != nil {
+		log.Printf("❌ Error writing audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record integrity repair"})
+		return
+	}
+
+	log.Printf("🧹 Admin %d repaired %d of %d orphaned rows (dry run: %v)", adminID, repaired, resp.Total, req.DryRun)
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminIntegrity(t *testing.T) {
+	setupJWT()
+	conn, path := openProductionSchema(t)
+
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/integrity", adminGetIntegrity)
+	admin.POST("/integrity/repair", adminRepairIntegrity)
+
+	insert := func(exec *sql.DB, query string, args ...interface{}) int64 {
+		t.Helper()
+		result, err := exec.Exec(query, args...)
+		require.NoError(t, err)
+		id, _ := result.LastInsertId()
+		return id
+	}
+	adminID := insert(conn, `INSERT INTO users (email, password, name, is_admin, email_verified) VALUES ('root@example.com', 'x', 'Root', 1, 1)`)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "root@example.com", IsAdmin: true, EmailVerified: true})
+	userID := insert(conn, `INSERT INTO users (email, password, name) VALUES ('user@example.com', 'x', 'User')`)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+	const eventColumns = `(user_id, title, description, category, latitude, longitude, start_time, creator_name)`
+	healthyEvent := insert(conn, `INSERT INTO events `+eventColumns+` VALUES (?, 'Board games', 'Bring snacks', 'gaming_hobbies', 52.23, 21.01, '2030-01-01T18:00:00Z', 'User')`, userID)
+	insert(conn, `INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, healthyEvent, userID)
+	insert(conn, `INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'See you')`, healthyEvent, userID)
+
+	// One orphan of each class, written the way the server used to, without foreign key enforcement
+	legacy, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer legacy.Close()
+	participant := insert(legacy, `INSERT INTO event_participants (event_id, user_id) VALUES (9999, ?)`, userID)
+	comment := insert(legacy, `INSERT INTO event_comments (event_id, user_id, comment) VALUES (9999, ?, 'Orphan')`, userID)
+	token := insert(legacy, `INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (8888, 'orphan-token', '2030-01-01')`)
+	webhook := insert(legacy, `INSERT INTO webhooks (url, secret, event_types, created_by) VALUES ('https://example.com/hook', 's', '[]', 8888)`)
+	ownerless := insert(legacy, `INSERT INTO events `+eventColumns+` VALUES (8888, 'Run club', 'Weekly run', 'sports_fitness', 52.23, 21.01, '2030-01-01T08:00:00Z', 'Gone')`)
+	insert(legacy, `INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, ownerless, userID)
+
+	integrity := func(method, path string, body interface{}) IntegrityResponse {
+		t.Helper()
+		w := doJSON(router, method, path, adminToken, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp IntegrityResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	count := func(query string, args ...interface{}) int {
+		return countRows(t, conn, query, args...)
+	}
+	expected := []OrphanClass{
+		{Table: "event_comments", Parent: "events", Action: OrphanDelete, Count: 1, SampleIDs: []int64{comment}},
+		{Table: "event_participants", Parent: "events", Action: OrphanDelete, Count: 1, SampleIDs: []int64{participant}},
+		{Table: "events", Parent: "users", Action: OrphanFlagForReview, Count: 1, SampleIDs: []int64{ownerless}},
+		{Table: "password_reset_tokens", Parent: "users", Action: OrphanDelete, Count: 1, SampleIDs: []int64{token}},
+		{Table: "webhooks", Parent: "users", Action: OrphanSetNull, Count: 1, SampleIDs: []int64{webhook}},
+	}
+
+	t.Run("Admins only", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", "/api/admin/integrity", userToken, nil).Code)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "POST", "/api/admin/integrity/repair", userToken, nil).Code)
+	})
+
+	t.Run("The report finds every class of orphan", func(t *testing.T) {
+		resp := integrity("GET", "/api/admin/integrity", nil)
+		assert.Empty(t, resp.Problems)
+		assert.Equal(t, 5, resp.Total)
+		assert.Equal(t, expected, resp.Orphans)
+	})
+
+	t.Run("A dry run changes nothing", func(t *testing.T) {
+		resp := integrity("POST", "/api/admin/integrity/repair", gin.H{"dry_run": true})
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, expected, resp.Orphans)
+		assert.Equal(t, 3, count(`SELECT COUNT(*) FROM event_participants`))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM password_reset_tokens`))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM events WHERE hidden_pending_review = 1`))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND admin_id = ? AND details LIKE '%"dry_run":true%'`,
+			AuditIntegrityRepaired, adminID))
+	})
+
+	t.Run("Repair clears orphans and leaves healthy rows alone", func(t *testing.T) {
+		resp := integrity("POST", "/api/admin/integrity/repair", nil)
+		assert.False(t, resp.DryRun)
+		for _, class := range resp.Orphans {
+			assert.Equal(t, 1, class.Repaired, "%s -> %s", class.Table, class.Parent)
+		}
+
+		assert.Zero(t, count(`SELECT COUNT(*) FROM event_participants WHERE event_id = 9999`))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM event_comments WHERE event_id = 9999`))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM password_reset_tokens`))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM webhooks WHERE id = ? AND created_by IS NULL`, webhook))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, healthyEvent))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_comments WHERE event_id = ?`, healthyEvent))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM events WHERE id = ? AND hidden_pending_review = 0`, healthyEvent))
+
+		// The ownerless event is hidden for review, not deleted, and keeps its participant
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM events WHERE id = ? AND hidden_pending_review = 1`, ownerless))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, ownerless))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND details LIKE '%"dry_run":false%'`, AuditIntegrityRepaired))
+
+		after := integrity("GET", "/api/admin/integrity", nil)
+		assert.Equal(t, 1, after.Total)
+		require.Len(t, after.Orphans, 1)
+		assert.Equal(t, OrphanFlagForReview, after.Orphans[0].Action)
+
+		// Running it again has nothing left to do
+		again := integrity("POST", "/api/admin/integrity/repair", nil)
+		assert.Zero(t, again.Orphans[0].Repaired)
+	})
+}
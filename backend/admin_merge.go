@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditUsersMerged is the admin audit log action of adminMergeUsers
+const AuditUsersMerged = "users_merged"
+
+// MergeUsersRequest names the account the source account is merged into
+type MergeUsersRequest struct {
+	IntoUserID int `json:"into_user_id" binding:"required"`
+}
+
+// userReference is a column pointing at users.id that a merge moves to the target account. When
+// the column is part of a unique key, uniqueWith lists the key's other columns: source rows that
+// would collide with a row the target already has are dropped instead of moved.
+type userReference struct {
+	table      string
+	column     string
+	uniqueWith []string
+}
+
+// mergedUserReferences are moved in order. Participation conflicts are resolved before the
+// event_participants entry runs, see mergeParticipations.
+var mergedUserReferences = []userReference{
+	{"events", "user_id", nil},
+	{"event_participants", "user_id", []string{"event_id"}},
+	{"event_comments", "user_id", nil},
+	{"comment_read_state", "user_id", []string{"event_id"}},
+	{"event_feedback", "user_id", []string{"event_id"}},
+	{"event_reports", "reporter_id", nil},
+	{"user_blocks", "blocker_id", []string{"blocked_id"}},
+	{"user_blocks", "blocked_id", []string{"blocker_id"}},
+	{"activity_log", "user_id", nil},
+	{"activity_log", "actor_id", nil},
+	{"moderation_queue", "user_id", nil},
+	{"organizer_digests", "user_id", []string{"month"}},
+	{"webhooks", "created_by", nil},
+	{"groups", "owner_id", nil},
+	{"group_members", "user_id", []string{"group_id"}},
+	{"event_question_answers", "user_id", []string{"question_id"}},
+	{"event_interest", "user_id", []string{"event_id"}},
+	{"event_hides", "user_id", []string{"event_id"}},
+	{"participation_exits", "user_id", nil},
+	{"event_transfers", "from_user_id", nil},
+	{"event_transfers", "to_user_id", nil},
+	{"notifications", "user_id", nil},
+}
+
+// mergeDiscardedTables hold credentials issued to the source account's email address; moving them
+// would let a link sent to the old (often mistyped) address act on the target account
+var mergeDiscardedTables = []string{"email_verification_tokens", "password_reset_tokens", "user_recovery_codes", "idempotency_keys"}
+
+// adminMergeUsers folds a duplicate account into another one (POST /api/admin/users/:id/merge).
+// Everything the source account owns moves to the target in one transaction; the source is then
+// blocked and its email scrubbed so the address can't sign in or collide again.
+func adminMergeUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+	adminID := c.GetInt("user_id")
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into_user_id is required"})
+		return
+	}
+	targetID := req.IntoUserID
+
+	log.Printf("🔀 POST /api/admin/users/%d/merge - Admin %d merging into user %d", sourceID, adminID, targetID)
+
+	if sourceID == targetID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge an account into itself"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+		return
+	}
+	defer tx.Rollback()
+
+	var source, target User
+	for _, account := range []struct {
+		id   int
+		user *User
+	}{{sourceID, &source}, {targetID, &target}} {
+		u := account.user
+		err := tx.QueryRowContext(ctx, `SELECT id, email, name, is_admin FROM users WHERE id = ?`, account.id).
+			Scan(&u.ID, &u.Email, &u.Name, nullable(&u.IsAdmin))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User %d not found", account.id)})
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Error loading user %d: %v", account.id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+			return
+		}
+	}
+	if source.IsAdmin || target.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin accounts cannot be merged"})
+		return
+	}
+
+	moved, err := mergeUserRows(ctx, tx, sourceID, targetID)
+	if err != nil {
+		log.Printf("❌ Error merging user %d into %d: %v", sourceID, targetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_blocked = 1, email = ?, updated_at = ? WHERE id = ?`,
+		fmt.Sprintf("merged-%d-into-%d@veidly.invalid", sourceID, targetID), time.Now().UTC(), sourceID); err != nil {
+		log.Printf("❌ Error disabling merged user %d: %v", sourceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+		return
+	}
+	if err := recordAdminAudit(tx, adminID, AuditUsersMerged, sourceID, gin.H{
+		"email":        source.Email,
+		"into_user_id": targetID,
+		"moved":        moved,
+	}); err != nil {
+		log.Printf("❌ Error writing audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing merge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge users"})
+		return
+	}
+	eventListCache.Invalidate()
+
+	log.Printf("✅ Admin %d merged user %d (%s) into user %d", adminID, sourceID, source.Email, targetID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Users merged",
+		"user_id":      sourceID,
+		"into_user_id": targetID,
+		"moved":        moved,
+	})
+}
+
+// mergeUserRows moves the source account's rows to the target and returns how many rows of each
+// table now belong to the target
+func mergeUserRows(ctx context.Context, tx *sql.Tx, sourceID, targetID int) (map[string]int64, error) {
+	if err := mergeParticipations(ctx, tx, sourceID, targetID); err != nil {
+		return nil, err
+	}
+
+	moved := map[string]int64{}
+	for _, ref := range mergedUserReferences {
+		if len(ref.uniqueWith) > 0 {
+			conflict := make([]string, len(ref.uniqueWith))
+			for i, column := range ref.uniqueWith {
+				conflict[i] = fmt.Sprintf("existing.%s = %s.%s", column, ref.table, column)
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+				DELETE FROM %s WHERE %s = ? AND EXISTS (
+					SELECT 1 FROM %s existing WHERE existing.%s = ? AND %s
+				)`, ref.table, ref.column, ref.table, ref.column, strings.Join(conflict, " AND ")),
+				sourceID, targetID); err != nil {
+				return nil, fmt.Errorf("%s.%s conflicts: %w", ref.table, ref.column, err)
+			}
+		}
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ?`, ref.table, ref.column, ref.column),
+			targetID, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", ref.table, ref.column, err)
+		}
+		n, _ := result.RowsAffected()
+		moved[ref.table] += n
+	}
+
+	// Blocks between the two accounts turned into the target blocking itself
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?`, targetID, targetID); err != nil {
+		return nil, fmt.Errorf("self blocks: %w", err)
+	}
+	// Interest in events the target already joined is moot, and dropped duplicates leave counts
+	// too high; merges are rare enough to simply recount
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM event_interest
+		WHERE user_id = ? AND event_id IN (SELECT event_id FROM event_participants WHERE user_id = ?)
+	`, targetID, targetID); err != nil {
+		return nil, fmt.Errorf("interest of participants: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE events SET interested_count = (SELECT COUNT(*) FROM event_interest WHERE event_id = events.id)
+		WHERE interested_count != (SELECT COUNT(*) FROM event_interest WHERE event_id = events.id)
+	`); err != nil {
+		return nil, fmt.Errorf("interested counts: %w", err)
+	}
+	for _, table := range mergeDiscardedTables {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE user_id = ?`, table), sourceID); err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return moved, nil
+}
+
+// mergeParticipations resolves events both accounts joined: the target keeps its row with the
+// earlier joined_at and a check-in from either account, so the source's copy can be dropped and
+// the event counts one participant less
+func mergeParticipations(ctx context.Context, tx *sql.Tx, sourceID, targetID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE events SET participant_count = MAX(participant_count - 1, 0), updated_at = ?
+		WHERE id IN (SELECT event_id FROM event_participants WHERE user_id = ?)
+		  AND id IN (SELECT event_id FROM event_participants WHERE user_id = ?)
+	`, time.Now().UTC(), sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("participant counts: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE event_participants
+		SET joined_at = MIN(joined_at, (
+		        SELECT s.joined_at FROM event_participants s
+		        WHERE s.user_id = ? AND s.event_id = event_participants.event_id)),
+		    checked_in_at = COALESCE(checked_in_at, (
+		        SELECT s.checked_in_at FROM event_participants s
+		        WHERE s.user_id = ? AND s.event_id = event_participants.event_id))
+		WHERE user_id = ? AND event_id IN (SELECT event_id FROM event_participants WHERE user_id = ?)
+	`, sourceID, sourceID, targetID, sourceID)
+	if err != nil {
+		return fmt.Errorf("participation conflicts: %w", err)
+	}
+	return nil
+}
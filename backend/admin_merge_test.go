@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminMergeUsers(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.POST("/users/:id/merge", adminMergeUsers)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	typoID := createTestUser(t, testDB, "jane@exmaple.com", "Jane", "password123", false)
+	realID := createTestUser(t, testDB, "jane@example.com", "Jane", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	userToken, _ := generateToken(User{ID: int(realID), Email: "jane@example.com", EmailVerified: true})
+
+	// The typo account organized one event, joined two (one of them also joined by the real
+	// account, earlier), commented, and blocked someone the real account blocks too
+	organized := createTestEvent(t, testDB, typoID, "Jane's picnic")
+	shared := createTestEvent(t, testDB, otherID, "Board games")
+	onlyTypo := createTestEvent(t, testDB, otherID, "Run club")
+	addParticipant(t, testDB, shared, typoID)
+	addParticipant(t, testDB, shared, realID)
+	addParticipant(t, testDB, onlyTypo, typoID)
+	_, err := testDB.Exec(`UPDATE event_participants SET joined_at = '2020-01-01 10:00:00' WHERE user_id = ? AND event_id = ?`, typoID, shared)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`UPDATE event_participants SET joined_at = '2020-02-01 10:00:00' WHERE user_id = ? AND event_id = ?`, realID, shared)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'Count me in')`, shared, typoID)
+	require.NoError(t, err)
+	for _, block := range [][2]int64{{typoID, otherID}, {realID, otherID}, {typoID, realID}} {
+		_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, block[0], block[1])
+		require.NoError(t, err)
+	}
+	_, err = testDB.Exec(`INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (?, 'typo-reset', '2030-01-01')`, typoID)
+	require.NoError(t, err)
+
+	merge := func(token string, sourceID, intoID int64) int {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/users/%d/merge", sourceID), token, gin.H{"into_user_id": intoID})
+		return w.Code
+	}
+	count := func(query string, args ...interface{}) int {
+		return countRows(t, testDB, query, args...)
+	}
+
+	t.Run("Refused merges", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, merge(userToken, typoID, realID), "admins only")
+		assert.Equal(t, http.StatusBadRequest, merge(adminToken, typoID, typoID))
+		assert.Equal(t, http.StatusForbidden, merge(adminToken, adminID, realID))
+		assert.Equal(t, http.StatusForbidden, merge(adminToken, typoID, adminID))
+		assert.Equal(t, http.StatusNotFound, merge(adminToken, typoID, 9999))
+		assert.Equal(t, 2, count(`SELECT COUNT(*) FROM event_participants WHERE user_id = ?`, typoID), "nothing moved")
+	})
+
+	t.Run("Merge moves history and resolves conflicts", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, merge(adminToken, typoID, realID))
+
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM events WHERE id = ? AND user_id = ?`, organized, realID))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM event_participants WHERE user_id = ?`, typoID))
+		assert.Equal(t, 2, count(`SELECT COUNT(*) FROM event_participants WHERE user_id = ?`, realID))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, shared))
+		assert.Equal(t, 1, count(`SELECT participant_count FROM events WHERE id = ?`, shared))
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_participants WHERE user_id = ? AND event_id = ? AND joined_at LIKE '2020-01-01%'`, realID, shared),
+			"the earliest join is kept")
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM event_comments WHERE user_id = ?`, realID))
+
+		assert.Equal(t, 1, count(`SELECT COUNT(*) FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?`, realID, otherID))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM user_blocks WHERE blocker_id = blocked_id`))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM user_blocks WHERE ? IN (blocker_id, blocked_id)`, typoID))
+		assert.Zero(t, count(`SELECT COUNT(*) FROM password_reset_tokens WHERE user_id IN (?, ?)`, typoID, realID),
+			"tokens sent to the old address don't carry over")
+
+		var email string
+		var blocked bool
+		require.NoError(t, testDB.QueryRow(`SELECT email, is_blocked FROM users WHERE id = ?`, typoID).Scan(&email, &blocked))
+		assert.True(t, blocked)
+		assert.NotEqual(t, "jane@exmaple.com", email)
+
+		var action string
+		var target int64
+		require.NoError(t, testDB.QueryRow(`SELECT action, target_user_id FROM admin_audit_log WHERE admin_id = ?`, adminID).Scan(&action, &target))
+		assert.Equal(t, AuditUsersMerged, action)
+		assert.Equal(t, typoID, target)
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin audit log actions
+const (
+	AuditAdminPromoted  = "admin_promoted"
+	AuditAdminDemoted   = "admin_demoted"
+	AuditEmailVerified  = "email_verified"
+	AuditEventDeleted   = "event_deleted"
+	AuditEventCancelled = "event_cancelled"
+)
+
+// ErrCodeLastAdmin is returned when a demotion would leave no active admin
+const ErrCodeLastAdmin = "LAST_ADMIN"
+
+// SetUserRoleRequest grants or revokes admin rights; the acting admin re-enters their password
+type SetUserRoleRequest struct {
+	IsAdmin  *bool  `json:"is_admin" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// recordAdminAudit appends a privileged admin action to admin_audit_log
+func recordAdminAudit(exec sqlExecer, adminID int, action string, targetUserID int, details interface{}) error {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = exec.Exec(`
+		INSERT INTO admin_audit_log (admin_id, action, target_user_id, details) VALUES (?, ?, ?, ?)
+	`, adminID, action, targetUserID, string(encoded))
+	return err
+}
+
+// adminSetUserRole promotes or demotes an admin (PUT /api/admin/users/:id/role).
+// The last active admin can't be demoted, which also covers self-demotion when no one else is left.
+// authMiddleware reads is_admin from the users row rather than the JWT, so promotions and
+// demotions both take effect on the user's next request.
+func adminSetUserRole(c *gin.Context) {
+	ctx := c.Request.Context()
+	adminID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	log.Printf("🛡️  PUT /api/admin/users/%d/role - Admin %d changing role", targetID, adminID)
+
+	var req SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "is_admin and password are required"})
+		return
+	}
+
+	var hashedPassword string
+	if err := db.QueryRowContext(ctx, `SELECT password FROM users WHERE id = ?`, adminID).Scan(&hashedPassword); err != nil {
+		log.Printf("❌ Error loading admin %d: %v", adminID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	if !checkPasswordHash(req.Password, hashedPassword) {
+		log.Printf("🚫 Admin %d failed the password check for a role change", adminID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	defer tx.Rollback()
+
+	var target User
+	err = tx.QueryRowContext(ctx, `SELECT id, email, name, is_admin FROM users WHERE id = ?`, targetID).
+		Scan(&target.ID, &target.Email, &target.Name, nullable(&target.IsAdmin))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading user %d: %v", targetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if target.IsAdmin == *req.IsAdmin {
+		c.JSON(http.StatusOK, gin.H{"message": "Role unchanged", "user": target})
+		return
+	}
+
+	action := AuditAdminPromoted
+	if !*req.IsAdmin {
+		action = AuditAdminDemoted
+		var otherAdmins int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM users WHERE is_admin = 1 AND is_blocked = 0 AND id != ?
+		`, targetID).Scan(&otherAdmins); err != nil {
+			log.Printf("❌ Error counting admins: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			return
+		}
+		if otherAdmins == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last remaining admin", "code": ErrCodeLastAdmin})
+			return
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_admin = ?, updated_at = ? WHERE id = ?`,
+		*req.IsAdmin, time.Now().UTC(), targetID); err != nil {
+		log.Printf("❌ Error updating role of user %d: %v", targetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	if err := recordAdminAudit(tx, adminID, action, targetID, gin.H{"email": target.Email}); err != nil {
+		log.Printf("❌ Error writing audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	if *req.IsAdmin {
+		notify(tx, targetID, NotificationAdminGranted, gin.H{})
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing role change: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	target.IsAdmin = *req.IsAdmin
+
+	if target.IsAdmin {
+		pendingModerationEmails.Add(1)
+		go func() {
+			defer pendingModerationEmails.Done()
+			message := "You have been granted admin rights on Veidly. Log in again to access the admin panel."
+			if err := sendModerationEmail(target.Email, target.Name, "You are now a Veidly admin", message, frontendBaseURL()+"/admin"); err != nil {
+				log.Printf("⚠️  Failed to notify %s about their promotion: %v", target.Email, err)
+			}
+		}()
+	}
+
+	log.Printf("✅ Admin %d %s user %d", adminID, action, targetID)
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "user": target})
+}
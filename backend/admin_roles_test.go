@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminSetUserRole(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	sentEmails := captureModerationEmails(t)
+
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/users", adminGetUsers)
+	admin.PUT("/users/:id/role", adminSetUserRole)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+
+	setRole := func(token string, targetID int64, isAdmin bool, password string) int {
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/admin/users/%d/role", targetID), token,
+			gin.H{"is_admin": isAdmin, "password": password})
+		return w.Code
+	}
+	isAdmin := func(id int64) bool {
+		var v bool
+		require.NoError(t, testDB.QueryRow(`SELECT is_admin FROM users WHERE id = ?`, id).Scan(&v))
+		return v
+	}
+	auditActions := func() []string {
+		rows, err := testDB.Query(`SELECT action FROM admin_audit_log WHERE admin_id = ? ORDER BY id`, adminID)
+		require.NoError(t, err)
+		defer rows.Close()
+		actions := []string{}
+		for rows.Next() {
+			var action string
+			require.NoError(t, rows.Scan(&action))
+			actions = append(actions, action)
+		}
+		return actions
+	}
+
+	t.Run("Only admins can change roles", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, setRole(userToken, userID, true, "password123"))
+		assert.False(t, isAdmin(userID))
+	})
+
+	t.Run("The last admin can't demote themselves", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, setRole(adminToken, adminID, false, "password123"))
+		assert.True(t, isAdmin(adminID))
+	})
+
+	t.Run("Requires the acting admin's password", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, setRole(adminToken, userID, true, "wrong-password"))
+		assert.Equal(t, http.StatusBadRequest, setRole(adminToken, userID, true, ""))
+		assert.False(t, isAdmin(userID))
+		assert.Empty(t, auditActions())
+	})
+
+	t.Run("Promotion is audited and emailed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, setRole(adminToken, userID, true, "password123"))
+		assert.True(t, isAdmin(userID))
+		assert.Equal(t, []string{AuditAdminPromoted}, auditActions())
+
+		emails := sentEmails()
+		require.Len(t, emails, 1)
+		assert.Equal(t, "user@example.com", emails[0].to)
+
+		// The role is read from the account, so the token issued before the promotion works
+		w := doJSON(router, "GET", "/api/admin/users", userToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Self-demotion is allowed once another admin exists", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, setRole(adminToken, adminID, false, "password123"))
+		assert.False(t, isAdmin(adminID))
+		assert.Equal(t, []string{AuditAdminPromoted, AuditAdminDemoted}, auditActions())
+
+		// Demotion applies to tokens that were issued before it
+		w := doJSON(router, "GET", "/api/admin/users", adminToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Blocked admins don't count towards the guard", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET is_admin = 1, is_blocked = 1 WHERE id = ?`, adminID)
+		require.NoError(t, err)
+		newToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", IsAdmin: true})
+		assert.Equal(t, http.StatusConflict, setRole(newToken, userID, false, "password123"))
+	})
+}
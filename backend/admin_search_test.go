@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type adminUsersPage struct {
+	Users   []User `json:"users"`
+	Total   int    `json:"total"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+}
+
+type adminEventsPage struct {
+	Events []Event `json:"events"`
+	Total  int     `json:"total"`
+}
+
+func setupAdminListRouter(adminID int64) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", int(adminID))
+		c.Set("is_admin", true)
+		c.Next()
+	})
+	router.GET("/api/admin/users", adminGetUsers)
+	router.GET("/api/admin/events", adminGetAllEvents)
+	return router
+}
+
+func TestAdminGetUsersSearchAndPagination(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+
+	// 30 regular users with strictly increasing created_at; every third one unverified
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 30; i++ {
+		id := createTestUser(t, testDB, fmt.Sprintf("user%02d@example.com", i), fmt.Sprintf("User %02d", i), "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET created_at = ?, email_verified = ? WHERE id = ?`,
+			base.Add(time.Duration(i)*time.Hour).Format("2006-01-02 15:04:05"), i%3 != 0, id)
+		require.NoError(t, err)
+	}
+
+	router := setupAdminListRouter(adminID)
+	fetch := func(query string) adminUsersPage {
+		w := doJSON(router, "GET", "/api/admin/users"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page adminUsersPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	t.Run("Page 2 ordered by email", func(t *testing.T) {
+		page := fetch("?is_admin=false&sort=email&order=asc&page=2&per_page=10")
+		assert.Equal(t, 30, page.Total)
+		assert.Equal(t, 2, page.Page)
+		assert.Equal(t, 10, page.PerPage)
+		require.Len(t, page.Users, 10)
+		assert.Equal(t, "user11@example.com", page.Users[0].Email)
+		assert.Equal(t, "user20@example.com", page.Users[9].Email)
+	})
+
+	t.Run("Default order is newest first", func(t *testing.T) {
+		page := fetch("?is_admin=false&per_page=5")
+		require.Len(t, page.Users, 5)
+		assert.Equal(t, "user30@example.com", page.Users[0].Email)
+	})
+
+	t.Run("Verified filter excludes unverified users", func(t *testing.T) {
+		page := fetch("?verified=false&per_page=100")
+		assert.Equal(t, 10, page.Total)
+		for _, u := range page.Users {
+			assert.False(t, u.EmailVerified, u.Email)
+		}
+
+		page = fetch("?verified=true&is_admin=false&per_page=100")
+		assert.Equal(t, 20, page.Total)
+		for _, u := range page.Users {
+			assert.True(t, u.EmailVerified, u.Email)
+		}
+	})
+
+	t.Run("Search matches email or name case-insensitively", func(t *testing.T) {
+		page := fetch("?q=USER%200")
+		assert.Equal(t, 9, page.Total)
+
+		page = fetch("?q=admin@EXAMPLE")
+		require.Len(t, page.Users, 1)
+		assert.True(t, page.Users[0].IsAdmin)
+	})
+
+	t.Run("Created date range", func(t *testing.T) {
+		after := base.Add(25 * time.Hour).Format(time.RFC3339)
+		page := fetch("?created_after=" + after + "&is_admin=false")
+		assert.Equal(t, 6, page.Total)
+	})
+
+	t.Run("Blocked filter", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET is_blocked = 1 WHERE email IN ('user01@example.com', 'user02@example.com')`)
+		require.NoError(t, err)
+		page := fetch("?blocked=true")
+		assert.Equal(t, 2, page.Total)
+	})
+}
+
+func TestAdminGetAllEventsFilters(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+
+	aliceEvent := createTestEvent(t, testDB, aliceID, "Alice Drinks")
+	createTestEvent(t, testDB, aliceID, "Alice Drinks 2")
+	bobEvent := createTestEvent(t, testDB, bobID, "Bob Hike")
+	_, err := testDB.Exec(`UPDATE events SET category = 'hiking' WHERE id = ?`, bobEvent)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO event_reports (event_id, reporter_id, reason) VALUES (?, ?, 'spam')`, aliceEvent, bobID)
+	require.NoError(t, err)
+
+	router := setupAdminListRouter(adminID)
+	fetch := func(query string) adminEventsPage {
+		w := doJSON(router, "GET", "/api/admin/events"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page adminEventsPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	assert.Equal(t, 2, fetch("?creator_email=ALICE@").Total)
+	assert.Equal(t, 1, fetch("?category=hiking").Total)
+
+	reported := fetch("?has_reports=true")
+	require.Len(t, reported.Events, 1)
+	assert.Equal(t, int(aliceEvent), reported.Events[0].ID)
+	assert.Equal(t, 2, fetch("?has_reports=false").Total)
+
+	paged := fetch("?per_page=2&page=2")
+	assert.Equal(t, 3, paged.Total)
+	assert.Len(t, paged.Events, 1)
+
+	future := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	assert.Equal(t, 0, fetch("?from="+future).Total)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Users may record a birth year so event age limits can be enforced. Ages are computed
+// from the year alone: someone born in 2000 counts as 26 for the whole of 2026.
+const (
+	MinUserAge = 13
+	MaxUserAge = 120
+
+	// openAgeMax is the event form's default age_max and means "no upper limit"
+	openAgeMax = 99
+)
+
+// Machine-readable codes for join rejections
+const (
+	ErrCodeAgeRestricted     = "AGE_RESTRICTED"
+	ErrCodeBirthYearRequired = "BIRTH_YEAR_REQUIRED"
+)
+
+func ageInYear(birthYear, year int) int {
+	return year - birthYear
+}
+
+// eventRestrictsAge reports whether an event's age limits exclude anyone
+func eventRestrictsAge(ageMin, ageMax int) bool {
+	return ageMin > 0 || ageMax < openAgeMax
+}
+
+// ageAllowed reports whether age is within the event's limits (both ends inclusive)
+func ageAllowed(age, ageMin, ageMax int) bool {
+	if age < ageMin {
+		return false
+	}
+	return ageMax >= openAgeMax || age <= ageMax
+}
+
+// checkJoinAge returns a rejection code and message when a user may not join an event
+// because of its age limits, or empty strings when they may
+func checkJoinAge(birthYear sql.NullInt64, ageMin, ageMax int, requireBirthYear bool) (string, string) {
+	if !eventRestrictsAge(ageMin, ageMax) {
+		return "", ""
+	}
+	if !birthYear.Valid {
+		if requireBirthYear {
+			return ErrCodeBirthYearRequired, "This event has age limits. Please add your birth year to your profile before joining."
+		}
+		return "", ""
+	}
+	if !ageAllowed(ageInYear(int(birthYear.Int64), time.Now().UTC().Year()), ageMin, ageMax) {
+		if ageMax >= openAgeMax {
+			return ErrCodeAgeRestricted, fmt.Sprintf("This event is for ages %d and up", ageMin)
+		}
+		return ErrCodeAgeRestricted, fmt.Sprintf("This event is for ages %d-%d", ageMin, ageMax)
+	}
+	return "", ""
+}
+
+// ageEligibleCondition hides events the viewer (bound as its single argument) is too young or
+// too old for. Viewers without a birth year see everything.
+var ageEligibleCondition = fmt.Sprintf(`
+		AND NOT EXISTS (
+			SELECT 1 FROM users viewer
+			WHERE viewer.id = ? AND viewer.birth_year IS NOT NULL
+			AND (CAST(strftime('%%Y', 'now') AS INTEGER) - viewer.birth_year < e.age_min
+				OR (e.age_max < %d AND CAST(strftime('%%Y', 'now') AS INTEGER) - viewer.birth_year > e.age_max))
+		)`, openAgeMax)
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeAllowed(t *testing.T) {
+	assert.True(t, ageAllowed(18, 18, 30), "lower bound is inclusive")
+	assert.True(t, ageAllowed(30, 18, 30), "upper bound is inclusive")
+	assert.False(t, ageAllowed(17, 18, 30))
+	assert.False(t, ageAllowed(31, 18, 30))
+	assert.True(t, ageAllowed(104, 18, 99), "99 means no upper limit")
+
+	assert.False(t, eventRestrictsAge(0, 99))
+	assert.True(t, eventRestrictsAge(18, 99))
+	assert.True(t, eventRestrictsAge(0, 40))
+}
+
+func TestProfileBirthYear(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/profile", getOwnProfile)
+	protected.PUT("/profile", updateProfile)
+	router.GET("/api/profile/:id", optionalAuthMiddleware(), getUserProfile)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+	year := time.Now().UTC().Year()
+
+	for _, birthYear := range []int{year - MinUserAge + 1, year - MaxUserAge - 1, year + 1} {
+		w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "birth_year": birthYear})
+		assert.Equal(t, http.StatusBadRequest, w.Code, birthYear)
+	}
+
+	w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "birth_year": year - MinUserAge})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var user User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	require.NotNil(t, user.BirthYear)
+	assert.Equal(t, year-MinUserAge, *user.BirthYear)
+
+	// Omitting birth_year keeps it
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "bio": "Hello"})
+	require.Equal(t, http.StatusOK, w.Code)
+	user = User{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	require.NotNil(t, user.BirthYear)
+
+	// Other people never see it
+	w = doJSON(router, "GET", fmt.Sprintf("/api/profile/%d", userID), "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "birth_year")
+
+	// 0 clears it
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "birth_year": 0})
+	require.Equal(t, http.StatusOK, w.Code)
+	user = User{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Nil(t, user.BirthYear)
+}
+
+func TestJoinEventAgeLimits(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	year := time.Now().UTC().Year()
+
+	newUser := func(email string, age int) string {
+		id := createTestUser(t, testDB, email, "User", "password123", false)
+		if age > 0 {
+			_, err := testDB.Exec(`UPDATE users SET birth_year = ? WHERE id = ?`, year-age, id)
+			require.NoError(t, err)
+		}
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return token
+	}
+	newEvent := func(ageMin, ageMax int, requireBirthYear bool) int64 {
+		id := createTestEvent(t, testDB, organizerID, "Age Limited")
+		_, err := testDB.Exec(`UPDATE events SET age_min = ?, age_max = ?, require_birth_year = ? WHERE id = ?`, ageMin, ageMax, requireBirthYear, id)
+		require.NoError(t, err)
+		return id
+	}
+	join := func(eventID int64, token string) (int, string) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), token, nil)
+		var body struct {
+			Code string `json:"code"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Code
+	}
+
+	eventID := newEvent(18, 30, false)
+	for _, tc := range []struct {
+		age  int
+		want int
+	}{{17, http.StatusForbidden}, {18, http.StatusOK}, {30, http.StatusOK}, {31, http.StatusForbidden}} {
+		status, code := join(eventID, newUser(fmt.Sprintf("age%d@example.com", tc.age), tc.age))
+		assert.Equal(t, tc.want, status, "age %d", tc.age)
+		if tc.want == http.StatusForbidden {
+			assert.Equal(t, ErrCodeAgeRestricted, code)
+		}
+	}
+
+	t.Run("Open-ended maximum", func(t *testing.T) {
+		status, _ := join(newEvent(18, 99, false), newUser("senior@example.com", 104))
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Missing birth year", func(t *testing.T) {
+		token := newUser("unknown@example.com", 0)
+
+		status, _ := join(newEvent(18, 30, false), token)
+		assert.Equal(t, http.StatusOK, status, "allowed unless the organizer requires a birth year")
+
+		status, code := join(newEvent(18, 30, true), token)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, ErrCodeBirthYearRequired, code)
+
+		status, _ = join(newEvent(0, 99, true), token)
+		assert.Equal(t, http.StatusOK, status, "events without age limits don't need a birth year")
+	})
+}
+
+func TestEventListHideIneligible(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	for title, ages := range map[string][2]int{"Everyone": {0, 99}, "Adults": {18, 99}, "Teens": {13, 17}, "Seniors": {60, 99}} {
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET age_min = ?, age_max = ? WHERE id = ?`, ages[0], ages[1], id)
+		require.NoError(t, err)
+	}
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+	titles := func(path string) []string {
+		w := doJSON(router, "GET", path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		var titles []string
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	assert.Len(t, titles("/api/events?hide_ineligible=true"), 4, "no birth year, nothing hidden")
+
+	_, err := testDB.Exec(`UPDATE users SET birth_year = ? WHERE id = ?`, sql.NullInt64{Int64: int64(time.Now().UTC().Year() - 25), Valid: true}, userID)
+	require.NoError(t, err)
+	assert.Len(t, titles("/api/events"), 4)
+	assert.ElementsMatch(t, []string{"Everyone", "Adults"}, titles("/api/events?hide_ineligible=true"))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAgendaDays = 7
+	maxAgendaDays     = 31 // Larger requests are capped
+)
+
+// AgendaDay is one day of GET /api/events/agenda; days without events have an empty list
+type AgendaDay struct {
+	Date   string  `json:"date"` // YYYY-MM-DD in the agenda's timezone
+	Events []Event `json:"events"`
+}
+
+// AgendaResponse is the answer of GET /api/events/agenda, days in order starting today
+type AgendaResponse struct {
+	Timezone string      `json:"timezone"`
+	Days     []AgendaDay `json:"days"`
+}
+
+// agendaTimezone picks the zone days are cut in: the tz parameter, then the viewer's profile
+// timezone, then UTC
+func agendaTimezone(ctx context.Context, tz string, userID int) (*time.Location, error) {
+	if tz != "" {
+		return loadTimezone(tz)
+	}
+	if userID > 0 {
+		var profileZone sql.NullString
+		if err := db.QueryRowContext(ctx, `SELECT timezone FROM users WHERE id = ?`, userID).Scan(&profileZone); err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if loc, err := loadTimezone(profileZone.String); err == nil {
+			return loc, nil
+		}
+	}
+	return time.UTC, nil
+}
+
+// groupAgendaDays buckets events by their local start date into days consecutive days from
+// start (local midnight). Events outside the window are dropped.
+func groupAgendaDays(events []Event, start time.Time, days int) []AgendaDay {
+	agenda := make([]AgendaDay, days)
+	index := make(map[string]int, days)
+	for i := range agenda {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		agenda[i] = AgendaDay{Date: date, Events: []Event{}}
+		index[date] = i
+	}
+	for _, e := range events {
+		startTime, err := time.Parse(time.RFC3339, formatStoredTime(e.StartTime))
+		if err != nil {
+			continue
+		}
+		if i, ok := index[startTime.In(start.Location()).Format("2006-01-02")]; ok {
+			agenda[i].Events = append(agenda[i].Events, e)
+		}
+	}
+	return agenda
+}
+
+// getEventAgenda returns the upcoming events of the next days grouped by day in the viewer's
+// timezone (GET /api/events/agenda?days=7&tz=). Filters, view permissions and blocks are those of
+// GET /api/events; days beyond maxAgendaDays are cut off.
+func getEventAgenda(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	isVerified := c.GetBool("email_verified")
+
+	days := defaultAgendaDays
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be a whole number from 1 to %d", maxAgendaDays)})
+			return
+		}
+		days = min(n, maxAgendaDays)
+	}
+	filter, err := ParseEventFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, err := agendaTimezone(ctx, c.Query("tz"), userID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, days)
+	filter.StartsBefore = &end
+	log.Printf("📅 GET /api/events/agenda - %d days from %s (%s)", days, start.Format("2006-01-02"), loc)
+
+	var events []Event
+	visible := true
+	if filter.CreatorID > 0 {
+		found, allowed, err := organizerEventsAccess(ctx, filter.CreatorID, userID, isAdmin)
+		if err != nil {
+			log.Printf("❌ Error checking access to organizer %d: %v", filter.CreatorID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+			return
+		}
+		visible = found && allowed
+	}
+	if visible {
+		events, err = loadEventList(ctx, filter, userID, isVerified, isAdmin)
+		if err != nil {
+			log.Printf("❌ Error querying agenda: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+			return
+		}
+		events = FilterEventsByBlocks(events, userID)
+	}
+
+	respondJSONWithETag(c, userID, AgendaResponse{Timezone: loc.String(), Days: groupAgendaDays(events, start, days)})
+}
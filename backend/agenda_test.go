@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEventAgenda(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/agenda", optionalAuthMiddleware(), getEventAgenda)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Vera", "password123", false)
+	viewerToken, _ := generateToken(User{ID: int(viewerID), Email: "viewer@example.com", EmailVerified: true})
+
+	// Late evening in UTC two days from now: already the next day in Tokyo
+	nowUTC := time.Now().UTC()
+	lateDay := time.Date(nowUTC.Year(), nowUTC.Month(), nowUTC.Day()+2, 0, 0, 0, 0, time.UTC)
+	event := func(title string, start time.Time, changes string) int {
+		t.Helper()
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, start, id)
+		require.NoError(t, err)
+		if changes != "" {
+			_, err = testDB.Exec(`UPDATE events SET `+changes+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+		return int(id)
+	}
+	lateID := event("Night walk", lateDay.Add(23*time.Hour+30*time.Minute), "")
+	event("New York meetup", lateDay.Add(12*time.Hour), "latitude = 40.71, longitude = -74.0")
+	event("Members only", lateDay.Add(12*time.Hour), "allow_unregistered_users = 0")
+	event("Next month", nowUTC.AddDate(0, 0, 40), "")
+
+	agenda := func(query, token string) (int, AgendaResponse, string) {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events/agenda"+query, token, nil)
+		var resp AgendaResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp, w.Body.String()
+	}
+	dayOf := func(resp AgendaResponse, eventID int) string {
+		for _, day := range resp.Days {
+			for _, e := range day.Events {
+				if e.ID == eventID {
+					return day.Date
+				}
+			}
+		}
+		return ""
+	}
+
+	t.Run("Events land on their local day", func(t *testing.T) {
+		code, resp, _ := agenda("?tz=UTC", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "UTC", resp.Timezone)
+		assert.Equal(t, lateDay.Format("2006-01-02"), dayOf(resp, lateID))
+
+		code, resp, _ = agenda("?tz=Asia/Tokyo", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Asia/Tokyo", resp.Timezone)
+		assert.Equal(t, lateDay.AddDate(0, 0, 1).Format("2006-01-02"), dayOf(resp, lateID))
+	})
+
+	t.Run("Every day is listed, empty ones too", func(t *testing.T) {
+		code, resp, body := agenda("?tz=UTC", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, resp.Days, defaultAgendaDays)
+		for i, day := range resp.Days {
+			assert.Equal(t, nowUTC.AddDate(0, 0, i).Format("2006-01-02"), day.Date)
+			if day.Date != lateDay.Format("2006-01-02") {
+				assert.Empty(t, day.Events, day.Date)
+			}
+		}
+		assert.Contains(t, body, `"events":[]`)
+
+		// The New York meetup is there; the members-only event isn't shown to visitors
+		assert.Len(t, resp.Days[2].Events, 2)
+	})
+
+	t.Run("Days are capped", func(t *testing.T) {
+		code, resp, _ := agenda("?days=100&tz=UTC", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, resp.Days, maxAgendaDays)
+		assert.Empty(t, resp.Days[len(resp.Days)-1].Events, "40 days out is past the cap")
+
+		code, resp, _ = agenda("?days=1&tz=UTC", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, resp.Days, 1)
+
+		for _, query := range []string{"?days=0", "?days=-3", "?days=week", "?tz=Mars/Olympus", "?tz=Local", "?lat=47.37&lng=8.54"} {
+			code, _, _ := agenda(query, "")
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+
+	t.Run("Standard filters apply", func(t *testing.T) {
+		code, resp, _ := agenda("?tz=UTC&lat=47.37&lng=8.54&radius_km=100", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, resp.Days[2].Events, 1)
+		assert.Equal(t, lateID, resp.Days[2].Events[0].ID)
+
+		code, resp, _ = agenda("?tz=UTC&category=sports_fitness", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, dayOf(resp, lateID))
+	})
+
+	t.Run("Signed-in viewers default to their profile timezone", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET timezone = 'Asia/Tokyo' WHERE id = ?`, viewerID)
+		require.NoError(t, err)
+		code, resp, _ := agenda("", viewerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Asia/Tokyo", resp.Timezone)
+		assert.Equal(t, lateDay.AddDate(0, 0, 1).Format("2006-01-02"), dayOf(resp, lateID))
+		assert.Equal(t, 3, countAgendaEvents(resp), "signed-in viewers see the members-only event")
+
+		code, resp, _ = agenda("?tz=UTC", viewerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "UTC", resp.Timezone)
+	})
+}
+
+func countAgendaEvents(resp AgendaResponse) int {
+	n := 0
+	for _, day := range resp.Days {
+		n += len(day.Events)
+	}
+	return n
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Announcement levels decide how prominent the banner is
+const (
+	AnnouncementInfo    = "info"
+	AnnouncementWarning = "warning"
+)
+
+// announcementCacheTTL bounds how long GET /api/announcements/active serves a stored copy;
+// admin changes invalidate it immediately
+const announcementCacheTTL = time.Minute
+
+const maxAnnouncementLength = 500
+
+var (
+	ErrAnnouncementMessage      = fmt.Errorf("message must be 1-%d characters", maxAnnouncementLength)
+	ErrInvalidAnnouncementLevel = errors.New("level must be one of: info, warning")
+	ErrAnnouncementWindow       = errors.New("ends_at must be after starts_at")
+	ErrAnnouncementTranslation  = fmt.Errorf("translations must map language codes to messages of 1-%d characters", maxAnnouncementLength)
+)
+
+// Announcement is a site-wide banner shown between starts_at and ends_at (open-ended when ends_at is null)
+type Announcement struct {
+	ID           int               `json:"id"`
+	Message      string            `json:"message"`
+	Translations map[string]string `json:"translations"` // Language code -> message; the default message covers the rest
+	Level        string            `json:"level"`
+	StartsAt     time.Time         `json:"starts_at"`
+	EndsAt       *time.Time        `json:"ends_at"`
+	CreatedBy    int               `json:"created_by"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// activeAt reports whether the banner should be shown at now
+func (a Announcement) activeAt(now time.Time) bool {
+	return !a.StartsAt.After(now) && (a.EndsAt == nil || a.EndsAt.After(now))
+}
+
+// ActiveAnnouncement is one banner as the public endpoint returns it, in a single language
+type ActiveAnnouncement struct {
+	ID       int        `json:"id"`
+	Message  string     `json:"message"`
+	Language string     `json:"language,omitempty"` // Empty when the default message was used
+	Level    string     `json:"level"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// localize picks the message for the first of langs the announcement has a variant for
+func (a Announcement) localize(langs []string) ActiveAnnouncement {
+	out := ActiveAnnouncement{ID: a.ID, Message: a.Message, Level: a.Level, StartsAt: a.StartsAt, EndsAt: a.EndsAt}
+	for _, lang := range langs {
+		if msg, ok := a.Translations[lang]; ok {
+			out.Message, out.Language = msg, lang
+			break
+		}
+	}
+	return out
+}
+
+// AnnouncementRequest creates or replaces an announcement
+type AnnouncementRequest struct {
+	Message      string            `json:"message" binding:"required"`
+	Translations map[string]string `json:"translations"`
+	Level        string            `json:"level"`     // info (default) | warning
+	StartsAt     *time.Time        `json:"starts_at"` // Defaults to now
+	EndsAt       *time.Time        `json:"ends_at"`   // Null keeps it up until deleted
+}
+
+// ValidateAnnouncement checks and normalizes a request; now fills a missing starts_at
+func ValidateAnnouncement(req *AnnouncementRequest, now time.Time) error {
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || utf8.RuneCountInString(req.Message) > maxAnnouncementLength {
+		return ErrAnnouncementMessage
+	}
+	switch req.Level {
+	case "":
+		req.Level = AnnouncementInfo
+	case AnnouncementInfo, AnnouncementWarning:
+	default:
+		return ErrInvalidAnnouncementLevel
+	}
+
+	translations := make(map[string]string, len(req.Translations))
+	for code, msg := range req.Translations {
+		code = strings.ToLower(strings.TrimSpace(code))
+		msg = strings.TrimSpace(msg)
+		if !IsValidLanguageCode(code) || msg == "" || utf8.RuneCountInString(msg) > maxAnnouncementLength {
+			return ErrAnnouncementTranslation
+		}
+		translations[code] = msg
+	}
+	req.Translations = translations
+
+	if req.StartsAt == nil {
+		req.StartsAt = &now
+	}
+	start := req.StartsAt.UTC()
+	req.StartsAt = &start
+	if req.EndsAt != nil {
+		end := req.EndsAt.UTC()
+		if !end.After(start) {
+			return ErrAnnouncementWindow
+		}
+		req.EndsAt = &end
+	}
+	return nil
+}
+
+// announcementCache holds the announcements that haven't ended yet, reloaded every announcementCacheTTL
+type announcementCache struct {
+	mu       sync.Mutex
+	loadedAt time.Time
+	items    []Announcement
+}
+
+var announcements announcementCache
+
+func (a *announcementCache) Get(ctx context.Context, now time.Time) ([]Announcement, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.loadedAt.IsZero() && now.Sub(a.loadedAt) < announcementCacheTTL {
+		return a.items, nil
+	}
+	items, err := queryAnnouncements(ctx, `WHERE ends_at IS NULL OR ends_at > ?`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	a.items, a.loadedAt = items, now
+	return items, nil
+}
+
+func (a *announcementCache) Invalidate() {
+	a.mu.Lock()
+	a.items, a.loadedAt = nil, time.Time{}
+	a.mu.Unlock()
+}
+
+// queryAnnouncements loads announcements matching where, soonest first
+func queryAnnouncements(ctx context.Context, where string, args ...interface{}) ([]Announcement, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, message, translations, level, starts_at, ends_at, created_by, created_at
+		FROM announcements `+where+`
+		ORDER BY starts_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Announcement{}
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+func scanAnnouncement(row rowScanner) (Announcement, error) {
+	var a Announcement
+	var translations string
+	var endsAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(&a.ID, &a.Message, &translations, &a.Level, &a.StartsAt, &endsAt, &createdBy, nullable(&a.CreatedAt)); err != nil {
+		return a, err
+	}
+	a.Translations = map[string]string{}
+	if err := json.Unmarshal([]byte(translations), &a.Translations); err != nil {
+		log.Printf("⚠️  Announcement %d has unreadable translations: %v", a.ID, err)
+	}
+	if endsAt.Valid {
+		a.EndsAt = &endsAt.Time
+	}
+	a.CreatedBy = int(createdBy.Int64)
+	return a, nil
+}
+
+// announcementLanguages lists the languages to try, in order: ?lang=, then Accept-Language
+func announcementLanguages(c *gin.Context) []string {
+	var langs []string
+	if lang := strings.ToLower(strings.TrimSpace(c.Query("lang"))); IsValidLanguageCode(lang) {
+		langs = append(langs, lang)
+	}
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if IsValidLanguageCode(primary) {
+			langs = append(langs, primary)
+		}
+	}
+	return langs
+}
+
+// activeAnnouncements returns the banners to show right now, localized to the first of langs
+// each one has a translation for
+func activeAnnouncements(ctx context.Context, langs []string) ([]ActiveAnnouncement, error) {
+	now := time.Now()
+	items, err := announcements.Get(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	active := []ActiveAnnouncement{}
+	for _, a := range items {
+		if a.activeAt(now) {
+			active = append(active, a.localize(langs))
+		}
+	}
+	return active, nil
+}
+
+// getActiveAnnouncements returns the banners to show right now in the viewer's language
+// (GET /api/announcements/active?lang=de)
+func getActiveAnnouncements(c *gin.Context) {
+	active, err := activeAnnouncements(c.Request.Context(), announcementLanguages(c))
+	if err != nil {
+		log.Printf("❌ Failed to load announcements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load announcements"})
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(announcementCacheTTL.Seconds())))
+	c.Header("Vary", "Accept-Language")
+	c.JSON(http.StatusOK, gin.H{"announcements": active})
+}
+
+// adminListAnnouncements returns every stored announcement, ended ones included until housekeeping
+// prunes them (GET /api/admin/announcements)
+func adminListAnnouncements(c *gin.Context) {
+	items, err := queryAnnouncements(c.Request.Context(), "")
+	if err != nil {
+		log.Printf("❌ Failed to query announcements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve announcements"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"announcements": items})
+}
+
+// bindAnnouncement reads and validates the request body, answering 400 itself
+func bindAnnouncement(c *gin.Context) (AnnouncementRequest, []byte, bool) {
+	var req AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message is required; starts_at and ends_at must be RFC3339 timestamps"})
+		return req, nil, false
+	}
+	if err := ValidateAnnouncement(&req, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, nil, false
+	}
+	translations, err := json.Marshal(req.Translations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAnnouncementTranslation.Error()})
+		return req, nil, false
+	}
+	return req, translations, true
+}
+
+// respondAnnouncement answers with the stored announcement id
+func respondAnnouncement(c *gin.Context, status int, id int64) {
+	a, err := scanAnnouncement(db.QueryRowContext(c.Request.Context(), `
+		SELECT id, message, translations, level, starts_at, ends_at, created_by, created_at
+		FROM announcements WHERE id = ?
+	`, id))
+	if err != nil {
+		log.Printf("❌ Failed to load announcement %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load announcement"})
+		return
+	}
+	c.JSON(status, a)
+}
+
+// adminCreateAnnouncement stores a new announcement (POST /api/admin/announcements)
+func adminCreateAnnouncement(c *gin.Context) {
+	req, translations, ok := bindAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	adminID := c.GetInt("user_id")
+	result, err := db.ExecContext(c.Request.Context(), `
+		INSERT INTO announcements (message, translations, level, starts_at, ends_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, req.Message, string(translations), req.Level, *req.StartsAt, req.EndsAt, adminID, time.Now().UTC())
+	if err != nil {
+		log.Printf("❌ Failed to create announcement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create announcement"})
+		return
+	}
+	announcements.Invalidate()
+
+	id, _ := result.LastInsertId()
+	log.Printf("📢 Admin %d created announcement %d (%s)", adminID, id, req.Level)
+	respondAnnouncement(c, http.StatusCreated, id)
+}
+
+// adminUpdateAnnouncement replaces an announcement (PUT /api/admin/announcements/:id)
+func adminUpdateAnnouncement(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid announcement ID"})
+		return
+	}
+	req, translations, ok := bindAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	result, err := db.ExecContext(c.Request.Context(), `
+		UPDATE announcements SET message = ?, translations = ?, level = ?, starts_at = ?, ends_at = ?, updated_at = ?
+		WHERE id = ?
+	`, req.Message, string(translations), req.Level, *req.StartsAt, req.EndsAt, time.Now().UTC(), id)
+	if err != nil {
+		log.Printf("❌ Failed to update announcement %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update announcement"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
+		return
+	}
+	announcements.Invalidate()
+
+	log.Printf("📢 Admin %d updated announcement %d", c.GetInt("user_id"), id)
+	respondAnnouncement(c, http.StatusOK, id)
+}
+
+// adminDeleteAnnouncement removes an announcement (DELETE /api/admin/announcements/:id)
+func adminDeleteAnnouncement(c *gin.Context) {
+	id := c.Param("id")
+	result, err := db.ExecContext(c.Request.Context(), `DELETE FROM announcements WHERE id = ?`, id)
+	if err != nil {
+		log.Printf("❌ Failed to delete announcement %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete announcement"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
+		return
+	}
+	announcements.Invalidate()
+
+	log.Printf("🗑️ Admin %d deleted announcement %s", c.GetInt("user_id"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "Announcement deleted"})
+}
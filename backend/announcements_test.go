@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAnnouncement(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req := AnnouncementRequest{Message: "  Maintenance Sunday 02:00-03:00 ", Translations: map[string]string{" DE ": "Wartung am Sonntag"}}
+	require.NoError(t, ValidateAnnouncement(&req, now))
+	assert.Equal(t, "Maintenance Sunday 02:00-03:00", req.Message)
+	assert.Equal(t, AnnouncementInfo, req.Level)
+	assert.Equal(t, map[string]string{"de": "Wartung am Sonntag"}, req.Translations)
+	assert.Equal(t, now, *req.StartsAt, "starts now by default")
+
+	assert.ErrorIs(t, ValidateAnnouncement(&AnnouncementRequest{Message: " "}, now), ErrAnnouncementMessage)
+	assert.ErrorIs(t, ValidateAnnouncement(&AnnouncementRequest{Message: "Hi", Level: "critical"}, now), ErrInvalidAnnouncementLevel)
+	assert.ErrorIs(t, ValidateAnnouncement(&AnnouncementRequest{Message: "Hi", Translations: map[string]string{"xx": "Hi"}}, now), ErrAnnouncementTranslation)
+	past := now.Add(-time.Hour)
+	assert.ErrorIs(t, ValidateAnnouncement(&AnnouncementRequest{Message: "Hi", EndsAt: &past}, now), ErrAnnouncementWindow)
+}
+
+func TestAnnouncements(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	announcements.Invalidate()
+	defer announcements.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/announcements/active", getActiveAnnouncements)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/announcements", adminListAnnouncements)
+	admin.POST("/announcements", adminCreateAnnouncement)
+	admin.PUT("/announcements/:id", adminUpdateAnnouncement)
+	admin.DELETE("/announcements/:id", adminDeleteAnnouncement)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	now := time.Now().UTC()
+	create := func(payload gin.H) Announcement {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/admin/announcements", adminToken, payload)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var a Announcement
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &a))
+		return a
+	}
+	active := func(query, acceptLanguage string) []ActiveAnnouncement {
+		t.Helper()
+		req, _ := http.NewRequest("GET", "/api/announcements/active"+query, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+		var body struct {
+			Announcements []ActiveAnnouncement `json:"announcements"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Announcements
+	}
+
+	maintenance := create(gin.H{
+		"message": "Maintenance Sunday 02:00-03:00", "level": "warning",
+		"translations": gin.H{"de": "Wartung am Sonntag 02:00-03:00", "fr": "Maintenance dimanche 02h00-03h00"},
+		"ends_at":      now.Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	create(gin.H{"message": "New privacy policy next week", "starts_at": now.Add(time.Hour).Format(time.RFC3339)})
+	ended := create(gin.H{
+		"message":   "Old news",
+		"starts_at": now.Add(-48 * time.Hour).Format(time.RFC3339), "ends_at": now.Add(-24 * time.Hour).Format(time.RFC3339),
+	})
+
+	t.Run("Only announcements inside their window are active", func(t *testing.T) {
+		list := active("", "")
+		require.Len(t, list, 1)
+		assert.Equal(t, maintenance.ID, list[0].ID)
+		assert.Equal(t, AnnouncementWarning, list[0].Level)
+		assert.Equal(t, "Maintenance Sunday 02:00-03:00", list[0].Message)
+		assert.Empty(t, list[0].Language)
+	})
+
+	t.Run("Messages follow the requested language and fall back to the default", func(t *testing.T) {
+		assert.Equal(t, "Wartung am Sonntag 02:00-03:00", active("?lang=de", "")[0].Message)
+		assert.Equal(t, "Maintenance dimanche 02h00-03h00", active("", "it-IT,fr-CH;q=0.8,de;q=0.5")[0].Message,
+			"the first Accept-Language with a variant wins")
+		assert.Equal(t, "Wartung am Sonntag 02:00-03:00", active("?lang=de", "fr")[0].Message, "lang= beats Accept-Language")
+		fallback := active("?lang=pl", "")[0]
+		assert.Equal(t, "Maintenance Sunday 02:00-03:00", fallback.Message)
+		assert.Empty(t, fallback.Language)
+	})
+
+	t.Run("Only admins manage announcements", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "POST", "/api/admin/announcements", userToken, gin.H{"message": "Hi"}).Code)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", "/api/admin/announcements", userToken, nil).Code)
+		path := fmt.Sprintf("/api/admin/announcements/%d", maintenance.ID)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "PUT", path, userToken, gin.H{"message": "Hi"}).Code)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "DELETE", path, userToken, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, doJSON(router, "DELETE", path, "", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", "/api/admin/announcements", adminToken, gin.H{"message": "Hi", "level": "critical"}).Code)
+	})
+
+	t.Run("Changes show up without waiting for the cache", func(t *testing.T) {
+		path := fmt.Sprintf("/api/admin/announcements/%d", maintenance.ID)
+		w := doJSON(router, "PUT", path, adminToken, gin.H{"message": "Maintenance moved to Monday"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		list := active("?lang=de", "")
+		require.Len(t, list, 1)
+		assert.Equal(t, "Maintenance moved to Monday", list[0].Message, "the update replaced the translations too")
+		assert.Equal(t, AnnouncementInfo, list[0].Level)
+
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", path, adminToken, nil).Code)
+		assert.Empty(t, active("", ""))
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "DELETE", path, adminToken, nil).Code)
+	})
+
+	t.Run("Housekeeping prunes ended announcements", func(t *testing.T) {
+		_, err := runCleanup(time.Now())
+		require.NoError(t, err)
+
+		w := doJSON(router, "GET", "/api/admin/announcements", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Announcements []Announcement `json:"announcements"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Announcements, 1, "the upcoming one stays")
+		assert.NotEqual(t, ended.ID, body.Announcements[0].ID)
+	})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attendanceCorrectionWindow is how long after the event start attendance can still be changed
+const attendanceCorrectionWindow = 14 * 24 * time.Hour
+
+// loadAttendanceEvent fetches the creator and start time of an event for attendance checks
+func loadAttendanceEvent(eventID int) (creatorID int, start time.Time, err error) {
+	var startTime string
+	err = db.QueryRow(`SELECT user_id, start_time FROM events WHERE id = ?`, eventID).Scan(&creatorID, &startTime)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	start, err = parseDateTime(startTime)
+	return creatorID, start, err
+}
+
+// markEventAttendance records attended/no_show for participants (PUT /api/events/:id/attendance)
+// Only the organizer can mark, only after start_time, and only within 14 days of the start
+func markEventAttendance(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	log.Printf("📋 PUT /api/events/%d/attendance - User %d marking attendance", eventID, userID)
+
+	var req MarkAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance must be a list of user IDs with status attended or no_show"})
+		return
+	}
+
+	creatorID, start, err := loadAttendanceEvent(eventID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+		return
+	}
+
+	if creatorID != userID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can mark attendance"})
+		return
+	}
+
+	now := time.Now()
+	if now.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance can only be marked after the event has started"})
+		return
+	}
+	if now.After(start.Add(attendanceCorrectionWindow)) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance can no longer be changed for this event"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+		return
+	}
+	defer tx.Rollback()
+
+	for _, mark := range req.Attendance {
+		// Marking someone as attended resolves any open dispute
+		result, err := tx.ExecContext(ctx, `
+			UPDATE event_participants
+			SET attendance = ?, attendance_marked_at = ?,
+			    attendance_disputed = CASE WHEN ? = 'attended' THEN 0 ELSE attendance_disputed END
+			WHERE event_id = ? AND user_id = ?
+		`, mark.Status, now, mark.Status, eventID, mark.UserID)
+		if err != nil {
+			log.Printf("❌ Error marking attendance: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User " + strconv.Itoa(mark.UserID) + " is not a participant of this event"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing attendance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+		return
+	}
+
+	log.Printf("✅ Attendance marked for %d participants of event %d", len(req.Attendance), eventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance saved", "updated": len(req.Attendance)})
+}
+
+// getEventAttendance lists participants with their attendance and dispute flags (organizer only)
+func getEventAttendance(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+
+	creatorID, _, err := loadAttendanceEvent(eventID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attendance"})
+		return
+	}
+
+	if creatorID != userID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can view attendance"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.name, ep.attendance, COALESCE(ep.attendance_disputed, 0)
+		FROM event_participants ep
+		JOIN users u ON ep.user_id = u.id
+		WHERE ep.event_id = ?
+		ORDER BY ep.joined_at ASC
+	`, eventID)
+	if err != nil {
+		log.Printf("❌ Error fetching attendance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attendance"})
+		return
+	}
+	defer rows.Close()
+
+	entries := []AttendanceEntry{}
+	for rows.Next() {
+		var entry AttendanceEntry
+		var status sql.NullString
+		if err := rows.Scan(&entry.UserID, &entry.Name, &status, &entry.Disputed); err != nil {
+			log.Printf("❌ Error scanning attendance: %v", err)
+			continue
+		}
+		entry.Status = status.String
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// disputeAttendance lets a participant flag a no_show mark they disagree with
+func disputeAttendance(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	log.Printf("⚖️ POST /api/events/%d/attendance/dispute - User %d disputing no-show", eventID, userID)
+
+	result, err := db.ExecContext(ctx, `
+		UPDATE event_participants SET attendance_disputed = 1
+		WHERE event_id = ? AND user_id = ? AND attendance = ?
+	`, eventID, userID, AttendanceNoShow)
+	if err != nil {
+		log.Printf("❌ Error disputing attendance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dispute attendance"})
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You were not marked as a no-show for this event"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dispute submitted, the organizer will see it"})
+}
+
+// GetAttendanceRate returns the percentage of marked events a user attended, or nil if never marked
+func GetAttendanceRate(userID int) (*int, error) {
+	var attended, marked int
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN attendance = ? THEN 1 ELSE 0 END), 0), COUNT(*)
+		FROM event_participants
+		WHERE user_id = ? AND attendance IS NOT NULL
+	`, AttendanceAttended, userID).Scan(&attended, &marked)
+	if err != nil || marked == 0 {
+		return nil, err
+	}
+
+	rate := int(math.Round(float64(attended) * 100 / float64(marked)))
+	return &rate, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAttendanceRouter() *gin.Engine {
+	router := gin.New()
+	router.GET("/api/profile/:id", optionalAuthMiddleware(), getUserProfile)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/events/:id/attendance", getEventAttendance)
+	protected.PUT("/events/:id/attendance", markEventAttendance)
+	protected.POST("/events/:id/attendance/dispute", disputeAttendance)
+	return router
+}
+
+func TestMarkEventAttendance(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupAttendanceRouter()
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com"})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com"})
+
+	t.Run("Rejected before the event starts", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Future Event")
+		addParticipant(t, testDB, eventID, aliceID)
+
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d/attendance", eventID), organizerToken,
+			gin.H{"attendance": []gin.H{{"user_id": aliceID, "status": "attended"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	eventID := createPastEvent(t, testDB, organizerID, "attendance-event", time.Now().Add(-3*time.Hour), nil)
+	addParticipant(t, testDB, eventID, aliceID)
+	addParticipant(t, testDB, eventID, bobID)
+	path := fmt.Sprintf("/api/events/%d/attendance", eventID)
+
+	t.Run("Only the organizer can mark", func(t *testing.T) {
+		w := doJSON(router, "PUT", path, aliceToken, gin.H{"attendance": []gin.H{{"user_id": bobID, "status": "no_show"}}})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Invalid status rejected", func(t *testing.T) {
+		w := doJSON(router, "PUT", path, organizerToken, gin.H{"attendance": []gin.H{{"user_id": aliceID, "status": "maybe"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Non-participant rejected", func(t *testing.T) {
+		w := doJSON(router, "PUT", path, organizerToken, gin.H{"attendance": []gin.H{{"user_id": organizerID, "status": "attended"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Partial list only updates listed participants", func(t *testing.T) {
+		w := doJSON(router, "PUT", path, organizerToken, gin.H{"attendance": []gin.H{{"user_id": bobID, "status": "no_show"}}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(router, "GET", path, organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var entries []AttendanceEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		require.Len(t, entries, 2)
+
+		statuses := map[int]string{}
+		for _, e := range entries {
+			statuses[e.UserID] = e.Status
+		}
+		assert.Equal(t, "", statuses[int(aliceID)])
+		assert.Equal(t, AttendanceNoShow, statuses[int(bobID)])
+	})
+
+	t.Run("No-show can be disputed and organizer sees the flag", func(t *testing.T) {
+		w := doJSON(router, "POST", path+"/dispute", aliceToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unmarked participant cannot dispute")
+
+		w = doJSON(router, "POST", path+"/dispute", bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(router, "GET", path, organizerToken, nil)
+		var entries []AttendanceEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		for _, e := range entries {
+			assert.Equal(t, e.UserID == int(bobID), e.Disputed)
+		}
+
+		// Correcting to attended resolves the dispute
+		w = doJSON(router, "PUT", path, organizerToken, gin.H{"attendance": []gin.H{{"user_id": bobID, "status": "attended"}}})
+		require.Equal(t, http.StatusOK, w.Code)
+		var disputed bool
+		testDB.QueryRow(`SELECT attendance_disputed FROM event_participants WHERE event_id = ? AND user_id = ?`, eventID, bobID).Scan(&disputed)
+		assert.False(t, disputed)
+	})
+
+	t.Run("Corrections closed after 14 days", func(t *testing.T) {
+		oldEventID := createPastEvent(t, testDB, organizerID, "old-attendance-event", time.Now().Add(-15*24*time.Hour), nil)
+		addParticipant(t, testDB, oldEventID, aliceID)
+
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d/attendance", oldEventID), organizerToken,
+			gin.H{"attendance": []gin.H{{"user_id": aliceID, "status": "attended"}}})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestAttendanceRate(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupAttendanceRouter()
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+
+	rate, err := GetAttendanceRate(int(userID))
+	require.NoError(t, err)
+	assert.Nil(t, rate, "no marks yet means no score")
+
+	// 2 attended, 1 no-show, 1 unmarked => 67%
+	for i, status := range []string{AttendanceAttended, AttendanceNoShow, AttendanceAttended, ""} {
+		eventID := createPastEvent(t, testDB, organizerID, fmt.Sprintf("rate-event-%d", i), time.Now().Add(-24*time.Hour), nil)
+		addParticipant(t, testDB, eventID, userID)
+		if status != "" {
+			_, err := testDB.Exec(`UPDATE event_participants SET attendance = ? WHERE event_id = ? AND user_id = ?`, status, eventID, userID)
+			require.NoError(t, err)
+		}
+	}
+
+	rate, err = GetAttendanceRate(int(userID))
+	require.NoError(t, err)
+	require.NotNil(t, rate)
+	assert.Equal(t, 67, *rate)
+
+	w := doJSON(router, "GET", fmt.Sprintf("/api/profile/%d", userID), "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(67), resp["attendance_rate"])
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -11,38 +12,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var jwtSecret []byte
 
-// bcryptCost is the cost factor for password hashing
-// Lower for tests (4), higher for production (14)
-var bcryptCost = 14
-
-func init() {
-	// Use lower bcrypt cost for tests to speed them up
-	if isTestMode() {
-		bcryptCost = 4
-	}
-}
-
 type Claims struct {
 	UserID  int    `json:"user_id"`
 	Email   string `json:"email"`
 	IsAdmin bool   `json:"is_admin"`
+	Purpose string `json:"purpose,omitempty"` // Empty for session tokens, set for single-purpose tokens (e.g. 2FA challenge)
+	// Set when an admin is acting as this user (support impersonation)
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
-	return string(bytes), err
-}
+// twoFactorChallengePurpose marks tokens that only allow completing a 2FA login
+const twoFactorChallengePurpose = "2fa_challenge"
 
-func checkPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
+// twoFactorChallengeTTL is how long a user has to enter their TOTP code after the password check
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// impersonationTTL is the lifetime of tokens issued to admins acting as another user
+const impersonationTTL = 15 * time.Minute
 
 func generateToken(user User) (string, error) {
 	claims := Claims{
@@ -50,7 +41,7 @@ func generateToken(user User) (string, error) {
 		Email:   user.Email,
 		IsAdmin: user.IsAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hours (reduced from 7 days for security)
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(appConfig.SessionTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -60,6 +51,71 @@ func generateToken(user User) (string, error) {
 }
 
 func validateToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single-purpose tokens (e.g. 2FA challenges) must never grant a session
+	if claims.Purpose != "" {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// generateImpersonationToken issues a short-lived session token for target that records which admin is acting
+func generateImpersonationToken(target User, impersonatorID int) (string, time.Time, error) {
+	expiresAt := time.Now().Add(impersonationTTL)
+	claims := Claims{
+		UserID:         target.ID,
+		Email:          target.Email,
+		IsAdmin:        target.IsAdmin,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtSecret)
+	return signed, expiresAt, err
+}
+
+// generateChallengeToken issues a short-lived token proving the password check passed
+// for an account with two-factor authentication enabled
+func generateChallengeToken(user User) (string, error) {
+	claims := Claims{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Purpose: twoFactorChallengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// validateChallengeToken validates a 2FA challenge token and returns its claims
+func validateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != twoFactorChallengePurpose {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	return claims, nil
+}
+
+// parseClaims verifies the signature and expiry of a token and returns its claims
+func parseClaims(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -122,9 +178,13 @@ func authMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is blocked and get email verification status
-		var isBlocked, emailVerified bool
-		err = db.QueryRow("SELECT is_blocked, email_verified FROM users WHERE id = ?", claims.UserID).Scan(&isBlocked, &emailVerified)
+		// The token only proves who the caller is: email, verification, role and blocks are read
+		// from the account on every request, so changes apply to tokens issued before them
+		var block accountBlock
+		var email string
+		var emailVerified, isAdmin bool
+		err = db.QueryRowContext(c.Request.Context(), "SELECT email, is_blocked, email_verified, is_admin, blocked_until, COALESCE(block_reason, '') FROM users WHERE id = ?", claims.UserID).
+			Scan(&email, nullable(&block.blocked), nullable(&emailVerified), nullable(&isAdmin), &block.until, &block.reason)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
@@ -135,17 +195,35 @@ func authMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if isBlocked {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User account is blocked"})
+		// Suspensions end on their own: the first request after blocked_until lifts them
+		now := time.Now()
+		if block.active(now) {
+			block.respond(c, "User account is blocked")
+			c.Abort()
+			return
+		}
+		if block.expired(now) {
+			if err := liftSuspension(c.Request.Context(), claims.UserID, now); err != nil {
+				log.Printf("⚠️  Failed to lift suspension of user %d: %v", claims.UserID, err)
+			}
+		}
+
+		// Impersonation tokens stop working as soon as the impersonator loses admin rights
+		if claims.ImpersonatorID != 0 && !isActiveAdmin(claims.ImpersonatorID) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Set user info in context
+		// Set user info in context. Promotions, demotions and email verification take effect
+		// on the next request, without a new token.
 		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("is_admin", claims.IsAdmin)
+		c.Set("user_email", email)
+		c.Set("is_admin", isAdmin)
 		c.Set("email_verified", emailVerified)
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonated_by", claims.ImpersonatorID)
+		}
 
 		c.Next()
 	}
@@ -177,20 +255,30 @@ func optionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Get user info from database
-		var isBlocked, emailVerified bool
-		err = db.QueryRow("SELECT is_blocked, email_verified FROM users WHERE id = ?", claims.UserID).Scan(&isBlocked, &emailVerified)
-		if err != nil || isBlocked {
+		// Get user info from database; as in authMiddleware the token is only the identity
+		var block accountBlock
+		var email string
+		var emailVerified, isAdmin bool
+		err = db.QueryRowContext(c.Request.Context(), "SELECT email, is_blocked, email_verified, is_admin, blocked_until FROM users WHERE id = ?", claims.UserID).
+			Scan(&email, nullable(&block.blocked), nullable(&emailVerified), nullable(&isAdmin), &block.until)
+		if err != nil || block.active(time.Now()) {
 			// User not found or blocked, continue without setting user context
 			c.Next()
 			return
 		}
+		if claims.ImpersonatorID != 0 && !isActiveAdmin(claims.ImpersonatorID) {
+			c.Next()
+			return
+		}
 
 		// Set user info in context for privacy filtering
 		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("is_admin", claims.IsAdmin)
+		c.Set("user_email", email)
+		c.Set("is_admin", isAdmin)
 		c.Set("email_verified", emailVerified)
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonated_by", claims.ImpersonatorID)
+		}
 
 		c.Next()
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -287,3 +288,62 @@ func TestAuthMiddlewareNonExistentUser(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	assert.Contains(t, w.Body.String(), "User not found")
 }
+
+func TestAuthMiddlewareReadsAccountState(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/admin", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "admin access granted"})
+	})
+	router.GET("/whoami", optionalAuthMiddleware(), func(c *gin.Context) {
+		c.JSON(200, gin.H{"is_admin": c.GetBool("is_admin"), "email_verified": c.GetBool("email_verified"), "email": c.GetString("user_email")})
+	})
+	router.POST("/api/events/:id/join", authMiddleware(), joinEvent)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+	exec := func(query string, args ...interface{}) {
+		t.Helper()
+		_, err := testDB.Exec(query, args...)
+		require.NoError(t, err)
+	}
+
+	t.Run("A demoted admin loses access without a new token", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doJSON(router, "GET", "/admin", adminToken, nil).Code)
+		exec(`UPDATE users SET is_admin = 0 WHERE id = ?`, adminID)
+		w := doJSON(router, "GET", "/admin", adminToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"is_admin": false, "email_verified": true, "email": "admin@example.com"}`, doJSON(router, "GET", "/whoami", adminToken, nil).Body.String())
+	})
+
+	t.Run("A promotion applies to the current token", func(t *testing.T) {
+		exec(`UPDATE users SET is_admin = 1 WHERE id = ?`, userID)
+		assert.Equal(t, http.StatusOK, doJSON(router, "GET", "/admin", userToken, nil).Code)
+		exec(`UPDATE users SET is_admin = 0 WHERE id = ?`, userID)
+	})
+
+	t.Run("A freshly verified user can join with their old token", func(t *testing.T) {
+		exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, userID)
+		eventID := createTestEvent(t, testDB, adminID, "Board games")
+		joinPath := fmt.Sprintf("/api/events/%d/join", eventID)
+
+		w := doJSON(router, "POST", joinPath, userToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeEmailNotVerified)
+
+		exec(`UPDATE users SET email_verified = 1 WHERE id = ?`, userID)
+		w = doJSON(router, "POST", joinPath, userToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("The email comes from the account, not the token", func(t *testing.T) {
+		exec(`UPDATE users SET email = 'renamed@example.com' WHERE id = ?`, userID)
+		assert.JSONEq(t, `{"is_admin": false, "email_verified": true, "email": "renamed@example.com"}`, doJSON(router, "GET", "/whoami", userToken, nil).Body.String())
+	})
+}
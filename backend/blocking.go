@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 // blockUser blocks a user (POST /api/users/:id/block)
 func blockUser(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -34,7 +36,7 @@ func blockUser(c *gin.Context) {
 
 	// Check if blocked user exists
 	var count int
-	err = db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, blockedID).Scan(&count)
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE id = ?`, blockedID).Scan(&count)
 	if err != nil || count == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -47,7 +49,7 @@ func blockUser(c *gin.Context) {
 	}
 
 	// Insert block (will fail if already blocked due to UNIQUE constraint)
-	_, err = db.Exec(`
+	_, err = db.ExecContext(ctx, `
 		INSERT INTO user_blocks (blocker_id, blocked_id, reason)
 		VALUES (?, ?, ?)
 	`, blockerID, blockedID, req.Reason)
@@ -69,6 +71,7 @@ func blockUser(c *gin.Context) {
 
 // unblockUser unblocks a user (DELETE /api/users/:id/block)
 func unblockUser(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -85,7 +88,7 @@ func unblockUser(c *gin.Context) {
 	blockerID := userID.(int)
 
 	// Delete the block
-	result, err := db.Exec(`
+	result, err := db.ExecContext(ctx, `
 		DELETE FROM user_blocks
 		WHERE blocker_id = ? AND blocked_id = ?
 	`, blockerID, blockedID)
@@ -108,6 +111,7 @@ func unblockUser(c *gin.Context) {
 
 // getBlockedUsers returns list of users that the current user has blocked (GET /api/blocks)
 func getBlockedUsers(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -116,7 +120,7 @@ func getBlockedUsers(c *gin.Context) {
 
 	blockerID := userID.(int)
 
-	rows, err := db.Query(`
+	rows, err := db.QueryContext(ctx, `
 		SELECT ub.id, ub.blocked_id, u.name, u.email, ub.reason, ub.created_at
 		FROM user_blocks ub
 		JOIN users u ON ub.blocked_id = u.id
@@ -179,6 +183,16 @@ func AreUsersBlocked(userID1, userID2 int) bool {
 	return err == nil && count > 0
 }
 
+// HasBlocked checks if blockerID blocked blockedID (one direction only)
+func HasBlocked(ctx context.Context, blockerID, blockedID int) bool {
+	var blocked bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?)
+	`, blockerID, blockedID).Scan(&blocked)
+
+	return err == nil && blocked
+}
+
 // FilterEventsByBlocks filters out events from blocked users
 func FilterEventsByBlocks(events []Event, userID int) []Event {
 	if userID == 0 {
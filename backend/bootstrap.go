@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bootstrapBudget bounds how long GET /api/bootstrap waits for all its sections together; optional
+// sections still running by then are reported unavailable
+const bootstrapBudget = 3 * time.Second
+
+// BootstrapEventRef is an event in the bootstrap payload: just enough to hydrate links and
+// "joined" badges without fetching the event
+type BootstrapEventRef struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// bootstrapSection is one independent part of the bootstrap payload. A required section that
+// fails fails the call; an optional one is left out and named in "unavailable".
+type bootstrapSection struct {
+	key      string
+	required bool
+	load     func(ctx context.Context) (interface{}, error)
+}
+
+// getBootstrap returns what the SPA needs on every page load in one call (GET /api/bootstrap):
+// the categories and active announcements for everyone, plus the account, unread notifications,
+// blocked user IDs, pending invitations and upcoming events for signed-in users
+func getBootstrap(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	langs := announcementLanguages(c)
+	log.Printf("🚀 GET /api/bootstrap - User %d", userID)
+
+	sections := []bootstrapSection{
+		{key: "announcements", load: func(ctx context.Context) (interface{}, error) {
+			return activeAnnouncements(ctx, langs)
+		}},
+	}
+	if userID > 0 {
+		sections = append(sections,
+			bootstrapSection{key: "user", required: true, load: func(ctx context.Context) (interface{}, error) {
+				return loadCurrentUser(ctx, userID)
+			}},
+			bootstrapSection{key: "unread_notifications", load: func(ctx context.Context) (interface{}, error) {
+				return countBootstrapRows(ctx, `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID)
+			}},
+			bootstrapSection{key: "blocked_user_ids", load: func(ctx context.Context) (interface{}, error) {
+				return loadBlockedUserIDs(ctx, userID)
+			}},
+			// Handovers offered to the user are the only invitations there are so far
+			bootstrapSection{key: "pending_invitations", load: func(ctx context.Context) (interface{}, error) {
+				return countBootstrapRows(ctx, `SELECT COUNT(*) FROM event_transfers WHERE to_user_id = ? AND expires_at > ?`, userID, time.Now().UTC())
+			}},
+			bootstrapSection{key: "created_events", load: func(ctx context.Context) (interface{}, error) {
+				return loadUpcomingEventRefs(ctx, `e.user_id = ?`, userID)
+			}},
+			bootstrapSection{key: "joined_events", load: func(ctx context.Context) (interface{}, error) {
+				return loadUpcomingEventRefs(ctx, `e.id IN (SELECT event_id FROM event_participants WHERE user_id = ?)`, userID)
+			}},
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bootstrapBudget)
+	defer cancel()
+	results := make([]interface{}, len(sections))
+	errs := make([]error, len(sections))
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func(i int, section bootstrapSection) {
+			defer wg.Done()
+			results[i], errs[i] = section.load(ctx)
+		}(i, section)
+	}
+	wg.Wait()
+
+	response := gin.H{"categories": CategoryNames}
+	unavailable := []string{}
+	for i, section := range sections {
+		if errs[i] == nil {
+			response[section.key] = results[i]
+			continue
+		}
+		if section.required {
+			if errors.Is(errs[i], sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			log.Printf("❌ Bootstrap section %s failed for user %d: %v", section.key, userID, errs[i])
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load account"})
+			return
+		}
+		log.Printf("⚠️  Bootstrap section %s unavailable for user %d: %v", section.key, userID, errs[i])
+		unavailable = append(unavailable, section.key)
+	}
+	response["unavailable"] = unavailable
+	// Same impersonation report as GET /api/auth/me, for the support banner
+	if userID > 0 {
+		response["impersonating"] = false
+		if impersonator, ok := c.Get("impersonated_by"); ok {
+			response["impersonating"] = true
+			response["impersonator_id"] = impersonator
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// countBootstrapRows runs a COUNT(*) query for one of the bootstrap counters
+func countBootstrapRows(ctx context.Context, query string, args ...interface{}) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// loadBlockedUserIDs lists the users the given user blocked, most recent first
+func loadBlockedUserIDs(ctx context.Context, userID int) ([]int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT blocked_id FROM user_blocks WHERE blocker_id = ? ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// loadUpcomingEventRefs lists the upcoming, not cancelled events matching where, soonest first
+func loadUpcomingEventRefs(ctx context.Context, where string, args ...interface{}) ([]BootstrapEventRef, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, COALESCE(e.slug, '')
+		FROM events e
+		WHERE `+where+` AND e.start_time >= datetime('now') AND e.cancelled_at IS NULL
+		ORDER BY e.start_time ASC, e.id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	refs := []BootstrapEventRef{}
+	for rows.Next() {
+		var ref BootstrapEventRef
+		if err := rows.Scan(&ref.ID, &ref.Slug); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
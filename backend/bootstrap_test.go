@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrap(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	announcements.Invalidate()
+	defer announcements.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/bootstrap", optionalAuthMiddleware(), getBootstrap)
+
+	userID := createTestUser(t, testDB, "user@example.com", "Uma", "password123", false)
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	blockedID := createTestUser(t, testDB, "blocked@example.com", "Bert", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	created := createTestEvent(t, testDB, userID, "My picnic")
+	joined := createTestEvent(t, testDB, organizerID, "Olga's hike")
+	addParticipant(t, testDB, joined, userID)
+	past := createTestEvent(t, testDB, organizerID, "Last week's quiz")
+	addParticipant(t, testDB, past, userID)
+	_, err := testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, time.Now().Add(-7*24*time.Hour).UTC(), past)
+	require.NoError(t, err)
+
+	_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, userID, blockedID)
+	require.NoError(t, err)
+	notify(testDB, int(userID), NotificationEventTransferred, nil)
+	notify(testDB, int(userID), NotificationEventTransferred, nil)
+	_, err = testDB.Exec(`
+		INSERT INTO event_transfers (event_id, from_user_id, to_user_id, keep_as_participant, expires_at, created_at)
+		VALUES (?, ?, ?, 0, ?, ?)
+	`, joined, organizerID, userID, time.Now().Add(time.Hour).UTC(), time.Now().UTC())
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO announcements (message, starts_at) VALUES ('Maintenance tonight', ?)`, time.Now().Add(-time.Hour).UTC())
+	require.NoError(t, err)
+
+	bootstrap := func(token string) map[string]json.RawMessage {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/bootstrap", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	keys := func(body map[string]json.RawMessage) []string {
+		var keys []string
+		for key := range body {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	t.Run("Anonymous callers get announcements and categories", func(t *testing.T) {
+		body := bootstrap("")
+		assert.ElementsMatch(t, []string{"announcements", "categories", "unavailable"}, keys(body))
+		assert.Contains(t, string(body["announcements"]), "Maintenance tonight")
+		assert.Contains(t, string(body["categories"]), "sports_fitness")
+		assert.JSONEq(t, `[]`, string(body["unavailable"]))
+	})
+
+	t.Run("Signed-in callers get their account state", func(t *testing.T) {
+		body := bootstrap(token)
+		assert.ElementsMatch(t, []string{
+			"announcements", "categories", "unavailable", "impersonating", "user", "unread_notifications",
+			"blocked_user_ids", "pending_invitations", "created_events", "joined_events",
+		}, keys(body))
+
+		var user User
+		require.NoError(t, json.Unmarshal(body["user"], &user))
+		assert.Equal(t, "Uma", user.Name)
+		assert.JSONEq(t, `2`, string(body["unread_notifications"]))
+		assert.JSONEq(t, `1`, string(body["pending_invitations"]))
+		assert.JSONEq(t, `false`, string(body["impersonating"]))
+
+		var blocked []int
+		require.NoError(t, json.Unmarshal(body["blocked_user_ids"], &blocked))
+		assert.Equal(t, []int{int(blockedID)}, blocked)
+
+		var createdRefs, joinedRefs []BootstrapEventRef
+		require.NoError(t, json.Unmarshal(body["created_events"], &createdRefs))
+		require.NoError(t, json.Unmarshal(body["joined_events"], &joinedRefs))
+		require.Len(t, createdRefs, 1)
+		assert.Equal(t, int(created), createdRefs[0].ID)
+		require.Len(t, joinedRefs, 1, "past events are left out")
+		assert.Equal(t, int(joined), joinedRefs[0].ID)
+	})
+
+	t.Run("A failing optional section degrades the payload", func(t *testing.T) {
+		_, err := testDB.Exec(`DROP TABLE announcements`)
+		require.NoError(t, err)
+		announcements.Invalidate()
+
+		for _, tok := range []string{"", token} {
+			body := bootstrap(tok)
+			assert.NotContains(t, body, "announcements")
+			assert.JSONEq(t, `["announcements"]`, string(body["unavailable"]))
+			assert.Contains(t, body, "categories")
+		}
+		assert.Contains(t, bootstrap(token), "user")
+	})
+}
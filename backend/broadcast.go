@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	NotifierTelegram = "telegram"
+	NotifierMatrix   = "matrix"
+
+	broadcastSendTimeout   = 5 * time.Second
+	defaultTelegramAPIURL  = "https://api.telegram.org"
+	broadcastResponseLimit = 1024
+)
+
+// Notifier posts a plain-text message to one chat or room of a messaging service
+type Notifier interface {
+	Send(ctx context.Context, target, message string) error
+}
+
+// telegramNotifier sends through the Bot API's sendMessage; target is the chat id
+type telegramNotifier struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+func (n *telegramNotifier) Send(ctx context.Context, target, message string) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"chat_id":                  target,
+		"text":                     message,
+		"disable_web_page_preview": false,
+	})
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(n.apiURL, "/"), n.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doNotifierRequest(n.client, req)
+}
+
+// matrixNotifier sends an m.text event; target is the room id
+type matrixNotifier struct {
+	homeserverURL string
+	accessToken   string
+	client        *http.Client
+}
+
+func (n *matrixNotifier) Send(ctx context.Context, target, message string) error {
+	body, _ := json.Marshal(map[string]string{"msgtype": "m.text", "body": message})
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(n.homeserverURL, "/"), url.PathEscape(target), generateRandomString(16))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+n.accessToken)
+	return doNotifierRequest(n.client, req)
+}
+
+func doNotifierRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, broadcastResponseLimit))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// BroadcastRoute sends events of one category to a chat on a notifier
+type BroadcastRoute struct {
+	ID        int       `json:"id"`
+	Category  string    `json:"category" binding:"required"`
+	Notifier  string    `json:"notifier" binding:"required"`
+	Target    string    `json:"target" binding:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var (
+	// notifiers holds the configured services by name; see configureNotifiers
+	notifiers = map[string]Notifier{}
+	// pendingBroadcasts lets shutdown (and tests) wait for in-flight sends
+	pendingBroadcasts sync.WaitGroup
+)
+
+// configureNotifiers sets up Telegram/Matrix from the environment and seeds their category routes.
+// Routes are "category:target" pairs separated by commas; targets may themselves contain colons.
+func configureNotifiers() {
+	client := &http.Client{Timeout: broadcastSendTimeout}
+
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		apiURL := os.Getenv("TELEGRAM_API_URL")
+		if apiURL == "" {
+			apiURL = defaultTelegramAPIURL
+		}
+		notifiers[NotifierTelegram] = &telegramNotifier{apiURL: apiURL, token: token, client: client}
+		seedBroadcastRoutes(NotifierTelegram, os.Getenv("TELEGRAM_ROUTES"))
+		log.Println("✓ Telegram broadcasts enabled")
+	}
+
+	homeserver, accessToken := os.Getenv("MATRIX_HOMESERVER_URL"), os.Getenv("MATRIX_ACCESS_TOKEN")
+	if homeserver != "" && accessToken != "" {
+		notifiers[NotifierMatrix] = &matrixNotifier{homeserverURL: homeserver, accessToken: accessToken, client: client}
+		seedBroadcastRoutes(NotifierMatrix, os.Getenv("MATRIX_ROUTES"))
+		log.Println("✓ Matrix broadcasts enabled")
+	}
+}
+
+// seedBroadcastRoutes stores env-configured routes; routes already in the table are left alone
+func seedBroadcastRoutes(notifier, spec string) {
+	for _, pair := range strings.Split(spec, ",") {
+		category, target, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || category == "" || target == "" {
+			if pair != "" {
+				log.Printf("⚠️  Warning: Ignoring malformed %s route %q", notifier, pair)
+			}
+			continue
+		}
+		if _, err := db.Exec(`INSERT OR IGNORE INTO broadcast_routes (category, notifier, target) VALUES (?, ?, ?)`,
+			category, notifier, target); err != nil {
+			log.Printf("⚠️  Warning: Could not store %s route for %s: %v", notifier, category, err)
+		}
+	}
+}
+
+// broadcastEvent announces a created or cancelled event to every chat routed for its category.
+// It returns immediately; failures are only logged so they never reach the organizer.
+func broadcastEvent(eventType string, eventID int) {
+	if len(notifiers) == 0 {
+		return
+	}
+	pendingBroadcasts.Add(1)
+	go func() {
+		defer pendingBroadcasts.Done()
+		if err := sendEventBroadcast(eventType, eventID); err != nil {
+			log.Printf("⚠️  Broadcast of %s for event %d failed: %v", eventType, eventID, err)
+		}
+	}()
+}
+
+func sendEventBroadcast(eventType string, eventID int) error {
+	var e Event
+	var slug sql.NullString
+	err := db.QueryRow(`
+		SELECT id, slug, title, category, latitude, longitude, location_name, start_time,
+		       COALESCE(allow_unregistered_users, 1), require_verified_to_view
+		FROM events WHERE id = ?
+	`, eventID).Scan(&e.ID, &slug, &e.Title, &e.Category, &e.Latitude, &e.Longitude, &e.LocationName, &e.StartTime,
+		&e.AllowUnregisteredUsers, nullable(&e.RequireVerifiedToView))
+	if err != nil {
+		return err
+	}
+	e.Slug = slug.String
+
+	// Group chats are public; don't advertise events strangers can't open
+	if !e.AllowUnregisteredUsers || e.RequireVerifiedToView {
+		return nil
+	}
+
+	routes, err := broadcastRoutesFor(e.Category)
+	if err != nil {
+		return err
+	}
+
+	message := formatBroadcastMessage(eventType, &e)
+	for _, route := range routes {
+		notifier, ok := notifiers[route.Notifier]
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), broadcastSendTimeout)
+		err := notifier.Send(ctx, route.Target, message)
+		cancel()
+		if err != nil {
+			log.Printf("⚠️  %s broadcast to %s failed for event %d: %v", route.Notifier, route.Target, eventID, err)
+			continue
+		}
+		log.Printf("📣 Event %d announced on %s (%s)", eventID, route.Notifier, route.Target)
+	}
+	return nil
+}
+
+func broadcastRoutesFor(category string) ([]BroadcastRoute, error) {
+	rows, err := db.Query(`SELECT id, category, notifier, target, created_at FROM broadcast_routes WHERE category = ? ORDER BY id`, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := []BroadcastRoute{}
+	for rows.Next() {
+		var route BroadcastRoute
+		if err := rows.Scan(&route.ID, &route.Category, &route.Notifier, &route.Target, &route.CreatedAt); err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, rows.Err()
+}
+
+// formatBroadcastMessage renders the chat message: title, start time, rough location and link
+func formatBroadcastMessage(eventType string, e *Event) string {
+	var b strings.Builder
+	switch eventType {
+	case WebhookEventCancelled:
+		b.WriteString("❌ Cancelled: ")
+	default:
+		b.WriteString("📢 New event: ")
+	}
+	b.WriteString(html.UnescapeString(e.Title))
+
+	if start, err := parseDateTime(e.StartTime); err == nil {
+		b.WriteString("\n📅 " + start.UTC().Format("Mon 2 Jan 2006, 15:04 MST"))
+	}
+
+	// Only the place name or ~1km-rounded coordinates; the street address stays on the event page
+	if e.LocationName != "" {
+		b.WriteString("\n📍 " + html.UnescapeString(e.LocationName))
+	} else {
+		b.WriteString(fmt.Sprintf("\n📍 %.2f, %.2f", e.Latitude, e.Longitude))
+	}
+
+	if e.Slug != "" {
+		b.WriteString("\n🔗 " + publicEventURL(e.Slug))
+	}
+	return b.String()
+}
+
+// adminListBroadcastRoutes returns all category routes (GET /api/admin/broadcast-routes)
+func adminListBroadcastRoutes(c *gin.Context) {
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx, `SELECT id, category, notifier, target, created_at FROM broadcast_routes ORDER BY category, id`)
+	if err != nil {
+		log.Printf("❌ Failed to query broadcast routes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve broadcast routes"})
+		return
+	}
+	defer rows.Close()
+
+	routes := []BroadcastRoute{}
+	for rows.Next() {
+		var route BroadcastRoute
+		if err := rows.Scan(&route.ID, &route.Category, &route.Notifier, &route.Target, &route.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning broadcast route: %v", err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+
+	configured := []string{}
+	for name := range notifiers {
+		configured = append(configured, name)
+	}
+	c.JSON(http.StatusOK, gin.H{"routes": routes, "configured_notifiers": configured})
+}
+
+// adminCreateBroadcastRoute routes a category to a chat (POST /api/admin/broadcast-routes)
+func adminCreateBroadcastRoute(c *gin.Context) {
+	ctx := c.Request.Context()
+	var route BroadcastRoute
+	if err := c.ShouldBindJSON(&route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category, notifier and target are required"})
+		return
+	}
+	if _, ok := CategoryNames[route.Category]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown category"})
+		return
+	}
+	if route.Notifier != NotifierTelegram && route.Notifier != NotifierMatrix {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "notifier must be telegram or matrix"})
+		return
+	}
+	route.Target = strings.TrimSpace(route.Target)
+
+	result, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO broadcast_routes (category, notifier, target) VALUES (?, ?, ?)`,
+		route.Category, route.Notifier, route.Target)
+	if err != nil {
+		log.Printf("❌ Failed to create broadcast route: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create broadcast route"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Route already exists"})
+		return
+	}
+	id, _ := result.LastInsertId()
+	route.ID = int(id)
+	route.CreatedAt = time.Now()
+
+	if _, ok := notifiers[route.Notifier]; !ok {
+		log.Printf("⚠️  Broadcast route %d uses %s, which is not configured", route.ID, route.Notifier)
+	}
+	log.Printf("📣 Admin %d routed %s to %s (%s)", c.GetInt("user_id"), route.Category, route.Notifier, route.Target)
+	c.JSON(http.StatusCreated, route)
+}
+
+// adminDeleteBroadcastRoute removes a route (DELETE /api/admin/broadcast-routes/:id)
+func adminDeleteBroadcastRoute(c *gin.Context) {
+	ctx := c.Request.Context()
+	result, err := db.ExecContext(ctx, `DELETE FROM broadcast_routes WHERE id = ?`, c.Param("id"))
+	if err != nil {
+		log.Printf("❌ Failed to delete broadcast route: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete broadcast route"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Route deleted"})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// stubTelegram records sendMessage calls; chats listed in failing get a 500
+func stubTelegram(t *testing.T, failing ...string) (*[]telegramMessage, *sync.Mutex) {
+	var mu sync.Mutex
+	messages := []telegramMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/botTEST-TOKEN/sendMessage", r.URL.Path)
+		var msg telegramMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		mu.Lock()
+		messages = append(messages, msg)
+		mu.Unlock()
+		for _, chat := range failing {
+			if msg.ChatID == chat {
+				http.Error(w, `{"ok":false,"description":"chat not found"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	original := notifiers
+	notifiers = map[string]Notifier{
+		NotifierTelegram: &telegramNotifier{apiURL: server.URL, token: "TEST-TOKEN", client: server.Client()},
+	}
+	t.Cleanup(func() { notifiers = original })
+	return &messages, &mu
+}
+
+func TestEventBroadcasts(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.BaseURL = "https://veidly.com" })
+	messages, mu := stubTelegram(t, "-100999")
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/broadcast-routes", adminListBroadcastRoutes)
+	admin.POST("/broadcast-routes", adminCreateBroadcastRoute)
+	admin.POST("/events/bulk", adminBulkEvents)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	w := doJSON(router, "POST", "/api/admin/broadcast-routes", adminToken, gin.H{"category": "sports_fitness", "notifier": "telegram", "target": "-100123"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	start := time.Date(2030, 5, 4, 18, 30, 0, 0, time.UTC)
+	createEventIn := func(category, title string) Event {
+		w := doJSON(router, "POST", "/api/events", adminToken, gin.H{
+			"title": title, "description": "Weekly evening run along the river",
+			"category": category, "latitude": 52.2297, "longitude": 21.0122, "location_name": "Bulwary Wiślane",
+			"start_time": start.Format(time.RFC3339), "creator_name": "Admin",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true, "long_range": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		pendingBroadcasts.Wait()
+		return event
+	}
+
+	var run Event
+	t.Run("Routed category is announced", func(t *testing.T) {
+		run = createEventIn("sports_fitness", "Riverside Run")
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, *messages, 1)
+		msg := (*messages)[0]
+		assert.Equal(t, "-100123", msg.ChatID)
+		assert.Contains(t, msg.Text, "New event: Riverside Run")
+		assert.Contains(t, msg.Text, "Sat 4 May 2030, 18:30 UTC")
+		assert.Contains(t, msg.Text, "Bulwary Wiślane")
+		assert.Contains(t, msg.Text, "https://veidly.com/event/"+run.Slug)
+	})
+
+	t.Run("Unrouted category is not announced", func(t *testing.T) {
+		createEventIn("food_dining", "Pierogi Tasting")
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, *messages, 1)
+	})
+
+	t.Run("Failed sends don't affect the organizer", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/broadcast-routes", adminToken, gin.H{"category": "sports_fitness", "notifier": "telegram", "target": "-100999"})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		createEventIn("sports_fitness", "Morning Yoga")
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, *messages, 3)
+	})
+
+	t.Run("Cancellation is announced", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/events/bulk", adminToken, gin.H{"ids": []int{run.ID}, "action": "cancel"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		pendingBroadcasts.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		last := (*messages)[len(*messages)-1]
+		assert.Contains(t, last.Text, "Cancelled: Riverside Run")
+	})
+
+	t.Run("Route validation", func(t *testing.T) {
+		for _, payload := range []gin.H{
+			{"category": "unknown", "notifier": "telegram", "target": "-1"},
+			{"category": "sports_fitness", "notifier": "irc", "target": "#veidly"},
+			{"category": "sports_fitness", "notifier": "telegram"},
+		} {
+			w := doJSON(router, "POST", "/api/admin/broadcast-routes", adminToken, payload)
+			assert.Equal(t, http.StatusBadRequest, w.Code, payload)
+		}
+		w := doJSON(router, "POST", "/api/admin/broadcast-routes", adminToken, gin.H{"category": "sports_fitness", "notifier": "telegram", "target": "-100123"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestBroadcastSkipsNonPublicEvents(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	messages, mu := stubTelegram(t)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createTestEvent(t, testDB, organizerID, "Members Only")
+	_, err := testDB.Exec(`UPDATE events SET allow_unregistered_users = 0 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO broadcast_routes (category, notifier, target) VALUES ('social_drinks', 'telegram', '-1')`)
+	require.NoError(t, err)
+
+	broadcastEvent(WebhookEventCreated, int(eventID))
+	pendingBroadcasts.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Empty(t, *messages)
+}
+
+func TestSeedBroadcastRoutes(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	seedBroadcastRoutes(NotifierMatrix, "sports_fitness:!abc:matrix.org, parents_kids:!def:matrix.org,broken,")
+	seedBroadcastRoutes(NotifierMatrix, "sports_fitness:!abc:matrix.org")
+
+	routes, err := broadcastRoutesFor("sports_fitness")
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "!abc:matrix.org", routes[0].Target)
+
+	routes, err = broadcastRoutesFor("parents_kids")
+	require.NoError(t, err)
+	assert.Len(t, routes, 1)
+}
+
+func TestMatrixNotifier(t *testing.T) {
+	var path, auth string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		path, auth = r.URL.EscapedPath(), r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"event_id":"$1"}`))
+	}))
+	defer server.Close()
+
+	n := &matrixNotifier{homeserverURL: server.URL, accessToken: "secret", client: server.Client()}
+	require.NoError(t, n.Send(context.Background(), "!room:matrix.org", "hello"))
+	assert.Contains(t, path, "/_matrix/client/v3/rooms/%21room:matrix.org/send/m.room.message/")
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, map[string]string{"msgtype": "m.text", "body": "hello"}, body)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCodeEventStarted is returned when joining or leaving after start_time (plus JOIN_GRACE_PERIOD)
+const ErrCodeEventStarted = "EVENT_STARTED"
+
+// ErrCodeCapacityTooLow is returned when an update leaves fewer spots than people already joined
+const ErrCodeCapacityTooLow = "CAPACITY_TOO_LOW"
+
+// ErrInvalidReservedSpots rejects reservations outside 0..max_participants; unlimited events have nothing to reserve
+var ErrInvalidReservedSpots = errors.New("reserved_spots must be between 0 and max_participants (and 0 when participants are unlimited)")
+
+// ValidateReservedSpots checks the organizer's reserved spots against the event's capacity
+func ValidateReservedSpots(event *Event) error {
+	if event.ReservedSpots < 0 || (event.ReservedSpots > 0 && event.ReservedSpots > event.MaxParticipants) {
+		return ErrInvalidReservedSpots
+	}
+	return nil
+}
+
+// checkCapacityFits rejects a max_participants and reserved_spots pair that leaves no room for the
+// participantCount people who already joined (0 means unlimited, as in spotsLeft)
+func checkCapacityFits(maxParticipants, reservedSpots, participantCount int) error {
+	if maxParticipants > 0 && participantCount+reservedSpots > maxParticipants {
+		return fmt.Errorf("%d people already joined: max_participants must be at least %d with %d reserved spots",
+			participantCount, participantCount+reservedSpots, reservedSpots)
+	}
+	return nil
+}
+
+// joinWindowClosed reports whether it's too late to join or leave an event starting at startTime.
+// Unparseable start times leave the window open rather than locking people out on bad data.
+func joinWindowClosed(startTime string, now time.Time) bool {
+	start, err := parseDateTime(startTime)
+	if err != nil {
+		return false
+	}
+	return now.After(start.Add(appConfig.JoinGracePeriod))
+}
+
+// applyCapacityFields fills the derived spots_left, is_full and join_closed fields; reserved spots are taken
+func applyCapacityFields(e *Event) {
+	now := time.Now()
+	e.SpotsLeft = spotsLeft(e.MaxParticipants, e.ParticipantCount+e.ReservedSpots)
+	e.IsFull = e.SpotsLeft != nil && *e.SpotsLeft == 0
+	e.JoinClosed = e.Cancelled || e.IsFull || joinWindowClosed(e.StartTime, now) || joinDeadlinePassed(e.JoinDeadline, now)
+}
+
+// AdminAddParticipantRequest names the user an admin adds to an event
+type AdminAddParticipantRequest struct {
+	UserID int `json:"user_id" binding:"required"`
+}
+
+// adminAddParticipant adds a user to an event regardless of start time, capacity or the user's
+// upcoming-event cap (POST /api/admin/events/:id/participants)
+func adminAddParticipant(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req AdminAddParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	log.Printf("➕ POST /api/admin/events/%d/participants - Admin %d adding user %d", eventID, c.GetInt("user_id"), req.UserID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	var eventExists, userExists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM events WHERE id = ?), EXISTS(SELECT 1 FROM users WHERE id = ?)
+	`, eventID, req.UserID).Scan(&eventExists, &userExists)
+	if err != nil {
+		log.Printf("❌ Error checking event and user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+	if !eventExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if !userExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, req.UserID)
+	if err != nil {
+		log.Printf("❌ Error adding participant: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is already a participant"})
+		return
+	}
+	if err := adjustParticipantCount(tx, eventID, 1); err != nil {
+		log.Printf("❌ Error updating participant count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+	if err := dropInterest(tx, eventID, req.UserID); err != nil {
+		log.Printf("❌ Error clearing interest: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
+		return
+	}
+
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookParticipantJoined, eventID, req.UserID)
+	log.Printf("✅ Admin added user %d to event %d", req.UserID, eventID)
+	c.JSON(http.StatusCreated, gin.H{"message": "Participant added"})
+}
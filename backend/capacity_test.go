@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCapacityFields(t *testing.T) {
+	upcoming := time.Now().Add(time.Hour).Format(time.RFC3339)
+	started := time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	for name, tc := range map[string]struct {
+		event      Event
+		spotsLeft  *int
+		isFull     bool
+		joinClosed bool
+	}{
+		"unlimited":       {Event{StartTime: upcoming, ParticipantCount: 40}, nil, false, false},
+		"spots left":      {Event{StartTime: upcoming, MaxParticipants: 5, ParticipantCount: 3}, spotsLeft(5, 3), false, false},
+		"full":            {Event{StartTime: upcoming, MaxParticipants: 3, ParticipantCount: 3}, spotsLeft(3, 3), true, true},
+		"started":         {Event{StartTime: started}, nil, false, true},
+		"cancelled":       {Event{StartTime: upcoming, Cancelled: true}, nil, false, true},
+		"bad start_time":  {Event{StartTime: "whenever"}, nil, false, false},
+		"over capacity":   {Event{StartTime: upcoming, MaxParticipants: 2, ParticipantCount: 4}, spotsLeft(2, 2), true, true},
+		"reserved":        {Event{StartTime: upcoming, MaxParticipants: 5, ParticipantCount: 2, ReservedSpots: 2}, spotsLeft(5, 4), false, false},
+		"reserved full":   {Event{StartTime: upcoming, MaxParticipants: 4, ParticipantCount: 2, ReservedSpots: 2}, spotsLeft(4, 4), true, true},
+		"unlimited ended": {Event{StartTime: time.Now().Add(-48 * time.Hour).Format(time.RFC3339)}, nil, false, true},
+	} {
+		e := tc.event
+		applyCapacityFields(&e)
+		assert.Equal(t, tc.spotsLeft, e.SpotsLeft, name)
+		assert.Equal(t, tc.isFull, e.IsFull, name)
+		assert.Equal(t, tc.joinClosed, e.JoinClosed, name)
+	}
+}
+
+func TestJoinClosesAtStart(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.POST("/events/:id/participants", adminAddParticipant)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	startedID := createPastEvent(t, testDB, organizerID, "started", time.Now().Add(-10*time.Minute), nil)
+	leavingID := createPastEvent(t, testDB, organizerID, "leaving", time.Now().Add(-10*time.Minute), nil)
+	addParticipant(t, testDB, leavingID, userID)
+
+	joinPath := fmt.Sprintf("/api/events/%d/join", startedID)
+	leavePath := fmt.Sprintf("/api/events/%d/leave", leavingID)
+	assertStarted := func(w *httptest.ResponseRecorder) {
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, ErrCodeEventStarted, resp["code"])
+	}
+
+	t.Run("Join and leave after start rejected", func(t *testing.T) {
+		assertStarted(doJSON(router, "POST", joinPath, userToken, nil))
+		assertStarted(doJSON(router, "DELETE", leavePath, userToken, nil))
+		assertStarted(doJSON(router, "POST", joinPath, adminToken, nil))
+	})
+
+	t.Run("Derived fields on the event", func(t *testing.T) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", startedID), userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Contains(t, event, "spots_left")
+		assert.Nil(t, event["spots_left"], "unlimited capacity")
+		assert.Equal(t, false, event["is_full"])
+		assert.Equal(t, true, event["join_closed"])
+	})
+
+	t.Run("Grace period honored", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.JoinGracePeriod = 15 * time.Minute })
+		assert.Equal(t, http.StatusOK, doJSON(router, "POST", joinPath, userToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", leavePath, userToken, nil).Code)
+	})
+
+	t.Run("Admins can force-add", func(t *testing.T) {
+		strangerID := createTestUser(t, testDB, "stranger@example.com", "Stranger", "password123", false)
+		path := fmt.Sprintf("/api/admin/events/%d/participants", startedID)
+		assert.Equal(t, http.StatusCreated, doJSON(router, "POST", path, adminToken, gin.H{"user_id": strangerID}).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", path, adminToken, gin.H{"user_id": strangerID}).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", path, adminToken, gin.H{"user_id": 9999}).Code)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "POST", path, userToken, gin.H{"user_id": userID}).Code)
+
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT participant_count FROM events WHERE id = ?`, startedID).Scan(&count))
+		assert.Equal(t, 2, count)
+	})
+}
+
+func TestReservedSpots(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	seq := 0
+	newUser := func() string {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, "User", "password123", false)
+		userToken, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return userToken
+	}
+	payload := func(maxParticipants, reserved int) gin.H {
+		return gin.H{
+			"title": "Picnic by the lake", "description": "Bring a blanket and something to share",
+			"category": "food_dining", "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+			"max_participants": maxParticipants, "reserved_spots": reserved,
+		}
+	}
+	errorCode := func(w *httptest.ResponseRecorder) interface{} {
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return resp["code"]
+	}
+	getEventJSON := func(id int) Event {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", id), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+
+	w := doJSON(router, "POST", "/api/events", token, payload(4, 2))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	eventPath := fmt.Sprintf("/api/events/%d", created.ID)
+
+	t.Run("Reservations take spots from joiners", func(t *testing.T) {
+		event := getEventJSON(created.ID)
+		assert.Equal(t, 4, event.MaxParticipants)
+		assert.Equal(t, 2, event.ReservedSpots)
+		assert.Equal(t, spotsLeft(2, 0), event.SpotsLeft)
+
+		assert.Equal(t, http.StatusOK, doJSON(router, "POST", eventPath+"/join", newUser(), nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "POST", eventPath+"/join", newUser(), nil).Code)
+		w := doJSON(router, "POST", eventPath+"/join", newUser(), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, ErrCodeEventFull, errorCode(w))
+
+		event = getEventJSON(created.ID)
+		assert.Equal(t, 2, event.ParticipantCount)
+		assert.Equal(t, spotsLeft(1, 1), event.SpotsLeft)
+		assert.True(t, event.IsFull)
+	})
+
+	t.Run("Updates can't push out participants", func(t *testing.T) {
+		// Two joined and two are reserved: the event is exactly full
+		w := doJSON(router, "PUT", eventPath, token, payload(4, 3))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, ErrCodeCapacityTooLow, errorCode(w))
+		w = doJSON(router, "PUT", eventPath, token, payload(3, 2))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, ErrCodeCapacityTooLow, errorCode(w))
+		w = doJSON(router, "PUT", eventPath, token, payload(1, 0))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "fewer spots than participants")
+		assert.Equal(t, ErrCodeCapacityTooLow, errorCode(w))
+
+		// Releasing a reservation opens a spot again
+		require.Equal(t, http.StatusOK, doJSON(router, "PUT", eventPath, token, payload(4, 1)).Code)
+		assert.Equal(t, spotsLeft(1, 0), getEventJSON(created.ID).SpotsLeft)
+		assert.Equal(t, http.StatusOK, doJSON(router, "POST", eventPath+"/join", newUser(), nil).Code)
+		assert.True(t, getEventJSON(created.ID).IsFull)
+		require.Equal(t, http.StatusOK, doJSON(router, "PUT", eventPath, token, payload(0, 0)).Code, "unlimited always fits")
+	})
+
+	t.Run("Reservations must fit the capacity", func(t *testing.T) {
+		for _, tc := range []struct{ max, reserved int }{{4, 5}, {4, -1}, {0, 2}} {
+			w := doJSON(router, "POST", "/api/events", token, payload(tc.max, tc.reserved))
+			assert.Equal(t, http.StatusBadRequest, w.Code, "max %d reserved %d: %s", tc.max, tc.reserved, w.Body.String())
+			w = doJSON(router, "PUT", eventPath, token, payload(tc.max, tc.reserved))
+			assert.Equal(t, http.StatusBadRequest, w.Code, "max %d reserved %d: %s", tc.max, tc.reserved, w.Body.String())
+		}
+		// Every spot may be reserved
+		allReserved := payload(4, 4)
+		allReserved["title"] = "Family picnic"
+		assert.Equal(t, http.StatusCreated, doJSON(router, "POST", "/api/events", token, allReserved).Code)
+	})
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	checkinCodeLength    = 8
+	checkinCodeTTL       = 5 * time.Minute
+	checkinOpensBefore   = time.Hour // Check-in opens this long before start_time and closes at the end
+	checkinAttempts      = 10        // Per user per checkinAttemptWindow
+	checkinAttemptWindow = 15 * time.Minute
+)
+
+// checkinCodeAlphabet leaves out characters that are easily confused when read off a screen (0/O, 1/I/L)
+const checkinCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// checkinLimiter caps check-in attempts per user so codes can't be brute forced
+var checkinLimiter = newRateLimiter("checkin", checkinAttempts, checkinAttemptWindow)
+
+// CheckinRequest is a participant's check-in with the code shown by the organizer
+type CheckinRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// generateCheckinCode returns a random code of checkinCodeLength characters
+func generateCheckinCode() (string, error) {
+	var code strings.Builder
+	limit := big.NewInt(int64(len(checkinCodeAlphabet)))
+	for i := 0; i < checkinCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(checkinCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// hashCheckinCode normalizes and hashes a check-in code for storage/lookup
+func hashCheckinCode(code string) string {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkinWindow returns when check-in opens and closes for an event
+func checkinWindow(startTime, endTime string) (opens, closes time.Time, err error) {
+	start, err := parseDateTime(startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	closes, err = eventEndedAt(startTime, endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start.Add(-checkinOpensBefore), closes, nil
+}
+
+// loadCheckinEvent fetches what check-in needs to know about an event
+func loadCheckinEvent(eventID int) (creatorID int, opens, closes time.Time, cancelled bool, err error) {
+	var startTime string
+	var endTime sql.NullString
+	err = db.QueryRow(`
+		SELECT user_id, start_time, end_time, cancelled_at IS NOT NULL FROM events WHERE id = ?
+	`, eventID).Scan(&creatorID, &startTime, &endTime, &cancelled)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, false, err
+	}
+	opens, closes, err = checkinWindow(startTime, endTime.String)
+	return creatorID, opens, closes, cancelled, err
+}
+
+// createCheckinCode issues a new short-lived check-in code (POST /api/events/:id/checkin-code)
+// Organizer only, and only while check-in is open. Earlier codes stay valid until they expire,
+// so participants typing an old code from the screen aren't turned away mid-rotation.
+func createCheckinCode(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	log.Printf("🎫 POST /api/events/%d/checkin-code - User %d requesting check-in code", eventID, userID)
+
+	creatorID, opens, closes, cancelled, err := loadCheckinEvent(eventID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create check-in code"})
+		return
+	}
+
+	if creatorID != userID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can create check-in codes"})
+		return
+	}
+	if cancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Event has been cancelled"})
+		return
+	}
+
+	now := time.Now()
+	if now.Before(opens) || now.After(closes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in opens one hour before the event starts and closes when it ends"})
+		return
+	}
+
+	code, err := generateCheckinCode()
+	if err != nil {
+		log.Printf("❌ Error generating check-in code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create check-in code"})
+		return
+	}
+
+	expiresAt := now.Add(checkinCodeTTL)
+	if expiresAt.After(closes) {
+		expiresAt = closes
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM event_checkin_codes WHERE event_id = ? AND expires_at < ?`, eventID, now.UTC()); err != nil {
+		log.Printf("⚠️  Failed to prune expired check-in codes: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO event_checkin_codes (event_id, code_hash, expires_at) VALUES (?, ?, ?)
+	`, eventID, hashCheckinCode(code), expiresAt.UTC())
+	if err != nil {
+		log.Printf("❌ Error storing check-in code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create check-in code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": code, "expires_at": expiresAt})
+}
+
+// checkInToEvent marks the current participant as attended using the organizer's code (POST /api/events/:id/checkin)
+// Checking in twice is a no-op success
+func checkInToEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	log.Printf("🎫 POST /api/events/%d/checkin - User %d checking in", eventID, userID)
+
+	if !checkinLimiter.allow("user:" + strconv.Itoa(userID)) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many check-in attempts. Please try again later."})
+		return
+	}
+
+	var req CheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in code is required"})
+		return
+	}
+
+	_, opens, closes, cancelled, err := loadCheckinEvent(eventID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+
+	var checkedInAt sql.NullTime
+	err = db.QueryRowContext(ctx, `
+		SELECT checked_in_at FROM event_participants WHERE event_id = ? AND user_id = ?
+	`, eventID, userID).Scan(&checkedInAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only participants can check in"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading participation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+	if checkedInAt.Valid {
+		c.JSON(http.StatusOK, gin.H{"message": "Already checked in", "checked_in_at": checkedInAt.Time})
+		return
+	}
+
+	now := time.Now()
+	if cancelled || now.Before(opens) || now.After(closes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in is not open for this event"})
+		return
+	}
+
+	var expiresAt time.Time
+	err = db.QueryRowContext(ctx, `
+		SELECT expires_at FROM event_checkin_codes
+		WHERE event_id = ? AND code_hash = ?
+		ORDER BY expires_at DESC LIMIT 1
+	`, eventID, hashCheckinCode(req.Code)).Scan(&expiresAt)
+	if err == sql.ErrNoRows || (err == nil && now.After(expiresAt)) {
+		log.Printf("❌ User %d entered an invalid or expired check-in code for event %d", userID, eventID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired check-in code"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error looking up check-in code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+
+	// An organizer's no-show mark is overridden, the participant proved they were there
+	_, err = db.ExecContext(ctx, `
+		UPDATE event_participants
+		SET checked_in_at = ?, attendance = ?, attendance_marked_at = ?, attendance_disputed = 0
+		WHERE event_id = ? AND user_id = ? AND checked_in_at IS NULL
+	`, now, AttendanceAttended, now, eventID, userID)
+	if err != nil {
+		log.Printf("❌ Error checking in: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+
+	log.Printf("✅ User %d checked in to event %d", userID, eventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Checked in", "checked_in_at": now})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCheckinRouter(t *testing.T) *gin.Engine {
+	previous := checkinLimiter
+	checkinLimiter = newRateLimiter("checkin", checkinAttempts, checkinAttemptWindow)
+	t.Cleanup(func() {
+		checkinLimiter.Shutdown()
+		checkinLimiter = previous
+	})
+
+	router := gin.New()
+	router.GET("/api/events/:id/participants", optionalAuthMiddleware(), getEventParticipants)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/checkin-code", createCheckinCode)
+	protected.POST("/events/:id/checkin", checkInToEvent)
+	return router
+}
+
+func TestCheckinCode(t *testing.T) {
+	code, err := generateCheckinCode()
+	require.NoError(t, err)
+	assert.Len(t, code, checkinCodeLength)
+	assert.Equal(t, hashCheckinCode(code), hashCheckinCode(" "+code[:4]+"-"+code[4:]+" "))
+}
+
+func TestEventCheckin(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	router := setupCheckinRouter(t)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com"})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com"})
+
+	// Started 30 minutes ago, default duration keeps it open
+	eventID := createPastEvent(t, testDB, organizerID, "checkin-event", time.Now().Add(-30*time.Minute), nil)
+	otherEventID := createPastEvent(t, testDB, organizerID, "other-event", time.Now().Add(-30*time.Minute), nil)
+	addParticipant(t, testDB, eventID, aliceID)
+	addParticipant(t, testDB, eventID, bobID)
+	addParticipant(t, testDB, otherEventID, aliceID)
+
+	newCode := func(eventID int64) string {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/checkin-code", eventID), organizerToken, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var body struct {
+			Code      string    `json:"code"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.WithinDuration(t, time.Now().Add(checkinCodeTTL), body.ExpiresAt, time.Minute)
+		return body.Code
+	}
+	checkIn := func(eventID int64, token, code string) int {
+		return doJSON(router, "POST", fmt.Sprintf("/api/events/%d/checkin", eventID), token, gin.H{"code": code}).Code
+	}
+	checkedInAt := func(userID int64) *time.Time {
+		var at *time.Time
+		require.NoError(t, testDB.QueryRow(`SELECT checked_in_at FROM event_participants WHERE event_id = ? AND user_id = ?`, eventID, userID).Scan(&at))
+		return at
+	}
+
+	t.Run("Only the organizer gets codes", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/checkin-code", eventID), aliceToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Wrong event's code rejected", func(t *testing.T) {
+		otherCode := newCode(otherEventID)
+		assert.Equal(t, http.StatusBadRequest, checkIn(eventID, aliceToken, otherCode))
+		assert.Nil(t, checkedInAt(aliceID))
+	})
+
+	t.Run("Expired code rejected", func(t *testing.T) {
+		code := newCode(eventID)
+		_, err := testDB.Exec(`UPDATE event_checkin_codes SET expires_at = ? WHERE event_id = ?`, time.Now().Add(-time.Second).UTC(), eventID)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, checkIn(eventID, aliceToken, code))
+		assert.Nil(t, checkedInAt(aliceID))
+	})
+
+	t.Run("Valid code checks in once", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE event_participants SET attendance = ? WHERE event_id = ? AND user_id = ?`, AttendanceNoShow, eventID, aliceID)
+		require.NoError(t, err)
+
+		code := newCode(eventID)
+		newCode(eventID) // Rotating doesn't invalidate the code people are still typing
+		assert.Equal(t, http.StatusOK, checkIn(eventID, aliceToken, code))
+		first := checkedInAt(aliceID)
+		require.NotNil(t, first)
+
+		var attendance string
+		require.NoError(t, testDB.QueryRow(`SELECT attendance FROM event_participants WHERE event_id = ? AND user_id = ?`, eventID, aliceID).Scan(&attendance))
+		assert.Equal(t, AttendanceAttended, attendance)
+
+		assert.Equal(t, http.StatusOK, checkIn(eventID, aliceToken, code), "double check-in is idempotent")
+		assert.Equal(t, first.Unix(), checkedInAt(aliceID).Unix())
+	})
+
+	t.Run("Non-participants can't check in", func(t *testing.T) {
+		strangerID := createTestUser(t, testDB, "stranger@example.com", "Stranger", "password123", false)
+		strangerToken, _ := generateToken(User{ID: int(strangerID), Email: "stranger@example.com"})
+		assert.Equal(t, http.StatusForbidden, checkIn(eventID, strangerToken, newCode(eventID)))
+	})
+
+	t.Run("Organizer sees who checked in", func(t *testing.T) {
+		status := func(token string) map[string]*bool {
+			w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/participants", eventID), token, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var participants struct {
+				Items []ParticipantView `json:"items"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &participants))
+			byName := map[string]*bool{}
+			for _, p := range participants.Items {
+				byName[p.Name] = p.CheckedIn
+			}
+			return byName
+		}
+
+		organizerView := status(organizerToken)
+		require.NotNil(t, organizerView["Alice"])
+		require.NotNil(t, organizerView["Bob"])
+		assert.True(t, *organizerView["Alice"])
+		assert.False(t, *organizerView["Bob"])
+
+		assert.Nil(t, status(bobToken)["Alice"], "participants don't see check-in status")
+	})
+
+	t.Run("Attempts are rate limited", func(t *testing.T) {
+		limited := 0
+		for i := 0; i < checkinAttempts+1; i++ {
+			if checkIn(eventID, bobToken, "WRONGCODE") == http.StatusTooManyRequests {
+				limited++
+			}
+		}
+		assert.Equal(t, 1, limited)
+	})
+}
+
+func TestEventCheckinWindow(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	router := setupCheckinRouter(t)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+
+	for name, tc := range map[string]struct {
+		start time.Time
+		end   time.Time
+		want  int
+	}{
+		"too early":           {time.Now().Add(2 * time.Hour), time.Now().Add(4 * time.Hour), http.StatusBadRequest},
+		"an hour before":      {time.Now().Add(50 * time.Minute), time.Now().Add(3 * time.Hour), http.StatusCreated},
+		"after the event end": {time.Now().Add(-3 * time.Hour), time.Now().Add(-time.Hour), http.StatusBadRequest},
+	} {
+		end := tc.end
+		eventID := createPastEvent(t, testDB, organizerID, name, tc.start, &end)
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/checkin-code", eventID), organizerToken, nil)
+		assert.Equal(t, tc.want, w.Code, name)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Comment flood protection codes
+const (
+	ErrCodeRateLimited      = "RATE_LIMITED"
+	ErrCodeDuplicateComment = "DUPLICATE_COMMENT"
+)
+
+const (
+	// commentLimitFactor multiplies CommentsPerMinute and CommentsPerDay for the organizer and admins
+	commentLimitFactor = 5
+	// duplicateCommentWindow is how long the same text can't be posted again on an event
+	duplicateCommentWindow = 10 * time.Minute
+)
+
+// CommentRateError reports a comment refused because its author posted too many on the event recently
+type CommentRateError struct {
+	Limit     int
+	Window    string // "minute" or "day"
+	AllowedAt time.Time
+}
+
+func (e *CommentRateError) Error() string {
+	return fmt.Sprintf("You can post at most %d comments per %s on an event. Please wait a moment", e.Limit, e.Window)
+}
+
+func (e *CommentRateError) respond(c *gin.Context) {
+	retryAfter := int(time.Until(e.AllowedAt).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": e.Error(), "code": ErrCodeRateLimited, "limit": e.Limit, "window": e.Window})
+}
+
+// errDuplicateComment is returned by checkCommentFlood for text the author just posted on the event
+var errDuplicateComment = errors.New("You already posted this comment")
+
+// checkCommentFlood returns a *CommentRateError when userID is over the per-event comment limits,
+// or errDuplicateComment when they posted the same text on the event within duplicateCommentWindow.
+// Counting the stored comments (deleted ones included) keeps the limits across restarts and instances.
+func checkCommentFlood(ctx context.Context, eventID, userID int, text string, elevated bool) error {
+	for _, limit := range []struct {
+		window   string
+		period   time.Duration
+		modifier string
+		max      int
+	}{
+		{"minute", time.Minute, "-1 minute", appConfig.CommentsPerMinute},
+		{"day", 24 * time.Hour, "-1 day", appConfig.CommentsPerDay},
+	} {
+		if elevated {
+			limit.max *= commentLimitFactor
+		}
+		var count int
+		var oldest sql.NullString
+		err := db.QueryRowContext(ctx, `
+			SELECT COUNT(*), MIN(created_at) FROM event_comments
+			WHERE event_id = ? AND user_id = ? AND created_at > datetime('now', ?)
+		`, eventID, userID, limit.modifier).Scan(&count, &oldest)
+		if err != nil {
+			return err
+		}
+		if count >= limit.max {
+			// Posting is possible again once the oldest comment in the window leaves it
+			allowedAt := time.Now().Add(limit.period)
+			if t, err := time.Parse("2006-01-02 15:04:05", oldest.String); err == nil {
+				allowedAt = t.Add(limit.period)
+			}
+			return &CommentRateError{Limit: limit.max, Window: limit.window, AllowedAt: allowedAt}
+		}
+	}
+
+	var duplicate bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM event_comments
+		               WHERE event_id = ? AND user_id = ? AND comment = ? AND is_deleted = 0 AND created_at > datetime('now', ?))
+	`, eventID, userID, text, fmt.Sprintf("-%d seconds", int(duplicateCommentWindow.Seconds()))).Scan(&duplicate)
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		return errDuplicateComment
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentFloodProtection(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.CommentsPerMinute = 3
+		cfg.CommentsPerDay = 10
+	})
+
+	router := gin.New()
+	router.POST("/api/events/:id/comments", authMiddleware(), createEventComment)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	seq := 0
+	newEvent := func() int64 {
+		seq++
+		return createTestEvent(t, testDB, organizerID, fmt.Sprintf("Event %d", seq))
+	}
+	newParticipant := func(eventIDs ...int64) (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, fmt.Sprintf("User %d", seq), "password123", false)
+		for _, eventID := range eventIDs {
+			_, err := testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, id)
+			require.NoError(t, err)
+		}
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	post := func(token string, eventID int64, text string) *jsonResponse {
+		t.Helper()
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/comments", eventID), token, gin.H{"comment": text})
+		resp := &jsonResponse{code: w.Code, retryAfter: w.Header().Get("Retry-After")}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp.body), w.Body.String())
+		return resp
+	}
+
+	t.Run("A burst is cut off after the per-minute limit", func(t *testing.T) {
+		eventID := newEvent()
+		_, token := newParticipant(eventID)
+		for i := 1; i <= 3; i++ {
+			resp := post(token, eventID, fmt.Sprintf("Message %d", i))
+			require.Equal(t, http.StatusCreated, resp.code, resp.body)
+		}
+
+		resp := post(token, eventID, "Message 4")
+		assert.Equal(t, http.StatusTooManyRequests, resp.code)
+		assert.Equal(t, ErrCodeRateLimited, resp.body["code"])
+		assert.Equal(t, "minute", resp.body["window"])
+		retryAfter, err := strconv.Atoi(resp.retryAfter)
+		require.NoError(t, err, resp.retryAfter)
+		assert.True(t, retryAfter >= 1 && retryAfter <= 61, retryAfter)
+		assert.Equal(t, 3, countRows(t, testDB, `SELECT COUNT(*) FROM event_comments WHERE event_id = ?`, eventID))
+	})
+
+	t.Run("Older comments count towards the daily limit", func(t *testing.T) {
+		eventID := newEvent()
+		userID, token := newParticipant(eventID)
+		for i := 0; i < 10; i++ {
+			_, err := testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment, is_deleted, created_at)
+				VALUES (?, ?, ?, ?, datetime('now', '-2 hours'))`, eventID, userID, fmt.Sprintf("Earlier %d", i), i%2)
+			require.NoError(t, err)
+		}
+
+		resp := post(token, eventID, "One more")
+		assert.Equal(t, http.StatusTooManyRequests, resp.code)
+		assert.Equal(t, "day", resp.body["window"])
+		assert.NotEmpty(t, resp.retryAfter)
+
+		// Comments older than a day no longer count
+		_, err := testDB.Exec(`UPDATE event_comments SET created_at = datetime('now', '-25 hours') WHERE event_id = ?`, eventID)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, post(token, eventID, "One more").code)
+	})
+
+	t.Run("The same text can't be posted twice in a row", func(t *testing.T) {
+		eventID := newEvent()
+		userID, token := newParticipant(eventID)
+		require.Equal(t, http.StatusCreated, post(token, eventID, "Who brings the ball?").code)
+
+		resp := post(token, eventID, "Who brings the ball?")
+		assert.Equal(t, http.StatusConflict, resp.code)
+		assert.Equal(t, ErrCodeDuplicateComment, resp.body["code"])
+
+		// Another participant may say the same, and so may the author once it's old or deleted
+		_, other := newParticipant(eventID)
+		assert.Equal(t, http.StatusCreated, post(other, eventID, "Who brings the ball?").code)
+		_, err := testDB.Exec(`UPDATE event_comments SET created_at = datetime('now', '-11 minutes') WHERE user_id = ?`, userID)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, post(token, eventID, "Who brings the ball?").code)
+		_, err = testDB.Exec(`UPDATE event_comments SET is_deleted = 1 WHERE user_id = ?`, userID)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, post(token, eventID, "Who brings the ball?").code)
+	})
+
+	t.Run("Limits are per event", func(t *testing.T) {
+		first, second := newEvent(), newEvent()
+		_, token := newParticipant(first, second)
+		for i := 1; i <= 3; i++ {
+			require.Equal(t, http.StatusCreated, post(token, first, fmt.Sprintf("First %d", i)).code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, post(token, first, "First 4").code)
+		assert.Equal(t, http.StatusCreated, post(token, second, "Second 1").code)
+	})
+
+	t.Run("The organizer gets a higher limit", func(t *testing.T) {
+		eventID := newEvent()
+		for i := 1; i <= 3*commentLimitFactor; i++ {
+			resp := post(organizerToken, eventID, fmt.Sprintf("Update %d", i))
+			require.Equal(t, http.StatusCreated, resp.code, resp.body)
+		}
+		resp := post(organizerToken, eventID, "One update too many")
+		assert.Equal(t, http.StatusTooManyRequests, resp.code)
+		assert.EqualValues(t, 3*commentLimitFactor, resp.body["limit"])
+	})
+}
+
+// jsonResponse is what TestCommentFloodProtection checks of an answer
+type jsonResponse struct {
+	code       int
+	retryAfter string
+	body       map[string]interface{}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// commentLongPollMax caps ?wait= on GET /api/events/:id/comments/updates
+	commentLongPollMax = 25 * time.Second
+	// commentLongPollTimeout is the route's deadline: the wait plus time for the queries around it
+	commentLongPollTimeout = commentLongPollMax + 5*time.Second
+	commentUpdatesLimit    = 200
+)
+
+// CommentUpdates is the body of GET /api/events/:id/comments/updates. Comments are in revision
+// order; deleted ones are tombstones with only id, event_id, is_deleted and revision.
+type CommentUpdates struct {
+	Comments []EventComment `json:"comments"`
+	LastID   int            `json:"last_id"` // pass back as since_id
+	HasMore  bool           `json:"has_more"`
+}
+
+// commentNotifier wakes long-polling comment readers after a write. commentWatchers only reaches
+// requests held by the same process, which is why the long poll is skipped with several instances;
+// a shared pub/sub implementation would lift that.
+type commentNotifier interface {
+	Wait(eventID int) <-chan struct{}
+	Notify(eventID int)
+}
+
+// commentWatchers lets long-polling requests wait for a write to an event's comments. Each event
+// has one channel that Notify closes, waking every waiter at once; the next Wait gets a new one.
+type commentWatchers struct {
+	mu      sync.Mutex
+	waiting map[int]chan struct{}
+}
+
+var commentUpdates commentNotifier = &commentWatchers{waiting: make(map[int]chan struct{})}
+
+// Wait returns a channel that is closed by the next Notify for eventID
+func (w *commentWatchers) Wait(eventID int) <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch, ok := w.waiting[eventID]
+	if !ok {
+		ch = make(chan struct{})
+		w.waiting[eventID] = ch
+	}
+	return ch
+}
+
+// Notify wakes everyone waiting on eventID's comments
+func (w *commentWatchers) Notify(eventID int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ch, ok := w.waiting[eventID]; ok {
+		close(ch)
+		delete(w.waiting, eventID)
+	}
+}
+
+// getEventCommentUpdates returns the comments posted, edited or deleted after revision since_id
+// (GET /api/events/:id/comments/updates?since_id=&wait=). With wait=N (seconds, up to 25) and
+// nothing new, the request is held until a comment is written or the wait runs out. Several
+// instances don't share the in-process signal, so there it answers immediately instead.
+// Same access rules as getEventComments.
+func getEventCommentUpdates(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	viewerID := userID.(int)
+
+	sinceID, err := strconv.Atoi(c.DefaultQuery("since_id", "0"))
+	if err != nil || sinceID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since_id must be a non-negative integer"})
+		return
+	}
+	wait := time.Duration(0)
+	if raw := c.Query("wait"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wait must be a non-negative number of seconds"})
+			return
+		}
+		wait = min(time.Duration(seconds)*time.Second, commentLongPollMax)
+	}
+	if appConfig.multiInstance() {
+		wait = 0
+	}
+
+	organizerView, ok := authorizeCommentReader(c, eventID, viewerID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	// Subscribe before querying so a comment written in between still wakes us
+	woken := commentUpdates.Wait(eventID)
+	updates, err := loadCommentUpdates(ctx, eventID, viewerID, sinceID, organizerView)
+	if err == nil && len(updates.Comments) == 0 && wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-woken:
+			updates, err = loadCommentUpdates(ctx, eventID, viewerID, sinceID, organizerView)
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching comment updates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+
+	// Same read receipt as getEventComments: the newest live comment now delivered
+	newest := 0
+	for _, comment := range updates.Comments {
+		if !comment.IsDeleted && comment.ID > newest {
+			newest = comment.ID
+		}
+	}
+	if newest > 0 {
+		if err := markCommentsRead(viewerID, eventID, newest); err != nil {
+			log.Printf("⚠️  Failed to update comment read state: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, updates)
+}
+
+// loadCommentUpdates reads the comments of eventID changed after revision sinceID that viewerID may
+// see: everything published, their own held comments, and tombstones for deletions. Authors are
+// named as aliasedName shows them to the viewer.
+func loadCommentUpdates(ctx context.Context, eventID, viewerID, sinceID int, organizerView bool) (CommentUpdates, error) {
+	updates := CommentUpdates{Comments: []EventComment{}, LastID: sinceID}
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.id, c.event_id, c.user_id, c.comment, c.created_at, c.updated_at, u.name,
+		       c.pending_review, c.is_deleted, c.revision, COALESCE(ep.display_alias, '')
+		FROM event_comments c
+		JOIN users u ON c.user_id = u.id
+		LEFT JOIN event_participants ep ON ep.event_id = c.event_id AND ep.user_id = c.user_id
+		WHERE c.event_id = ? AND c.revision > ? AND (c.pending_review = 0 OR c.user_id = ? OR c.is_deleted = 1)
+		ORDER BY c.revision ASC
+		LIMIT ?
+	`, eventID, sinceID, viewerID, commentUpdatesLimit+1)
+	if err != nil {
+		return updates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var comment EventComment
+		var updatedAt sql.NullTime
+		var isDeleted bool
+		var alias string
+		if err := rows.Scan(&comment.ID, &comment.EventID, &comment.UserID, &comment.Comment, &comment.CreatedAt,
+			&updatedAt, &comment.UserName, &comment.PendingReview, nullable(&isDeleted), &comment.Revision, &alias); err != nil {
+			return updates, err
+		}
+		if len(updates.Comments) == commentUpdatesLimit {
+			updates.HasMore = true
+			break
+		}
+		if isDeleted {
+			comment = EventComment{ID: comment.ID, EventID: comment.EventID, IsDeleted: true, Revision: comment.Revision}
+		} else {
+			if updatedAt.Valid {
+				comment.UpdatedAt = updatedAt.Time
+			}
+			comment.IsOwn = comment.UserID == viewerID
+			comment.UserName = aliasedName(comment.UserName, alias, organizerView)
+			if aliasHidesAccount(alias, organizerView, comment.IsOwn) {
+				comment.UserID = 0
+			}
+		}
+		updates.Comments = append(updates.Comments, comment)
+		updates.LastID = comment.Revision
+	}
+	return updates, rows.Err()
+}
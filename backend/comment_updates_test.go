@@ -0,0 +1,212 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCommentUpdates(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/events/:id/comments", getEventComments)
+	protected.GET("/events/:id/comments/updates", getEventCommentUpdates)
+	protected.POST("/events/:id/comments", createEventComment)
+	protected.PUT("/comments/:id", updateEventComment)
+	protected.DELETE("/comments/:id", deleteEventComment)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Oscar", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	outsiderToken, _ := generateToken(User{ID: int(outsiderID), Email: "outsider@example.com", EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Pub Quiz")
+	_, err := testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, aliceID)
+	require.NoError(t, err)
+	base := fmt.Sprintf("/api/events/%d", eventID)
+
+	post := func(token, text string) EventComment {
+		t.Helper()
+		w := doJSON(router, "POST", base+"/comments", token, gin.H{"comment": text})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var comment EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+		return comment
+	}
+	updates := func(token string, query string) CommentUpdates {
+		t.Helper()
+		w := doJSON(router, "GET", base+"/comments/updates?"+query, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body CommentUpdates
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	first := post(organizerToken, "Teams of four")
+	second := post(aliceToken, "Can I bring a friend?")
+	third := post(organizerToken, "Starts at 8")
+	assert.Less(t, first.Revision, second.Revision)
+
+	t.Run("Only comments after since_id are returned", func(t *testing.T) {
+		body := updates(aliceToken, fmt.Sprintf("since_id=%d", first.Revision))
+		require.Len(t, body.Comments, 2)
+		assert.Equal(t, second.ID, body.Comments[0].ID)
+		assert.Equal(t, "Starts at 8", body.Comments[1].Comment)
+		assert.True(t, body.Comments[0].IsOwn)
+		assert.Equal(t, third.Revision, body.LastID)
+
+		body = updates(aliceToken, fmt.Sprintf("since_id=%d", body.LastID))
+		assert.Empty(t, body.Comments)
+		assert.Equal(t, third.Revision, body.LastID, "an empty answer keeps the cursor")
+	})
+
+	t.Run("Edits and deletions arrive as updates of older comments", func(t *testing.T) {
+		cursor := third.Revision
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/comments/%d", first.ID), organizerToken, gin.H{"comment": "Teams of three"})
+		require.Equal(t, http.StatusOK, w.Code)
+		w = doJSON(router, "DELETE", fmt.Sprintf("/api/comments/%d", second.ID), aliceToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		body := updates(organizerToken, fmt.Sprintf("since_id=%d", cursor))
+		require.Len(t, body.Comments, 2)
+		edited, deleted := body.Comments[0], body.Comments[1]
+		assert.Equal(t, first.ID, edited.ID)
+		assert.Equal(t, "Teams of three", edited.Comment)
+		assert.False(t, edited.UpdatedAt.IsZero())
+		assert.Equal(t, EventComment{ID: second.ID, EventID: int(eventID), IsDeleted: true, Revision: deleted.Revision}, deleted,
+			"tombstones carry no text or author")
+		assert.Greater(t, deleted.Revision, edited.Revision)
+		assert.Equal(t, deleted.Revision, body.LastID)
+
+		// The full list exposes revisions so a client can start polling from it
+		w = doJSON(router, "GET", base+"/comments", organizerToken, nil)
+		var list []EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list, 2)
+		assert.Equal(t, edited.Revision, list[0].Revision)
+	})
+
+	t.Run("Same access rules as the comment list", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", base+"/comments/updates", outsiderToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/events/9999/comments/updates", aliceToken, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", base+"/comments/updates?since_id=-1", aliceToken, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", base+"/comments/updates?wait=soon", aliceToken, nil).Code)
+	})
+
+	t.Run("Long poll returns as soon as a comment arrives", func(t *testing.T) {
+		cursor := updates(aliceToken, "").LastID
+
+		done := make(chan *httptest.ResponseRecorder, 1)
+		started := time.Now()
+		go func() {
+			done <- doJSON(router, "GET", fmt.Sprintf("%s/comments/updates?since_id=%d&wait=20", base, cursor), aliceToken, nil)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		post(organizerToken, "Doors open at 7:30")
+
+		select {
+		case w := <-done:
+			require.Equal(t, http.StatusOK, w.Code)
+			var body CommentUpdates
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			require.Len(t, body.Comments, 1)
+			assert.Equal(t, "Doors open at 7:30", body.Comments[0].Comment)
+			assert.Less(t, time.Since(started), 5*time.Second)
+		case <-time.After(10 * time.Second):
+			t.Fatal("long poll did not return after a new comment")
+		}
+	})
+
+	t.Run("Long poll times out with an empty answer", func(t *testing.T) {
+		cursor := updates(aliceToken, "").LastID
+		started := time.Now()
+		body := updates(aliceToken, fmt.Sprintf("since_id=%d&wait=1", cursor))
+		assert.Empty(t, body.Comments)
+		assert.Equal(t, cursor, body.LastID)
+		assert.GreaterOrEqual(t, time.Since(started), time.Second)
+	})
+
+	t.Run("Several instances answer immediately", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.RedisURL = "redis://localhost:6379" })
+		cursor := updates(aliceToken, "").LastID
+		started := time.Now()
+		body := updates(aliceToken, fmt.Sprintf("since_id=%d&wait=25", cursor))
+		assert.Empty(t, body.Comments)
+		assert.Less(t, time.Since(started), time.Second)
+	})
+}
+
+// TestCommentLongPollOutlastsWriteTimeout answers a gzip long poll after the server's WriteTimeout
+// through the production router, where the compressing writer sits between the route and the
+// connection whose deadline it must extend
+func TestCommentLongPollOutlastsWriteTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits longer than the server's write timeout")
+	}
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.DisableCompression = false })
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	eventID := createTestEvent(t, testDB, organizerID, "Pub Quiz")
+
+	server := httptest.NewUnstartedServer(newVersionedTestRouter())
+	server.Config.WriteTimeout = serverWriteTimeout
+	server.Start()
+	defer server.Close()
+	request := func(method, path string, body string) *http.Request {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+organizerToken)
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	// Long enough to be compressed, arriving after the write timeout has passed
+	text := strings.TrimSpace(strings.Repeat("Bring a pen and a team name. ", 34))
+	answerAfter := serverWriteTimeout + 2*time.Second
+	go func() {
+		time.Sleep(answerAfter)
+		payload, _ := json.Marshal(gin.H{"comment": text})
+		if resp, err := server.Client().Do(request("POST", fmt.Sprintf("/api/events/%d/comments", eventID), string(payload))); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	poll := request("GET", fmt.Sprintf("/api/events/%d/comments/updates?wait=%d", eventID, int(commentLongPollMax.Seconds())), "")
+	poll.Header.Set("Accept-Encoding", "gzip")
+	started := time.Now()
+	resp, err := server.Client().Do(poll)
+	require.NoError(t, err, "the connection must not be cut at the write timeout")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.GreaterOrEqual(t, time.Since(started), answerAfter)
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	var body CommentUpdates
+	require.NoError(t, json.NewDecoder(reader).Decode(&body), "the response must arrive intact")
+	require.Len(t, body.Comments, 1)
+	assert.Equal(t, text, body.Comments[0].Comment)
+}
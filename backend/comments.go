@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -10,9 +11,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// getEventComments retrieves all comments for an event (GET /api/events/:id/comments)
+// getEventComments retrieves a page of comments for an event (GET /api/events/:id/comments?before_id=&limit=)
 // Only accessible to event participants
 func getEventComments(c *gin.Context) {
+	ctx := c.Request.Context()
 	eventIDStr := c.Param("id")
 	eventID, err := strconv.Atoi(eventIDStr)
 	if err != nil {
@@ -28,41 +30,30 @@ func getEventComments(c *gin.Context) {
 
 	viewerID := userID.(int)
 
-	// Check if event exists and user is a participant or creator
-	var eventCreatorID int
-	var isParticipant bool
-	err = db.QueryRow(`
-		SELECT e.user_id,
-		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) as is_participant
-		FROM events e
-		WHERE e.id = ?
-	`, eventID, viewerID, eventID).Scan(&eventCreatorID, &isParticipant)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		return
-	}
-	if err != nil {
-		log.Printf("❌ Error checking event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+	organizerView, ok := authorizeCommentReader(c, eventID, viewerID)
+	if !ok {
 		return
 	}
 
-	// Only participants and creator can view comments
-	isCreator := eventCreatorID == viewerID
-	if !isParticipant && !isCreator {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only event participants can view comments"})
+	limit, beforeID, ok := parseCommentCursor(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before_id and limit must be positive integers"})
 		return
 	}
 
-	// Retrieve comments (excluding soft-deleted)
-	rows, err := db.Query(`
-		SELECT c.id, c.event_id, c.user_id, c.comment, c.created_at, c.updated_at, u.name
+	// Retrieve one page of comments (excluding soft-deleted, and others' held for review), newest first so the page is
+	// anchored at the cursor. Paging by id rather than offset keeps boundaries stable when
+	// comments are deleted between requests.
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.id, c.event_id, c.user_id, c.comment, c.created_at, c.updated_at, u.name, c.pending_review, c.revision,
+		       COALESCE(ep.display_alias, '')
 		FROM event_comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.event_id = ? AND c.is_deleted = 0
-		ORDER BY c.created_at ASC
-	`, eventID)
+		LEFT JOIN event_participants ep ON ep.event_id = c.event_id AND ep.user_id = c.user_id
+		WHERE c.event_id = ? AND c.is_deleted = 0 AND (c.pending_review = 0 OR c.user_id = ?) AND (? = 0 OR c.id < ?)
+		ORDER BY c.id DESC
+		LIMIT ?
+	`, eventID, viewerID, beforeID, beforeID, limit+1)
 
 	if err != nil {
 		log.Printf("❌ Error fetching comments: %v", err)
@@ -75,6 +66,7 @@ func getEventComments(c *gin.Context) {
 	for rows.Next() {
 		var comment EventComment
 		var updatedAt sql.NullTime
+		var alias string
 
 		err := rows.Scan(
 			&comment.ID,
@@ -84,11 +76,15 @@ func getEventComments(c *gin.Context) {
 			&comment.CreatedAt,
 			&updatedAt,
 			&comment.UserName,
+			&comment.PendingReview,
+			&comment.Revision,
+			&alias,
 		)
 		if err != nil {
 			log.Printf("❌ Error scanning comment: %v", err)
 			continue
 		}
+		comment.UserName = aliasedName(comment.UserName, alias, organizerView)
 
 		if updatedAt.Valid {
 			comment.UpdatedAt = updatedAt.Time
@@ -96,14 +92,30 @@ func getEventComments(c *gin.Context) {
 
 		// Mark if this comment belongs to the viewer
 		comment.IsOwn = comment.UserID == viewerID
+		if aliasHidesAccount(alias, organizerView, comment.IsOwn) {
+			comment.UserID = 0
+		}
 
 		comments = append(comments, comment)
 	}
 
+	hasMore := len(comments) > limit
+	if hasMore {
+		comments = comments[:limit]
+	}
+	// Pages are displayed oldest first
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+
 	if comments == nil {
 		comments = []EventComment{}
+	} else if err := markCommentsRead(viewerID, eventID, comments[len(comments)-1].ID); err != nil {
+		log.Printf("⚠️  Failed to update comment read state: %v", err)
 	}
 
+	// The body stays a plain array; older pages are fetched with before_id=<first id>
+	c.Header("X-Has-More", strconv.FormatBool(hasMore))
 	log.Printf("💬 User %d fetched %d comments for event %d", viewerID, len(comments), eventID)
 	c.JSON(http.StatusOK, comments)
 }
@@ -111,6 +123,7 @@ func getEventComments(c *gin.Context) {
 // createEventComment creates a new comment on an event (POST /api/events/:id/comments)
 // Only accessible to event participants
 func createEventComment(c *gin.Context) {
+	ctx := c.Request.Context()
 	eventIDStr := c.Param("id")
 	eventID, err := strconv.Atoi(eventIDStr)
 	if err != nil {
@@ -130,8 +143,8 @@ func createEventComment(c *gin.Context) {
 	var eventCreatorID int
 	var isParticipant bool
 	var commentsEnabled bool
-	err = db.QueryRow(`
-		SELECT e.user_id, e.comments_enabled,
+	err = db.QueryRowContext(ctx, `
+		SELECT e.user_id, COALESCE(e.comments_enabled, 1),
 		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) as is_participant
 		FROM events e
 		WHERE e.id = ?
@@ -167,11 +180,38 @@ func createEventComment(c *gin.Context) {
 		return
 	}
 
+	// Clients render comments as plain text; markup is dropped rather than escaped
+	if req.Comment = stripHTMLTags(req.Comment); req.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+
+	// Participants get per-event rate limits; the organizer and admins run the discussion, so theirs are higher
+	var rateErr *CommentRateError
+	if err := checkCommentFlood(ctx, eventID, viewerID, req.Comment, isCreator || c.GetBool("is_admin")); errors.As(err, &rateErr) {
+		log.Printf("⚠️  User %d hit the per-%s comment limit on event %d", viewerID, rateErr.Window, eventID)
+		rateErr.respond(c)
+		return
+	} else if errors.Is(err, errDuplicateComment) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": ErrCodeDuplicateComment})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error checking comment limits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+
+	// Flagged comments are only shown to their author until an admin reviews them
+	moderation, ok := moderateCommentText(c, req.Comment)
+	if !ok {
+		return
+	}
+
 	// Insert comment
-	result, err := db.Exec(`
-		INSERT INTO event_comments (event_id, user_id, comment)
-		VALUES (?, ?, ?)
-	`, eventID, viewerID, req.Comment)
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO event_comments (event_id, user_id, comment, pending_review, revision)
+		VALUES (?, ?, ?, ?, `+nextCommentRevision+`)
+	`, eventID, viewerID, req.Comment, moderation.Flagged())
 
 	if err != nil {
 		log.Printf("❌ Error creating comment: %v", err)
@@ -180,13 +220,20 @@ func createEventComment(c *gin.Context) {
 	}
 
 	commentID, _ := result.LastInsertId()
+	commentUpdates.Notify(eventID)
+	recordActivity(db, viewerID, ActivityCommented, eventID)
+	if moderation.Flagged() {
+		flagForReview(db, "comment", int(commentID), viewerID, moderation)
+	}
 
 	// Retrieve the created comment with user info
 	var comment EventComment
-	err = db.QueryRow(`
-		SELECT c.id, c.event_id, c.user_id, c.comment, c.created_at, u.name
+	var alias string
+	err = db.QueryRowContext(ctx, `
+		SELECT c.id, c.event_id, c.user_id, c.comment, c.created_at, u.name, c.revision, COALESCE(ep.display_alias, '')
 		FROM event_comments c
 		JOIN users u ON c.user_id = u.id
+		LEFT JOIN event_participants ep ON ep.event_id = c.event_id AND ep.user_id = c.user_id
 		WHERE c.id = ?
 	`, commentID).Scan(
 		&comment.ID,
@@ -195,6 +242,8 @@ func createEventComment(c *gin.Context) {
 		&comment.Comment,
 		&comment.CreatedAt,
 		&comment.UserName,
+		&comment.Revision,
+		&alias,
 	)
 
 	if err != nil {
@@ -204,6 +253,8 @@ func createEventComment(c *gin.Context) {
 	}
 
 	comment.IsOwn = true
+	comment.PendingReview = moderation.Flagged()
+	comment.UserName = aliasedName(comment.UserName, alias, isCreator || c.GetBool("is_admin"))
 
 	log.Printf("💬 User %d created comment on event %d", viewerID, eventID)
 	c.JSON(http.StatusCreated, comment)
@@ -212,6 +263,7 @@ func createEventComment(c *gin.Context) {
 // updateEventComment updates a comment (PUT /api/comments/:id)
 // Only the comment author can update
 func updateEventComment(c *gin.Context) {
+	ctx := c.Request.Context()
 	commentIDStr := c.Param("id")
 	commentID, err := strconv.Atoi(commentIDStr)
 	if err != nil {
@@ -228,8 +280,8 @@ func updateEventComment(c *gin.Context) {
 	viewerID := userID.(int)
 
 	// Check if comment exists and belongs to user
-	var commentUserID int
-	err = db.QueryRow(`SELECT user_id FROM event_comments WHERE id = ? AND is_deleted = 0`, commentID).Scan(&commentUserID)
+	var commentUserID, eventID int
+	err = db.QueryRowContext(ctx, `SELECT user_id, event_id FROM event_comments WHERE id = ? AND is_deleted = 0`, commentID).Scan(&commentUserID, &eventID)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
@@ -253,13 +305,22 @@ func updateEventComment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
 		return
 	}
+	if req.Comment = stripHTMLTags(req.Comment); req.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
 
-	// Update comment
-	_, err = db.Exec(`
+	moderation, ok := moderateCommentText(c, req.Comment)
+	if !ok {
+		return
+	}
+
+	// Update comment; an edit that trips a flag rule hides it again
+	_, err = db.ExecContext(ctx, `
 		UPDATE event_comments
-		SET comment = ?, updated_at = ?
+		SET comment = ?, updated_at = ?, pending_review = MAX(pending_review, ?), revision = `+nextCommentRevision+`
 		WHERE id = ?
-	`, req.Comment, time.Now(), commentID)
+	`, req.Comment, time.Now(), moderation.Flagged(), commentID)
 
 	if err != nil {
 		log.Printf("❌ Error updating comment: %v", err)
@@ -267,6 +328,11 @@ func updateEventComment(c *gin.Context) {
 		return
 	}
 
+	commentUpdates.Notify(eventID)
+	if moderation.Flagged() {
+		flagForReview(db, "comment", commentID, viewerID, moderation)
+	}
+
 	log.Printf("✏️  User %d updated comment %d", viewerID, commentID)
 	c.JSON(http.StatusOK, gin.H{"message": "Comment updated successfully"})
 }
@@ -274,6 +340,7 @@ func updateEventComment(c *gin.Context) {
 // deleteEventComment soft-deletes a comment (DELETE /api/comments/:id)
 // Only the comment author can delete
 func deleteEventComment(c *gin.Context) {
+	ctx := c.Request.Context()
 	commentIDStr := c.Param("id")
 	commentID, err := strconv.Atoi(commentIDStr)
 	if err != nil {
@@ -290,8 +357,8 @@ func deleteEventComment(c *gin.Context) {
 	viewerID := userID.(int)
 
 	// Check if comment exists and belongs to user
-	var commentUserID int
-	err = db.QueryRow(`SELECT user_id FROM event_comments WHERE id = ? AND is_deleted = 0`, commentID).Scan(&commentUserID)
+	var commentUserID, eventID int
+	err = db.QueryRowContext(ctx, `SELECT user_id, event_id FROM event_comments WHERE id = ? AND is_deleted = 0`, commentID).Scan(&commentUserID, &eventID)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
@@ -310,9 +377,9 @@ func deleteEventComment(c *gin.Context) {
 	}
 
 	// Soft delete comment
-	_, err = db.Exec(`
+	_, err = db.ExecContext(ctx, `
 		UPDATE event_comments
-		SET is_deleted = 1
+		SET is_deleted = 1, revision = `+nextCommentRevision+`
 		WHERE id = ?
 	`, commentID)
 
@@ -322,6 +389,113 @@ func deleteEventComment(c *gin.Context) {
 		return
 	}
 
+	commentUpdates.Notify(eventID)
 	log.Printf("🗑️  User %d deleted comment %d", viewerID, commentID)
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
+
+// nextCommentRevision numbers every comment write (post, edit, delete, moderation decision) from
+// one sequence, so GET /api/events/:id/comments/updates can return what changed after a revision.
+// SQLite serializes writers, so two writes never get the same number.
+const nextCommentRevision = `(SELECT COALESCE(MAX(revision), 0) + 1 FROM event_comments)`
+
+// ErrCodeParticipantsOnly refuses a comment thread to a signed-in viewer who hasn't joined the event
+const ErrCodeParticipantsOnly = "PARTICIPANTS_ONLY"
+
+// authorizeCommentReader checks that the viewer may see the event and is a participant or its
+// creator, writing the error response otherwise. Events the viewer can't see answer 404 like
+// getEvent; for visible ones, non-participants get 403 PARTICIPANTS_ONLY. organizerView tells
+// whether the viewer sees authors' profile names next to their aliases (the creator and admins).
+func authorizeCommentReader(c *gin.Context, eventID, viewerID int) (organizerView, ok bool) {
+	var e Event
+	var isParticipant bool
+	err := db.QueryRowContext(c.Request.Context(), `
+		SELECT e.user_id, COALESCE(e.allow_unregistered_users, 1), e.require_verified_to_view, e.hidden_pending_review, e.published = 0,
+		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) as is_participant
+		FROM events e
+		WHERE e.id = ?
+	`, eventID, viewerID, eventID).Scan(&e.UserID, &e.AllowUnregisteredUsers, nullable(&e.RequireVerifiedToView),
+		nullable(&e.HiddenPendingReview), &e.Draft, &isParticipant)
+
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return false, false
+	}
+	if err != nil {
+		log.Printf("❌ Error checking event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return false, false
+	}
+	if r := CheckEventViewPermission(&e, viewerID, c.GetBool("email_verified"), c.GetBool("is_admin")); r != nil {
+		respondRestricted(c, r)
+		return false, false
+	}
+
+	// Only participants and creator can view comments
+	if !isParticipant && e.UserID != viewerID {
+		respondRestricted(c, &Restriction{Code: ErrCodeParticipantsOnly, Message: "Only event participants can view comments"})
+		return false, false
+	}
+	return e.UserID == viewerID || c.GetBool("is_admin"), true
+}
+
+// Comment page sizes for GET /api/events/:id/comments
+const (
+	commentPageDefault = 50
+	commentPageMax     = 100
+)
+
+// parseCommentCursor reads the before_id/limit query parameters; beforeID is 0 for the newest page
+func parseCommentCursor(c *gin.Context) (limit, beforeID int, ok bool) {
+	limit = commentPageDefault
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		limit = min(n, commentPageMax)
+	}
+	if raw := c.Query("before_id"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		beforeID = n
+	}
+	return limit, beforeID, true
+}
+
+// markCommentsRead moves the user's read marker for an event forward to commentID (never back,
+// so paging through older comments doesn't resurrect unread ones)
+func markCommentsRead(userID, eventID, commentID int) error {
+	_, err := db.Exec(`
+		INSERT INTO comment_read_state (user_id, event_id, last_read_comment_id) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, event_id) DO UPDATE SET
+			last_read_comment_id = MAX(last_read_comment_id, excluded.last_read_comment_id),
+			updated_at = CURRENT_TIMESTAMP
+	`, userID, eventID, commentID)
+	return err
+}
+
+// attachUnreadCount sets e.UnreadCount to the comments from others the viewer hasn't fetched yet.
+// Only participants and the creator can read comments, so everyone else gets no count.
+func attachUnreadCount(e *Event, viewerID int) {
+	if viewerID == 0 {
+		return
+	}
+	var isMember bool
+	var unread int
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) OR ? = ?,
+		       (SELECT COUNT(*) FROM event_comments c
+		        WHERE c.event_id = ? AND c.is_deleted = 0 AND c.pending_review = 0 AND c.user_id != ?
+		        AND c.id > COALESCE((SELECT last_read_comment_id FROM comment_read_state WHERE user_id = ? AND event_id = ?), 0))
+	`, e.ID, viewerID, e.UserID, viewerID, e.ID, viewerID, viewerID, e.ID).Scan(&isMember, &unread)
+	if err != nil {
+		log.Printf("⚠️  Failed to count unread comments for event %d: %v", e.ID, err)
+		return
+	}
+	if isMember {
+		e.UnreadCount = &unread
+	}
+}
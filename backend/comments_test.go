@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -373,3 +374,130 @@ func TestCommentsSoftDelete(t *testing.T) {
 		assert.Equal(t, "Active comment", comments[0].Comment)
 	})
 }
+
+func TestEventCommentsPagination(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id/comments", authMiddleware(), getEventComments)
+
+	creatorID := createTestUser(t, testDB, "creator@example.com", "Creator", "password123", false)
+	token, _ := generateToken(User{ID: int(creatorID), Email: "creator@example.com"})
+	eventID := createTestEvent(t, testDB, creatorID, "Busy Thread")
+	ids := make([]int, 7)
+	for i := range ids {
+		result, err := testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, ?)`, eventID, creatorID, fmt.Sprintf("Comment %d", i+1))
+		require.NoError(t, err)
+		id, _ := result.LastInsertId()
+		ids[i] = int(id)
+	}
+	// A soft-deleted comment right at a page boundary
+	_, err := testDB.Exec(`UPDATE event_comments SET is_deleted = 1 WHERE id = ?`, ids[3])
+	require.NoError(t, err)
+
+	page := func(query string) ([]int, string) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments%s", eventID, query), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var comments []EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comments))
+		got := []int{}
+		for _, comment := range comments {
+			got = append(got, comment.ID)
+		}
+		return got, w.Header().Get("X-Has-More")
+	}
+
+	got, more := page("?limit=3")
+	assert.Equal(t, []int{ids[4], ids[5], ids[6]}, got, "newest page, oldest first")
+	assert.Equal(t, "true", more)
+
+	got, more = page(fmt.Sprintf("?limit=3&before_id=%d", got[0]))
+	assert.Equal(t, []int{ids[0], ids[1], ids[2]}, got, "deleted comments don't take up a slot")
+	assert.Equal(t, "false", more)
+
+	got, more = page(fmt.Sprintf("?limit=2&before_id=%d", ids[3]))
+	assert.Equal(t, []int{ids[1], ids[2]}, got, "a deleted comment works as a cursor")
+	assert.Equal(t, "true", more)
+
+	got, more = page(fmt.Sprintf("?before_id=%d", ids[0]))
+	assert.Empty(t, got)
+	assert.Equal(t, "false", more)
+
+	got, more = page("")
+	assert.Len(t, got, 6, "default limit covers the whole thread")
+	assert.Equal(t, "false", more)
+
+	for _, query := range []string{"?limit=0", "?limit=abc", "?before_id=-1"} {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments%s", eventID, query), token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestEventCommentsUnreadCount(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/events/:id/comments", authMiddleware(), getEventComments)
+
+	creatorID := createTestUser(t, testDB, "creator@example.com", "Creator", "password123", false)
+	participantID := createTestUser(t, testDB, "participant@example.com", "Participant", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Outsider", "password123", false)
+	participantToken, _ := generateToken(User{ID: int(participantID), Email: "participant@example.com", EmailVerified: true})
+	outsiderToken, _ := generateToken(User{ID: int(outsiderID), Email: "outsider@example.com", EmailVerified: true})
+	eventID := createTestEvent(t, testDB, creatorID, "Chatty Event")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'chatty-event' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	addParticipant(t, testDB, eventID, participantID)
+
+	comment := func(userID int64) int {
+		result, err := testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'Hi')`, eventID, userID)
+		require.NoError(t, err)
+		id, _ := result.LastInsertId()
+		return int(id)
+	}
+	unread := func(path, token string) *int {
+		w := doJSON(router, "GET", path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event.UnreadCount
+	}
+	eventPath := fmt.Sprintf("/api/events/%d", eventID)
+
+	for i := 0; i < 3; i++ {
+		comment(creatorID)
+	}
+	comment(participantID) // own comments never count
+
+	require.NotNil(t, unread(eventPath, participantToken))
+	assert.Equal(t, 3, *unread(eventPath, participantToken))
+	assert.Equal(t, 3, *unread("/api/public/events/chatty-event", participantToken))
+	assert.Nil(t, unread(eventPath, outsiderToken), "non-participants get no count")
+	assert.Nil(t, unread(eventPath, ""))
+
+	// Fetching an older page only marks that page as read
+	latest := comment(creatorID)
+	w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments?limit=1&before_id=%d", eventID, latest-3), participantToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, *unread(eventPath, participantToken))
+
+	w = doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments", eventID), participantToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, *unread(eventPath, participantToken))
+
+	// Re-reading an old page doesn't move the marker back
+	w = doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments?limit=1&before_id=%d", eventID, latest-3), participantToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, *unread(eventPath, participantToken))
+
+	comment(creatorID)
+	assert.Equal(t, 1, *unread(eventPath, participantToken))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minCompressSize is the smallest body worth compressing; below it gzip framing outweighs the savings
+const minCompressSize = 1024
+
+// incompressibleTypes are content types that are already compressed
+var incompressibleTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/pdf",
+	"application/octet-stream",
+	"font/woff", "font/woff2",
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// CompressionMiddleware gzips responses for clients that accept it. Bodies under 1KB and
+// already-compressed content types are sent as-is.
+func CompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.Request.Header.Get("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			// Hand the original writer back so a recovered panic can still write its 500
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip (and doesn't refuse it with q=0)
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		if strings.ToLower(strings.TrimSpace(fields[0])) != "gzip" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); strings.HasPrefix(param, "q=") && err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isCompressibleType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range incompressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// gzipResponseWriter buffers the start of the body until it knows whether compression is worth it,
+// then either streams through a gzip.Writer or passes everything through unchanged
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	status    int
+	decided   bool
+	headerSet bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+		w.headerSet = true
+	}
+}
+
+// WriteHeaderNow is deferred until the body size is known (see finish)
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	w.headerSet = true
+}
+
+func (w *gzipResponseWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipResponseWriter) Written() bool {
+	return w.headerSet || w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.headerSet = true
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= minCompressSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the connection, so routes behind compression can still
+// extend their write deadline (see withDBTimeout)
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buf.Len() >= minCompressSize)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide sends the headers and the buffered body, switching to gzip when large is set and the
+// response is compressible
+func (w *gzipResponseWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if large && header.Get("Content-Encoding") == "" && isCompressibleType(header.Get("Content-Type")) &&
+		bodyAllowedForStatus(w.status) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// finish flushes whatever is still buffered once the handler chain returns
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		if !w.headerSet {
+			// Nothing was written; leave the response untouched so gin can fill in defaults
+			return
+		}
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
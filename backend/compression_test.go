@@ -0,0 +1,109 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCompressionRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(CompressionMiddleware())
+	router.GET("/large", func(c *gin.Context) {
+		events := make([]Event, 50)
+		for i := range events {
+			events[i] = Event{ID: i, Title: "Board games night", Description: strings.Repeat("Long description ", 20)}
+		}
+		c.JSON(http.StatusOK, events)
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 4096))
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": strings.Repeat("x", 2048)})
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	router := setupCompressionRouter()
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Large JSON is gzipped when accepted", func(t *testing.T) {
+		w := get("/large", "gzip, deflate, br")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		var events []Event
+		require.NoError(t, json.Unmarshal(body, &events))
+		assert.Len(t, events, 50)
+	})
+
+	t.Run("Identity encoding without Accept-Encoding", func(t *testing.T) {
+		w := get("/large", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		assert.Len(t, events, 50)
+	})
+
+	t.Run("gzip refused with q=0", func(t *testing.T) {
+		w := get("/large", "gzip;q=0, identity")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Small bodies are not compressed", func(t *testing.T) {
+		w := get("/small", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Already-compressed content types are skipped", func(t *testing.T) {
+		w := get("/image", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Len(t, w.Body.Bytes(), 4096)
+	})
+
+	t.Run("Status code is preserved", func(t *testing.T) {
+		w := get("/missing", "gzip")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Panics still produce a 500", func(t *testing.T) {
+		w := get("/panic", "gzip")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
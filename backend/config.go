@@ -0,0 +1,490 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config holds the server's tunable behavior. It is loaded once at startup by LoadConfig;
+// until then (and in tests) appConfig holds the defaults.
+type Config struct {
+	Environment        string
+	Port               string
+	BaseURL            string
+	CORSOrigins        []string
+	UseTLS             bool
+	TLSCert            string
+	TLSKey             string
+	DisableCompression bool
+
+	// When set, unversioned /api responses carry Deprecation and Sunset headers pointing at /api/v1
+	LegacyAPISunset time.Time
+
+	// Event listing
+	EventListWindowDays int // How far ahead GET /api/events looks
+	EventListLimit      int // Maximum events per listing response
+
+	// How many months ahead events may be scheduled (admins can override per event)
+	MaxEventLeadMonths int
+
+	MaxRequestBytes int64
+
+	// How long a request's database work may take before it fails with 503; exports get the longer one
+	DBTimeout       time.Duration
+	DBExportTimeout time.Duration
+
+	// SQLite has a single writer: DBBusyTimeout is how long a write waits for the lock before it
+	// fails with 503 DB_BUSY, DBMaxOpenConns bounds the connections competing for it
+	DBBusyTimeout  time.Duration
+	DBMaxOpenConns int
+
+	// Distinct pending reports that hide an event until an admin reviews it
+	ReportTakedownThreshold int
+
+	// Content moderation: links allowed per text field, and what happens when the link cap or the
+	// shouting heuristics trip (reject, flag or off). Banned terms are managed by admins in the DB.
+	ModerationMaxLinks       int
+	ModerationLinkAction     string
+	ModerationShoutingAction string
+
+	// Per-user caps on upcoming events (admins are exempt)
+	MaxUpcomingJoins   int
+	MaxUpcomingCreated int
+
+	// How similar (percent of shared character trigrams in title and description) a new event may
+	// be to one its organizer created within 1 km in the last 24 hours; 0 turns the check off
+	SimilarEventThreshold int
+
+	// Comments one user may post per event per minute and per day; organizers and admins get
+	// commentLimitFactor times as many
+	CommentsPerMinute int
+	CommentsPerDay    int
+
+	// Anti-abuse rules for new accounts (admins are exempt): how old an account must be to create
+	// or join events, how many events it may create in its first 24 hours, and whether its first
+	// event waits in the moderation queue. Zero values turn a rule off.
+	MinAccountAgeToCreate time.Duration
+	MinAccountAgeToJoin   time.Duration
+	NewAccountEventLimit  int
+	ReviewFirstEvent      bool
+
+	// How long after start_time participants can still join or leave (0 closes joins at the start)
+	JoinGracePeriod time.Duration
+
+	// Housekeeping: how often expired tokens are purged, and after how many months past events
+	// are anonymized (0 keeps them as they are)
+	CleanupInterval      time.Duration
+	EventRetentionMonths int
+
+	// Whether the startup integrity pass deletes rows whose foreign keys point at missing rows
+	// instead of only logging them
+	RepairOrphans bool
+
+	// Whether GET /api/public/stats rounds its counts down instead of publishing exact numbers
+	PublicStatsRounded bool
+
+	// Requests per IP
+	AuthRateLimit        int // per minute
+	APIRateLimit         int // per minute
+	SearchRateLimit      int // per minute
+	CreateEventRateLimit int // per hour
+
+	// Shared rate limit store; empty keeps counts in memory per instance
+	RedisURL string // secret (may carry a password)
+
+	SessionTTL            time.Duration
+	VerificationTokenTTL  time.Duration
+	PasswordResetTokenTTL time.Duration
+	PasswordHash          string // bcrypt or argon2id; existing hashes are upgraded on login
+	BcryptCost            int
+	allowWeakBcrypt       bool // GIN_MODE=test may use costs below 10 to keep test suites fast
+
+	AdminEmail    string
+	AdminPassword string // secret
+
+	MailgunDomain    string
+	MailgunAPIKey    string // secret
+	MailgunFromEmail string
+}
+
+var appConfig = DefaultConfig()
+
+// BCRYPT_COST bounds: below 10 is too weak to deploy, above 15 makes logins take seconds
+const (
+	minProductionBcryptCost = 10
+	maxBcryptCost           = 15
+)
+
+// DefaultConfig returns the configuration used when no environment overrides are set
+func DefaultConfig() *Config {
+	return &Config{
+		Port:                     "8080",
+		BaseURL:                  "http://localhost:5173",
+		EventListWindowDays:      30,
+		EventListLimit:           100,
+		MaxEventLeadMonths:       18,
+		MaxRequestBytes:          5 * 1024 * 1024,
+		DBTimeout:                5 * time.Second,
+		DBExportTimeout:          60 * time.Second,
+		DBBusyTimeout:            5 * time.Second,
+		DBMaxOpenConns:           10,
+		ReportTakedownThreshold:  5,
+		ModerationMaxLinks:       2,
+		ModerationLinkAction:     ModerationReject,
+		ModerationShoutingAction: ModerationFlag,
+		MaxUpcomingJoins:         10,
+		MaxUpcomingCreated:       20,
+		SimilarEventThreshold:    90,
+		CommentsPerMinute:        5,
+		CommentsPerDay:           100,
+		CleanupInterval:          time.Hour,
+		AuthRateLimit:            20,
+		APIRateLimit:             200,
+		SearchRateLimit:          50,
+		CreateEventRateLimit:     100,
+		SessionTTL:               24 * time.Hour,
+		VerificationTokenTTL:     24 * time.Hour,
+		PasswordResetTokenTTL:    time.Hour,
+		PasswordHash:             PasswordHashBcrypt,
+		BcryptCost:               14,
+		AdminEmail:               "admin@veidly.com",
+	}
+}
+
+// LoadConfig reads the configuration from the environment
+func LoadConfig() (*Config, error) {
+	return loadConfig(os.Getenv)
+}
+
+// loadConfig applies overrides from getenv on top of the defaults and validates the result.
+// All problems are reported together so a bad deploy can be fixed in one go.
+func loadConfig(getenv func(string) string) (*Config, error) {
+	cfg := DefaultConfig()
+	var problems []string
+
+	str := func(key string, dst *string) {
+		if v := strings.TrimSpace(getenv(key)); v != "" {
+			*dst = v
+		}
+	}
+	integer := func(key string, dst *int) {
+		v := strings.TrimSpace(getenv(key))
+		if v == "" {
+			return
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be an integer (got %q)", key, v))
+			return
+		}
+		*dst = n
+	}
+	duration := func(key string, dst *time.Duration) {
+		v := strings.TrimSpace(getenv(key))
+		if v == "" {
+			return
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a duration like 30m or 24h (got %q)", key, v))
+			return
+		}
+		*dst = d
+	}
+
+	str("ENVIRONMENT", &cfg.Environment)
+	str("PORT", &cfg.Port)
+	if strings.TrimSpace(getenv("PORT")) == "" {
+		str("SERVER_PORT", &cfg.Port)
+	}
+	str("BASE_URL", &cfg.BaseURL)
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	cfg.UseTLS = getenv("USE_TLS") == "true"
+	str("TLS_CERT", &cfg.TLSCert)
+	str("TLS_KEY", &cfg.TLSKey)
+	cfg.DisableCompression = getenv("DISABLE_COMPRESSION") == "true"
+	if v := strings.TrimSpace(getenv("LEGACY_API_SUNSET")); v != "" {
+		sunset, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("LEGACY_API_SUNSET must be a date like 2026-06-30 (got %q)", v))
+		} else {
+			cfg.LegacyAPISunset = sunset
+		}
+	}
+	for _, origin := range strings.Split(getenv("CORS_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.CORSOrigins = append(cfg.CORSOrigins, origin)
+		}
+	}
+
+	integer("EVENT_LIST_WINDOW_DAYS", &cfg.EventListWindowDays)
+	integer("EVENT_LIST_LIMIT", &cfg.EventListLimit)
+	integer("EVENT_MAX_LEAD_MONTHS", &cfg.MaxEventLeadMonths)
+	maxRequestBytes := int(cfg.MaxRequestBytes)
+	integer("MAX_REQUEST_BYTES", &maxRequestBytes)
+	cfg.MaxRequestBytes = int64(maxRequestBytes)
+	integer("REPORT_TAKEDOWN_THRESHOLD", &cfg.ReportTakedownThreshold)
+	integer("MODERATION_MAX_LINKS", &cfg.ModerationMaxLinks)
+	str("MODERATION_LINK_ACTION", &cfg.ModerationLinkAction)
+	str("MODERATION_SHOUTING_ACTION", &cfg.ModerationShoutingAction)
+	integer("MAX_UPCOMING_JOINS", &cfg.MaxUpcomingJoins)
+	integer("MAX_UPCOMING_CREATED", &cfg.MaxUpcomingCreated)
+	integer("SIMILAR_EVENT_THRESHOLD", &cfg.SimilarEventThreshold)
+	integer("COMMENT_LIMIT_PER_MINUTE", &cfg.CommentsPerMinute)
+	integer("COMMENT_LIMIT_PER_DAY", &cfg.CommentsPerDay)
+	duration("DB_TIMEOUT", &cfg.DBTimeout)
+	duration("DB_EXPORT_TIMEOUT", &cfg.DBExportTimeout)
+	duration("DB_BUSY_TIMEOUT", &cfg.DBBusyTimeout)
+	integer("DB_MAX_OPEN_CONNS", &cfg.DBMaxOpenConns)
+	duration("JOIN_GRACE_PERIOD", &cfg.JoinGracePeriod)
+	duration("MIN_ACCOUNT_AGE_TO_CREATE", &cfg.MinAccountAgeToCreate)
+	duration("MIN_ACCOUNT_AGE_TO_JOIN", &cfg.MinAccountAgeToJoin)
+	integer("NEW_ACCOUNT_EVENT_LIMIT", &cfg.NewAccountEventLimit)
+	cfg.ReviewFirstEvent = getenv("REVIEW_FIRST_EVENT") == "true"
+	duration("CLEANUP_INTERVAL", &cfg.CleanupInterval)
+	integer("EVENT_RETENTION_MONTHS", &cfg.EventRetentionMonths)
+	cfg.RepairOrphans = getenv("REPAIR_ORPHANS") == "true"
+	cfg.PublicStatsRounded = getenv("PUBLIC_STATS_ROUNDED") == "true"
+	integer("RATE_LIMIT_AUTH", &cfg.AuthRateLimit)
+	integer("RATE_LIMIT_API", &cfg.APIRateLimit)
+	integer("RATE_LIMIT_SEARCH", &cfg.SearchRateLimit)
+	integer("RATE_LIMIT_CREATE_EVENT", &cfg.CreateEventRateLimit)
+	str("REDIS_URL", &cfg.RedisURL)
+
+	duration("SESSION_TTL", &cfg.SessionTTL)
+	duration("VERIFICATION_TOKEN_TTL", &cfg.VerificationTokenTTL)
+	duration("PASSWORD_RESET_TOKEN_TTL", &cfg.PasswordResetTokenTTL)
+	str("PASSWORD_HASH", &cfg.PasswordHash)
+	integer("BCRYPT_COST", &cfg.BcryptCost)
+	cfg.allowWeakBcrypt = getenv("GIN_MODE") == "test"
+
+	str("ADMIN_EMAIL", &cfg.AdminEmail)
+	cfg.AdminPassword = getenv("ADMIN_PASSWORD")
+	str("MAILGUN_DOMAIN", &cfg.MailgunDomain)
+	str("MAILGUN_API_KEY", &cfg.MailgunAPIKey)
+	str("MAILGUN_FROM_EMAIL", &cfg.MailgunFromEmail)
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) validate() []string {
+	var problems []string
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"EVENT_LIST_WINDOW_DAYS", cfg.EventListWindowDays},
+		{"EVENT_LIST_LIMIT", cfg.EventListLimit},
+		{"EVENT_MAX_LEAD_MONTHS", cfg.MaxEventLeadMonths},
+		{"MAX_REQUEST_BYTES", int(cfg.MaxRequestBytes)},
+		{"DB_MAX_OPEN_CONNS", cfg.DBMaxOpenConns},
+		{"REPORT_TAKEDOWN_THRESHOLD", cfg.ReportTakedownThreshold},
+		{"MAX_UPCOMING_JOINS", cfg.MaxUpcomingJoins},
+		{"MAX_UPCOMING_CREATED", cfg.MaxUpcomingCreated},
+		{"COMMENT_LIMIT_PER_MINUTE", cfg.CommentsPerMinute},
+		{"COMMENT_LIMIT_PER_DAY", cfg.CommentsPerDay},
+		{"RATE_LIMIT_AUTH", cfg.AuthRateLimit},
+		{"RATE_LIMIT_API", cfg.APIRateLimit},
+		{"RATE_LIMIT_SEARCH", cfg.SearchRateLimit},
+		{"RATE_LIMIT_CREATE_EVENT", cfg.CreateEventRateLimit},
+	} {
+		if setting.value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive", setting.key))
+		}
+	}
+	if cfg.EventListLimit > 1000 {
+		problems = append(problems, "EVENT_LIST_LIMIT must be at most 1000")
+	}
+
+	for _, setting := range []struct {
+		key string
+		ttl time.Duration
+	}{
+		{"SESSION_TTL", cfg.SessionTTL},
+		{"VERIFICATION_TOKEN_TTL", cfg.VerificationTokenTTL},
+		{"PASSWORD_RESET_TOKEN_TTL", cfg.PasswordResetTokenTTL},
+		{"CLEANUP_INTERVAL", cfg.CleanupInterval},
+	} {
+		if setting.ttl < time.Minute {
+			problems = append(problems, fmt.Sprintf("%s must be at least 1m", setting.key))
+		}
+	}
+	if cfg.DBTimeout <= 0 || cfg.DBExportTimeout <= 0 {
+		problems = append(problems, "DB_TIMEOUT and DB_EXPORT_TIMEOUT must be positive")
+	}
+	if cfg.DBBusyTimeout <= 0 {
+		problems = append(problems, "DB_BUSY_TIMEOUT must be positive")
+	}
+	if cfg.ModerationMaxLinks < 0 {
+		problems = append(problems, "MODERATION_MAX_LINKS must not be negative")
+	}
+	for _, setting := range []struct{ key, action string }{
+		{"MODERATION_LINK_ACTION", cfg.ModerationLinkAction},
+		{"MODERATION_SHOUTING_ACTION", cfg.ModerationShoutingAction},
+	} {
+		if setting.action != ModerationReject && setting.action != ModerationFlag && setting.action != ModerationOff {
+			problems = append(problems, fmt.Sprintf("%s must be reject, flag or off (got %q)", setting.key, setting.action))
+		}
+	}
+	if cfg.RedisURL != "" {
+		if _, err := redis.ParseURL(cfg.RedisURL); err != nil {
+			problems = append(problems, "REDIS_URL must be a redis:// or rediss:// URL")
+		}
+	}
+	if cfg.JoinGracePeriod < 0 {
+		problems = append(problems, "JOIN_GRACE_PERIOD must not be negative")
+	}
+	if cfg.MinAccountAgeToCreate < 0 || cfg.MinAccountAgeToJoin < 0 {
+		problems = append(problems, "MIN_ACCOUNT_AGE_TO_CREATE and MIN_ACCOUNT_AGE_TO_JOIN must not be negative")
+	}
+	if cfg.SimilarEventThreshold < 0 || cfg.SimilarEventThreshold > 100 {
+		problems = append(problems, "SIMILAR_EVENT_THRESHOLD must be between 0 and 100 (0 disables it)")
+	}
+	if cfg.NewAccountEventLimit < 0 {
+		problems = append(problems, "NEW_ACCOUNT_EVENT_LIMIT must not be negative (0 disables it)")
+	}
+	if cfg.EventRetentionMonths < 0 {
+		problems = append(problems, "EVENT_RETENTION_MONTHS must not be negative (0 disables anonymization)")
+	}
+	minBcryptCost := minProductionBcryptCost
+	if cfg.allowWeakBcrypt {
+		minBcryptCost = bcrypt.MinCost
+	}
+	if cfg.BcryptCost < minBcryptCost || cfg.BcryptCost > maxBcryptCost {
+		problems = append(problems, fmt.Sprintf("BCRYPT_COST must be between %d and %d", minBcryptCost, maxBcryptCost))
+	}
+	if cfg.PasswordHash != PasswordHashBcrypt && cfg.PasswordHash != PasswordHashArgon2id {
+		problems = append(problems, fmt.Sprintf("PASSWORD_HASH must be %s or %s (got %q)", PasswordHashBcrypt, PasswordHashArgon2id, cfg.PasswordHash))
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT must be between 1 and 65535 (got %q)", cfg.Port))
+	}
+	if cfg.UseTLS && (cfg.TLSCert == "" || cfg.TLSKey == "") {
+		problems = append(problems, "TLS_CERT and TLS_KEY must be set when USE_TLS=true")
+	}
+	for _, origin := range cfg.CORSOrigins {
+		if strings.Contains(origin, "*") {
+			problems = append(problems, "wildcard CORS origins (*) are not allowed for security")
+			break
+		}
+	}
+	if cfg.IsProduction() && len(cfg.CORSOrigins) == 0 {
+		problems = append(problems, "CORS_ORIGINS must be set in production")
+	}
+	return problems
+}
+
+// legacyAPISunsetDate formats LegacyAPISunset as configured, or "" when unversioned paths aren't deprecated
+func (cfg *Config) legacyAPISunsetDate() string {
+	if cfg.LegacyAPISunset.IsZero() {
+		return ""
+	}
+	return cfg.LegacyAPISunset.Format("2006-01-02")
+}
+
+// rateLimitStoreName reports where rate limit counts live, without revealing REDIS_URL
+func (cfg *Config) rateLimitStoreName() string {
+	if cfg.RedisURL != "" {
+		return "redis"
+	}
+	return "memory"
+}
+
+// multiInstance reports whether several backends may be serving traffic: a shared rate limit
+// store is only configured for that, and in-process signals then don't reach every client
+func (cfg *Config) multiInstance() bool {
+	return cfg.RedisURL != ""
+}
+
+func (cfg *Config) IsProduction() bool {
+	return cfg.Environment == "production"
+}
+
+// Public returns the effective configuration without secrets (GET /api/admin/config)
+func (cfg *Config) Public() gin.H {
+	return gin.H{
+		"environment":                cfg.Environment,
+		"port":                       cfg.Port,
+		"base_url":                   cfg.BaseURL,
+		"cors_origins":               cfg.CORSOrigins,
+		"use_tls":                    cfg.UseTLS,
+		"disable_compression":        cfg.DisableCompression,
+		"legacy_api_sunset":          cfg.legacyAPISunsetDate(),
+		"event_list_window_days":     cfg.EventListWindowDays,
+		"event_list_limit":           cfg.EventListLimit,
+		"max_request_bytes":          cfg.MaxRequestBytes,
+		"db_timeout":                 cfg.DBTimeout.String(),
+		"db_export_timeout":          cfg.DBExportTimeout.String(),
+		"db_busy_timeout":            cfg.DBBusyTimeout.String(),
+		"db_max_open_conns":          cfg.DBMaxOpenConns,
+		"report_takedown_threshold":  cfg.ReportTakedownThreshold,
+		"moderation_max_links":       cfg.ModerationMaxLinks,
+		"moderation_link_action":     cfg.ModerationLinkAction,
+		"moderation_shouting_action": cfg.ModerationShoutingAction,
+		"max_upcoming_joins":         cfg.MaxUpcomingJoins,
+		"max_upcoming_created":       cfg.MaxUpcomingCreated,
+		"similar_event_threshold":    cfg.SimilarEventThreshold,
+		"comment_limit_per_minute":   cfg.CommentsPerMinute,
+		"comment_limit_per_day":      cfg.CommentsPerDay,
+		"join_grace_period":          cfg.JoinGracePeriod.String(),
+		"min_account_age_to_create":  cfg.MinAccountAgeToCreate.String(),
+		"min_account_age_to_join":    cfg.MinAccountAgeToJoin.String(),
+		"new_account_event_limit":    cfg.NewAccountEventLimit,
+		"review_first_event":         cfg.ReviewFirstEvent,
+		"cleanup_interval":           cfg.CleanupInterval.String(),
+		"event_retention_months":     cfg.EventRetentionMonths,
+		"repair_orphans":             cfg.RepairOrphans,
+		"public_stats_rounded":       cfg.PublicStatsRounded,
+		"rate_limit_auth":            cfg.AuthRateLimit,
+		"rate_limit_api":             cfg.APIRateLimit,
+		"rate_limit_search":          cfg.SearchRateLimit,
+		"rate_limit_create_event":    cfg.CreateEventRateLimit,
+		"rate_limit_store":           cfg.rateLimitStoreName(),
+		"session_ttl":                cfg.SessionTTL.String(),
+		"verification_token_ttl":     cfg.VerificationTokenTTL.String(),
+		"password_reset_token_ttl":   cfg.PasswordResetTokenTTL.String(),
+		"password_hash":              cfg.PasswordHash,
+		"bcrypt_cost":                cfg.BcryptCost,
+		"admin_email":                cfg.AdminEmail,
+		"mailgun_domain":             cfg.MailgunDomain,
+		"mailgun_from_email":         cfg.MailgunFromEmail,
+		"email_enabled":              cfg.MailgunDomain != "" && cfg.MailgunAPIKey != "",
+	}
+}
+
+// adminGetConfig returns the effective non-secret configuration (GET /api/admin/config)
+func adminGetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, appConfig.Public())
+}
+
+// describeTTL renders a token lifetime for email copy, e.g. "24 hours" or "30 minutes"
+func describeTTL(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d >= 48*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestConfig applies override to a copy of the current config for the duration of t
+func useTestConfig(t *testing.T, override func(cfg *Config)) {
+	previous := appConfig
+	cfg := *previous
+	override(&cfg)
+	appConfig = &cfg
+	t.Cleanup(func() { appConfig = previous })
+}
+
+func envMap(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig(envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), cfg)
+	assert.Equal(t, 30, cfg.EventListWindowDays)
+	assert.Equal(t, 100, cfg.EventListLimit)
+	assert.Equal(t, 24*time.Hour, cfg.VerificationTokenTTL)
+	assert.Equal(t, time.Hour, cfg.PasswordResetTokenTTL)
+	assert.False(t, cfg.IsProduction())
+}
+
+func TestLoadConfigOverrides(t *testing.T) {
+	cfg, err := loadConfig(envMap(map[string]string{
+		"ENVIRONMENT":              "production",
+		"SERVER_PORT":              "9090",
+		"BASE_URL":                 "https://veidly.com/",
+		"CORS_ORIGINS":             "https://veidly.com, https://www.veidly.com",
+		"EVENT_LIST_WINDOW_DAYS":   "14",
+		"EVENT_LIST_LIMIT":         "250",
+		"VERIFICATION_TOKEN_TTL":   "72h",
+		"PASSWORD_RESET_TOKEN_TTL": "30m",
+		"BCRYPT_COST":              "12",
+		"MAILGUN_API_KEY":          "key-123",
+	}))
+	require.NoError(t, err)
+	assert.True(t, cfg.IsProduction())
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, "https://veidly.com", cfg.BaseURL)
+	assert.Equal(t, []string{"https://veidly.com", "https://www.veidly.com"}, cfg.CORSOrigins)
+	assert.Equal(t, 14, cfg.EventListWindowDays)
+	assert.Equal(t, 250, cfg.EventListLimit)
+	assert.Equal(t, 72*time.Hour, cfg.VerificationTokenTTL)
+	assert.Equal(t, 30*time.Minute, cfg.PasswordResetTokenTTL)
+	assert.Equal(t, 12, cfg.BcryptCost)
+
+	// Test mode may trade hash strength for speed
+	cfg, err = loadConfig(envMap(map[string]string{"GIN_MODE": "test", "BCRYPT_COST": "4", "PASSWORD_HASH": "argon2id"}))
+	require.NoError(t, err)
+	assert.Equal(t, 4, cfg.BcryptCost)
+	assert.Equal(t, PasswordHashArgon2id, cfg.PasswordHash)
+
+	// PORT wins over SERVER_PORT
+	cfg, err = loadConfig(envMap(map[string]string{"PORT": "3000", "SERVER_PORT": "9090"}))
+	require.NoError(t, err)
+	assert.Equal(t, "3000", cfg.Port)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		env     map[string]string
+		problem string
+	}{
+		"non-numeric limit":     {map[string]string{"EVENT_LIST_LIMIT": "lots"}, "EVENT_LIST_LIMIT must be an integer"},
+		"negative limit":        {map[string]string{"EVENT_LIST_LIMIT": "-5"}, "EVENT_LIST_LIMIT must be positive"},
+		"limit too large":       {map[string]string{"EVENT_LIST_LIMIT": "5000"}, "EVENT_LIST_LIMIT must be at most 1000"},
+		"zero window":           {map[string]string{"EVENT_LIST_WINDOW_DAYS": "0"}, "EVENT_LIST_WINDOW_DAYS must be positive"},
+		"bad duration":          {map[string]string{"VERIFICATION_TOKEN_TTL": "1 day"}, "VERIFICATION_TOKEN_TTL must be a duration"},
+		"tiny ttl":              {map[string]string{"PASSWORD_RESET_TOKEN_TTL": "10s"}, "PASSWORD_RESET_TOKEN_TTL must be at least 1m"},
+		"bcrypt out of range":   {map[string]string{"BCRYPT_COST": "40"}, "BCRYPT_COST must be between"},
+		"weak bcrypt":           {map[string]string{"BCRYPT_COST": "4"}, "BCRYPT_COST must be between 10 and 15"},
+		"unknown hash":          {map[string]string{"PASSWORD_HASH": "md5"}, "PASSWORD_HASH must be bcrypt or argon2id"},
+		"bad port":              {map[string]string{"PORT": "99999"}, "PORT must be between 1 and 65535"},
+		"tls without cert":      {map[string]string{"USE_TLS": "true"}, "TLS_CERT and TLS_KEY must be set"},
+		"wildcard cors":         {map[string]string{"CORS_ORIGINS": "*"}, "wildcard CORS origins"},
+		"production needs cors": {map[string]string{"ENVIRONMENT": "production"}, "CORS_ORIGINS must be set in production"},
+		"no connections":        {map[string]string{"DB_MAX_OPEN_CONNS": "0"}, "DB_MAX_OPEN_CONNS must be positive"},
+		"no busy timeout":       {map[string]string{"DB_BUSY_TIMEOUT": "0s"}, "DB_BUSY_TIMEOUT must be positive"},
+	} {
+		_, err := loadConfig(envMap(tc.env))
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), tc.problem, name)
+	}
+
+	// Every problem is reported at once
+	_, err := loadConfig(envMap(map[string]string{"EVENT_LIST_LIMIT": "0", "SESSION_TTL": "soon"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EVENT_LIST_LIMIT")
+	assert.Contains(t, err.Error(), "SESSION_TTL")
+}
+
+func TestDescribeTTL(t *testing.T) {
+	assert.Equal(t, "24 hours", describeTTL(24*time.Hour))
+	assert.Equal(t, "1 hour", describeTTL(time.Hour))
+	assert.Equal(t, "3 days", describeTTL(72*time.Hour))
+	assert.Equal(t, "30 minutes", describeTTL(30*time.Minute))
+	assert.Equal(t, "90 minutes", describeTTL(90*time.Minute))
+}
+
+func TestEventListingUsesConfig(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	createTestEvent(t, testDB, userID, "Tomorrow")
+	laterID := createTestEvent(t, testDB, userID, "In Ten Days")
+	_, err := testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, time.Now().Add(10*24*time.Hour).Format(time.RFC3339), laterID)
+	require.NoError(t, err)
+	createTestEvent(t, testDB, userID, "Also Tomorrow")
+
+	router := gin.New()
+	router.GET("/api/events", getEvents)
+	list := func() []Event {
+		eventListCache.Invalidate()
+		w := doJSON(router, "GET", "/api/events", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+
+	assert.Len(t, list(), 3)
+
+	useTestConfig(t, func(cfg *Config) { cfg.EventListWindowDays = 5 })
+	for _, event := range list() {
+		assert.NotEqual(t, "In Ten Days", event.Title)
+	}
+
+	useTestConfig(t, func(cfg *Config) { cfg.EventListLimit = 1 })
+	assert.Len(t, list(), 1)
+}
+
+func TestSessionTTLFromConfig(t *testing.T) {
+	setupJWT()
+	useTestConfig(t, func(cfg *Config) { cfg.SessionTTL = 2 * time.Hour })
+
+	token, err := generateToken(User{ID: 1, Email: "user@example.com"})
+	require.NoError(t, err)
+	claims, err := validateToken(token)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), claims.ExpiresAt.Time, time.Minute)
+}
+
+func TestAdminGetConfig(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.AdminPassword = "super-secret-admin"
+		cfg.MailgunAPIKey = "key-super-secret"
+		cfg.MailgunDomain = "mg.veidly.com"
+	})
+
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/config", adminGetConfig)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+
+	w := doJSON(router, "GET", "/api/admin/config", userToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(router, "GET", "/api/admin/config", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "super-secret")
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(30), body["event_list_window_days"])
+	assert.Equal(t, "1h0m0s", body["password_reset_token_ttl"])
+	assert.Equal(t, true, body["email_enabled"])
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrCodeDBTimeout marks a request whose database work ran past DB_TIMEOUT
+const ErrCodeDBTimeout = "DB_TIMEOUT"
+
+// ErrCodeDBBusy marks a write that gave up waiting DB_BUSY_TIMEOUT for SQLite's write lock
+const ErrCodeDBBusy = "DB_BUSY"
+
+// clientContextKey holds the request context before any DB deadline was applied, so a route can
+// swap in a longer deadline while still being cancelled when the client goes away
+const clientContextKey = "client_context"
+
+// serverWriteTimeout is the server's WriteTimeout. Routes that answer later, like comment long
+// polls and exports, push their own deadline out with withDBTimeout.
+const serverWriteTimeout = 15 * time.Second
+
+// writeDeadlineSlack is how much longer than its DB timeout a long-running route may take to write
+const writeDeadlineSlack = 5 * time.Second
+
+// DBTimeoutMiddleware bounds the database work of each request. Handlers run their queries with
+// c.Request.Context(), so a locked or slow query is abandoned after timeout (or as soon as the
+// client disconnects) and the request fails with 503 instead of hanging until WriteTimeout.
+func DBTimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientContextKey, c.Request.Context())
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := &dbTimeoutResponseWriter{ResponseWriter: c.Writer, c: c}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+	}
+}
+
+// withDBTimeout replaces the default DB deadline for a single route, e.g. admin exports.
+// The server's write deadline is pushed out to match so the response isn't cut off.
+func withDBTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent := c.Request.Context()
+		if client, ok := c.Get(clientContextKey); ok {
+			parent = client.(context.Context)
+		}
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// ErrNotSupported means a wrapping writer hides the connection, and the response would be
+		// cut off at the server's WriteTimeout
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout + writeDeadlineSlack)); err != nil {
+			log.Printf("⚠️  Could not extend write deadline for %s: %v", c.FullPath(), err)
+		}
+
+		c.Next()
+	}
+}
+
+// isDatabaseBusy reports whether err is SQLite refusing a statement because another connection
+// held the lock for longer than the busy timeout
+func isDatabaseBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// respondDBError answers a failed write: 503 with ErrCodeDBBusy and Retry-After when the database
+// was busy, so clients retry instead of reporting a failure, and a 500 with message otherwise
+func respondDBError(c *gin.Context, err error, message string) {
+	if isDatabaseBusy(err) {
+		log.Printf("🔒 %s %s gave up waiting for the database lock: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The server is busy, please try again", "code": ErrCodeDBBusy})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// dbTimeoutResponseWriter turns the 500 a handler sends after its query was cut off into a 503
+// with ErrCodeDBTimeout, so clients can tell "try again" apart from a real failure
+type dbTimeoutResponseWriter struct {
+	gin.ResponseWriter
+	c        *gin.Context
+	timedOut bool
+	replaced bool
+}
+
+func (w *dbTimeoutResponseWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && errors.Is(w.c.Request.Context().Err(), context.DeadlineExceeded) {
+		log.Printf("⏱️  %s %s ran past its database timeout", w.c.Request.Method, w.c.Request.URL.Path)
+		w.timedOut = true
+		code = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", "1")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *dbTimeoutResponseWriter) Write(data []byte) (int, error) {
+	if !w.timedOut {
+		return w.ResponseWriter.Write(data)
+	}
+	// The handler's own error body is dropped in favour of the timeout error
+	if !w.replaced {
+		w.replaced = true
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if _, err := w.ResponseWriter.Write([]byte(`{"code":"` + ErrCodeDBTimeout + `","error":"The server is busy, please try again"}`)); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *dbTimeoutResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection (see withDBTimeout)
+func (w *dbTimeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
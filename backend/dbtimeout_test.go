@@ -0,0 +1,202 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBTimeout(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createTestEvent(t, testDB, organizerID, "Board Games")
+
+	router := gin.New()
+	router.Use(DBTimeoutMiddleware(100 * time.Millisecond))
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/deadline", func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"remaining": time.Until(deadline).Seconds()})
+	})
+	router.GET("/export/deadline", withDBTimeout(time.Minute), func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"remaining": time.Until(deadline).Seconds()})
+	})
+	path := fmt.Sprintf("/api/events/%d", eventID)
+
+	t.Run("Fast queries are unaffected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doJSON(router, "GET", path, "", nil).Code)
+	})
+
+	t.Run("Blocked query fails fast with 503", func(t *testing.T) {
+		// With a single connection held by an open transaction, the handler's query can't start
+		testDB.SetMaxOpenConns(1)
+		defer testDB.SetMaxOpenConns(0)
+		tx, err := testDB.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		start := time.Now()
+		w := doJSON(router, "GET", path, "", nil)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		assert.Equal(t, ErrCodeDBTimeout, resp["code"])
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Exports opt into a longer timeout", func(t *testing.T) {
+		remaining := func(path string) float64 {
+			w := doJSON(router, "GET", path, "", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp map[string]float64
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			return resp["remaining"]
+		}
+		assert.Less(t, remaining("/deadline"), 0.2)
+		assert.Greater(t, remaining("/export/deadline"), 50.0)
+	})
+}
+
+func TestWithDBTimeoutWriteDeadline(t *testing.T) {
+	// The route outlives the server's WriteTimeout and answers with a body large enough to be gzipped
+	router := gin.New()
+	router.Use(CompressionMiddleware())
+	router.Use(DBTimeoutMiddleware(100 * time.Millisecond))
+	router.GET("/export", withDBTimeout(2*time.Second), func(c *gin.Context) {
+		time.Sleep(700 * time.Millisecond)
+		c.String(http.StatusOK, strings.Repeat("id,name\n", 1000))
+	})
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 300 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	for _, encoding := range []string{"gzip", "identity"} {
+		t.Run("Accept-Encoding "+encoding, func(t *testing.T) {
+			req, err := http.NewRequest("GET", server.URL+"/export", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept-Encoding", encoding)
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err, "the response must not be cut off at WriteTimeout")
+			defer resp.Body.Close()
+
+			body := resp.Body
+			if encoding == "gzip" {
+				require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+				body, err = gzip.NewReader(resp.Body)
+				require.NoError(t, err)
+			}
+			data, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Len(t, data, 8000)
+		})
+	}
+}
+
+func TestDatabaseBusy(t *testing.T) {
+	setupJWT()
+	useTestConfig(t, func(cfg *Config) { cfg.DBBusyTimeout = 50 * time.Millisecond })
+	conn, _ := openProductionSchema(t)
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+
+	// Another connection holds the write lock for longer than the busy timeout
+	tx, err := conn.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec(`UPDATE users SET name = name`)
+	require.NoError(t, err)
+
+	w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "latecomer@example.com", "password": "password123", "name": "Latecomer"})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, ErrCodeDBBusy, resp["code"])
+
+	require.NoError(t, tx.Rollback())
+	w = doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "latecomer@example.com", "password": "password123", "name": "Latecomer"})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	setupJWT()
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MinAccountAgeToCreate = 0
+		cfg.MinAccountAgeToJoin = 0
+		cfg.NewAccountEventLimit = 0
+		cfg.ReviewFirstEvent = false
+	})
+	conn, _ := openProductionSchema(t)
+	eventListCache.Invalidate()
+
+	router := gin.New()
+	router.Use(DBTimeoutMiddleware(appConfig.DBTimeout))
+	protected := router.Group("/api", authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, conn, "organizer@example.com", "Olga", "password123", false)
+	eventID := createTestEvent(t, conn, organizerID, "Open air cinema")
+	joinPath := fmt.Sprintf("/api/events/%d/join", eventID)
+
+	const creators, joiners = 15, 25
+	tokens := make([]string, creators+joiners)
+	for i := range tokens {
+		email := fmt.Sprintf("user%d@example.com", i)
+		id := createTestUser(t, conn, email, fmt.Sprintf("User %d", i), "password123", false)
+		tokens[i], _ = generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+	}
+
+	start := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	var mu sync.Mutex
+	var failures []string
+	check := func(w *httptest.ResponseRecorder, wantCode int) {
+		if w.Code != wantCode {
+			mu.Lock()
+			failures = append(failures, fmt.Sprintf("%d %s", w.Code, w.Body.String()))
+			mu.Unlock()
+		}
+	}
+
+	// Everyone joins the same event while the first users also create their own
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			if i < creators {
+				check(doJSON(router, "POST", "/api/events", token, gin.H{
+					"title": fmt.Sprintf("Picnic %d", i), "description": "Bring a blanket and something to share",
+					"category": "food_dining", "latitude": 52.52, "longitude": 13.405, "start_time": start,
+					"creator_name": fmt.Sprintf("User %d", i), "gender_restriction": "any",
+					"age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+				}), http.StatusCreated)
+			}
+			check(doJSON(router, "POST", joinPath, token, nil), http.StatusOK)
+		}(i, token)
+	}
+	wg.Wait()
+
+	assert.Empty(t, failures, "no write may fail on the database lock")
+	assert.Equal(t, creators+joiners, countRows(t, conn, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID))
+	assert.Equal(t, creators+1, countRows(t, conn, `SELECT COUNT(*) FROM events`))
+	assert.Equal(t, creators+joiners, countRows(t, conn, `SELECT participant_count FROM events WHERE id = ?`, eventID))
+}
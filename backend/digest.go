@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsMonthFormat is how months are written in ?month= and in organizer_digests
+const statsMonthFormat = "2006-01"
+
+// OrganizerStats summarizes the events an organizer held in one calendar month (UTC)
+type OrganizerStats struct {
+	Month             string             `json:"month"`
+	EventsHeld        int                `json:"events_held"`
+	TotalParticipants int                `json:"total_participants"`
+	AverageFillRate   *float64           `json:"average_fill_rate"` // 0..1 over events with a capacity; null if none had one
+	TopEvent          *OrganizerTopEvent `json:"top_event"`
+	FeedbackAverage   *float64           `json:"feedback_average"` // null until someone rated one of the events
+	FeedbackCount     int                `json:"feedback_count"`
+}
+
+// OrganizerTopEvent is the month's event with the most participants
+type OrganizerTopEvent struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Slug         string `json:"slug"`
+	Participants int    `json:"participants"`
+}
+
+// sendOrganizerDigest delivers one digest; tests swap it to capture them
+var sendOrganizerDigest = func(email, name string, stats *OrganizerStats) error {
+	return emailService.SendOrganizerDigestEmail(email, name, stats)
+}
+
+func (s *OrganizerStats) monthName() string {
+	month, err := time.Parse(statsMonthFormat, s.Month)
+	if err != nil {
+		return s.Month
+	}
+	return month.Format("January 2006")
+}
+
+// monthlyOrganizerStats aggregates events held in the month starting at monthStart (up to now for the
+// current month) in one query, keyed by organizer. organizerID limits it to one organizer; 0 means all.
+// Cancelled events don't count.
+func monthlyOrganizerStats(ctx context.Context, monthStart time.Time, organizerID int) (map[int]*OrganizerStats, error) {
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	if now := time.Now().UTC(); now.Before(monthEnd) {
+		monthEnd = now
+	}
+
+	query := `
+		SELECT e.user_id, e.id, e.title, COALESCE(e.slug, ''), COALESCE(e.max_participants, 0),
+		       COALESCE(p.participants, 0), COALESCE(f.rating_total, 0), COALESCE(f.ratings, 0)
+		FROM events e
+		LEFT JOIN (SELECT event_id, COUNT(*) AS participants FROM event_participants GROUP BY event_id) p ON p.event_id = e.id
+		LEFT JOIN (SELECT event_id, SUM(rating) AS rating_total, COUNT(*) AS ratings FROM event_feedback GROUP BY event_id) f ON f.event_id = e.id
+		WHERE e.cancelled_at IS NULL AND e.start_time >= ? AND e.start_time < ?`
+	args := []interface{}{monthStart, monthEnd}
+	if organizerID > 0 {
+		query += ` AND e.user_id = ?`
+		args = append(args, organizerID)
+	}
+	query += ` ORDER BY e.user_id, e.start_time, e.id`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type totals struct {
+		fillSum              float64
+		fillEvents           int
+		ratingSum, ratingCnt int
+	}
+	month := monthStart.Format(statsMonthFormat)
+	stats := map[int]*OrganizerStats{}
+	sums := map[int]*totals{}
+	for rows.Next() {
+		var userID, maxParticipants, ratingTotal, ratings int
+		var top OrganizerTopEvent
+		if err := rows.Scan(&userID, &top.ID, &top.Title, &top.Slug, &maxParticipants, &top.Participants, &ratingTotal, &ratings); err != nil {
+			return nil, err
+		}
+		s, ok := stats[userID]
+		if !ok {
+			s = &OrganizerStats{Month: month}
+			stats[userID] = s
+			sums[userID] = &totals{}
+		}
+		t := sums[userID]
+
+		s.EventsHeld++
+		s.TotalParticipants += top.Participants
+		if maxParticipants > 0 {
+			t.fillSum += min(float64(top.Participants)/float64(maxParticipants), 1)
+			t.fillEvents++
+		}
+		t.ratingSum += ratingTotal
+		t.ratingCnt += ratings
+		if s.TopEvent == nil || top.Participants > s.TopEvent.Participants {
+			s.TopEvent = &top
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for userID, s := range stats {
+		t := sums[userID]
+		if t.fillEvents > 0 {
+			fill := t.fillSum / float64(t.fillEvents)
+			s.AverageFillRate = &fill
+		}
+		if t.ratingCnt > 0 {
+			avg := float64(t.ratingSum) / float64(t.ratingCnt)
+			s.FeedbackAverage = &avg
+			s.FeedbackCount = t.ratingCnt
+		}
+	}
+	return stats, nil
+}
+
+// previousMonthStart is the first instant of the calendar month before now, in UTC
+func previousMonthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+}
+
+// runOrganizerDigests mails last month's stats to every organizer who held an event, hasn't
+// opted out and hasn't been sent this month's digest yet. A marker row is claimed before sending
+// so overlapping runs (or instances) don't double-send; it's released if delivery fails.
+func runOrganizerDigests(ctx context.Context, now time.Time) (int, error) {
+	monthStart := previousMonthStart(now)
+	month := monthStart.Format(statsMonthFormat)
+
+	stats, err := monthlyOrganizerStats(ctx, monthStart, 0)
+	if err != nil || len(stats) == 0 {
+		return 0, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, email, name FROM users
+		WHERE digest_emails = 1 AND is_blocked = 0
+		  AND NOT EXISTS (SELECT 1 FROM organizer_digests d WHERE d.user_id = users.id AND d.month = ?)
+	`, month)
+	if err != nil {
+		return 0, err
+	}
+	type recipient struct {
+		id          int
+		email, name string
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.id, &r.email, &r.name); err == nil && stats[r.id] != nil {
+			recipients = append(recipients, r)
+		}
+	}
+	rows.Close()
+
+	sent := 0
+	for _, r := range recipients {
+		claimed, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO organizer_digests (user_id, month, sent_at) VALUES (?, ?, ?)`,
+			r.id, month, time.Now().UTC())
+		if err != nil {
+			return sent, err
+		}
+		if n, _ := claimed.RowsAffected(); n == 0 {
+			continue // Another run got there first
+		}
+		if err := sendOrganizerDigest(r.email, r.name, stats[r.id]); err != nil {
+			log.Printf("⚠️  Organizer digest to user %d failed: %v", r.id, err)
+			db.ExecContext(ctx, `DELETE FROM organizer_digests WHERE user_id = ? AND month = ?`, r.id, month)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		log.Printf("📬 Sent %d organizer digests for %s", sent, month)
+	}
+	return sent, nil
+}
+
+// getOwnStats returns the caller's organizer stats for ?month=YYYY-MM, defaulting to last month
+// (GET /api/profile/stats). It's the same aggregation the monthly digest email uses.
+func getOwnStats(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+
+	monthStart := previousMonthStart(time.Now())
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse(statsMonthFormat, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be formatted as YYYY-MM"})
+			return
+		}
+		monthStart = parsed
+	}
+	log.Printf("📊 GET /api/profile/stats - User %d, month %s", userID, monthStart.Format(statsMonthFormat))
+
+	stats, err := monthlyOrganizerStats(ctx, monthStart, userID)
+	if err != nil {
+		log.Printf("❌ Error aggregating stats for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load stats"})
+		return
+	}
+	result := stats[userID]
+	if result == nil {
+		result = &OrganizerStats{Month: monthStart.Format(statsMonthFormat)}
+	}
+	c.JSON(http.StatusOK, result)
+}
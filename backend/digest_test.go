@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedDigestMonth gives the organizer two held events last month (one cancelled and one from
+// another month don't count) with participants and ratings
+func seedDigestMonth(t *testing.T, testDB *sql.DB, organizerID int64) (popularID int64) {
+	monthStart := previousMonthStart(time.Now())
+	at := func(eventID int64, start time.Time) {
+		_, err := testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, start, eventID)
+		require.NoError(t, err)
+	}
+
+	capped := createTestEvent(t, testDB, organizerID, "Capped")
+	at(capped, monthStart.Add(48*time.Hour))
+	_, err := testDB.Exec(`UPDATE events SET max_participants = 4 WHERE id = ?`, capped)
+	require.NoError(t, err)
+
+	popularID = createTestEvent(t, testDB, organizerID, "Popular")
+	at(popularID, monthStart.Add(72*time.Hour))
+
+	cancelled := createTestEvent(t, testDB, organizerID, "Cancelled")
+	at(cancelled, monthStart.Add(96*time.Hour))
+	_, err = testDB.Exec(`UPDATE events SET cancelled_at = ? WHERE id = ?`, time.Now().UTC(), cancelled)
+	require.NoError(t, err)
+
+	older := createTestEvent(t, testDB, organizerID, "Older")
+	at(older, monthStart.AddDate(0, -1, 0))
+
+	for i := 0; i < 3; i++ {
+		participant := createTestUser(t, testDB, fmt.Sprintf("p%d-%d@example.com", organizerID, i), "Participant", "password123", false)
+		addParticipant(t, testDB, popularID, participant)
+		addParticipant(t, testDB, older, participant)
+		if i < 2 {
+			addParticipant(t, testDB, capped, participant)
+			_, err := testDB.Exec(`INSERT INTO event_feedback (event_id, user_id, rating) VALUES (?, ?, ?)`, capped, participant, 4+i)
+			require.NoError(t, err)
+		}
+	}
+	return popularID
+}
+
+func TestMonthlyOrganizerStats(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	popularID := seedDigestMonth(t, testDB, organizerID)
+
+	stats, err := monthlyOrganizerStats(context.Background(), previousMonthStart(time.Now()), 0)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	s := stats[int(organizerID)]
+	require.NotNil(t, s)
+	assert.Equal(t, 2, s.EventsHeld)
+	assert.Equal(t, 5, s.TotalParticipants)
+	require.NotNil(t, s.AverageFillRate)
+	assert.InDelta(t, 0.5, *s.AverageFillRate, 1e-9, "only the capped event has a fill rate")
+	require.NotNil(t, s.TopEvent)
+	assert.Equal(t, int(popularID), s.TopEvent.ID)
+	assert.Equal(t, 3, s.TopEvent.Participants)
+	require.NotNil(t, s.FeedbackAverage)
+	assert.InDelta(t, 4.5, *s.FeedbackAverage, 1e-9)
+	assert.Equal(t, 2, s.FeedbackCount)
+}
+
+func TestOrganizerDigestJob(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	var sent []string
+	failFor := ""
+	original := sendOrganizerDigest
+	sendOrganizerDigest = func(email, name string, stats *OrganizerStats) error {
+		if email == failFor {
+			return errors.New("mail server down")
+		}
+		sent = append(sent, email)
+		return nil
+	}
+	t.Cleanup(func() { sendOrganizerDigest = original })
+
+	activeID := createTestUser(t, testDB, "active@example.com", "Active", "password123", false)
+	optedOutID := createTestUser(t, testDB, "optedout@example.com", "Opted Out", "password123", false)
+	createTestUser(t, testDB, "inactive@example.com", "Inactive", "password123", false)
+	seedDigestMonth(t, testDB, activeID)
+	seedDigestMonth(t, testDB, optedOutID)
+	_, err := testDB.Exec(`UPDATE users SET digest_emails = 0 WHERE id = ?`, optedOutID)
+	require.NoError(t, err)
+
+	t.Run("Failed delivery is retried on the next run", func(t *testing.T) {
+		failFor = "active@example.com"
+		n, err := runOrganizerDigests(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		failFor = ""
+	})
+
+	t.Run("Only active organizers who opted in get a digest", func(t *testing.T) {
+		n, err := runOrganizerDigests(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"active@example.com"}, sent)
+	})
+
+	t.Run("Running twice doesn't double-send", func(t *testing.T) {
+		n, err := runOrganizerDigests(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Len(t, sent, 1)
+	})
+}
+
+func TestGetOwnStats(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/profile/stats", authMiddleware(), getOwnStats)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	seedDigestMonth(t, testDB, organizerID)
+
+	w := doJSON(router, "GET", "/api/profile/stats", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stats OrganizerStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, previousMonthStart(time.Now()).Format(statsMonthFormat), stats.Month)
+	assert.Equal(t, 2, stats.EventsHeld)
+
+	w = doJSON(router, "GET", "/api/profile/stats?month=2001-02", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, OrganizerStats{Month: "2001-02"}, stats)
+
+	assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/profile/stats?month=January", token, nil).Code)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"time"
+)
+
+const (
+	// draftNotice tells the creator why their new event isn't visible yet
+	draftNotice = "Your event is saved as a draft. Verify your email address to publish it."
+
+	// draftRetention is how long an unverified user's draft is kept; a reminder goes out a day before
+	draftRetention      = 7 * 24 * time.Hour
+	draftReminderBefore = 24 * time.Hour
+)
+
+// draftEventCondition selects unpublished drafts; publishedEventCondition the rest
+const (
+	draftEventCondition     = `e.published = 0`
+	publishedEventCondition = `
+		AND e.published = 1`
+)
+
+// publishDrafts makes a newly verified user's drafts public and announces them as if they had just
+// been created. Returns how many were published.
+func publishDrafts(ctx context.Context, userID int) (int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT e.id, e.hidden_pending_review FROM events e WHERE e.user_id = ? AND `+draftEventCondition, userID)
+	if err != nil {
+		return 0, err
+	}
+	type draft struct {
+		id     int
+		hidden bool
+	}
+	var drafts []draft
+	for rows.Next() {
+		var d draft
+		if err := rows.Scan(&d.id, &d.hidden); err == nil {
+			drafts = append(drafts, d)
+		}
+	}
+	rows.Close()
+	if len(drafts) == 0 {
+		return 0, nil
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE events SET published = 1, updated_at = ? WHERE user_id = ? AND published = 0`,
+		time.Now().UTC(), userID); err != nil {
+		return 0, err
+	}
+
+	eventListCache.Invalidate()
+	for _, d := range drafts {
+		webhookDispatch.Dispatch(WebhookEventCreated, d.id, 0)
+		if !d.hidden {
+			broadcastEvent(WebhookEventCreated, d.id)
+			recordActivity(db, userID, ActivityPosted, d.id)
+		}
+	}
+	log.Printf("📢 Published %d draft events of user %d", len(drafts), userID)
+	return len(drafts), nil
+}
+
+// publishDraftsAfterVerification is called wherever an email becomes verified; failures are logged
+// since the verification itself already succeeded
+func publishDraftsAfterVerification(ctx context.Context, userID int) {
+	if _, err := publishDrafts(ctx, userID); err != nil {
+		log.Printf("⚠️  Could not publish drafts of user %d: %v", userID, err)
+	}
+}
+
+// remindStaleDrafts emails the creators of drafts that will be deleted within draftReminderBefore,
+// once per draft
+func remindStaleDrafts(now time.Time) (int64, error) {
+	rows, err := db.Query(`
+		SELECT e.id, e.user_id, e.title, u.email, u.name
+		FROM events e JOIN users u ON u.id = e.user_id
+		WHERE `+draftEventCondition+` AND e.draft_reminded_at IS NULL AND e.created_at < ?
+	`, now.Add(-(draftRetention - draftReminderBefore)).UTC())
+	if err != nil {
+		return 0, err
+	}
+	type reminder struct {
+		id, userID         int
+		title, email, name string
+	}
+	var reminders []reminder
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.id, &r.userID, &r.title, &r.email, &r.name); err == nil {
+			reminders = append(reminders, r)
+		}
+	}
+	rows.Close()
+
+	var sent int64
+	for _, r := range reminders {
+		// Mark first so a slow mail server can't cause a second reminder on the next run, and only if
+		// still unmarked so another instance running housekeeping at the same time skips it
+		result, err := db.Exec(`UPDATE events SET draft_reminded_at = ? WHERE id = ? AND draft_reminded_at IS NULL`, now.UTC(), r.id)
+		if err != nil {
+			return sent, err
+		}
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+		notify(db, r.userID, NotificationDraftExpiring, eventNotification{EventID: r.id, Title: r.title})
+		message := fmt.Sprintf("Your draft event \"%s\" will be deleted tomorrow because your email address is still not verified. Verify it to publish the event.",
+			html.UnescapeString(r.title))
+		if err := sendModerationEmail(r.email, r.name, "Your draft event expires tomorrow", message, frontendBaseURL()+"/profile"); err != nil {
+			log.Printf("⚠️  Draft reminder for event %d failed: %v", r.id, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
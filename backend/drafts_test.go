@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnverifiedOrganizerDrafts(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/verify-email", VerifyEmail)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "new@example.com", "Newbie", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, organizerID)
+	require.NoError(t, err)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "new@example.com"})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	listedIDs := func(token string) []int {
+		w := doJSON(router, "GET", "/api/events", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		ids := []int{}
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	w := doJSON(router, "POST", "/api/events", organizerToken, gin.H{
+		"title": "Sunday Picnic", "description": "Bring a blanket and something to share",
+		"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+		"start_time": time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Newbie",
+		"gender_restriction": "any", "age_min": 18, "age_max": 99,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var draft Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &draft))
+
+	t.Run("Unverified create yields a draft", func(t *testing.T) {
+		assert.True(t, draft.Draft)
+		assert.Equal(t, draftNotice, draft.Notice)
+
+		var published bool
+		require.NoError(t, testDB.QueryRow(`SELECT published FROM events WHERE id = ?`, draft.ID).Scan(&published))
+		assert.False(t, published)
+	})
+
+	t.Run("Drafts never leak into listings or public pages", func(t *testing.T) {
+		assert.NotContains(t, listedIDs(""), draft.ID)
+		assert.NotContains(t, listedIDs(userToken), draft.ID)
+		assert.NotContains(t, listedIDs(organizerToken), draft.ID)
+
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/public/events/"+draft.Slug, "", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/public/events/"+draft.Slug, userToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", fmt.Sprintf("/api/events/%d", draft.ID), userToken, nil).Code)
+
+		w := doJSON(router, "GET", "/api/public/events/"+draft.Slug, organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, "the creator still sees their draft")
+		var own Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &own))
+		assert.True(t, own.Draft)
+	})
+
+	t.Run("Drafts can't be joined", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", draft.ID), userToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Verification publishes the draft", func(t *testing.T) {
+		_, err := testDB.Exec(`INSERT INTO email_verification_tokens (user_id, token, expires_at) VALUES (?, ?, ?)`,
+			organizerID, "verify-newbie", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		w := doJSON(router, "GET", "/api/verify-email?token=verify-newbie", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Contains(t, listedIDs(userToken), draft.ID)
+		w = doJSON(router, "GET", "/api/public/events/"+draft.Slug, userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var published Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &published))
+		assert.False(t, published.Draft)
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", draft.ID), userToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+func TestStaleDraftCleanup(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	emails := captureModerationEmails(t)
+
+	userID := createTestUser(t, testDB, "new@example.com", "Newbie", "password123", false)
+	now := time.Now()
+	draftAged := func(title string, age time.Duration) int64 {
+		id := createTestEvent(t, testDB, userID, title)
+		_, err := testDB.Exec(`UPDATE events SET published = 0, created_at = ? WHERE id = ?`, now.Add(-age).UTC(), id)
+		require.NoError(t, err)
+		return id
+	}
+	freshID := draftAged("Fresh draft", 2*24*time.Hour)
+	staleID := draftAged("Stale draft", 6*24*time.Hour+time.Hour)
+	publishedID := createTestEvent(t, testDB, userID, "Published event")
+	_, err := testDB.Exec(`UPDATE events SET created_at = ? WHERE id = ?`, now.Add(-30*24*time.Hour).UTC(), publishedID)
+	require.NoError(t, err)
+
+	exists := func(id int64) bool {
+		var n int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE id = ?`, id).Scan(&n))
+		return n == 1
+	}
+
+	t.Run("Warned a day before deletion", func(t *testing.T) {
+		result, err := runCleanup(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.DraftReminders)
+		assert.Zero(t, result.DraftsDeleted)
+		sent := emails()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "new@example.com", sent[0].to)
+
+		result, err = runCleanup(now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, result.DraftReminders, "one reminder per draft")
+	})
+
+	t.Run("Deleted once the retention has passed", func(t *testing.T) {
+		result, err := runCleanup(now.Add(draftReminderBefore + time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.DraftsDeleted)
+		assert.False(t, exists(staleID))
+		assert.True(t, exists(freshID))
+		assert.True(t, exists(publishedID), "published events are never cleaned up as drafts")
+	})
+}
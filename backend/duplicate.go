@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// duplicateEvent copies an event's details and privacy settings to a new start time
+// (POST /api/events/:id/duplicate). Participants and comments are not copied.
+func duplicateEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	log.Printf("📑 POST /api/events/%d/duplicate - User %d duplicating event", eventID, userID)
+
+	// Same email verification requirement as createEvent (admins are exempt)
+	var emailVerified, isAdmin bool
+	err = db.QueryRowContext(ctx, `SELECT email_verified, is_admin FROM users WHERE id = ?`, userID).Scan(nullable(&emailVerified), nullable(&isAdmin))
+	if err != nil {
+		log.Printf("❌ Failed to check email verification status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account status"})
+		return
+	}
+	if !emailVerified && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before creating events"})
+		return
+	}
+
+	var req DuplicateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time is required"})
+		return
+	}
+
+	var event Event
+	var origStart string
+	var origEnd, genderRestriction, eventLanguages sql.NullString
+	var maxParticipants, groupID, priceAmount sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT user_id, title, description, description_format, category, latitude, longitude, start_time, end_time,
+		       creator_name, max_participants, gender_restriction, COALESCE(age_min, 0), COALESCE(age_max, 99),
+		       smoking_allowed, alcohol_allowed, event_languages,
+		       hide_organizer_until_joined, COALESCE(hide_participants_until_joined, 1),
+		       require_verified_to_join, require_verified_to_view, COALESCE(allow_unregistered_users, 1),
+		       location_name, address, require_birth_year, timezone, group_id,
+		       price_amount, price_currency, payment_note, max_joins_per_network, reserved_spots
+		FROM events WHERE id = ?
+	`, eventID).Scan(
+		&event.UserID, &event.Title, &event.Description, &event.DescriptionFormat, &event.Category, &event.Latitude, &event.Longitude,
+		&origStart, &origEnd, &event.CreatorName, &maxParticipants, &genderRestriction, &event.AgeMin, &event.AgeMax,
+		nullable(&event.SmokingAllowed), nullable(&event.AlcoholAllowed), &eventLanguages,
+		nullable(&event.HideOrganizerUntilJoined), &event.HideParticipantsUntilJoined,
+		nullable(&event.RequireVerifiedToJoin), nullable(&event.RequireVerifiedToView), &event.AllowUnregisteredUsers,
+		&event.LocationName, &event.Address, nullable(&event.RequireBirthYear), &event.Timezone, &groupID,
+		&priceAmount, &event.PriceCurrency, &event.PaymentNote, &event.NetworkJoinLimit, &event.ReservedSpots,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event to duplicate: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate event"})
+		return
+	}
+
+	if event.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can duplicate this event"})
+		return
+	}
+
+	// The copy stays in the original's group, as long as the organizer may still post there
+	if groupID.Valid {
+		id := int(groupID.Int64)
+		event.GroupID = &id
+		if err := checkGroupPosting(ctx, id, userID, isAdmin); err != nil {
+			switch {
+			case errors.Is(err, errGroupNotFound):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+			case errors.Is(err, errGroupPostingNotAllowed):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				log.Printf("❌ Failed to check group %d: %v", id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate event"})
+			}
+			return
+		}
+	}
+
+	if maxParticipants.Valid {
+		event.MaxParticipants = int(maxParticipants.Int64)
+	}
+	event.GenderRestriction = "any"
+	if genderRestriction.Valid && genderRestriction.String != "" {
+		event.GenderRestriction = genderRestriction.String
+	}
+	event.EventLanguages = eventLanguages.String
+	if priceAmount.Valid {
+		amount := int(priceAmount.Int64)
+		event.PriceAmount = &amount
+	}
+
+	// The copy keeps the original's zone, so wall-clock times mean the same thing
+	loc, err := resolveEventTimezone(ctx, &event, userID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+	startTime, err := parseEventTime(req.StartTime, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
+		return
+	}
+
+	// Keep the original duration when no new end_time is given
+	var endTimePtr *time.Time
+	if req.EndTime != "" {
+		endTime, err := parseEventTime(req.EndTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
+			return
+		}
+		endTimePtr = &endTime
+	} else if origEnd.Valid && origEnd.String != "" {
+		oldStart, errStart := parseDateTime(origStart)
+		oldEnd, errEnd := parseDateTime(origEnd.String)
+		if errStart == nil && errEnd == nil && oldEnd.After(oldStart) {
+			endTime := startTime.Add(oldEnd.Sub(oldStart))
+			endTimePtr = &endTime
+		}
+	}
+	setEventTimes(&event, startTime, endTimePtr)
+
+	// Stored text is already escaped; unescape so ValidateEvent doesn't escape it twice
+	event.Title = html.UnescapeString(event.Title)
+	event.Description = html.UnescapeString(event.Description)
+	event.CreatorName = html.UnescapeString(event.CreatorName)
+	event.PaymentNote = html.UnescapeString(event.PaymentNote)
+
+	// Category-specific fields come along too
+	attributes, err := loadEventAttributes(ctx, db, []int{eventID})
+	if err != nil {
+		log.Printf("❌ Error loading attributes to duplicate: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate event"})
+		return
+	}
+	event.Attributes = attributes[eventID]
+	for key, value := range event.Attributes {
+		event.Attributes[key] = html.UnescapeString(value)
+	}
+
+	if err := ValidateEvent(&event, &startTime, endTimePtr); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+
+	// The banned-terms list may have changed since the original was posted
+	moderation, ok := moderateEventText(c, &event, isAdmin)
+	if !ok {
+		return
+	}
+	if !applyNewAccountRules(c, &moderation, userID, isAdmin) {
+		return
+	}
+	event.HiddenPendingReview = moderation.Flagged()
+
+	err = insertEvent(ctx, &event, userID, isAdmin, startTime, endTimePtr, func(tx *sql.Tx, newID int) {
+		if event.HiddenPendingReview {
+			flagForReview(tx, "event", newID, userID, moderation)
+		} else {
+			recordActivity(tx, userID, ActivityPosted, newID)
+		}
+	})
+	if err != nil {
+		var limitErr *LimitReachedError
+		if errors.As(err, &limitErr) {
+			limitErr.respond(c)
+			return
+		}
+		log.Printf("❌ Failed to duplicate event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to duplicate event")
+		return
+	}
+
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookEventCreated, event.ID, 0)
+	if !event.HiddenPendingReview {
+		broadcastEvent(WebhookEventCreated, event.ID)
+	}
+	log.Printf("✅ Event %d duplicated as %d (slug: %s)", eventID, event.ID, event.Slug)
+	applyCapacityFields(&event)
+	c.JSON(http.StatusCreated, event)
+}
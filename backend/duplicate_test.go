@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateEvent(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/duplicate", duplicateEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+	otherToken, _ := generateToken(User{ID: int(otherID), Email: "other@example.com"})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Weekly Board Games")
+	tomorrow := time.Now().Add(24 * time.Hour)
+	_, err := testDB.Exec(`
+		UPDATE events SET slug = 'weekly-board-games', description = 'Bring snacks &amp; games',
+		       max_participants = 8, require_verified_to_join = 1, start_time = ?, end_time = ?
+		WHERE id = ?
+	`, tomorrow.Format(time.RFC3339), tomorrow.Add(3*time.Hour).Format(time.RFC3339), eventID)
+	require.NoError(t, err)
+	addParticipant(t, testDB, eventID, otherID)
+	_, err = testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'See you there')`, eventID, otherID)
+	require.NoError(t, err)
+
+	path := fmt.Sprintf("/api/events/%d/duplicate", eventID)
+	nextWeek := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+
+	t.Run("Copy gets a new slug and no participants or comments", func(t *testing.T) {
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var copy Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &copy))
+		assert.NotEqual(t, int(eventID), copy.ID)
+		assert.NotEmpty(t, copy.Slug)
+		assert.NotEqual(t, "weekly-board-games", copy.Slug)
+		assert.Equal(t, 8, copy.MaxParticipants)
+		assert.True(t, copy.RequireVerifiedToJoin)
+
+		var participants, comments int
+		testDB.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, copy.ID).Scan(&participants)
+		testDB.QueryRow(`SELECT COUNT(*) FROM event_comments WHERE event_id = ?`, copy.ID).Scan(&comments)
+		assert.Equal(t, 0, participants)
+		assert.Equal(t, 0, comments)
+
+		var description, endTime string
+		testDB.QueryRow(`SELECT description, end_time FROM events WHERE id = ?`, copy.ID).Scan(&description, &endTime)
+		assert.Equal(t, "Bring snacks &amp; games", description, "description must not be escaped twice")
+
+		// The driver stores time.Time in SQLite's "YYYY-MM-DD HH:MM:SS+HH:MM" text format
+		end, err := time.Parse("2006-01-02 15:04:05-07:00", endTime)
+		require.NoError(t, err)
+		assert.Equal(t, 3*time.Hour, end.Sub(nextWeek), "original duration is kept")
+	})
+
+	t.Run("Past start_time rejected", func(t *testing.T) {
+		past := time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": past})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Missing start_time rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", path, organizerToken, gin.H{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Only the creator can duplicate", func(t *testing.T) {
+		w := doJSON(router, "POST", path, otherToken, gin.H{"start_time": nextWeek.Format(time.RFC3339)})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Copy stays in the group and tells its members", func(t *testing.T) {
+		result, err := testDB.Exec(`INSERT INTO groups (name, slug, owner_id) VALUES ('Board Gamers', 'board-gamers', ?)`, organizerID)
+		require.NoError(t, err)
+		groupID, _ := result.LastInsertId()
+		_, err = testDB.Exec(`INSERT INTO group_members (group_id, user_id) VALUES (?, ?), (?, ?)`, groupID, organizerID, groupID, otherID)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`UPDATE events SET group_id = ? WHERE id = ?`, groupID, eventID)
+		require.NoError(t, err)
+		defer testDB.Exec(`UPDATE events SET group_id = NULL WHERE id = ?`, eventID)
+
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(time.Hour).Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var copy Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &copy))
+		require.NotNil(t, copy.GroupID)
+		assert.Equal(t, int(groupID), *copy.GroupID)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM activity_log WHERE user_id = ? AND verb = ? AND event_id = ?`,
+			otherID, ActivityPosted, copy.ID))
+
+		// Posting rights are checked again
+		_, err = testDB.Exec(`UPDATE groups SET owner_id = ? WHERE id = ?`, otherID, groupID)
+		require.NoError(t, err)
+		w = doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(2 * time.Hour).Format(time.RFC3339)})
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	t.Run("Copy keeps the price", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET price_amount = 2500, price_currency = 'EUR', payment_note = ? WHERE id = ?`,
+			"Cash &amp; card at the door", eventID)
+		require.NoError(t, err)
+		defer testDB.Exec(`UPDATE events SET price_amount = NULL, price_currency = 'CHF', payment_note = '' WHERE id = ?`, eventID)
+
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(3 * time.Hour).Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var copy Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &copy))
+		require.NotNil(t, copy.PriceAmount)
+		assert.Equal(t, 2500, *copy.PriceAmount)
+		assert.Equal(t, "EUR", copy.PriceCurrency)
+		assert.Equal(t, "Cash &amp; card at the door", copy.PaymentNote)
+	})
+
+	t.Run("Copy keeps the per-network join limit", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET max_joins_per_network = 2 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		defer testDB.Exec(`UPDATE events SET max_joins_per_network = 0 WHERE id = ?`, eventID)
+
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(4 * time.Hour).Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var copy Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &copy))
+		assert.Equal(t, 2, copy.NetworkJoinLimit)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND max_joins_per_network = 2`, copy.ID))
+	})
+
+	t.Run("Copy keeps the reserved spots", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET reserved_spots = 3 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		defer testDB.Exec(`UPDATE events SET reserved_spots = 0 WHERE id = ?`, eventID)
+
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(5 * time.Hour).Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var copy Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &copy))
+		assert.Equal(t, 3, copy.ReservedSpots)
+		require.NotNil(t, copy.SpotsLeft)
+		assert.Equal(t, 5, *copy.SpotsLeft)
+
+		// More reservations than seats are checked again rather than copied
+		_, err = testDB.Exec(`UPDATE events SET reserved_spots = 9 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		w = doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Add(6 * time.Hour).Format(time.RFC3339)})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Unverified email rejected", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, organizerID)
+		require.NoError(t, err)
+
+		w := doJSON(router, "POST", path, organizerToken, gin.H{"start_time": nextWeek.Format(time.RFC3339)})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -5,8 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"html"
 	"log"
-	"os"
 	"time"
 
 	"github.com/mailgun/mailgun-go/v4"
@@ -17,13 +17,17 @@ type EmailService struct {
 	mg     *mailgun.MailgunImpl
 	domain string
 	from   string
+	send   mailSender
 }
 
+// mailSender hands a built message to the provider and returns its message ID; tests swap in fakes
+type mailSender func(ctx context.Context, message *mailgun.Message) (string, error)
+
 // NewEmailService creates a new email service instance
 func NewEmailService() *EmailService {
-	domain := os.Getenv("MAILGUN_DOMAIN")
-	apiKey := os.Getenv("MAILGUN_API_KEY")
-	from := os.Getenv("MAILGUN_FROM_EMAIL")
+	domain := appConfig.MailgunDomain
+	apiKey := appConfig.MailgunAPIKey
+	from := appConfig.MailgunFromEmail
 
 	if domain == "" || apiKey == "" {
 		log.Println("⚠️  Mailgun not configured - email features disabled")
@@ -42,9 +46,23 @@ func NewEmailService() *EmailService {
 		mg:     mg,
 		domain: domain,
 		from:   from,
+		send: func(ctx context.Context, message *mailgun.Message) (string, error) {
+			_, id, err := mg.Send(ctx, message)
+			return id, err
+		},
 	}
 }
 
+// deliver sends message to recipient and records the attempt in email_log
+func (s *EmailService) deliver(emailType, recipient string, message *mailgun.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	providerID, err := s.send(ctx, message)
+	recordEmailAttempt(emailType, recipient, providerID, err)
+	return err
+}
+
 // generateEmailToken generates a secure random token for email verification
 func generateEmailToken() (string, error) {
 	bytes := make([]byte, 32)
@@ -61,12 +79,10 @@ func (s *EmailService) SendVerificationEmail(email, name, token string) error {
 		return nil
 	}
 
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:5173"
-	}
+	baseURL := frontendBaseURL()
 
 	verificationLink := fmt.Sprintf("%s/verify-email?token=%s", baseURL, token)
+	expiresIn := describeTTL(appConfig.VerificationTokenTTL)
 
 	subject := "Verify your Veidly account"
 	htmlBody := fmt.Sprintf(`
@@ -96,7 +112,7 @@ func (s *EmailService) SendVerificationEmail(email, name, token string) error {
             </p>
             <p>Or copy and paste this link into your browser:</p>
             <p style="word-break: break-all; color: #667eea;">%s</p>
-            <p><strong>This link will expire in 24 hours.</strong></p>
+            <p><strong>This link will expire in %s.</strong></p>
             <p>If you didn't create an account, you can safely ignore this email.</p>
         </div>
         <div class="footer">
@@ -105,7 +121,7 @@ func (s *EmailService) SendVerificationEmail(email, name, token string) error {
     </div>
 </body>
 </html>
-`, name, verificationLink, verificationLink)
+`, name, verificationLink, verificationLink, expiresIn)
 
 	textBody := fmt.Sprintf(`
 Hi %s,
@@ -115,20 +131,17 @@ Thank you for signing up for Veidly!
 Please verify your email address by clicking the link below:
 %s
 
-This link will expire in 24 hours.
+This link will expire in %s.
 
 If you didn't create an account, you can safely ignore this email.
 
 © 2025 Veidly - Connect and meet new people
-`, name, verificationLink)
+`, name, verificationLink, expiresIn)
 
 	message := s.mg.NewMessage(s.from, subject, textBody, email)
 	message.SetHtml(htmlBody)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-
-	_, _, err := s.mg.Send(ctx, message)
+	err := s.deliver(EmailTypeVerification, email, message)
 	if err != nil {
 		log.Printf("❌ Failed to send verification email to %s: %v", email, err)
 		return err
@@ -145,12 +158,10 @@ func (s *EmailService) SendPasswordResetEmail(email, name, token string) error {
 		return nil
 	}
 
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:5173"
-	}
+	baseURL := frontendBaseURL()
 
 	resetLink := fmt.Sprintf("%s/reset-password?token=%s", baseURL, token)
+	expiresIn := describeTTL(appConfig.PasswordResetTokenTTL)
 
 	subject := "Reset your Veidly password"
 	htmlBody := fmt.Sprintf(`
@@ -183,7 +194,7 @@ func (s *EmailService) SendPasswordResetEmail(email, name, token string) error {
             <p style="word-break: break-all; color: #667eea;">%s</p>
             <div class="warning">
                 <p><strong>⚠️ Security Notice:</strong></p>
-                <p>This link will expire in 1 hour. If you didn't request a password reset, please ignore this email and your password will remain unchanged.</p>
+                <p>This link will expire in %s. If you didn't request a password reset, please ignore this email and your password will remain unchanged.</p>
             </div>
         </div>
         <div class="footer">
@@ -192,7 +203,7 @@ func (s *EmailService) SendPasswordResetEmail(email, name, token string) error {
     </div>
 </body>
 </html>
-`, name, resetLink, resetLink)
+`, name, resetLink, resetLink, expiresIn)
 
 	textBody := fmt.Sprintf(`
 Hi %s,
@@ -202,20 +213,17 @@ We received a request to reset your password for your Veidly account.
 Click the link below to reset your password:
 %s
 
-This link will expire in 1 hour.
+This link will expire in %s.
 
 If you didn't request a password reset, please ignore this email and your password will remain unchanged.
 
 © 2025 Veidly - Connect and meet new people
-`, name, resetLink)
+`, name, resetLink, expiresIn)
 
 	message := s.mg.NewMessage(s.from, subject, textBody, email)
 	message.SetHtml(htmlBody)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-
-	_, _, err := s.mg.Send(ctx, message)
+	err := s.deliver(EmailTypePasswordReset, email, message)
 	if err != nil {
 		log.Printf("❌ Failed to send password reset email to %s: %v", email, err)
 		return err
@@ -231,10 +239,7 @@ func (s *EmailService) SendWelcomeEmail(email, name string) error {
 		return nil
 	}
 
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:5173"
-	}
+	baseURL := frontendBaseURL()
 
 	subject := "Welcome to Veidly - Let's get started!"
 	htmlBody := fmt.Sprintf(`
@@ -301,10 +306,7 @@ Have fun connecting with people!
 	message := s.mg.NewMessage(s.from, subject, textBody, email)
 	message.SetHtml(htmlBody)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-
-	_, _, err := s.mg.Send(ctx, message)
+	err := s.deliver(EmailTypeWelcome, email, message)
 	if err != nil {
 		log.Printf("❌ Failed to send welcome email to %s: %v", email, err)
 		return err
@@ -313,3 +315,142 @@ Have fun connecting with people!
 	log.Printf("✓ Welcome email sent to %s", email)
 	return nil
 }
+
+// SendModerationEmail sends a short moderation notice (report takedowns and their outcome)
+func (s *EmailService) SendModerationEmail(email, name, subject, message, link string) error {
+	if s == nil {
+		log.Println("⚠️  Email service not available - skipping moderation email")
+		return nil
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .content { background: #f9f9f9; padding: 30px; border-radius: 10px; }
+        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="content">
+            <p>Hi %s,</p>
+            <p>%s</p>
+            <p><a href="%s">%s</a></p>
+        </div>
+        <div class="footer">
+            <p>© 2025 Veidly - Connect and meet new people</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(name), html.EscapeString(message), link, link)
+
+	textBody := fmt.Sprintf(`
+Hi %s,
+
+%s
+
+%s
+
+© 2025 Veidly - Connect and meet new people
+`, name, message, link)
+
+	msg := s.mg.NewMessage(s.from, subject, textBody, email)
+	msg.SetHtml(htmlBody)
+
+	if err := s.deliver(EmailTypeModeration, email, msg); err != nil {
+		log.Printf("❌ Failed to send moderation email to %s: %v", email, err)
+		return err
+	}
+
+	log.Printf("✓ Moderation email sent to %s", email)
+	return nil
+}
+
+// SendOrganizerDigestEmail sends the monthly summary of an organizer's events
+func (s *EmailService) SendOrganizerDigestEmail(email, name string, stats *OrganizerStats) error {
+	if s == nil {
+		log.Println("⚠️  Email service not available - skipping organizer digest")
+		return nil
+	}
+
+	lines := []string{
+		fmt.Sprintf("Events held: %d", stats.EventsHeld),
+		fmt.Sprintf("Total participants: %d", stats.TotalParticipants),
+	}
+	if stats.AverageFillRate != nil {
+		lines = append(lines, fmt.Sprintf("Average fill rate: %.0f%%", *stats.AverageFillRate*100))
+	}
+	if stats.TopEvent != nil {
+		lines = append(lines, fmt.Sprintf("Top event: %s (%d participants)", html.UnescapeString(stats.TopEvent.Title), stats.TopEvent.Participants))
+	}
+	if stats.FeedbackAverage != nil {
+		lines = append(lines, fmt.Sprintf("Average rating: %.1f / 5 from %d reviews", *stats.FeedbackAverage, stats.FeedbackCount))
+	}
+
+	var htmlItems, textItems string
+	for _, line := range lines {
+		htmlItems += "<li>" + html.EscapeString(line) + "</li>"
+		textItems += "- " + line + "\n"
+	}
+	link := frontendBaseURL() + "/profile"
+	subject := fmt.Sprintf("Your Veidly events in %s", stats.monthName())
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .content { background: #f9f9f9; padding: 30px; border-radius: 10px; }
+        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="content">
+            <p>Hi %s,</p>
+            <p>Here is how your events went in %s:</p>
+            <ul>%s</ul>
+            <p><a href="%s">See your profile</a></p>
+        </div>
+        <div class="footer">
+            <p>You can turn these monthly emails off in your profile settings.</p>
+            <p>© 2025 Veidly - Connect and meet new people</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(name), stats.monthName(), htmlItems, link)
+
+	textBody := fmt.Sprintf(`
+Hi %s,
+
+Here is how your events went in %s:
+
+%s
+See your profile: %s
+
+You can turn these monthly emails off in your profile settings.
+
+© 2025 Veidly - Connect and meet new people
+`, name, stats.monthName(), textItems, link)
+
+	msg := s.mg.NewMessage(s.from, subject, textBody, email)
+	msg.SetHtml(htmlBody)
+
+	if err := s.deliver(EmailTypeOrganizerDigest, email, msg); err != nil {
+		log.Printf("❌ Failed to send organizer digest to %s: %v", email, err)
+		return err
+	}
+
+	log.Printf("✓ Organizer digest sent to %s", email)
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mailgun/mailgun-go/v4"
+)
+
+// Email types recorded in email_log
+const (
+	EmailTypeVerification    = "verification"
+	EmailTypePasswordReset   = "password_reset"
+	EmailTypeWelcome         = "welcome"
+	EmailTypeModeration      = "moderation"
+	EmailTypeOrganizerDigest = "organizer_digest"
+)
+
+// Delivery statuses recorded in email_log
+const (
+	EmailStatusSent   = "sent"
+	EmailStatusFailed = "failed"
+)
+
+const (
+	emailLogRetention = 90 * 24 * time.Hour
+	emailLogListLimit = 100
+)
+
+// EmailLogEntry is one row of email_log: a single attempt to hand an email to the provider
+type EmailLogEntry struct {
+	ID                int       `json:"id"`
+	UserID            *int      `json:"user_id"` // null when the recipient has no account (any more)
+	Recipient         string    `json:"recipient"`
+	Type              string    `json:"type"`
+	Status            string    `json:"status"`
+	ProviderMessageID string    `json:"provider_message_id,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// recordEmailAttempt logs one delivery attempt. The user is found by address, so mails to people
+// without an account are still recorded. A failure to write the log is only logged.
+func recordEmailAttempt(emailType, recipient, providerID string, sendErr error) {
+	status, errText := EmailStatusSent, ""
+	if sendErr != nil {
+		status, errText = EmailStatusFailed, sendErr.Error()
+	}
+	if _, err := db.Exec(`
+		INSERT INTO email_log (user_id, recipient, email_type, status, provider_message_id, error)
+		VALUES ((SELECT id FROM users WHERE email = ?), ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))
+	`, normalizeEmail(recipient), recipient, emailType, status, providerID, errText); err != nil {
+		log.Printf("⚠️  Failed to record %s email to %s: %v", emailType, recipient, err)
+	}
+}
+
+// emailRejected reports whether the provider refused the message itself (a malformed or
+// suppressed address) rather than failing temporarily, so retrying can't help
+func emailRejected(err error) bool {
+	status := mailgun.GetStatusFromErr(err)
+	return status >= 400 && status < 500 && status != http.StatusTooManyRequests
+}
+
+// markVerificationEmailFailed flags the recipient's account once their verification email has
+// permanently failed, so getCurrentUser can ask them to check the address or resend
+func markVerificationEmailFailed(ctx context.Context, payload json.RawMessage, _ error) error {
+	var p emailJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET verification_email_failed = 1 WHERE email = ? AND email_verified = 0
+	`, normalizeEmail(p.Email))
+	return err
+}
+
+// clearVerificationEmailFailed drops the flag once a verification email to address went out
+func clearVerificationEmailFailed(ctx context.Context, address string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET verification_email_failed = 0 WHERE email = ? AND verification_email_failed = 1
+	`, normalizeEmail(address))
+	return err
+}
+
+// adminGetEmailLog lists the most recent email attempts for support, optionally for one user and
+// one status (GET /api/admin/email-log?user_id=&status=failed)
+func adminGetEmailLog(c *gin.Context) {
+	query := `
+		SELECT id, user_id, recipient, email_type, status, provider_message_id, error, created_at
+		FROM email_log WHERE 1=1`
+	var args []interface{}
+
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.Atoi(raw)
+		if err != nil || userID < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	if status := c.Query("status"); status != "" {
+		if status != EmailStatusSent && status != EmailStatusFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, emailLogListLimit)
+
+	rows, err := db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		log.Printf("❌ Failed to query email log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve email log"})
+		return
+	}
+	defer rows.Close()
+
+	entries := []EmailLogEntry{}
+	for rows.Next() {
+		var entry EmailLogEntry
+		var userID sql.NullInt64
+		var providerID, errText sql.NullString
+		if err := rows.Scan(&entry.ID, &userID, &entry.Recipient, &entry.Type, &entry.Status,
+			&providerID, &errText, &entry.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning email log entry: %v", err)
+			continue
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			entry.UserID = &id
+		}
+		entry.ProviderMessageID = providerID.String
+		entry.Error = errText.String
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Error reading email log: %v", err)
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mailgun/mailgun-go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useFakeMailer configures an email service whose messages go to send instead of Mailgun
+func useFakeMailer(t *testing.T, send mailSender) {
+	t.Helper()
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
+	previous := emailService
+	emailService = NewEmailService()
+	emailService.send = send
+	t.Cleanup(func() { emailService = previous })
+}
+
+func TestEmailDeliveryFailures(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	// Mailgun answers 400 for addresses it won't send to and 503 when it is having trouble
+	var sendErr error
+	sent := 0
+	useFakeMailer(t, func(ctx context.Context, message *mailgun.Message) (string, error) {
+		if sendErr != nil {
+			return "", sendErr
+		}
+		sent++
+		return fmt.Sprintf("<%d@mg.example.com>", sent), nil
+	})
+	rejected := &mailgun.UnexpectedResponseError{Expected: []int{200}, Actual: 400, Data: []byte(`{"message":"'to' parameter is not a valid address"}`)}
+	unavailable := &mailgun.UnexpectedResponseError{Expected: []int{200}, Actual: 503}
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+	router.POST("/api/auth/resend-verification", ResendVerificationEmail)
+	router.GET("/api/auth/me", authMiddleware(), getCurrentUser)
+	router.GET("/api/admin/email-log", authMiddleware(), adminMiddleware(), adminGetEmailLog)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	runner := newJobRunner(0, time.Hour, time.Hour) // The test runs the queued emails itself
+
+	signUp := func(email string) (int, string) {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": email, "password": "password123", "name": "Newbie"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var body AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		ran, err := runner.runNext()
+		require.NoError(t, err)
+		require.True(t, ran)
+		return body.User.ID, body.Token
+	}
+	failedFlag := func(token string) bool {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/auth/me", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			User map[string]interface{} `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.User["verification_email_failed"] == true
+	}
+	emailLog := func(query string) []EmailLogEntry {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/admin/email-log"+query, adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var entries []EmailLogEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		return entries
+	}
+
+	t.Run("A rejected address flags the account at once", func(t *testing.T) {
+		sendErr = rejected
+		userID, token := signUp("typo@exmaple.con")
+
+		var status string
+		var attempts int
+		require.NoError(t, db.QueryRow(`SELECT status, attempts FROM jobs WHERE type = ?`, JobSendVerificationEmail).Scan(&status, &attempts))
+		assert.Equal(t, JobStatusDead, status, "retrying a refused address can't help")
+		assert.Equal(t, 1, attempts)
+		assert.True(t, failedFlag(token))
+
+		entries := emailLog(fmt.Sprintf("?user_id=%d", userID))
+		require.Len(t, entries, 1)
+		assert.Equal(t, EmailStatusFailed, entries[0].Status)
+		assert.Equal(t, EmailTypeVerification, entries[0].Type)
+		assert.Equal(t, "typo@exmaple.con", entries[0].Recipient)
+		assert.Contains(t, entries[0].Error, "not a valid address")
+		assert.Empty(t, entries[0].ProviderMessageID)
+
+		t.Run("A successful resend clears the flag", func(t *testing.T) {
+			sendErr = nil
+			w := doJSON(router, "POST", "/api/auth/resend-verification", "", gin.H{"email": "typo@exmaple.con"})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.False(t, failedFlag(token))
+
+			entries := emailLog(fmt.Sprintf("?user_id=%d", userID))
+			require.Len(t, entries, 2)
+			assert.Equal(t, EmailStatusSent, entries[0].Status, "newest first")
+			assert.Equal(t, "<1@mg.example.com>", entries[0].ProviderMessageID)
+			assert.Empty(t, entries[0].Error)
+		})
+	})
+
+	t.Run("Temporary failures are retried without flagging", func(t *testing.T) {
+		sendErr = unavailable
+		_, token := signUp("busy@example.com")
+
+		var status string
+		require.NoError(t, db.QueryRow(`SELECT status FROM jobs WHERE type = ? ORDER BY id DESC LIMIT 1`, JobSendVerificationEmail).Scan(&status))
+		assert.Equal(t, JobStatusPending, status)
+		assert.False(t, failedFlag(token))
+	})
+
+	t.Run("Failures can be listed across users", func(t *testing.T) {
+		failed := emailLog("?status=failed")
+		require.Len(t, failed, 2)
+		for _, entry := range failed {
+			assert.Equal(t, EmailStatusFailed, entry.Status)
+			require.NotNil(t, entry.UserID)
+		}
+		assert.Len(t, emailLog(""), 3)
+		w := doJSON(router, "GET", "/api/admin/email-log?status=bounced", adminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
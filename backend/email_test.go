@@ -1,7 +1,6 @@
 package main
 
 import (
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,20 +8,12 @@ import (
 )
 
 func TestNewEmailService(t *testing.T) {
-	// Save original env vars
-	origDomain := os.Getenv("MAILGUN_DOMAIN")
-	origAPIKey := os.Getenv("MAILGUN_API_KEY")
-	origFromEmail := os.Getenv("MAILGUN_FROM_EMAIL")
-	defer func() {
-		os.Setenv("MAILGUN_DOMAIN", origDomain)
-		os.Setenv("MAILGUN_API_KEY", origAPIKey)
-		os.Setenv("MAILGUN_FROM_EMAIL", origFromEmail)
-	}()
-
 	// Test with valid credentials
-	os.Setenv("MAILGUN_DOMAIN", "mg.example.com")
-	os.Setenv("MAILGUN_API_KEY", "test-api-key-123")
-	os.Setenv("MAILGUN_FROM_EMAIL", "noreply@example.com")
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key-123"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
 
 	service := NewEmailService()
 	assert.NotNil(t, service)
@@ -49,9 +40,11 @@ func TestGenerateEmailToken(t *testing.T) {
 
 func TestSendVerificationEmail(t *testing.T) {
 	// Setup test email service (won't actually send emails)
-	os.Setenv("MAILGUN_DOMAIN", "mg.example.com")
-	os.Setenv("MAILGUN_API_KEY", "test-api-key")
-	os.Setenv("MAILGUN_FROM_EMAIL", "noreply@example.com")
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
 
 	service := NewEmailService()
 
@@ -89,9 +82,11 @@ func TestSendVerificationEmail(t *testing.T) {
 }
 
 func TestSendPasswordResetEmail(t *testing.T) {
-	os.Setenv("MAILGUN_DOMAIN", "mg.example.com")
-	os.Setenv("MAILGUN_API_KEY", "test-api-key")
-	os.Setenv("MAILGUN_FROM_EMAIL", "noreply@example.com")
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
 
 	service := NewEmailService()
 
@@ -118,9 +113,11 @@ func TestSendPasswordResetEmail(t *testing.T) {
 }
 
 func TestSendWelcomeEmail(t *testing.T) {
-	os.Setenv("MAILGUN_DOMAIN", "mg.example.com")
-	os.Setenv("MAILGUN_API_KEY", "test-api-key")
-	os.Setenv("MAILGUN_FROM_EMAIL", "noreply@example.com")
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
 
 	service := NewEmailService()
 
@@ -151,9 +148,11 @@ func TestSendWelcomeEmail(t *testing.T) {
 
 func TestEmailServiceNilSafety(t *testing.T) {
 	// Test that email service handles missing configuration gracefully
-	os.Unsetenv("MAILGUN_DOMAIN")
-	os.Unsetenv("MAILGUN_API_KEY")
-	os.Unsetenv("MAILGUN_FROM_EMAIL")
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = ""
+		cfg.MailgunAPIKey = ""
+		cfg.MailgunFromEmail = ""
+	})
 
 	service := NewEmailService()
 
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
+)
+
+// normalizeEmail is the stored form of an email address: trimmed and lower-cased, so
+// Foo@example.com and foo@example.com are one account
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailLookup is the WHERE tail for finding a user by an address as typed; pass emailLookupArgs.
+// It matches the normalized address, or the exact casing for accounts lowercaseUserEmails left
+// alone because of a collision, preferring the exact match so those accounts sign in as before.
+const emailLookup = `email IN (?, ?) ORDER BY email = ? DESC LIMIT 1`
+
+func emailLookupArgs(typed string) []interface{} {
+	typed = strings.TrimSpace(typed)
+	return []interface{}{typed, normalizeEmail(typed), typed}
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// EmailCollision is a set of accounts whose emails differ only by case. They predate email
+// normalization; an admin resolves them by merging (POST /api/admin/users/:id/merge).
+type EmailCollision struct {
+	Email   string `json:"email"` // The normalized address
+	UserIDs []int  `json:"user_ids"`
+}
+
+// lowercaseUserEmails normalizes stored emails. Addresses that would collide with another account
+// are left unchanged and returned, not merged: which account to keep is an admin's call. Once no
+// collisions remain, a unique index on lower(email) keeps new ones out.
+func lowercaseUserEmails(db *sql.DB) ([]EmailCollision, error) {
+	result, err := db.Exec(`
+		UPDATE users SET email = lower(trim(email))
+		WHERE email != lower(trim(email))
+		  AND (SELECT COUNT(*) FROM users other WHERE lower(trim(other.email)) = lower(trim(users.email))) = 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("✓ Normalized %d user emails to lower case", n)
+	}
+	collisions, err := emailCollisions(context.Background(), db)
+	if err != nil || len(collisions) > 0 {
+		return collisions, err
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email))`); err != nil {
+		return nil, err
+	}
+	return collisions, nil
+}
+
+// emailCollisions lists accounts whose emails are equal ignoring case, oldest account first
+func emailCollisions(ctx context.Context, q *sql.DB) ([]EmailCollision, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT lower(trim(email)), GROUP_CONCAT(id)
+		FROM (SELECT id, email FROM users ORDER BY id)
+		GROUP BY lower(trim(email))
+		HAVING COUNT(*) > 1
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collisions := []EmailCollision{}
+	for rows.Next() {
+		var collision EmailCollision
+		var ids string
+		if err := rows.Scan(&collision.Email, &ids); err != nil {
+			return nil, err
+		}
+		for _, id := range strings.Split(ids, ",") {
+			if n, err := strconv.Atoi(id); err == nil {
+				collision.UserIDs = append(collision.UserIDs, n)
+			}
+		}
+		collisions = append(collisions, collision)
+	}
+	return collisions, rows.Err()
+}
+
+// adminGetEmailCollisions lists accounts whose emails differ only by case
+// (GET /api/admin/users/email-collisions)
+func adminGetEmailCollisions(c *gin.Context) {
+	log.Println("📋 GET /api/admin/users/email-collisions - Admin fetching email collisions")
+	collisions, err := emailCollisions(c.Request.Context(), db)
+	if err != nil {
+		log.Printf("❌ Error listing email collisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list email collisions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collisions": collisions})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "foo@example.com", normalizeEmail("  Foo@Example.COM "))
+	assert.Equal(t, "foo@example.com", normalizeEmail("foo@example.com"))
+}
+
+func TestEmailCaseInsensitiveAccounts(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+	router.POST("/api/auth/login", login)
+
+	registerAs := func(email string) int {
+		return doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": email, "password": "password123", "name": "Foo"}).Code
+	}
+
+	t.Run("Mixed-case signup then lowercase login", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "Foo.Bar@Example.com", "password": "password123", "name": "Foo"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "foo.bar@example.com", resp.User.Email)
+
+		for _, email := range []string{"foo.bar@example.com", "Foo.Bar@Example.com", "FOO.BAR@EXAMPLE.COM"} {
+			w := doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": email, "password": "password123"})
+			assert.Equal(t, http.StatusOK, w.Code, "login as %s: %s", email, w.Body.String())
+		}
+	})
+
+	t.Run("Duplicate differing only by case is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, registerAs("foo.bar@EXAMPLE.com"))
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Other database errors are not reported as a conflict", func(t *testing.T) {
+		_, err := testDB.Exec(`CREATE TRIGGER users_unavailable BEFORE INSERT ON users BEGIN SELECT RAISE(ABORT, 'disk I/O error'); END`)
+		require.NoError(t, err)
+		defer testDB.Exec(`DROP TRIGGER users_unavailable`)
+
+		assert.Equal(t, http.StatusInternalServerError, registerAs("someone.new@example.com"))
+	})
+}
+
+func TestLowercaseUserEmails(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	for _, email := range []string{"Anna@Example.com", "bob@example.com", "carl@example.com", "Carl@Example.com", "DORA@example.com", "Dora@example.com"} {
+		_, err := testDB.Exec(`INSERT INTO users (email, password, name) VALUES (?, 'x', 'User')`, email)
+		require.NoError(t, err)
+	}
+
+	collisions, err := lowercaseUserEmails(testDB)
+	require.NoError(t, err)
+	assert.Equal(t, []EmailCollision{
+		{Email: "carl@example.com", UserIDs: []int{3, 4}},
+		{Email: "dora@example.com", UserIDs: []int{5, 6}},
+	}, collisions)
+
+	emails := func() []string {
+		rows, err := testDB.Query(`SELECT email FROM users ORDER BY id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		var emails []string
+		for rows.Next() {
+			var email string
+			require.NoError(t, rows.Scan(&email))
+			emails = append(emails, email)
+		}
+		return emails
+	}
+	assert.Equal(t, []string{"anna@example.com", "bob@example.com", "carl@example.com", "Carl@Example.com", "DORA@example.com", "Dora@example.com"}, emails(),
+		"colliding addresses are reported, not rewritten")
+
+	t.Run("Colliding accounts still sign in with their own casing", func(t *testing.T) {
+		var id int
+		require.NoError(t, testDB.QueryRow(`SELECT id FROM users WHERE `+emailLookup, emailLookupArgs("Dora@example.com")...).Scan(&id))
+		assert.Equal(t, 6, id)
+		require.NoError(t, testDB.QueryRow(`SELECT id FROM users WHERE `+emailLookup, emailLookupArgs("ANNA@example.com")...).Scan(&id))
+		assert.Equal(t, 1, id)
+	})
+
+	t.Run("Registering an address that matches a colliding account is refused", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/api/auth/register", register)
+		w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "dora@EXAMPLE.com", "password": "password123", "name": "Dora"})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT COUNT(*) FROM users WHERE lower(email) = 'dora@example.com'`))
+	})
+
+	t.Run("Admins see the collisions", func(t *testing.T) {
+		adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+		adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+		router := gin.New()
+		router.GET("/api/admin/users/email-collisions", authMiddleware(), adminMiddleware(), adminGetEmailCollisions)
+
+		w := doJSON(router, "GET", "/api/admin/users/email-collisions", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Collisions []EmailCollision `json:"collisions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, collisions, body.Collisions)
+	})
+}
+
+func TestLowercaseUserEmailsIndex(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+
+	for _, email := range []string{"Carl@Example.com", "carl@example.com"} {
+		_, err := testDB.Exec(`INSERT INTO users (email, password, name) VALUES (?, 'x', 'User')`, email)
+		require.NoError(t, err)
+	}
+	_, err := lowercaseUserEmails(testDB)
+	require.NoError(t, err)
+	assert.Zero(t, countRows(t, testDB, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'idx_users_email_lower'`),
+		"no index while collisions remain")
+
+	// The admin merges the accounts
+	_, err = testDB.Exec(`UPDATE users SET email = 'merged-1-into-2@veidly.invalid' WHERE email = 'Carl@Example.com'`)
+	require.NoError(t, err)
+	collisions, err := lowercaseUserEmails(testDB)
+	require.NoError(t, err)
+	assert.Empty(t, collisions)
+
+	_, err = testDB.Exec(`INSERT INTO users (email, password, name) VALUES ('CARL@example.com', 'x', 'User')`)
+	assert.True(t, isUniqueViolation(err), "got %v", err)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// weakETag hashes a response body together with the viewer it was rendered for. Responses are
+// privacy-filtered per viewer, so the same tag never validates another viewer's variant.
+func weakETag(viewerID int, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(viewerID)))
+	h.Write([]byte{0})
+	h.Write(body)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag (weak comparison)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// respondJSONWithETag writes obj as JSON with an ETag, or 304 Not Modified if the client's
+// If-None-Match already matches
+func respondJSONWithETag(c *gin.Context, viewerID int, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		log.Printf("❌ Error encoding response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+
+	etag := weakETag(viewerID, body)
+	c.Header("ETag", etag)
+	if viewerID > 0 {
+		// Per-viewer variants must not be stored by shared caches
+		c.Header("Cache-Control", "private, no-cache")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventETags(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Conditional Event")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'conditional-event' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	conditionalGet := func(path, token, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/events", fmt.Sprintf("/api/events/%d", eventID), "/api/public/events/conditional-event"} {
+		t.Run("Second conditional request returns 304 for "+path, func(t *testing.T) {
+			w := conditionalGet(path, userToken, "")
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			w = conditionalGet(path, userToken, etag)
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Empty(t, w.Body.String())
+		})
+	}
+
+	t.Run("ETag is bound to the viewer", func(t *testing.T) {
+		path := "/api/public/events/conditional-event"
+		w := conditionalGet(path, organizerToken, "")
+		organizerETag := w.Header().Get("ETag")
+
+		w = conditionalGet(path, userToken, organizerETag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = conditionalGet(path, "", organizerETag)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ETag changes after joinEvent", func(t *testing.T) {
+		path := "/api/public/events/conditional-event"
+		before := conditionalGet(path, userToken, "").Header().Get("ETag")
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = conditionalGet(path, userToken, before)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, before, w.Header().Get("ETag"))
+	})
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	assert.True(t, etagMatches(`W/"abc"`, etag))
+	assert.True(t, etagMatches(`"abc"`, etag), "weak comparison ignores the W/ prefix")
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, etag))
+	assert.True(t, etagMatches(`*`, etag))
+	assert.False(t, etagMatches(`W/"xyz"`, etag))
+	assert.False(t, etagMatches("", etag))
+}
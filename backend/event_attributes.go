@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrCodeInvalidAttributes answers attributes the event's category doesn't define or allow
+const ErrCodeInvalidAttributes = "INVALID_ATTRIBUTES"
+
+// attributeFilterPrefix marks listing params that filter on an attribute (attr.skill_level=beginner)
+const attributeFilterPrefix = "attr."
+
+// AttributeSpec is one category-specific event field: either one of Values, or free text of up
+// to MaxLength characters
+type AttributeSpec struct {
+	Key       string   `json:"key"`
+	Values    []string `json:"values,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+}
+
+// CategoryAttributes are the extra fields each category accepts; categories not listed have none
+var CategoryAttributes = map[string][]AttributeSpec{
+	"sports_fitness":  {{Key: "skill_level", Values: []string{"beginner", "intermediate", "advanced"}}},
+	"food_dining":     {{Key: "cuisine", MaxLength: 40}},
+	"learning_skills": {{Key: "topic", MaxLength: 60}},
+}
+
+// ErrInvalidAttributeFilter is returned for attr.* params that no category defines
+var ErrInvalidAttributeFilter = errors.New("attr.* filters must name a known attribute, e.g. attr.skill_level")
+
+// AttributeError lists what is wrong with each attribute, keyed by field ("attributes.cuisine")
+type AttributeError struct {
+	Fields map[string]string
+}
+
+func (e *AttributeError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, problem := range e.Fields {
+		fields = append(fields, field+": "+problem)
+	}
+	sort.Strings(fields)
+	return "invalid attributes (" + strings.Join(fields, "; ") + ")"
+}
+
+// attributeSpec finds key in the category's schema
+func attributeSpec(category, key string) (AttributeSpec, bool) {
+	for _, spec := range CategoryAttributes[category] {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return AttributeSpec{}, false
+}
+
+// anyAttributeSpec finds key in whichever category defines it (keys are unique across categories)
+func anyAttributeSpec(key string) (AttributeSpec, bool) {
+	for category := range CategoryAttributes {
+		if spec, ok := attributeSpec(category, key); ok {
+			return spec, true
+		}
+	}
+	return AttributeSpec{}, false
+}
+
+// normalizeAttributeValue trims a value; listed values are matched case-insensitively and free
+// text is HTML-escaped like the other free-text event fields
+func normalizeAttributeValue(spec AttributeSpec, value string) string {
+	value = strings.TrimSpace(value)
+	if len(spec.Values) > 0 {
+		return strings.ToLower(value)
+	}
+	return html.EscapeString(value)
+}
+
+// ValidateEventAttributes checks event.Attributes against the category's schema and normalizes
+// the values. Empty values are dropped, so sending "" clears an attribute.
+func ValidateEventAttributes(event *Event) error {
+	if len(event.Attributes) == 0 {
+		return nil
+	}
+	problems := map[string]string{}
+	for key, value := range event.Attributes {
+		field := "attributes." + key
+		spec, ok := attributeSpec(event.Category, key)
+		if !ok {
+			problems[field] = fmt.Sprintf("not an attribute of category %s", event.Category)
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			delete(event.Attributes, key)
+			continue
+		}
+		if len(spec.Values) > 0 {
+			if !containsString(spec.Values, strings.ToLower(value)) {
+				problems[field] = "must be one of: " + strings.Join(spec.Values, ", ")
+				continue
+			}
+		} else if utf8.RuneCountInString(value) > spec.MaxLength {
+			problems[field] = fmt.Sprintf("must be at most %d characters", spec.MaxLength)
+			continue
+		}
+		event.Attributes[key] = normalizeAttributeValue(spec, value)
+	}
+	if len(problems) > 0 {
+		return &AttributeError{Fields: problems}
+	}
+	return nil
+}
+
+// sortedKeys returns the map's keys in order, so filters build the same SQL and cache key every time
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// saveEventAttributes stores an event's attributes. A nil map (the field left out of an update)
+// keeps the stored ones, minus those the category no longer has; any other map replaces them.
+func saveEventAttributes(exec sqlExecer, eventID int, category string, attributes map[string]string) error {
+	if attributes == nil {
+		keys := []interface{}{eventID}
+		placeholders := []string{}
+		for _, spec := range CategoryAttributes[category] {
+			keys = append(keys, spec.Key)
+			placeholders = append(placeholders, "?")
+		}
+		query := `DELETE FROM event_attributes WHERE event_id = ?`
+		if len(placeholders) > 0 {
+			query += ` AND key NOT IN (` + strings.Join(placeholders, ", ") + `)`
+		}
+		_, err := exec.Exec(query, keys...)
+		return err
+	}
+
+	if _, err := exec.Exec(`DELETE FROM event_attributes WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	for key, value := range attributes {
+		if _, err := exec.Exec(`INSERT INTO event_attributes (event_id, key, value) VALUES (?, ?, ?)`, eventID, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEventAttributes returns the attributes of the given events, keyed by event ID. Events
+// without any are left out.
+func loadEventAttributes(ctx context.Context, q sqlQueryer, eventIDs []int) (map[int]map[string]string, error) {
+	attributes := map[int]map[string]string{}
+	if len(eventIDs) == 0 {
+		return attributes, nil
+	}
+	placeholders := make([]string, len(eventIDs))
+	args := make([]interface{}, len(eventIDs))
+	for i, id := range eventIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT event_id, key, value FROM event_attributes WHERE event_id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID int
+		var key, value string
+		if err := rows.Scan(&eventID, &key, &value); err != nil {
+			return nil, err
+		}
+		if attributes[eventID] == nil {
+			attributes[eventID] = map[string]string{}
+		}
+		attributes[eventID][key] = value
+	}
+	return attributes, rows.Err()
+}
+
+// attachEventAttributes fills in a single event's attributes; failures are logged, not surfaced
+func attachEventAttributes(ctx context.Context, e *Event) {
+	attributes, err := loadEventAttributes(ctx, db, []int{e.ID})
+	if err != nil {
+		log.Printf("⚠️  Failed to load attributes of event %d: %v", e.ID, err)
+		return
+	}
+	e.Attributes = attributes[e.ID]
+}
+
+// parseAttributeFilters reads attr.<key>=value listing params. Keys must be defined by some
+// category; values are normalized like stored ones so they compare equal.
+func parseAttributeFilters(params url.Values) (map[string]string, error) {
+	var filters map[string]string
+	for param, values := range params {
+		key, ok := strings.CutPrefix(param, attributeFilterPrefix)
+		if !ok {
+			continue
+		}
+		spec, known := anyAttributeSpec(key)
+		if !known {
+			return nil, ErrInvalidAttributeFilter
+		}
+		if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+		if filters == nil {
+			filters = map[string]string{}
+		}
+		filters[key] = normalizeAttributeValue(spec, values[0])
+	}
+	return filters, nil
+}
+
+// attributeFilterCondition is the listing WHERE clause for one attr.* filter; args are key and value
+const attributeFilterCondition = " AND EXISTS (SELECT 1 FROM event_attributes a WHERE a.event_id = e.id AND a.key = ? AND a.value = ? COLLATE NOCASE)"
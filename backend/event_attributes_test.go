@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventAttributes(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	// Several runs at the same spot on purpose
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	payload := func(title, category string, attributes gin.H) gin.H {
+		body := gin.H{
+			"title": title, "description": "Meet at the fountain, all welcome",
+			"category": category, "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+		if attributes != nil {
+			body["attributes"] = attributes
+		}
+		return body
+	}
+	create := func(body gin.H) Event {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/events", token, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+	get := func(id int) Event {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", id), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+	list := func(query string) []int {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		ids := []int{}
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	refused := func(w *httptest.ResponseRecorder, field string) {
+		t.Helper()
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		var resp struct {
+			Code   string            `json:"code"`
+			Fields map[string]string `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, ErrCodeInvalidAttributes, resp.Code)
+		assert.Contains(t, resp.Fields, field)
+	}
+
+	beginners := create(payload("Beginner run", "sports_fitness", gin.H{"skill_level": "Beginner"}))
+	advanced := create(payload("Hill sprints", "sports_fitness", gin.H{"skill_level": "advanced"}))
+	dinner := create(payload("Supper club", "food_dining", gin.H{"cuisine": "Thai & Lao"}))
+
+	t.Run("Attributes round-trip", func(t *testing.T) {
+		assert.Equal(t, map[string]string{"skill_level": "beginner"}, beginners.Attributes)
+		assert.Equal(t, map[string]string{"skill_level": "beginner"}, get(beginners.ID).Attributes)
+		assert.Equal(t, map[string]string{"cuisine": "Thai &amp; Lao"}, get(dinner.ID).Attributes)
+
+		// Leaving attributes out of an update keeps them; sending them replaces them
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", advanced.ID), token, payload("Hill sprints", "sports_fitness", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "advanced", get(advanced.ID).Attributes["skill_level"])
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", advanced.ID), token,
+			payload("Hill sprints", "sports_fitness", gin.H{"skill_level": "intermediate"}))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "intermediate", get(advanced.ID).Attributes["skill_level"])
+	})
+
+	t.Run("Invalid values are refused per field", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload("Yoga", "sports_fitness", gin.H{"skill_level": "expert"}))
+		refused(w, "attributes.skill_level")
+
+		long := ""
+		for len(long) <= 40 {
+			long += "ramen "
+		}
+		w = doJSON(router, "POST", "/api/events", token, payload("Noodles", "food_dining", gin.H{"cuisine": long}))
+		refused(w, "attributes.cuisine")
+		w = doJSON(router, "POST", "/api/events", token, payload("Noodles", "food_dining", gin.H{"spice": "hot"}))
+		refused(w, "attributes.spice")
+	})
+
+	t.Run("Another category's key is refused", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload("Pasta night", "food_dining", gin.H{"skill_level": "beginner"}))
+		refused(w, "attributes.skill_level")
+
+		// Also when the category changes under an update
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", dinner.ID), token,
+			payload("Supper club", "social_drinks", gin.H{"cuisine": "Thai"}))
+		refused(w, "attributes.cuisine")
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND category = 'social_drinks'`, dinner.ID))
+	})
+
+	t.Run("Listings filter on attributes", func(t *testing.T) {
+		eventListCache.Invalidate()
+		assert.Equal(t, []int{beginners.ID}, list("?attr.skill_level=beginner"))
+		assert.Equal(t, []int{beginners.ID}, list("?attr.skill_level=BEGINNER&category=sports_fitness"))
+		assert.Equal(t, []int{dinner.ID}, list("?attr.cuisine=thai%20%26%20lao"))
+		assert.Empty(t, list("?attr.skill_level=advanced"))
+		assert.Len(t, list(""), 3)
+
+		w := doJSON(router, "GET", "/api/events?attr.dress_code=formal", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventExportSchemaVersion is the export document format this build writes and fully understands.
+// Bump it when a field is added to EventExportFields; older builds then import the newer document
+// and list the fields they dropped as warnings.
+const EventExportSchemaVersion = 1
+
+// maxEventExportBytes caps an uploaded export document
+const maxEventExportBytes = 256 << 10
+
+var (
+	ErrExportSchemaVersion = errors.New("schema_version must be a positive integer")
+	ErrExportMissingEvent  = errors.New("document has no event")
+)
+
+// EventExport is a self-contained copy of one event for moving it between instances (e.g. staging
+// to production). It carries no IDs, slug, organizer or participants: the importer becomes the owner.
+// Events have no images or translations in this version, so neither is exported.
+type EventExport struct {
+	SchemaVersion int               `json:"schema_version"`
+	ExportedAt    time.Time         `json:"exported_at"`
+	Event         EventExportFields `json:"event"`
+}
+
+// EventExportFields are the portable event fields. Text is unescaped; times are RFC3339 UTC instants
+// and timezone keeps the organizer's wall clock.
+type EventExportFields struct {
+	Title             string  `json:"title"`
+	Description       string  `json:"description"`
+	DescriptionFormat string  `json:"description_format"`
+	Category          string  `json:"category"`
+	Latitude          float64 `json:"latitude"`
+	Longitude         float64 `json:"longitude"`
+	LocationName      string  `json:"location_name"`
+	Address           string  `json:"address"`
+	StartTime         string  `json:"start_time"`
+	EndTime           string  `json:"end_time"`
+	Timezone          string  `json:"timezone"`
+	MaxParticipants   int     `json:"max_participants"`
+	ReservedSpots     int     `json:"reserved_spots"`
+	PriceAmount       *int    `json:"price_amount"`
+	PriceCurrency     string  `json:"price_currency"`
+	PaymentNote       string  `json:"payment_note"`
+	GenderRestriction string  `json:"gender_restriction"`
+	AgeMin            int     `json:"age_min"`
+	AgeMax            int     `json:"age_max"`
+	SmokingAllowed    bool    `json:"smoking_allowed"`
+	AlcoholAllowed    bool    `json:"alcohol_allowed"`
+	EventLanguages    string  `json:"event_languages"`
+
+	HideOrganizerUntilJoined    bool `json:"hide_organizer_until_joined"`
+	HideParticipantsUntilJoined bool `json:"hide_participants_until_joined"`
+	RequireVerifiedToJoin       bool `json:"require_verified_to_join"`
+	RequireVerifiedToView       bool `json:"require_verified_to_view"`
+	AllowUnregisteredUsers      bool `json:"allow_unregistered_users"`
+	RequireBirthYear            bool `json:"require_birth_year"`
+	NetworkJoinLimit            int  `json:"max_joins_per_network"`
+}
+
+// exportFieldsFromEvent copies a stored event into its portable form
+func exportFieldsFromEvent(e Event) EventExportFields {
+	return EventExportFields{
+		Title:                       html.UnescapeString(e.Title),
+		Description:                 html.UnescapeString(e.Description),
+		DescriptionFormat:           e.DescriptionFormat,
+		Category:                    e.Category,
+		Latitude:                    e.Latitude,
+		Longitude:                   e.Longitude,
+		LocationName:                html.UnescapeString(e.LocationName),
+		Address:                     html.UnescapeString(e.Address),
+		StartTime:                   e.StartTime,
+		EndTime:                     e.EndTime,
+		Timezone:                    e.Timezone,
+		MaxParticipants:             e.MaxParticipants,
+		ReservedSpots:               e.ReservedSpots,
+		PriceAmount:                 e.PriceAmount,
+		PriceCurrency:               e.PriceCurrency,
+		PaymentNote:                 html.UnescapeString(e.PaymentNote),
+		GenderRestriction:           e.GenderRestriction,
+		AgeMin:                      e.AgeMin,
+		AgeMax:                      e.AgeMax,
+		SmokingAllowed:              e.SmokingAllowed,
+		AlcoholAllowed:              e.AlcoholAllowed,
+		EventLanguages:              e.EventLanguages,
+		HideOrganizerUntilJoined:    e.HideOrganizerUntilJoined,
+		HideParticipantsUntilJoined: e.HideParticipantsUntilJoined,
+		RequireVerifiedToJoin:       e.RequireVerifiedToJoin,
+		RequireVerifiedToView:       e.RequireVerifiedToView,
+		AllowUnregisteredUsers:      e.AllowUnregisteredUsers,
+		RequireBirthYear:            e.RequireBirthYear,
+		NetworkJoinLimit:            e.NetworkJoinLimit,
+	}
+}
+
+// event returns the fields as an unsaved Event, before validation
+func (f EventExportFields) event() Event {
+	return Event{
+		Title:                       f.Title,
+		Description:                 f.Description,
+		DescriptionFormat:           f.DescriptionFormat,
+		Category:                    f.Category,
+		Latitude:                    f.Latitude,
+		Longitude:                   f.Longitude,
+		LocationName:                f.LocationName,
+		Address:                     f.Address,
+		Timezone:                    f.Timezone,
+		MaxParticipants:             f.MaxParticipants,
+		ReservedSpots:               f.ReservedSpots,
+		PriceAmount:                 f.PriceAmount,
+		PriceCurrency:               f.PriceCurrency,
+		PaymentNote:                 f.PaymentNote,
+		GenderRestriction:           f.GenderRestriction,
+		AgeMin:                      f.AgeMin,
+		AgeMax:                      f.AgeMax,
+		SmokingAllowed:              f.SmokingAllowed,
+		AlcoholAllowed:              f.AlcoholAllowed,
+		EventLanguages:              f.EventLanguages,
+		HideOrganizerUntilJoined:    f.HideOrganizerUntilJoined,
+		HideParticipantsUntilJoined: f.HideParticipantsUntilJoined,
+		RequireVerifiedToJoin:       f.RequireVerifiedToJoin,
+		RequireVerifiedToView:       f.RequireVerifiedToView,
+		AllowUnregisteredUsers:      f.AllowUnregisteredUsers,
+		RequireBirthYear:            f.RequireBirthYear,
+		NetworkJoinLimit:            f.NetworkJoinLimit,
+	}
+}
+
+// ParseEventExport decodes an export document. Fields this build doesn't know (written by a newer
+// schema version) are dropped and named in warnings instead of failing the import.
+func ParseEventExport(data []byte) (doc EventExport, warnings []string, err error) {
+	var raw struct {
+		SchemaVersion json.RawMessage            `json:"schema_version"`
+		Event         map[string]json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return doc, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := json.Unmarshal(raw.SchemaVersion, &doc.SchemaVersion); err != nil || doc.SchemaVersion < 1 {
+		return doc, nil, ErrExportSchemaVersion
+	}
+	if raw.Event == nil {
+		return doc, nil, ErrExportMissingEvent
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if doc.SchemaVersion > EventExportSchemaVersion {
+		warnings = append(warnings, fmt.Sprintf("document uses schema version %d; this server reads version %d", doc.SchemaVersion, EventExportSchemaVersion))
+	}
+	for _, name := range unknownJSONFields(data, EventExport{}) {
+		warnings = append(warnings, fmt.Sprintf("ignored unknown field %q", name))
+	}
+	for _, name := range unknownJSONFields(mustMarshalRaw(raw.Event), EventExportFields{}) {
+		warnings = append(warnings, fmt.Sprintf("ignored unknown field %q", "event."+name))
+	}
+	return doc, warnings, nil
+}
+
+// unknownJSONFields lists the top-level keys of a JSON object that have no json tag on target's
+// struct type, sorted
+func unknownJSONFields(data []byte, target interface{}) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	known := map[string]bool{}
+	t := reflect.TypeOf(target)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+	var unknown []string
+	for name := range fields {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func mustMarshalRaw(fields map[string]json.RawMessage) []byte {
+	data, _ := json.Marshal(fields)
+	return data
+}
+
+// exportEvent returns one event as a portable JSON document (GET /api/events/:id/export).
+// Only the organizer and admins may export, since the document includes unpublished settings.
+func exportEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("📤 GET /api/events/%d/export - User %d exporting event", eventID, userID)
+
+	e, _, err := scanEventRow(db.QueryRowContext(ctx, `
+		SELECT `+eventColumns+`
+		FROM events e
+		LEFT JOIN users u ON e.user_id = u.id
+		WHERE e.id = ?
+	`, eventID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event to export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export event"})
+		return
+	}
+	if e.UserID != userID && !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can export this event"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, e.Slug))
+	c.JSON(http.StatusOK, EventExport{
+		SchemaVersion: EventExportSchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Event:         exportFieldsFromEvent(e),
+	})
+}
+
+// importEventJSON creates an event from an export document (POST /api/events/import-json). The
+// importer owns the new event and its slug is generated afresh; it is validated and moderated like
+// any new event. Responds with the event and any warnings about ignored fields.
+func importEventJSON(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("📥 POST /api/events/import-json - User %d importing event", userID)
+
+	// Same email verification requirement as createEvent (admins are exempt)
+	var emailVerified, isAdmin bool
+	var userName string
+	err := db.QueryRowContext(ctx, `SELECT email_verified, is_admin, name FROM users WHERE id = ?`, userID).Scan(nullable(&emailVerified), nullable(&isAdmin), &userName)
+	if err != nil {
+		log.Printf("❌ Failed to check email verification status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account status"})
+		return
+	}
+	if !emailVerified && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before creating events"})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEventExportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read document"})
+		return
+	}
+	if len(data) > maxEventExportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Document too large"})
+		return
+	}
+	doc, warnings, err := ParseEventExport(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event := doc.Event.event()
+	event.CreatorName = userName
+	loc, err := resolveEventTimezone(ctx, &event, userID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+	startTime, err := parseEventTime(doc.Event.StartTime, loc)
+	if err != nil || startTime.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
+		return
+	}
+	var endTimePtr *time.Time
+	if doc.Event.EndTime != "" {
+		endTime, err := parseEventTime(doc.Event.EndTime, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
+			return
+		}
+		endTimePtr = &endTime
+	}
+	setEventTimes(&event, startTime, endTimePtr)
+	if event.GenderRestriction == "" {
+		event.GenderRestriction = "any"
+	}
+
+	if err := ValidateEvent(&event, &startTime, endTimePtr); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+	moderation, ok := moderateEventText(c, &event, isAdmin)
+	if !ok {
+		return
+	}
+	event.HiddenPendingReview = moderation.Flagged()
+
+	err = insertEvent(ctx, &event, userID, isAdmin, startTime, endTimePtr, func(tx *sql.Tx, eventID int) {
+		if event.HiddenPendingReview {
+			flagForReview(tx, "event", eventID, userID, moderation)
+		}
+	})
+	if err != nil {
+		var limitErr *LimitReachedError
+		if errors.As(err, &limitErr) {
+			limitErr.respond(c)
+			return
+		}
+		log.Printf("❌ Failed to import event: %v", err)
+		respondDBError(c, err, "Failed to import event")
+		return
+	}
+
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookEventCreated, event.ID, 0)
+	if !event.HiddenPendingReview {
+		broadcastEvent(WebhookEventCreated, event.ID)
+	}
+	log.Printf("✅ Imported event %d from schema version %d (slug: %s, %d warnings)", event.ID, doc.SchemaVersion, event.Slug, len(warnings))
+	applyCapacityFields(&event)
+	if warnings == nil {
+		warnings = []string{}
+	}
+	c.JSON(http.StatusCreated, gin.H{"event": event, "warnings": warnings})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventExport(t *testing.T) {
+	doc, warnings, err := ParseEventExport([]byte(`{
+		"schema_version": 3, "exported_at": "2026-01-01T00:00:00Z", "signature": "abc",
+		"event": {"title": "Board games", "cover_image": "data:...", "latitude": 47.1}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, doc.SchemaVersion)
+	assert.Equal(t, "Board games", doc.Event.Title)
+	assert.Equal(t, 47.1, doc.Event.Latitude)
+	assert.Equal(t, []string{
+		"document uses schema version 3; this server reads version 1",
+		`ignored unknown field "signature"`,
+		`ignored unknown field "event.cover_image"`,
+	}, warnings)
+
+	_, _, err = ParseEventExport([]byte(`{"event": {"title": "Board games"}}`))
+	assert.ErrorIs(t, err, ErrExportSchemaVersion)
+	_, _, err = ParseEventExport([]byte(`{"schema_version": "1", "event": {}}`))
+	assert.ErrorIs(t, err, ErrExportSchemaVersion)
+	_, _, err = ParseEventExport([]byte(`{"schema_version": 1}`))
+	assert.ErrorIs(t, err, ErrExportMissingEvent)
+	_, _, err = ParseEventExport([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestEventExportImport(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.GET("/events/:id/export", exportEvent)
+	protected.POST("/events/import-json", importEventJSON)
+
+	stagingID := createTestUser(t, testDB, "staging@example.com", "Stefan", "password123", false)
+	prodID := createTestUser(t, testDB, "prod@example.com", "Paula", "password123", false)
+	stagingToken, _ := generateToken(User{ID: int(stagingID), Email: "staging@example.com", EmailVerified: true})
+	prodToken, _ := generateToken(User{ID: int(prodID), Email: "prod@example.com", EmailVerified: true})
+
+	start := time.Now().Add(96 * time.Hour).UTC().Truncate(time.Second)
+	w := doJSON(router, "POST", "/api/events", stagingToken, gin.H{
+		"title": "Fondue & <Jass> night", "description": "Bring cards; cheese is on us. **No** phones.",
+		"description_format": "markdown", "category": "gaming_hobbies",
+		"latitude": 47.0502, "longitude": 8.3093, "location_name": "Café \"Zur Linde\"", "address": "Kapellgasse 1, Luzern",
+		"start_time": start.Format(time.RFC3339), "end_time": start.Add(3 * time.Hour).Format(time.RFC3339),
+		"timezone": "Europe/Zurich", "creator_name": "Stefan",
+		"max_participants": 8, "gender_restriction": "female", "age_min": 21, "age_max": 45,
+		"smoking_allowed": true, "alcohol_allowed": true, "event_languages": "de,en",
+		"hide_organizer_until_joined": true, "hide_participants_until_joined": false,
+		"require_verified_to_join": true, "require_verified_to_view": true,
+		"allow_unregistered_users": false, "require_birth_year": true,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var original Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &original))
+
+	export := func(token string, id int) EventExport {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/export", id), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var doc EventExport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		return doc
+	}
+
+	doc := export(stagingToken, original.ID)
+	assert.Equal(t, EventExportSchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, "Fondue & <Jass> night", doc.Event.Title, "text is exported unescaped")
+	assert.Equal(t, "Café \"Zur Linde\"", doc.Event.LocationName)
+
+	t.Run("Only the organizer exports", func(t *testing.T) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/export", original.ID), prodToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(router, "GET", "/api/events/999999/export", stagingToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Round trip preserves every field", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events/import-json", prodToken, doc)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var body struct {
+			Event    Event    `json:"event"`
+			Warnings []string `json:"warnings"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Empty(t, body.Warnings)
+		assert.Equal(t, int(prodID), body.Event.UserID, "the importer owns the copy")
+		assert.Equal(t, "Paula", body.Event.CreatorName)
+		assert.NotEqual(t, original.ID, body.Event.ID)
+		assert.NotEqual(t, original.Slug, body.Event.Slug, "the slug is regenerated")
+		assert.NotEmpty(t, body.Event.Slug)
+
+		assert.Equal(t, doc.Event, export(prodToken, body.Event.ID).Event)
+	})
+
+	t.Run("Unknown fields from a newer schema are ignored with warnings", func(t *testing.T) {
+		var raw map[string]interface{}
+		data, _ := json.Marshal(doc)
+		require.NoError(t, json.Unmarshal(data, &raw))
+		raw["schema_version"] = EventExportSchemaVersion + 1
+		raw["event"].(map[string]interface{})["recurrence"] = "weekly"
+
+		w := doJSON(router, "POST", "/api/events/import-json", prodToken, raw)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `ignored unknown field \"event.recurrence\"`)
+	})
+
+	t.Run("Tampered and invalid documents are rejected", func(t *testing.T) {
+		tampered := doc
+		tampered.Event.Latitude = 123.4
+		w := doJSON(router, "POST", "/api/events/import-json", prodToken, tampered)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrInvalidLatitude.Error())
+
+		noVersion := doc
+		noVersion.SchemaVersion = 0
+		w = doJSON(router, "POST", "/api/events/import-json", prodToken, noVersion)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&count))
+		assert.Equal(t, 3, count, "the original plus two successful imports")
+	})
+}
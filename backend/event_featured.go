@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFeaturedSlots is how many featured events a listing pins above the rest; more can be
+// featured at once, the soonest ones get the slots
+const maxFeaturedSlots = 3
+
+// Admin audit log actions of adminFeatureEvent
+const (
+	AuditEventFeatured   = "event_featured"
+	AuditEventUnfeatured = "event_unfeatured"
+)
+
+// featuredEventCondition matches events whose feature is running; its one argument is the
+// current time. A feature past featured_until is over even before housekeeping clears it.
+const featuredEventCondition = `(e.is_featured = 1 AND (e.featured_until IS NULL OR e.featured_until > ?))`
+
+// FeatureEventRequest is the body of PUT /api/admin/events/:id/feature. featured_until is only
+// read when featuring; without it the event stays featured until unfeatured.
+type FeatureEventRequest struct {
+	Featured      *bool      `json:"featured" binding:"required"`
+	FeaturedUntil *time.Time `json:"featured_until"`
+}
+
+// adminFeatureEvent features or unfeatures an event and audit-logs it
+// (PUT /api/admin/events/:id/feature). The audit entry targets the event's organizer.
+func adminFeatureEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	adminID := c.GetInt("user_id")
+
+	var req FeatureEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "featured is required"})
+		return
+	}
+	now := time.Now()
+	var until *time.Time
+	if *req.Featured && req.FeaturedUntil != nil {
+		if !req.FeaturedUntil.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "featured_until must be in the future"})
+			return
+		}
+		utc := req.FeaturedUntil.UTC()
+		until = &utc
+	}
+	log.Printf("📌 PUT /api/admin/events/%d/feature - Admin %d sets featured=%t", eventID, adminID, *req.Featured)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	var organizerID int
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM events WHERE id = ?`, eventID).Scan(&organizerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE events SET is_featured = ?, featured_until = ?, updated_at = ? WHERE id = ?`,
+		*req.Featured, until, now.UTC(), eventID); err != nil {
+		log.Printf("❌ Error featuring event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to update event")
+		return
+	}
+	action, details := AuditEventUnfeatured, gin.H{"event_id": eventID}
+	if *req.Featured {
+		action, details = AuditEventFeatured, gin.H{"event_id": eventID, "featured_until": until}
+	}
+	if err := recordAdminAudit(tx, adminID, action, organizerID, details); err != nil {
+		log.Printf("❌ Error recording audit entry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		respondDBError(c, err, "Failed to update event")
+		return
+	}
+
+	eventListCache.Invalidate()
+	publicStats.Invalidate()
+	log.Printf("✅ Event %d featured=%t", eventID, *req.Featured)
+	c.JSON(http.StatusOK, gin.H{"id": eventID, "is_featured": *req.Featured, "featured_until": until})
+}
+
+// clearExpiredFeatures unfeatures every event whose featured_until has passed, so the flag in
+// the database matches what listings show
+func clearExpiredFeatures(now time.Time) (int64, error) {
+	result, err := db.Exec(`
+		UPDATE events SET is_featured = 0, featured_until = NULL, updated_at = ?
+		WHERE is_featured = 1 AND featured_until IS NOT NULL AND featured_until <= ?
+	`, now.UTC(), now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// loadFeaturedEvents lists the upcoming featured events anonymous visitors may see, soonest
+// first and at most maxFeaturedSlots of them (GET /api/public/stats)
+func loadFeaturedEvents(ctx context.Context) ([]LandingEvent, error) {
+	return queryLandingEvents(ctx, `
+		WHERE e.start_time >= datetime('now')
+		AND `+publicEventCondition+`
+		AND `+featuredEventCondition, []interface{}{time.Now().UTC()}, maxFeaturedSlots)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeaturedEvents(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+	publicStats.Invalidate()
+	defer publicStats.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/public/stats", getPublicStats)
+	admin := router.Group("/api/admin", authMiddleware(), adminMiddleware())
+	admin.PUT("/events/:id/feature", adminFeatureEvent)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+
+	// Six events a day apart; the later ones get featured
+	var ids []int
+	for i := 0; i < 6; i++ {
+		id := createTestEvent(t, testDB, organizerID, fmt.Sprintf("Event %d", i))
+		_, err := testDB.Exec(`UPDATE events SET start_time = ?, slug = ? WHERE id = ?`,
+			time.Now().Add(time.Duration(i+1)*24*time.Hour).UTC().Format(time.RFC3339), fmt.Sprintf("event-%d", i), id)
+		require.NoError(t, err)
+		ids = append(ids, int(id))
+	}
+	feature := func(token string, eventID int, body gin.H) int {
+		t.Helper()
+		return doJSON(router, "PUT", fmt.Sprintf("/api/admin/events/%d/feature", eventID), token, body).Code
+	}
+	listing := func(query string) []Event {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+	order := func(events []Event) []int {
+		out := make([]int, len(events))
+		for i, e := range events {
+			out[i] = e.ID
+		}
+		return out
+	}
+
+	t.Run("Only admins can feature events", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, feature(organizerToken, ids[5], gin.H{"featured": true}))
+		assert.Equal(t, http.StatusUnauthorized, feature("", ids[5], gin.H{"featured": true}))
+		assert.Equal(t, http.StatusBadRequest, feature(adminToken, ids[5], gin.H{}))
+		assert.Equal(t, http.StatusBadRequest, feature(adminToken, ids[5], gin.H{"featured": true, "featured_until": time.Now().Add(-time.Hour)}))
+		assert.Equal(t, http.StatusNotFound, feature(adminToken, 9999, gin.H{"featured": true}))
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE is_featured = 1`))
+	})
+
+	t.Run("Featured events come first, at most three of them", func(t *testing.T) {
+		for _, id := range ids[2:] {
+			require.Equal(t, http.StatusOK, feature(adminToken, id, gin.H{"featured": true}))
+		}
+		assert.Equal(t, 4, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND target_user_id = ?`, AuditEventFeatured, organizerID))
+
+		events := listing("")
+		assert.Equal(t, []int{ids[2], ids[3], ids[4], ids[0], ids[1], ids[5]}, order(events))
+		assert.True(t, events[0].IsFeatured)
+		assert.True(t, events[5].IsFeatured, "past the slots it's still featured, just not pinned")
+		assert.False(t, events[3].IsFeatured)
+
+		assert.Equal(t, ids, order(listing("?include_featured=false")))
+
+		// Filters still apply to the featured ones
+		_, err := testDB.Exec(`UPDATE events SET category = 'music' WHERE id IN (?, ?, ?)`, ids[0], ids[4], ids[5])
+		require.NoError(t, err)
+		eventListCache.Invalidate()
+		assert.Equal(t, []int{ids[4], ids[5], ids[0]}, order(listing("?category=music")))
+	})
+
+	t.Run("The public stats list the featured events", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/stats", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats PublicStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		require.Len(t, stats.Featured, maxFeaturedSlots)
+		assert.Equal(t, "event-2", stats.Featured[0].Slug)
+	})
+
+	t.Run("Unfeaturing is audit-logged", func(t *testing.T) {
+		for _, id := range ids[3:] {
+			require.Equal(t, http.StatusOK, feature(adminToken, id, gin.H{"featured": false}))
+		}
+		assert.Equal(t, 3, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ?`, AuditEventUnfeatured))
+		assert.Equal(t, []int{ids[2], ids[0], ids[1], ids[3], ids[4], ids[5]}, order(listing("")))
+	})
+
+	t.Run("Expired features are cleared by housekeeping", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, feature(adminToken, ids[4], gin.H{"featured": true, "featured_until": time.Now().Add(time.Hour)}))
+		events := listing("")
+		assert.Equal(t, []int{ids[2], ids[4]}, order(events)[:2])
+		require.NotNil(t, events[1].FeaturedUntil)
+
+		result, err := runCleanup(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), result.FeaturesCleared, "not over yet")
+
+		result, err = runCleanup(time.Now().Add(2 * time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.FeaturesCleared)
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND (is_featured = 1 OR featured_until IS NOT NULL)`, ids[4]))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE is_featured = 1`), "open-ended features stay")
+		assert.Equal(t, []int{ids[2], ids[0], ids[1], ids[3], ids[4], ids[5]}, order(listing("")))
+	})
+}
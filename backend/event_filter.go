@@ -0,0 +1,447 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventFilter holds the GET /api/events filters, parsed and validated by ParseEventFilter.
+// The zero value lists every upcoming event the viewer may see.
+type EventFilter struct {
+	Category  string
+	CreatorID int    // 0 = any organizer; getEvents checks the viewer may see this one first
+	Group     string // group slug
+	Keyword   string // title or description contains
+	Location  string // place name or street address contains
+	Languages []string
+	Smoking   *bool
+	Alcohol   *bool
+	// Gender is "" for no filter, "me" for the signed-in viewer's own profile gender (ignored for
+	// anonymous visitors) or the restriction to match next to "any"
+	Gender         string
+	AgeMin         *int // events whose age_max is at least this
+	AgeMax         *int // events whose age_min is at most this
+	FreeOnly       bool
+	MaxPrice       *int              // cents in each event's own currency; events without a price count as free
+	HideIneligible bool              // signed-in viewers with a birth year only
+	UpdatedSince   *time.Time        // delta sync: include cancelled events touched after this instant
+	Sort           string            // "" for start time order or SortRelevance
+	Near           *GeoRadius        // lat, lng and radius_km
+	StartsBefore   *time.Time        // replaces the EVENT_LIST_WINDOW_DAYS bound (the agenda's last day)
+	Attributes     map[string]string // attr.<key>=value, see CategoryAttributes
+	NoFeatured     bool              // include_featured=false: plain order, no featured events pinned first
+}
+
+// GeoRadius limits a listing to events within RadiusKm of a point
+type GeoRadius struct {
+	Lat, Lng, RadiusKm float64
+}
+
+const (
+	maxRadiusKm    = 500
+	earthRadiusKm  = 6371.0
+	kmPerDegreeLat = 111.2
+)
+
+// Contains reports whether a point lies within the radius (great-circle distance)
+func (g GeoRadius) Contains(lat, lng float64) bool {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLng := rad(lat-g.Lat), rad(lng-g.Lng)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(g.Lat))*math.Cos(rad(lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2*earthRadiusKm*math.Asin(math.Min(1, math.Sqrt(a))) <= g.RadiusKm
+}
+
+// EventViewer is who a listing is built for; ID 0 is an anonymous visitor
+type EventViewer struct {
+	ID        int
+	Languages []string // profile languages, used by sort=relevance
+}
+
+// Listing filter errors, in the order ParseEventFilter checks them
+var (
+	ErrInvalidUpdatedSince = errors.New("updated_since must be an RFC3339 timestamp")
+	ErrInvalidSort         = errors.New("sort must be relevance (or omitted for start time order)")
+	ErrInvalidCreatorID    = errors.New("Invalid creator_id")
+	ErrInvalidAgeFilter    = errors.New("age_min and age_max must be whole numbers")
+	ErrInvalidRadius       = fmt.Errorf("lat, lng and radius_km go together: lat -90 to 90, lng -180 to 180, radius_km above 0 and at most %d", maxRadiusKm)
+)
+
+// ParseEventFilter reads the listing filters from query params. Values the listing has always
+// ignored (unknown language codes, smoking=yes, gender=any) are dropped rather than rejected.
+func ParseEventFilter(params url.Values) (EventFilter, error) {
+	f := EventFilter{
+		Category:       params.Get("category"),
+		Group:          params.Get("group"),
+		Keyword:        params.Get("keyword"),
+		Location:       params.Get("location"),
+		Smoking:        parseTriState(params.Get("smoking")),
+		Alcohol:        parseTriState(params.Get("alcohol")),
+		Gender:         params.Get("gender"),
+		FreeOnly:       params.Get("free_only") == "true",
+		HideIneligible: params.Get("hide_ineligible") == "true",
+		Sort:           params.Get("sort"),
+		NoFeatured:     params.Get("include_featured") == "false",
+	}
+	if f.Gender == "any" {
+		f.Gender = ""
+	}
+
+	// Stored values are normalized, so only known codes can ever match
+	for _, code := range splitLanguages(params.Get("languages")) {
+		if IsValidLanguageCode(code) {
+			f.Languages = append(f.Languages, code)
+		}
+	}
+
+	if raw := params.Get("updated_since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return f, ErrInvalidUpdatedSince
+		}
+		f.UpdatedSince = &since
+	}
+	if f.Sort != "" && f.Sort != SortRelevance {
+		return f, ErrInvalidSort
+	}
+	if raw := params.Get("max_price"); raw != "" {
+		maxPrice, err := strconv.Atoi(raw)
+		if err != nil || maxPrice < 0 {
+			return f, ErrInvalidMaxPrice
+		}
+		f.MaxPrice = &maxPrice
+	}
+	if raw := params.Get("creator_id"); raw != "" {
+		creatorID, err := strconv.Atoi(raw)
+		if err != nil || creatorID < 1 {
+			return f, ErrInvalidCreatorID
+		}
+		f.CreatorID = creatorID
+	}
+	for _, bound := range []struct {
+		name string
+		dst  **int
+	}{{"age_min", &f.AgeMin}, {"age_max", &f.AgeMax}} {
+		if raw := strings.TrimSpace(params.Get(bound.name)); raw != "" {
+			age, err := strconv.Atoi(raw)
+			if err != nil {
+				return f, ErrInvalidAgeFilter
+			}
+			*bound.dst = &age
+		}
+	}
+	if params.Get("lat") != "" || params.Get("lng") != "" || params.Get("radius_km") != "" {
+		near, err := parseGeoRadius(params)
+		if err != nil {
+			return f, err
+		}
+		f.Near = &near
+	}
+	attributes, err := parseAttributeFilters(params)
+	if err != nil {
+		return f, err
+	}
+	f.Attributes = attributes
+	return f, nil
+}
+
+func parseGeoRadius(params url.Values) (GeoRadius, error) {
+	var g GeoRadius
+	for _, field := range []struct {
+		name     string
+		dest     *float64
+		min, max float64
+	}{
+		{"lat", &g.Lat, -90, 90},
+		{"lng", &g.Lng, -180, 180},
+		{"radius_km", &g.RadiusKm, 0, maxRadiusKm},
+	} {
+		value, err := strconv.ParseFloat(params.Get(field.name), 64)
+		if err != nil || math.IsNaN(value) || value < field.min || value > field.max {
+			return g, ErrInvalidRadius
+		}
+		*field.dest = value
+	}
+	if g.RadiusKm == 0 {
+		return g, ErrInvalidRadius
+	}
+	return g, nil
+}
+
+// parseTriState reads a true/false filter; anything else means "don't filter"
+func parseTriState(raw string) *bool {
+	switch raw {
+	case "true":
+		v := true
+		return &v
+	case "false":
+		v := false
+		return &v
+	}
+	return nil
+}
+
+// cacheKey normalizes the filter into an eventListCache key. Free-text searches (keyword,
+// location), delta syncs (updated_since) and agenda windows (StartsBefore) aren't cached since almost every value is unique.
+// Only anonymous listings are cached, so viewer-only filters (gender=me, hide_ineligible, sort)
+// are left out.
+func (f EventFilter) cacheKey() (string, bool) {
+	if strings.TrimSpace(f.Keyword) != "" || strings.TrimSpace(f.Location) != "" || f.UpdatedSince != nil || f.StartsBefore != nil {
+		return "", false
+	}
+
+	// Language order doesn't matter (codes are OR-ed together)
+	languages := append([]string(nil), f.Languages...)
+	sort.Strings(languages)
+
+	triState := func(v *bool) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatBool(*v)
+	}
+	number := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	gender := f.Gender
+	if gender == "me" {
+		gender = ""
+	}
+	creator := ""
+	if f.CreatorID > 0 {
+		creator = strconv.Itoa(f.CreatorID)
+	}
+	near := ""
+	if f.Near != nil {
+		near = fmt.Sprintf("%g,%g,%g", f.Near.Lat, f.Near.Lng, f.Near.RadiusKm)
+	}
+	attributes := make([]string, 0, len(f.Attributes))
+	for _, key := range sortedKeys(f.Attributes) {
+		attributes = append(attributes, key+":"+f.Attributes[key])
+	}
+	freeOnly := ""
+	if f.FreeOnly {
+		freeOnly = "true"
+	}
+	featured := ""
+	if f.NoFeatured {
+		featured = "false"
+	}
+
+	parts := []string{
+		"category=" + f.Category,
+		"creator=" + creator,
+		"group=" + f.Group,
+		"languages=" + strings.Join(languages, ","),
+		"smoking=" + triState(f.Smoking),
+		"alcohol=" + triState(f.Alcohol),
+		"gender=" + gender,
+		"age_min=" + number(f.AgeMin),
+		"age_max=" + number(f.AgeMax),
+		"free_only=" + freeOnly,
+		"max_price=" + number(f.MaxPrice),
+		"near=" + near,
+		"attributes=" + url.QueryEscape(strings.Join(attributes, ",")),
+		"featured=" + featured,
+	}
+	return strings.Join(parts, "&"), true
+}
+
+// BuildEventsQuery assembles the listing SQL and its arguments: upcoming events within
+// EVENT_LIST_WINDOW_DAYS (or before f.StartsBefore) matching f, at most EVENT_LIST_LIMIT of them, featured ones first. Every filter value is passed
+// as an argument, never spliced into the SQL. A signed-in viewer adds is_participant and
+// is_interested; the columns are scanEventRow's followed by those two.
+func BuildEventsQuery(f EventFilter, viewer EventViewer) (string, []interface{}) {
+	userID := viewer.ID
+	args := []interface{}{}
+
+	query := `SELECT ` + eventColumns
+
+	// participant_count and interested_count are maintained on the events row (see
+	// adjustParticipantCount), so the only per-row lookups left are the viewer's own membership
+	// and interest, both unique index hits
+	if userID > 0 {
+		query += `, me.user_id IS NOT NULL as is_participant, mi.user_id IS NOT NULL as is_interested
+		FROM events e
+		LEFT JOIN users u ON e.user_id = u.id
+		LEFT JOIN event_participants me ON me.event_id = e.id AND me.user_id = ?
+		LEFT JOIN event_interest mi ON mi.event_id = e.id AND mi.user_id = ?`
+		args = append(args, userID, userID)
+	} else {
+		query += `, 0 as is_participant, 0 as is_interested
+		FROM events e
+		LEFT JOIN users u ON e.user_id = u.id`
+	}
+
+	query += `
+		WHERE e.start_time >= datetime('now')`
+	if f.StartsBefore != nil {
+		query += " AND e.start_time < ?"
+		args = append(args, f.StartsBefore.UTC().Format("2006-01-02 15:04:05"))
+	} else {
+		query += " AND e.start_time <= datetime('now', ?)"
+		args = append(args, fmt.Sprintf("+%d days", appConfig.EventListWindowDays))
+	}
+
+	// Delta sync: only events touched after updated_since, including cancelled ones so clients
+	// can drop them
+	if f.UpdatedSince != nil {
+		query += " AND e.updated_at > ?"
+		args = append(args, f.UpdatedSince.UTC().Format("2006-01-02 15:04:05.999999999"))
+	} else {
+		query += " AND e.cancelled_at IS NULL"
+	}
+
+	query += visibleUnderReviewCondition + publishedEventCondition
+	args = append(args, userID, userID)
+
+	// Events the viewer hid or has an open report against stay out of their own listings
+	if userID > 0 {
+		query += viewerMutedCondition
+		args = append(args, userID, userID)
+	}
+
+	// Category filter
+	if f.Category != "" {
+		query += " AND e.category = ?"
+		args = append(args, f.Category)
+	}
+
+	// Organizer filter
+	if f.CreatorID > 0 {
+		query += " AND e.user_id = ?" + organizerRevealedCondition
+		args = append(args, f.CreatorID, userID, userID, userID)
+	}
+
+	// Group filter (group=slug)
+	if f.Group != "" {
+		query += " AND e.group_id = (SELECT id FROM groups WHERE slug = ?)"
+		args = append(args, f.Group)
+	}
+
+	// Keyword search (title or description)
+	if f.Keyword != "" {
+		query += " AND (e.title LIKE ? OR e.description LIKE ?)"
+		likeKeyword := "%" + f.Keyword + "%"
+		args = append(args, likeKeyword, likeKeyword)
+	}
+
+	// Location search (place name or street address)
+	if f.Location != "" {
+		query += " AND (e.location_name LIKE ? OR e.address LIKE ?)"
+		likeLocation := "%" + f.Location + "%"
+		args = append(args, likeLocation, likeLocation)
+	}
+
+	// Language filter (any of the codes); an exact match inside the comma-delimited list
+	if len(f.Languages) > 0 {
+		langConditions := make([]string, 0, len(f.Languages))
+		for _, code := range f.Languages {
+			langConditions = append(langConditions, "(',' || e.event_languages || ',') LIKE ?")
+			args = append(args, "%,"+code+",%")
+		}
+		query += " AND (" + strings.Join(langConditions, " OR ") + ")"
+	}
+
+	// Smoking and alcohol filters
+	if f.Smoking != nil {
+		query += " AND e.smoking_allowed = " + sqlBool(*f.Smoking)
+	}
+	if f.Alcohol != nil {
+		query += " AND e.alcohol_allowed = " + sqlBool(*f.Alcohol)
+	}
+
+	// Gender filter; gender=me uses the viewer's own profile gender
+	if f.Gender == "me" {
+		if userID > 0 {
+			query += " AND (e.gender_restriction = " + viewerGenderRestriction + " OR e.gender_restriction = 'any')"
+			args = append(args, userID)
+		}
+	} else if f.Gender != "" {
+		query += " AND (e.gender_restriction = ? OR e.gender_restriction = 'any')"
+		args = append(args, f.Gender)
+	}
+
+	// Age filters: events whose age range overlaps the one asked for
+	if f.AgeMin != nil {
+		query += " AND e.age_max >= ?"
+		args = append(args, *f.AgeMin)
+	}
+	if f.AgeMax != nil {
+		query += " AND e.age_min <= ?"
+		args = append(args, *f.AgeMax)
+	}
+
+	// Price filters; events without a price count as free
+	if f.FreeOnly {
+		query += " AND COALESCE(e.price_amount, 0) = 0"
+	}
+	if f.MaxPrice != nil {
+		query += " AND COALESCE(e.price_amount, 0) <= ?"
+		args = append(args, *f.MaxPrice)
+	}
+
+	// Category-specific attributes, all of which must match
+	for _, key := range sortedKeys(f.Attributes) {
+		query += attributeFilterCondition
+		args = append(args, key, f.Attributes[key])
+	}
+
+	// Radius filter: a bounding box here, the exact distance in queryEventList. Boxes that would
+	// reach a pole or cross the antimeridian only bound the latitude.
+	if f.Near != nil {
+		latDelta := f.Near.RadiusKm / kmPerDegreeLat
+		query += " AND e.latitude BETWEEN ? AND ?"
+		args = append(args, f.Near.Lat-latDelta, f.Near.Lat+latDelta)
+		if cos := math.Cos(f.Near.Lat * math.Pi / 180); math.Abs(f.Near.Lat)+latDelta < 90 && cos > 0 {
+			lngDelta := f.Near.RadiusKm / (kmPerDegreeLat * cos)
+			if f.Near.Lng-lngDelta >= -180 && f.Near.Lng+lngDelta <= 180 {
+				query += " AND e.longitude BETWEEN ? AND ?"
+				args = append(args, f.Near.Lng-lngDelta, f.Near.Lng+lngDelta)
+			}
+		}
+	}
+
+	// Signed-in users with a birth year can hide events whose age limits they fall outside of
+	if userID > 0 && f.HideIneligible {
+		query += ageEligibleCondition
+		args = append(args, userID)
+	}
+
+	// The soonest maxFeaturedSlots featured events among the matches come first, unless
+	// include_featured=false; after them sort=relevance puts events held in the viewer's languages first
+	orderBy := ""
+	var orderArgs []interface{}
+	if !f.NoFeatured {
+		now := time.Now().UTC()
+		orderBy = "(" + featuredEventCondition + " AND ROW_NUMBER() OVER (ORDER BY " + featuredEventCondition +
+			" DESC, e.start_time, e.id) <= ?) DESC, "
+		orderArgs = append(orderArgs, now, now, maxFeaturedSlots)
+	}
+	if userID > 0 && f.Sort == SortRelevance {
+		relevance, relevanceArgs := languageMatchOrder(viewer.Languages)
+		orderBy += relevance
+		orderArgs = append(orderArgs, relevanceArgs...)
+	}
+	query += " ORDER BY " + orderBy + "e.start_time ASC LIMIT ?"
+	args = append(args, orderArgs...)
+	args = append(args, appConfig.EventListLimit)
+
+	return query, args
+}
+
+// sqlBool renders a boolean filter as the 0/1 SQLite stores
+func sqlBool(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
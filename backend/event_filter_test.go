@@ -0,0 +1,329 @@
+package main
+
+import (
+	"math/rand"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	yes, no := true, false
+	since := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	ten, zero, forty := 10, 0, 40
+
+	for query, want := range map[string]EventFilter{
+		"":                                   {},
+		"category=sports_fitness":            {Category: "sports_fitness"},
+		"creator_id=7":                       {CreatorID: 7},
+		"group=climbers":                     {Group: "climbers"},
+		"keyword=quiz&location=Basel":        {Keyword: "quiz", Location: "Basel"},
+		"languages=EN, de,xx,en,":            {Languages: []string{"en", "de"}},
+		"languages=xx,zz":                    {},
+		"smoking=true&alcohol=false":         {Smoking: &yes, Alcohol: &no},
+		"smoking=yes&alcohol=1":              {},
+		"gender=any":                         {},
+		"gender=female":                      {Gender: "female"},
+		"gender=me":                          {Gender: "me"},
+		"age_min=10&age_max=40":              {AgeMin: &ten, AgeMax: &forty},
+		"age_min=+10":                        {AgeMin: &ten},
+		"free_only=true&max_price=0":         {FreeOnly: true, MaxPrice: &zero},
+		"free_only=1":                        {},
+		"hide_ineligible=true":               {HideIneligible: true},
+		"updated_since=2026-05-01T12:00:00Z": {UpdatedSince: &since},
+		"sort=relevance":                     {Sort: SortRelevance},
+		"lat=47.37&lng=8.54&radius_km=10":    {Near: &GeoRadius{Lat: 47.37, Lng: 8.54, RadiusKm: 10}},
+	} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		got, err := ParseEventFilter(values)
+		require.NoError(t, err, query)
+		if want.UpdatedSince != nil {
+			require.NotNil(t, got.UpdatedSince, query)
+			assert.True(t, want.UpdatedSince.Equal(*got.UpdatedSince), query)
+			got.UpdatedSince, want.UpdatedSince = nil, nil
+		}
+		assert.Equal(t, want, got, query)
+	}
+
+	// getEvents answers these with 400 and the error text, as it always has
+	for query, want := range map[string]error{
+		"updated_since=yesterday":              ErrInvalidUpdatedSince,
+		"sort=popularity":                      ErrInvalidSort,
+		"max_price=-1":                         ErrInvalidMaxPrice,
+		"max_price=cheap":                      ErrInvalidMaxPrice,
+		"creator_id=0":                         ErrInvalidCreatorID,
+		"creator_id=1 OR 1=1":                  ErrInvalidCreatorID,
+		"age_min=eighteen":                     ErrInvalidAgeFilter,
+		"age_max=99)%3BDROP TABLE events%3B--": ErrInvalidAgeFilter,
+		"sort=popularity&updated_since=never":  ErrInvalidUpdatedSince,
+		"lat=47.37&lng=8.54":                   ErrInvalidRadius,
+		"lat=47.37&lng=8.54&radius_km=0":       ErrInvalidRadius,
+		"lat=47.37&lng=8.54&radius_km=501":     ErrInvalidRadius,
+		"lat=95&lng=8.54&radius_km=10":         ErrInvalidRadius,
+		"lat=NaN&lng=8.54&radius_km=10":        ErrInvalidRadius,
+	} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseEventFilter(values)
+		assert.ErrorIs(t, err, want, query)
+	}
+}
+
+func TestBuildEventsQuery(t *testing.T) {
+	yes, no := true, false
+	eighteen, thirty, price := 18, 30, 1500
+	since := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	base, baseArgs := BuildEventsQuery(EventFilter{}, EventViewer{})
+	const viewerID = 42
+
+	for name, tc := range map[string]struct {
+		filter    EventFilter
+		viewerID  int
+		condition string
+		args      []interface{} // in order, among the query's arguments
+		absent    string
+	}{
+		"category":                  {EventFilter{Category: "music"}, 0, " AND e.category = ?", []interface{}{"music"}, ""},
+		"organizer":                 {EventFilter{CreatorID: 7}, viewerID, " AND e.user_id = ?" + organizerRevealedCondition, []interface{}{7, viewerID, viewerID, viewerID}, ""},
+		"group":                     {EventFilter{Group: "climbers"}, 0, " AND e.group_id = (SELECT id FROM groups WHERE slug = ?)", []interface{}{"climbers"}, ""},
+		"keyword":                   {EventFilter{Keyword: "quiz"}, 0, " AND (e.title LIKE ? OR e.description LIKE ?)", []interface{}{"%quiz%", "%quiz%"}, ""},
+		"location":                  {EventFilter{Location: "Basel"}, 0, " AND (e.location_name LIKE ? OR e.address LIKE ?)", []interface{}{"%Basel%", "%Basel%"}, ""},
+		"one language":              {EventFilter{Languages: []string{"de"}}, 0, " AND ((',' || e.event_languages || ',') LIKE ?)", []interface{}{"%,de,%"}, ""},
+		"languages are OR-ed":       {EventFilter{Languages: []string{"de", "fr"}}, 0, " AND ((',' || e.event_languages || ',') LIKE ? OR (',' || e.event_languages || ',') LIKE ?)", []interface{}{"%,de,%", "%,fr,%"}, ""},
+		"smoking allowed":           {EventFilter{Smoking: &yes}, 0, " AND e.smoking_allowed = 1", nil, ""},
+		"smoke-free":                {EventFilter{Smoking: &no}, 0, " AND e.smoking_allowed = 0", nil, ""},
+		"alcohol allowed":           {EventFilter{Alcohol: &yes}, 0, " AND e.alcohol_allowed = 1", nil, ""},
+		"alcohol-free":              {EventFilter{Alcohol: &no}, 0, " AND e.alcohol_allowed = 0", nil, ""},
+		"gender":                    {EventFilter{Gender: "female"}, 0, " AND (e.gender_restriction = ? OR e.gender_restriction = 'any')", []interface{}{"female"}, ""},
+		"own gender":                {EventFilter{Gender: "me"}, viewerID, "e.gender_restriction = " + viewerGenderRestriction, []interface{}{viewerID}, ""},
+		"own gender anonymous":      {EventFilter{Gender: "me"}, 0, "", nil, "e.gender_restriction ="},
+		"minimum age":               {EventFilter{AgeMin: &eighteen}, 0, " AND e.age_max >= ?", []interface{}{18}, "e.age_min <="},
+		"maximum age":               {EventFilter{AgeMax: &thirty}, 0, " AND e.age_min <= ?", []interface{}{30}, "e.age_max >="},
+		"free only":                 {EventFilter{FreeOnly: true}, 0, " AND COALESCE(e.price_amount, 0) = 0", nil, ""},
+		"maximum price":             {EventFilter{MaxPrice: &price}, 0, " AND COALESCE(e.price_amount, 0) <= ?", []interface{}{1500}, ""},
+		"hide ineligible":           {EventFilter{HideIneligible: true}, viewerID, ageEligibleCondition, []interface{}{viewerID}, ""},
+		"hide ineligible anonymous": {EventFilter{HideIneligible: true}, 0, "", nil, "viewer.birth_year"},
+		"delta sync":                {EventFilter{UpdatedSince: &since}, 0, " AND e.updated_at > ?", []interface{}{"2026-05-01 12:00:00"}, "e.cancelled_at IS NULL"},
+		"relevance":                 {EventFilter{Sort: SortRelevance, NoFeatured: true}, viewerID, "ORDER BY ((CASE WHEN", []interface{}{"%,de,%"}, ""},
+		"relevance anonymous":       {EventFilter{Sort: SortRelevance, NoFeatured: true}, 0, "ORDER BY e.start_time ASC", nil, "CASE"},
+		"featured first":            {EventFilter{Sort: SortRelevance}, viewerID, "ORDER BY (" + featuredEventCondition, []interface{}{maxFeaturedSlots, "%,de,%"}, ""},
+	} {
+		query, args := BuildEventsQuery(tc.filter, EventViewer{ID: tc.viewerID, Languages: []string{"de"}})
+		assert.Contains(t, query, tc.condition, name)
+		if tc.absent != "" {
+			assert.NotContains(t, query, tc.absent, name)
+		}
+		assert.Subset(t, args, tc.args, name)
+		assert.Equal(t, strings.Count(query, "?"), len(args), name)
+		if tc.viewerID == 0 && tc.condition != "" && tc.absent == "" {
+			assert.Equal(t, len(baseArgs)+len(tc.args), len(args), name)
+			assert.Equal(t, len(base)+len(tc.condition), len(query), name+": nothing but the condition is added")
+		}
+	}
+
+	t.Run("Filters combine with AND", func(t *testing.T) {
+		query, args := BuildEventsQuery(EventFilter{
+			Category: "music", Languages: []string{"en"}, Gender: "female", AgeMin: &eighteen, AgeMax: &thirty, FreeOnly: true, NoFeatured: true,
+		}, EventViewer{})
+		for _, condition := range []string{" AND e.category = ?", " AND (e.gender_restriction = ?", " AND e.age_max >= ?", " AND e.age_min <= ?", " AND COALESCE(e.price_amount, 0) = 0"} {
+			assert.Contains(t, query, condition)
+		}
+		// Arguments follow the conditions' order, with the limit last
+		_, plainArgs := BuildEventsQuery(EventFilter{NoFeatured: true}, EventViewer{})
+		assert.Equal(t, []interface{}{"music", "%,en,%", "female", 18, 30, appConfig.EventListLimit}, args[len(plainArgs)-1:])
+	})
+}
+
+func TestBuildEventsQueryResists(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	createTestEvent(t, testDB, organizerID, "Still here")
+
+	// Every free-text parameter goes through ParseEventFilter the way getEvents receives it
+	payloads := []string{
+		`'; DROP TABLE events; --`,
+		`" OR 1=1 --`,
+		`%' OR '1'='1`,
+		`) UNION SELECT password FROM users --`,
+		`?`,
+	}
+	for _, param := range []string{"category", "group", "keyword", "location", "languages", "smoking", "alcohol", "gender", "free_only", "hide_ineligible"} {
+		for _, payload := range payloads {
+			filter, err := ParseEventFilter(url.Values{param: {payload}})
+			require.NoError(t, err, param)
+			for _, viewer := range []EventViewer{{}, {ID: int(organizerID), Languages: []string{"en"}}} {
+				query, args := BuildEventsQuery(filter, viewer)
+				if payload != "?" { // a stray placeholder shows up in the count below instead
+					assert.NotContains(t, query, payload, "%s=%s must be an argument, not SQL", param, payload)
+				}
+				assert.Equal(t, strings.Count(query, "?"), len(args))
+
+				rows, err := testDB.Query(query, args...)
+				require.NoError(t, err, "%s=%s", param, payload)
+				for rows.Next() {
+				}
+				require.NoError(t, rows.Err())
+				rows.Close()
+			}
+		}
+	}
+	assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events`))
+}
+
+func TestBuildEventsQueryPlaceholders(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+
+	// Random filter combinations: the placeholders always match the arguments, and SQLite agrees
+	rng := rand.New(rand.NewSource(1))
+	pick := func(values ...string) string { return values[rng.Intn(len(values))] }
+	for i := 0; i < 500; i++ {
+		values := url.Values{}
+		for param, options := range map[string][]string{
+			"category":        {"", "music", "sports_fitness"},
+			"creator_id":      {"", "1", "99"},
+			"group":           {"", "climbers"},
+			"keyword":         {"", "quiz", "50%"},
+			"location":        {"", "Zürich"},
+			"languages":       {"", "en", "de,fr,xx", "xx"},
+			"smoking":         {"", "true", "false", "maybe"},
+			"alcohol":         {"", "true", "false"},
+			"gender":          {"", "any", "me", "female", "non-binary"},
+			"age_min":         {"", "0", "18"},
+			"age_max":         {"", "30", "150"},
+			"free_only":       {"", "true"},
+			"max_price":       {"", "0", "2500"},
+			"hide_ineligible": {"", "true"},
+			"updated_since":   {"", "2026-05-01T12:00:00Z"},
+			"sort":            {"", SortRelevance},
+		} {
+			if v := pick(options...); v != "" {
+				values.Set(param, v)
+			}
+		}
+		if rng.Intn(2) == 1 {
+			values.Set("lat", pick("47.37", "-89.9"))
+			values.Set("lng", pick("8.54", "179.9"))
+			values.Set("radius_km", pick("5", "500"))
+		}
+		filter, err := ParseEventFilter(values)
+		require.NoError(t, err, values.Encode())
+		viewer := EventViewer{}
+		if rng.Intn(2) == 1 {
+			viewer = EventViewer{ID: 1 + rng.Intn(5), Languages: []string{"en", "pl"}[:rng.Intn(3)]}
+		}
+
+		query, args := BuildEventsQuery(filter, viewer)
+		require.Equal(t, strings.Count(query, "?"), len(args), values.Encode())
+		rows, err := testDB.Query(query, args...)
+		require.NoError(t, err, values.Encode())
+		rows.Close()
+	}
+}
+
+func TestBuildEventsQueryMatches(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Vera", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET gender = 'female', birth_year = ? WHERE id = ?`, time.Now().UTC().Year()-25, viewerID)
+	require.NoError(t, err)
+
+	event := func(title, changes string) {
+		t.Helper()
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET gender_restriction = 'any', event_languages = 'en' WHERE id = ?`, id)
+		require.NoError(t, err)
+		if changes != "" {
+			_, err = testDB.Exec(`UPDATE events SET `+changes+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+	}
+	event("open", "")
+	event("women", "gender_restriction = 'female'")
+	event("men", "gender_restriction = 'male'")
+	event("non-binary", "gender_restriction = 'non-binary'")
+	event("teens", "age_min = 13, age_max = 17")
+	event("seniors", "age_min = 60, age_max = 99")
+	event("german", "event_languages = 'de,fr'")
+	event("italian", "event_languages = 'it'")
+	event("smokers", "smoking_allowed = 1")
+	event("bar", "alcohol_allowed = 1")
+	event("paid", "price_amount = 2000")
+	event("cheap", "price_amount = 500")
+
+	titles := func(query string, viewer EventViewer) []string {
+		t.Helper()
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		filter, err := ParseEventFilter(values)
+		require.NoError(t, err)
+		sqlQuery, args := BuildEventsQuery(filter, viewer)
+		rows, err := testDB.Query(sqlQuery, args...)
+		require.NoError(t, err)
+		defer rows.Close()
+		var found []string
+		for rows.Next() {
+			var isParticipant, isInterested bool
+			e, _, err := scanEventRow(rows, &isParticipant, &isInterested)
+			require.NoError(t, err)
+			found = append(found, e.Title)
+		}
+		sort.Strings(found)
+		return found
+	}
+	all := titles("", EventViewer{})
+	require.Len(t, all, 12)
+	except := func(excluded ...string) []string {
+		var kept []string
+		for _, title := range all {
+			if !slices.Contains(excluded, title) {
+				kept = append(kept, title)
+			}
+		}
+		return kept
+	}
+	signedIn := EventViewer{ID: int(viewerID)}
+
+	for _, tc := range []struct {
+		query  string
+		viewer EventViewer
+		want   []string
+	}{
+		{"gender=female", EventViewer{}, except("men", "non-binary")},
+		{"gender=male", EventViewer{}, except("women", "non-binary")},
+		{"gender=any", EventViewer{}, all},
+		{"gender=me", signedIn, except("men", "non-binary")},
+		{"gender=me", EventViewer{}, all},
+		{"age_min=18", EventViewer{}, except("teens")},
+		{"age_max=30", EventViewer{}, except("seniors")},
+		{"age_min=14&age_max=16", EventViewer{}, except("seniors")},
+		{"age_min=65&age_max=70", EventViewer{}, except("teens")},
+		{"hide_ineligible=true", signedIn, except("teens", "seniors")},
+		{"hide_ineligible=true", EventViewer{}, all},
+		{"languages=de", EventViewer{}, []string{"german"}},
+		{"languages=fr,xx", EventViewer{}, []string{"german"}},
+		{"languages=xx", EventViewer{}, all},
+		{"languages=en,it", EventViewer{}, except("german")},
+		{"smoking=true", EventViewer{}, []string{"smokers"}},
+		{"smoking=false", EventViewer{}, except("smokers")},
+		{"alcohol=true&smoking=false", EventViewer{}, []string{"bar"}},
+		{"free_only=true", EventViewer{}, except("paid", "cheap")},
+		{"max_price=1000", EventViewer{}, except("paid")},
+		{"keyword=smok", EventViewer{}, []string{"smokers"}},
+		{"gender=female&age_min=18&languages=en&max_price=1000", EventViewer{}, except("men", "non-binary", "teens", "german", "italian", "paid")},
+	} {
+		assert.Equal(t, tc.want, titles(tc.query, tc.viewer), "%s (viewer %d)", tc.query, tc.viewer.ID)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doubleSubmitWindow is how long an identical title and start time from the same user count as a
+// resubmission of the creation form rather than a new event
+const doubleSubmitWindow = time.Minute
+
+// eventFingerprint normalizes an event's title, place (rounded to ~1 km) and start date so reposted
+// copies match even when whitespace, case, punctuation or the exact start time differ
+func eventFingerprint(title string, latitude, longitude float64, start time.Time) string {
+	return fmt.Sprintf("%s|%.2f,%.2f|%s", normalizeWords(title), roundCoordinate(latitude), roundCoordinate(longitude), start.UTC().Format("2006-01-02"))
+}
+
+// normalizeWords lowercases (HTML-escaped) text and reduces it to its letters and digits, words
+// separated by single spaces
+func normalizeWords(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(html.UnescapeString(text)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// roundCoordinate rounds to two decimals without producing "-0.00"
+func roundCoordinate(value float64) float64 {
+	rounded := math.Round(value*100) / 100
+	if rounded == 0 {
+		return 0
+	}
+	return rounded
+}
+
+// findDoubleSubmit returns the ID of an event the user created within doubleSubmitWindow with the
+// same (already sanitized) title and start time, or 0
+func findDoubleSubmit(ctx context.Context, userID int, title string, start time.Time) (int, error) {
+	var id int
+	err := db.QueryRowContext(ctx, `
+		SELECT id FROM events
+		WHERE user_id = ? AND title = ? AND start_time = ? AND created_at >= datetime('now', ?)
+		ORDER BY id DESC LIMIT 1
+	`, userID, title, start.UTC(), fmt.Sprintf("-%d seconds", int(doubleSubmitWindow.Seconds()))).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// backfillEventFingerprints fills in fingerprints of events stored before the column existed
+func backfillEventFingerprints(db *sql.DB) {
+	rows, err := db.Query(`SELECT id, title, latitude, longitude, start_time FROM events WHERE fingerprint IS NULL`)
+	if err != nil {
+		log.Printf("⚠️  Could not read events for fingerprint backfill: %v", err)
+		return
+	}
+	fingerprints := map[int]string{}
+	for rows.Next() {
+		var id int
+		var title, start string
+		var latitude, longitude float64
+		if err := rows.Scan(&id, &title, &latitude, &longitude, &start); err != nil {
+			continue
+		}
+		startTime, err := parseEventTime(start, time.UTC)
+		if err != nil {
+			continue
+		}
+		fingerprints[id] = eventFingerprint(title, latitude, longitude, startTime)
+	}
+	rows.Close()
+
+	for id, fingerprint := range fingerprints {
+		if _, err := db.Exec(`UPDATE events SET fingerprint = ? WHERE id = ?`, fingerprint, id); err != nil {
+			log.Printf("⚠️  Could not backfill fingerprint of event %d: %v", id, err)
+		}
+	}
+	log.Printf("✓ Backfilled fingerprints of %d events", len(fingerprints))
+}
+
+// DuplicateEventGroup is a set of events by different organizers sharing one fingerprint
+type DuplicateEventGroup struct {
+	Fingerprint string           `json:"fingerprint"`
+	UserCount   int              `json:"user_count"`
+	Events      []DuplicateEvent `json:"events"`
+}
+
+// DuplicateEvent is the moderation summary of one event in a DuplicateEventGroup
+type DuplicateEvent struct {
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	Slug         string    `json:"slug"`
+	UserID       int       `json:"user_id"`
+	CreatorEmail string    `json:"creator_email"`
+	StartTime    string    `json:"start_time"`
+	CreatedAt    time.Time `json:"created_at"`
+	Cancelled    bool      `json:"cancelled"`
+}
+
+// adminGetDuplicateEvents lists fingerprints used by more than one organizer (GET /api/admin/events/duplicates).
+// Pages hold the groups with the most recent copies first.
+func adminGetDuplicateEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	page, perPage := parsePagination(c)
+	log.Printf("🔁 GET /api/admin/events/duplicates - Admin %d reviewing duplicate events", c.GetInt("user_id"))
+
+	const duplicatedFingerprints = `
+		SELECT fingerprint FROM events
+		WHERE fingerprint IS NOT NULL AND fingerprint != ''
+		GROUP BY fingerprint HAVING COUNT(DISTINCT user_id) > 1`
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (`+duplicatedFingerprints+`)`).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count duplicate groups: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve duplicates"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.fingerprint, e.id, e.title, COALESCE(e.slug, ''), e.user_id, COALESCE(u.email, ''),
+		       e.start_time, e.created_at, e.cancelled_at IS NOT NULL
+		FROM events e
+		LEFT JOIN users u ON u.id = e.user_id
+		WHERE e.fingerprint IN (
+			SELECT fingerprint FROM (`+duplicatedFingerprints+` ORDER BY MAX(created_at) DESC, fingerprint LIMIT ? OFFSET ?)
+		)
+		ORDER BY e.fingerprint, e.created_at, e.id
+	`, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Printf("❌ Failed to query duplicate events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve duplicates"})
+		return
+	}
+	defer rows.Close()
+
+	groups := []DuplicateEventGroup{}
+	users := map[string]map[int]bool{}
+	for rows.Next() {
+		var fingerprint, startTime string
+		var e DuplicateEvent
+		if err := rows.Scan(&fingerprint, &e.ID, &e.Title, &e.Slug, &e.UserID, &e.CreatorEmail,
+			&startTime, &e.CreatedAt, &e.Cancelled); err != nil {
+			log.Printf("❌ Error scanning duplicate event: %v", err)
+			continue
+		}
+		e.StartTime = formatStoredTime(startTime)
+		if len(groups) == 0 || groups[len(groups)-1].Fingerprint != fingerprint {
+			groups = append(groups, DuplicateEventGroup{Fingerprint: fingerprint})
+			users[fingerprint] = map[int]bool{}
+		}
+		group := &groups[len(groups)-1]
+		group.Events = append(group.Events, e)
+		users[fingerprint][e.UserID] = true
+		group.UserCount = len(users[fingerprint])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups":   groups,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// respondExistingEvent answers a double-submitted createEvent with the event the first submission
+// created, as the creator sees it
+func respondExistingEvent(c *gin.Context, eventID, userID int, isAdmin bool) {
+	e, org, err := scanEventRow(db.QueryRowContext(c.Request.Context(), `
+		SELECT `+eventColumns+`
+		FROM events e
+		LEFT JOIN users u ON e.user_id = u.id
+		WHERE e.id = ?
+	`, eventID))
+	if err != nil {
+		log.Printf("❌ Error loading event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return
+	}
+	serializeEvent(&e, org, userID, true, isAdmin)
+	if e.Draft {
+		e.Notice = draftNotice
+	}
+	applyCapacityFields(&e)
+	c.JSON(http.StatusOK, e)
+}
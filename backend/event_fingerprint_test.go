@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFingerprint(t *testing.T) {
+	start := time.Date(2026, 11, 20, 18, 0, 0, 0, time.UTC)
+	base := eventFingerprint("Board Games Night", 52.2297, 21.0122, start)
+	assert.Equal(t, "board games night|52.23,21.01|2026-11-20", base)
+
+	assert.Equal(t, base, eventFingerprint("  board games   NIGHT!! ", 52.2301, 21.0149, start.Add(2*time.Hour)),
+		"case, punctuation, nearby coordinates and the hour don't matter")
+	assert.Equal(t, eventFingerprint("Rock &amp; Roll", 0, 0, start), eventFingerprint("rock & roll", 0, 0, start),
+		"stored titles are HTML-escaped")
+	assert.Equal(t, "x|0.00,0.00|2026-11-20", eventFingerprint("x", -0.001, 0.001, start))
+
+	assert.NotEqual(t, base, eventFingerprint("Board Games Night", 52.2297, 21.0122, start.AddDate(0, 0, 1)))
+	assert.NotEqual(t, base, eventFingerprint("Board Games Night", 50.0647, 19.9450, start))
+}
+
+func TestCreateEventDuplicates(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	// Reposts are what fingerprints catch; the similarity throttle has its own test
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/events/duplicates", adminGetDuplicateEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	scammerID := createTestUser(t, testDB, "scammer@example.com", "Scammer", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	scammerToken, _ := generateToken(User{ID: int(scammerID), Email: "scammer@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	// Noon UTC, so the copy an hour later falls on the same date
+	year, month, day := time.Now().AddDate(0, 0, 3).Date()
+	start := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	payload := func(title string, start time.Time) gin.H {
+		return gin.H{
+			"title": title, "description": "Concert tickets, pay upfront",
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": start.Format(time.RFC3339), "creator_name": "Organizer",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		}
+	}
+	countEvents := func() int {
+		var n int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&n))
+		return n
+	}
+
+	t.Run("Rapid double submit yields one event", func(t *testing.T) {
+		first := doJSON(router, "POST", "/api/events", organizerToken, payload("Open Air Concert", start))
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		second := doJSON(router, "POST", "/api/events", organizerToken, payload("Open Air Concert", start))
+		require.Equal(t, http.StatusOK, second.Code, second.Body.String())
+
+		var created, replayed Event
+		require.NoError(t, json.Unmarshal(first.Body.Bytes(), &created))
+		require.NoError(t, json.Unmarshal(second.Body.Bytes(), &replayed))
+		assert.Equal(t, created.ID, replayed.ID)
+		assert.Equal(t, created.Slug, replayed.Slug)
+		assert.Equal(t, 1, countEvents())
+	})
+
+	t.Run("Same title at another time is a new event", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", organizerToken, payload("Open Air Concert", start.Add(7*24*time.Hour)))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, 2, countEvents())
+	})
+
+	t.Run("Admin duplicates view groups copies across users", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", scammerToken, payload("OPEN-AIR concert", start.Add(time.Hour)))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		// Another user's unrelated event and a legacy row without a fingerprint stay out of the view
+		w = doJSON(router, "POST", "/api/events", scammerToken, payload("Jazz Brunch", start))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		createTestEvent(t, testDB, scammerID, "Open Air Concert")
+
+		w = doJSON(router, "GET", "/api/admin/events/duplicates", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Groups []DuplicateEventGroup `json:"groups"`
+			Total  int                   `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, 1, body.Total)
+		require.Len(t, body.Groups, 1)
+		group := body.Groups[0]
+		assert.Equal(t, 2, group.UserCount)
+		require.Len(t, group.Events, 2)
+		assert.Equal(t, int(organizerID), group.Events[0].UserID)
+		assert.Equal(t, int(scammerID), group.Events[1].UserID)
+		assert.Equal(t, "scammer@example.com", group.Events[1].CreatorEmail)
+
+		w = doJSON(router, "GET", "/api/admin/events/duplicates", organizerToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Backfill fingerprints legacy rows", func(t *testing.T) {
+		backfillEventFingerprints(testDB)
+		var missing int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE fingerprint IS NULL`).Scan(&missing))
+		assert.Zero(t, missing)
+	})
+}
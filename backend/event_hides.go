@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// viewerMutedCondition drops events from a signed-in viewer's listings that they hid ("not
+// interested") or reported and are still waiting on a decision about. Both arguments are the
+// viewer's user ID. The events stay reachable by ID and slug, and nobody else's listing changes.
+const viewerMutedCondition = `
+		AND NOT EXISTS (SELECT 1 FROM event_hides h WHERE h.event_id = e.id AND h.user_id = ?)
+		AND NOT EXISTS (SELECT 1 FROM event_reports r WHERE r.event_id = e.id AND r.reporter_id = ? AND r.status = 'pending')`
+
+// HiddenEvent is an entry of GET /api/profile/hidden-events
+type HiddenEvent struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	StartTime string `json:"start_time"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+	HiddenAt  string `json:"hidden_at"`
+}
+
+// hideEvent mutes an event in the viewer's own listings (POST /api/events/:id/hide). Repeating it
+// is harmless.
+func hideEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🙈 POST /api/events/%d/hide - User %d hides event", eventID, userID)
+
+	var organizerID int
+	var draft, hidden bool
+	err = db.QueryRowContext(ctx, `SELECT user_id, published = 0, hidden_pending_review FROM events WHERE id = ?`, eventID).
+		Scan(&organizerID, &draft, nullable(&hidden))
+	// Drafts and events under review look deleted, as they do for joining
+	if err == sql.ErrNoRows || draft || (hidden && organizerID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error checking event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hide event"})
+		return
+	}
+	if organizerID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are organizing this event"})
+		return
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO event_hides (event_id, user_id) VALUES (?, ?)`, eventID, userID); err != nil {
+		log.Printf("❌ Error hiding event: %v", err)
+		respondDBError(c, err, "Failed to hide event")
+		return
+	}
+
+	log.Printf("✅ User %d hid event %d", userID, eventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Event hidden", "hidden": true})
+}
+
+// unhideEvent brings a hidden event back into the viewer's listings (DELETE /api/events/:id/hide)
+func unhideEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🙈 DELETE /api/events/%d/hide - User %d unhides event", eventID, userID)
+
+	result, err := db.ExecContext(ctx, `DELETE FROM event_hides WHERE event_id = ? AND user_id = ?`, eventID, userID)
+	if err != nil {
+		log.Printf("❌ Error unhiding event: %v", err)
+		respondDBError(c, err, "Failed to unhide event")
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This event is not hidden"})
+		return
+	}
+
+	log.Printf("✅ User %d unhid event %d", userID, eventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Event unhidden", "hidden": false})
+}
+
+// getHiddenEvents lists the events the viewer hid, most recently hidden first
+// (GET /api/profile/hidden-events), so they can be unhidden. Past and cancelled events are
+// included; drafts and events under review are not.
+func getHiddenEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.title, COALESCE(e.slug, ''), e.start_time, e.cancelled_at IS NOT NULL, h.created_at
+		FROM event_hides h
+		JOIN events e ON e.id = h.event_id
+		WHERE h.user_id = ? AND e.published = 1 AND e.hidden_pending_review = 0
+		ORDER BY h.created_at DESC, h.id DESC
+	`, userID)
+	if err != nil {
+		log.Printf("❌ Error fetching hidden events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hidden events"})
+		return
+	}
+	defer rows.Close()
+
+	events := []HiddenEvent{}
+	for rows.Next() {
+		var e HiddenEvent
+		var startTime, hiddenAt string
+		if err := rows.Scan(&e.ID, &e.Title, &e.Slug, &startTime, &e.Cancelled, &hiddenAt); err != nil {
+			log.Printf("❌ Error scanning hidden event: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hidden events"})
+			return
+		}
+		e.StartTime = formatStoredTime(startTime)
+		e.HiddenAt = formatStoredTime(hiddenAt)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Error fetching hidden events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hidden events"})
+		return
+	}
+
+	log.Printf("📋 User %d fetched %d hidden events", userID, len(events))
+	c.JSON(http.StatusOK, events)
+}
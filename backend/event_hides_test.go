@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHides(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	protected := router.Group("/api", authMiddleware())
+	protected.POST("/events/:id/hide", hideEvent)
+	protected.DELETE("/events/:id/hide", unhideEvent)
+	protected.GET("/profile/hidden-events", getHiddenEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com", EmailVerified: true})
+
+	hike := int(createTestEvent(t, testDB, organizerID, "Hike"))
+	quiz := int(createTestEvent(t, testDB, organizerID, "Quiz"))
+	_, err := testDB.Exec(`UPDATE events SET slug = 'hike' WHERE id = ?`, hike)
+	require.NoError(t, err)
+	hidePath := fmt.Sprintf("/api/events/%d/hide", hike)
+
+	listed := func(token string) []int {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		ids := []int{}
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	hiddenList := func(token string) []HiddenEvent {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/profile/hidden-events", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []HiddenEvent
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+
+	t.Run("A hidden event leaves only the viewer's listing", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doJSON(router, "POST", hidePath, aliceToken, nil).Code)
+		require.Equal(t, http.StatusOK, doJSON(router, "POST", hidePath, aliceToken, nil).Code, "hiding twice is harmless")
+
+		assert.ElementsMatch(t, []int{quiz}, listed(aliceToken))
+		assert.ElementsMatch(t, []int{hike, quiz}, listed(bobToken))
+		assert.ElementsMatch(t, []int{hike, quiz}, listed(""))
+
+		w := doJSON(router, "GET", "/api/public/events/hike", aliceToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, "the slug URL still works")
+
+		hidden := hiddenList(aliceToken)
+		require.Len(t, hidden, 1)
+		assert.Equal(t, hike, hidden[0].ID)
+		assert.Equal(t, "hike", hidden[0].Slug)
+		assert.NotEmpty(t, hidden[0].HiddenAt)
+		assert.Empty(t, hiddenList(bobToken))
+	})
+
+	t.Run("Unhiding restores it", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doJSON(router, "DELETE", hidePath, aliceToken, nil).Code)
+		assert.ElementsMatch(t, []int{hike, quiz}, listed(aliceToken))
+		assert.Empty(t, hiddenList(aliceToken))
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "DELETE", hidePath, aliceToken, nil).Code)
+	})
+
+	t.Run("Own and missing events can't be hidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", hidePath, organizerToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", "/api/events/9999/hide", aliceToken, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, doJSON(router, "POST", hidePath, "", nil).Code)
+	})
+
+	t.Run("Events with a pending report from the viewer are left out", func(t *testing.T) {
+		_, err := testDB.Exec(`INSERT INTO event_reports (event_id, reporter_id, reason, status) VALUES (?, ?, 'spam', ?)`,
+			quiz, bobID, ReportStatusPending)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{hike}, listed(bobToken))
+		assert.ElementsMatch(t, []int{hike, quiz}, listed(aliceToken))
+
+		_, err = testDB.Exec(`UPDATE event_reports SET status = ? WHERE event_id = ?`, ReportStatusDismissed, quiz)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{hike, quiz}, listed(bobToken), "back once the report is resolved")
+	})
+}
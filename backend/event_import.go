@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxImportEvents caps how many events one import may contain
+const MaxImportEvents = 50
+
+// Per-item outcomes of an event import
+const (
+	ImportStatusCreated       = "created"
+	ImportStatusSkipped       = "skipped"
+	ImportStatusNeedsLocation = "needs_location" // LOCATION without GEO; create it by hand once placed on the map
+)
+
+// EventImportResult reports what happened to one imported entry, in file order
+type EventImportResult struct {
+	Index     int    `json:"index"`
+	Title     string `json:"title"`
+	StartTime string `json:"start_time,omitempty"`
+	Status    string `json:"status"`
+	EventID   int    `json:"event_id,omitempty"`
+	Slug      string `json:"slug,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// importCandidate is a parsed entry that passed validation and waits for the insert transaction
+type importCandidate struct {
+	result     *EventImportResult
+	event      Event
+	moderation ModerationResult
+	slug       string
+	startTime  time.Time
+	endTimePtr *time.Time
+}
+
+// parseImportPayload reads an ICS file or a JSON array of events. hasGeo is nil for JSON, where
+// coordinates are always given.
+func parseImportPayload(data []byte) (events []Event, hasGeo []bool, err error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &events); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return events, nil, nil
+	}
+	return ParseICSEvents(string(trimmed))
+}
+
+// importEvents creates events in bulk from an uploaded ICS file or JSON array
+// (POST /api/events/import). The category form parameter applies to every event. Valid entries
+// are created in one transaction; the rest are reported per item with the reason they were skipped.
+func importEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("📥 POST /api/events/import - User %d importing events", userID)
+
+	// Same email verification requirement as createEvent (admins are exempt)
+	var emailVerified, isAdmin bool
+	var userName string
+	err := db.QueryRowContext(ctx, `SELECT email_verified, is_admin, name FROM users WHERE id = ?`, userID).Scan(nullable(&emailVerified), nullable(&isAdmin), &userName)
+	if err != nil {
+		log.Printf("❌ Failed to check email verification status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account status"})
+		return
+	}
+	if !emailVerified && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before creating events"})
+		return
+	}
+
+	category := c.PostForm("category")
+	if category == "" {
+		category = c.Query("category")
+	}
+	if _, ok := CategoryNames[category]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid category: %s", category)})
+		return
+	}
+
+	// Either a multipart upload ("file") or the raw ICS/JSON as the request body
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read import data"})
+		return
+	}
+
+	parsed, hasGeo, err := parseImportPayload(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import must be an iCalendar file or a JSON array of events"})
+		return
+	}
+	if len(parsed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No events found to import"})
+		return
+	}
+	if len(parsed) > MaxImportEvents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d events can be imported at once", MaxImportEvents)})
+		return
+	}
+
+	results := make([]EventImportResult, len(parsed))
+	var candidates []importCandidate
+	seen := map[string]bool{} // title + start time, for duplicates within the file
+	for i := range parsed {
+		event := parsed[i]
+		result := &results[i]
+		*result = EventImportResult{Index: i, Title: event.Title, Status: ImportStatusSkipped}
+
+		if hasGeo != nil && !hasGeo[i] {
+			result.Status = ImportStatusNeedsLocation
+			result.Reason = "event has no GEO coordinates"
+			continue
+		}
+
+		event.Category = category
+		event.CreatorName = html.UnescapeString(userName)
+		if event.GenderRestriction == "" {
+			event.GenderRestriction = "any"
+		}
+		if event.AgeMax == 0 {
+			event.AgeMax = 99
+		}
+
+		loc, err := resolveEventTimezone(ctx, &event, userID)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidTimezone) {
+				log.Printf("❌ Error resolving import timezone: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+				return
+			}
+			result.Reason = err.Error()
+			continue
+		}
+		startTime, err := parseEventTime(event.StartTime, loc)
+		if err != nil || event.StartTime == "" {
+			result.Reason = "Invalid start_time format"
+			continue
+		}
+		var endTimePtr *time.Time
+		if event.EndTime != "" {
+			endTime, err := parseEventTime(event.EndTime, loc)
+			if err != nil {
+				result.Reason = "Invalid end_time format"
+				continue
+			}
+			endTimePtr = &endTime
+		}
+		setEventTimes(&event, startTime, endTimePtr)
+		result.StartTime = event.StartTime
+
+		if err := ValidateEvent(&event, &startTime, endTimePtr); err != nil {
+			result.Reason = err.Error()
+			continue
+		}
+
+		key := event.Title + "\x00" + event.StartTime
+		if seen[key] {
+			result.Reason = "duplicate of an earlier entry in this import"
+			continue
+		}
+		seen[key] = true
+		var existing int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM events WHERE user_id = ? AND title = ? AND datetime(start_time) = datetime(?)`,
+			userID, event.Title, startTime.Format("2006-01-02 15:04:05")).Scan(&existing); err != nil {
+			log.Printf("❌ Error checking for duplicate events: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+			return
+		}
+		if existing > 0 {
+			result.Reason = "you already have an event with this title and start time"
+			continue
+		}
+
+		var moderation ModerationResult
+		if !isAdmin {
+			moderation, err = moderateContent(ctx, html.UnescapeString(event.Title), html.UnescapeString(event.Description),
+				html.UnescapeString(event.LocationName), html.UnescapeString(event.Address))
+			if err != nil {
+				log.Printf("❌ Moderation check failed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check event content"})
+				return
+			}
+			if moderation.Rejected() {
+				result.Reason = "rejected by the content filter"
+				continue
+			}
+		}
+		event.HiddenPendingReview = moderation.Flagged()
+
+		slug, err := generateUniqueSlug(event.Title)
+		if err != nil {
+			log.Printf("❌ Slug generation failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+			return
+		}
+		candidates = append(candidates, importCandidate{result: result, event: event, moderation: moderation,
+			slug: slug, startTime: startTime, endTimePtr: endTimePtr})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Failed to start import transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+		return
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	var created []importCandidate
+	for _, candidate := range candidates {
+		id, err := insertEventTx(ctx, tx, &candidate.event, userID, isAdmin, candidate.slug, candidate.startTime, candidate.endTimePtr)
+		var limitErr *LimitReachedError
+		if errors.As(err, &limitErr) {
+			candidate.result.Reason = fmt.Sprintf("upcoming event limit reached (%d)", limitErr.Limit)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Failed to insert imported event: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+			return
+		}
+		candidate.event.ID = int(id)
+		candidate.result.Status = ImportStatusCreated
+		candidate.result.EventID = int(id)
+		candidate.result.Slug = candidate.slug
+		created = append(created, candidate)
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Failed to commit import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+		return
+	}
+
+	if len(created) > 0 {
+		eventListCache.Invalidate()
+	}
+	for _, candidate := range created {
+		webhookDispatch.Dispatch(WebhookEventCreated, candidate.event.ID, 0)
+		if candidate.event.HiddenPendingReview {
+			flagForReview(db, "event", candidate.event.ID, userID, candidate.moderation)
+		} else {
+			broadcastEvent(WebhookEventCreated, candidate.event.ID)
+		}
+	}
+
+	counts := map[string]int{ImportStatusCreated: 0, ImportStatusSkipped: 0, ImportStatusNeedsLocation: 0}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	log.Printf("✅ User %d imported %d of %d events", userID, counts[ImportStatusCreated], len(results))
+	c.JSON(http.StatusOK, gin.H{
+		"created":        counts[ImportStatusCreated],
+		"skipped":        counts[ImportStatusSkipped],
+		"needs_location": counts[ImportStatusNeedsLocation],
+		"results":        results,
+	})
+}
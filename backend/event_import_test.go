@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseICSEvents(t *testing.T) {
+	events, hasGeo, err := ParseICSEvents("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" +
+		"SUMMARY:Derby\\, home game\r\n" +
+		"DESCRIPTION:First line\\nsecond line that is\r\n  folded\r\n" +
+		"DTSTART;TZID=Europe/Lisbon:20300715T180000\r\n" +
+		"DTEND:20300715T190000Z\r\n" +
+		"LOCATION:Estádio\r\n" +
+		"GEO:38.7223;-9.1393\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, []bool{true}, hasGeo)
+	e := events[0]
+	assert.Equal(t, "Derby, home game", e.Title)
+	assert.Equal(t, "First line\nsecond line that is folded", e.Description)
+	assert.Equal(t, "2030-07-15T18:00:00", e.StartTime)
+	assert.Equal(t, "Europe/Lisbon", e.Timezone)
+	assert.Equal(t, "2030-07-15T19:00:00Z", e.EndTime)
+	assert.Equal(t, "Estádio", e.LocationName)
+	assert.InDelta(t, 38.7223, e.Latitude, 1e-9)
+	assert.InDelta(t, -9.1393, e.Longitude, 1e-9)
+
+	_, _, err = ParseICSEvents("just some text")
+	assert.Error(t, err)
+}
+
+func TestImportEvents(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/import", importEvents)
+
+	userID := createTestUser(t, testDB, "club@example.com", "Sports Club", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "club@example.com", EmailVerified: true})
+	existing := createTestEvent(t, testDB, userID, "Existing Match")
+	var storedStart string
+	require.NoError(t, testDB.QueryRow(`SELECT start_time FROM events WHERE id = ?`, existing).Scan(&storedStart))
+	existingStart, err := parseEventTime(storedStart, time.UTC)
+	require.NoError(t, err)
+
+	day := time.Now().UTC().Add(72 * time.Hour).Format("20060102")
+	vevent := func(summary, start, extra string) string {
+		return "BEGIN:VEVENT\r\nSUMMARY:" + summary + "\r\nDESCRIPTION:Season fixture\\, bring a scarf\r\n" +
+			"DTSTART:" + start + "\r\n" + extra + "END:VEVENT\r\n"
+	}
+	geo := "GEO:52.2297;21.0122\r\n"
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		vevent("Home Game", day+"T180000Z", geo) +
+		vevent("Away Game", day+"T200000Z", "LOCATION:Somewhere far\r\n") +
+		vevent("X", day+"T190000Z", geo) +
+		vevent("Home Game", day+"T180000Z", geo) +
+		vevent("Existing Match", existingStart.Format("20060102T150405Z"), geo) +
+		vevent("Past Game", "20000101T100000Z", geo) +
+		"END:VCALENDAR\r\n"
+
+	post := func(t *testing.T, body io.Reader, contentType, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/events/import"+query, body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Per-item report", func(t *testing.T) {
+		w := post(t, strings.NewReader(ics), "text/calendar", "?category=sports_fitness")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Created       int                 `json:"created"`
+			Skipped       int                 `json:"skipped"`
+			NeedsLocation int                 `json:"needs_location"`
+			Results       []EventImportResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 6)
+		assert.Equal(t, 1, resp.Created)
+		assert.Equal(t, 4, resp.Skipped)
+		assert.Equal(t, 1, resp.NeedsLocation)
+
+		assert.Equal(t, ImportStatusCreated, resp.Results[0].Status)
+		assert.NotEmpty(t, resp.Results[0].Slug)
+		assert.Equal(t, ImportStatusNeedsLocation, resp.Results[1].Status)
+		assert.Equal(t, ErrTitleTooShort.Error(), resp.Results[2].Reason)
+		assert.Contains(t, resp.Results[3].Reason, "duplicate")
+		assert.Contains(t, resp.Results[4].Reason, "already have an event")
+		assert.Equal(t, ErrEventInPast.Error(), resp.Results[5].Reason)
+
+		var category, description, creator string
+		require.NoError(t, testDB.QueryRow(`SELECT category, description, creator_name FROM events WHERE id = ?`,
+			resp.Results[0].EventID).Scan(&category, &description, &creator))
+		assert.Equal(t, "sports_fitness", category)
+		assert.Equal(t, "Season fixture, bring a scarf", description)
+		assert.Equal(t, "Sports Club", creator)
+	})
+
+	t.Run("Re-importing creates nothing new", func(t *testing.T) {
+		w := post(t, strings.NewReader(ics), "text/calendar", "?category=sports_fitness")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"created":0`)
+	})
+
+	t.Run("JSON array via multipart upload", func(t *testing.T) {
+		events := []gin.H{{
+			"title": "Training", "description": "Weekly training session", "latitude": 52.2, "longitude": 21.0,
+			"start_time": time.Now().Add(96 * time.Hour).UTC().Format(time.RFC3339),
+		}}
+		data, _ := json.Marshal(events)
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.WriteField("category", "sports_fitness"))
+		part, err := mw.CreateFormFile("file", "schedule.json")
+		require.NoError(t, err)
+		part.Write(data)
+		mw.Close()
+
+		w := post(t, &buf, mw.FormDataContentType(), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"created":1`)
+	})
+
+	t.Run("Rejected imports", func(t *testing.T) {
+		w := post(t, strings.NewReader(ics), "text/calendar", "?category=nonsense")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = post(t, strings.NewReader("hello"), "text/plain", "?category=sports_fitness")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		tooMany := "BEGIN:VCALENDAR\r\n" + strings.Repeat(vevent("Home Game", day+"T180000Z", geo), MaxImportEvents+1) + "END:VCALENDAR\r\n"
+		w = post(t, strings.NewReader(tooMany), "text/calendar", "?category=sports_fitness")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unverified users can't import", func(t *testing.T) {
+		unverifiedID := createTestUser(t, testDB, "new@example.com", "Newbie", "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, unverifiedID)
+		require.NoError(t, err)
+		unverified, _ := generateToken(User{ID: int(unverifiedID), Email: "new@example.com"})
+		req := httptest.NewRequest("POST", "/api/events/import?category=sports_fitness", strings.NewReader(ics))
+		req.Header.Set("Authorization", "Bearer "+unverified)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventListSpotsLeft(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+
+	limited := createTestEvent(t, testDB, organizerID, "Limited Event")
+	unlimited := createTestEvent(t, testDB, organizerID, "Unlimited Event")
+	_, err := testDB.Exec(`UPDATE events SET max_participants = 5 WHERE id = ?`, limited)
+	require.NoError(t, err)
+	addParticipant(t, testDB, limited, aliceID)
+	addParticipant(t, testDB, limited, bobID)
+	addParticipant(t, testDB, unlimited, aliceID)
+
+	events, err := queryEventList(context.Background(), EventFilter{}, int(aliceID), true, false)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	byID := map[int]Event{}
+	for _, e := range events {
+		byID[e.ID] = e
+	}
+	require.NotNil(t, byID[int(limited)].SpotsLeft)
+	assert.Equal(t, 3, *byID[int(limited)].SpotsLeft)
+	assert.Equal(t, 2, byID[int(limited)].ParticipantCount)
+	assert.True(t, byID[int(limited)].IsParticipant)
+	assert.Nil(t, byID[int(unlimited)].SpotsLeft)
+	assert.Equal(t, 1, byID[int(unlimited)].ParticipantCount)
+
+	// Bob's view of the same listing
+	events, err = queryEventList(context.Background(), EventFilter{}, int(bobID), true, false)
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.Equal(t, e.ID == int(limited), e.IsParticipant, e.Title)
+	}
+
+	// spots_left is serialized as null rather than omitted for unlimited events
+	body, err := json.Marshal(byID[int(unlimited)])
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"spots_left":null`)
+}
+
+func TestEventListCountsUnderConcurrentJoins(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createTestEvent(t, testDB, organizerID, "Popular Event")
+	_, err := testDB.Exec(`UPDATE events SET max_participants = 5 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	tokens := make([]string, 20)
+	for i := range tokens {
+		email := fmt.Sprintf("joiner%d@example.com", i)
+		id := createTestUser(t, testDB, email, "Joiner", "password123", false)
+		tokens[i], _ = generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+	}
+
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), token, nil)
+		}(token)
+	}
+	wg.Wait()
+
+	var stored int
+	require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID).Scan(&stored))
+	assert.LessOrEqual(t, stored, 5)
+
+	events, err := queryEventList(context.Background(), EventFilter{}, 0, false, false)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, stored, events[0].ParticipantCount)
+	require.NotNil(t, events[0].SpotsLeft)
+	assert.Equal(t, 5-stored, *events[0].SpotsLeft)
+}
+
+// legacyEventListQuery is the listing query before participant_count was denormalized onto the
+// events row (two correlated subqueries per row); kept for the before/after benchmark
+const legacyEventListQuery = `
+	SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
+	       e.start_time, e.end_time, e.creator_name, e.max_participants,
+	       e.gender_restriction, e.age_min, e.age_max,
+	       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
+	       e.hide_organizer_until_joined, e.hide_participants_until_joined,
+	       e.require_verified_to_join, e.require_verified_to_view, e.allow_unregistered_users,
+	       u.email, u.languages as creator_languages,
+	       (SELECT COUNT(*) FROM event_participants WHERE event_id = e.id) as participant_count,
+	       (SELECT COUNT(*) > 0 FROM event_participants WHERE event_id = e.id AND user_id = ?) as is_participant
+	FROM events e
+	LEFT JOIN users u ON e.user_id = u.id
+	WHERE e.start_time >= datetime('now')
+	AND e.start_time <= datetime('now', '+1 month')
+	AND e.cancelled_at IS NULL
+	ORDER BY e.start_time ASC LIMIT 100`
+
+// seedEventListBenchmark creates 5k upcoming events and ~50k participant rows
+func seedEventListBenchmark(b *testing.B, testDB *sql.DB) {
+	_, err := seedDatabase(testDB, SeedOptions{Users: 500, Events: 5000, ParticipantsPerEvent: 20, Seed: 1, Now: time.Now()})
+	require.NoError(b, err)
+
+	// Same indexes as production (see initDB)
+	testDB.Exec(`CREATE INDEX IF NOT EXISTS idx_events_start_time ON events(start_time)`)
+	testDB.Exec(`CREATE INDEX IF NOT EXISTS idx_participants_event_id ON event_participants(event_id)`)
+	testDB.Exec(`CREATE INDEX IF NOT EXISTS idx_participants_user_id ON event_participants(user_id)`)
+}
+
+func BenchmarkEventList(b *testing.B) {
+	testDB := setupTestDB(b)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	seedEventListBenchmark(b, testDB)
+
+	drain := func(b *testing.B, query string, args ...interface{}) {
+		for i := 0; i < b.N; i++ {
+			rows, err := db.Query(query, args...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for rows.Next() {
+			}
+			rows.Close()
+		}
+	}
+
+	b.Run("legacy correlated subqueries", func(b *testing.B) {
+		drain(b, legacyEventListQuery, 42)
+	})
+
+	b.Run("denormalized count", func(b *testing.B) {
+		query, args := BuildEventsQuery(EventFilter{}, EventViewer{ID: 42})
+		drain(b, query, args...)
+	})
+
+	b.Run("handler anonymous uncached", func(b *testing.B) {
+		router := gin.New()
+		router.GET("/api/events", getEvents)
+		for i := 0; i < b.N; i++ {
+			eventListCache.Invalidate()
+			if w := doJSON(router, "GET", "/api/events", "", nil); w.Code != http.StatusOK {
+				b.Fatal(w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultPriceCurrency is used when an event states a price without a currency
+const DefaultPriceCurrency = "CHF"
+
+// maxPaymentNoteLength caps payment_note, counted before HTML escaping
+const maxPaymentNoteLength = 200
+
+// priceCurrencies are the ISO 4217 codes events may be priced in. All have two decimal places,
+// so price_amount is always in cents.
+var priceCurrencies = map[string]bool{
+	"CHF": true, "EUR": true, "USD": true, "GBP": true,
+	"SEK": true, "NOK": true, "DKK": true, "PLN": true, "CZK": true,
+}
+
+var (
+	ErrInvalidPriceAmount   = errors.New("price_amount must be zero or a positive number of cents")
+	ErrInvalidPriceCurrency = errors.New("price_currency must be one of: CHF, CZK, DKK, EUR, GBP, NOK, PLN, SEK, USD")
+	ErrPaymentNoteTooLong   = fmt.Errorf("payment_note too long (max %d characters)", maxPaymentNoteLength)
+	ErrInvalidMaxPrice      = errors.New("max_price must be zero or a positive number of cents")
+)
+
+// ValidateEventPrice checks and normalizes the price fields: the currency is upper-cased and
+// defaults to CHF, the note is trimmed, stripped of markup and escaped like other event text
+func ValidateEventPrice(event *Event) error {
+	if event.PriceAmount != nil && *event.PriceAmount < 0 {
+		return ErrInvalidPriceAmount
+	}
+
+	event.PriceCurrency = strings.ToUpper(strings.TrimSpace(event.PriceCurrency))
+	if event.PriceCurrency == "" {
+		event.PriceCurrency = DefaultPriceCurrency
+	}
+	if !priceCurrencies[event.PriceCurrency] {
+		return ErrInvalidPriceCurrency
+	}
+
+	event.PaymentNote = strings.TrimSpace(stripHTMLTags(event.PaymentNote))
+	if utf8.RuneCountInString(event.PaymentNote) > maxPaymentNoteLength {
+		return ErrPaymentNoteTooLong
+	}
+	event.PaymentNote = html.EscapeString(event.PaymentNote)
+	return nil
+}
+
+// formatEventPrice renders the price for people, e.g. "CHF 10.00 (cash at the door)" or "Free".
+// It returns "" when the event states neither a price nor a payment note.
+func formatEventPrice(event *Event) string {
+	price := ""
+	switch {
+	case event.PriceAmount == nil:
+	case *event.PriceAmount == 0:
+		price = "Free"
+	default:
+		currency := event.PriceCurrency
+		if currency == "" {
+			currency = DefaultPriceCurrency
+		}
+		price = fmt.Sprintf("%s %d.%02d", currency, *event.PriceAmount/100, *event.PriceAmount%100)
+	}
+
+	note := html.UnescapeString(event.PaymentNote)
+	switch {
+	case note == "":
+		return price
+	case price == "":
+		return note
+	}
+	return price + " (" + note + ")"
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEventPrice(t *testing.T) {
+	amount := 1000
+	event := Event{PriceAmount: &amount, PriceCurrency: " eur ", PaymentNote: "  <b>Cash</b> at the door & tips  "}
+	require.NoError(t, ValidateEventPrice(&event))
+	assert.Equal(t, "EUR", event.PriceCurrency)
+	assert.Equal(t, "Cash at the door &amp; tips", event.PaymentNote)
+
+	event = Event{}
+	require.NoError(t, ValidateEventPrice(&event))
+	assert.Equal(t, DefaultPriceCurrency, event.PriceCurrency, "currency defaults to CHF")
+	assert.Nil(t, event.PriceAmount)
+
+	negative := -1
+	assert.ErrorIs(t, ValidateEventPrice(&Event{PriceAmount: &negative}), ErrInvalidPriceAmount)
+	assert.ErrorIs(t, ValidateEventPrice(&Event{PriceCurrency: "BTC"}), ErrInvalidPriceCurrency)
+	assert.ErrorIs(t, ValidateEventPrice(&Event{PaymentNote: strings.Repeat("x", maxPaymentNoteLength+1)}), ErrPaymentNoteTooLong)
+}
+
+func TestFormatEventPrice(t *testing.T) {
+	amount, free := 1050, 0
+	assert.Equal(t, "CHF 10.50", formatEventPrice(&Event{PriceAmount: &amount, PriceCurrency: "CHF"}))
+	assert.Equal(t, "Free", formatEventPrice(&Event{PriceAmount: &free}))
+	assert.Equal(t, "EUR 10.50 (cash & card)", formatEventPrice(&Event{PriceAmount: &amount, PriceCurrency: "EUR", PaymentNote: "cash &amp; card"}))
+	assert.Equal(t, "bring a snack", formatEventPrice(&Event{PaymentNote: "bring a snack"}))
+	assert.Empty(t, formatEventPrice(&Event{}))
+}
+
+func TestEventPrices(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/public/events/:slug/ics", downloadEventICS)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "organizer@example.com", EmailVerified: true})
+
+	payload := func(title string, price gin.H) gin.H {
+		body := gin.H{
+			"title": title, "description": "An evening of board games",
+			"category": "gaming_hobbies", "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+		for k, v := range price {
+			body[k] = v
+		}
+		return body
+	}
+	create := func(title string, price gin.H) Event {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/events", token, payload(title, price))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+	titles := func(query string) []string {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		var titles []string
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	paid := create("Catan Night", gin.H{"price_amount": 1000, "payment_note": "CHF 10 for the hall, cash"})
+	create("Chess Meetup", nil)
+	create("Go Club", gin.H{"price_amount": 0})
+	create("Poker Evening", gin.H{"price_amount": 2500, "price_currency": "eur"})
+
+	t.Run("Prices are in the public payload", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/"+paid.Slug, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		require.NotNil(t, event.PriceAmount)
+		assert.Equal(t, 1000, *event.PriceAmount)
+		assert.Equal(t, "CHF", event.PriceCurrency)
+		assert.Equal(t, "CHF 10 for the hall, cash", event.PaymentNote)
+		assert.Contains(t, w.Body.String(), `"price_amount":1000`)
+	})
+
+	t.Run("The ICS description states the price", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/"+paid.Slug+"/ics", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		unfolded := strings.ReplaceAll(w.Body.String(), "\r\n ", "") // long lines are folded
+		assert.Contains(t, unfolded, `Price: CHF 10.00 (CHF 10 for the hall\, cash)`)
+	})
+
+	t.Run("free_only and max_price filter the listing", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Catan Night", "Chess Meetup", "Go Club", "Poker Evening"}, titles(""))
+		assert.ElementsMatch(t, []string{"Chess Meetup", "Go Club"}, titles("?free_only=true"))
+		assert.ElementsMatch(t, []string{"Catan Night", "Chess Meetup", "Go Club"}, titles("?max_price=1500"))
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/events?max_price=cheap", "", nil).Code)
+	})
+
+	t.Run("Invalid prices are rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload("Mahjong", gin.H{"price_amount": 500, "price_currency": "BTC"}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "price_currency")
+
+		w = doJSON(router, "POST", "/api/events", token, payload("Mahjong", gin.H{"price_amount": -5}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", paid.ID), token, payload("Catan Night", gin.H{"price_amount": 1000, "price_currency": "XYZ"}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxEventQuestions caps how many questions an organizer can ask joiners
+const MaxEventQuestions = 3
+
+const (
+	maxQuestionTextLength = 300
+	maxAnswerLength       = 1000
+)
+
+// Question types
+const (
+	QuestionTypeText  = "text"
+	QuestionTypeYesNo = "yes_no" // Answered with "yes" or "no"
+)
+
+// ErrCodeInvalidAnswers is returned when a join's answers don't satisfy the event's questions
+const ErrCodeInvalidAnswers = "INVALID_ANSWERS"
+
+var (
+	ErrTooManyQuestions    = fmt.Errorf("at most %d questions per event", MaxEventQuestions)
+	ErrQuestionText        = fmt.Errorf("question text must be 1-%d characters", maxQuestionTextLength)
+	ErrInvalidQuestionType = errors.New("question type must be text or yes_no")
+	ErrAnswerRequired      = errors.New("an answer is required")
+	ErrInvalidYesNoAnswer  = errors.New("answer must be yes or no")
+	ErrAnswerTooLong       = fmt.Errorf("answers are limited to %d characters", maxAnswerLength)
+	ErrUnknownQuestion     = errors.New("answer to a question this event doesn't ask")
+)
+
+// EventQuestion is something the organizer asks everyone who joins. Text is HTML-escaped.
+type EventQuestion struct {
+	ID       int    `json:"id"`
+	Text     string `json:"text"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// EventQuestionsRequest replaces an event's questions. Questions keep their ID when resubmitted
+// unchanged; any edit makes a new question so earlier answers stay with the wording they answered.
+type EventQuestionsRequest struct {
+	Questions []EventQuestion `json:"questions"`
+}
+
+// QuestionAnswer is a joiner's answer to one question
+type QuestionAnswer struct {
+	QuestionID int    `json:"question_id"`
+	Answer     string `json:"answer"`
+}
+
+// EventAnswer is one participant's answer as the organizer sees it. Retired is set when the
+// question has since been edited or removed; Question is the wording that was answered.
+type EventAnswer struct {
+	UserID     int    `json:"user_id"`
+	Name       string `json:"name"`
+	QuestionID int    `json:"question_id"`
+	Question   string `json:"question"`
+	Type       string `json:"type"`
+	Answer     string `json:"answer"`
+	Retired    bool   `json:"retired"`
+}
+
+// sqlQueryer is satisfied by both *sql.DB and *sql.Tx
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ValidateEventQuestions trims and escapes question texts and defaults the type to text
+func ValidateEventQuestions(questions []EventQuestion) error {
+	if len(questions) > MaxEventQuestions {
+		return ErrTooManyQuestions
+	}
+	for i := range questions {
+		q := &questions[i]
+		q.Text = strings.TrimSpace(q.Text)
+		if q.Text == "" || utf8.RuneCountInString(q.Text) > maxQuestionTextLength {
+			return ErrQuestionText
+		}
+		q.Text = html.EscapeString(q.Text)
+		if q.Type == "" {
+			q.Type = QuestionTypeText
+		}
+		if q.Type != QuestionTypeText && q.Type != QuestionTypeYesNo {
+			return ErrInvalidQuestionType
+		}
+	}
+	return nil
+}
+
+// validateJoinAnswers checks answers against an event's current questions: every required
+// question is answered, yes/no questions get "yes" or "no". It returns the answers to store,
+// normalized and escaped, leaving out blank answers to optional questions.
+func validateJoinAnswers(questions []EventQuestion, answers []QuestionAnswer) ([]QuestionAnswer, error) {
+	given := map[int]string{}
+	for _, a := range answers {
+		given[a.QuestionID] = strings.TrimSpace(a.Answer)
+	}
+
+	var valid []QuestionAnswer
+	for _, q := range questions {
+		answer := given[q.ID]
+		delete(given, q.ID)
+		if answer == "" {
+			if q.Required {
+				return nil, fmt.Errorf("%w: %s", ErrAnswerRequired, html.UnescapeString(q.Text))
+			}
+			continue
+		}
+		if q.Type == QuestionTypeYesNo {
+			answer = strings.ToLower(answer)
+			if answer != "yes" && answer != "no" {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidYesNoAnswer, html.UnescapeString(q.Text))
+			}
+		} else if utf8.RuneCountInString(answer) > maxAnswerLength {
+			return nil, ErrAnswerTooLong
+		}
+		valid = append(valid, QuestionAnswer{QuestionID: q.ID, Answer: html.EscapeString(answer)})
+	}
+	if len(given) > 0 {
+		return nil, ErrUnknownQuestion
+	}
+	return valid, nil
+}
+
+// loadEventQuestions returns an event's current questions in order
+func loadEventQuestions(ctx context.Context, q sqlQueryer, eventID int) ([]EventQuestion, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, text, type, required FROM event_questions
+		WHERE event_id = ? AND retired_at IS NULL
+		ORDER BY position, id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []EventQuestion{}
+	for rows.Next() {
+		var question EventQuestion
+		if err := rows.Scan(&question.ID, &question.Text, &question.Type, &question.Required); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+// storeJoinAnswers replaces a participant's answers for an event, inside the join transaction
+func storeJoinAnswers(ctx context.Context, tx *sql.Tx, eventID, userID int, answers []QuestionAnswer) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_question_answers WHERE event_id = ? AND user_id = ?`, eventID, userID); err != nil {
+		return err
+	}
+	for _, a := range answers {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO event_question_answers (question_id, event_id, user_id, answer) VALUES (?, ?, ?, ?)
+		`, a.QuestionID, eventID, userID, a.Answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEventAnswers returns the answers of an event's current participants, in join order, leaving
+// out participants with a block relationship to viewerID like the participant export does
+func loadEventAnswers(ctx context.Context, eventID, viewerID int) ([]EventAnswer, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.user_id, u.name, q.id, q.text, q.type, a.answer, q.retired_at IS NOT NULL
+		FROM event_question_answers a
+		JOIN event_questions q ON q.id = a.question_id
+		JOIN users u ON u.id = a.user_id
+		JOIN event_participants ep ON ep.event_id = a.event_id AND ep.user_id = a.user_id
+		WHERE a.event_id = ?
+		  AND NOT EXISTS (
+		      SELECT 1 FROM user_blocks ub
+		      WHERE (ub.blocker_id = ? AND ub.blocked_id = u.id)
+		         OR (ub.blocker_id = u.id AND ub.blocked_id = ?)
+		  )
+		ORDER BY ep.joined_at, ep.id, q.position, q.id
+	`, eventID, viewerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := []EventAnswer{}
+	for rows.Next() {
+		var a EventAnswer
+		if err := rows.Scan(&a.UserID, &a.Name, &a.QuestionID, &a.Question, &a.Type, &a.Answer, &a.Retired); err != nil {
+			return nil, err
+		}
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// requireEventOrganizer responds with an error unless the viewer created the event or is an admin
+func requireEventOrganizer(c *gin.Context, eventID int, action string) bool {
+	var creatorID int
+	err := db.QueryRowContext(c.Request.Context(), `SELECT user_id FROM events WHERE id = ?`, eventID).Scan(&creatorID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action})
+		return false
+	}
+	if creatorID != c.GetInt("user_id") && !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can " + action})
+		return false
+	}
+	return true
+}
+
+// setEventQuestions replaces the questions asked when joining (PUT /api/events/:id/questions).
+// Creator and admins only. Edited or removed questions that were already answered are retired
+// rather than deleted, so existing answers keep their wording.
+func setEventQuestions(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	log.Printf("❓ PUT /api/events/%d/questions - User %d updating questions", eventID, c.GetInt("user_id"))
+
+	var req EventQuestionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if err := ValidateEventQuestions(req.Questions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !requireEventOrganizer(c, eventID, "edit questions") {
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save questions"})
+		return
+	}
+	defer tx.Rollback()
+
+	if err := replaceEventQuestions(ctx, tx, eventID, req.Questions); err != nil {
+		log.Printf("❌ Error saving questions of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save questions"})
+		return
+	}
+	questions, err := loadEventQuestions(ctx, tx, eventID)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error saving questions of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save questions"})
+		return
+	}
+
+	log.Printf("✅ Event %d now asks %d questions", eventID, len(questions))
+	c.JSON(http.StatusOK, gin.H{"questions": questions})
+}
+
+// replaceEventQuestions makes questions the event's current set, in order
+func replaceEventQuestions(ctx context.Context, tx *sql.Tx, eventID int, questions []EventQuestion) error {
+	current, err := loadEventQuestions(ctx, tx, eventID)
+	if err != nil {
+		return err
+	}
+	unchanged := map[int]EventQuestion{}
+	for _, q := range current {
+		unchanged[q.ID] = q
+	}
+
+	kept := map[int]bool{}
+	for position, q := range questions {
+		if existing, ok := unchanged[q.ID]; ok && !kept[q.ID] &&
+			existing.Text == q.Text && existing.Type == q.Type && existing.Required == q.Required {
+			kept[q.ID] = true
+			if _, err := tx.ExecContext(ctx, `UPDATE event_questions SET position = ? WHERE id = ?`, position, q.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO event_questions (event_id, position, text, type, required) VALUES (?, ?, ?, ?, ?)
+		`, eventID, position, q.Text, q.Type, q.Required); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range current {
+		if kept[q.ID] {
+			continue
+		}
+		// Unanswered questions can simply go; answered ones are retired
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM event_questions WHERE id = ? AND NOT EXISTS (SELECT 1 FROM event_question_answers WHERE question_id = ?)
+		`, q.ID, q.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE event_questions SET retired_at = ? WHERE id = ?`, time.Now().UTC(), q.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getEventAnswers lists participants' answers to the event's questions (GET /api/events/:id/answers).
+// Creator and admins only; participants never see each other's answers.
+func getEventAnswers(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("❓ GET /api/events/%d/answers - User %d fetching answers", eventID, userID)
+
+	if !requireEventOrganizer(c, eventID, "view answers") {
+		return
+	}
+	questions, err := loadEventQuestions(ctx, db, eventID)
+	if err != nil {
+		log.Printf("❌ Error loading questions of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load answers"})
+		return
+	}
+	answers, err := loadEventAnswers(ctx, eventID, userID)
+	if err != nil {
+		log.Printf("❌ Error loading answers of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load answers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"questions": questions, "answers": answers})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEventQuestions(t *testing.T) {
+	questions := []EventQuestion{{Text: "  Own <climbing> shoes? "}, {Text: "Shoe size", Type: QuestionTypeText}}
+	require.NoError(t, ValidateEventQuestions(questions))
+	assert.Equal(t, "Own &lt;climbing&gt; shoes?", questions[0].Text)
+	assert.Equal(t, QuestionTypeText, questions[0].Type, "free text by default")
+
+	assert.ErrorIs(t, ValidateEventQuestions(make([]EventQuestion, MaxEventQuestions+1)), ErrTooManyQuestions)
+	assert.ErrorIs(t, ValidateEventQuestions([]EventQuestion{{Text: " "}}), ErrQuestionText)
+	assert.ErrorIs(t, ValidateEventQuestions([]EventQuestion{{Text: "Size?", Type: "number"}}), ErrInvalidQuestionType)
+}
+
+func TestValidateJoinAnswers(t *testing.T) {
+	questions := []EventQuestion{
+		{ID: 1, Text: "Own shoes?", Type: QuestionTypeYesNo, Required: true},
+		{ID: 2, Text: "Anything else?", Type: QuestionTypeText},
+	}
+
+	answers, err := validateJoinAnswers(questions, []QuestionAnswer{{QuestionID: 1, Answer: " Yes "}, {QuestionID: 2, Answer: " "}})
+	require.NoError(t, err)
+	assert.Equal(t, []QuestionAnswer{{QuestionID: 1, Answer: "yes"}}, answers, "blank optional answers are dropped")
+
+	_, err = validateJoinAnswers(questions, nil)
+	assert.ErrorIs(t, err, ErrAnswerRequired)
+	_, err = validateJoinAnswers(questions, []QuestionAnswer{{QuestionID: 1, Answer: "maybe"}})
+	assert.ErrorIs(t, err, ErrInvalidYesNoAnswer)
+	_, err = validateJoinAnswers(questions, []QuestionAnswer{{QuestionID: 1, Answer: "no"}, {QuestionID: 9, Answer: "hi"}})
+	assert.ErrorIs(t, err, ErrUnknownQuestion)
+
+	answers, err = validateJoinAnswers(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, answers, "events without questions accept joins without answers")
+}
+
+func TestEventQuestions(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.PUT("/events/:id/questions", setEventQuestions)
+	protected.GET("/events/:id/answers", getEventAnswers)
+	protected.GET("/events/:id/participants/export", exportEventParticipants)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com", EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Bouldering Workshop")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'bouldering-workshop' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	base := fmt.Sprintf("/api/events/%d", eventID)
+
+	setQuestions := func(token string, questions []gin.H) []EventQuestion {
+		t.Helper()
+		w := doJSON(router, "PUT", base+"/questions", token, gin.H{"questions": questions})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Questions []EventQuestion `json:"questions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Questions
+	}
+	answers := func(token string) []EventAnswer {
+		t.Helper()
+		w := doJSON(router, "GET", base+"/answers", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Answers []EventAnswer `json:"answers"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Answers
+	}
+
+	questions := setQuestions(organizerToken, []gin.H{
+		{"text": "Do you have your own climbing shoes?", "type": "yes_no", "required": true},
+		{"text": "Shoe size if not?"},
+	})
+	require.Len(t, questions, 2)
+	shoes, size := questions[0], questions[1]
+
+	t.Run("Only the organizer sets questions", func(t *testing.T) {
+		w := doJSON(router, "PUT", base+"/questions", aliceToken, gin.H{"questions": []gin.H{{"text": "Hi?"}}})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(router, "PUT", base+"/questions", organizerToken, gin.H{"questions": []gin.H{{"text": "1"}, {"text": "2"}, {"text": "3"}, {"text": "4"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("The public event lists the questions", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/bouldering-workshop", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, questions, event.Questions)
+	})
+
+	t.Run("Join without a required answer is rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", base+"/join", aliceToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidAnswers)
+		w = doJSON(router, "POST", base+"/join", aliceToken, gin.H{"answers": []gin.H{{"question_id": shoes.ID, "answer": "sort of"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var joined int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID).Scan(&joined))
+		assert.Zero(t, joined)
+	})
+
+	w := doJSON(router, "POST", base+"/join", aliceToken, gin.H{"answers": []gin.H{
+		{"question_id": shoes.ID, "answer": "No"}, {"question_id": size.ID, "answer": "42"},
+	}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(router, "POST", base+"/join", bobToken, gin.H{"answers": []gin.H{{"question_id": shoes.ID, "answer": "yes"}}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Run("Answers are visible only to the organizer", func(t *testing.T) {
+		list := answers(organizerToken)
+		require.Len(t, list, 3)
+		assert.Equal(t, EventAnswer{UserID: int(aliceID), Name: "Alice", QuestionID: shoes.ID,
+			Question: "Do you have your own climbing shoes?", Type: QuestionTypeYesNo, Answer: "no"}, list[0])
+		assert.Equal(t, "42", list[1].Answer)
+		assert.Equal(t, "Bob", list[2].Name)
+
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", base+"/answers", aliceToken, nil).Code)
+		w := doJSON(router, "GET", "/api/public/events/bouldering-workshop", bobToken, nil)
+		assert.NotContains(t, w.Body.String(), `"answer"`)
+	})
+
+	t.Run("Editing a question doesn't orphan its answers", func(t *testing.T) {
+		updated := setQuestions(organizerToken, []gin.H{
+			{"id": shoes.ID, "text": "Do you have your own climbing shoes?", "type": "yes_no", "required": true},
+			{"id": size.ID, "text": "Shoe size (EU) if not?"},
+		})
+		require.Len(t, updated, 2)
+		assert.Equal(t, shoes.ID, updated[0].ID, "unchanged questions keep their ID")
+		assert.NotEqual(t, size.ID, updated[1].ID)
+
+		list := answers(organizerToken)
+		require.Len(t, list, 3)
+		assert.Equal(t, size.ID, list[1].QuestionID)
+		assert.Equal(t, "Shoe size if not?", list[1].Question, "the answer keeps the wording it answered")
+		assert.True(t, list[1].Retired)
+		assert.False(t, list[0].Retired)
+
+		// Removing an unanswered question deletes it outright
+		setQuestions(organizerToken, []gin.H{{"id": shoes.ID, "text": "Do you have your own climbing shoes?", "type": "yes_no", "required": true}})
+		var rows int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM event_questions WHERE event_id = ?`, eventID).Scan(&rows))
+		assert.Equal(t, 2, rows, "the answered original stays retired, the unanswered edit is gone")
+	})
+
+	t.Run("The participant export has a column per question", func(t *testing.T) {
+		w := doJSON(router, "GET", base+"/participants/export", organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "name,joined_at,attendance,email,threema,Do you have your own climbing shoes?,Shoe size if not? (earlier version)\n")
+		assert.Contains(t, w.Body.String(), ",no,42\n")
+		assert.Contains(t, w.Body.String(), ",yes,\n")
+
+		w = doJSON(router, "GET", base+"/participants/export?format=json", organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var rows []ParticipantExportRow
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		require.Len(t, rows, 2)
+		assert.Len(t, rows[0].Answers, 2)
+	})
+
+	t.Run("Rejoining replaces earlier answers", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doJSON(router, "DELETE", base+"/leave", bobToken, nil).Code)
+		assert.Len(t, answers(organizerToken), 2, "answers of people who left are not listed")
+
+		w := doJSON(router, "POST", base+"/join", bobToken, gin.H{"answers": []gin.H{{"question_id": shoes.ID, "answer": "no"}}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		list := answers(organizerToken)
+		require.Len(t, list, 3)
+		assert.Equal(t, "Bob", list[2].Name)
+		assert.Equal(t, "no", list[2].Answer)
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// eventColumns is the SELECT list scanEventRow reads, for queries over
+// `events e LEFT JOIN users u ON e.user_id = u.id`. Extra columns go after it.
+const eventColumns = `
+		e.id, e.user_id, e.title, e.description, e.description_format, e.category, e.latitude, e.longitude,
+		e.start_time, e.end_time, e.creator_name, e.max_participants,
+		e.gender_restriction, e.age_min, e.age_max,
+		e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.timezone, e.created_at, e.updated_at,
+		e.location_name, e.address,
+		e.hide_organizer_until_joined, COALESCE(e.hide_participants_until_joined, 1),
+		e.require_verified_to_join, e.require_verified_to_view, COALESCE(e.allow_unregistered_users, 1), e.require_birth_year, e.hidden_pending_review, e.published = 0,
+		u.email, e.participant_count, e.interested_count, e.cancelled_at IS NOT NULL, e.group_id,
+		e.price_amount, e.price_currency, e.payment_note, e.max_joins_per_network, e.reserved_spots, e.joins_paused,
+		e.is_featured, e.featured_until, e.join_deadline, ` + organizerColumns
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEventRow reads one eventColumns row, plus any extra trailing columns into extra.
+// Nullable columns come back as zero values or their column defaults (a NULL gender_restriction as "any", NULL
+// age bounds as 0-99, a NULL updated_at as created_at, a NULL price_currency as CHF) and
+// start/end times and the join deadline as RFC3339 UTC; pass the organizerRow on to serializeEvent.
+func scanEventRow(row rowScanner, extra ...interface{}) (Event, organizerRow, error) {
+	var e Event
+	var org organizerRow
+	var startTime, endTime, joinDeadline, genderRestriction, eventLanguages, slug, userEmail, priceCurrency sql.NullString
+	var maxParticipants, ageMin, ageMax, groupID, priceAmount sql.NullInt64
+	var createdAt time.Time
+	var updatedAt, featuredUntil sql.NullTime
+	var featured bool
+
+	dest := []interface{}{
+		&e.ID, &e.UserID, &e.Title, &e.Description, &e.DescriptionFormat, &e.Category, &e.Latitude, &e.Longitude,
+		&startTime, &endTime, &e.CreatorName, &maxParticipants,
+		&genderRestriction, &ageMin, &ageMax,
+		nullable(&e.SmokingAllowed), nullable(&e.AlcoholAllowed), &eventLanguages, &slug, &e.Timezone, nullable(&createdAt), &updatedAt,
+		&e.LocationName, &e.Address,
+		nullable(&e.HideOrganizerUntilJoined), nullable(&e.HideParticipantsUntilJoined),
+		nullable(&e.RequireVerifiedToJoin), nullable(&e.RequireVerifiedToView), nullable(&e.AllowUnregisteredUsers), nullable(&e.RequireBirthYear),
+		&e.HiddenPendingReview, &e.Draft,
+		&userEmail, &e.ParticipantCount, &e.InterestedCount, &e.Cancelled, &groupID,
+		&priceAmount, &priceCurrency, nullable(&e.PaymentNote), nullable(&e.NetworkJoinLimit), &e.ReservedSpots, &e.JoinsPaused,
+		&featured, &featuredUntil, &joinDeadline,
+	}
+	dest = append(dest, org.dest()...)
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return e, org, err
+	}
+
+	e.StartTime = formatStoredTime(startTime.String)
+	e.EndTime = formatStoredTime(endTime.String)
+	e.JoinDeadline = formatStoredTime(joinDeadline.String)
+	e.MaxParticipants = int(maxParticipants.Int64)
+	e.AgeMin, e.AgeMax = 0, 99
+	if ageMin.Valid {
+		e.AgeMin = int(ageMin.Int64)
+	}
+	if ageMax.Valid {
+		e.AgeMax = int(ageMax.Int64)
+	}
+	if groupID.Valid {
+		id := int(groupID.Int64)
+		e.GroupID = &id
+	}
+	if priceAmount.Valid {
+		amount := int(priceAmount.Int64)
+		e.PriceAmount = &amount
+	}
+	e.PriceCurrency = priceCurrency.String
+	if e.PriceCurrency == "" {
+		e.PriceCurrency = DefaultPriceCurrency
+	}
+	e.GenderRestriction = genderRestriction.String
+	if !genderRestriction.Valid || e.GenderRestriction == "" {
+		e.GenderRestriction = "any"
+	}
+	e.EventLanguages = eventLanguages.String
+	e.Slug = slug.String
+	e.UserEmail = userEmail.String
+	e.DescriptionHTML = renderDescriptionHTML(e.Description, e.DescriptionFormat)
+	e.CreatedAt = createdAt
+	e.UpdatedAt = createdAt
+	if updatedAt.Valid {
+		e.UpdatedAt = updatedAt.Time
+	}
+	// A feature past its end counts as over even before housekeeping clears the flag
+	if featured && (!featuredUntil.Valid || featuredUntil.Time.After(time.Now())) {
+		e.IsFeatured = true
+		if featuredUntil.Valid {
+			until := featuredUntil.Time.UTC()
+			e.FeaturedUntil = &until
+		}
+	}
+	return e, org, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Every event endpoint reads rows through scanEventRow; nullable columns must come back as zero
+// values rather than failing the scan (and dropping the event from listings)
+func TestEventEndpointsHandleNullColumns(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/public/events/:slug/ics", downloadEventICS)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/events", adminGetAllEvents)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+
+	// createTestEvent leaves max_participants, end_time and gender_restriction NULL
+	eventID := createTestEvent(t, testDB, userID, "Null Columns")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'null-columns', event_languages = NULL WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	var nulls int
+	require.NoError(t, testDB.QueryRow(`
+		SELECT COUNT(*) FROM events WHERE id = ? AND max_participants IS NULL AND end_time IS NULL AND gender_restriction IS NULL
+	`, eventID).Scan(&nulls))
+	require.Equal(t, 1, nulls)
+
+	assertEvent := func(t *testing.T, e Event) {
+		assert.Equal(t, int(eventID), e.ID)
+		assert.Equal(t, "Null Columns", e.Title)
+		assert.Zero(t, e.MaxParticipants)
+		assert.Empty(t, e.EndTime)
+		assert.Equal(t, "any", e.GenderRestriction)
+		assert.Equal(t, "null-columns", e.Slug)
+		assert.NotEmpty(t, e.StartTime)
+		require.NotNil(t, e.Organizer)
+		assert.Equal(t, "Organizer", e.Organizer.Name)
+	}
+	find := func(t *testing.T, events []Event) Event {
+		for _, e := range events {
+			if e.ID == int(eventID) {
+				return e
+			}
+		}
+		t.Fatalf("event %d missing from %d events", eventID, len(events))
+		return Event{}
+	}
+
+	t.Run("Listing", func(t *testing.T) {
+		eventListCache.Invalidate()
+		w := doJSON(router, "GET", "/api/events", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		assertEvent(t, find(t, events))
+	})
+
+	t.Run("By ID", func(t *testing.T) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		assertEvent(t, e)
+	})
+
+	t.Run("By slug", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/null-columns", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		assertEvent(t, e)
+	})
+
+	t.Run("Admin listing", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/admin/events", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Events []Event `json:"events"`
+			Total  int     `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Total)
+		assertEvent(t, find(t, resp.Events))
+	})
+
+	t.Run("ICS", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/null-columns/ics", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "SUMMARY:Null Columns")
+		assert.Contains(t, w.Body.String(), "DTEND:", "end defaults from the start time")
+	})
+
+	t.Run("Getting by ID applies the privacy columns", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET hide_organizer_until_joined = 1 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		assert.True(t, e.OrganizerHidden)
+		assert.Nil(t, e.Organizer)
+	})
+}
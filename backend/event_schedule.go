@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// minEventLeadTime is how far ahead a new or moved event must start
+	minEventLeadTime = 15 * time.Minute
+	// maxEventDuration bounds end_time - start_time
+	maxEventDuration = 7 * 24 * time.Hour
+)
+
+// Codes of EventTimeError, returned next to the field they concern
+const (
+	ErrCodeStartTooSoon   = "START_TOO_SOON"
+	ErrCodeStartTooFar    = "START_TOO_FAR"
+	ErrCodeEndBeforeStart = "END_BEFORE_START"
+	ErrCodeEventTooLong   = "EVENT_TOO_LONG"
+
+	ErrCodeDeadlineAfterStart = "DEADLINE_AFTER_START"
+)
+
+// EventTimeError is a start_time, end_time or join_deadline the schedule rules refuse. Handlers
+// answer with its field and code (see respondEventValidationError) so forms can mark the offending input.
+type EventTimeError struct {
+	Field string
+	Code  string
+	Err   error
+}
+
+func (e *EventTimeError) Error() string { return e.Err.Error() }
+func (e *EventTimeError) Unwrap() error { return e.Err }
+
+// ValidateEventSchedule checks an event's times: it must start at least minEventLeadTime from now
+// and at most MaxEventLeadMonths ahead (unless longRange, an admin override), and end after its
+// start within maxEventDuration. previousStart is the stored start of an event being edited;
+// keeping it skips the start checks, so events that already started can still be corrected.
+func ValidateEventSchedule(startTime time.Time, endTime, previousStart *time.Time, longRange bool) error {
+	now := time.Now()
+	if previousStart == nil || !startTime.Equal(*previousStart) {
+		if startTime.Before(now.Add(minEventLeadTime)) {
+			return &EventTimeError{Field: "start_time", Code: ErrCodeStartTooSoon, Err: ErrEventInPast}
+		}
+		months := appConfig.MaxEventLeadMonths
+		if !longRange && startTime.After(now.AddDate(0, months, 0)) {
+			return &EventTimeError{Field: "start_time", Code: ErrCodeStartTooFar,
+				Err: fmt.Errorf("%w (at most %d months ahead)", ErrEventTooFarAhead, months)}
+		}
+	}
+
+	if endTime != nil {
+		if endTime.Before(startTime) {
+			return &EventTimeError{Field: "end_time", Code: ErrCodeEndBeforeStart, Err: ErrEndBeforeStart}
+		}
+		if endTime.Sub(startTime) > maxEventDuration {
+			return &EventTimeError{Field: "end_time", Code: ErrCodeEventTooLong, Err: ErrEventTooLong}
+		}
+	}
+	return nil
+}
+
+// ValidateJoinDeadline checks that an event's join deadline, if it has one, comes before its start.
+// A deadline already in the past is allowed: it closes signups right away.
+func ValidateJoinDeadline(deadline *time.Time, startTime time.Time) error {
+	if deadline != nil && !deadline.Before(startTime) {
+		return &EventTimeError{Field: "join_deadline", Code: ErrCodeDeadlineAfterStart, Err: ErrDeadlineAfterStart}
+	}
+	return nil
+}
+
+// storedStart parses an event's stored start_time for ValidateEventSchedule; nil when unreadable
+func storedStart(value string) *time.Time {
+	start, err := parseDateTime(value)
+	if err != nil {
+		return nil
+	}
+	return &start
+}
+
+// respondEventValidationError sends the 400 for a failed event validation, with field and code
+// when the times were the problem and fields when attributes were
+func respondEventValidationError(c *gin.Context, err error) {
+	body := gin.H{"error": err.Error()}
+	var timeErr *EventTimeError
+	var attrErr *AttributeError
+	if errors.As(err, &timeErr) {
+		body["field"] = timeErr.Field
+		body["code"] = timeErr.Code
+	} else if errors.As(err, &attrErr) {
+		body["fields"] = attrErr.Fields
+		body["code"] = ErrCodeInvalidAttributes
+	}
+	c.JSON(http.StatusBadRequest, body)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEventSchedule(t *testing.T) {
+	useTestConfig(t, func(cfg *Config) { cfg.MaxEventLeadMonths = 18 })
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+	code := func(err error) string {
+		var timeErr *EventTimeError
+		if assert.ErrorAs(t, err, &timeErr) {
+			return timeErr.Code
+		}
+		return ""
+	}
+
+	t.Run("Start at least 15 minutes ahead", func(t *testing.T) {
+		assert.NoError(t, ValidateEventSchedule(*at(16 * time.Minute), nil, nil, false))
+		err := ValidateEventSchedule(*at(14 * time.Minute), nil, nil, false)
+		assert.ErrorIs(t, err, ErrEventInPast)
+		assert.Equal(t, ErrCodeStartTooSoon, code(err))
+		assert.Equal(t, ErrCodeStartTooSoon, code(ValidateEventSchedule(*at(-time.Hour), nil, nil, true)), "the override only lifts the upper bound")
+	})
+
+	t.Run("Start at most 18 months ahead unless overridden", func(t *testing.T) {
+		inside := now.AddDate(0, 18, 0).Add(-time.Hour)
+		beyond := now.AddDate(0, 18, 0).Add(time.Hour)
+		assert.NoError(t, ValidateEventSchedule(inside, nil, nil, false))
+		err := ValidateEventSchedule(beyond, nil, nil, false)
+		assert.ErrorIs(t, err, ErrEventTooFarAhead)
+		assert.Equal(t, ErrCodeStartTooFar, code(err))
+		assert.NoError(t, ValidateEventSchedule(beyond, nil, nil, true))
+
+		useTestConfig(t, func(cfg *Config) { cfg.MaxEventLeadMonths = 3 })
+		assert.Equal(t, ErrCodeStartTooFar, code(ValidateEventSchedule(now.AddDate(0, 4, 0), nil, nil, false)))
+	})
+
+	t.Run("End after start, within 7 days", func(t *testing.T) {
+		start := *at(time.Hour)
+		assert.NoError(t, ValidateEventSchedule(start, at(time.Hour+7*24*time.Hour), nil, false))
+		assert.NoError(t, ValidateEventSchedule(start, &start, nil, false))
+		err := ValidateEventSchedule(start, at(time.Hour+7*24*time.Hour+time.Minute), nil, false)
+		assert.ErrorIs(t, err, ErrEventTooLong)
+		assert.Equal(t, ErrCodeEventTooLong, code(err))
+		err = ValidateEventSchedule(start, at(30*time.Minute), nil, false)
+		assert.ErrorIs(t, err, ErrEndBeforeStart)
+		assert.Equal(t, ErrCodeEndBeforeStart, code(err))
+	})
+
+	t.Run("An edit may keep a start that has passed", func(t *testing.T) {
+		started := *at(-2 * time.Hour)
+		assert.NoError(t, ValidateEventSchedule(started, at(time.Hour), &started, false))
+		assert.Equal(t, ErrCodeStartTooSoon, code(ValidateEventSchedule(*at(-time.Hour), nil, &started, false)), "moving it is checked")
+		assert.Equal(t, ErrCodeEventTooLong, code(ValidateEventSchedule(started, at(8*24*time.Hour), &started, false)), "the end is still checked")
+	})
+}
+
+func TestEventScheduleEndpoints(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.MaxEventLeadMonths = 18 })
+
+	router := gin.New()
+	protected := router.Group("/api", authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	payload := func(title string, start time.Time, extra gin.H) gin.H {
+		body := gin.H{
+			"title": title, "description": "A walk around the lake with a picnic",
+			"category": "adventure_travel", "latitude": 47.3667, "longitude": 8.55,
+			"start_time": start.UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+		for k, v := range extra {
+			body[k] = v
+		}
+		return body
+	}
+	refused := func(w *httptest.ResponseRecorder, field, code string) {
+		t.Helper()
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, field, body["field"])
+		assert.Equal(t, code, body["code"])
+		assert.NotEmpty(t, body["error"])
+	}
+
+	t.Run("Creation refuses past, imminent and far-off starts", func(t *testing.T) {
+		refused(doJSON(router, "POST", "/api/events", token, payload("Lake walk", time.Now().Add(-time.Hour), nil)), "start_time", ErrCodeStartTooSoon)
+		refused(doJSON(router, "POST", "/api/events", token, payload("Lake walk", time.Now().Add(10*time.Minute), nil)), "start_time", ErrCodeStartTooSoon)
+		refused(doJSON(router, "POST", "/api/events", token, payload("Lake walk", time.Date(2199, 1, 1, 12, 0, 0, 0, time.UTC), nil)), "start_time", ErrCodeStartTooFar)
+
+		start := time.Now().Add(48 * time.Hour)
+		w := doJSON(router, "POST", "/api/events", token, payload("Lake walk", start, gin.H{"end_time": start.Add(8 * 24 * time.Hour).UTC().Format(time.RFC3339)}))
+		refused(w, "end_time", ErrCodeEventTooLong)
+	})
+
+	t.Run("Only admins can schedule long-range events", func(t *testing.T) {
+		far := time.Now().AddDate(3, 0, 0)
+		w := doJSON(router, "POST", "/api/events", token, payload("Anniversary walk", far, gin.H{"long_range": true}))
+		refused(w, "start_time", ErrCodeStartTooFar)
+
+		w = doJSON(router, "POST", "/api/events", adminToken, payload("Anniversary walk", far, gin.H{"long_range": true}))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("Updates keep a started event's time but can't move it into the past", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Morning run")
+		started := time.Now().Add(-30 * time.Minute).UTC().Truncate(time.Second)
+		_, err := testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, started, eventID)
+		require.NoError(t, err)
+		path := fmt.Sprintf("/api/events/%d", eventID)
+
+		w := doJSON(router, "PUT", path, token, payload("Morning run (meet at the gate)", started, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		refused(doJSON(router, "PUT", path, token, payload("Morning run", started.Add(-time.Hour), nil)), "start_time", ErrCodeStartTooSoon)
+		refused(doJSON(router, "PUT", path, token, payload("Morning run", time.Now().AddDate(2, 0, 0), nil)), "start_time", ErrCodeStartTooFar)
+		w = doJSON(router, "PUT", path, token, payload("Morning run", time.Now().Add(24*time.Hour), nil))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
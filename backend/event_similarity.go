@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCodeDuplicateContent refuses an event that nearly repeats one the organizer created recently
+const ErrCodeDuplicateContent = "DUPLICATE_CONTENT"
+
+const (
+	// similarEventWindow is how far back an organizer's events are compared with a new one
+	similarEventWindow = "-1 day"
+	// similarEventRadiusKm is how close a recent event must be to count as the same one reposted
+	similarEventRadiusKm = 1
+)
+
+// SimilarEventError reports a new event too similar to EventID, created by the same user nearby
+// within the last 24 hours. The duplicate endpoint is the way to repeat an event.
+type SimilarEventError struct {
+	EventID    int
+	Similarity float64
+}
+
+func (e *SimilarEventError) Error() string {
+	return "You created a very similar event nearby in the last 24 hours. To hold it again on another date, duplicate it instead"
+}
+
+func (e *SimilarEventError) respond(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":          e.Error(),
+		"code":           ErrCodeDuplicateContent,
+		"event_id":       e.EventID,
+		"similarity":     math.Round(e.Similarity*100) / 100,
+		"duplicate_path": fmt.Sprintf("/api/events/%d/duplicate", e.EventID),
+	})
+}
+
+// textSimilarity compares two texts by the Jaccard index of their character trigrams after
+// normalizeWords, from 0 (nothing shared) to 1 (same words). Trigrams make small edits,
+// reordered words and punctuation changes count as near-identical.
+func textSimilarity(a, b string) float64 {
+	a, b = normalizeWords(a), normalizeWords(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for gram := range ta {
+		if _, ok := tb[gram]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of three-rune sequences of normalized text, padded with a space on
+// both ends so word boundaries count
+func trigrams(text string) map[string]struct{} {
+	runes := []rune(" " + text + " ")
+	grams := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = struct{}{}
+	}
+	return grams
+}
+
+// checkSimilarEvent returns a *SimilarEventError when the user created an event within
+// similarEventRadiusKm in the last 24 hours whose title and description are at least
+// SimilarEventThreshold percent similar to the new one. A threshold of 0 turns the check off.
+func checkSimilarEvent(ctx context.Context, userID int, event *Event) error {
+	threshold := appConfig.SimilarEventThreshold
+	if threshold <= 0 {
+		return nil
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, description, latitude, longitude FROM events
+		WHERE user_id = ? AND created_at >= datetime('now', ?)
+		ORDER BY id DESC
+	`, userID, similarEventWindow)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	near := GeoRadius{Lat: event.Latitude, Lng: event.Longitude, RadiusKm: similarEventRadiusKm}
+	text := event.Title + " " + event.Description
+	var best *SimilarEventError
+	for rows.Next() {
+		var id int
+		var title, description string
+		var latitude, longitude float64
+		if err := rows.Scan(&id, &title, &description, &latitude, &longitude); err != nil {
+			return err
+		}
+		if !near.Contains(latitude, longitude) {
+			continue
+		}
+		similarity := textSimilarity(text, title+" "+description)
+		if similarity*100 >= float64(threshold) && (best == nil || similarity > best.Similarity) {
+			best = &SimilarEventError{EventID: id, Similarity: similarity}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if best != nil {
+		return best
+	}
+	return nil
+}
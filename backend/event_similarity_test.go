@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, textSimilarity("FREE iPhone giveaway!!! Click now", "Free iPhone giveaway - click now!"),
+		"case and punctuation don't matter")
+	assert.Equal(t, 1.0, textSimilarity("Rock &amp; Roll night", "rock & roll night"), "stored text is HTML-escaped")
+	assert.Zero(t, textSimilarity("", "Board games"))
+	assert.Zero(t, textSimilarity("!!!", "???"))
+	assert.Equal(t, textSimilarity("Salsa night downtown", "Salsa night uptown"), textSimilarity("Salsa night uptown", "Salsa night downtown"))
+
+	typo := textSimilarity("Cheap concert tickets, pay upfront via bank transfer", "Cheap concert tickets, pay upfront via bank transfers")
+	assert.Greater(t, typo, 0.9)
+	reworded := textSimilarity("Morning run in the park, meet at the fountain", "Evening yoga by the river, bring a mat")
+	assert.Less(t, reworded, 0.2)
+	sharedDescription := textSimilarity("Picnic by the lake. Bring a blanket and something to share", "Pub quiz. Bring a blanket and something to share")
+	assert.Less(t, sharedDescription, typo)
+	assert.Greater(t, sharedDescription, reworded)
+}
+
+func TestCreateEventSimilarityThrottle(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Otto", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	otherToken, _ := generateToken(User{ID: int(otherID), Email: "other@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	day := 0
+	payload := func(title, description string, latitude, longitude float64) gin.H {
+		day++ // a new start time each time, so the double-submit check never answers first
+		return gin.H{
+			"title": title, "description": description,
+			"category": "social_drinks", "latitude": latitude, "longitude": longitude,
+			"start_time": time.Now().AddDate(0, 0, day+1).Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		}
+	}
+	const spam = "Cheap concert tickets, pay upfront via bank transfer. Limited offer!"
+	create := func(token string, body gin.H) (int, map[string]interface{}) {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/events", token, body)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp
+	}
+
+	code, first := create(token, payload("Concert tickets", spam, 47.3769, 8.5417))
+	require.Equal(t, http.StatusCreated, code)
+	firstID := first["id"].(float64)
+
+	t.Run("A near-identical repost is refused", func(t *testing.T) {
+		code, resp := create(token, payload("CONCERT TICKETS!!", spam+"!!", 47.3790, 8.5430))
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, ErrCodeDuplicateContent, resp["code"])
+		assert.Equal(t, firstID, resp["event_id"])
+		assert.Equal(t, "/api/events/1/duplicate", resp["duplicate_path"])
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events`))
+	})
+
+	t.Run("Different events, far away ones, other users and admins are fine", func(t *testing.T) {
+		code, _ := create(token, payload("Board games night", "Bring your favourite game, we have snacks and tea", 47.3769, 8.5417))
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = create(token, payload("Concert tickets", spam, 46.2044, 6.1432)) // Geneva, not Zürich
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = create(otherToken, payload("Concert tickets", spam, 47.3769, 8.5417))
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = create(adminToken, payload("Concert tickets", spam, 47.3769, 8.5417))
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = create(adminToken, payload("Concert tickets", spam, 47.3769, 8.5417))
+		assert.Equal(t, http.StatusCreated, code)
+	})
+
+	t.Run("Only the last 24 hours count", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET created_at = datetime('now', '-25 hours') WHERE user_id = ?`, organizerID)
+		require.NoError(t, err)
+		code, _ := create(token, payload("Concert tickets", spam, 47.3769, 8.5417))
+		assert.Equal(t, http.StatusCreated, code)
+	})
+
+	t.Run("The threshold is configurable", func(t *testing.T) {
+		reworded := func() gin.H {
+			return payload("Concert tickets for Saturday", "Tickets for Saturday's concert, pay upfront via bank transfer", 47.3769, 8.5417)
+		}
+		t.Run("Strict", func(t *testing.T) {
+			useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 40 })
+			code, _ := create(token, reworded())
+			assert.Equal(t, http.StatusConflict, code)
+		})
+		t.Run("Default", func(t *testing.T) {
+			code, _ := create(token, reworded())
+			assert.Equal(t, http.StatusCreated, code)
+		})
+		t.Run("Off", func(t *testing.T) {
+			useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+			code, _ := create(token, payload("Concert tickets", spam, 47.3769, 8.5417))
+			assert.Equal(t, http.StatusCreated, code)
+		})
+	})
+}
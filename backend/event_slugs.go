@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// minSlugBaseLength is the shortest title part generateSlug keeps before its random suffix
+	minSlugBaseLength = 3
+	// maxSlugAttempts bounds the retries when a fresh slug is taken between the check and the write
+	maxSlugAttempts = 3
+)
+
+// reservedSlugs are words an event slug must not be, since they are routes next to /event/:slug
+// and /api/public/events/:slug (generated slugs always carry a suffix; old or hand-edited ones may not)
+var reservedSlugs = map[string]bool{
+	"ics": true, "meta": true, "qr.png": true, "map": true, "suggest": true,
+	"import": true, "import-json": true, "duplicates": true, "bulk": true,
+}
+
+// Kinds of SlugIssue
+const (
+	SlugIssueMissing   = "missing"
+	SlugIssueDuplicate = "duplicate"
+	SlugIssueReserved  = "reserved"
+)
+
+// newEventSlug picks the slug of a new event; tests swap it to force collisions
+var newEventSlug = generateUniqueSlug
+
+// isSlugConflict reports whether err is an event slug taken by another event
+func isSlugConflict(err error) bool {
+	return isUniqueViolation(err) && strings.Contains(err.Error(), "events.slug")
+}
+
+// SlugIssue is an event whose public link is broken or ambiguous
+type SlugIssue struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	Issue     string    `json:"issue"` // missing, duplicate or reserved
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// adminGetSlugIssues lists events whose slug is missing, shared with another event or a reserved
+// route word (GET /api/admin/events/slug-issues). Fix them with regenerate-slug.
+func adminGetSlugIssues(c *gin.Context) {
+	log.Printf("🔗 GET /api/admin/events/slug-issues - Admin %d checking event slugs", c.GetInt("user_id"))
+
+	reserved := make([]string, 0, len(reservedSlugs))
+	args := make([]interface{}, 0, len(reservedSlugs))
+	for slug := range reservedSlugs {
+		reserved = append(reserved, "?")
+		args = append(args, slug)
+	}
+
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT id, title, slug, issue, created_at FROM (
+			SELECT e.id, e.title, COALESCE(e.slug, '') AS slug, e.created_at,
+			       CASE
+			           WHEN e.slug IS NULL OR TRIM(e.slug) = '' THEN '`+SlugIssueMissing+`'
+			           WHEN LOWER(e.slug) IN (`+strings.Join(reserved, ", ")+`) THEN '`+SlugIssueReserved+`'
+			           WHEN EXISTS (SELECT 1 FROM events d WHERE d.slug = e.slug AND d.id != e.id) THEN '`+SlugIssueDuplicate+`'
+			       END AS issue
+			FROM events e
+		)
+		WHERE issue IS NOT NULL
+		ORDER BY issue, slug, id
+	`, args...)
+	if err != nil {
+		log.Printf("❌ Failed to query slug issues: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slugs"})
+		return
+	}
+	defer rows.Close()
+
+	issues := []SlugIssue{}
+	for rows.Next() {
+		var issue SlugIssue
+		if err := rows.Scan(&issue.ID, &issue.Title, &issue.Slug, &issue.Issue, &issue.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning slug issue: %v", err)
+			continue
+		}
+		issues = append(issues, issue)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": issues, "total": len(issues)})
+}
+
+// adminRegenerateSlug gives an event a fresh unique slug from its title
+// (POST /api/admin/events/:id/regenerate-slug). Links with the old slug stop working.
+func adminRegenerateSlug(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var title string
+	var previous sql.NullString
+	err = db.QueryRowContext(ctx, `SELECT title, slug FROM events WHERE id = ?`, eventID).Scan(&title, &previous)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate slug"})
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		slug, err := newEventSlug(title)
+		if err != nil {
+			log.Printf("❌ Slug generation failed for event %d: %v", eventID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate slug"})
+			return
+		}
+
+		_, err = db.ExecContext(ctx, `UPDATE events SET slug = ?, updated_at = ? WHERE id = ?`, slug, time.Now().UTC(), eventID)
+		if isSlugConflict(err) && attempt < maxSlugAttempts {
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store slug of event %d: %v", eventID, err)
+			respondDBError(c, err, "Failed to regenerate slug")
+			return
+		}
+
+		eventListCache.Invalidate()
+		publicSitemap.Invalidate()
+		log.Printf("🔗 Admin %d regenerated the slug of event %d: %q -> %q", c.GetInt("user_id"), eventID, previous.String, slug)
+		c.JSON(http.StatusOK, gin.H{"id": eventID, "slug": slug, "previous_slug": previous.String})
+		return
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSlugShortTitles(t *testing.T) {
+	valid := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	for _, title := range []string{"", "!!!", "🎉", "ab", "Q&A"} {
+		slug := generateSlug(title)
+		assert.Regexp(t, valid, slug, "title %q", title)
+		base := slug[:strings.LastIndex(slug, "-")]
+		assert.GreaterOrEqual(t, len(base), minSlugBaseLength, "title %q gave %q", title, slug)
+	}
+	assert.True(t, strings.HasPrefix(generateSlug("ab"), "ab-"))
+	assert.True(t, strings.HasPrefix(generateSlug("Yoga"), "yoga-"))
+}
+
+func TestEventSlugTools(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.POST("/api/events", authMiddleware(), createEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/events/slug-issues", adminGetSlugIssues)
+	admin.POST("/events/:id/regenerate-slug", adminRegenerateSlug)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	withSlug := func(title string, slug interface{}) int64 {
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET slug = ? WHERE id = ?`, slug, id)
+		require.NoError(t, err)
+		return id
+	}
+	issues := func() map[int64]string {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/admin/events/slug-issues", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Events []SlugIssue `json:"events"`
+			Total  int         `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Events, resp.Total)
+		found := map[int64]string{}
+		for _, issue := range resp.Events {
+			found[int64(issue.ID)] = issue.Issue
+		}
+		return found
+	}
+	regenerate := func(id int64) (int, map[string]interface{}) {
+		t.Helper()
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/regenerate-slug", id), adminToken, nil)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp
+	}
+
+	t.Run("The report finds broken slugs", func(t *testing.T) {
+		fine := withSlug("Yoga in the park", "yoga-in-the-park-1a2b3c4d")
+		first := withSlug("Pub quiz", "pub-quiz-0a0a0a0a")
+		copied := withSlug("Pub quiz", "pub-quiz-0a0a0a0a")
+		missing := withSlug("Lost slug", nil)
+		empty := withSlug("Blank slug", "")
+		reserved := withSlug("Calendar", "ics")
+
+		found := issues()
+		assert.Equal(t, map[int64]string{
+			first: SlugIssueDuplicate, copied: SlugIssueDuplicate,
+			missing: SlugIssueMissing, empty: SlugIssueMissing, reserved: SlugIssueReserved,
+		}, found)
+		assert.NotContains(t, found, fine)
+	})
+
+	t.Run("Regeneration fixes them", func(t *testing.T) {
+		for id := range issues() {
+			code, resp := regenerate(id)
+			require.Equal(t, http.StatusOK, code, resp)
+			slug := resp["slug"].(string)
+			assert.NotEqual(t, resp["previous_slug"], slug)
+
+			w := doJSON(router, "GET", "/api/public/events/"+slug, "", nil)
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+		assert.Empty(t, issues())
+
+		code, _ := regenerate(99999)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("A slug taken meanwhile is replaced instead of failing", func(t *testing.T) {
+		_, err := testDB.Exec(`CREATE UNIQUE INDEX idx_events_slug_unique ON events(slug)`)
+		require.NoError(t, err)
+		withSlug("Board games", "board-games-taken")
+
+		calls := 0
+		newEventSlug = func(title string) (string, error) {
+			calls++
+			if calls == 1 {
+				return "board-games-taken", nil
+			}
+			return generateUniqueSlug(title)
+		}
+		defer func() { newEventSlug = generateUniqueSlug }()
+
+		w := doJSON(router, "POST", "/api/events", token, gin.H{
+			"title": "Board games", "description": "Catan, Carcassonne and snacks",
+			"category": "gaming_hobbies", "latitude": 52.23, "longitude": 21.01,
+			"start_time": time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.NotEqual(t, "board-games-taken", event.Slug)
+		assert.True(t, strings.HasPrefix(event.Slug, "board-games-"))
+		assert.Equal(t, 2, calls)
+
+		// Regeneration retries the same way
+		calls = 0
+		code, resp := regenerate(int64(event.ID))
+		require.Equal(t, http.StatusOK, code, resp)
+		assert.NotEqual(t, "board-games-taken", resp["slug"])
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxEventSuggestions caps GET /api/events/suggest
+	MaxEventSuggestions = 8
+	// minSuggestQueryLength is the shortest query worth looking up; shorter ones get no suggestions
+	minSuggestQueryLength = 2
+	// suggestCacheTTL is short: the same prefixes repeat while people type, but new events should
+	// show up quickly
+	suggestCacheTTL        = 15 * time.Second
+	maxSuggestCacheEntries = 1024
+)
+
+// EventSuggestion is one search-box suggestion; title is HTML-escaped like everywhere else
+type EventSuggestion struct {
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	StartTime string `json:"start_time"`
+	Category  string `json:"category"`
+}
+
+// loadEventSuggestions runs the suggestion query; tests swap it to count database round trips
+var loadEventSuggestions = queryEventSuggestions
+
+// suggestCacheEntry remembers which eventListCache generation it was computed in, so any event
+// write (which invalidates the listing cache) also retires cached suggestions
+type suggestCacheEntry struct {
+	suggestions []EventSuggestion
+	generation  uint64
+	expires     time.Time
+}
+
+type suggestCache struct {
+	mu      sync.Mutex
+	entries map[string]suggestCacheEntry
+}
+
+var eventSuggestCache = &suggestCache{entries: make(map[string]suggestCacheEntry)}
+
+func (sc *suggestCache) Get(key string, generation uint64) ([]EventSuggestion, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	entry, ok := sc.entries[key]
+	if !ok || entry.generation != generation || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (sc *suggestCache) Set(key string, generation uint64, suggestions []EventSuggestion) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := time.Now()
+	if len(sc.entries) >= maxSuggestCacheEntries {
+		for k, e := range sc.entries {
+			if e.generation != generation || now.After(e.expires) {
+				delete(sc.entries, k)
+			}
+		}
+		if len(sc.entries) >= maxSuggestCacheEntries {
+			return
+		}
+	}
+	sc.entries[key] = suggestCacheEntry{suggestions: suggestions, generation: generation, expires: now.Add(suggestCacheTTL)}
+}
+
+func (sc *suggestCache) Invalidate() {
+	sc.mu.Lock()
+	sc.entries = make(map[string]suggestCacheEntry)
+	sc.mu.Unlock()
+}
+
+// normalizeSuggestQuery trims, lower-cases and collapses whitespace; it is both the cache key and
+// the text matched. LIKE is case-insensitive, so lower-casing doesn't change the results.
+func normalizeSuggestQuery(q string) string {
+	return strings.ToLower(strings.Join(strings.Fields(q), " "))
+}
+
+// escapeLike escapes LIKE wildcards for use with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// queryEventSuggestions finds upcoming events anyone may see whose title starts with q, then those
+// with a later word starting with q, soonest first within each
+func queryEventSuggestions(ctx context.Context, q string) ([]EventSuggestion, error) {
+	// Titles are stored escaped, so match the escaped form ("R&B" is stored as "R&amp;B")
+	pattern := escapeLike(html.EscapeString(q))
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.title, e.slug, e.start_time, e.category
+		FROM events e
+		WHERE e.start_time >= datetime('now')
+		AND e.start_time <= datetime('now', ?)
+		AND e.cancelled_at IS NULL
+		AND e.slug IS NOT NULL AND e.slug != ''
+		AND COALESCE(e.allow_unregistered_users, 1) = 1
+		AND COALESCE(e.require_verified_to_view, 0) = 0`+visibleUnderReviewCondition+publishedEventCondition+`
+		AND (e.title LIKE ? ESCAPE '\' OR e.title LIKE ? ESCAPE '\')
+		ORDER BY e.title LIKE ? ESCAPE '\' DESC, e.start_time ASC, e.id ASC
+		LIMIT ?
+	`, fmt.Sprintf("+%d days", appConfig.EventListWindowDays), 0, 0,
+		pattern+"%", "% "+pattern+"%", pattern+"%", MaxEventSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []EventSuggestion{}
+	for rows.Next() {
+		var s EventSuggestion
+		var startTime string
+		if err := rows.Scan(&s.Title, &s.Slug, &startTime, &s.Category); err != nil {
+			return nil, err
+		}
+		s.StartTime = formatStoredTime(startTime)
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// suggestEvents returns up to MaxEventSuggestions upcoming public events for a search box
+// (GET /api/events/suggest?q=). Events hidden from anonymous visitors never appear, so the
+// answer is the same for everyone and cached per normalized query.
+func suggestEvents(c *gin.Context) {
+	q := normalizeSuggestQuery(c.Query("q"))
+	if utf8.RuneCountInString(q) < minSuggestQueryLength {
+		c.JSON(http.StatusOK, gin.H{"suggestions": []EventSuggestion{}})
+		return
+	}
+
+	generation := eventListCache.Generation()
+	suggestions, ok := eventSuggestCache.Get(q, generation)
+	if !ok {
+		var err error
+		suggestions, err = loadEventSuggestions(c.Request.Context(), q)
+		if err != nil {
+			log.Printf("❌ Error querying event suggestions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load suggestions"})
+			return
+		}
+		eventSuggestCache.Set(q, generation, suggestions)
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(suggestCacheTTL.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestEvents(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	eventSuggestCache.Invalidate()
+	defer eventSuggestCache.Invalidate()
+
+	queries := 0
+	loadEventSuggestions = func(ctx context.Context, q string) ([]EventSuggestion, error) {
+		queries++
+		return queryEventSuggestions(ctx, q)
+	}
+	defer func() { loadEventSuggestions = queryEventSuggestions }()
+
+	router := gin.New()
+	router.GET("/api/events/suggest", suggestEvents)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	event := func(title string, startIn time.Duration, privacy string) int64 {
+		t.Helper()
+		id := createTestEvent(t, testDB, userID, title)
+		_, err := testDB.Exec(`UPDATE events SET slug = ?, start_time = ? WHERE id = ?`,
+			strings.ToLower(strings.ReplaceAll(title, " ", "-")), time.Now().Add(startIn).UTC().Format(time.RFC3339), id)
+		require.NoError(t, err)
+		if privacy != "" {
+			_, err = testDB.Exec(`UPDATE events SET `+privacy+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+		return id
+	}
+	event("Salsa night", 72*time.Hour, "")
+	event("Salsa for beginners", 24*time.Hour, "")
+	event("Rooftop salsa social", 12*time.Hour, "")
+	event("Salsa members only", 6*time.Hour, "allow_unregistered_users = 0")
+	event("Salsa verified only", 6*time.Hour, "require_verified_to_view = 1")
+	event("Salsa under review", 6*time.Hour, "hidden_pending_review = 1")
+	event("Salsa draft", 6*time.Hour, "published = 0")
+	event("Salsa cancelled", 6*time.Hour, "cancelled_at = datetime('now')")
+	event("Salsa last week", -7*24*time.Hour, "")
+	event("Tango night", 24*time.Hour, "")
+
+	suggest := func(q string) []EventSuggestion {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events/suggest?q="+q, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Suggestions []EventSuggestion `json:"suggestions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Suggestions
+	}
+	titles := func(suggestions []EventSuggestion) []string {
+		var titles []string
+		for _, s := range suggestions {
+			titles = append(titles, s.Title)
+		}
+		return titles
+	}
+
+	t.Run("Prefix matches come first, soonest first", func(t *testing.T) {
+		list := suggest("sal")
+		assert.Equal(t, []string{"Salsa for beginners", "Salsa night", "Rooftop salsa social"}, titles(list))
+		assert.Equal(t, "salsa-for-beginners", list[0].Slug)
+		assert.Equal(t, "social_drinks", list[0].Category)
+		assert.NotEmpty(t, list[0].StartTime)
+	})
+
+	t.Run("Restricted, hidden and past events never appear", func(t *testing.T) {
+		for _, q := range []string{"salsa m", "salsa v", "salsa u", "salsa d", "salsa c", "salsa l"} {
+			assert.Empty(t, suggest(q), q)
+		}
+	})
+
+	t.Run("Queries shorter than two characters are not looked up", func(t *testing.T) {
+		before := queries
+		assert.Empty(t, suggest("s"))
+		assert.Empty(t, suggest("%20%20"))
+		assert.Equal(t, before, queries)
+	})
+
+	t.Run("Repeat queries are served from the cache", func(t *testing.T) {
+		eventSuggestCache.Invalidate()
+		before := queries
+		suggest("tan")
+		suggest("TAN")
+		suggest("%20tan%20")
+		assert.Equal(t, before+1, queries, "normalized queries share one entry")
+
+		eventListCache.Invalidate()
+		assert.Equal(t, []string{"Tango night"}, titles(suggest("tan")))
+		assert.Equal(t, before+2, queries, "an event write retires cached suggestions")
+	})
+
+	t.Run("LIKE wildcards match literally", func(t *testing.T) {
+		assert.Empty(t, suggest("%25a"))
+		assert.Empty(t, suggest("_a"))
+	})
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventTransferTTL is how long the new organizer has to accept a handover
+const eventTransferTTL = 7 * 24 * time.Hour
+
+const (
+	ErrCodeTransferPending   = "TRANSFER_PENDING"
+	ErrCodeTransferExpired   = "TRANSFER_EXPIRED"
+	ErrCodeTargetNotVerified = "TARGET_NOT_VERIFIED"
+)
+
+// AuditEventTransferRequested is logged when an admin hands over someone else's event
+const AuditEventTransferRequested = "event_transfer_requested"
+
+// TransferEventRequest is the body of POST /api/events/:id/transfer
+type TransferEventRequest struct {
+	UserID            int  `json:"user_id" binding:"required"`
+	KeepAsParticipant bool `json:"keep_as_participant"` // The current organizer stays on as a participant
+}
+
+// EventTransfer is a handover waiting for the new organizer to accept it
+type EventTransfer struct {
+	EventID           int       `json:"event_id"`
+	FromUserID        int       `json:"from_user_id"`
+	ToUserID          int       `json:"to_user_id"`
+	KeepAsParticipant bool      `json:"keep_as_participant"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// eventTransferredPayload is the payload of JobNotifyTransferred
+type eventTransferredPayload struct {
+	EventID    int `json:"event_id"`
+	FromUserID int `json:"from_user_id"`
+}
+
+// hasPendingTransfer reports whether an unexpired handover of the event waits to be accepted
+func hasPendingTransfer(ctx context.Context, q joinQuerier, eventID interface{}) (bool, error) {
+	var pending bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM event_transfers WHERE event_id = ? AND expires_at > ?)`,
+		eventID, time.Now().UTC()).Scan(&pending)
+	return pending, err
+}
+
+// transferEvent offers the event to another user (POST /api/events/:id/transfer). Creator or
+// admin only. The target must be verified and have no block with the organizer either way; they
+// become the organizer once they accept within eventTransferTTL. A new offer replaces an older one.
+func transferEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🤝 POST /api/events/%d/transfer - User %d handing over event", eventID, userID)
+
+	var req TransferEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	var organizerID int
+	var title string
+	var slug sql.NullString
+	var cancelled bool
+	err = db.QueryRowContext(ctx, `SELECT user_id, title, slug, cancelled_at IS NOT NULL FROM events WHERE id = ?`, eventID).
+		Scan(&organizerID, &title, &slug, &cancelled)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to transfer event")
+		return
+	}
+	if organizerID != userID && !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can transfer this event"})
+		return
+	}
+	if cancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This event has been cancelled", "code": ErrCodeEventCancelled})
+		return
+	}
+	if req.UserID == organizerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The event already belongs to this user"})
+		return
+	}
+
+	var verified, accountBlocked bool
+	err = db.QueryRowContext(ctx, `SELECT email_verified, COALESCE(is_blocked, 0) FROM users WHERE id = ?`, req.UserID).
+		Scan(&verified, &accountBlocked)
+	if err == sql.ErrNoRows || (err == nil && accountBlocked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading user %d: %v", req.UserID, err)
+		respondDBError(c, err, "Failed to transfer event")
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The new organizer must verify their email address first", "code": ErrCodeTargetNotVerified})
+		return
+	}
+	if AreUsersBlocked(organizerID, req.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't hand this event over to that user", "code": ErrCodeUserBlocked})
+		return
+	}
+
+	transfer := EventTransfer{
+		EventID:           eventID,
+		FromUserID:        organizerID,
+		ToUserID:          req.UserID,
+		KeepAsParticipant: req.KeepAsParticipant,
+		ExpiresAt:         time.Now().Add(eventTransferTTL).UTC(),
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		respondDBError(c, err, "Failed to transfer event")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_transfers (event_id, from_user_id, to_user_id, keep_as_participant, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (event_id) DO UPDATE SET from_user_id = excluded.from_user_id, to_user_id = excluded.to_user_id,
+			keep_as_participant = excluded.keep_as_participant, expires_at = excluded.expires_at, created_at = excluded.created_at
+	`, transfer.EventID, transfer.FromUserID, transfer.ToUserID, transfer.KeepAsParticipant, transfer.ExpiresAt, time.Now().UTC())
+	if err != nil {
+		log.Printf("❌ Error storing transfer of event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to transfer event")
+		return
+	}
+	notify(tx, req.UserID, NotificationTransferOffered, eventNotification{EventID: eventID, Title: title, Slug: slug.String})
+	if userID != organizerID {
+		if err := recordAdminAudit(tx, userID, AuditEventTransferRequested, req.UserID,
+			gin.H{"event_id": eventID, "from_user_id": organizerID}); err != nil {
+			log.Printf("❌ Error recording audit entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer event"})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		respondDBError(c, err, "Failed to transfer event")
+		return
+	}
+
+	log.Printf("✅ Event %d offered to user %d until %s", eventID, req.UserID, transfer.ExpiresAt.Format(time.RFC3339))
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// acceptEventTransfer makes the viewer the organizer of an event offered to them
+// (POST /api/events/:id/transfer/accept). Participants are notified in the app and by email.
+// An expired offer is dropped and answered with 410.
+func acceptEventTransfer(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🤝 POST /api/events/%d/transfer/accept - User %d taking over event", eventID, userID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		respondDBError(c, err, "Failed to accept transfer")
+		return
+	}
+	defer tx.Rollback()
+
+	var transfer EventTransfer
+	var organizerID int
+	var verified, blocked bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.event_id, t.from_user_id, t.to_user_id, t.keep_as_participant, t.expires_at, e.user_id,
+		       (SELECT email_verified FROM users WHERE id = t.to_user_id),
+		       EXISTS (SELECT 1 FROM user_blocks
+		               WHERE (blocker_id = t.from_user_id AND blocked_id = t.to_user_id)
+		                  OR (blocker_id = t.to_user_id AND blocked_id = t.from_user_id))
+		FROM event_transfers t JOIN events e ON e.id = t.event_id
+		WHERE t.event_id = ? AND t.to_user_id = ?
+	`, eventID, userID).Scan(&transfer.EventID, &transfer.FromUserID, &transfer.ToUserID, &transfer.KeepAsParticipant,
+		&transfer.ExpiresAt, &organizerID, &verified, &blocked)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No transfer of this event is waiting for you"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading transfer of event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to accept transfer")
+		return
+	}
+
+	// An expired offer, or one the organizer can no longer make, is dropped
+	if !time.Now().Before(transfer.ExpiresAt) || organizerID != transfer.FromUserID || blocked {
+		_, err := tx.ExecContext(ctx, `DELETE FROM event_transfers WHERE event_id = ?`, eventID)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to drop stale transfer of event %d: %v", eventID, err)
+		}
+		switch {
+		case blocked:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can't take over this event", "code": ErrCodeUserBlocked})
+		case organizerID != transfer.FromUserID:
+			c.JSON(http.StatusConflict, gin.H{"error": "The event changed hands since it was offered to you"})
+		default:
+			c.JSON(http.StatusGone, gin.H{"error": "This transfer has expired", "code": ErrCodeTransferExpired})
+		}
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first", "code": ErrCodeEmailNotVerified})
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE events SET user_id = ?, creator_name = (SELECT name FROM users WHERE id = ?), updated_at = ? WHERE id = ?
+	`, userID, userID, time.Now().UTC(), eventID); err != nil {
+		log.Printf("❌ Error transferring event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to accept transfer")
+		return
+	}
+	if transfer.KeepAsParticipant {
+		result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO event_participants (event_id, user_id) VALUES (?, ?)`,
+			eventID, transfer.FromUserID)
+		if err == nil {
+			if added, _ := result.RowsAffected(); added > 0 {
+				err = adjustParticipantCount(tx, eventID, 1)
+			}
+		}
+		if err != nil {
+			log.Printf("❌ Error keeping the previous organizer of event %d: %v", eventID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept transfer"})
+			return
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_transfers WHERE event_id = ?`, eventID); err != nil {
+		log.Printf("❌ Error clearing transfer of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept transfer"})
+		return
+	}
+	recordActivity(tx, userID, ActivityTookOver, eventID)
+	notifyEventParticipants(tx, eventID, NotificationEventTransferred, userID)
+	if _, err := enqueueJob(tx, JobNotifyTransferred, eventTransferredPayload{EventID: eventID, FromUserID: transfer.FromUserID}); err != nil {
+		log.Printf("❌ Error queueing transfer emails: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept transfer"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		respondDBError(c, err, "Failed to accept transfer")
+		return
+	}
+	backgroundJobs.Notify()
+	eventListCache.Invalidate()
+
+	log.Printf("✅ User %d took over event %d from user %d", userID, eventID, transfer.FromUserID)
+	c.JSON(http.StatusOK, gin.H{"message": "You are now the organizer of this event", "event_id": eventID})
+}
+
+// cancelEventTransfer withdraws a pending handover (DELETE /api/events/:id/transfer). The
+// organizer and admins withdraw it; the user it was offered to declines it the same way.
+func cancelEventTransfer(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🤝 DELETE /api/events/%d/transfer - User %d withdrawing transfer", eventID, userID)
+
+	result, err := db.ExecContext(c.Request.Context(), `
+		DELETE FROM event_transfers
+		WHERE event_id = ? AND (? OR from_user_id = ? OR to_user_id = ?)
+	`, eventID, c.GetBool("is_admin"), userID, userID)
+	if err != nil {
+		log.Printf("❌ Error withdrawing transfer of event %d: %v", eventID, err)
+		respondDBError(c, err, "Failed to withdraw transfer")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending transfer"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer withdrawn"})
+}
+
+// notifyTransferredJob emails the participants of an event that changed hands, except the new
+// organizer and the previous one. A failed email is logged and skipped rather than retried, so
+// a retry can't mail the others twice.
+func notifyTransferredJob(ctx context.Context, payload json.RawMessage) error {
+	var p eventTransferredPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	var title, organizer string
+	var slug sql.NullString
+	var organizerID int
+	err := db.QueryRowContext(ctx, `
+		SELECT e.title, e.slug, e.user_id, u.name FROM events e JOIN users u ON u.id = e.user_id WHERE e.id = ?
+	`, p.EventID).Scan(&title, &slug, &organizerID, &organizer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.email, u.name
+		FROM event_participants p JOIN users u ON u.id = p.user_id
+		WHERE p.event_id = ? AND p.user_id NOT IN (?, ?)
+		  AND u.email_verified = 1 AND COALESCE(u.is_blocked, 0) = 0
+	`, p.EventID, organizerID, p.FromUserID)
+	if err != nil {
+		return err
+	}
+	type recipient struct{ email, name string }
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.email, &r.name); err == nil {
+			recipients = append(recipients, r)
+		}
+	}
+	rows.Close()
+
+	message := fmt.Sprintf("%s is now organizing \"%s\". Your spot is unchanged; questions about the event now go to them.",
+		html.UnescapeString(organizer), html.UnescapeString(title))
+	for _, r := range recipients {
+		if err := sendModerationEmail(r.email, r.name, "New organizer for your event", message, publicEventURL(slug.String)); err != nil {
+			log.Printf("⚠️  Transfer email for event %d to %s failed: %v", p.EventID, r.email, err)
+		}
+	}
+	log.Printf("📧 Told %d participants of event %d about their new organizer", len(recipients), p.EventID)
+	return nil
+}
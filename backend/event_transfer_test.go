@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTransfer(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	sent := captureModerationEmails(t)
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.DELETE("/events/:id", deleteEvent)
+	protected.POST("/events/:id/transfer", transferEvent)
+	protected.POST("/events/:id/transfer/accept", acceptEventTransfer)
+	protected.DELETE("/events/:id/transfer", cancelEventTransfer)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.DELETE("/events/:id", adminDeleteEvent)
+	admin.POST("/events/bulk", adminBulkEvents)
+
+	seq := 0
+	newUser := func(name string) (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, name, "password123", false)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	organizerID, organizerToken := newUser("Olga")
+	path := func(eventID int64, suffix string) string {
+		return fmt.Sprintf("/api/events/%d/transfer%s", eventID, suffix)
+	}
+
+	t.Run("Handshake hands the event over", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Tuesday run club")
+		newID, newToken := newUser("Nina")
+		participantID, _ := newUser("Paul")
+		addParticipant(t, testDB, eventID, participantID)
+		_, strangerToken := newUser("Sam")
+
+		// Only the organizer offers, and only the target accepts
+		w := doJSON(router, "POST", path(eventID, ""), strangerToken, gin.H{"user_id": newID})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": newID, "keep_as_participant": true})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var transfer EventTransfer
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &transfer))
+		assert.Equal(t, int(newID), transfer.ToUserID)
+		assert.WithinDuration(t, time.Now().Add(eventTransferTTL), transfer.ExpiresAt, time.Minute)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ?`, newID, NotificationTransferOffered))
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", path(eventID, "/accept"), strangerToken, nil).Code)
+
+		// A pending transfer blocks deletion
+		w = doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d", eventID), organizerToken, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeTransferPending)
+
+		w = doJSON(router, "POST", path(eventID, "/accept"), newToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var ownerID int
+		var creatorName string
+		require.NoError(t, testDB.QueryRow(`SELECT user_id, creator_name FROM events WHERE id = ?`, eventID).Scan(&ownerID, &creatorName))
+		assert.Equal(t, int(newID), ownerID)
+		assert.Equal(t, "Nina", creatorName)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE event_id = ? AND user_id = ?`, eventID, organizerID))
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT participant_count FROM events WHERE id = ?`, eventID))
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM event_transfers WHERE event_id = ?`, eventID))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM activity_log WHERE user_id = ? AND verb = ?`, participantID, ActivityTookOver))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ?`, participantID, NotificationEventTransferred))
+
+		// The queued job mails the participant, not the old or new organizer
+		var payload string
+		require.NoError(t, testDB.QueryRow(`SELECT payload FROM jobs WHERE type = ?`, JobNotifyTransferred).Scan(&payload))
+		require.NoError(t, notifyTransferredJob(context.Background(), json.RawMessage(payload)))
+		emails := sent()
+		require.Len(t, emails, 1)
+		assert.Equal(t, "user3@example.com", emails[0].to) // Paul
+
+		// The previous organizer can't delete it any more; the new one can
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d", eventID), organizerToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d", eventID), newToken, nil).Code)
+	})
+
+	t.Run("Unaccepted transfers expire", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Board games")
+		newID, newToken := newUser("Nora")
+		w := doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": newID})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		_, err := testDB.Exec(`UPDATE event_transfers SET expires_at = ? WHERE event_id = ?`, time.Now().Add(-time.Minute).UTC(), eventID)
+		require.NoError(t, err)
+
+		// Deleting is allowed again once the offer ran out
+		pending, err := hasPendingTransfer(context.Background(), testDB, eventID)
+		require.NoError(t, err)
+		assert.False(t, pending)
+
+		w = doJSON(router, "POST", path(eventID, "/accept"), newToken, nil)
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeTransferExpired)
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM event_transfers WHERE event_id = ?`, eventID))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND user_id = ?`, eventID, organizerID))
+	})
+
+	t.Run("Blocks and unverified targets are refused", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Pub quiz")
+		blockedID, _ := newUser("Bert")
+		_, err := testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, blockedID, organizerID)
+		require.NoError(t, err)
+		w := doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": blockedID})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeUserBlocked)
+
+		unverifiedID, _ := newUser("Uma")
+		_, err = testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, unverifiedID)
+		require.NoError(t, err)
+		w = doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": unverifiedID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeTargetNotVerified)
+
+		// A block made after the offer stops the acceptance
+		laterID, laterToken := newUser("Lena")
+		w = doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": laterID})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, organizerID, laterID)
+		require.NoError(t, err)
+		w = doJSON(router, "POST", path(eventID, "/accept"), laterToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND user_id = ?`, eventID, organizerID))
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM event_transfers WHERE event_id = ?`, eventID))
+	})
+
+	t.Run("The offer can be withdrawn or declined", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Book club")
+		newID, newToken := newUser("Dora")
+		require.Equal(t, http.StatusCreated, doJSON(router, "POST", path(eventID, ""), organizerToken, gin.H{"user_id": newID}).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", path(eventID, ""), newToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "DELETE", path(eventID, ""), organizerToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d", eventID), organizerToken, nil).Code)
+	})
+	t.Run("Admin and bulk deletes wait for the transfer too", func(t *testing.T) {
+		adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+		adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+		offered := createTestEvent(t, testDB, organizerID, "Chess night")
+		other := createTestEvent(t, testDB, organizerID, "Spam")
+		newID, _ := newUser("Hana")
+		require.Equal(t, http.StatusCreated, doJSON(router, "POST", path(offered, ""), organizerToken, gin.H{"user_id": newID}).Code)
+
+		w := doJSON(router, "DELETE", fmt.Sprintf("/api/admin/events/%d", offered), adminToken, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeTransferPending)
+
+		w = doJSON(router, "POST", "/api/admin/events/bulk", adminToken, gin.H{"ids": []int64{offered, other}, "action": "delete"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp bulkResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []BulkResult{
+			{ID: int(offered), Status: BulkResultTransferPending},
+			{ID: int(other), Status: BulkResultSuccess},
+		}, resp.Results)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ?`, offered))
+		assert.Zero(t, countRows(t, testDB, `SELECT COUNT(*) FROM admin_audit_log WHERE action = ? AND details LIKE '%"title":"Chess night"%'`, AuditEventDeleted))
+	})
+}
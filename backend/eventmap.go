@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mapPointLimit       = 200 // Boxes with more visible events than this are clustered
+	mapDefaultZoom      = 10
+	mapMaxZoom          = 22
+	mapClusterCellsTile = 4 // Grid cells per 256px map tile; cells shrink as the zoom level grows
+)
+
+// MapPoint is one event on the map, kept small on purpose: the popup loads details by slug
+type MapPoint struct {
+	ID        int     `json:"id"`
+	Slug      string  `json:"slug"`
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lng"`
+	Category  string  `json:"category"`
+	StartTime string  `json:"start_time"`
+}
+
+// MapCluster is a grid cell with Count events, placed at their centroid
+type MapCluster struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lng"`
+	Count     int     `json:"count"`
+}
+
+// MapResponse carries points when the box holds at most mapPointLimit events, clusters otherwise
+type MapResponse struct {
+	Mode     string       `json:"mode"` // points | clusters
+	Total    int          `json:"total"`
+	Points   []MapPoint   `json:"points,omitempty"`
+	Clusters []MapCluster `json:"clusters,omitempty"`
+}
+
+// mapBounds is the bounding box of GET /api/events/map
+type mapBounds struct {
+	minLat, maxLat, minLng, maxLng float64
+}
+
+// parseMapBounds reads min_lat, max_lat, min_lng and max_lng. Boxes crossing the antimeridian
+// aren't supported; the client splits them.
+func parseMapBounds(c *gin.Context) (mapBounds, error) {
+	var b mapBounds
+	for _, field := range []struct {
+		name     string
+		dest     *float64
+		min, max float64
+	}{
+		{"min_lat", &b.minLat, -90, 90},
+		{"max_lat", &b.maxLat, -90, 90},
+		{"min_lng", &b.minLng, -180, 180},
+		{"max_lng", &b.maxLng, -180, 180},
+	} {
+		value, err := strconv.ParseFloat(c.Query(field.name), 64)
+		if err != nil || math.IsNaN(value) || value < field.min || value > field.max {
+			return b, fmt.Errorf("%s must be a number between %g and %g", field.name, field.min, field.max)
+		}
+		*field.dest = value
+	}
+	if b.minLat > b.maxLat || b.minLng > b.maxLng {
+		return b, fmt.Errorf("min_lat/min_lng must not exceed max_lat/max_lng")
+	}
+	return b, nil
+}
+
+// getEventMap returns the upcoming events inside a bounding box (GET /api/events/map), as points
+// or as zoom-dependent grid clusters. Time window, category and view permissions match getEvents.
+func getEventMap(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	isVerified := c.GetBool("email_verified")
+	isAdmin := c.GetBool("is_admin")
+
+	bounds, err := parseMapBounds(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zoom := mapDefaultZoom
+	if raw := c.Query("zoom"); raw != "" {
+		zoom, err = strconv.Atoi(raw)
+		if err != nil || zoom < 0 || zoom > mapMaxZoom {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be between 0 and %d", mapMaxZoom)})
+			return
+		}
+	}
+	log.Printf("🗺️  GET /api/events/map - zoom %d, lat %g..%g, lng %g..%g", zoom, bounds.minLat, bounds.maxLat, bounds.minLng, bounds.maxLng)
+
+	query := `
+		SELECT e.id, COALESCE(e.slug, ''), e.latitude, e.longitude, e.category, e.start_time,
+		       COALESCE(e.allow_unregistered_users, 1), e.require_verified_to_view
+		FROM events e
+		WHERE e.start_time >= datetime('now')
+		AND e.start_time <= datetime('now', ?)
+		AND e.cancelled_at IS NULL
+		AND e.latitude BETWEEN ? AND ?
+		AND e.longitude BETWEEN ? AND ?` + visibleUnderReviewCondition + publishedEventCondition
+	args := []interface{}{fmt.Sprintf("+%d days", appConfig.EventListWindowDays),
+		bounds.minLat, bounds.maxLat, bounds.minLng, bounds.maxLng, userID, userID}
+	if category := c.Query("category"); category != "" {
+		query += " AND e.category = ?"
+		args = append(args, category)
+	}
+	query += " ORDER BY e.start_time ASC, e.id ASC"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("❌ Error querying map events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+		return
+	}
+	defer rows.Close()
+
+	points := []MapPoint{}
+	for rows.Next() {
+		var p MapPoint
+		var startTime string
+		var e Event
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Latitude, &p.Longitude, &p.Category, &startTime,
+			&e.AllowUnregisteredUsers, nullable(&e.RequireVerifiedToView)); err != nil {
+			log.Printf("❌ Error scanning map event: %v", err)
+			continue
+		}
+		if CheckEventViewPermission(&e, userID, isVerified, isAdmin) != nil {
+			continue
+		}
+		p.StartTime = formatStoredTime(startTime)
+		points = append(points, p)
+	}
+
+	response := MapResponse{Mode: "points", Total: len(points), Points: points}
+	if len(points) > mapPointLimit {
+		response = MapResponse{Mode: "clusters", Total: len(points), Clusters: clusterMapPoints(points, zoom)}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// clusterMapPoints buckets points into a square grid whose cell size halves with every zoom level
+func clusterMapPoints(points []MapPoint, zoom int) []MapCluster {
+	cell := 360 / (math.Exp2(float64(zoom)) * mapClusterCellsTile)
+
+	type bucket struct {
+		latSum, lngSum float64
+		count          int
+	}
+	type cellKey struct{ lat, lng int }
+	buckets := map[cellKey]*bucket{}
+	for _, p := range points {
+		key := cellKey{int(math.Floor(p.Latitude / cell)), int(math.Floor(p.Longitude / cell))}
+		b := buckets[key]
+		if b == nil {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.latSum += p.Latitude
+		b.lngSum += p.Longitude
+		b.count++
+	}
+
+	clusters := make([]MapCluster, 0, len(buckets))
+	for _, b := range buckets {
+		clusters = append(clusters, MapCluster{
+			Latitude:  b.latSum / float64(b.count),
+			Longitude: b.lngSum / float64(b.count),
+			Count:     b.count,
+		})
+	}
+	// Largest clusters first so clients can cap what they draw
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		if clusters[i].Latitude != clusters[j].Latitude {
+			return clusters[i].Latitude < clusters[j].Latitude
+		}
+		return clusters[i].Longitude < clusters[j].Longitude
+	})
+	return clusters
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventMap(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/map", optionalAuthMiddleware(), getEventMap)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	placeEvent := func(title string, lat, lng float64, category string) int64 {
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET latitude = ?, longitude = ?, category = ? WHERE id = ?`, lat, lng, category, id)
+		require.NoError(t, err)
+		return id
+	}
+
+	// Three events around Warsaw, one of them registration-only, and one far away in Lisbon
+	placeEvent("Board games", 52.23, 21.01, "gaming_hobbies")
+	placeEvent("Run club", 52.24, 21.02, "sports_fitness")
+	membersOnly := placeEvent("Members only", 52.25, 21.03, "sports_fitness")
+	_, err := testDB.Exec(`UPDATE events SET allow_unregistered_users = 0 WHERE id = ?`, membersOnly)
+	require.NoError(t, err)
+	placeEvent("Surfing", 38.72, -9.14, "sports_fitness")
+	past := placeEvent("Yesterday", 52.23, 21.01, "gaming_hobbies")
+	_, err = testDB.Exec(`UPDATE events SET start_time = ? WHERE id = ?`, time.Now().Add(-24*time.Hour).Format(time.RFC3339), past)
+	require.NoError(t, err)
+
+	fetch := func(query, token string) MapResponse {
+		w := doJSON(router, "GET", "/api/events/map?"+query, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response MapResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	warsaw := "min_lat=52&max_lat=53&min_lng=20&max_lng=22"
+
+	t.Run("Small box returns lightweight points", func(t *testing.T) {
+		response := fetch(warsaw, userToken)
+		assert.Equal(t, "points", response.Mode)
+		require.Len(t, response.Points, 3)
+		assert.Empty(t, response.Clusters)
+		assert.Equal(t, "gaming_hobbies", response.Points[0].Category)
+		assert.NotEmpty(t, response.Points[0].StartTime)
+
+		w := doJSON(router, "GET", "/api/events/map?"+warsaw, userToken, nil)
+		assert.NotContains(t, w.Body.String(), "description")
+	})
+
+	t.Run("Filters and view permissions apply", func(t *testing.T) {
+		assert.Len(t, fetch(warsaw, "").Points, 2, "registration-only events are hidden from anonymous visitors")
+		assert.Len(t, fetch(warsaw+"&category=sports_fitness", userToken).Points, 2)
+		assert.Equal(t, 4, fetch("min_lat=-90&max_lat=90&min_lng=-180&max_lng=180", userToken).Total)
+	})
+
+	t.Run("Invalid boxes are rejected", func(t *testing.T) {
+		for _, query := range []string{
+			"",
+			"min_lat=52&max_lat=53&min_lng=20",
+			"min_lat=53&max_lat=52&min_lng=20&max_lng=22",
+			"min_lat=-95&max_lat=53&min_lng=20&max_lng=22",
+			warsaw + "&zoom=40",
+		} {
+			w := doJSON(router, "GET", "/api/events/map?"+query, "", nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Large box returns clusters whose counts add up", func(t *testing.T) {
+		tx, err := testDB.Begin()
+		require.NoError(t, err)
+		start := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+		for i := 0; i < 500; i++ {
+			_, err := tx.Exec(`
+				INSERT INTO events (user_id, title, description, category, latitude, longitude, start_time, creator_name, allow_unregistered_users)
+				VALUES (?, 'Synthetic', 'Synthetic event', 'social_drinks', ?, ?, ?, 'Organizer', 1)
+			`, organizerID, 10+float64(i%25)*0.2, 10+float64(i/25)*0.2, start)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tx.Commit())
+
+		response := fetch("min_lat=9&max_lat=16&min_lng=9&max_lng=16&zoom=6", "")
+		assert.Equal(t, "clusters", response.Mode)
+		assert.Equal(t, 500, response.Total)
+		assert.Empty(t, response.Points)
+		require.NotEmpty(t, response.Clusters)
+		assert.Less(t, len(response.Clusters), 500)
+
+		sum := 0
+		for _, cluster := range response.Clusters {
+			sum += cluster.Count
+			assert.True(t, cluster.Latitude >= 10 && cluster.Latitude <= 15, "centroid stays inside the data")
+		}
+		assert.Equal(t, 500, sum)
+
+		coarse := fetch("min_lat=9&max_lat=16&min_lng=9&max_lng=16&zoom=2", "")
+		assert.Less(t, len(coarse.Clusters), len(response.Clusters), "lower zoom levels use bigger cells")
+	})
+}
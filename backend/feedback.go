@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultEventDuration is assumed for events without an end_time when deciding if they're over
+const defaultEventDuration = 4 * time.Hour
+
+// feedbackEditWindow is how long participants can change their rating after submitting it
+const feedbackEditWindow = 7 * 24 * time.Hour
+
+// eventEndedAt returns when an event is considered over: end_time, or start_time + 4h if unset
+func eventEndedAt(startTime, endTime string) (time.Time, error) {
+	if endTime != "" {
+		end, err := parseDateTime(endTime)
+		if err == nil && !end.IsZero() {
+			return end, nil
+		}
+	}
+
+	start, err := parseDateTime(startTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(defaultEventDuration), nil
+}
+
+// submitEventFeedback creates or updates a participant's rating (POST /api/events/:id/feedback)
+// Only participants can rate, only after the event ended, and edits are allowed for 7 days
+func submitEventFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventIDStr := c.Param("id")
+	eventID, err := strconv.Atoi(eventIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	log.Printf("⭐ POST /api/events/%d/feedback - User %d submitting feedback", eventID, userID)
+
+	var req SubmitFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be between 1 and 5"})
+		return
+	}
+
+	var creatorID int
+	var startTime string
+	var endTime sql.NullString
+	var isParticipant bool
+	err = db.QueryRowContext(ctx, `
+		SELECT e.user_id, e.start_time, e.end_time,
+		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) as is_participant
+		FROM events e
+		WHERE e.id = ?
+	`, eventID, userID, eventID).Scan(&creatorID, &startTime, &endTime, &isParticipant)
+
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error checking event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit feedback"})
+		return
+	}
+
+	// Organizers can't rate themselves
+	if creatorID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organizers cannot rate their own event"})
+		return
+	}
+
+	if !isParticipant {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only event participants can leave feedback"})
+		return
+	}
+
+	endedAt, err := eventEndedAt(startTime, endTime.String)
+	if err != nil {
+		log.Printf("❌ Error parsing event times: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit feedback"})
+		return
+	}
+	if time.Now().Before(endedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Feedback can only be left after the event has ended"})
+		return
+	}
+
+	comment := html.EscapeString(req.Comment)
+
+	// The first submit inserts; DO NOTHING leaves an existing rating to the edit path below, so two
+	// submits racing each other can't both try to insert
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO event_feedback (event_id, user_id, rating, comment)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_id, user_id) DO NOTHING
+	`, eventID, userID, req.Rating, comment)
+	if err != nil {
+		log.Printf("❌ Error saving feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit feedback"})
+		return
+	}
+
+	status := http.StatusCreated
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		// Existing feedback can be edited within the edit window
+		var existingID int
+		var createdAt time.Time
+		err = db.QueryRowContext(ctx, `SELECT id, created_at FROM event_feedback WHERE event_id = ? AND user_id = ?`, eventID, userID).
+			Scan(&existingID, &createdAt)
+		if err != nil {
+			log.Printf("❌ Error checking existing feedback: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit feedback"})
+			return
+		}
+		if time.Since(createdAt) > feedbackEditWindow {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Feedback can no longer be edited"})
+			return
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE event_feedback SET rating = ?, comment = ?, updated_at = ?
+			WHERE id = ?
+		`, req.Rating, comment, time.Now(), existingID)
+		status = http.StatusOK
+	}
+
+	if err != nil {
+		log.Printf("❌ Error saving feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit feedback"})
+		return
+	}
+
+	log.Printf("✅ User %d rated event %d with %d stars", userID, eventID, req.Rating)
+	c.JSON(status, gin.H{"message": "Feedback saved", "rating": req.Rating, "comment": comment})
+}
+
+// GetEventRating returns the feedback aggregate for a single event
+func GetEventRating(eventID int) (RatingSummary, error) {
+	return queryRatingSummary(`
+		SELECT AVG(rating), COUNT(*) FROM event_feedback WHERE event_id = ?
+	`, eventID)
+}
+
+// GetOrganizerRating returns the feedback aggregate across all events created by a user
+func GetOrganizerRating(userID int) (RatingSummary, error) {
+	return queryRatingSummary(`
+		SELECT AVG(f.rating), COUNT(f.id)
+		FROM event_feedback f
+		JOIN events e ON f.event_id = e.id
+		WHERE e.user_id = ?
+	`, userID)
+}
+
+func queryRatingSummary(query string, args ...interface{}) (RatingSummary, error) {
+	var summary RatingSummary
+	var average sql.NullFloat64
+	if err := db.QueryRow(query, args...).Scan(&average, &summary.Count); err != nil {
+		return summary, err
+	}
+	if average.Valid {
+		summary.Average = math.Round(average.Float64*100) / 100
+	}
+	return summary, nil
+}
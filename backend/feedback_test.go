@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFeedbackRouter() *gin.Engine {
+	router := gin.New()
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/profile/:id", optionalAuthMiddleware(), getUserProfile)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/feedback", submitEventFeedback)
+	return router
+}
+
+// createPastEvent inserts an event that started (and, unless endTime is empty, ended) in the past
+func createPastEvent(t *testing.T, testDB *sql.DB, userID int64, slug string, start time.Time, end *time.Time) int64 {
+	var endTime interface{}
+	if end != nil {
+		endTime = end.Format(time.RFC3339)
+	}
+	result, err := testDB.Exec(`
+		INSERT INTO events (user_id, title, description, category, latitude, longitude, start_time, end_time, creator_name, slug, allow_unregistered_users)
+		VALUES (?, 'Past Event', 'Test description', 'social_drinks', 46.88, 8.64, ?, ?, 'Organizer', ?, 1)
+	`, userID, start.Format(time.RFC3339), endTime, slug)
+	require.NoError(t, err)
+	eventID, err := result.LastInsertId()
+	require.NoError(t, err)
+	return eventID
+}
+
+func addParticipant(t *testing.T, testDB *sql.DB, eventID, userID int64) {
+	_, err := testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, userID)
+	require.NoError(t, err)
+	require.NoError(t, adjustParticipantCount(testDB, eventID, 1))
+}
+
+func TestSubmitEventFeedback(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupFeedbackRouter()
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	participantID := createTestUser(t, testDB, "participant@example.com", "Participant", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Outsider", "password123", false)
+
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+	participantToken, _ := generateToken(User{ID: int(participantID), Email: "participant@example.com"})
+	outsiderToken, _ := generateToken(User{ID: int(outsiderID), Email: "outsider@example.com"})
+
+	t.Run("Rejected before the event has ended", func(t *testing.T) {
+		start := time.Now().Add(-1 * time.Hour)
+		end := time.Now().Add(1 * time.Hour)
+		eventID := createPastEvent(t, testDB, organizerID, "ongoing-event", start, &end)
+		addParticipant(t, testDB, eventID, participantID)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), participantToken, gin.H{"rating": 5})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Without end_time the event ends 4 hours after start", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "no-end-event", time.Now().Add(-2*time.Hour), nil)
+		addParticipant(t, testDB, eventID, participantID)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), participantToken, gin.H{"rating": 4})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Non-participant rejected", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "closed-event", time.Now().Add(-48*time.Hour), nil)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), outsiderToken, gin.H{"rating": 3})
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	t.Run("Organizer cannot rate own event", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "own-event", time.Now().Add(-48*time.Hour), nil)
+		addParticipant(t, testDB, eventID, organizerID)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), organizerToken, gin.H{"rating": 5})
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	t.Run("Rating out of range rejected", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "range-event", time.Now().Add(-48*time.Hour), nil)
+		addParticipant(t, testDB, eventID, participantID)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), participantToken, gin.H{"rating": 6})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Participant can submit and edit within the window", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "rated-event", time.Now().Add(-48*time.Hour), nil)
+		addParticipant(t, testDB, eventID, participantID)
+		path := fmt.Sprintf("/api/events/%d/feedback", eventID)
+
+		w := doJSON(router, "POST", path, participantToken, gin.H{"rating": 2, "comment": "Meh"})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doJSON(router, "POST", path, participantToken, gin.H{"rating": 4, "comment": "Actually good"})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var rating, count int
+		testDB.QueryRow(`SELECT rating, (SELECT COUNT(*) FROM event_feedback WHERE event_id = ?) FROM event_feedback WHERE event_id = ? AND user_id = ?`,
+			eventID, eventID, participantID).Scan(&rating, &count)
+		assert.Equal(t, 4, rating)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Concurrent first submits store one rating", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "raced-event", time.Now().Add(-48*time.Hour), nil)
+		addParticipant(t, testDB, eventID, participantID)
+		path := fmt.Sprintf("/api/events/%d/feedback", eventID)
+
+		codes := make(chan int, 8)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(codes); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- doJSON(router, "POST", path, participantToken, gin.H{"rating": 5}).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		created := 0
+		for code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusOK}, code, "later submits edit the first")
+			if code == http.StatusCreated {
+				created++
+			}
+		}
+		assert.Equal(t, 1, created)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM event_feedback WHERE event_id = ?`, eventID))
+	})
+
+	t.Run("Editing closed after 7 days", func(t *testing.T) {
+		eventID := createPastEvent(t, testDB, organizerID, "old-event", time.Now().Add(-30*24*time.Hour), nil)
+		addParticipant(t, testDB, eventID, participantID)
+		_, err := testDB.Exec(`INSERT INTO event_feedback (event_id, user_id, rating, created_at) VALUES (?, ?, 3, ?)`,
+			eventID, participantID, time.Now().Add(-8*24*time.Hour))
+		require.NoError(t, err)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), participantToken, gin.H{"rating": 5})
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+}
+
+func TestEventFeedbackAggregates(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupFeedbackRouter()
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	eventID := createPastEvent(t, testDB, organizerID, "aggregate-event", time.Now().Add(-48*time.Hour), nil)
+	otherEventID := createPastEvent(t, testDB, organizerID, "other-event", time.Now().Add(-72*time.Hour), nil)
+
+	for i, rating := range []int{5, 4, 2} {
+		email := fmt.Sprintf("rater%d@example.com", i)
+		raterID := createTestUser(t, testDB, email, "Rater", "password123", false)
+		addParticipant(t, testDB, eventID, raterID)
+		token, _ := generateToken(User{ID: int(raterID), Email: email})
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", eventID), token, gin.H{"rating": rating})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	raterID := createTestUser(t, testDB, "other@example.com", "Rater", "password123", false)
+	addParticipant(t, testDB, otherEventID, raterID)
+	token, _ := generateToken(User{ID: int(raterID), Email: "other@example.com"})
+	w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/feedback", otherEventID), token, gin.H{"rating": 1})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("Event average and count", func(t *testing.T) {
+		summary, err := GetEventRating(int(eventID))
+		require.NoError(t, err)
+		assert.Equal(t, 3, summary.Count)
+		assert.Equal(t, 3.67, summary.Average)
+	})
+
+	t.Run("Public event includes rating once past", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/aggregate-event", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		require.NotNil(t, event.Rating)
+		assert.Equal(t, 3, event.Rating.Count)
+		assert.Equal(t, 3.67, event.Rating.Average)
+	})
+
+	t.Run("Organizer profile aggregates across events", func(t *testing.T) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/profile/%d", organizerID), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			OrganizerRating RatingSummary `json:"organizer_rating"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 4, resp.OrganizerRating.Count)
+		assert.Equal(t, 3.0, resp.OrganizerRating.Average)
+	})
+
+	t.Run("No feedback yields zero summary", func(t *testing.T) {
+		summary, err := GetOrganizerRating(int(raterID))
+		require.NoError(t, err)
+		assert.Equal(t, RatingSummary{}, summary)
+	})
+}
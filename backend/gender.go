@@ -0,0 +1,52 @@
+package main
+
+// Self-declared gender on user profiles, checked against events' gender_restriction
+const (
+	GenderMale        = "male"
+	GenderFemale      = "female"
+	GenderOther       = "other"
+	GenderUnspecified = "unspecified"
+)
+
+// Machine-readable codes for join rejections
+const (
+	ErrCodeGenderRestricted = "GENDER_RESTRICTED"
+	ErrCodeGenderRequired   = "GENDER_REQUIRED"
+)
+
+func isValidGender(gender string) bool {
+	switch gender {
+	case GenderMale, GenderFemale, GenderOther, GenderUnspecified:
+		return true
+	}
+	return false
+}
+
+// restrictionForGender maps a profile gender onto the gender_restriction value it satisfies;
+// "other" profiles may join events restricted to non-binary participants
+func restrictionForGender(gender string) string {
+	if gender == GenderOther {
+		return "non-binary"
+	}
+	return gender
+}
+
+// checkJoinGender returns a rejection code and message when a user's gender doesn't satisfy an
+// event's gender_restriction, or empty strings when it does
+func checkJoinGender(restriction, gender string) (string, string) {
+	if restriction == "" || restriction == "any" {
+		return "", ""
+	}
+	if gender == "" || gender == GenderUnspecified {
+		return ErrCodeGenderRequired, "This event is restricted by gender. Please set your gender in your profile before joining."
+	}
+	if restrictionForGender(gender) != restriction {
+		return ErrCodeGenderRestricted, "This event is only open to " + restriction + " participants"
+	}
+	return "", ""
+}
+
+// viewerGenderRestriction is the SQL for the gender_restriction the viewer (bound as its single
+// argument) satisfies, mirroring restrictionForGender
+const viewerGenderRestriction = `(SELECT CASE viewer.gender WHEN 'other' THEN 'non-binary' ELSE viewer.gender END
+			FROM users viewer WHERE viewer.id = ?)`
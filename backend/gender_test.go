@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileGender(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/profile", getOwnProfile)
+	protected.PUT("/profile", updateProfile)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+
+	w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "gender": "robot"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "gender": GenderFemale})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var user User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, GenderFemale, user.Gender)
+
+	// Omitting gender keeps it
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User"})
+	require.Equal(t, http.StatusOK, w.Code)
+	user = User{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, GenderFemale, user.Gender)
+}
+
+func TestJoinEventGenderRestriction(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	events := map[string]int64{}
+	for _, restriction := range []string{"any", "male", "female", "non-binary"} {
+		id := createTestEvent(t, testDB, organizerID, "Restricted to "+restriction)
+		_, err := testDB.Exec(`UPDATE events SET gender_restriction = ? WHERE id = ?`, restriction, id)
+		require.NoError(t, err)
+		events[restriction] = id
+	}
+
+	expected := map[string]map[string]string{
+		// profile gender -> restriction -> rejection code ("" = allowed)
+		GenderMale:        {"any": "", "male": "", "female": ErrCodeGenderRestricted, "non-binary": ErrCodeGenderRestricted},
+		GenderFemale:      {"any": "", "male": ErrCodeGenderRestricted, "female": "", "non-binary": ErrCodeGenderRestricted},
+		GenderOther:       {"any": "", "male": ErrCodeGenderRestricted, "female": ErrCodeGenderRestricted, "non-binary": ""},
+		GenderUnspecified: {"any": "", "male": ErrCodeGenderRequired, "female": ErrCodeGenderRequired, "non-binary": ErrCodeGenderRequired},
+	}
+	for gender, byRestriction := range expected {
+		email := gender + "@example.com"
+		userID := createTestUser(t, testDB, email, "User", "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET gender = ? WHERE id = ?`, gender, userID)
+		require.NoError(t, err)
+		token, _ := generateToken(User{ID: int(userID), Email: email, EmailVerified: true})
+
+		for restriction, wantCode := range byRestriction {
+			w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", events[restriction]), token, nil)
+			var body struct {
+				Code string `json:"code"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if wantCode == "" {
+				assert.Equal(t, http.StatusOK, w.Code, "%s joining %s event", gender, restriction)
+			} else {
+				assert.Equal(t, http.StatusForbidden, w.Code, "%s joining %s event", gender, restriction)
+				assert.Equal(t, wantCode, body.Code, "%s joining %s event", gender, restriction)
+			}
+		}
+	}
+
+	t.Run("Admins bypass", func(t *testing.T) {
+		adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+		token, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", events["female"]), token, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+func TestEventListGenderMe(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	for _, restriction := range []string{"any", "male", "female", "non-binary"} {
+		id := createTestEvent(t, testDB, organizerID, restriction)
+		_, err := testDB.Exec(`UPDATE events SET gender_restriction = ? WHERE id = ?`, restriction, id)
+		require.NoError(t, err)
+	}
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+	titles := func(token string) []string {
+		w := doJSON(router, "GET", "/api/events?gender=me", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		var titles []string
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	assert.ElementsMatch(t, []string{"any"}, titles(token), "unspecified only sees open events")
+
+	_, err := testDB.Exec(`UPDATE users SET gender = ? WHERE id = ?`, GenderOther, userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"any", "non-binary"}, titles(token))
+
+	assert.Len(t, titles(""), 4, "ignored for anonymous visitors")
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultPhotonURL       = "https://photon.komoot.io/api/"
+	defaultGeocoderAgent   = "Veidly/1.0 (+https://veidly.com)"
+	placeCacheSize         = 1000
+	placeCacheTTL          = 24 * time.Hour
+	minPlaceQueryLength    = 2
+	breakerFailureLimit    = 3
+	breakerCooldown        = 30 * time.Second
+	fallbackMinInterval    = time.Second // Nominatim usage policy: at most 1 request per second
+	geocoderRequestTimeout = 5 * time.Second
+)
+
+// Place sources reported in the `source` field of search results
+const (
+	PlaceSourcePhoton    = "photon"
+	PlaceSourceNominatim = "nominatim"
+)
+
+var (
+	errGeocoderUnavailable = errors.New("place search is temporarily unavailable")
+	errGeocoderThrottled   = errors.New("place search is rate limited")
+)
+
+// placeGeocoder serves /api/search/places and /api/search/reverse; tests replace it with one pointed at httptest servers
+var placeGeocoder = newGeocoderFromEnv()
+
+// geocoder resolves free-text place queries with Photon, caching results and falling back to
+// Nominatim (if configured) while Photon is failing
+type geocoder struct {
+	photonURL        string
+	photonReverseURL string
+	fallbackURL      string
+	userAgent        string
+	client           *http.Client
+	cache            *placeCache
+	breaker          *circuitBreaker
+
+	fallbackMu   sync.Mutex
+	fallbackLast time.Time
+}
+
+func newGeocoder(photonURL, fallbackURL string, timeout time.Duration) *geocoder {
+	// Photon serves reverse lookups next to the search API: /api/ -> /reverse
+	base := strings.TrimSuffix(strings.TrimSuffix(photonURL, "/"), "/api")
+	return &geocoder{
+		photonURL:        photonURL,
+		photonReverseURL: base + "/reverse",
+		fallbackURL:      fallbackURL,
+		userAgent:        defaultGeocoderAgent,
+		client:           &http.Client{Timeout: timeout},
+		cache:            newPlaceCache(placeCacheSize, placeCacheTTL),
+		breaker:          newCircuitBreaker(breakerFailureLimit, breakerCooldown),
+	}
+}
+
+// newGeocoderFromEnv reads PHOTON_URL, GEOCODER_FALLBACK_URL (e.g. https://nominatim.openstreetmap.org/search)
+// and GEOCODER_USER_AGENT, which Nominatim requires to identify the application
+func newGeocoderFromEnv() *geocoder {
+	photonURL := strings.TrimSpace(os.Getenv("PHOTON_URL"))
+	if photonURL == "" {
+		photonURL = defaultPhotonURL
+	}
+	g := newGeocoder(photonURL, strings.TrimSpace(os.Getenv("GEOCODER_FALLBACK_URL")), geocoderRequestTimeout)
+	if agent := strings.TrimSpace(os.Getenv("GEOCODER_USER_AGENT")); agent != "" {
+		g.userAgent = agent
+	}
+	return g
+}
+
+// normalizePlaceQuery lowercases and collapses whitespace so equivalent queries share a cache entry
+func normalizePlaceQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// Search returns places for query and whether they came from the cache
+func (g *geocoder) Search(query string) ([]Place, bool, error) {
+	key := normalizePlaceQuery(query)
+
+	cached, fresh, found := g.cache.Get(key)
+	if found && fresh {
+		return cached, true, nil
+	}
+
+	var lastErr error
+	if g.breaker.Allow() {
+		places, err := g.searchPhoton(query)
+		if err == nil {
+			g.breaker.Success()
+			g.cache.Set(key, places)
+			return places, false, nil
+		}
+		g.breaker.Failure()
+		log.Printf("⚠️  Photon search failed: %v", err)
+		lastErr = err
+	}
+
+	if g.fallbackURL != "" {
+		places, err := g.searchFallback(query)
+		if err == nil {
+			g.cache.Set(key, places)
+			return places, false, nil
+		}
+		log.Printf("⚠️  Fallback geocoder failed: %v", err)
+		lastErr = err
+	}
+
+	// An expired answer beats no answer while the providers are down
+	if found {
+		return cached, true, nil
+	}
+	if errors.Is(lastErr, errGeocoderThrottled) {
+		return nil, false, errGeocoderThrottled
+	}
+	return nil, false, errGeocoderUnavailable
+}
+
+func (g *geocoder) get(endpoint string, target interface{}) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return errGeocoderThrottled
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// photonProperties are the Photon feature properties used to build a display name
+type photonProperties struct {
+	Name        string `json:"name"`
+	City        string `json:"city"`
+	Country     string `json:"country"`
+	Street      string `json:"street"`
+	Housenumber string `json:"housenumber"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+	OSMType     string `json:"osm_type"`
+	OSMValue    string `json:"osm_value"`
+}
+
+// photonFeatureCollection is Photon's GeoJSON FeatureCollection response
+type photonFeatureCollection struct {
+	Features []struct {
+		Geometry struct {
+			Coordinates [2]float64 `json:"coordinates"` // [lon, lat]
+		} `json:"geometry"`
+		Properties photonProperties `json:"properties"`
+	} `json:"features"`
+}
+
+// places converts Photon features to our Place format
+func (fc photonFeatureCollection) places() []Place {
+	places := make([]Place, 0, len(fc.Features))
+	for _, feature := range fc.Features {
+		places = append(places, Place{
+			DisplayName: buildDisplayName(feature.Properties),
+			Lat:         fmt.Sprintf("%f", feature.Geometry.Coordinates[1]),
+			Lon:         fmt.Sprintf("%f", feature.Geometry.Coordinates[0]),
+			Type:        feature.Properties.OSMValue,
+			Importance:  0, // Photon doesn't provide importance, but order is by relevance
+			Source:      PlaceSourcePhoton,
+		})
+	}
+	return places
+}
+
+func (g *geocoder) searchPhoton(query string) ([]Place, error) {
+	var photonResponse photonFeatureCollection
+	endpoint := fmt.Sprintf("%s?q=%s&limit=10", g.photonURL, url.QueryEscape(query))
+	if err := g.get(endpoint, &photonResponse); err != nil {
+		return nil, err
+	}
+	return photonResponse.places(), nil
+}
+
+// Reverse returns the place at lat/lon (nil if Photon knows nothing there) and whether it came
+// from the cache. Coordinates are rounded to 4 decimals (~11m) so nearby pins share an entry.
+func (g *geocoder) Reverse(lat, lon float64) (*Place, bool, error) {
+	key := fmt.Sprintf("reverse:%.4f,%.4f", lat, lon)
+
+	cached, fresh, found := g.cache.Get(key)
+	if found && fresh {
+		return firstPlace(cached), true, nil
+	}
+
+	if !g.breaker.Allow() {
+		if found {
+			return firstPlace(cached), true, nil
+		}
+		return nil, false, errGeocoderUnavailable
+	}
+
+	var photonResponse photonFeatureCollection
+	endpoint := fmt.Sprintf("%s?lat=%.4f&lon=%.4f&limit=1", g.photonReverseURL, lat, lon)
+	if err := g.get(endpoint, &photonResponse); err != nil {
+		g.breaker.Failure()
+		log.Printf("⚠️  Photon reverse lookup failed: %v", err)
+		if found {
+			return firstPlace(cached), true, nil
+		}
+		if errors.Is(err, errGeocoderThrottled) {
+			return nil, false, errGeocoderThrottled
+		}
+		return nil, false, errGeocoderUnavailable
+	}
+	g.breaker.Success()
+
+	places := photonResponse.places()
+	g.cache.Set(key, places)
+	return firstPlace(places), false, nil
+}
+
+func firstPlace(places []Place) *Place {
+	if len(places) == 0 {
+		return nil
+	}
+	return &places[0]
+}
+
+func (g *geocoder) searchFallback(query string) ([]Place, error) {
+	// Nominatim allows one request per second per application
+	g.fallbackMu.Lock()
+	if since := time.Since(g.fallbackLast); since < fallbackMinInterval {
+		g.fallbackMu.Unlock()
+		return nil, errGeocoderThrottled
+	}
+	g.fallbackLast = time.Now()
+	g.fallbackMu.Unlock()
+
+	var results []struct {
+		DisplayName string  `json:"display_name"`
+		Lat         string  `json:"lat"`
+		Lon         string  `json:"lon"`
+		Type        string  `json:"type"`
+		Importance  float64 `json:"importance"`
+	}
+	endpoint := fmt.Sprintf("%s?q=%s&format=json&limit=10", g.fallbackURL, url.QueryEscape(query))
+	if err := g.get(endpoint, &results); err != nil {
+		return nil, err
+	}
+
+	places := make([]Place, 0, len(results))
+	for _, r := range results {
+		places = append(places, Place{
+			DisplayName: r.DisplayName,
+			Lat:         r.Lat,
+			Lon:         r.Lon,
+			Type:        r.Type,
+			Importance:  r.Importance,
+			Source:      PlaceSourceNominatim,
+		})
+	}
+	return places, nil
+}
+
+// circuitBreaker stops calling a failing upstream for a cooldown after too many consecutive
+// failures, then lets a single trial request through
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	limit     int
+	cooldown  time.Duration
+	openUntil time.Time
+	trial     bool
+}
+
+func newCircuitBreaker(limit int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{limit: limit, cooldown: cooldown}
+}
+
+// Allow reports whether a request may be sent upstream
+func (cb *circuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < cb.limit {
+		return true
+	}
+	if time.Now().Before(cb.openUntil) || cb.trial {
+		return false
+	}
+	// Half-open: one request decides whether to close again
+	cb.trial = true
+	return true
+}
+
+func (cb *circuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.trial = false
+}
+
+func (cb *circuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	cb.trial = false
+	if cb.failures >= cb.limit {
+		if cb.failures == cb.limit {
+			log.Printf("🔌 Geocoder circuit open for %s after %d consecutive failures", cb.cooldown, cb.failures)
+		}
+		cb.openUntil = time.Now().Add(cb.cooldown)
+	}
+}
+
+// Open reports whether the breaker is currently rejecting requests
+func (cb *circuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.failures >= cb.limit && time.Now().Before(cb.openUntil)
+}
+
+type placeCacheEntry struct {
+	key     string
+	places  []Place
+	expires time.Time
+}
+
+// placeCache is a fixed-size LRU of search results. Expired entries are kept until evicted so
+// they can still be served while every provider is down.
+type placeCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+func newPlaceCache(capacity int, ttl time.Duration) *placeCache {
+	return &placeCache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached places for key, whether they are still fresh, and whether any were found
+func (pc *placeCache) Get(key string) ([]Place, bool, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	elem, ok := pc.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	pc.order.MoveToFront(elem)
+	entry := elem.Value.(*placeCacheEntry)
+	return entry.places, time.Now().Before(entry.expires), true
+}
+
+func (pc *placeCache) Set(key string, places []Place) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if elem, ok := pc.entries[key]; ok {
+		entry := elem.Value.(*placeCacheEntry)
+		entry.places = places
+		entry.expires = time.Now().Add(pc.ttl)
+		pc.order.MoveToFront(elem)
+		return
+	}
+
+	pc.entries[key] = pc.order.PushFront(&placeCacheEntry{key: key, places: places, expires: time.Now().Add(pc.ttl)})
+	if pc.order.Len() > pc.capacity {
+		oldest := pc.order.Back()
+		pc.order.Remove(oldest)
+		delete(pc.entries, oldest.Value.(*placeCacheEntry).key)
+	}
+}
+
+// validPlaceQuery reports whether a query is long enough to be worth sending upstream
+func validPlaceQuery(query string) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(query)) >= minPlaceQueryLength
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const photonWarsawResponse = `{"features":[{"geometry":{"coordinates":[21.01,52.23]},
+	"properties":{"name":"Warsaw","country":"Poland","osm_value":"city"}}]}`
+
+// useTestGeocoder swaps placeGeocoder for one pointed at test servers
+func useTestGeocoder(t *testing.T, g *geocoder) {
+	original := placeGeocoder
+	placeGeocoder = g
+	t.Cleanup(func() { placeGeocoder = original })
+}
+
+func searchPlacesRequest(router *gin.Engine, query string) (*httptest.ResponseRecorder, []Place) {
+	req, _ := http.NewRequest("GET", "/api/search/places?q="+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var places []Place
+	json.Unmarshal(w.Body.Bytes(), &places)
+	return w, places
+}
+
+func TestSearchPlacesCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	var photonCalls atomic.Int32
+	photon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		photonCalls.Add(1)
+		if !healthy.Load() {
+			time.Sleep(100 * time.Millisecond) // longer than the client timeout
+		}
+		w.Write([]byte(photonWarsawResponse))
+	}))
+	defer photon.Close()
+
+	useTestGeocoder(t, newGeocoder(photon.URL, "", 20*time.Millisecond))
+
+	router := gin.New()
+	router.GET("/api/search/places", searchPlaces)
+
+	w, places := searchPlacesRequest(router, "Warsaw")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, places, 1)
+	assert.Equal(t, "Warsaw, Poland", places[0].DisplayName)
+	assert.Equal(t, PlaceSourcePhoton, places[0].Source)
+
+	healthy.Store(false)
+
+	t.Run("Breaker opens after consecutive timeouts", func(t *testing.T) {
+		for i := 0; i < breakerFailureLimit; i++ {
+			w, _ := searchPlacesRequest(router, "Krakow")
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		}
+		assert.True(t, placeGeocoder.breaker.Open())
+
+		calls := photonCalls.Load()
+		w, _ := searchPlacesRequest(router, "Gdansk")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, calls, photonCalls.Load(), "open breaker must not call Photon")
+	})
+
+	t.Run("Cached results are served while the breaker is open", func(t *testing.T) {
+		w, places := searchPlacesRequest(router, "%20%20WARSAW%20")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "HIT", w.Header().Get("X-Geocoder-Cache"))
+		require.Len(t, places, 1)
+		assert.Equal(t, PlaceSourcePhoton, places[0].Source)
+	})
+
+	t.Run("Half-open trial closes the breaker on success", func(t *testing.T) {
+		healthy.Store(true)
+		placeGeocoder.breaker.mu.Lock()
+		placeGeocoder.breaker.openUntil = time.Now().Add(-time.Second)
+		placeGeocoder.breaker.mu.Unlock()
+
+		w, _ := searchPlacesRequest(router, "Poznan")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, placeGeocoder.breaker.Open())
+	})
+}
+
+func TestSearchPlacesFallback(t *testing.T) {
+	photon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer photon.Close()
+
+	var userAgent string
+	nominatim := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		w.Write([]byte(`[{"display_name":"Zurich, Switzerland","lat":"47.37","lon":"8.54","type":"city","importance":0.8}]`))
+	}))
+	defer nominatim.Close()
+
+	useTestGeocoder(t, newGeocoder(photon.URL, nominatim.URL, time.Second))
+
+	router := gin.New()
+	router.GET("/api/search/places", searchPlaces)
+
+	w, places := searchPlacesRequest(router, "Zurich")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, places, 1)
+	assert.Equal(t, PlaceSourceNominatim, places[0].Source)
+	assert.Equal(t, "47.37", places[0].Lat)
+	assert.Contains(t, userAgent, "Veidly")
+
+	// Nominatim allows one request per second; a second uncached query is throttled
+	w, _ = searchPlacesRequest(router, "Bern")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestSearchPlacesMinimumLength(t *testing.T) {
+	router := gin.New()
+	router.GET("/api/search/places", searchPlaces)
+
+	w, _ := searchPlacesRequest(router, "a")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = searchPlacesRequest(router, "%20%20")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPlaceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newPlaceCache(2, time.Hour)
+	cache.Set("a", []Place{{DisplayName: "A"}})
+	cache.Set("b", []Place{{DisplayName: "B"}})
+	cache.Get("a")
+	cache.Set("c", []Place{{DisplayName: "C"}})
+
+	_, _, found := cache.Get("b")
+	assert.False(t, found)
+	_, fresh, found := cache.Get("a")
+	assert.True(t, found)
+	assert.True(t, fresh)
+}
+
+func TestReverseGeocode(t *testing.T) {
+	var lastQuery string
+	var calls atomic.Int32
+	photon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.Equal(t, "/reverse", r.URL.Path)
+		assert.Equal(t, defaultGeocoderAgent, r.Header.Get("User-Agent"))
+		lastQuery = r.URL.RawQuery
+		if r.URL.Query().Get("lat") == "0.0000" {
+			w.Write([]byte(`{"features":[]}`))
+			return
+		}
+		w.Write([]byte(`{"features":[{"geometry":{"coordinates":[21.0122,52.2297]},
+			"properties":{"street":"Marszalkowska","housenumber":"1","city":"Warsaw","country":"Poland","osm_value":"house"}}]}`))
+	}))
+	defer photon.Close()
+
+	useTestGeocoder(t, newGeocoder(photon.URL+"/api/", "", time.Second))
+
+	router := gin.New()
+	router.GET("/api/search/reverse", reverseGeocode)
+	reverse := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/search/reverse?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Pin resolves to a display name", func(t *testing.T) {
+		w := reverse("lat=52.229712&lon=21.012231")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var place Place
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &place))
+		assert.Equal(t, "Marszalkowska 1, Warsaw, Poland", place.DisplayName)
+		assert.Equal(t, PlaceSourcePhoton, place.Source)
+		assert.Contains(t, lastQuery, "lat=52.2297")
+	})
+
+	t.Run("Nearby pins share a cache entry", func(t *testing.T) {
+		before := calls.Load()
+		w := reverse("lat=52.229749&lon=21.012249")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "HIT", w.Header().Get("X-Geocoder-Cache"))
+		assert.Equal(t, before, calls.Load())
+	})
+
+	t.Run("Empty feature list", func(t *testing.T) {
+		w := reverse("lat=0&lon=0")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid coordinates", func(t *testing.T) {
+		for _, query := range []string{"lat=91&lon=0", "lat=0&lon=-180.5", "lat=abc&lon=1", "lon=1", "lat=NaN&lon=0"} {
+			w := reverse(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
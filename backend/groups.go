@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Group join policies: open groups take anyone, approval groups hold requests until the owner accepts
+const (
+	GroupJoinOpen     = "open"
+	GroupJoinApproval = "approval"
+)
+
+// Membership statuses
+const (
+	GroupMemberActive  = "member"
+	GroupMemberPending = "pending"
+)
+
+var (
+	ErrGroupNameTooShort        = errors.New("name must be at least 3 characters")
+	ErrGroupNameTooLong         = errors.New("name too long (max 100 characters)")
+	ErrGroupDescriptionTooLong  = errors.New("description too long (max 2000 characters)")
+	ErrInvalidGroupJoinPolicy   = errors.New("join_policy must be one of: open, approval")
+	errGroupNotFound            = errors.New("group not found")
+	errGroupPostingNotAllowed   = errors.New("only the group owner can publish events in this group")
+	errGroupOwnerCannotLeave    = errors.New("the owner can't leave their own group")
+	errGroupMembershipNotActive = errors.New("not a member of this group")
+)
+
+// Group is a persistent community that members follow and that owns events
+type Group struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	OwnerID     int       `json:"owner_id"`
+	OwnerName   string    `json:"owner_name"`
+	JoinPolicy  string    `json:"join_policy"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Viewer-specific
+	IsOwner          bool   `json:"is_owner"`
+	MembershipStatus string `json:"membership_status,omitempty"` // member | pending, empty when not joined
+	Notify           *bool  `json:"notify,omitempty"`            // Whether new group events land in the viewer's feed
+}
+
+// GroupRequest creates a group
+type GroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	JoinPolicy  string `json:"join_policy"` // open (default) | approval
+}
+
+// GroupMembershipRequest updates the viewer's own membership settings
+type GroupMembershipRequest struct {
+	Notify *bool `json:"notify"`
+}
+
+// GroupMember is one row of the owner's member list
+type GroupMember struct {
+	UserID   int       `json:"user_id"`
+	Name     string    `json:"name"`
+	Status   string    `json:"status"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ValidateGroup checks and sanitizes a group before it is stored
+func ValidateGroup(req *GroupRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Name) < 3 {
+		return ErrGroupNameTooShort
+	}
+	if utf8.RuneCountInString(req.Name) > 100 {
+		return ErrGroupNameTooLong
+	}
+	if utf8.RuneCountInString(req.Description) > 2000 {
+		return ErrGroupDescriptionTooLong
+	}
+	switch req.JoinPolicy {
+	case "":
+		req.JoinPolicy = GroupJoinOpen
+	case GroupJoinOpen, GroupJoinApproval:
+	default:
+		return ErrInvalidGroupJoinPolicy
+	}
+
+	req.Name = html.EscapeString(req.Name)
+	req.Description = html.EscapeString(req.Description)
+	return nil
+}
+
+// generateUniqueGroupSlug picks a slug for a new group, retrying on the rare suffix collision
+func generateUniqueGroupSlug(ctx context.Context, name string) (string, error) {
+	for i := 0; i < 5; i++ {
+		slug := generateSlug(html.UnescapeString(name))
+		var count int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM groups WHERE slug = ?`, slug).Scan(&count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+	}
+	return "", errors.New("failed to generate unique group slug after 5 attempts")
+}
+
+// loadGroup reads a group by slug with its member count and the viewer's membership
+func loadGroup(ctx context.Context, slug string, viewerID int) (Group, error) {
+	var g Group
+	var status sql.NullString
+	var notify sql.NullBool
+	err := db.QueryRowContext(ctx, `
+		SELECT g.id, g.name, g.slug, g.description, g.owner_id, COALESCE(u.name, ''), g.join_policy, g.created_at,
+		       (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id AND m.status = 'member'),
+		       me.status, me.notify
+		FROM groups g
+		LEFT JOIN users u ON u.id = g.owner_id
+		LEFT JOIN group_members me ON me.group_id = g.id AND me.user_id = ?
+		WHERE g.slug = ?
+	`, viewerID, slug).Scan(&g.ID, &g.Name, &g.Slug, &g.Description, &g.OwnerID, &g.OwnerName, &g.JoinPolicy, &g.CreatedAt,
+		&g.MemberCount, &status, &notify)
+	if err == sql.ErrNoRows {
+		return g, errGroupNotFound
+	}
+	if err != nil {
+		return g, err
+	}
+	g.IsOwner = viewerID > 0 && g.OwnerID == viewerID
+	g.MembershipStatus = status.String
+	if notify.Valid {
+		g.Notify = &notify.Bool
+	}
+	return g, nil
+}
+
+// respondGroupError maps loadGroup failures to a response
+func respondGroupError(c *gin.Context, err error) {
+	if errors.Is(err, errGroupNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+	log.Printf("❌ Error loading group: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load group"})
+}
+
+// checkGroupPosting verifies userID may publish an event in the group (its owner, or an admin)
+func checkGroupPosting(ctx context.Context, groupID, userID int, isAdmin bool) error {
+	var ownerID int
+	err := db.QueryRowContext(ctx, `SELECT owner_id FROM groups WHERE id = ?`, groupID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return errGroupNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID != userID && !isAdmin {
+		return errGroupPostingNotAllowed
+	}
+	return nil
+}
+
+// createGroup creates a group owned by the caller, who becomes its first member (POST /api/groups)
+func createGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("👥 POST /api/groups - User %d creating group", userID)
+
+	if !c.GetBool("email_verified") && !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before creating groups"})
+		return
+	}
+
+	var req GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if err := ValidateGroup(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slug, err := generateUniqueGroupSlug(ctx, req.Name)
+	if err != nil {
+		log.Printf("❌ Failed to generate group slug: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO groups (name, slug, description, owner_id, join_policy, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, slug, req.Description, userID, req.JoinPolicy, now, now)
+	if err == nil {
+		var groupID int64
+		groupID, err = result.LastInsertId()
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT INTO group_members (group_id, user_id, status) VALUES (?, ?, ?)`,
+				groupID, userID, GroupMemberActive)
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Failed to create group: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
+
+	group, err := loadGroup(ctx, slug, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	log.Printf("✅ Group %d created: %s", group.ID, slug)
+	c.JSON(http.StatusCreated, group)
+}
+
+// getGroup returns a group's profile and its upcoming events as the viewer may see them
+// (GET /api/groups/:slug)
+func getGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+	viewerID := c.GetInt("user_id")
+	log.Printf("👥 GET /api/groups/%s - Fetching group", slug)
+
+	group, err := loadGroup(ctx, slug, viewerID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	events, err := queryEventList(ctx, EventFilter{Group: slug}, viewerID, c.GetBool("email_verified"), c.GetBool("is_admin"))
+	if err != nil {
+		log.Printf("❌ Error querying group events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load group"})
+		return
+	}
+	events = FilterEventsByBlocks(events, viewerID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":  group,
+		"events": events,
+	})
+}
+
+// joinGroup makes the caller a member, or a pending one for approval groups (POST /api/groups/:slug/join)
+func joinGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+	userID := c.GetInt("user_id")
+	log.Printf("👥 POST /api/groups/%s/join - User %d joining", slug, userID)
+
+	group, err := loadGroup(ctx, slug, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	if group.MembershipStatus != "" {
+		c.JSON(http.StatusOK, group)
+		return
+	}
+
+	status := GroupMemberActive
+	if group.JoinPolicy == GroupJoinApproval {
+		status = GroupMemberPending
+	}
+	if _, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO group_members (group_id, user_id, status) VALUES (?, ?, ?)`,
+		group.ID, userID, status); err != nil {
+		log.Printf("❌ Failed to join group %d: %v", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join group"})
+		return
+	}
+
+	group, err = loadGroup(ctx, slug, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	log.Printf("✅ User %d joined group %d (%s)", userID, group.ID, status)
+	c.JSON(http.StatusOK, group)
+}
+
+// leaveGroup ends the caller's membership or withdraws a pending request (DELETE /api/groups/:slug/leave)
+func leaveGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+	userID := c.GetInt("user_id")
+	log.Printf("👥 DELETE /api/groups/%s/leave - User %d leaving", slug, userID)
+
+	group, err := loadGroup(ctx, slug, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	if group.IsOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGroupOwnerCannotLeave.Error()})
+		return
+	}
+	if group.MembershipStatus == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGroupMembershipNotActive.Error()})
+		return
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, group.ID, userID); err != nil {
+		log.Printf("❌ Failed to leave group %d: %v", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave group"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Left group successfully"})
+}
+
+// updateGroupMembership changes the caller's notification setting (PUT /api/groups/:slug/membership)
+func updateGroupMembership(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+	userID := c.GetInt("user_id")
+
+	var req GroupMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Notify == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "notify is required"})
+		return
+	}
+
+	group, err := loadGroup(ctx, slug, userID)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+	if group.MembershipStatus == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGroupMembershipNotActive.Error()})
+		return
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE group_members SET notify = ? WHERE group_id = ? AND user_id = ?`,
+		*req.Notify, group.ID, userID); err != nil {
+		log.Printf("❌ Failed to update membership in group %d: %v", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update membership"})
+		return
+	}
+	group.Notify = req.Notify
+	c.JSON(http.StatusOK, group)
+}
+
+// loadModeratedGroup loads the group for a membership moderation request, answering 404/403 itself
+// unless the caller is the owner or an admin
+func loadModeratedGroup(c *gin.Context) (Group, bool) {
+	group, err := loadGroup(c.Request.Context(), c.Param("slug"), c.GetInt("user_id"))
+	if err != nil {
+		respondGroupError(c, err)
+		return group, false
+	}
+	if !group.IsOwner && !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can manage members"})
+		return group, false
+	}
+	return group, true
+}
+
+// getGroupMembers lists members and pending requests for the owner (GET /api/groups/:slug/members)
+func getGroupMembers(c *gin.Context) {
+	group, ok := loadModeratedGroup(c)
+	if !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT m.user_id, u.name, m.status, m.joined_at
+		FROM group_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = ?
+		ORDER BY m.status = 'pending' DESC, m.joined_at ASC
+	`, group.ID)
+	if err != nil {
+		log.Printf("❌ Failed to list members of group %d: %v", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+	defer rows.Close()
+
+	members := []GroupMember{}
+	for rows.Next() {
+		var m GroupMember
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Status, &m.JoinedAt); err != nil {
+			log.Printf("❌ Error scanning group member: %v", err)
+			continue
+		}
+		members = append(members, m)
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// approveGroupMember accepts a pending join request (POST /api/groups/:slug/members/:user_id/approve)
+func approveGroupMember(c *gin.Context) {
+	group, ok := loadModeratedGroup(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result, err := db.ExecContext(c.Request.Context(), `
+		UPDATE group_members SET status = ? WHERE group_id = ? AND user_id = ? AND status = ?
+	`, GroupMemberActive, group.ID, memberID, GroupMemberPending)
+	if err != nil {
+		log.Printf("❌ Failed to approve member %d of group %d: %v", memberID, group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve member"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending request from this user"})
+		return
+	}
+	notify(db, memberID, NotificationGroupApproved, gin.H{"group_id": group.ID, "name": group.Name, "slug": group.Slug})
+	log.Printf("✅ User %d approved into group %d", memberID, group.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Member approved"})
+}
+
+// removeGroupMember removes a member or declines a pending request (DELETE /api/groups/:slug/members/:user_id)
+func removeGroupMember(c *gin.Context) {
+	group, ok := loadModeratedGroup(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if memberID == group.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errGroupOwnerCannotLeave.Error()})
+		return
+	}
+
+	result, err := db.ExecContext(c.Request.Context(), `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, group.ID, memberID)
+	if err != nil {
+		log.Printf("❌ Failed to remove member %d from group %d: %v", memberID, group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+	log.Printf("🚪 User %d removed from group %d by %d", memberID, group.ID, c.GetInt("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGroup(t *testing.T) {
+	req := GroupRequest{Name: "  Zürich Bouldering Crew ", Description: "<b>Climb</b>"}
+	require.NoError(t, ValidateGroup(&req))
+	assert.Equal(t, "Zürich Bouldering Crew", req.Name)
+	assert.Equal(t, "&lt;b&gt;Climb&lt;/b&gt;", req.Description)
+	assert.Equal(t, GroupJoinOpen, req.JoinPolicy, "open is the default policy")
+
+	assert.ErrorIs(t, ValidateGroup(&GroupRequest{Name: "ab"}), ErrGroupNameTooShort)
+	assert.ErrorIs(t, ValidateGroup(&GroupRequest{Name: "Crew", JoinPolicy: "invite"}), ErrInvalidGroupJoinPolicy)
+}
+
+func TestGroups(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	// Posts near-identical events ("Lead Clinic", "Lead Clinic II")
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+	eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/groups/:slug", optionalAuthMiddleware(), getGroup)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/groups", createGroup)
+	protected.POST("/groups/:slug/join", joinGroup)
+	protected.DELETE("/groups/:slug/leave", leaveGroup)
+	protected.PUT("/groups/:slug/membership", updateGroupMembership)
+	protected.GET("/groups/:slug/members", getGroupMembers)
+	protected.POST("/groups/:slug/members/:user_id/approve", approveGroupMember)
+	protected.DELETE("/groups/:slug/members/:user_id", removeGroupMember)
+	protected.GET("/profile/activity", getOwnActivity)
+
+	ownerID := createTestUser(t, testDB, "owner@example.com", "Olga", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	ownerToken, _ := generateToken(User{ID: int(ownerID), Email: "owner@example.com", EmailVerified: true})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com", EmailVerified: true})
+
+	postEvent := func(token, title string, groupID *int) *Event {
+		t.Helper()
+		payload := gin.H{
+			"title": title, "description": "Meet at the wall, shoes available to rent",
+			"category": "sports_fitness", "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		}
+		if groupID != nil {
+			payload["group_id"] = *groupID
+		}
+		w := doJSON(router, "POST", "/api/events", token, payload)
+		if w.Code != http.StatusCreated {
+			return nil
+		}
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		return &e
+	}
+	feedVerbs := func(token string) []string {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/profile/activity", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var f struct {
+			Activity []ActivityEntry `json:"activity"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &f))
+		verbs := []string{}
+		for _, a := range f.Activity {
+			verbs = append(verbs, fmt.Sprintf("%s %s", a.Verb, a.Title))
+		}
+		return verbs
+	}
+
+	w := doJSON(router, "POST", "/api/groups", ownerToken, gin.H{"name": "Zürich Bouldering Crew", "description": "Weekly climbs"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var crew Group
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &crew))
+	assert.True(t, crew.IsOwner)
+	assert.Equal(t, GroupMemberActive, crew.MembershipStatus, "the owner is the first member")
+	assert.Equal(t, 1, crew.MemberCount)
+	assert.NotEmpty(t, crew.Slug)
+
+	t.Run("Unverified users can't create groups", func(t *testing.T) {
+		newID := createTestUser(t, testDB, "new@example.com", "Newbie", "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, newID)
+		require.NoError(t, err)
+		newToken, _ := generateToken(User{ID: int(newID), Email: "new@example.com"})
+		w := doJSON(router, "POST", "/api/groups", newToken, gin.H{"name": "Newbie's Club"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Joining an open group is immediate", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/groups/"+crew.Slug+"/join", aliceToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var g Group
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &g))
+		assert.Equal(t, GroupMemberActive, g.MembershipStatus)
+		assert.Equal(t, 2, g.MemberCount)
+		require.NotNil(t, g.Notify)
+		assert.True(t, *g.Notify)
+
+		w = doJSON(router, "POST", "/api/groups/"+crew.Slug+"/join", aliceToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, "joining twice is a no-op")
+	})
+
+	t.Run("Only the owner publishes events in the group", func(t *testing.T) {
+		assert.Nil(t, postEvent(aliceToken, "Alice's Session", &crew.ID))
+
+		missing := 9999
+		w := doJSON(router, "POST", "/api/events", ownerToken, gin.H{
+			"title": "Lost Event", "description": "Posted to a group that doesn't exist",
+			"category": "sports_fitness", "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "group_id": missing,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	groupEvent := postEvent(ownerToken, "Tuesday Bouldering", &crew.ID)
+	require.NotNil(t, groupEvent)
+	require.NotNil(t, groupEvent.GroupID)
+	assert.Equal(t, crew.ID, *groupEvent.GroupID)
+	standalone := postEvent(ownerToken, "Solo Hike", nil)
+	require.NotNil(t, standalone)
+
+	t.Run("Members are notified of new group events", func(t *testing.T) {
+		assert.Equal(t, []string{"posted Tuesday Bouldering"}, feedVerbs(aliceToken))
+		assert.Empty(t, feedVerbs(ownerToken), "the poster isn't notified of their own event")
+		assert.Empty(t, feedVerbs(bobToken), "non-members aren't notified")
+	})
+
+	t.Run("Group filter and group page list only group events", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/events?group="+crew.Slug, bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, groupEvent.ID, events[0].ID)
+
+		w = doJSON(router, "GET", "/api/groups/"+crew.Slug, bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page struct {
+			Group  Group   `json:"group"`
+			Events []Event `json:"events"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Equal(t, "Zürich Bouldering Crew", page.Group.Name)
+		assert.Empty(t, page.Group.MembershipStatus)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, groupEvent.ID, page.Events[0].ID)
+
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/groups/no-such-group", "", nil).Code)
+	})
+
+	t.Run("Members can opt out of notifications", func(t *testing.T) {
+		w := doJSON(router, "PUT", "/api/groups/"+crew.Slug+"/membership", aliceToken, gin.H{"notify": false})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.NotNil(t, postEvent(ownerToken, "Thursday Bouldering", &crew.ID))
+		assert.Equal(t, []string{"posted Tuesday Bouldering"}, feedVerbs(aliceToken))
+	})
+
+	t.Run("Members leave, owners can't", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "DELETE", "/api/groups/"+crew.Slug+"/leave", ownerToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", "/api/groups/"+crew.Slug+"/leave", aliceToken, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "DELETE", "/api/groups/"+crew.Slug+"/leave", aliceToken, nil).Code)
+	})
+
+	t.Run("Approval groups hold requests for the owner", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/groups", ownerToken, gin.H{"name": "Lead Climbers", "join_policy": "approval"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var leads Group
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &leads))
+
+		w = doJSON(router, "POST", "/api/groups/"+leads.Slug+"/join", bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var g Group
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &g))
+		assert.Equal(t, GroupMemberPending, g.MembershipStatus)
+		assert.Equal(t, 1, g.MemberCount, "pending requests aren't counted")
+
+		require.NotNil(t, postEvent(ownerToken, "Lead Clinic", &leads.ID))
+		assert.Empty(t, feedVerbs(bobToken), "pending members aren't notified")
+
+		members := "/api/groups/" + leads.Slug + "/members"
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", members, bobToken, nil).Code)
+		w = doJSON(router, "GET", members, ownerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list struct {
+			Members []GroupMember `json:"members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Members, 2)
+		assert.Equal(t, int(bobID), list.Members[0].UserID, "pending requests are listed first")
+		assert.Equal(t, GroupMemberPending, list.Members[0].Status)
+
+		approve := fmt.Sprintf("%s/%d/approve", members, bobID)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "POST", approve, bobToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "POST", approve, ownerToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", approve, ownerToken, nil).Code, "already approved")
+
+		require.NotNil(t, postEvent(ownerToken, "Lead Clinic II", &leads.ID))
+		assert.Equal(t, []string{"posted Lead Clinic II"}, feedVerbs(bobToken))
+
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "DELETE", fmt.Sprintf("%s/%d", members, ownerID), ownerToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "DELETE", fmt.Sprintf("%s/%d", members, bobID), ownerToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "DELETE", fmt.Sprintf("%s/%d", members, bobID), ownerToken, nil).Code)
+	})
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -14,9 +16,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-
 // Auth handlers
 func register(c *gin.Context) {
+	ctx := c.Request.Context()
 	log.Println("📝 POST /api/auth/register - New user registration")
 
 	var req RegisterRequest
@@ -26,23 +28,56 @@ func register(c *gin.Context) {
 		return
 	}
 
+	req.Email = normalizeEmail(req.Email)
+
 	// Hash password
 	hashedPassword, err := hashPassword(req.Password)
+	if errors.Is(err, ErrPasswordTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Password hashing failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
 		return
 	}
 
+	// The user, their verification token and the email job are written together, so a crash
+	// after commit can't leave an account whose verification email was never queued
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ User registration failed: %v", err)
+		respondDBError(c, err, "Failed to create account")
+		return
+	}
+	defer tx.Rollback()
+
+	// Accounts that collided before emails were normalized keep their casing, which the column's
+	// UNIQUE constraint can't see past
+	var taken bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE lower(trim(email)) = ?)`, req.Email).Scan(&taken); err != nil {
+		log.Printf("❌ User registration failed: %v", err)
+		respondDBError(c, err, "Failed to create account")
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		return
+	}
+
 	// Insert user (email_verified defaults to false/0)
-	result, err := db.Exec(`
-		INSERT INTO users (email, password, name, email_verified)
-		VALUES (?, ?, ?, 0)
-	`, req.Email, hashedPassword, req.Name)
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO users (email, password, name, email_verified, registration_ip)
+		VALUES (?, ?, ?, 0, NULLIF(?, ''))
+	`, req.Email, hashedPassword, req.Name, c.ClientIP())
 
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ User registration failed: %v", err)
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		respondDBError(c, err, "Failed to create account")
 		return
 	}
 
@@ -64,37 +99,22 @@ func register(c *gin.Context) {
 		CreatedAt:     time.Now(),
 	}
 
-	// Send verification email if email service is configured
+	// Queue the verification email if email service is configured
 	if emailService != nil {
-		// Generate verification token
-		verificationToken, err := generateEmailToken()
-		if err != nil {
-			log.Printf("⚠️  Warning: Could not generate verification token: %v", err)
-		} else {
-			// Store token in database (expires in 24 hours)
-			expiresAt := time.Now().Add(24 * time.Hour)
-			_, err = db.Exec(`
-				INSERT INTO email_verification_tokens (user_id, token, expires_at)
-				VALUES (?, ?, ?)
-			`, user.ID, verificationToken, expiresAt)
-			if err != nil {
-				log.Printf("⚠️  Warning: Could not store verification token: %v", err)
-			} else {
-				// Send verification email asynchronously
-				go func() {
-					err := emailService.SendVerificationEmail(user.Email, user.Name, verificationToken)
-					if err != nil {
-						log.Printf("⚠️  Warning: Could not send verification email to %s: %v", user.Email, err)
-					} else {
-						log.Printf("📧 Verification email sent to: %s", user.Email)
-					}
-				}()
-			}
+		if err := queueVerificationEmail(ctx, tx, user); err != nil {
+			log.Printf("⚠️  Warning: Could not queue verification email: %v", err)
 		}
 	} else {
 		log.Println("⚠️  Email service not configured - skipping verification email")
 	}
 
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ User registration failed: %v", err)
+		respondDBError(c, err, "Failed to create account")
+		return
+	}
+	backgroundJobs.Notify()
+
 	// Generate JWT token
 	token, err := generateToken(user)
 	if err != nil {
@@ -108,6 +128,7 @@ func register(c *gin.Context) {
 }
 
 func login(c *gin.Context) {
+	ctx := c.Request.Context()
 	log.Println("🔐 POST /api/auth/login - User login attempt")
 
 	var req LoginRequest
@@ -120,11 +141,13 @@ func login(c *gin.Context) {
 	var user User
 	var hashedPassword string
 	var bio, languages sql.NullString
-	err := db.QueryRow(`
-		SELECT id, email, password, name, bio, languages, is_admin, is_blocked, email_verified, created_at
-		FROM users WHERE email = ?
-	`, req.Email).Scan(&user.ID, &user.Email, &hashedPassword, &user.Name, &bio, &languages,
-		&user.IsAdmin, &user.IsBlocked, &user.EmailVerified, &user.CreatedAt)
+	var block accountBlock
+	err := db.QueryRowContext(ctx, `
+		SELECT id, email, password, name, bio, languages, is_admin, is_blocked, email_verified, totp_enabled, created_at,
+		       blocked_until, COALESCE(block_reason, '')
+		FROM users WHERE `+emailLookup, emailLookupArgs(req.Email)...).Scan(&user.ID, &user.Email, &hashedPassword, &user.Name, &bio, &languages,
+		nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.TwoFactorEnabled), nullable(&user.CreatedAt),
+		&block.until, &block.reason)
 
 	// Convert NullString to string
 	if bio.Valid {
@@ -145,17 +168,39 @@ func login(c *gin.Context) {
 		return
 	}
 
-	if user.IsBlocked {
+	block.blocked = user.IsBlocked
+	if block.active(time.Now()) {
 		log.Printf("❌ Login denied: User is blocked - %s", req.Email)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Account is blocked"})
+		block.respond(c, "Account is blocked")
 		return
 	}
+	if block.expired(time.Now()) {
+		if err := liftSuspension(ctx, user.ID, time.Now()); err != nil {
+			log.Printf("⚠️  Failed to lift suspension of user %d: %v", user.ID, err)
+		}
+		user.IsBlocked = false
+	}
 
 	if !checkPasswordHash(req.Password, hashedPassword) {
 		log.Printf("❌ Login failed: Invalid password - %s", req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	upgradePasswordHash(user.ID, req.Password, hashedPassword)
+
+	// Accounts with 2FA get a short-lived challenge instead of a session token
+	if user.TwoFactorEnabled {
+		challenge, err := generateChallengeToken(user)
+		if err != nil {
+			log.Printf("❌ Challenge token generation failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		log.Printf("🔐 Password accepted, 2FA required for: %s", user.Email)
+		c.JSON(http.StatusOK, gin.H{"two_factor_required": true, "challenge_token": challenge})
+		return
+	}
 
 	token, err := generateToken(user)
 	if err != nil {
@@ -175,15 +220,16 @@ func logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
-func getCurrentUser(c *gin.Context) {
-	userID := c.GetInt("user_id")
-
+// loadCurrentUser reads the signed-in user's own account, as GET /api/auth/me returns it
+func loadCurrentUser(ctx context.Context, userID int) (User, error) {
 	var user User
 	var bio, languages sql.NullString
-	err := db.QueryRow(`
-		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at
+	err := db.QueryRowContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, totp_enabled,
+		       COALESCE(profile_visibility, 'public'), show_email, verification_email_failed, created_at
 		FROM users WHERE id = ?
-	`, userID).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages, &user.IsAdmin, &user.IsBlocked, &user.EmailVerified, &user.CreatedAt)
+	`, userID).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages, nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.TwoFactorEnabled),
+		&user.ProfileVisibility, nullable(&user.ShowEmail), &user.VerifyEmailFailed, nullable(&user.CreatedAt))
 
 	// Convert NullString to string
 	if bio.Valid {
@@ -192,31 +238,40 @@ func getCurrentUser(c *gin.Context) {
 	if languages.Valid {
 		user.Languages = languages.String
 	}
+	return user, err
+}
 
+func getCurrentUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+
+	user, err := loadCurrentUser(ctx, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
+	// Unread inbox entries badge the bell icon; a failed count shouldn't lock the user out
+	unread, err := countUnreadNotifications(db, userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to count unread notifications of user %d: %v", userID, err)
+	}
+
+	// Report impersonation so the frontend can show a support banner
+	response := gin.H{"user": user, "impersonating": false, "unread_notifications": unread}
+	if impersonator, ok := c.Get("impersonated_by"); ok {
+		response["impersonating"] = true
+		response["impersonator_id"] = impersonator
+	}
+
 	// Return user wrapped in object for consistency with login/register
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, response)
 }
 
 // Event handlers
 func getEvents(c *gin.Context) {
 	log.Println("📋 GET /api/events - Fetching all upcoming events")
 
-	// Get query parameters
-	category := c.Query("category")
-	keyword := c.Query("keyword")
-	location := c.Query("location")
-	languages := c.Query("languages")
-	smokingAllowed := c.Query("smoking")
-	alcoholAllowed := c.Query("alcohol")
-	gender := c.Query("gender")
-	ageMin := c.Query("age_min")
-	ageMax := c.Query("age_max")
-
 	// Get viewer info for privacy filtering
 	viewerUserID, _ := c.Get("user_id")
 	viewerIsAdmin, _ := c.Get("is_admin")
@@ -236,211 +291,135 @@ func getEvents(c *gin.Context) {
 		isVerified, _ = viewerIsVerified.(bool)
 	}
 
-	args := []interface{}{}
-
-	query := `
-		SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
-		       e.start_time, e.end_time, e.creator_name, e.max_participants,
-		       e.gender_restriction, e.age_min, e.age_max,
-		       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
-		       e.hide_organizer_until_joined, e.hide_participants_until_joined,
-		       e.require_verified_to_join, e.require_verified_to_view, e.allow_unregistered_users,
-		       u.email, u.languages as creator_languages,
-		       (SELECT COUNT(*) FROM event_participants WHERE event_id = e.id) as participant_count
-	`
-
-	// If user is authenticated, check if they're a participant
-	if userID > 0 {
-		query += `, (SELECT COUNT(*) > 0 FROM event_participants WHERE event_id = e.id AND user_id = ?) as is_participant`
-		args = append(args, userID)
-	} else {
-		query += `, 0 as is_participant`
-	}
-
-	query += `
-		FROM events e
-		LEFT JOIN users u ON e.user_id = u.id
-		WHERE e.start_time >= datetime('now')
-		AND e.start_time <= datetime('now', '+1 month')
-	`
-
-	// Category filter
-	if category != "" {
-		query += " AND e.category = ?"
-		args = append(args, category)
-	}
-
-	// Keyword search (title or description)
-	if keyword != "" {
-		query += " AND (e.title LIKE ? OR e.description LIKE ?)"
-		likeKeyword := "%" + keyword + "%"
-		args = append(args, likeKeyword, likeKeyword)
-	}
-
-	// Location search (title/description contains location)
-	if location != "" {
-		query += " AND (e.title LIKE ? OR e.description LIKE ?)"
-		likeLocation := "%" + location + "%"
-		args = append(args, likeLocation, likeLocation)
+	filter, err := ParseEventFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Language filter (search in event_languages, supports comma-separated list)
-	if languages != "" {
-		// Whitelist of valid language codes to prevent SQL injection via LIKE wildcards
-		validLanguageCodes := map[string]bool{
-			"bg": true, "hr": true, "cs": true, "da": true, "nl": true, "en": true,
-			"et": true, "fi": true, "fr": true, "de": true, "el": true, "hu": true,
-			"ga": true, "it": true, "lv": true, "lt": true, "mt": true, "pl": true,
-			"pt": true, "ro": true, "sk": true, "sl": true, "es": true, "sv": true,
-			"rm": true, "tr": true, "ar": true, "ru": true, "uk": true, "zh": true,
+	// creator_id narrows the listing to one organizer, with the same access rules as GET /api/users/:id/events
+	if filter.CreatorID > 0 {
+		found, visible, err := organizerEventsAccess(c.Request.Context(), filter.CreatorID, userID, isAdmin)
+		if err != nil {
+			log.Printf("❌ Error checking access to organizer %d: %v", filter.CreatorID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+			return
 		}
-
-		// Split comma-separated language codes and search for any match
-		langCodes := strings.Split(languages, ",")
-		if len(langCodes) > 0 {
-			langConditions := []string{}
-			for _, code := range langCodes {
-				code = strings.TrimSpace(code)
-				// Validate language code against whitelist
-				if code != "" && validLanguageCodes[code] {
-					langConditions = append(langConditions, "e.event_languages LIKE ?")
-					args = append(args, "%"+code+"%")
-				}
-			}
-			if len(langConditions) > 0 {
-				query += " AND (" + strings.Join(langConditions, " OR ") + ")"
-			}
+		if !found || !visible {
+			respondJSONWithETag(c, userID, []Event{})
+			return
 		}
 	}
 
-	// Smoking filter
-	if smokingAllowed == "true" {
-		query += " AND e.smoking_allowed = 1"
-	} else if smokingAllowed == "false" {
-		query += " AND e.smoking_allowed = 0"
+	// Anonymous listings look the same for every visitor, so they're served from a short-lived
+	// cache. Authenticated requests carry is_participant and privacy filtering and always bypass it.
+	cacheKey, cacheable := "", userID == 0
+	if cacheable {
+		cacheKey, cacheable = filter.cacheKey()
+	}
+	var generation uint64
+	if cacheable {
+		var events []Event
+		var hit bool
+		events, generation, hit = eventListCache.Get(cacheKey)
+		if hit {
+			log.Printf("✓ Serving %d events from cache", len(events))
+			respondJSONWithETag(c, userID, events)
+			return
+		}
 	}
 
-	// Alcohol filter
-	if alcoholAllowed == "true" {
-		query += " AND e.alcohol_allowed = 1"
-	} else if alcoholAllowed == "false" {
-		query += " AND e.alcohol_allowed = 0"
+	events, err := loadEventList(c.Request.Context(), filter, userID, isVerified, isAdmin)
+	if err != nil {
+		log.Printf("❌ Error querying events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+		return
 	}
-
-	// Gender filter
-	if gender != "" && gender != "any" {
-		query += " AND (e.gender_restriction = ? OR e.gender_restriction = 'any')"
-		args = append(args, gender)
+	if cacheable {
+		eventListCache.Set(cacheKey, generation, events)
 	}
+	events = FilterEventsByBlocks(events, userID)
 
-	// Age filters
-	if ageMin != "" {
-		query += " AND e.age_max >= ?"
-		args = append(args, ageMin)
-	}
-	if ageMax != "" {
-		query += " AND e.age_min <= ?"
-		args = append(args, ageMax)
-	}
+	log.Printf("✓ Found %d events", len(events))
+	respondJSONWithETag(c, userID, events)
+}
 
-	query += " ORDER BY e.start_time ASC LIMIT 100"
+// queryEventList runs the upcoming-events listing query for filter, applying view permissions
+// and privacy filters for the given viewer
+func queryEventList(ctx context.Context, filter EventFilter, userID int, isVerified, isAdmin bool) ([]Event, error) {
+	// Signed-in viewers get a language_match score on every event
+	var languages []string
+	if userID > 0 {
+		var err error
+		if languages, err = viewerLanguages(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
 
-	rows, err := db.Query(query, args...)
+	query, args := BuildEventsQuery(filter, EventViewer{ID: userID, Languages: languages})
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("❌ Error querying events: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
 	var events []Event
 	for rows.Next() {
-		var e Event
-		var startTime, endTime, genderRestriction, eventLanguages, creatorLanguages, slug, userEmail sql.NullString
-		var maxParticipants sql.NullInt64
-		var createdAt time.Time
-		var isParticipant bool
-		err := rows.Scan(
-			&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-			&startTime, &endTime, &e.CreatorName,
-			&maxParticipants, &genderRestriction, &e.AgeMin, &e.AgeMax,
-			&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &slug, &createdAt,
-			&e.HideOrganizerUntilJoined, &e.HideParticipantsUntilJoined,
-			&e.RequireVerifiedToJoin, &e.RequireVerifiedToView, &e.AllowUnregisteredUsers,
-			&userEmail, &creatorLanguages, &e.ParticipantCount, &isParticipant,
-		)
+		var isParticipant, isInterested bool
+		e, org, err := scanEventRow(rows, &isParticipant, &isInterested)
 		if err != nil {
 			log.Printf("❌ Error scanning event: %v", err)
 			continue
 		}
-		if startTime.Valid {
-			e.StartTime = startTime.String
-		}
-		if endTime.Valid {
-			e.EndTime = endTime.String
-		}
-		if maxParticipants.Valid {
-			e.MaxParticipants = int(maxParticipants.Int64)
-		}
-		if genderRestriction.Valid {
-			e.GenderRestriction = genderRestriction.String
-		} else {
-			e.GenderRestriction = "any"
-		}
-		if eventLanguages.Valid {
-			e.EventLanguages = eventLanguages.String
-		}
-		if creatorLanguages.Valid {
-			e.CreatorLanguages = creatorLanguages.String
-		}
-		if slug.Valid {
-			e.Slug = slug.String
-		}
-		if userEmail.Valid {
-			e.UserEmail = userEmail.String
+		if filter.Near != nil && !filter.Near.Contains(e.Latitude, e.Longitude) {
+			continue
 		}
-		e.CreatedAt = createdAt
 		e.IsParticipant = isParticipant
+		e.IsInterested = isInterested
+		if userID > 0 {
+			match := languageMatch(languages, e.EventLanguages)
+			e.LanguageMatch = &match
+		}
 
 		// Check if event can be viewed
-		if errMsg := CheckEventViewPermission(&e, userID, isVerified, isAdmin); errMsg != "" {
+		if CheckEventViewPermission(&e, userID, isVerified, isAdmin) != nil {
 			// Skip events that require verification
 			continue
 		}
 
-		// Apply privacy filters
-		ApplyPrivacyFilters(&e, userID, isVerified, isAdmin)
+		serializeEvent(&e, org, userID, isVerified, isAdmin)
 
 		events = append(events, e)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	log.Printf("✓ Found %d events", len(events))
-	c.JSON(http.StatusOK, events)
+	ids := make([]int, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+	}
+	attributes, err := loadEventAttributes(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Attributes = attributes[events[i].ID]
+	}
+	return events, nil
 }
 
 func getEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
 	log.Printf("📖 GET /api/events/%s - Fetching single event", id)
 
-	var e Event
-	var startTime, endTime, genderRestriction, eventLanguages, slug sql.NullString
-	var maxParticipants sql.NullInt64
-	var createdAt time.Time
-	err := db.QueryRow(`
-		SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
-		       e.start_time, e.end_time, e.creator_name, e.max_participants,
-		       e.gender_restriction, e.age_min, e.age_max,
-		       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
-		       u.email
+	e, org, err := scanEventRow(db.QueryRowContext(ctx, `
+		SELECT `+eventColumns+`
 		FROM events e
 		LEFT JOIN users u ON e.user_id = u.id
 		WHERE e.id = ?
-	`, id).Scan(
-		&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-		&startTime, &endTime, &e.CreatorName,
-		&maxParticipants, &genderRestriction, &e.AgeMin, &e.AgeMax,
-		&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &slug, &createdAt, &e.UserEmail,
-	)
+	`, id))
 
 	if err == sql.ErrNoRows {
 		log.Printf("❌ Event %s not found", id)
@@ -453,49 +432,35 @@ func getEvent(c *gin.Context) {
 		return
 	}
 
-	if startTime.Valid {
-		e.StartTime = startTime.String
-	}
-	if endTime.Valid {
-		e.EndTime = endTime.String
-	}
-	if maxParticipants.Valid {
-		e.MaxParticipants = int(maxParticipants.Int64)
-	}
-	if genderRestriction.Valid {
-		e.GenderRestriction = genderRestriction.String
-	} else {
-		e.GenderRestriction = "any"
-	}
-	if eventLanguages.Valid {
-		e.EventLanguages = eventLanguages.String
-	}
-	if slug.Valid {
-		e.Slug = slug.String
+	viewerID := c.GetInt("user_id")
+
+	if r := CheckEventViewPermission(&e, viewerID, c.GetBool("email_verified"), c.GetBool("is_admin")); r != nil {
+		log.Printf("❌ User %d cannot view event %s: %s", viewerID, id, r.Message)
+		respondRestricted(c, r)
+		return
 	}
-	e.CreatedAt = createdAt
+
+	serializeEvent(&e, org, viewerID, c.GetBool("email_verified"), c.GetBool("is_admin"))
+	attachUnreadCount(&e, viewerID)
+	attachInterest(&e, viewerID)
+	attachEventAttributes(ctx, &e)
 
 	log.Printf("✓ Event %s found", id)
-	c.JSON(http.StatusOK, e)
+	respondJSONWithETag(c, viewerID, e)
 }
 
 func createEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID := c.GetInt("user_id")
 	requestID, _ := c.Get("request_id")
 	log.Printf("[%v] ➕ POST /api/events - Creating new event for user ID: %d", requestID, userID)
 
-	// Check if user's email is verified (admins are exempt)
+	// Unverified users can create events, but only as drafts until they verify (admins are exempt)
 	var emailVerified, isAdmin bool
-	err := db.QueryRow(`SELECT email_verified, is_admin FROM users WHERE id = ?`, userID).Scan(&emailVerified, &isAdmin)
+	err := db.QueryRowContext(ctx, `SELECT email_verified, is_admin FROM users WHERE id = ?`, userID).Scan(nullable(&emailVerified), nullable(&isAdmin))
 	if err != nil {
 		log.Printf("[%v] ❌ Failed to check email verification status: %v", requestID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account status"})
-		return
-	}
-	// Admins can create events without email verification (they can verify themselves)
-	if !emailVerified && !isAdmin {
-		log.Printf("[%v] ❌ User %d attempted to create event with unverified email", requestID, userID)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before creating events"})
+		respondDBError(c, err, "Failed to verify account status")
 		return
 	}
 
@@ -505,9 +470,15 @@ func createEvent(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
+	event.Draft = !emailVerified && !isAdmin
 
-	// Parse times first
-	startTime, err := parseDateTime(event.StartTime)
+	// Parse times first; wall-clock times are read in the event's zone and stored as UTC
+	loc, err := resolveEventTimezone(ctx, &event, userID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+	startTime, err := parseEventTime(event.StartTime, loc)
 	if err != nil {
 		log.Printf("[%v] ❌ Invalid start_time: %v", requestID, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
@@ -516,7 +487,7 @@ func createEvent(c *gin.Context) {
 
 	var endTimePtr *time.Time
 	if event.EndTime != "" {
-		endTime, err := parseDateTime(event.EndTime)
+		endTime, err := parseEventTime(event.EndTime, loc)
 		if err != nil {
 			log.Printf("[%v] ❌ Invalid end_time: %v", requestID, err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
@@ -524,60 +495,222 @@ func createEvent(c *gin.Context) {
 		}
 		endTimePtr = &endTime
 	}
+	setEventTimes(&event, startTime, endTimePtr)
+	joinDeadline, err := parseJoinDeadline(&event, loc)
+	if err != nil {
+		log.Printf("[%v] ❌ Invalid join_deadline: %v", requestID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join_deadline format"})
+		return
+	}
+	event.LongRange = event.LongRange && isAdmin
 
 	// Validate event data
 	if err := ValidateEvent(&event, &startTime, endTimePtr); err != nil {
 		log.Printf("[%v] ❌ Validation failed: %v", requestID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondEventValidationError(c, err)
 		return
 	}
+	if err := ValidateJoinDeadline(joinDeadline, startTime); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+
+	// Only the group's owner publishes events under it
+	if event.GroupID != nil {
+		if err := checkGroupPosting(ctx, *event.GroupID, userID, isAdmin); err != nil {
+			switch {
+			case errors.Is(err, errGroupNotFound):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+			case errors.Is(err, errGroupPostingNotAllowed):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				log.Printf("[%v] ❌ Failed to check group %d: %v", requestID, *event.GroupID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+			}
+			return
+		}
+	}
 
-	// Generate unique slug for the event (with uniqueness check)
-	slug, err := generateUniqueSlug(event.Title)
+	// A resubmitted creation form gets the event it already created instead of a copy
+	if existingID, err := findDoubleSubmit(ctx, userID, event.Title, startTime); err != nil {
+		log.Printf("[%v] ⚠️  Double-submit check failed: %v", requestID, err)
+	} else if existingID > 0 {
+		log.Printf("[%v] 🔁 User %d resubmitted event %d, returning it", requestID, userID, existingID)
+		respondExistingEvent(c, existingID, userID, isAdmin)
+		return
+	}
+
+	// A near-identical repost of a recent event nearby is refused; the duplicate endpoint is for that
+	if !isAdmin {
+		if err := checkSimilarEvent(ctx, userID, &event); err != nil {
+			var similar *SimilarEventError
+			if errors.As(err, &similar) {
+				log.Printf("[%v] 🚫 User %d reposted event %d (similarity %.2f)", requestID, userID, similar.EventID, similar.Similarity)
+				similar.respond(c)
+				return
+			}
+			log.Printf("[%v] ⚠️  Similar event check failed: %v", requestID, err)
+		}
+	}
+
+	// Flagged events are created hidden until an admin reviews them
+	moderation, ok := moderateEventText(c, &event, isAdmin)
+	if !ok {
+		return
+	}
+	if !applyNewAccountRules(c, &moderation, userID, isAdmin) {
+		return
+	}
+	event.HiddenPendingReview = moderation.Flagged()
+
+	err = insertEvent(ctx, &event, userID, isAdmin, startTime, endTimePtr, func(tx *sql.Tx, eventID int) {
+		if event.HiddenPendingReview {
+			flagForReview(tx, "event", eventID, userID, moderation)
+		} else if !event.Draft {
+			recordActivity(tx, userID, ActivityPosted, eventID)
+		}
+	})
 	if err != nil {
-		log.Printf("[%v] ❌ Slug generation failed: %v", requestID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate event URL"})
+		var limitErr *LimitReachedError
+		if errors.As(err, &limitErr) {
+			log.Printf("[%v] ❌ User %d is at the upcoming event cap (%d/%d)", requestID, userID, limitErr.Count, limitErr.Limit)
+			limitErr.respond(c)
+			return
+		}
+		log.Printf("[%v] ❌ Failed to create event: %v", requestID, err)
+		respondDBError(c, err, "Failed to create event")
 		return
 	}
-	log.Printf("✓ Generated slug: %s", slug)
 
-	result, err := db.Exec(`
+	if event.Draft {
+		// Announced by publishDrafts once the email is verified
+		log.Printf("📝 Draft event created with ID: %d for unverified user %d", event.ID, userID)
+		event.Notice = draftNotice
+		applyCapacityFields(&event)
+		c.JSON(http.StatusCreated, event)
+		return
+	}
+
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookEventCreated, event.ID, 0)
+	if !event.HiddenPendingReview {
+		broadcastEvent(WebhookEventCreated, event.ID)
+	}
+	log.Printf("✅ Event created successfully with ID: %d, slug: %s", event.ID, event.Slug)
+	applyCapacityFields(&event)
+	c.JSON(http.StatusCreated, event)
+}
+
+// insertEvent stores a validated event under a fresh unique slug and fills in ID, UserID, Slug and CreatedAt.
+// Unless exempt, it returns a *LimitReachedError when the user is already at MaxUpcomingCreated.
+// related, if set, runs in the same transaction once the row exists, for the writes that go with a
+// new event (review queue, activity feed), so they commit or roll back together with it.
+func insertEvent(ctx context.Context, event *Event, userID int, exemptFromCap bool, startTime time.Time, endTimePtr *time.Time, related func(tx *sql.Tx, eventID int)) error {
+	for attempt := 1; ; attempt++ {
+		// Generate unique slug for the event (with uniqueness check)
+		slug, err := newEventSlug(event.Title)
+		if err != nil {
+			return fmt.Errorf("slug generation failed: %w", err)
+		}
+		log.Printf("✓ Generated slug: %s", slug)
+
+		id, err := insertEventWithSlug(ctx, event, userID, exemptFromCap, slug, startTime, endTimePtr, related)
+		// Another event can take the slug between the check and the insert
+		if isSlugConflict(err) && attempt < maxSlugAttempts {
+			log.Printf("⚠️  Slug %s was taken meanwhile, generating another", slug)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		event.ID = int(id)
+		event.UserID = userID
+		event.Slug = slug
+		event.CreatedAt = time.Now()
+		event.UpdatedAt = event.CreatedAt
+		return nil
+	}
+}
+
+// insertEventWithSlug is one attempt of insertEvent
+func insertEventWithSlug(ctx context.Context, event *Event, userID int, exemptFromCap bool, slug string, startTime time.Time, endTimePtr *time.Time, related func(tx *sql.Tx, eventID int)) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	id, err := insertEventTx(ctx, tx, event, userID, exemptFromCap, slug, startTime, endTimePtr)
+	if err != nil {
+		return 0, err
+	}
+	if related != nil {
+		related(tx, int(id))
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit failed: %w", err)
+	}
+	return id, nil
+}
+
+// insertEventTx is the INSERT behind insertEvent, for callers that store several events in one
+// transaction. The caller fills in the event's ID, UserID and Slug once the transaction commits.
+func insertEventTx(ctx context.Context, tx *sql.Tx, event *Event, userID int, exemptFromCap bool, slug string, startTime time.Time, endTimePtr *time.Time) (int64, error) {
+	if !exemptFromCap {
+		if err := checkUpcomingCreateLimit(tx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	// Events created while the organizer is away start paused like the rest of theirs
+	if !event.JoinsPaused {
+		if err := tx.QueryRowContext(ctx, `SELECT events_paused FROM users WHERE id = ?`, userID).Scan(&event.JoinsPaused); err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("checking vacation mode failed: %w", err)
+		}
+	}
+
+	// Events only start hidden when a flag rule matched their text
+	hiddenSources := 0
+	if event.HiddenPendingReview {
+		hiddenSources = HideSourceModeration
+	}
+	result, err := tx.ExecContext(ctx, `
 		INSERT INTO events (
-			user_id, title, description, category, latitude, longitude, start_time, end_time,
-			creator_name, max_participants,
+			user_id, title, description, description_format, category, latitude, longitude, start_time, end_time,
+			join_deadline, creator_name, max_participants,
 			gender_restriction, age_min, age_max,
 			smoking_allowed, alcohol_allowed, event_languages, slug,
 			hide_organizer_until_joined, hide_participants_until_joined,
-			require_verified_to_join, require_verified_to_view, allow_unregistered_users) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, userID, event.Title, event.Description, event.Category, event.Latitude, event.Longitude,
-		startTime, endTimePtr, event.CreatorName,
+			require_verified_to_join, require_verified_to_view, allow_unregistered_users,
+			location_name, address, require_birth_year, hidden_pending_review, hidden_sources, published, timezone, fingerprint, updated_at,
+			group_id, price_amount, price_currency, payment_note, max_joins_per_network, reserved_spots, joins_paused) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, userID, event.Title, event.Description, event.DescriptionFormat, event.Category, event.Latitude, event.Longitude,
+		startTime, endTimePtr, joinDeadlineAt(event.JoinDeadline), event.CreatorName,
 		event.MaxParticipants, event.GenderRestriction, event.AgeMin, event.AgeMax,
 		event.SmokingAllowed, event.AlcoholAllowed, event.EventLanguages, slug,
 		event.HideOrganizerUntilJoined, event.HideParticipantsUntilJoined,
-		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers)
-
+		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers,
+		event.LocationName, event.Address, event.RequireBirthYear, event.HiddenPendingReview, hiddenSources, !event.Draft, event.Timezone,
+		eventFingerprint(event.Title, event.Latitude, event.Longitude, startTime), time.Now().UTC(),
+		event.GroupID, event.PriceAmount, event.PriceCurrency, event.PaymentNote, event.NetworkJoinLimit, event.ReservedSpots, event.JoinsPaused)
 	if err != nil {
-		log.Printf("❌ Database insert failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
-		return
+		return 0, fmt.Errorf("database insert failed: %w", err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		log.Printf("❌ Failed to get event ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
-		return
+		return 0, fmt.Errorf("failed to get event ID: %w", err)
 	}
-	event.ID = int(id)
-	event.UserID = userID
-	event.Slug = slug
-	event.CreatedAt = time.Now()
-
-	log.Printf("✅ Event created successfully with ID: %d, slug: %s", id, slug)
-	c.JSON(http.StatusCreated, event)
+	if len(event.Attributes) > 0 {
+		if err := saveEventAttributes(tx, int(id), event.Category, event.Attributes); err != nil {
+			return 0, fmt.Errorf("failed to store attributes: %w", err)
+		}
+	}
+	return id, nil
 }
 
 func updateEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
 	userID := c.GetInt("user_id")
 	isAdmin := c.GetBool("is_admin")
@@ -585,8 +718,9 @@ func updateEvent(c *gin.Context) {
 	log.Printf("✏️ PUT /api/events/%s - Updating event", id)
 
 	// Check ownership
-	var eventUserID int
-	err := db.QueryRow("SELECT user_id FROM events WHERE id = ?", id).Scan(&eventUserID)
+	var eventUserID, participantCount int
+	var currentTimezone, currentStart string
+	err := db.QueryRowContext(ctx, "SELECT user_id, timezone, start_time, participant_count FROM events WHERE id = ?", id).Scan(&eventUserID, &currentTimezone, &currentStart, &participantCount)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
@@ -604,7 +738,16 @@ func updateEvent(c *gin.Context) {
 		return
 	}
 
-	startTime, err := parseDateTime(event.StartTime)
+	// Edits keep the event's zone unless they name a new one
+	if strings.TrimSpace(event.Timezone) == "" {
+		event.Timezone = currentTimezone
+	}
+	loc, err := resolveEventTimezone(ctx, &event, eventUserID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+	startTime, err := parseEventTime(event.StartTime, loc)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid start_time: %v", err)})
 		return
@@ -612,29 +755,99 @@ func updateEvent(c *gin.Context) {
 
 	var endTimePtr *time.Time
 	if event.EndTime != "" {
-		endTime, err := parseDateTime(event.EndTime)
+		endTime, err := parseEventTime(event.EndTime, loc)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid end_time: %v", err)})
 			return
 		}
 		endTimePtr = &endTime
 	}
+	setEventTimes(&event, startTime, endTimePtr)
+	joinDeadline, err := parseJoinDeadline(&event, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid join_deadline: %v", err)})
+		return
+	}
+
+	// An event that already started keeps its time; moving it follows the rules for new events
+	if err := ValidateEventSchedule(startTime, endTimePtr, storedStart(currentStart), event.LongRange && isAdmin); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+	if err := ValidateJoinDeadline(joinDeadline, startTime); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+
+	if err := ValidateEventLocation(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateEventPrice(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateNetworkJoinLimit(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	result, err := db.Exec(`
+	if err := ValidateReservedSpots(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Neither a smaller max_participants nor more reserved spots may push out people who already joined
+	if err := checkCapacityFits(event.MaxParticipants, event.ReservedSpots, participantCount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": ErrCodeCapacityTooLow})
+		return
+	}
+
+	if event.EventLanguages, err = ValidateLanguages(event.EventLanguages); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateEventDescription(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sanitizeEventDescription(&event)
+
+	if err := ValidateEventAttributes(&event); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+
+	moderation, ok := moderateEventText(c, &event, isAdmin)
+	if !ok {
+		return
+	}
+
+	updatedAt := time.Now().UTC()
+	result, err := db.ExecContext(ctx, `
 		UPDATE events SET
-			title = ?, description = ?, category = ?, latitude = ?, longitude = ?,
-			start_time = ?, end_time = ?, creator_name = ?,
+			title = ?, description = ?, description_format = ?, category = ?, latitude = ?, longitude = ?,
+			start_time = ?, end_time = ?, join_deadline = ?, creator_name = ?,
+			deadline_reminded_at = CASE WHEN join_deadline IS ? THEN deadline_reminded_at END,
 			max_participants = ?, gender_restriction = ?, age_min = ?, age_max = ?,
 			smoking_allowed = ?, alcohol_allowed = ?, event_languages = ?,
 			hide_organizer_until_joined = ?, hide_participants_until_joined = ?,
-			require_verified_to_join = ?, require_verified_to_view = ?, allow_unregistered_users = ?
+			require_verified_to_join = ?, require_verified_to_view = ?, allow_unregistered_users = ?,
+			location_name = ?, address = ?, require_birth_year = ?, timezone = ?, fingerprint = ?, updated_at = ?,
+			price_amount = ?, price_currency = ?, payment_note = ?, max_joins_per_network = ?, reserved_spots = ?, joins_paused = ?
 		WHERE id = ?
-	`, event.Title, event.Description, event.Category, event.Latitude, event.Longitude,
-		startTime, endTimePtr, event.CreatorName,
+	`, event.Title, event.Description, event.DescriptionFormat, event.Category, event.Latitude, event.Longitude,
+		startTime, endTimePtr, joinDeadline, event.CreatorName, joinDeadline,
 		event.MaxParticipants, event.GenderRestriction, event.AgeMin, event.AgeMax,
 		event.SmokingAllowed, event.AlcoholAllowed, event.EventLanguages,
 		event.HideOrganizerUntilJoined, event.HideParticipantsUntilJoined,
-		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers, id)
+		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers,
+		event.LocationName, event.Address, event.RequireBirthYear, event.Timezone,
+		eventFingerprint(event.Title, event.Latitude, event.Longitude, startTime), updatedAt,
+		event.PriceAmount, event.PriceCurrency, event.PaymentNote, event.NetworkJoinLimit, event.ReservedSpots, event.JoinsPaused, id)
 
 	if err != nil {
 		log.Printf("❌ Database update failed: %v", err)
@@ -650,11 +863,29 @@ func updateEvent(c *gin.Context) {
 
 	eventID, _ := strconv.Atoi(id)
 	event.ID = eventID
+	event.UpdatedAt = updatedAt
+	if err := saveEventAttributes(db, eventID, event.Category, event.Attributes); err != nil {
+		log.Printf("❌ Failed to store attributes of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	attachEventAttributes(ctx, &event)
+	// Edits that trip a flag rule take the event down until an admin reviews it
+	if moderation.Flagged() {
+		if _, err := holdEvent(db, eventID, HideSourceModeration); err != nil {
+			log.Printf("⚠️  Failed to hide flagged event %d: %v", eventID, err)
+		}
+		flagForReview(db, "event", eventID, userID, moderation)
+		event.HiddenPendingReview = true
+	}
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookEventUpdated, eventID, 0)
 	log.Printf("✅ Event %s updated successfully", id)
 	c.JSON(http.StatusOK, event)
 }
 
 func deleteEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
 	userID := c.GetInt("user_id")
 	isAdmin := c.GetBool("is_admin")
@@ -663,7 +894,7 @@ func deleteEvent(c *gin.Context) {
 
 	// Check ownership
 	var eventUserID int
-	err := db.QueryRow("SELECT user_id FROM events WHERE id = ?", id).Scan(&eventUserID)
+	err := db.QueryRowContext(ctx, "SELECT user_id FROM events WHERE id = ?", id).Scan(&eventUserID)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
@@ -674,19 +905,23 @@ func deleteEvent(c *gin.Context) {
 		return
 	}
 
-	result, err := db.Exec("DELETE FROM events WHERE id = ?", id)
+	deleted, err := deleteEventRecord(ctx, db, id)
+	if errors.Is(err, errTransferPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Withdraw the pending transfer before deleting this event", "code": ErrCodeTransferPending})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Database delete failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	if !deleted {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
+	eventListCache.Invalidate()
 	log.Printf("✅ Event %s deleted successfully", id)
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 }
@@ -695,6 +930,7 @@ func getCategories(c *gin.Context) {
 	log.Println("📚 GET /api/categories - Fetching categories")
 	c.JSON(http.StatusOK, gin.H{
 		"categories": CategoryNames,
+		"attributes": CategoryAttributes, // Extra fields per category, for the event form and attr.* filters
 	})
 }
 
@@ -704,87 +940,72 @@ func searchPlaces(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
 		return
 	}
+	if !validPlaceQuery(query) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Query must be at least %d characters", minPlaceQueryLength)})
+		return
+	}
 
 	log.Printf("🔍 Searching places for: %s", query)
 
-	// Use Photon API from Komoot for geocoding autocomplete
-	url := fmt.Sprintf("https://photon.komoot.io/api/?q=%s&limit=10", url.QueryEscape(query))
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Printf("❌ Failed to create request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search places"})
+	places, cached, err := placeGeocoder.Search(query)
+	if errors.Is(err, errGeocoderThrottled) {
+		// Clients debounce keystrokes; tell them when to retry instead of failing the form
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many place searches, please retry shortly"})
 		return
 	}
-
-	req.Header.Set("User-Agent", "Veidly/1.0")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("❌ Failed to fetch places: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search places"})
+		log.Printf("❌ Failed to search places: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Place search is temporarily unavailable"})
 		return
 	}
-	defer resp.Body.Close()
 
-	// Photon returns GeoJSON FeatureCollection
-	var photonResponse struct {
-		Features []struct {
-			Geometry struct {
-				Coordinates [2]float64 `json:"coordinates"` // [lon, lat]
-			} `json:"geometry"`
-			Properties struct {
-				Name        string `json:"name"`
-				City        string `json:"city"`
-				Country     string `json:"country"`
-				Street      string `json:"street"`
-				Housenumber string `json:"housenumber"`
-				State       string `json:"state"`
-				Postcode    string `json:"postcode"`
-				OSMType     string `json:"osm_type"`
-				OSMValue    string `json:"osm_value"`
-			} `json:"properties"`
-		} `json:"features"`
+	if cached {
+		c.Header("X-Geocoder-Cache", "HIT")
 	}
+	log.Printf("✓ Found %d places", len(places))
+	c.JSON(http.StatusOK, places)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&photonResponse); err != nil {
-		log.Printf("❌ Failed to decode response: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse places"})
+// reverseGeocode returns the place under a map pin (GET /api/search/reverse?lat=..&lon=..)
+func reverseGeocode(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(c.Query("lon"), 64)
+	if latErr != nil || lonErr != nil || math.IsNaN(lat) || math.IsNaN(lon) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'lat' and 'lon' must be numbers"})
+		return
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Coordinates out of range"})
 		return
 	}
 
-	// Convert Photon response to our Place format
-	places := make([]Place, 0, len(photonResponse.Features))
-	for _, feature := range photonResponse.Features {
-		// Build display name from available properties
-		displayName := buildDisplayName(feature.Properties)
+	log.Printf("📍 Reverse geocoding %.4f, %.4f", lat, lon)
 
-		places = append(places, Place{
-			DisplayName: displayName,
-			Lat:         fmt.Sprintf("%f", feature.Geometry.Coordinates[1]),
-			Lon:         fmt.Sprintf("%f", feature.Geometry.Coordinates[0]),
-			Type:        feature.Properties.OSMValue,
-			Importance:  0, // Photon doesn't provide importance, but order is by relevance
-		})
+	place, cached, err := placeGeocoder.Reverse(lat, lon)
+	if errors.Is(err, errGeocoderThrottled) {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many place searches, please retry shortly"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to reverse geocode: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Place search is temporarily unavailable"})
+		return
 	}
 
-	log.Printf("✓ Found %d places", len(places))
-	c.JSON(http.StatusOK, places)
+	if cached {
+		c.Header("X-Geocoder-Cache", "HIT")
+	}
+	if place == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No place found at this location"})
+		return
+	}
+	c.JSON(http.StatusOK, place)
 }
 
 // buildDisplayName creates a human-readable display name from Photon properties
-func buildDisplayName(props struct {
-	Name        string `json:"name"`
-	City        string `json:"city"`
-	Country     string `json:"country"`
-	Street      string `json:"street"`
-	Housenumber string `json:"housenumber"`
-	State       string `json:"state"`
-	Postcode    string `json:"postcode"`
-	OSMType     string `json:"osm_type"`
-	OSMValue    string `json:"osm_value"`
-}) string {
+func buildDisplayName(props photonProperties) string {
 	parts := []string{}
 
 	// Add street address if available
@@ -822,28 +1043,124 @@ func buildDisplayName(props struct {
 	return strings.Join(parts, ", ")
 }
 
+// Admin list pagination defaults
+const (
+	adminDefaultPerPage = 50
+	adminMaxPerPage     = 200
+)
+
+// parsePagination reads page/per_page query parameters, falling back to sane defaults
+func parsePagination(c *gin.Context) (page, perPage int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err = strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(adminDefaultPerPage)))
+	if err != nil || perPage < 1 {
+		perPage = adminDefaultPerPage
+	}
+	if perPage > adminMaxPerPage {
+		perPage = adminMaxPerPage
+	}
+	return page, perPage
+}
+
+// parseBoolFilter reads an optional true/false query parameter; ok is false when absent or invalid
+func parseBoolFilter(c *gin.Context, name string) (value bool, ok bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return false, false
+	}
+	value, err := strconv.ParseBool(raw)
+	return value, err == nil
+}
+
+// parseSortOrder maps sort/order query parameters onto whitelisted ORDER BY columns
+func parseSortOrder(c *gin.Context, columns map[string]string, defaultColumn string) string {
+	column, ok := columns[c.Query("sort")]
+	if !ok {
+		column = columns[defaultColumn]
+	}
+	direction := "DESC"
+	if strings.EqualFold(c.Query("order"), "asc") {
+		direction = "ASC"
+	}
+	return column + " " + direction
+}
+
 // Admin handlers
 func adminGetUsers(c *gin.Context) {
-	log.Println("👥 GET /api/admin/users - Admin fetching all users")
+	ctx := c.Request.Context()
+	log.Println("👥 GET /api/admin/users - Admin fetching users")
 
-	rows, err := db.Query(`
-		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at
-		FROM users ORDER BY created_at DESC
-	`)
+	page, perPage := parsePagination(c)
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	// Case-insensitive search on email or name
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		where += " AND (LOWER(email) LIKE ? OR LOWER(name) LIKE ?)"
+		like := "%" + strings.ToLower(q) + "%"
+		args = append(args, like, like)
+	}
+	if verified, ok := parseBoolFilter(c, "verified"); ok {
+		where += " AND COALESCE(email_verified, 0) = ?"
+		args = append(args, verified)
+	}
+	if blocked, ok := parseBoolFilter(c, "blocked"); ok {
+		where += " AND COALESCE(is_blocked, 0) = ?"
+		args = append(args, blocked)
+	}
+	if isAdmin, ok := parseBoolFilter(c, "is_admin"); ok {
+		where += " AND COALESCE(is_admin, 0) = ?"
+		args = append(args, isAdmin)
+	}
+	if after, err := parseDateTime(c.Query("created_after")); err == nil && !after.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, after.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if before, err := parseDateTime(c.Query("created_before")); err == nil && !before.IsZero() {
+		where += " AND created_at <= ?"
+		args = append(args, before.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	orderBy := parseSortOrder(c, map[string]string{"created_at": "created_at", "email": "LOWER(email)"}, "created_at")
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at,
+		       COALESCE(registration_ip, ''), blocked_until, COALESCE(block_reason, '')
+		FROM users`+where+`
+		ORDER BY `+orderBy+`, id
+		LIMIT ? OFFSET ?
+	`, append(args, perPage, (page-1)*perPage)...)
 	if err != nil {
+		log.Printf("❌ Failed to query users: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
 	defer rows.Close()
 
-	var users []User
+	users := []User{}
 	for rows.Next() {
 		var u User
 		var bio, languages sql.NullString
-		err := rows.Scan(&u.ID, &u.Email, &u.Name, &bio, &languages, &u.IsAdmin, &u.IsBlocked, &u.EmailVerified, &u.CreatedAt)
+		var blockedUntil sql.NullTime
+		err := rows.Scan(&u.ID, &u.Email, &u.Name, &bio, &languages, nullable(&u.IsAdmin), nullable(&u.IsBlocked), nullable(&u.EmailVerified), nullable(&u.CreatedAt),
+			&u.RegistrationIP, &blockedUntil, &u.BlockReason)
 		if err != nil {
 			continue
 		}
+		if blockedUntil.Valid {
+			until := blockedUntil.Time.UTC()
+			u.BlockedUntil = &until
+		}
 		// Convert NullString to string
 		if bio.Valid {
 			u.Bio = bio.String
@@ -854,126 +1171,248 @@ func adminGetUsers(c *gin.Context) {
 		users = append(users, u)
 	}
 
-	log.Printf("✓ Found %d users", len(users))
-	c.JSON(http.StatusOK, users)
+	log.Printf("✓ Found %d users (page %d, %d total)", len(users), page, total)
+	c.JSON(http.StatusOK, gin.H{
+		"users":    users,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
 }
 
+// adminBlockUser blocks a user permanently, or suspends them for duration_days with an optional
+// reason (PUT /api/admin/users/:id/block)
 func adminBlockUser(c *gin.Context) {
-	id := c.Param("id")
-	log.Printf("🚫 PUT /api/admin/users/%s/block - Admin blocking user", id)
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	log.Printf("🚫 PUT /api/admin/users/%d/block - Admin blocking user", id)
 
-	_, err := db.Exec("UPDATE users SET is_blocked = 1 WHERE id = ?", id)
+	// The body is optional; without one the block is permanent
+	var req SuspendUserRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+			return
+		}
+	}
+	until, err := req.suspensionEnd(time.Now())
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := setUserBlock(ctx, c.GetInt("user_id"), id, true, until, req.Reason); errors.Is(err, errUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error blocking user %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
 		return
 	}
 
-	log.Printf("✅ User %s blocked", id)
-	c.JSON(http.StatusOK, gin.H{"message": "User blocked successfully"})
+	if until != nil {
+		log.Printf("✅ User %d suspended until %s", id, until.Format(time.RFC3339))
+		c.JSON(http.StatusOK, gin.H{"message": "User suspended", "blocked_until": until, "block_reason": req.Reason})
+		return
+	}
+	log.Printf("✅ User %d blocked", id)
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked successfully", "block_reason": req.Reason})
 }
 
+// adminUnblockUser lifts a block or suspension early (PUT /api/admin/users/:id/unblock)
 func adminUnblockUser(c *gin.Context) {
-	id := c.Param("id")
-	log.Printf("✅ PUT /api/admin/users/%s/unblock - Admin unblocking user", id)
-
-	_, err := db.Exec("UPDATE users SET is_blocked = 0 WHERE id = ?", id)
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	log.Printf("✅ PUT /api/admin/users/%d/unblock - Admin unblocking user", id)
+
+	if err := setUserBlock(ctx, c.GetInt("user_id"), id, false, nil, ""); errors.Is(err, errUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error unblocking user %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
 		return
 	}
 
-	log.Printf("✅ User %s unblocked", id)
+	log.Printf("✅ User %d unblocked", id)
 	c.JSON(http.StatusOK, gin.H{"message": "User unblocked successfully"})
 }
 
 func adminVerifyUserEmail(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
 	log.Printf("✉️  PUT /api/admin/users/%s/verify-email - Admin manually verifying user email", id)
 
-	_, err := db.Exec("UPDATE users SET email_verified = 1 WHERE id = ?", id)
+	_, err := db.ExecContext(ctx, "UPDATE users SET email_verified = 1 WHERE id = ?", id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify user email"})
 		return
 	}
 
+	if userID, err := strconv.Atoi(id); err == nil {
+		publishDraftsAfterVerification(ctx, userID)
+	}
+
 	log.Printf("✅ User %s email verified by admin", id)
 	c.JSON(http.StatusOK, gin.H{"message": "User email verified successfully"})
 }
 
 func adminGetAllEvents(c *gin.Context) {
-	log.Println("📋 GET /api/admin/events - Admin fetching all events")
-
-	rows, err := db.Query(`
-		SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
-		       e.start_time, e.end_time, e.creator_name, e.max_participants,
-		       e.gender_restriction, e.age_min, e.age_max,
-		       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
-		       u.email
+	ctx := c.Request.Context()
+	log.Println("📋 GET /api/admin/events - Admin fetching events")
+
+	page, perPage := parsePagination(c)
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if category := c.Query("category"); category != "" {
+		where += " AND e.category = ?"
+		args = append(args, category)
+	}
+	if creator := strings.TrimSpace(c.Query("creator_email")); creator != "" {
+		where += " AND LOWER(u.email) LIKE ?"
+		args = append(args, "%"+strings.ToLower(creator)+"%")
+	}
+	// Date range applies to the event's start time
+	if from, err := parseDateTime(c.Query("from")); err == nil && !from.IsZero() {
+		where += " AND e.start_time >= ?"
+		args = append(args, from.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if to, err := parseDateTime(c.Query("to")); err == nil && !to.IsZero() {
+		where += " AND e.start_time <= ?"
+		args = append(args, to.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if hasReports, ok := parseBoolFilter(c, "has_reports"); ok {
+		if hasReports {
+			where += " AND EXISTS (SELECT 1 FROM event_reports r WHERE r.event_id = e.id)"
+		} else {
+			where += " AND NOT EXISTS (SELECT 1 FROM event_reports r WHERE r.event_id = e.id)"
+		}
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM events e LEFT JOIN users u ON e.user_id = u.id"+where, args...).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+		return
+	}
+
+	orderBy := parseSortOrder(c, map[string]string{"created_at": "e.created_at", "email": "LOWER(u.email)"}, "created_at")
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+eventColumns+`
 		FROM events e
-		LEFT JOIN users u ON e.user_id = u.id
-		ORDER BY e.created_at DESC
-	`)
+		LEFT JOIN users u ON e.user_id = u.id`+where+`
+		ORDER BY `+orderBy+`, e.id
+		LIMIT ? OFFSET ?
+	`, append(args, perPage, (page-1)*perPage)...)
 	if err != nil {
+		log.Printf("❌ Failed to query events: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
 		return
 	}
 	defer rows.Close()
 
-	var events []Event
+	events := []Event{}
 	for rows.Next() {
-		var e Event
-		var startTime, endTime, eventLanguages, slug sql.NullString
-		var createdAt time.Time
-		err := rows.Scan(
-			&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-			&startTime, &endTime, &e.CreatorName,
-			&e.MaxParticipants, &e.GenderRestriction, &e.AgeMin, &e.AgeMax,
-			&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &slug, &createdAt, &e.UserEmail,
-		)
+		e, org, err := scanEventRow(rows)
 		if err != nil {
+			log.Printf("❌ Error scanning event: %v", err)
 			continue
 		}
-		if startTime.Valid {
-			e.StartTime = startTime.String
-		}
-		if endTime.Valid {
-			e.EndTime = endTime.String
-		}
-		if eventLanguages.Valid {
-			e.EventLanguages = eventLanguages.String
-		}
-		if slug.Valid {
-			e.Slug = slug.String
-		}
-		e.CreatedAt = createdAt
+		serializeEvent(&e, org, c.GetInt("user_id"), true, true)
 		events = append(events, e)
 	}
 
-	log.Printf("✓ Found %d events", len(events))
-	c.JSON(http.StatusOK, events)
+	log.Printf("✓ Found %d events (page %d, %d total)", len(events), page, total)
+	c.JSON(http.StatusOK, gin.H{
+		"events":   events,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
 }
 
 func adminDeleteEvent(c *gin.Context) {
-	id := c.Param("id")
-	log.Printf("🗑️ DELETE /api/admin/events/%s - Admin deleting event", id)
+	ctx := c.Request.Context()
+	adminID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	log.Printf("🗑️ DELETE /api/admin/events/%d - Admin deleting event", id)
 
-	result, err := db.Exec("DELETE FROM events WHERE id = ?", id)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 		return
 	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	deleted, err := auditEventAction(tx, adminID, AuditEventDeleted, id, gin.H{}, func() (bool, error) {
+		return deleteEventRecord(ctx, tx, id)
+	})
+	if err == nil && deleted {
+		err = tx.Commit()
+	}
+	if errors.Is(err, errTransferPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Withdraw the pending transfer before deleting this event", "code": ErrCodeTransferPending})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error deleting event %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
+	}
+
+	if !deleted {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
-	log.Printf("✅ Event %s deleted by admin", id)
+	eventListCache.Invalidate()
+	log.Printf("✅ Event %d deleted by admin %d", id, adminID)
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 }
 
+// eventDeleter is satisfied by both *sql.DB and *sql.Tx
+type eventDeleter interface {
+	joinQuerier
+	sqlExecer
+}
+
+// errTransferPending is returned by deleteEventRecord while someone may be about to take the
+// event over; the offer has to be withdrawn first
+var errTransferPending = errors.New("event has a pending transfer")
+
+// deleteEventRecord removes an event (participants, comments etc. cascade via foreign keys).
+// Shared by the owner, admin and bulk delete endpoints so they behave identically.
+func deleteEventRecord(ctx context.Context, exec eventDeleter, id interface{}) (bool, error) {
+	if pending, err := hasPendingTransfer(ctx, exec, id); err != nil {
+		return false, err
+	} else if pending {
+		return false, errTransferPending
+	}
+	result, err := exec.Exec("DELETE FROM events WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
 func adminUpdateEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
 	log.Printf("✏️ PUT /api/admin/events/%s - Admin updating event", id)
 
@@ -983,7 +1422,26 @@ func adminUpdateEvent(c *gin.Context) {
 		return
 	}
 
-	startTime, err := parseDateTime(event.StartTime)
+	var eventUserID, participantCount int
+	var currentTimezone, currentStart string
+	err := db.QueryRowContext(ctx, "SELECT user_id, timezone, start_time, participant_count FROM events WHERE id = ?", id).Scan(&eventUserID, &currentTimezone, &currentStart, &participantCount)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	if strings.TrimSpace(event.Timezone) == "" {
+		event.Timezone = currentTimezone
+	}
+	loc, err := resolveEventTimezone(ctx, &event, eventUserID)
+	if err != nil {
+		respondTimezoneError(c, err)
+		return
+	}
+	startTime, err := parseEventTime(event.StartTime, loc)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid start_time: %v", err)})
 		return
@@ -991,29 +1449,93 @@ func adminUpdateEvent(c *gin.Context) {
 
 	var endTimePtr *time.Time
 	if event.EndTime != "" {
-		endTime, err := parseDateTime(event.EndTime)
+		endTime, err := parseEventTime(event.EndTime, loc)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid end_time: %v", err)})
 			return
 		}
 		endTimePtr = &endTime
 	}
+	setEventTimes(&event, startTime, endTimePtr)
+	joinDeadline, err := parseJoinDeadline(&event, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid join_deadline: %v", err)})
+		return
+	}
+
+	if err := ValidateEventSchedule(startTime, endTimePtr, storedStart(currentStart), event.LongRange); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+	if err := ValidateJoinDeadline(joinDeadline, startTime); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
+
+	if err := ValidateEventLocation(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateEventPrice(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateNetworkJoinLimit(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateReservedSpots(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Neither a smaller max_participants nor more reserved spots may push out people who already joined
+	if err := checkCapacityFits(event.MaxParticipants, event.ReservedSpots, participantCount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": ErrCodeCapacityTooLow})
+		return
+	}
+
+	if event.EventLanguages, err = ValidateLanguages(event.EventLanguages); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateEventDescription(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sanitizeEventDescription(&event)
+
+	if err := ValidateEventAttributes(&event); err != nil {
+		respondEventValidationError(c, err)
+		return
+	}
 
-	result, err := db.Exec(`
+	updatedAt := time.Now().UTC()
+	result, err := db.ExecContext(ctx, `
 		UPDATE events SET
-			title = ?, description = ?, category = ?, latitude = ?, longitude = ?,
-			start_time = ?, end_time = ?, creator_name = ?,
+			title = ?, description = ?, description_format = ?, category = ?, latitude = ?, longitude = ?,
+			start_time = ?, end_time = ?, join_deadline = ?, creator_name = ?,
+			deadline_reminded_at = CASE WHEN join_deadline IS ? THEN deadline_reminded_at END,
 			max_participants = ?, gender_restriction = ?, age_min = ?, age_max = ?,
 			smoking_allowed = ?, alcohol_allowed = ?, event_languages = ?,
 			hide_organizer_until_joined = ?, hide_participants_until_joined = ?,
-			require_verified_to_join = ?, require_verified_to_view = ?, allow_unregistered_users = ?
+			require_verified_to_join = ?, require_verified_to_view = ?, allow_unregistered_users = ?,
+			location_name = ?, address = ?, require_birth_year = ?, timezone = ?, fingerprint = ?, updated_at = ?,
+			price_amount = ?, price_currency = ?, payment_note = ?, max_joins_per_network = ?, reserved_spots = ?, joins_paused = ?
 		WHERE id = ?
-	`, event.Title, event.Description, event.Category, event.Latitude, event.Longitude,
-		startTime, endTimePtr, event.CreatorName,
+	`, event.Title, event.Description, event.DescriptionFormat, event.Category, event.Latitude, event.Longitude,
+		startTime, endTimePtr, joinDeadline, event.CreatorName, joinDeadline,
 		event.MaxParticipants, event.GenderRestriction, event.AgeMin, event.AgeMax,
 		event.SmokingAllowed, event.AlcoholAllowed, event.EventLanguages,
 		event.HideOrganizerUntilJoined, event.HideParticipantsUntilJoined,
-		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers, id)
+		event.RequireVerifiedToJoin, event.RequireVerifiedToView, event.AllowUnregisteredUsers,
+		event.LocationName, event.Address, event.RequireBirthYear, event.Timezone,
+		eventFingerprint(event.Title, event.Latitude, event.Longitude, startTime), updatedAt,
+		event.PriceAmount, event.PriceCurrency, event.PaymentNote, event.NetworkJoinLimit, event.ReservedSpots, event.JoinsPaused, id)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
@@ -1026,23 +1548,39 @@ func adminUpdateEvent(c *gin.Context) {
 		return
 	}
 
+	event.UpdatedAt = updatedAt
+	eventID, _ := strconv.Atoi(id)
+	event.ID = eventID
+	if err := saveEventAttributes(db, eventID, event.Category, event.Attributes); err != nil {
+		log.Printf("❌ Failed to store attributes of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	attachEventAttributes(ctx, &event)
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookEventUpdated, eventID, 0)
 	log.Printf("✅ Event %s updated by admin", id)
 	c.JSON(http.StatusOK, event)
 }
 
 // Profile handlers
 func getOwnProfile(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID := c.GetInt("user_id")
 	log.Printf("👤 GET /api/profile - Fetching own profile for user ID: %d", userID)
 
 	// Get user profile
 	var user User
 	var bio, languages sql.NullString
-	err := db.QueryRow(`
-		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at
+	var birthYear sql.NullInt64
+	var updatedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, totp_enabled,
+		       COALESCE(profile_visibility, 'public'), show_email, birth_year, COALESCE(gender, 'unspecified'), COALESCE(timezone, ''), digest_emails, events_paused, COALESCE(threema, ''), created_at, updated_at
 		FROM users WHERE id = ?
 	`, userID).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages,
-		&user.IsAdmin, &user.IsBlocked, &user.EmailVerified, &user.CreatedAt)
+		nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.TwoFactorEnabled),
+		&user.ProfileVisibility, nullable(&user.ShowEmail), &birthYear, &user.Gender, &user.Timezone, &user.DigestEmails, &user.EventsPaused, &user.Threema, nullable(&user.CreatedAt), &updatedAt)
 
 	// Convert NullString to string
 	if bio.Valid {
@@ -1051,6 +1589,14 @@ func getOwnProfile(c *gin.Context) {
 	if languages.Valid {
 		user.Languages = languages.String
 	}
+	if birthYear.Valid {
+		year := int(birthYear.Int64)
+		user.BirthYear = &year
+	}
+	user.UpdatedAt = &user.CreatedAt
+	if updatedAt.Valid {
+		user.UpdatedAt = &updatedAt.Time
+	}
 
 	if err != nil {
 		log.Printf("❌ Failed to fetch user profile: %v", err)
@@ -1059,7 +1605,7 @@ func getOwnProfile(c *gin.Context) {
 	}
 
 	// Get user's created events (upcoming)
-	createdRows, err := db.Query(`
+	createdRows, err := db.QueryContext(ctx, `
 		SELECT id, title, slug, start_time, category, latitude, longitude
 		FROM events
 		WHERE user_id = ? AND start_time > datetime('now')
@@ -1094,7 +1640,7 @@ func getOwnProfile(c *gin.Context) {
 	}
 
 	// Get user's joined events (upcoming, not created by user)
-	joinedRows, err := db.Query(`
+	joinedRows, err := db.QueryContext(ctx, `
 		SELECT e.id, e.title, e.slug, e.start_time, e.category, e.latitude, e.longitude
 		FROM events e
 		INNER JOIN event_participants ep ON e.id = ep.event_id
@@ -1130,7 +1676,7 @@ func getOwnProfile(c *gin.Context) {
 	}
 
 	// Get user's past events (both created and joined)
-	pastRows, err := db.Query(`
+	pastRows, err := db.QueryContext(ctx, `
 		SELECT DISTINCT e.id, e.title, e.slug, e.start_time, e.category, e.latitude, e.longitude,
 		CASE WHEN e.user_id = ? THEN 1 ELSE 0 END as is_creator
 		FROM events e
@@ -1168,17 +1714,26 @@ func getOwnProfile(c *gin.Context) {
 		})
 	}
 
+	allowance, err := upcomingAllowance(userID, user.IsAdmin)
+	if err != nil {
+		log.Printf("❌ Failed to count upcoming events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
 	log.Printf("✅ Profile fetched for user: %s with %d created, %d joined, %d past events",
 		user.Email, len(createdEvents), len(joinedEvents), len(pastEvents))
 	c.JSON(http.StatusOK, gin.H{
-		"user":          user,
+		"user":           user,
 		"created_events": createdEvents,
 		"joined_events":  joinedEvents,
 		"past_events":    pastEvents,
+		"limits":         allowance, // null for admins
 	})
 }
 
 func updateProfile(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID := c.GetInt("user_id")
 	log.Printf("👤 PUT /api/profile - Updating profile for user ID: %d", userID)
 
@@ -1188,10 +1743,33 @@ func updateProfile(c *gin.Context) {
 		return
 	}
 
-	_, err := db.Exec(`
-		UPDATE users SET name = ?, bio = ?, languages = ?
+	if err := ValidateProfileUpdate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Privacy settings are optional in the request and keep their current value when omitted
+	var showEmail, digestEmails interface{}
+	if req.ShowEmail != nil {
+		showEmail = *req.ShowEmail
+	}
+	if req.DigestEmails != nil {
+		digestEmails = *req.DigestEmails
+	}
+
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET name = ?, bio = ?, languages = ?,
+			profile_visibility = COALESCE(NULLIF(?, ''), profile_visibility),
+			show_email = COALESCE(?, show_email),
+			birth_year = CASE WHEN ? THEN NULLIF(?, 0) ELSE birth_year END,
+			gender = COALESCE(NULLIF(?, ''), gender),
+			timezone = COALESCE(NULLIF(?, ''), timezone),
+			digest_emails = COALESCE(?, digest_emails),
+			threema = CASE WHEN ? THEN NULLIF(?, '') ELSE threema END,
+			updated_at = ?
 		WHERE id = ?
-	`, req.Name, req.Bio, req.Languages, userID)
+	`, req.Name, req.Bio, req.Languages, req.ProfileVisibility, showEmail, req.BirthYear != nil, req.BirthYear, req.Gender,
+		req.Timezone, digestEmails, req.Threema != nil, req.Threema, time.Now().UTC(), userID)
 
 	if err != nil {
 		log.Printf("❌ Profile update failed: %v", err)
@@ -1202,11 +1780,15 @@ func updateProfile(c *gin.Context) {
 	// Get updated user
 	var user User
 	var bio, languages sql.NullString
-	err = db.QueryRow(`
-		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at
+	var birthYear sql.NullInt64
+	var updatedAt sql.NullTime
+	err = db.QueryRowContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, totp_enabled,
+		       COALESCE(profile_visibility, 'public'), show_email, birth_year, COALESCE(gender, 'unspecified'), COALESCE(timezone, ''), digest_emails, events_paused, COALESCE(threema, ''), created_at, updated_at
 		FROM users WHERE id = ?
 	`, userID).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages,
-		&user.IsAdmin, &user.IsBlocked, &user.EmailVerified, &user.CreatedAt)
+		nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.TwoFactorEnabled),
+		&user.ProfileVisibility, nullable(&user.ShowEmail), &birthYear, &user.Gender, &user.Timezone, &user.DigestEmails, &user.EventsPaused, &user.Threema, nullable(&user.CreatedAt), &updatedAt)
 
 	// Convert NullString to string
 	if bio.Valid {
@@ -1215,6 +1797,14 @@ func updateProfile(c *gin.Context) {
 	if languages.Valid {
 		user.Languages = languages.String
 	}
+	if birthYear.Valid {
+		year := int(birthYear.Int64)
+		user.BirthYear = &year
+	}
+	user.UpdatedAt = &user.CreatedAt
+	if updatedAt.Valid {
+		user.UpdatedAt = &updatedAt.Time
+	}
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated profile"})
@@ -1226,16 +1816,21 @@ func updateProfile(c *gin.Context) {
 }
 
 func getUserProfile(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := c.Param("id")
+	viewerID := c.GetInt("user_id") // 0 for anonymous visitors
+	isAdmin := c.GetBool("is_admin")
 	log.Printf("👤 GET /api/profile/%s - Fetching user profile", id)
 
 	var user User
 	var bio, languages sql.NullString
-	err := db.QueryRow(`
-		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at
+	err := db.QueryRowContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, totp_enabled,
+		       COALESCE(profile_visibility, 'public'), show_email, created_at
 		FROM users WHERE id = ?
 	`, id).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages,
-		&user.IsAdmin, &user.IsBlocked, &user.EmailVerified, &user.CreatedAt)
+		nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.TwoFactorEnabled),
+		&user.ProfileVisibility, nullable(&user.ShowEmail), nullable(&user.CreatedAt))
 
 	// Convert NullString to string
 	if bio.Valid {
@@ -1254,15 +1849,38 @@ func getUserProfile(c *gin.Context) {
 		return
 	}
 
-	userIDInt, _ := strconv.Atoi(id)
+	// The user themselves and admins always get the complete profile
+	fullView := isAdmin || viewerID == user.ID
+
+	if !fullView {
+		// Someone who blocked the viewer has no profile as far as the viewer can tell
+		if viewerID > 0 && HasBlocked(ctx, user.ID, viewerID) {
+			log.Printf("🔒 Profile %s blocked viewer %d", id, viewerID)
+			respondRestricted(c, notFoundRestriction("User not found"))
+			return
+		}
+		switch ProfileAccessFor(user.ProfileVisibility, viewerID) {
+		case ProfileAccessNone:
+			log.Printf("🔒 Profile %s is hidden from viewer %d", id, viewerID)
+			respondRestricted(c, notFoundRestriction("User not found"))
+			return
+		case ProfileAccessLimited:
+			log.Printf("🔒 Profile %s is limited for anonymous viewer", id)
+			c.JSON(http.StatusOK, gin.H{
+				"user":    PublicProfile(user, viewerID),
+				"limited": true,
+			})
+			return
+		}
+	}
 
 	// Get user's created events (upcoming only for other users)
-	createdRows, err := db.Query(`
+	createdRows, err := db.QueryContext(ctx, `
 		SELECT id, title, slug, start_time, category, latitude, longitude
 		FROM events
 		WHERE user_id = ? AND start_time > datetime('now')
 		ORDER BY start_time ASC
-	`, userIDInt)
+	`, user.ID)
 
 	if err != nil {
 		log.Printf("❌ Failed to fetch created events: %v", err)
@@ -1291,15 +1909,35 @@ func getUserProfile(c *gin.Context) {
 		})
 	}
 
-	log.Printf("✓ Profile found for: %s with %d upcoming events", user.Email, len(createdEvents))
+	var userPayload interface{} = user
+	if !fullView {
+		userPayload = PublicProfile(user, viewerID)
+	}
+
+	// Aggregate feedback across all events this user organized
+	organizerRating, err := GetOrganizerRating(user.ID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load organizer rating for user %d: %v", user.ID, err)
+	}
+
+	// Attendance is shown as a percentage so organizers can judge reliability
+	attendanceRate, err := GetAttendanceRate(user.ID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load attendance rate for user %d: %v", user.ID, err)
+	}
+
+	log.Printf("✓ Profile found for user %d with %d upcoming events", user.ID, len(createdEvents))
 	c.JSON(http.StatusOK, gin.H{
-		"user":          user,
-		"created_events": createdEvents,
+		"user":             userPayload,
+		"created_events":   createdEvents,
+		"organizer_rating": organizerRating,
+		"attendance_rate":  attendanceRate,
 	})
 }
 
 // Event participation handlers
 func joinEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	eventID := c.Param("id")
 	userID := c.GetInt("user_id")
 	isVerified := c.GetBool("email_verified")
@@ -1307,86 +1945,171 @@ func joinEvent(c *gin.Context) {
 
 	log.Printf("➕ POST /api/events/%s/join - User %d joining event", eventID, userID)
 
-	// GLOBAL REQUIREMENT: Email verification required for all event joins (except admins)
-	if !isVerified && !isAdmin {
-		log.Printf("❌ User %d needs verified email to join any event", userID)
-		c.JSON(http.StatusForbidden, gin.H{"error": "You must verify your email address before joining events. Please check your email for the verification link."})
+	// The body is optional; without one the participant's contact stays private
+	var req JoinEventRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+			return
+		}
+	}
+	if err := ValidateDisplayAlias(&req.DisplayAlias); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !moderateDisplayAlias(c, req.DisplayAlias) {
+		return
+	}
+
+	eventIDInt, err := strconv.Atoi(eventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 
 	// Start transaction to prevent race condition (CRITICAL SECURITY FIX)
 	// Without transaction, multiple users could join simultaneously when only 1 spot left
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("❌ Error starting transaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		respondDBError(c, err, "Failed to join event")
 		return
 	}
 	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
 
-	// Check if event exists, has space, and check privacy settings WITH ROW LOCK
-	var maxParticipants sql.NullInt64
-	var currentCount int
-	var requireVerifiedToJoin bool
-	err = tx.QueryRow(`
-		SELECT max_participants,
-		       (SELECT COUNT(*) FROM event_participants WHERE event_id = ?) as count,
-		       require_verified_to_join
-		FROM events WHERE id = ?
-	`, eventID, eventID).Scan(&maxParticipants, &currentCount, &requireVerifiedToJoin)
-
-	if err == sql.ErrNoRows {
+	// The same checks back GET /api/events/:id/join-eligibility; inside the transaction the
+	// capacity and caps can't change before the insert
+	eval, err := evaluateJoin(ctx, tx, eventIDInt, joinViewer{ID: userID, Admin: isAdmin, Verified: isVerified})
+	if errors.Is(err, errJoinEventNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
 	}
 	if err != nil {
 		log.Printf("❌ Error checking event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		respondDBError(c, err, "Failed to join event")
+		return
+	}
+	if len(eval.refusals) > 0 {
+		refusal := eval.refusals[0]
+		log.Printf("❌ User %d can't join event %s: %s", userID, eventID, refusal.Code)
+		refusal.respond(c)
 		return
 	}
 
-	// The require_verified_to_join flag is now redundant (kept for backward compatibility)
-	// but the global check above already enforces verification for all events
-
-	// Check capacity
-	if maxParticipants.Valid && currentCount >= int(maxParticipants.Int64) {
-		log.Printf("❌ Event %s is full (%d/%d participants)", eventID, currentCount, maxParticipants.Int64)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Event is full"})
+	// Answers are checked against the organizer's questions as they are now
+	questions, err := loadEventQuestions(ctx, tx, eventIDInt)
+	if err != nil {
+		log.Printf("❌ Error loading event questions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		return
+	}
+	answers, err := validateJoinAnswers(questions, req.Answers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": ErrCodeInvalidAnswers})
 		return
 	}
 
 	// Insert participant within transaction
-	_, err = tx.Exec(`
-		INSERT INTO event_participants (event_id, user_id)
-		VALUES (?, ?)
-	`, eventID, userID)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_participants (event_id, user_id, share_contact, display_alias)
+		VALUES (?, ?, ?, NULLIF(?, ''))
+	`, eventID, userID, req.ShareContact, req.DisplayAlias)
 
 	// Handle duplicate join (UNIQUE constraint)
-	if err != nil {
+	if isUniqueViolation(err) {
 		log.Printf("❌ User %d already joined event %s", userID, eventID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Already joined this event"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Already joined this event", "code": ErrCodeAlreadyJoined})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error adding participant: %v", err)
+		respondDBError(c, err, "Failed to join event")
+		return
+	}
+
+	if err := adjustParticipantCount(tx, eventID, 1); err != nil {
+		log.Printf("❌ Error updating participant count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		return
+	}
+	if err := storeJoinAnswers(ctx, tx, eventIDInt, userID, answers); err != nil {
+		log.Printf("❌ Error storing answers: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		return
+	}
+	if err := dropInterest(tx, eventID, userID); err != nil {
+		log.Printf("❌ Error clearing interest: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
 		return
 	}
+	recordActivity(tx, userID, ActivityJoined, eventID)
 
 	// Commit transaction
 	err = tx.Commit()
 	if err != nil {
 		log.Printf("❌ Error committing transaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join event"})
+		respondDBError(c, err, "Failed to join event")
 		return
 	}
 
+	eventListCache.Invalidate()
+	webhookDispatch.Dispatch(WebhookParticipantJoined, eventIDInt, userID)
 	log.Printf("✅ User %d successfully joined event %s", userID, eventID)
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully joined event"})
 }
 
 func leaveEvent(c *gin.Context) {
+	ctx := c.Request.Context()
 	eventID := c.Param("id")
 	userID := c.GetInt("user_id")
 
 	log.Printf("➖ DELETE /api/events/%s/leave - User %d leaving event", eventID, userID)
 
-	result, err := db.Exec(`
+	// The body is optional; without one the leave is recorded without a reason
+	var req LeaveEventRequest
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+			return
+		}
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !moderateLeaveNote(c, &req) {
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		respondDBError(c, err, "Failed to leave event")
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	var startTime string
+	var maxParticipants, reservedSpots, participantCount int
+	err = tx.QueryRowContext(ctx, `
+		SELECT start_time, COALESCE(max_participants, 0), reserved_spots, participant_count FROM events WHERE id = ?
+	`, eventID).Scan(&startTime, &maxParticipants, &reservedSpots, &participantCount)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error checking event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave event"})
+		return
+	}
+	if joinWindowClosed(startTime, time.Now()) {
+		log.Printf("❌ User %d can't leave event %s: already started", userID, eventID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't leave an event that has already started", "code": ErrCodeEventStarted})
+		return
+	}
+
+	result, err := tx.ExecContext(ctx, `
 		DELETE FROM event_participants
 		WHERE event_id = ? AND user_id = ?
 	`, eventID, userID)
@@ -1404,10 +2127,51 @@ func leaveEvent(c *gin.Context) {
 		return
 	}
 
+	if err := adjustParticipantCount(tx, eventID, -1); err != nil {
+		log.Printf("❌ Error updating participant count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave event"})
+		return
+	}
+	recordActivity(tx, userID, ActivityLeft, eventID)
+	if err := recordParticipationExit(tx, eventID, userID, req); err != nil {
+		log.Printf("❌ Error recording leave reason: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave event"})
+		return
+	}
+
+	// Leaving a full event frees a spot the users marked interested may want
+	if maxParticipants > 0 && participantCount+reservedSpots >= maxParticipants {
+		eventIDInt, _ := strconv.Atoi(eventID)
+		if err := queueSpotsAvailable(tx, eventIDInt); err != nil {
+			log.Printf("❌ Error queueing spot notifications: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave event"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		respondDBError(c, err, "Failed to leave event")
+		return
+	}
+
+	eventListCache.Invalidate()
 	log.Printf("✅ User %d successfully left event %s", userID, eventID)
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully left event"})
 }
 
+// adjustParticipantCount keeps events.participant_count in step with event_participants.
+// Call it in the same transaction as the participant insert/delete. The count is part of the
+// event resource, so this also bumps updated_at.
+func adjustParticipantCount(exec sqlExecer, eventID interface{}, delta int) error {
+	_, err := exec.Exec(`UPDATE events SET participant_count = MAX(participant_count + ?, 0), updated_at = ? WHERE id = ?`,
+		delta, time.Now().UTC(), eventID)
+	return err
+}
+
+// getPublicEvent returns the event behind a public slug (GET /api/public/events/:slug). Clients
+// whose Accept header prefers text/calendar get the ICS file inline instead of JSON, under the same
+// privacy checks.
 func getPublicEvent(c *gin.Context) {
 	slug := c.Param("slug")
 	log.Printf("🌐 GET /api/public/events/%s - Fetching public event by slug", slug)
@@ -1431,107 +2195,36 @@ func getPublicEvent(c *gin.Context) {
 		isVerified, _ = viewerIsVerified.(bool)
 	}
 
-	var e Event
-	var startTime, endTime, genderRestriction, eventLanguages, eventSlug, userEmail, creatorLanguages sql.NullString
-	var maxParticipants sql.NullInt64
-	var createdAt time.Time
-	var isParticipant bool
-
-	// Build query with participant check if user is authenticated
-	query := `
-		SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
-		       e.start_time, e.end_time, e.creator_name, e.max_participants,
-		       e.gender_restriction, e.age_min, e.age_max,
-		       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
-		       e.hide_organizer_until_joined, e.hide_participants_until_joined,
-		       e.require_verified_to_join, e.require_verified_to_view, e.allow_unregistered_users,
-		       u.email, u.languages as creator_languages,
-		       (SELECT COUNT(*) FROM event_participants WHERE event_id = e.id) as participant_count
-	`
-
-	var err error
-	if userID > 0 {
-		query += `, (SELECT COUNT(*) > 0 FROM event_participants WHERE event_id = e.id AND user_id = ?) as is_participant
-			FROM events e
-			LEFT JOIN users u ON e.user_id = u.id
-			WHERE e.slug = ?`
-		err = db.QueryRow(query, userID, slug).Scan(
-			&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-			&startTime, &endTime, &e.CreatorName,
-			&maxParticipants, &genderRestriction, &e.AgeMin, &e.AgeMax,
-			&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &eventSlug, &createdAt,
-			&e.HideOrganizerUntilJoined, &e.HideParticipantsUntilJoined,
-			&e.RequireVerifiedToJoin, &e.RequireVerifiedToView, &e.AllowUnregisteredUsers,
-			&userEmail, &creatorLanguages, &e.ParticipantCount, &isParticipant,
-		)
-	} else {
-		query += `, 0 as is_participant
-			FROM events e
-			LEFT JOIN users u ON e.user_id = u.id
-			WHERE e.slug = ?`
-		err = db.QueryRow(query, slug).Scan(
-			&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-			&startTime, &endTime, &e.CreatorName,
-			&maxParticipants, &genderRestriction, &e.AgeMin, &e.AgeMax,
-			&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &eventSlug, &createdAt,
-			&e.HideOrganizerUntilJoined, &e.HideParticipantsUntilJoined,
-			&e.RequireVerifiedToJoin, &e.RequireVerifiedToView, &e.AllowUnregisteredUsers,
-			&userEmail, &creatorLanguages, &e.ParticipantCount, &isParticipant,
-		)
-	}
-
-	if err == sql.ErrNoRows {
-		log.Printf("❌ Event with slug %s not found", slug)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		return
-	}
-	if err != nil {
-		log.Printf("❌ Error fetching event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+	e, org, ok := loadPublicEvent(c, slug, userID, isVerified, isAdmin)
+	if !ok {
 		return
 	}
+	c.Writer.Header().Add("Vary", "Accept")
 
-	if startTime.Valid {
-		e.StartTime = startTime.String
-	}
-	if endTime.Valid {
-		e.EndTime = endTime.String
-	}
-	if maxParticipants.Valid {
-		e.MaxParticipants = int(maxParticipants.Int64)
+	serializeEvent(&e, org, userID, isVerified, isAdmin)
+	if prefersCalendar(c.GetHeader("Accept")) {
+		respondEventICS(c, &e, userID, "inline")
+		return
 	}
-	if genderRestriction.Valid {
-		e.GenderRestriction = genderRestriction.String
+	attachUnreadCount(&e, userID)
+	attachEventAttributes(c.Request.Context(), &e)
+	if questions, err := loadEventQuestions(c.Request.Context(), db, e.ID); err == nil {
+		e.Questions = questions
 	} else {
-		e.GenderRestriction = "any"
-	}
-	if eventLanguages.Valid {
-		e.EventLanguages = eventLanguages.String
-	}
-	if eventSlug.Valid {
-		e.Slug = eventSlug.String
-	}
-	if userEmail.Valid {
-		e.UserEmail = userEmail.String
-	}
-	if creatorLanguages.Valid {
-		e.CreatorLanguages = creatorLanguages.String
+		log.Printf("⚠️  Failed to load questions for event %d: %v", e.ID, err)
 	}
-	e.CreatedAt = createdAt
-	e.IsParticipant = isParticipant
 
-	// Check if event can be viewed
-	if errMsg := CheckEventViewPermission(&e, userID, isVerified, isAdmin); errMsg != "" {
-		log.Printf("❌ User cannot view event %s: %s", slug, errMsg)
-		c.JSON(http.StatusForbidden, gin.H{"error": errMsg})
-		return
+	// Past events show their participant rating
+	if endedAt, err := eventEndedAt(e.StartTime, e.EndTime); err == nil && time.Now().After(endedAt) {
+		if rating, err := GetEventRating(e.ID); err == nil {
+			e.Rating = &rating
+		} else {
+			log.Printf("⚠️  Failed to load rating for event %d: %v", e.ID, err)
+		}
 	}
 
-	// Apply privacy filters
-	ApplyPrivacyFilters(&e, userID, isVerified, isAdmin)
-
 	log.Printf("✓ Public event found: %s (ID: %d)", slug, e.ID)
-	c.JSON(http.StatusOK, e)
+	respondJSONWithETag(c, userID, e)
 }
 
 func getEventParticipants(c *gin.Context) {
@@ -1564,84 +2257,132 @@ func getEventParticipants(c *gin.Context) {
 	}
 
 	// Use privacy-aware participant fetching
-	participants, err := GetParticipantsWithPrivacy(eventIDInt, userID, isVerified, isAdmin)
+	page, perPage := parsePagination(c)
+	participants, total, restriction, err := GetParticipantsWithPrivacy(eventIDInt, userID, isVerified, isAdmin, page, perPage)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Error fetching participants: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve participants"})
 		return
 	}
+	if restriction != nil {
+		log.Printf("❌ User %d cannot view participants of event %s: %s", userID, eventID, restriction.Message)
+		respondRestricted(c, restriction)
+		return
+	}
 
 	log.Printf("✓ Found %d participants for event %s", len(participants), eventID)
-	c.JSON(http.StatusOK, participants)
+	c.JSON(http.StatusOK, gin.H{
+		"items":    participants,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
 }
 
-func downloadEventICS(c *gin.Context) {
-	slug := c.Param("slug")
-	log.Printf("📅 GET /api/public/events/%s/ics - Downloading ICS file", slug)
-
+// loadPublicEvent reads the event behind a public slug with the viewer's participation, and applies
+// CheckEventViewPermission, which every representation of it shares. It writes the error response itself.
+func loadPublicEvent(c *gin.Context, slug string, userID int, isVerified, isAdmin bool) (Event, organizerRow, bool) {
+	ctx := c.Request.Context()
+	var isParticipant, isInterested bool
 	var e Event
-	var startTime, endTime, genderRestriction, eventLanguages, eventSlug sql.NullString
-	var maxParticipants sql.NullInt64
-	var createdAt time.Time
-
-	err := db.QueryRow(`
-		SELECT e.id, e.user_id, e.title, e.description, e.category, e.latitude, e.longitude,
-		       e.start_time, e.end_time, e.creator_name, e.max_participants,
-		       e.gender_restriction, e.age_min, e.age_max,
-		       e.smoking_allowed, e.alcohol_allowed, e.event_languages, e.slug, e.created_at,
-		       e.hide_organizer_until_joined, e.hide_participants_until_joined,
-		       e.require_verified_to_join, e.require_verified_to_view, e.allow_unregistered_users
-		FROM events e
-		WHERE e.slug = ?
-	`, slug).Scan(
-		&e.ID, &e.UserID, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude,
-		&startTime, &endTime, &e.CreatorName,
-		&maxParticipants, &genderRestriction, &e.AgeMin, &e.AgeMax,
-		&e.SmokingAllowed, &e.AlcoholAllowed, &eventLanguages, &eventSlug, &createdAt,
-		&e.HideOrganizerUntilJoined, &e.HideParticipantsUntilJoined,
-		&e.RequireVerifiedToJoin, &e.RequireVerifiedToView, &e.AllowUnregisteredUsers,
-	)
+	var org organizerRow
+	var err error
+	if userID > 0 {
+		e, org, err = scanEventRow(db.QueryRowContext(ctx, `
+			SELECT `+eventColumns+`,
+			       EXISTS(SELECT 1 FROM event_participants WHERE event_id = e.id AND user_id = ?) as is_participant,
+			       EXISTS(SELECT 1 FROM event_interest WHERE event_id = e.id AND user_id = ?) as is_interested
+			FROM events e
+			LEFT JOIN users u ON e.user_id = u.id
+			WHERE e.slug = ?`, userID, userID, slug), &isParticipant, &isInterested)
+	} else {
+		e, org, err = scanEventRow(db.QueryRowContext(ctx, `
+			SELECT `+eventColumns+`, 0 as is_participant, 0 as is_interested
+			FROM events e
+			LEFT JOIN users u ON e.user_id = u.id
+			WHERE e.slug = ?`, slug), &isParticipant, &isInterested)
+	}
 
 	if err == sql.ErrNoRows {
 		log.Printf("❌ Event with slug %s not found", slug)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-		return
+		return e, org, false
 	}
 	if err != nil {
 		log.Printf("❌ Error fetching event: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
-		return
+		return e, org, false
 	}
 
-	if startTime.Valid {
-		e.StartTime = startTime.String
-	}
-	if endTime.Valid {
-		e.EndTime = endTime.String
-	}
-	if maxParticipants.Valid {
-		e.MaxParticipants = int(maxParticipants.Int64)
+	e.IsParticipant = isParticipant
+	e.IsInterested = isInterested
+
+	if r := CheckEventViewPermission(&e, userID, isVerified, isAdmin); r != nil {
+		log.Printf("❌ User %d cannot view event %s: %s", userID, slug, r.Message)
+		respondRestricted(c, r)
+		return e, org, false
 	}
-	if genderRestriction.Valid {
-		e.GenderRestriction = genderRestriction.String
+	return e, org, true
+}
+
+// downloadEventICS serves the event as a calendar file (GET and HEAD /api/public/events/:slug/ics),
+// with the same visibility rules as getPublicEvent
+func downloadEventICS(c *gin.Context) {
+	slug := c.Param("slug")
+	log.Printf("📅 %s /api/public/events/%s/ics - Downloading ICS file", c.Request.Method, slug)
+
+	userID := c.GetInt("user_id")
+	isVerified := c.GetBool("email_verified")
+	isAdmin := c.GetBool("is_admin")
+	e, org, ok := loadPublicEvent(c, slug, userID, isVerified, isAdmin)
+	if !ok {
+		return
 	}
-	if eventLanguages.Valid {
-		e.EventLanguages = eventLanguages.String
+	serializeEvent(&e, org, userID, isVerified, isAdmin)
+
+	// The download button saves a file; ?disposition=inline lets calendar apps open it directly
+	disposition := "attachment"
+	if c.Query("disposition") == "inline" {
+		disposition = "inline"
 	}
-	if eventSlug.Valid {
-		e.Slug = eventSlug.String
+	respondEventICS(c, &e, userID, disposition)
+}
+
+// respondEventICS writes the event's ICS file. Calendar subscriptions poll with HEAD and
+// If-Modified-Since; Last-Modified follows the event's updated_at, which every edit, join and
+// cancellation bumps. Only anonymous responses may be stored by shared caches.
+func respondEventICS(c *gin.Context, e *Event, viewerUserID int, disposition string) {
+	cacheScope := "public"
+	if viewerUserID > 0 {
+		cacheScope = "private"
+	}
+	c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheScope, int(icsCacheMaxAge.Seconds())))
+	if !e.UpdatedAt.IsZero() {
+		lastModified := e.UpdatedAt.UTC().Truncate(time.Second)
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
 	}
-	e.CreatedAt = createdAt
 
 	// Generate ICS content
-	icsContent := GenerateICS(&e)
+	icsContent := GenerateICS(e)
 
-	// Set headers for file download
-	filename := fmt.Sprintf("%s.ics", slug)
+	filename := fmt.Sprintf("%s.ics", e.Slug)
 	c.Header("Content-Type", "text/calendar; charset=utf-8")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	c.Header("Cache-Control", "no-cache")
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, filename))
+
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Length", strconv.Itoa(len(icsContent)))
+		c.Status(http.StatusOK)
+		return
+	}
 
-	log.Printf("✅ ICS file generated for event: %s", slug)
+	log.Printf("✅ ICS file generated for event: %s", e.Slug)
 	c.String(http.StatusOK, icsContent)
 }
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorEnableRequest confirms 2FA setup with a first code from the authenticator app
+type TwoFactorEnableRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest re-verifies the user before turning 2FA off
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest completes a login for accounts with 2FA enabled
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// setupTwoFactor generates a new TOTP secret for the user (POST /api/profile/2fa/setup)
+// The secret is stored encrypted but stays inactive until confirmed via enableTwoFactor
+func setupTwoFactor(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("🔐 POST /api/profile/2fa/setup - User %d starting 2FA setup", userID)
+
+	var email string
+	var enabled bool
+	err := db.QueryRowContext(ctx, `SELECT email, totp_enabled FROM users WHERE id = ?`, userID).Scan(&email, nullable(&enabled))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading user for 2FA setup: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		log.Printf("❌ Error generating TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+
+	encrypted, err := encryptTOTPSecret(secret)
+	if err != nil {
+		log.Printf("❌ Error encrypting TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+
+	_, err = db.ExecContext(ctx, `UPDATE users SET totp_secret = ?, totp_enabled = 0 WHERE id = ?`, encrypted, userID)
+	if err != nil {
+		log.Printf("❌ Error storing TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+
+	log.Printf("✓ 2FA setup started for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": buildOTPAuthURI(secret, email),
+	})
+}
+
+// enableTwoFactor verifies the first TOTP code and activates 2FA (POST /api/profile/2fa/enable)
+// Returns freshly generated recovery codes; only their hashes are stored
+func enableTwoFactor(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("🔐 POST /api/profile/2fa/enable - User %d enabling 2FA", userID)
+
+	var req TwoFactorEnableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	var encrypted sql.NullString
+	var enabled bool
+	err := db.QueryRowContext(ctx, `SELECT totp_secret, totp_enabled FROM users WHERE id = ?`, userID).Scan(&encrypted, nullable(&enabled))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading 2FA state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if !encrypted.Valid || encrypted.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		return
+	}
+
+	secret, err := decryptTOTPSecret(encrypted.String)
+	if err != nil {
+		log.Printf("❌ Error decrypting TOTP secret for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	if !verifyTOTPCode(secret, req.Code, totpNow()) {
+		log.Printf("❌ Invalid 2FA code during enable for user %d", userID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("❌ Error generating recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		log.Printf("❌ Error clearing old recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	for _, code := range recoveryCodes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hashRecoveryCode(code)); err != nil {
+			log.Printf("❌ Error storing recovery code: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+			return
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled = 1 WHERE id = ?`, userID); err != nil {
+		log.Printf("❌ Error enabling 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	log.Printf("✅ 2FA enabled for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// disableTwoFactor turns 2FA off after re-verifying password and code (DELETE /api/profile/2fa)
+func disableTwoFactor(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	log.Printf("🔓 DELETE /api/profile/2fa - User %d disabling 2FA", userID)
+
+	var req TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	var hashedPassword string
+	var encrypted sql.NullString
+	var enabled bool
+	err := db.QueryRowContext(ctx, `SELECT password, totp_secret, totp_enabled FROM users WHERE id = ?`, userID).
+		Scan(&hashedPassword, &encrypted, nullable(&enabled))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading 2FA state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	if !enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if !checkPasswordHash(req.Password, hashedPassword) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	ok, err := verifySecondFactor(userID, encrypted.String, req.Code)
+	if err != nil {
+		log.Printf("❌ Error verifying 2FA code for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled = 0 WHERE id = ?`, userID); err != nil {
+		log.Printf("❌ Error disabling 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		log.Printf("❌ Error deleting recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	log.Printf("✅ 2FA disabled for user %d", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// verifyTwoFactorLogin exchanges a challenge token + code for a session token (POST /api/auth/2fa)
+func verifyTwoFactorLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+	log.Println("🔐 POST /api/auth/2fa - Completing two-factor login")
+
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	claims, err := validateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	var user User
+	var encrypted sql.NullString
+	var bio, languages sql.NullString
+	var block accountBlock
+	err = db.QueryRowContext(ctx, `
+		SELECT id, email, name, bio, languages, is_admin, is_blocked, email_verified, created_at,
+		       totp_secret, totp_enabled, blocked_until, COALESCE(block_reason, '')
+		FROM users WHERE id = ?
+	`, claims.UserID).Scan(&user.ID, &user.Email, &user.Name, &bio, &languages,
+		nullable(&user.IsAdmin), nullable(&user.IsBlocked), nullable(&user.EmailVerified), nullable(&user.CreatedAt),
+		&encrypted, nullable(&user.TwoFactorEnabled), &block.until, &block.reason)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Database error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+	if bio.Valid {
+		user.Bio = bio.String
+	}
+	if languages.Valid {
+		user.Languages = languages.String
+	}
+
+	block.blocked = user.IsBlocked
+	if block.active(time.Now()) {
+		block.respond(c, "Account is blocked")
+		return
+	}
+	user.IsBlocked = false
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	ok, err := verifySecondFactor(user.ID, encrypted.String, req.Code)
+	if err != nil {
+		log.Printf("❌ Error verifying 2FA code for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+	if !ok {
+		log.Printf("❌ Invalid 2FA code for user %d", user.ID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	token, err := generateToken(user)
+	if err != nil {
+		log.Printf("❌ Token generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	log.Printf("✅ User completed 2FA login: %s", user.Email)
+	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+}
+
+// verifySecondFactor accepts either a valid TOTP code or an unused recovery code.
+// Recovery codes are consumed atomically so each one works exactly once.
+func verifySecondFactor(userID int, encryptedSecret, code string) (bool, error) {
+	if encryptedSecret != "" {
+		secret, err := decryptTOTPSecret(encryptedSecret)
+		if err != nil {
+			return false, err
+		}
+		if verifyTOTPCode(secret, code, totpNow()) {
+			return true, nil
+		}
+	}
+
+	result, err := db.Exec(`
+		UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected > 0 {
+		log.Printf("🔑 Recovery code used by user %d", userID)
+		return true, nil
+	}
+	return false, nil
+}
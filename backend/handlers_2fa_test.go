@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixTOTPClock pins the TOTP clock so codes are deterministic within a test
+func fixTOTPClock(t *testing.T, at time.Time) {
+	original := totpNow
+	totpNow = func() time.Time { return at }
+	t.Cleanup(func() { totpNow = original })
+}
+
+func setupTwoFactorRouter() *gin.Engine {
+	router := gin.New()
+	router.POST("/api/auth/login", login)
+	router.POST("/api/auth/2fa", verifyTwoFactorLogin)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/profile/2fa/setup", setupTwoFactor)
+	protected.POST("/profile/2fa/enable", enableTwoFactor)
+	protected.DELETE("/profile/2fa", disableTwoFactor)
+	protected.GET("/auth/me", getCurrentUser)
+	return router
+}
+
+func doJSON(router *gin.Engine, method, path, token string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// enableTwoFactorForTest runs setup + enable and returns the secret and recovery codes
+func enableTwoFactorForTest(t *testing.T, router *gin.Engine, token string, now time.Time) (string, []string) {
+	w := doJSON(router, "POST", "/api/profile/2fa/setup", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var setup struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	require.NotEmpty(t, setup.Secret)
+	assert.Contains(t, setup.OTPAuthURI, "otpauth://totp/")
+
+	code, err := generateTOTPCode(setup.Secret, now)
+	require.NoError(t, err)
+
+	w = doJSON(router, "POST", "/api/profile/2fa/enable", token, gin.H{"code": code})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var enabled struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enabled))
+	require.Len(t, enabled.RecoveryCodes, recoveryCodeCount)
+
+	return setup.Secret, enabled.RecoveryCodes
+}
+
+// loginForChallenge performs the password step and returns the 2FA challenge token
+func loginForChallenge(t *testing.T, router *gin.Engine, email, password string) string {
+	w := doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": email, "password": password})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, true, resp["two_factor_required"])
+	assert.NotContains(t, resp, "token", "session token must not be issued before 2FA")
+	challenge, _ := resp["challenge_token"].(string)
+	require.NotEmpty(t, challenge)
+	return challenge
+}
+
+func TestTwoFactorEnable(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	now := time.Unix(1700000000, 0)
+	fixTOTPClock(t, now)
+
+	userID := createTestUser(t, testDB, "totp@example.com", "TOTP User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "totp@example.com"})
+	router := setupTwoFactorRouter()
+
+	t.Run("Enable requires setup first", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/profile/2fa/enable", token, gin.H{"code": "123456"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Bad code does not enable", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/profile/2fa/setup", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(router, "POST", "/api/profile/2fa/enable", token, gin.H{"code": "000000"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var enabled bool
+		testDB.QueryRow("SELECT totp_enabled FROM users WHERE id = ?", userID).Scan(&enabled)
+		assert.False(t, enabled)
+	})
+
+	t.Run("Valid code enables and stores only hashes", func(t *testing.T) {
+		secret, codes := enableTwoFactorForTest(t, router, token, now)
+
+		var storedSecret string
+		var enabled bool
+		err := testDB.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id = ?", userID).Scan(&storedSecret, &enabled)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		assert.NotEqual(t, secret, storedSecret, "secret must be stored encrypted")
+
+		var count int
+		testDB.QueryRow("SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ?", userID).Scan(&count)
+		assert.Equal(t, recoveryCodeCount, count)
+
+		testDB.QueryRow("SELECT COUNT(*) FROM user_recovery_codes WHERE code_hash = ?", codes[0]).Scan(&count)
+		assert.Equal(t, 0, count, "recovery codes must not be stored in plaintext")
+	})
+
+	t.Run("Setup is rejected once enabled", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/profile/2fa/setup", token, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	now := time.Unix(1700000000, 0)
+	fixTOTPClock(t, now)
+
+	userID := createTestUser(t, testDB, "totp@example.com", "TOTP User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "totp@example.com"})
+	router := setupTwoFactorRouter()
+	secret, _ := enableTwoFactorForTest(t, router, token, now)
+
+	t.Run("Challenge token is not a session token", func(t *testing.T) {
+		challenge := loginForChallenge(t, router, "totp@example.com", "password123")
+		w := doJSON(router, "GET", "/api/auth/me", challenge, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Wrong password never reaches 2FA", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": "totp@example.com", "password": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotContains(t, w.Body.String(), "challenge_token")
+	})
+
+	t.Run("Bad code is rejected", func(t *testing.T) {
+		challenge := loginForChallenge(t, router, "totp@example.com", "password123")
+		w := doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": "000000"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Session token cannot be used as challenge", func(t *testing.T) {
+		code, _ := generateTOTPCode(secret, now)
+		w := doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": token, "code": code})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Valid code within skew window logs in", func(t *testing.T) {
+		challenge := loginForChallenge(t, router, "totp@example.com", "password123")
+		code, _ := generateTOTPCode(secret, now.Add(-totpPeriod*time.Second))
+		w := doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": code})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Token string `json:"token"`
+			User  User   `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Token)
+		assert.True(t, resp.User.TwoFactorEnabled)
+
+		w = doJSON(router, "GET", "/api/auth/me", resp.Token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Code outside skew window is rejected", func(t *testing.T) {
+		challenge := loginForChallenge(t, router, "totp@example.com", "password123")
+		code, _ := generateTOTPCode(secret, now.Add(-2*totpPeriod*time.Second))
+		w := doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": code})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestTwoFactorRecoveryCodeSingleUse(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	now := time.Unix(1700000000, 0)
+	fixTOTPClock(t, now)
+
+	userID := createTestUser(t, testDB, "totp@example.com", "TOTP User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "totp@example.com"})
+	router := setupTwoFactorRouter()
+	_, codes := enableTwoFactorForTest(t, router, token, now)
+
+	challenge := loginForChallenge(t, router, "totp@example.com", "password123")
+	w := doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": codes[0]})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Same recovery code a second time must fail
+	challenge = loginForChallenge(t, router, "totp@example.com", "password123")
+	w = doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": codes[0]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Other recovery codes still work
+	w = doJSON(router, "POST", "/api/auth/2fa", "", gin.H{"challenge_token": challenge, "code": codes[1]})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var used int
+	testDB.QueryRow("SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NOT NULL", userID).Scan(&used)
+	assert.Equal(t, 2, used)
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	now := time.Unix(1700000000, 0)
+	fixTOTPClock(t, now)
+
+	userID := createTestUser(t, testDB, "totp@example.com", "TOTP User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "totp@example.com"})
+	router := setupTwoFactorRouter()
+	secret, _ := enableTwoFactorForTest(t, router, token, now)
+	code, _ := generateTOTPCode(secret, now)
+
+	w := doJSON(router, "DELETE", "/api/profile/2fa", token, gin.H{"password": "wrongpassword", "code": code})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON(router, "DELETE", "/api/profile/2fa", token, gin.H{"password": "password123", "code": "000000"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON(router, "DELETE", "/api/profile/2fa", token, gin.H{"password": "password123", "code": code})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var enabled bool
+	var storedSecret *string
+	testDB.QueryRow("SELECT totp_enabled, totp_secret FROM users WHERE id = ?", userID).Scan(&enabled, &storedSecret)
+	assert.False(t, enabled)
+	assert.Nil(t, storedSecret)
+
+	var count int
+	testDB.QueryRow("SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ?", userID).Scan(&count)
+	assert.Equal(t, 0, count)
+
+	// Plain login works again
+	w = doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": "totp@example.com", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"token"`)
+	assert.NotContains(t, w.Body.String(), "challenge_token")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -9,8 +10,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// passwordResetsPerHour caps the reset emails one account gets per hour, whichever IPs ask for
+// them; the per-IP auth limiter alone lets a botnet flood an inbox
+const passwordResetsPerHour = 3
+
+// invalidateResetTokens marks the user's outstanding (unused, unexpired) reset tokens used, so
+// only the newest link or none works
+func invalidateResetTokens(exec sqlExecer, userID int, now time.Time) error {
+	_, err := exec.Exec(`UPDATE password_reset_tokens SET used = 1 WHERE user_id = ? AND used = 0 AND expires_at > ?`, userID, now)
+	return err
+}
+
 // VerifyEmail handles email verification via token
 func VerifyEmail(c *gin.Context) {
+	ctx := c.Request.Context()
 	token := c.Query("token")
 	if token == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token is required"})
@@ -24,7 +37,7 @@ func VerifyEmail(c *gin.Context) {
 		ExpiresAt time.Time
 	}
 
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT id, user_id, expires_at
 		FROM email_verification_tokens
 		WHERE token = ?
@@ -46,8 +59,17 @@ func VerifyEmail(c *gin.Context) {
 		return
 	}
 
+	// Verification, token removal and the welcome email job commit together
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Error starting verification transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	defer tx.Rollback()
+
 	// Update user's email_verified status
-	_, err = db.Exec(`UPDATE users SET email_verified = 1 WHERE id = ?`, tokenData.UserID)
+	_, err = tx.ExecContext(ctx, `UPDATE users SET email_verified = 1 WHERE id = ?`, tokenData.UserID)
 	if err != nil {
 		log.Printf("Error updating user email_verified status: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
@@ -55,27 +77,40 @@ func VerifyEmail(c *gin.Context) {
 	}
 
 	// Delete the used token
-	_, err = db.Exec(`DELETE FROM email_verification_tokens WHERE id = ?`, tokenData.ID)
+	_, err = tx.ExecContext(ctx, `DELETE FROM email_verification_tokens WHERE id = ?`, tokenData.ID)
 	if err != nil {
 		log.Printf("Warning: Could not delete verification token: %v", err)
 	}
 
-	// Send welcome email if email service is available
+	// Queue the welcome email if email service is available
 	if emailService != nil {
 		var user User
-		err = db.QueryRow(`SELECT id, email, name FROM users WHERE id = ?`, tokenData.UserID).
+		err = tx.QueryRowContext(ctx, `SELECT id, email, name FROM users WHERE id = ?`, tokenData.UserID).
 			Scan(&user.ID, &user.Email, &user.Name)
 		if err == nil {
-			go emailService.SendWelcomeEmail(user.Email, user.Name)
+			_, err = enqueueJob(tx, JobSendWelcomeEmail, emailJobPayload{Email: user.Email, Name: user.Name})
+		}
+		if err != nil {
+			log.Printf("Warning: Could not queue welcome email: %v", err)
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing email verification: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	backgroundJobs.Notify()
+
+	publishDraftsAfterVerification(ctx, tokenData.UserID)
+
 	log.Printf("✓ Email verified for user ID: %d", tokenData.UserID)
 	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
 }
 
 // ResendVerificationEmail resends the verification email to a user
 func ResendVerificationEmail(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req struct {
 		Email string `json:"email" binding:"required,email"`
 	}
@@ -87,11 +122,10 @@ func ResendVerificationEmail(c *gin.Context) {
 
 	// Find user by email
 	var user User
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT id, email, name, email_verified
 		FROM users
-		WHERE email = ?
-	`, req.Email).Scan(&user.ID, &user.Email, &user.Name, &user.EmailVerified)
+		WHERE `+emailLookup, emailLookupArgs(req.Email)...).Scan(&user.ID, &user.Email, &user.Name, nullable(&user.EmailVerified))
 
 	if err == sql.ErrNoRows {
 		// Don't reveal if email exists or not (security)
@@ -117,7 +151,7 @@ func ResendVerificationEmail(c *gin.Context) {
 	}
 
 	// Delete any existing verification tokens for this user
-	_, err = db.Exec(`DELETE FROM email_verification_tokens WHERE user_id = ?`, user.ID)
+	_, err = db.ExecContext(ctx, `DELETE FROM email_verification_tokens WHERE user_id = ?`, user.ID)
 	if err != nil {
 		log.Printf("Warning: Could not delete old verification tokens: %v", err)
 	}
@@ -131,8 +165,8 @@ func ResendVerificationEmail(c *gin.Context) {
 	}
 
 	// Store token in database (expires in 24 hours)
-	expiresAt := time.Now().Add(24 * time.Hour)
-	_, err = db.Exec(`
+	expiresAt := time.Now().Add(appConfig.VerificationTokenTTL)
+	_, err = db.ExecContext(ctx, `
 		INSERT INTO email_verification_tokens (user_id, token, expires_at)
 		VALUES (?, ?, ?)
 	`, user.ID, token, expiresAt)
@@ -150,12 +184,17 @@ func ResendVerificationEmail(c *gin.Context) {
 		return
 	}
 
+	if err := clearVerificationEmailFailed(ctx, user.Email); err != nil {
+		log.Printf("⚠️  Failed to clear verification_email_failed for user %d: %v", user.ID, err)
+	}
+
 	log.Printf("✓ Verification email resent to: %s", user.Email)
 	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
 }
 
 // ForgotPassword handles password reset requests
 func ForgotPassword(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
@@ -164,7 +203,7 @@ func ForgotPassword(c *gin.Context) {
 
 	// Find user by email
 	var user User
-	err := db.QueryRow(`SELECT id, email, name FROM users WHERE email = ?`, req.Email).
+	err := db.QueryRowContext(ctx, `SELECT id, email, name FROM users WHERE `+emailLookup, emailLookupArgs(req.Email)...).
 		Scan(&user.ID, &user.Email, &user.Name)
 
 	if err == sql.ErrNoRows {
@@ -184,6 +223,21 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
+	// Throttled requests get the usual answer, so the limit doesn't reveal the account either.
+	// Invalidated tokens are kept (see resetTokenRetention), so they still count.
+	var recent int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND created_at > datetime('now', '-1 hour')`,
+		user.ID).Scan(&recent); err != nil {
+		log.Printf("Error counting reset tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if recent >= passwordResetsPerHour {
+		log.Printf("⚠️  Password reset throttled for user %d (%d requests in the last hour)", user.ID, recent)
+		c.JSON(http.StatusOK, gin.H{"message": "If the email exists, a password reset link has been sent"})
+		return
+	}
+
 	// Generate password reset token
 	token, err := generateEmailToken()
 	if err != nil {
@@ -192,12 +246,29 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	// Store token in database (expires in 1 hour)
-	expiresAt := time.Now().Add(1 * time.Hour)
-	_, err = db.Exec(`
+	// Store token in database (expires in 1 hour), retiring the links sent before it
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	now := time.Now()
+	if err := invalidateResetTokens(tx, user.ID, now); err != nil {
+		log.Printf("Error invalidating reset tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+	expiresAt := now.Add(appConfig.PasswordResetTokenTTL)
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO password_reset_tokens (user_id, token, expires_at)
 		VALUES (?, ?, ?)
 	`, user.ID, token, expiresAt)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		log.Printf("Error storing reset token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
@@ -218,6 +289,7 @@ func ForgotPassword(c *gin.Context) {
 
 // ResetPassword handles password reset via token
 func ResetPassword(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
@@ -232,7 +304,7 @@ func ResetPassword(c *gin.Context) {
 		Used      bool
 	}
 
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT id, user_id, expires_at, used
 		FROM password_reset_tokens
 		WHERE token = ?
@@ -262,24 +334,51 @@ func ResetPassword(c *gin.Context) {
 
 	// Hash the new password
 	hashedPassword, err := hashPassword(req.NewPassword)
+	if errors.Is(err, ErrPasswordTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
 		return
 	}
 
-	// Update user's password
-	_, err = db.Exec(`UPDATE users SET password = ? WHERE id = ?`, hashedPassword, tokenData.UserID)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error updating password: %v", err)
+		log.Printf("Error starting transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
 		return
 	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
 
-	// Mark token as used
-	_, err = db.Exec(`UPDATE password_reset_tokens SET used = 1 WHERE id = ?`, tokenData.ID)
+	// Mark token as used; a concurrent reset with the same token may have beaten us to it
+	result, err := tx.ExecContext(ctx, `UPDATE password_reset_tokens SET used = 1 WHERE id = ? AND used = 0`, tokenData.ID)
 	if err != nil {
-		log.Printf("Warning: Could not mark reset token as used: %v", err)
+		log.Printf("Error marking reset token as used: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset token has already been used"})
+		return
+	}
+
+	// Update user's password and retire every other link sent to them
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password = ? WHERE id = ?`, hashedPassword, tokenData.UserID); err != nil {
+		log.Printf("Error updating password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if err := invalidateResetTokens(tx, tokenData.UserID, time.Now()); err != nil {
+		log.Printf("Error invalidating reset tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing password reset: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
 	}
 
 	log.Printf("✓ Password reset successful for user ID: %d", tokenData.UserID)
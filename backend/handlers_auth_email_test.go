@@ -2,13 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mailgun/mailgun-go/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -347,3 +350,96 @@ func TestResetPassword(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w5.Code)
 }
+
+func TestPasswordResetTokenInvalidation(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	sent := 0
+	useFakeMailer(t, func(ctx context.Context, message *mailgun.Message) (string, error) {
+		sent++
+		return fmt.Sprintf("<%d@mg.example.com>", sent), nil
+	})
+
+	router := gin.New()
+	router.POST("/api/forgot-password", ForgotPassword)
+	router.POST("/api/reset-password", ResetPassword)
+
+	userID := createTestUser(t, testDB, "test@example.com", "Test User", "oldpassword123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other User", "password123", false)
+
+	forgot := func(email string) {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/forgot-password", "", ForgotPasswordRequest{Email: email})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "If the email exists")
+	}
+	tokens := func(userID int64) []string {
+		t.Helper()
+		rows, err := testDB.Query(`SELECT token FROM password_reset_tokens WHERE user_id = ? ORDER BY id`, userID)
+		require.NoError(t, err)
+		defer rows.Close()
+		var tokens []string
+		for rows.Next() {
+			var token string
+			require.NoError(t, rows.Scan(&token))
+			tokens = append(tokens, token)
+		}
+		return tokens
+	}
+	reset := func(token, password string) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", "/api/reset-password", "", ResetPasswordRequest{Token: token, NewPassword: password})
+	}
+
+	t.Run("A newer request retires the older link", func(t *testing.T) {
+		forgot("test@example.com")
+		forgot("Test@Example.com")
+		issued := tokens(userID)
+		require.Len(t, issued, 2)
+
+		w := reset(issued[0], "newpassword123")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "already been used")
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND used = 0`, userID))
+	})
+
+	t.Run("A successful reset kills every outstanding link", func(t *testing.T) {
+		forgot("other@example.com")
+		_, err := testDB.Exec(`INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (?, 'stray-token', ?)`,
+			userID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		issued := tokens(userID)
+
+		w := reset(issued[1], "newpassword123")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		for _, token := range issued {
+			w := reset(token, "hijacked12345")
+			assert.Equal(t, http.StatusBadRequest, w.Code, token)
+		}
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND used = 0`, userID))
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND used = 0`, otherID),
+			"other accounts' links are untouched")
+	})
+
+	t.Run("Requests are throttled per account", func(t *testing.T) {
+		_, err := testDB.Exec(`DELETE FROM password_reset_tokens WHERE user_id = ?`, userID)
+		require.NoError(t, err)
+		before := sent
+		for i := 0; i < passwordResetsPerHour+2; i++ {
+			forgot("test@example.com")
+		}
+		assert.Equal(t, before+passwordResetsPerHour, sent, "requests over the limit send nothing")
+		assert.Len(t, tokens(userID), passwordResetsPerHour)
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = ? AND used = 0`, userID),
+			"the last link sent still works")
+
+		forgot("other@example.com")
+		assert.Equal(t, before+passwordResetsPerHour+1, sent, "other accounts keep their own allowance")
+
+		_, err = testDB.Exec(`UPDATE password_reset_tokens SET created_at = datetime('now', '-2 hours') WHERE user_id = ?`, userID)
+		require.NoError(t, err)
+		forgot("test@example.com")
+		assert.Equal(t, before+passwordResetsPerHour+2, sent, "the allowance comes back after an hour")
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -29,7 +28,7 @@ func TestMain(m *testing.M) {
 
 	// Speed up tests by reducing bcrypt cost (4 instead of 14)
 	// This makes password hashing ~1000x faster in tests
-	bcryptCost = 4
+	appConfig.BcryptCost = 4
 
 	// Clean up any existing test database
 	os.Remove(testDBFile)
@@ -44,15 +43,20 @@ func TestMain(m *testing.M) {
 }
 
 // setupTestDB creates a fresh test database for each test
-func setupTestDB(t *testing.T) *sql.DB {
+func setupTestDB(t testing.TB) *sql.DB {
 	testDBMutex.Lock()
 	defer testDBMutex.Unlock()
 
 	// Remove existing test DB
 	os.Remove(testDBFile)
-
-	// Create new test database
-	testDB, err := sql.Open("sqlite3", testDBFile)
+	// Cached listings from a previous test's database must not leak into this one
+	eventListCache.Invalidate()
+	publicSitemap.Invalidate()
+	moderationTerms.Invalidate()
+
+	// Create new test database. Like production, writers wait for the lock instead of failing
+	// (see databaseDSN); WAL is left out so removing the file is enough to start fresh.
+	testDB, err := sql.Open("sqlite3", testDBFile+"?_busy_timeout=5000&_txlock=immediate")
 	require.NoError(t, err, "Failed to open test database")
 
 	// Verify we're using the test database file
@@ -71,11 +75,26 @@ func setupTestDB(t *testing.T) *sql.DB {
 		name TEXT NOT NULL,
 		bio TEXT,
 		phone TEXT,
+		threema TEXT,
 		languages TEXT,
 		is_admin BOOLEAN DEFAULT 0,
 		is_blocked BOOLEAN DEFAULT 0,
-		email_verified BOOLEAN DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		email_verified INTEGER DEFAULT 0,
+		totp_secret TEXT,
+		totp_enabled INTEGER DEFAULT 0,
+		profile_visibility TEXT DEFAULT 'public',
+		show_email INTEGER DEFAULT 0,
+		birth_year INTEGER,
+		gender TEXT DEFAULT 'unspecified',
+		timezone TEXT,
+		digest_emails INTEGER NOT NULL DEFAULT 1,
+		events_paused INTEGER NOT NULL DEFAULT 0,
+		verification_email_failed INTEGER NOT NULL DEFAULT 0,
+		registration_ip TEXT,
+		blocked_until DATETIME,
+		block_reason TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME
 	)`)
 	require.NoError(t, err, "Failed to create users table")
 
@@ -86,6 +105,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 		user_id INTEGER,
 		title TEXT NOT NULL,
 		description TEXT NOT NULL,
+		description_format TEXT NOT NULL DEFAULT 'plain',
 		category TEXT NOT NULL,
 		latitude REAL NOT NULL,
 		longitude REAL NOT NULL,
@@ -102,11 +122,36 @@ func setupTestDB(t *testing.T) *sql.DB {
 		slug TEXT,
 		comments_enabled BOOLEAN DEFAULT 1,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		hide_organizer_until_joined BOOLEAN DEFAULT 0,
-		hide_participants_until_joined BOOLEAN DEFAULT 1,
-		require_verified_to_join BOOLEAN DEFAULT 0,
-		require_verified_to_view BOOLEAN DEFAULT 0,
-		allow_unregistered_users BOOLEAN DEFAULT 0,
+		hide_organizer_until_joined INTEGER DEFAULT 0,
+		hide_participants_until_joined INTEGER DEFAULT 1,
+		require_verified_to_join INTEGER DEFAULT 0,
+		require_verified_to_view INTEGER DEFAULT 0,
+		allow_unregistered_users INTEGER DEFAULT 1,
+		require_birth_year INTEGER DEFAULT 0,
+		hidden_pending_review INTEGER NOT NULL DEFAULT 0,
+		hidden_sources INTEGER NOT NULL DEFAULT 0,
+		cancelled_at DATETIME,
+		participant_count INTEGER NOT NULL DEFAULT 0,
+		interested_count INTEGER NOT NULL DEFAULT 0,
+		location_name TEXT NOT NULL DEFAULT '',
+		address TEXT NOT NULL DEFAULT '',
+		anonymized_at DATETIME,
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		published INTEGER NOT NULL DEFAULT 1,
+		draft_reminded_at DATETIME,
+		fingerprint TEXT,
+		updated_at DATETIME,
+		group_id INTEGER REFERENCES groups (id) ON DELETE SET NULL,
+		price_amount INTEGER,
+		price_currency TEXT NOT NULL DEFAULT 'CHF',
+		payment_note TEXT NOT NULL DEFAULT '',
+		max_joins_per_network INTEGER NOT NULL DEFAULT 0,
+		reserved_spots INTEGER NOT NULL DEFAULT 0,
+		joins_paused INTEGER NOT NULL DEFAULT 0,
+		is_featured INTEGER NOT NULL DEFAULT 0,
+		featured_until DATETIME,
+		join_deadline DATETIME,
+		deadline_reminded_at DATETIME,
 		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
 	)`)
 	require.NoError(t, err, "Failed to create events table")
@@ -118,12 +163,30 @@ func setupTestDB(t *testing.T) *sql.DB {
 		event_id INTEGER NOT NULL,
 		user_id INTEGER NOT NULL,
 		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		attendance TEXT,
+		attendance_marked_at DATETIME,
+		attendance_disputed INTEGER DEFAULT 0,
+		checked_in_at DATETIME,
+		share_contact INTEGER NOT NULL DEFAULT 0,
+		display_alias TEXT,
 		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
 		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
 		UNIQUE(event_id, user_id)
 	)`)
 	require.NoError(t, err, "Failed to create event_participants table")
 
+	// Create event_checkin_codes table
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_checkin_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create event_checkin_codes table")
+
 	// Create email_verification_tokens table
 	_, err = testDB.Exec(`
 	CREATE TABLE IF NOT EXISTS email_verification_tokens (
@@ -173,16 +236,361 @@ func setupTestDB(t *testing.T) *sql.DB {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME,
 		is_deleted BOOLEAN DEFAULT 0,
+		pending_review INTEGER DEFAULT 0,
+		revision INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
 		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
 	)`)
 	require.NoError(t, err, "Failed to create event_comments table")
 
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS comment_read_state (
+		user_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		last_read_comment_id INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, event_id),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create comment_read_state table")
+
+	// Create event_reports table
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		reporter_id INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		description TEXT,
+		status TEXT DEFAULT 'pending',
+		reviewed_by INTEGER,
+		reviewed_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (reporter_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (reviewed_by) REFERENCES users (id)
+	)`)
+	require.NoError(t, err, "Failed to create event_reports table")
+
+	// Create user_recovery_codes table
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS user_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create user_recovery_codes table")
+
+	// Create event_feedback table
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_feedback (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		comment TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	require.NoError(t, err, "Failed to create event_feedback table")
+
+	// Create webhooks tables
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT NOT NULL,
+		created_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL
+	)`)
+	require.NoError(t, err, "Failed to create webhooks table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		succeeded BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create webhook_deliveries table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS broadcast_routes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		category TEXT NOT NULL,
+		notifier TEXT NOT NULL,
+		target TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(category, notifier, target)
+	)`)
+	require.NoError(t, err, "Failed to create broadcast_routes table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS activity_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		actor_id INTEGER NOT NULL,
+		verb TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create activity_log table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL DEFAULT '{}',
+		read_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create notifications table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status_code INTEGER,
+		response TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, idempotency_key),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create idempotency_keys table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS moderation_terms (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		term TEXT UNIQUE NOT NULL,
+		action TEXT NOT NULL DEFAULT 'reject',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err, "Failed to create moderation_terms table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS moderation_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content_type TEXT NOT NULL,
+		content_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		violations TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reviewed_by INTEGER,
+		reviewed_at DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create moderation_queue table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS admin_audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		admin_id INTEGER,
+		action TEXT NOT NULL,
+		target_user_id INTEGER,
+		details TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err, "Failed to create admin_audit_log table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS organizer_digests (
+		user_id INTEGER NOT NULL,
+		month TEXT NOT NULL,
+		sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, month),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create organizer_digests table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS announcements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message TEXT NOT NULL,
+		translations TEXT NOT NULL DEFAULT '{}',
+		level TEXT NOT NULL DEFAULT 'info',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME,
+		created_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME
+	)`)
+	require.NoError(t, err, "Failed to create announcements table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_questions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		text TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT 'text',
+		required INTEGER NOT NULL DEFAULT 0,
+		retired_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create event_questions table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_question_answers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		answer TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (question_id) REFERENCES event_questions (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(question_id, user_id)
+	)`)
+	require.NoError(t, err, "Failed to create event_question_answers table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_interest (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		notified_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	require.NoError(t, err, "Failed to create event_interest table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_hides (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	require.NoError(t, err, "Failed to create event_hides table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		run_at DATETIME NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'pending',
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err, "Failed to create jobs table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS email_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER,
+		recipient TEXT NOT NULL,
+		email_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		provider_message_id TEXT,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create email_log table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		slug TEXT UNIQUE NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		owner_id INTEGER NOT NULL,
+		join_policy TEXT NOT NULL DEFAULT 'open',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME,
+		FOREIGN KEY (owner_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create groups table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'member',
+		notify INTEGER NOT NULL DEFAULT 1,
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id),
+		FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create group_members table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS participation_exits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		reason TEXT,
+		note TEXT NOT NULL DEFAULT '',
+		share_identity INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create participation_exits table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_transfers (
+		event_id INTEGER PRIMARY KEY,
+		from_user_id INTEGER NOT NULL,
+		to_user_id INTEGER NOT NULL,
+		keep_as_participant INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (from_user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (to_user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create event_transfers table")
+
+	_, err = testDB.Exec(`
+	CREATE TABLE IF NOT EXISTS event_attributes (
+		event_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (event_id, key),
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	require.NoError(t, err, "Failed to create event_attributes table")
+
 	return testDB
 }
 
 // cleanupTestDB closes and removes the test database
 func cleanupTestDB(testDB *sql.DB) {
+	// Moderation emails sent in the background still read the database
+	pendingModerationEmails.Wait()
 	if testDB != nil {
 		testDB.Close()
 	}
@@ -955,10 +1363,14 @@ func TestAdminGetUsers(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var users []User
-	err := json.Unmarshal(w.Body.Bytes(), &users)
+	var response struct {
+		Users []User `json:"users"`
+		Total int    `json:"total"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.GreaterOrEqual(t, len(users), 3)
+	assert.GreaterOrEqual(t, len(response.Users), 3)
+	assert.Equal(t, len(response.Users), response.Total)
 }
 
 func TestBlockUser(t *testing.T) {
@@ -990,170 +1402,6 @@ func TestBlockUser(t *testing.T) {
 	assert.True(t, isBlocked)
 }
 
-// ============================================================================
-// INTEGRATION TEST: Full User Journey
-// ============================================================================
-
-func TestFullUserJourney(t *testing.T) {
-	testDB := setupTestDB(t)
-	defer cleanupTestDB(testDB)
-	db = testDB
-
-	router := gin.New()
-	setupRoutes(router)
-
-	// 1. Register a new user
-	t.Run("Register", func(t *testing.T) {
-		payload := map[string]interface{}{
-			"email":    "journey@example.com",
-			"password": "password123",
-			"name":     "Journey User",
-		}
-		body, _ := json.Marshal(payload)
-
-		req, _ := http.NewRequest("POST", "/api/register", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusCreated, w.Code)
-	})
-
-	// 2. Login
-	var authToken string
-	t.Run("Login", func(t *testing.T) {
-		payload := map[string]string{
-			"email":    "journey@example.com",
-			"password": "password123",
-		}
-		body, _ := json.Marshal(payload)
-
-		req, _ := http.NewRequest("POST", "/api/login", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
-
-		// Extract token from response body
-		assert.Contains(t, response, "token")
-		authToken = response["token"].(string)
-		assert.NotEmpty(t, authToken, "token should not be empty")
-	})
-
-	// 3. Verify email (for testing - manually set email_verified to true)
-	t.Run("Verify Email", func(t *testing.T) {
-		_, err := testDB.Exec("UPDATE users SET email_verified = 1 WHERE email = ?", "journey@example.com")
-		require.NoError(t, err)
-	})
-
-	// 4. Update profile with languages
-	t.Run("Update Profile", func(t *testing.T) {
-		payload := map[string]interface{}{
-			"name":      "Journey User Updated",
-			"bio":       "I love meeting new people",
-			"languages": "en,de,fr",
-		}
-		body, _ := json.Marshal(payload)
-
-		req, _ := http.NewRequest("PUT", "/api/profile", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+authToken)
-
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-	})
-
-	// 5. Create an event
-	var eventID float64
-	t.Run("Create Event", func(t *testing.T) {
-		future := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
-
-		payload := map[string]interface{}{
-			"title":                   "Coffee Meetup",
-			"description":             "Let's grab coffee and chat",
-			"category":                "social_drinks",
-			"latitude":                46.8805,
-			"longitude":               8.6444,
-			"start_time":              future,
-			"creator_name":            "Journey User",
-			"max_participants":        5,
-			"gender_restriction":      "any",
-			"allow_unregistered_users": true,
-		}
-		body, _ := json.Marshal(payload)
-
-		req, _ := http.NewRequest("POST", "/api/events", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+authToken)
-
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusCreated, w.Code)
-
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		require.NoError(t, err)
-		eventID = response["id"].(float64)
-	})
-
-	// 5. View all events
-	t.Run("View Events", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/api/events", nil)
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-
-		var events []Event
-		err := json.Unmarshal(w.Body.Bytes(), &events)
-		require.NoError(t, err)
-		assert.GreaterOrEqual(t, len(events), 1)
-	})
-
-	// 6. Delete event
-	t.Run("Delete Event", func(t *testing.T) {
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/events/%d", int(eventID)), nil)
-		req.Header.Set("Authorization", "Bearer "+authToken)
-
-		w := httptest.NewRecorder()
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusOK, w.Code)
-	})
-}
-
-// Helper function to setup all routes for integration testing
-func setupRoutes(router *gin.Engine) {
-	router.POST("/api/register", register)
-	router.POST("/api/login", login)
-	router.GET("/api/user", authMiddleware(), getCurrentUser)
-	router.GET("/api/profile", authMiddleware(), getCurrentUser)
-	router.PUT("/api/profile", authMiddleware(), updateProfile)
-	router.GET("/api/profile/:id", getUserProfile)
-	router.GET("/api/events", getEvents)
-	router.GET("/api/events/:id", getEvent)
-	router.POST("/api/events", authMiddleware(), createEvent)
-	router.PUT("/api/events/:id", authMiddleware(), updateEvent)
-	router.DELETE("/api/events/:id", authMiddleware(), deleteEvent)
-	router.POST("/api/events/:id/join", authMiddleware(), joinEvent)
-	router.DELETE("/api/events/:id/leave", authMiddleware(), leaveEvent)
-	router.GET("/api/events/:id/participants", getEventParticipants)
-
-	router.GET("/api/admin/users", adminGetUsers)
-	router.PUT("/api/admin/users/:id/block", adminBlockUser)
-	router.PUT("/api/admin/users/:id/unblock", adminUnblockUser)
-}
-
 // ============================================================================
 // ADDITIONAL COVERAGE TESTS
 // ============================================================================
@@ -1207,10 +1455,14 @@ func TestGetEventParticipants(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var participants []User
-	err = json.Unmarshal(w.Body.Bytes(), &participants)
+	var resp struct {
+		Items []ParticipantView `json:"items"`
+		Total int               `json:"total"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Equal(t, 1, len(participants))
+	assert.Equal(t, 1, len(resp.Items))
+	assert.Equal(t, 1, resp.Total)
 }
 
 func TestEventFiltering(t *testing.T) {
@@ -1522,7 +1774,7 @@ func TestSearchPlaces(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// This will hit the external API, so just check for valid response
-		assert.True(t, w.Code == http.StatusOK || w.Code == http.StatusInternalServerError)
+		assert.True(t, w.Code == http.StatusOK || w.Code == http.StatusServiceUnavailable)
 	})
 }
 
@@ -1549,11 +1801,15 @@ func TestAdminGetAllEvents(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var events []Event
-	err := json.Unmarshal(w.Body.Bytes(), &events)
+	var response struct {
+		Events []Event `json:"events"`
+		Total  int     `json:"total"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	// Admin sees all events (including past ones)
-	assert.GreaterOrEqual(t, len(events), 0)
+	assert.Len(t, response.Events, 1)
+	assert.Equal(t, 1, response.Total)
 }
 
 func TestAdminDeleteEvent(t *testing.T) {
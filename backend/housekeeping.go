@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cleanupBatchSize    = 500                // Rows per DELETE/UPDATE so normal traffic isn't locked out
+	resetTokenRetention = 7 * 24 * time.Hour // Used or expired reset tokens are kept this long for support questions
+)
+
+// CleanupResult counts what one housekeeping run removed or anonymized
+type CleanupResult struct {
+	VerificationTokens int64     `json:"verification_tokens_deleted"`
+	ResetTokens        int64     `json:"reset_tokens_deleted"`
+	ActivityEntries    int64     `json:"activity_entries_deleted"`
+	Notifications      int64     `json:"notifications_deleted"`
+	IdempotencyKeys    int64     `json:"idempotency_keys_deleted"`
+	AnonymizedEvents   int64     `json:"events_anonymized"`
+	DraftReminders     int64     `json:"draft_reminders_sent"`
+	DeadlineReminders  int64     `json:"deadline_reminders_sent"`
+	DraftsDeleted      int64     `json:"drafts_deleted"`
+	Announcements      int64     `json:"announcements_deleted"`
+	Jobs               int64     `json:"jobs_deleted"`
+	EmailLog           int64     `json:"email_log_deleted"`
+	SuspensionsLifted  int64     `json:"suspensions_lifted"`
+	FeaturesCleared    int64     `json:"features_cleared"`
+	StartedAt          time.Time `json:"started_at"`
+	DurationMs         int64     `json:"duration_ms"`
+}
+
+// housekeepingStats accumulates run counters for the metrics endpoint
+type housekeepingStats struct {
+	mu        sync.Mutex
+	runs      int64
+	failures  int64
+	lastRun   *CleanupResult
+	lastError string
+	totals    CleanupResult
+}
+
+var housekeeping housekeepingStats
+
+// cleanupMu keeps the scheduled run and a manual trigger from overlapping. It is per process:
+// several instances each run their own cleanup, which is safe since every step is idempotent.
+var cleanupMu sync.Mutex
+
+// runCleanup purges expired verification tokens, old reset tokens, activity and notifications older than 90 days, idempotency keys
+// older than a day, drafts of unverified organizers older than draftRetention (after a reminder), ended announcements, reminds interested users of join deadlines a day ahead, finished jobs, lifts expired suspensions, unfeatures events whose feature ran out and, when EVENT_RETENTION_MONTHS is set, anonymizes events that started before the retention
+// window. Safe to run alongside traffic.
+func runCleanup(now time.Time) (CleanupResult, error) {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+
+	result := CleanupResult{StartedAt: now}
+	var err error
+	defer func() {
+		result.DurationMs = time.Since(now).Milliseconds()
+		housekeeping.record(result, err)
+	}()
+
+	result.VerificationTokens, err = deleteInBatches("email_verification_tokens", `expires_at < ?`, now)
+	if err != nil {
+		return result, fmt.Errorf("verification tokens: %w", err)
+	}
+
+	retention := fmt.Sprintf("-%d seconds", int(resetTokenRetention.Seconds()))
+	result.ResetTokens, err = deleteInBatches("password_reset_tokens",
+		`expires_at < ? OR (used = 1 AND created_at < datetime('now', ?))`, now.Add(-resetTokenRetention), retention)
+	if err != nil {
+		return result, fmt.Errorf("reset tokens: %w", err)
+	}
+
+	result.ActivityEntries, err = deleteInBatches("activity_log", `created_at < datetime('now', ?)`,
+		fmt.Sprintf("-%d seconds", int(activityRetention.Seconds())))
+	if err != nil {
+		return result, fmt.Errorf("activity log: %w", err)
+	}
+
+	result.Notifications, err = deleteInBatches("notifications", `created_at < ?`, now.Add(-notificationRetention).UTC())
+	if err != nil {
+		return result, fmt.Errorf("notifications: %w", err)
+	}
+
+	result.IdempotencyKeys, err = deleteInBatches("idempotency_keys", `created_at < datetime('now', ?)`,
+		fmt.Sprintf("-%d seconds", int(idempotencyKeyTTL.Seconds())))
+	if err != nil {
+		return result, fmt.Errorf("idempotency keys: %w", err)
+	}
+
+	result.DraftReminders, err = remindStaleDrafts(now)
+	if err != nil {
+		return result, fmt.Errorf("draft reminders: %w", err)
+	}
+
+	result.DeadlineReminders, err = remindJoinDeadlines(now)
+	if err != nil {
+		return result, fmt.Errorf("join deadline reminders: %w", err)
+	}
+
+	// Only drafts whose creator was warned at least draftReminderBefore ago
+	result.DraftsDeleted, err = deleteInBatches("events", `published = 0 AND created_at < ? AND draft_reminded_at < ?`,
+		now.Add(-draftRetention).UTC(), now.Add(-draftReminderBefore).UTC())
+	if err != nil {
+		return result, fmt.Errorf("stale drafts: %w", err)
+	}
+	if result.DraftsDeleted > 0 {
+		eventListCache.Invalidate()
+	}
+
+	result.Announcements, err = deleteInBatches("announcements", `ends_at < ?`, now.UTC())
+	if err != nil {
+		return result, fmt.Errorf("announcements: %w", err)
+	}
+	if result.Announcements > 0 {
+		announcements.Invalidate()
+	}
+
+	result.Jobs, err = deleteInBatches("jobs", `status = ? AND updated_at < ?`, JobStatusDone, now.Add(-jobRetention).UTC())
+	if err != nil {
+		return result, fmt.Errorf("jobs: %w", err)
+	}
+
+	result.EmailLog, err = deleteInBatches("email_log", `created_at < ?`, now.Add(-emailLogRetention).UTC())
+	if err != nil {
+		return result, fmt.Errorf("email log: %w", err)
+	}
+
+	result.SuspensionsLifted, err = liftExpiredSuspensions(now)
+	if err != nil {
+		return result, fmt.Errorf("suspensions: %w", err)
+	}
+
+	result.FeaturesCleared, err = clearExpiredFeatures(now)
+	if err != nil {
+		return result, fmt.Errorf("featured events: %w", err)
+	}
+	if result.FeaturesCleared > 0 {
+		eventListCache.Invalidate()
+		publicStats.Invalidate()
+	}
+
+	if appConfig.EventRetentionMonths > 0 {
+		result.AnonymizedEvents, err = anonymizeOldEvents(appConfig.EventRetentionMonths)
+		if err != nil {
+			return result, fmt.Errorf("event anonymization: %w", err)
+		}
+	}
+
+	log.Printf("🧹 Cleanup: %d verification tokens, %d reset tokens, %d activity entries, %d notifications, %d idempotency keys, %d drafts deleted, %d announcements, %d finished jobs, %d email log entries, %d suspensions lifted, %d features cleared, %d events anonymized",
+		result.VerificationTokens, result.ResetTokens, result.ActivityEntries, result.Notifications, result.IdempotencyKeys, result.DraftsDeleted, result.Announcements, result.Jobs, result.EmailLog, result.SuspensionsLifted, result.FeaturesCleared, result.AnonymizedEvents)
+	return result, nil
+}
+
+// deleteInBatches deletes rows of table matching where, cleanupBatchSize at a time
+func deleteInBatches(table, where string, args ...interface{}) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s LIMIT %d)`, table, table, where, cleanupBatchSize)
+	var total int64
+	for {
+		result, err := db.Exec(query, args...)
+		if err != nil {
+			return total, err
+		}
+		n, _ := result.RowsAffected()
+		total += n
+		if n < cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// anonymizeOldEvents strips personal text from events that started more than months ago.
+// Title, category, place and attendance stay for statistics; descriptions, the organizer's
+// display name, the street address, comments and feedback texts are cleared.
+func anonymizeOldEvents(months int) (int64, error) {
+	cutoff := fmt.Sprintf("-%d months", months)
+	var total int64
+	for {
+		rows, err := db.Query(`
+			SELECT id FROM events
+			WHERE anonymized_at IS NULL AND start_time < datetime('now', ?)
+			LIMIT ?
+		`, cutoff, cleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		for _, id := range ids {
+			if err := anonymizeEvent(id); err != nil {
+				return total, fmt.Errorf("event %d: %w", id, err)
+			}
+			total++
+		}
+		if len(ids) < cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func anonymizeEvent(id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	for _, query := range []string{
+		`UPDATE events SET description = '', creator_name = '', address = '', anonymized_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		`UPDATE event_comments SET comment = '', is_deleted = 1 WHERE event_id = ?`,
+		`UPDATE event_feedback SET comment = NULL WHERE event_id = ?`,
+		`DELETE FROM event_question_answers WHERE event_id = ?`,
+		`UPDATE participation_exits SET note = '', share_identity = 0 WHERE event_id = ?`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *housekeepingStats) record(result CleanupResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs++
+	s.lastRun = &result
+	s.lastError = ""
+	if err != nil {
+		s.failures++
+		s.lastError = err.Error()
+	}
+	s.totals.VerificationTokens += result.VerificationTokens
+	s.totals.ResetTokens += result.ResetTokens
+	s.totals.ActivityEntries += result.ActivityEntries
+	s.totals.Notifications += result.Notifications
+	s.totals.IdempotencyKeys += result.IdempotencyKeys
+	s.totals.AnonymizedEvents += result.AnonymizedEvents
+	s.totals.DraftReminders += result.DraftReminders
+	s.totals.DeadlineReminders += result.DeadlineReminders
+	s.totals.DraftsDeleted += result.DraftsDeleted
+	s.totals.Announcements += result.Announcements
+	s.totals.Jobs += result.Jobs
+	s.totals.EmailLog += result.EmailLog
+	s.totals.SuspensionsLifted += result.SuspensionsLifted
+	s.totals.FeaturesCleared += result.FeaturesCleared
+}
+
+// Stats reports run counters for the metrics endpoint
+func (s *housekeepingStats) Stats() gin.H {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return gin.H{
+		"runs":                        s.runs,
+		"failures":                    s.failures,
+		"last_run":                    s.lastRun,
+		"last_error":                  s.lastError,
+		"verification_tokens_deleted": s.totals.VerificationTokens,
+		"reset_tokens_deleted":        s.totals.ResetTokens,
+		"activity_entries_deleted":    s.totals.ActivityEntries,
+		"notifications_deleted":       s.totals.Notifications,
+		"idempotency_keys_deleted":    s.totals.IdempotencyKeys,
+		"events_anonymized":           s.totals.AnonymizedEvents,
+		"draft_reminders_sent":        s.totals.DraftReminders,
+		"deadline_reminders_sent":     s.totals.DeadlineReminders,
+		"drafts_deleted":              s.totals.DraftsDeleted,
+		"announcements_deleted":       s.totals.Announcements,
+		"jobs_deleted":                s.totals.Jobs,
+		"email_log_deleted":           s.totals.EmailLog,
+		"suspensions_lifted":          s.totals.SuspensionsLifted,
+		"features_cleared":            s.totals.FeaturesCleared,
+	}
+}
+
+// housekeeper runs runCleanup and the monthly organizer digests on a fixed interval until Shutdown
+type housekeeper struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startHousekeeping(interval time.Duration) *housekeeper {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &housekeeper{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	go h.run(interval)
+	return h
+}
+
+func (h *housekeeper) run(interval time.Duration) {
+	defer close(h.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := runCleanup(time.Now()); err != nil {
+				log.Printf("⚠️  Cleanup failed: %v", err)
+			}
+			// Cheap once the month's digests are out: organizer_digests markers skip everyone
+			if _, err := runOrganizerDigests(h.ctx, time.Now()); err != nil {
+				log.Printf("⚠️  Organizer digests failed: %v", err)
+			}
+		case <-h.ctx.Done():
+			log.Println("🛑 Housekeeping goroutine shutting down")
+			return
+		}
+	}
+}
+
+// Shutdown stops the schedule and waits for a run in progress to finish
+func (h *housekeeper) Shutdown() {
+	h.cancel()
+	<-h.done
+}
+
+// adminRunCleanup runs housekeeping immediately (POST /api/admin/maintenance/cleanup)
+func adminRunCleanup(c *gin.Context) {
+	log.Printf("🧹 POST /api/admin/maintenance/cleanup - Admin %d triggered cleanup", c.GetInt("user_id"))
+	result, err := runCleanup(time.Now())
+	if err != nil {
+		log.Printf("❌ Cleanup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cleanup failed", "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCleanup(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.EventRetentionMonths = 12 })
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	now := time.Now()
+
+	insertToken := func(table, token string, expiresAt time.Time, extra string) {
+		query := `INSERT INTO ` + table + ` (user_id, token, expires_at) VALUES (?, ?, ?)`
+		if extra != "" {
+			query = `INSERT INTO ` + table + ` (user_id, token, expires_at, used, created_at) VALUES (?, ?, ?, 1, ` + extra + `)`
+		}
+		_, err := testDB.Exec(query, userID, token, expiresAt)
+		require.NoError(t, err)
+	}
+	insertToken("email_verification_tokens", "verify-expired", now.Add(-time.Minute), "")
+	insertToken("email_verification_tokens", "verify-valid", now.Add(time.Hour), "")
+	insertToken("password_reset_tokens", "reset-expired-long-ago", now.Add(-8*24*time.Hour), "")
+	insertToken("password_reset_tokens", "reset-expired-recently", now.Add(-time.Hour), "")
+	insertToken("password_reset_tokens", "reset-used-long-ago", now.Add(time.Hour), "datetime('now', '-8 days')")
+	insertToken("password_reset_tokens", "reset-used-recently", now.Add(time.Hour), "datetime('now', '-1 days')")
+	insertToken("password_reset_tokens", "reset-valid", now.Add(time.Hour), "")
+
+	oldEventID := createPastEvent(t, testDB, userID, "old-event", now.AddDate(-1, -1, 0), nil)
+	recentEventID := createPastEvent(t, testDB, userID, "recent-event", now.AddDate(0, -11, 0), nil)
+	for _, id := range []int64{oldEventID, recentEventID} {
+		_, err := testDB.Exec(`UPDATE events SET description = 'Meet at my flat, 3rd floor', address = 'Main St 1' WHERE id = ?`, id)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'Call me at 555-0100')`, id, userID)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`INSERT INTO event_feedback (event_id, user_id, rating, comment) VALUES (?, ?, 5, 'Great host')`, id, userID)
+		require.NoError(t, err)
+	}
+
+	_, err := testDB.Exec(`
+		INSERT INTO activity_log (user_id, actor_id, verb, event_id, created_at) VALUES
+		(?, ?, 'joined', ?, datetime('now', '-91 days')), (?, ?, 'joined', ?, datetime('now', '-89 days'))
+	`, userID, userID, recentEventID, userID, userID, recentEventID)
+	require.NoError(t, err)
+
+	tokens := func(table string) []string {
+		rows, err := testDB.Query(`SELECT token FROM ` + table + ` ORDER BY id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		var tokens []string
+		for rows.Next() {
+			var token string
+			require.NoError(t, rows.Scan(&token))
+			tokens = append(tokens, token)
+		}
+		return tokens
+	}
+
+	result, err := runCleanup(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.VerificationTokens)
+	assert.Equal(t, int64(2), result.ResetTokens)
+	assert.Equal(t, int64(1), result.ActivityEntries)
+	assert.Equal(t, int64(1), result.AnonymizedEvents)
+
+	assert.Equal(t, []string{"verify-valid"}, tokens("email_verification_tokens"))
+	assert.Equal(t, []string{"reset-expired-recently", "reset-used-recently", "reset-valid"}, tokens("password_reset_tokens"))
+
+	eventState := func(id int64) (description, address string, anonymized bool, comment string, feedback sql.NullString) {
+		require.NoError(t, testDB.QueryRow(`SELECT description, address, anonymized_at IS NOT NULL FROM events WHERE id = ?`, id).Scan(&description, &address, &anonymized))
+		require.NoError(t, testDB.QueryRow(`SELECT comment FROM event_comments WHERE event_id = ?`, id).Scan(&comment))
+		require.NoError(t, testDB.QueryRow(`SELECT comment FROM event_feedback WHERE event_id = ?`, id).Scan(&feedback))
+		return
+	}
+
+	description, address, anonymized, comment, feedback := eventState(oldEventID)
+	assert.Empty(t, description)
+	assert.Empty(t, address)
+	assert.True(t, anonymized)
+	assert.Empty(t, comment)
+	assert.False(t, feedback.Valid)
+
+	description, address, anonymized, comment, feedback = eventState(recentEventID)
+	assert.NotEmpty(t, description)
+	assert.NotEmpty(t, address)
+	assert.False(t, anonymized)
+	assert.NotEmpty(t, comment)
+	assert.True(t, feedback.Valid)
+
+	t.Run("Second run finds nothing", func(t *testing.T) {
+		result, err := runCleanup(now)
+		require.NoError(t, err)
+		assert.Zero(t, result.VerificationTokens+result.ResetTokens+result.AnonymizedEvents)
+	})
+
+	t.Run("Anonymization off by default", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.EventRetentionMonths = 0 })
+		_, err := testDB.Exec(`UPDATE events SET anonymized_at = NULL`)
+		require.NoError(t, err)
+		result, err := runCleanup(now)
+		require.NoError(t, err)
+		assert.Zero(t, result.AnonymizedEvents)
+	})
+}
@@ -2,31 +2,30 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// icsCacheMaxAge is how long clients may reuse a downloaded ICS file before asking again
+const icsCacheMaxAge = 5 * time.Minute
+
 // GenerateICS creates an ICS (iCalendar) file content for an event
 func GenerateICS(event *Event) string {
-	// Parse start time
-	startTime, err := time.Parse(time.RFC3339, event.StartTime)
-	if err != nil {
-		// Try alternative format
-		startTime, _ = time.Parse("2006-01-02T15:04:05Z07:00", event.StartTime)
-	}
+	// Stored times are UTC instants; legacy values without an offset are read as UTC too
+	startTime, _ := parseEventTime(event.StartTime, time.UTC)
 
 	// Parse end time if available, otherwise default to 2 hours after start
-	var endTime time.Time
+	endTime := startTime.Add(2 * time.Hour)
 	if event.EndTime != "" {
-		endTime, err = time.Parse(time.RFC3339, event.EndTime)
-		if err != nil {
-			endTime, _ = time.Parse("2006-01-02T15:04:05Z07:00", event.EndTime)
+		if t, err := parseEventTime(event.EndTime, time.UTC); err == nil {
+			endTime = t
 		}
-	} else {
-		endTime = startTime.Add(2 * time.Hour)
 	}
 
-	// Format times for ICS (YYYYMMDDTHHMMSSZ)
+	// Format times for ICS (YYYYMMDDTHHMMSSZ). UTC needs no VTIMEZONE block; calendars show
+	// the instant in the reader's own zone.
 	startICS := startTime.UTC().Format("20060102T150405Z")
 	endICS := endTime.UTC().Format("20060102T150405Z")
 	nowICS := time.Now().UTC().Format("20060102T150405Z")
@@ -36,48 +35,114 @@ func GenerateICS(event *Event) string {
 
 	// Clean and escape text for ICS format
 	title := escapeICS(event.Title)
-	description := escapeICS(event.Description)
-	location := fmt.Sprintf("%.6f,%.6f", event.Latitude, event.Longitude)
+	description := event.Description
+	if price := formatEventPrice(event); price != "" {
+		description += "\n\nPrice: " + price
+	}
+	if deadline := joinDeadlineAt(event.JoinDeadline); deadline != nil {
+		description += "\n\nJoin by: " + formatJoinDeadline(*deadline, event.Timezone)
+	}
+	description = escapeICS(description)
+	location := icsLocation(event)
 	organizer := escapeICS(event.CreatorName)
 
-	// Build ICS content
+	// Build ICS content; every content line is folded to the RFC 5545 limit
 	ics := strings.Builder{}
-	ics.WriteString("BEGIN:VCALENDAR\r\n")
-	ics.WriteString("VERSION:2.0\r\n")
-	ics.WriteString("PRODID:-//Veidly//Event Calendar//EN\r\n")
-	ics.WriteString("CALSCALE:GREGORIAN\r\n")
-	ics.WriteString("METHOD:PUBLISH\r\n")
-	ics.WriteString("BEGIN:VEVENT\r\n")
-	ics.WriteString(fmt.Sprintf("UID:%s\r\n", uid))
-	ics.WriteString(fmt.Sprintf("DTSTAMP:%s\r\n", nowICS))
-	ics.WriteString(fmt.Sprintf("DTSTART:%s\r\n", startICS))
-	ics.WriteString(fmt.Sprintf("DTEND:%s\r\n", endICS))
-	ics.WriteString(fmt.Sprintf("SUMMARY:%s\r\n", title))
-	ics.WriteString(fmt.Sprintf("DESCRIPTION:%s\r\n", description))
-	ics.WriteString(fmt.Sprintf("LOCATION:%s\r\n", location))
-	ics.WriteString(fmt.Sprintf("ORGANIZER;CN=%s:MAILTO:noreply@veidly.com\r\n", organizer))
-	ics.WriteString("STATUS:CONFIRMED\r\n")
-	ics.WriteString("SEQUENCE:0\r\n")
+	writeLine := func(line string) {
+		ics.WriteString(foldICSLine(line))
+		ics.WriteString("\r\n")
+	}
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Veidly//Event Calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	if event.Timezone != "" {
+		writeLine(fmt.Sprintf("X-WR-TIMEZONE:%s", event.Timezone))
+	}
+	writeLine("BEGIN:VEVENT")
+	writeLine(fmt.Sprintf("UID:%s", uid))
+	writeLine(fmt.Sprintf("DTSTAMP:%s", nowICS))
+	writeLine(fmt.Sprintf("DTSTART:%s", startICS))
+	writeLine(fmt.Sprintf("DTEND:%s", endICS))
+	writeLine(fmt.Sprintf("SUMMARY:%s", title))
+	writeLine(fmt.Sprintf("DESCRIPTION:%s", description))
+	writeLine(fmt.Sprintf("LOCATION:%s", location))
+	writeLine(fmt.Sprintf("GEO:%.6f;%.6f", event.Latitude, event.Longitude))
+	// Left out while the organizer is hidden from the viewer
+	if organizer != "" {
+		writeLine(fmt.Sprintf("ORGANIZER;CN=%s:MAILTO:noreply@veidly.com", organizer))
+	}
+	writeLine("STATUS:CONFIRMED")
+	writeLine("SEQUENCE:0")
 
 	// Add categories based on event category
 	if event.Category != "" {
 		categoryName := CategoryNames[event.Category]
 		if categoryName != "" {
-			ics.WriteString(fmt.Sprintf("CATEGORIES:%s\r\n", escapeICS(categoryName)))
+			writeLine(fmt.Sprintf("CATEGORIES:%s", escapeICS(categoryName)))
 		}
 	}
 
 	// Add URL if slug is available
 	if event.Slug != "" {
-		ics.WriteString(fmt.Sprintf("URL:https://veidly.com/event/%s\r\n", event.Slug))
+		writeLine(fmt.Sprintf("URL:%s", publicEventURL(event.Slug)))
 	}
 
-	ics.WriteString("END:VEVENT\r\n")
-	ics.WriteString("END:VCALENDAR\r\n")
+	writeLine("END:VEVENT")
+	writeLine("END:VCALENDAR")
 
 	return ics.String()
 }
 
+// prefersCalendar reports whether an Accept header ranks text/calendar above JSON. Wildcards count
+// towards JSON, the default representation, so only clients naming text/calendar get ICS.
+func prefersCalendar(accept string) bool {
+	calendar, json := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); strings.HasPrefix(param, "q=") && err == nil {
+				q = v
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(fields[0])) {
+		case "text/calendar":
+			calendar = max(calendar, q)
+		case "application/json", "application/*", "*/*":
+			json = max(json, q)
+		}
+	}
+	return calendar > 0 && calendar > json
+}
+
+// icsMaxLineOctets is the longest content line RFC 5545 (3.1) allows, excluding the CRLF
+const icsMaxLineOctets = 75
+
+// foldICSLine splits a content line longer than icsMaxLineOctets into continuation lines that
+// start with a space. Lines are cut between UTF-8 characters, never inside one.
+func foldICSLine(line string) string {
+	if len(line) <= icsMaxLineOctets {
+		return line
+	}
+	var folded strings.Builder
+	limit := icsMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		folded.WriteString(line[:cut])
+		folded.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsMaxLineOctets - 1 // the leading space counts
+	}
+	folded.WriteString(line)
+	return folded.String()
+}
+
 // escapeICS escapes special characters for ICS format
 func escapeICS(text string) string {
 	// Replace special characters according to RFC 5545
@@ -88,3 +153,132 @@ func escapeICS(text string) string {
 	text = strings.ReplaceAll(text, "\r", "")
 	return text
 }
+
+// icsLocation prefers the human-readable place name and address, falling back to bare coordinates
+func icsLocation(event *Event) string {
+	parts := []string{}
+	for _, part := range []string{event.LocationName, event.Address} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%.6f,%.6f", event.Latitude, event.Longitude)
+	}
+	return escapeICS(strings.Join(parts, ", "))
+}
+
+// unescapeICS reverses escapeICS for imported text values
+func unescapeICS(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return replacer.Replace(text)
+}
+
+// icsProperty is one content line of an iCalendar file: NAME;PARAM=x:value
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// parseICSProperty splits an unfolded content line; colons inside quoted parameters don't end the name
+func parseICSProperty(line string) (icsProperty, bool) {
+	inQuotes := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		return icsProperty{}, false
+	}
+
+	head := strings.Split(line[:split], ";")
+	prop := icsProperty{Name: strings.ToUpper(head[0]), Params: map[string]string{}, Value: line[split+1:]}
+	for _, param := range head[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+// icsDateTime converts a DTSTART/DTEND value into a string parseEventTime accepts: UTC values
+// ("…Z") become RFC3339 instants, local and floating values and all-day dates become wall-clock
+// times (read in the zone named by TZID, if any)
+func icsDateTime(prop icsProperty) (string, error) {
+	value := strings.TrimSpace(prop.Value)
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	for _, format := range []string{"20060102T150405", "20060102"} {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.Format("2006-01-02T15:04:05"), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported %s value: %s", prop.Name, value)
+}
+
+// ParseICSEvents reads the VEVENTs of an iCalendar file into events for import. Only SUMMARY,
+// DESCRIPTION, DTSTART/DTEND (with TZID), LOCATION and GEO are read; hasGeo reports whether GEO
+// was present, since LOCATION text alone isn't geocoded.
+func ParseICSEvents(data string) (events []Event, hasGeo []bool, err error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	// Unfold continuation lines (RFC 5545 3.1)
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	var current *Event
+	var currentGeo bool
+	for _, line := range strings.Split(data, "\n") {
+		prop, ok := parseICSProperty(strings.TrimRight(line, "\r"))
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			current, currentGeo = &Event{}, false
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			if current != nil {
+				events = append(events, *current)
+				hasGeo = append(hasGeo, currentGeo)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case prop.Name == "SUMMARY":
+			current.Title = strings.TrimSpace(unescapeICS(prop.Value))
+		case prop.Name == "DESCRIPTION":
+			current.Description = strings.TrimSpace(unescapeICS(prop.Value))
+		case prop.Name == "LOCATION":
+			current.LocationName = strings.TrimSpace(unescapeICS(prop.Value))
+		case prop.Name == "GEO":
+			var lat, lng float64
+			if _, err := fmt.Sscanf(strings.Replace(prop.Value, ";", " ", 1), "%g %g", &lat, &lng); err == nil {
+				current.Latitude, current.Longitude, currentGeo = lat, lng, true
+			}
+		case prop.Name == "DTSTART" || prop.Name == "DTEND":
+			value, err := icsDateTime(prop)
+			if err != nil {
+				value = prop.Value // Reported as an invalid time for this event
+			}
+			if prop.Name == "DTSTART" {
+				current.StartTime = value
+				if tzid := prop.Params["TZID"]; tzid != "" {
+					current.Timezone = tzid
+				}
+			} else {
+				current.EndTime = value
+			}
+		}
+	}
+
+	if len(events) == 0 && !strings.Contains(strings.ToUpper(data), "BEGIN:VCALENDAR") {
+		return nil, nil, fmt.Errorf("not an iCalendar file")
+	}
+	return events, hasGeo, nil
+}
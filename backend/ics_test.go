@@ -0,0 +1,207 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoldICSLine(t *testing.T) {
+	assert.Equal(t, "SUMMARY:Picnic", foldICSLine("SUMMARY:Picnic"))
+	exact := "DESCRIPTION:" + strings.Repeat("x", icsMaxLineOctets-len("DESCRIPTION:"))
+	assert.Equal(t, exact, foldICSLine(exact))
+
+	// A 200-character description, and one where the cut could land inside a character
+	for name, text := range map[string]string{
+		"ascii":     strings.Repeat("Bring a blanket. ", 12)[:200],
+		"multibyte": strings.Repeat("Grüße aus Zürich 🍫 ", 10),
+	} {
+		ics := GenerateICS(&Event{ID: 1, Title: "Picnic", Description: text, StartTime: "2030-06-01T12:00:00Z"})
+
+		for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+			assert.LessOrEqual(t, len(line), icsMaxLineOctets, "%s: %q", name, line)
+			assert.True(t, utf8.ValidString(line), "%s: folding split a character in %q", name, line)
+		}
+		assert.NotContains(t, ics, "\n\r\n", name)
+
+		// Unfolding (as ParseICSEvents does) gives back the whole description
+		events, _, err := ParseICSEvents(ics)
+		require.NoError(t, err, name)
+		require.Len(t, events, 1, name)
+		assert.Equal(t, strings.TrimSpace(text), events[0].Description, name)
+	}
+}
+
+func TestDownloadEventICSConditional(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/public/events/:slug/ics", downloadEventICS)
+	router.HEAD("/api/public/events/:slug/ics", downloadEventICS)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	eventID := createTestEvent(t, testDB, userID, "Picnic")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'picnic', updated_at = ? WHERE id = ?`,
+		time.Date(2026, 5, 1, 12, 0, 0, 500, time.UTC), eventID)
+	require.NoError(t, err)
+	const lastModified = "Fri, 01 May 2026 12:00:00 GMT"
+
+	request := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	get := request("GET", "/api/public/events/picnic/ics", nil)
+	require.Equal(t, http.StatusOK, get.Code, get.Body.String())
+	assert.Equal(t, `attachment; filename="picnic.ics"`, get.Header().Get("Content-Disposition"))
+	assert.Equal(t, lastModified, get.Header().Get("Last-Modified"))
+	assert.Equal(t, "public, max-age=300", get.Header().Get("Cache-Control"))
+	assert.Contains(t, get.Body.String(), "SUMMARY:Picnic\r\n")
+
+	t.Run("HEAD sends the headers without the file", func(t *testing.T) {
+		w := request("HEAD", "/api/public/events/picnic/ics", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, lastModified, w.Header().Get("Last-Modified"))
+		assert.Equal(t, strconv.Itoa(get.Body.Len()), w.Header().Get("Content-Length"))
+
+		assert.Equal(t, http.StatusNotFound, request("HEAD", "/api/public/events/missing/ics", nil).Code)
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		for since, want := range map[string]int{
+			lastModified:                    http.StatusNotModified,
+			"Sat, 02 May 2026 08:00:00 GMT": http.StatusNotModified,
+			"Fri, 01 May 2026 11:59:59 GMT": http.StatusOK,
+			"not a date":                    http.StatusOK,
+		} {
+			w := request("GET", "/api/public/events/picnic/ics", http.Header{"If-Modified-Since": {since}})
+			assert.Equal(t, want, w.Code, since)
+			if want == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		}
+
+		// An edit makes the file new again
+		_, err := testDB.Exec(`UPDATE events SET title = 'Picnic moved', updated_at = ? WHERE id = ?`, time.Date(2026, 5, 3, 9, 0, 0, 0, time.UTC), eventID)
+		require.NoError(t, err)
+		w := request("GET", "/api/public/events/picnic/ics", http.Header{"If-Modified-Since": {lastModified}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "SUMMARY:Picnic moved\r\n")
+	})
+
+	t.Run("Calendar apps can ask for it inline", func(t *testing.T) {
+		w := request("GET", "/api/public/events/picnic/ics?disposition=inline", nil)
+		assert.Equal(t, `inline; filename="picnic.ics"`, w.Header().Get("Content-Disposition"))
+		w = request("GET", "/api/public/events/picnic/ics?disposition=bogus", nil)
+		assert.Equal(t, `attachment; filename="picnic.ics"`, w.Header().Get("Content-Disposition"))
+	})
+}
+
+func TestPrefersCalendar(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                                        false,
+		"*/*":                                     false,
+		"application/json":                        false,
+		"text/calendar":                           true,
+		"TEXT/CALENDAR; charset=utf-8":            true,
+		"text/calendar, application/json":         false, // a tie keeps JSON
+		"text/calendar, */*;q=0.8":                true,
+		"application/json;q=0.5, text/calendar":   true,
+		"text/calendar;q=0.5, application/json":   false,
+		"text/calendar;q=0, text/html":            false,
+		"text/html, text/calendar;q=0.9, */*;q=1": false,
+	} {
+		assert.Equal(t, want, prefersCalendar(accept), accept)
+	}
+}
+
+func TestPublicEventContentNegotiation(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/public/events/:slug/ics", optionalAuthMiddleware(), downloadEventICS)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Vera", "password123", false)
+	viewerToken, _ := generateToken(User{ID: int(viewerID), Email: "viewer@example.com", EmailVerified: true})
+	for slug, title := range map[string]string{"picnic": "Picnic", "members-dinner": "Members dinner"} {
+		eventID := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET slug = ? WHERE id = ?`, slug, eventID)
+		require.NoError(t, err)
+	}
+	_, err := testDB.Exec(`UPDATE events SET allow_unregistered_users = 0, require_verified_to_view = 1 WHERE slug = 'members-dinner'`)
+	require.NoError(t, err)
+
+	request := func(path, token, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accept: text/calendar returns the ICS inline", func(t *testing.T) {
+		w := request("/api/public/events/picnic", "", "text/calendar")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename="picnic.ics"`, w.Header().Get("Content-Disposition"))
+		assert.Contains(t, w.Header().Values("Vary"), "Accept")
+		assert.True(t, strings.HasPrefix(w.Body.String(), "BEGIN:VCALENDAR\r\n"))
+		assert.Contains(t, w.Body.String(), "SUMMARY:Picnic\r\n")
+	})
+
+	t.Run("Other Accept headers get JSON", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "*/*", "text/calendar;q=0.5, application/json"} {
+			w := request("/api/public/events/picnic", "", accept)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+			assert.Contains(t, w.Body.String(), `"slug":"picnic"`, accept)
+		}
+	})
+
+	t.Run("Restricted events are refused in every representation", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, request("/api/public/events/members-dinner/ics", "", "").Code)
+		assert.Equal(t, http.StatusNotFound, request("/api/public/events/members-dinner", "", "text/calendar").Code)
+		assert.Equal(t, http.StatusNotFound, request("/api/public/events/members-dinner", "", "").Code)
+
+		w := request("/api/public/events/members-dinner/ics", viewerToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "SUMMARY:Members dinner\r\n")
+		assert.Equal(t, "private, max-age=300", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("A hidden organizer stays hidden in the ICS", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET hide_organizer_until_joined = 1 WHERE slug = 'picnic'`)
+		require.NoError(t, err)
+		w := request("/api/public/events/picnic/ics", "", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "Olga")
+		assert.NotContains(t, w.Body.String(), "ORGANIZER")
+	})
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotencyKeyTTL    = 24 * time.Hour // Replays after this run as new requests (see runCleanup)
+	maxIdempotencyKeyLen = 255
+)
+
+// Idempotency error codes
+const (
+	ErrCodeIdempotencyMismatch   = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+// idempotencyRecorder copies the response body so it can be stored for replays
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes a POST safe to retry when the client sends an Idempotency-Key header.
+// The first request claims the key by inserting it (the unique index settles races); its
+// response is stored and replayed for retries with the same body. The same key with a
+// different body, or while the first request is still running, gets 409.
+// Requests without the header are unaffected. Must run after authMiddleware.
+func idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLen)})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := c.GetInt("user_id")
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+		ctx := c.Request.Context()
+
+		// An expired key is free to be claimed again
+		ttl := fmt.Sprintf("-%d seconds", int(idempotencyKeyTTL.Seconds()))
+		if _, err := db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND created_at < datetime('now', ?)`,
+			userID, key, ttl); err != nil {
+			log.Printf("⚠️  Failed to expire idempotency key for user %d: %v", userID, err)
+		}
+
+		_, err = db.ExecContext(ctx, `INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash) VALUES (?, ?, ?)`,
+			userID, key, requestHash)
+		if isUniqueViolation(err) {
+			replayIdempotentResponse(c, userID, key, requestHash)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store idempotency key for user %d: %v", userID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() { c.Writer = recorder.ResponseWriter }()
+
+		c.Next()
+
+		// Server errors aren't cached so the retry gets a real second attempt. The request context
+		// may already be done here, so the bookkeeping runs without it.
+		if status := recorder.Status(); status >= http.StatusInternalServerError {
+			_, err = db.Exec(`DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?`, userID, key)
+		} else {
+			_, err = db.Exec(`UPDATE idempotency_keys SET status_code = ?, response = ? WHERE user_id = ? AND idempotency_key = ?`,
+				status, recorder.body.String(), userID, key)
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to finish idempotency key for user %d: %v", userID, err)
+		}
+	}
+}
+
+// replayIdempotentResponse answers a request whose key was already claimed
+func replayIdempotentResponse(c *gin.Context, userID int, key, requestHash string) {
+	var storedHash string
+	var status sql.NullInt64
+	var response sql.NullString
+	err := db.QueryRowContext(c.Request.Context(), `
+		SELECT request_hash, status_code, response FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?
+	`, userID, key).Scan(&storedHash, &status, &response)
+	if err != nil {
+		log.Printf("❌ Failed to load idempotency key for user %d: %v", userID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+
+	if storedHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "Idempotency-Key was already used for a different request",
+			"code":  ErrCodeIdempotencyMismatch,
+		})
+		return
+	}
+	if !status.Valid {
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "A request with this Idempotency-Key is still being processed",
+			"code":  ErrCodeIdempotencyInProgress,
+		})
+		return
+	}
+
+	log.Printf("🔁 Replaying idempotent response for user %d", userID)
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(int(status.Int64), "application/json; charset=utf-8", []byte(response.String))
+	c.Abort()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	// Retries repeat the same event on purpose
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", idempotent(), createEvent)
+	protected.POST("/events/:id/join", idempotent(), joinEvent)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+	otherToken, _ := generateToken(User{ID: int(otherID), Email: "other@example.com", EmailVerified: true})
+
+	post := func(path, token, key string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	eventPayload := func(title string) gin.H {
+		return gin.H{
+			"title": title, "description": "Flat white and a chat",
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		}
+	}
+	countEvents := func(title string) int {
+		var n int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE title = ?`, title).Scan(&n))
+		return n
+	}
+	eventID := func(w *httptest.ResponseRecorder) int {
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event), w.Body.String())
+		return event.ID
+	}
+	errorCode := func(w *httptest.ResponseRecorder) string {
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		code, _ := resp["code"].(string)
+		return code
+	}
+
+	t.Run("Identical retry replays the first response", func(t *testing.T) {
+		payload := eventPayload("Coffee Meetup")
+		first := post("/api/events", token, "create-1", payload)
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		retry := post("/api/events", token, "create-1", payload)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, eventID(first), eventID(retry))
+		assert.Equal(t, 1, countEvents("Coffee Meetup"))
+	})
+
+	t.Run("Same key with a different body is rejected", func(t *testing.T) {
+		w := post("/api/events", token, "create-1", eventPayload("Tea Meetup"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, ErrCodeIdempotencyMismatch, errorCode(w))
+		assert.Zero(t, countEvents("Tea Meetup"))
+	})
+
+	t.Run("Keys are per user", func(t *testing.T) {
+		w := post("/api/events", otherToken, "create-1", eventPayload("Coffee Meetup"))
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Without a key every request runs", func(t *testing.T) {
+		post("/api/events", token, "", eventPayload("Board Games"))
+		// A different start time so double-submit detection doesn't fold the two together
+		nextWeek := eventPayload("Board Games")
+		nextWeek["start_time"] = time.Now().Add(7 * 24 * time.Hour).Format(time.RFC3339)
+		post("/api/events", token, "", nextWeek)
+		assert.Equal(t, 2, countEvents("Board Games"))
+	})
+
+	t.Run("Join retry is not an already-joined error", func(t *testing.T) {
+		joinID := createTestEvent(t, testDB, otherID, "Pub Quiz")
+		path := fmt.Sprintf("/api/events/%d/join", joinID)
+		require.Equal(t, http.StatusOK, post(path, token, "join-1", nil).Code)
+		w := post(path, token, "join-1", nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Concurrent duplicates create exactly one event", func(t *testing.T) {
+		payload := eventPayload("Picnic")
+		var wg sync.WaitGroup
+		codes := make([]int, 5)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = post("/api/events", token, "create-concurrent", payload).Code
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, countEvents("Picnic"))
+		for _, code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		}
+		assert.Contains(t, codes, http.StatusCreated)
+	})
+
+	t.Run("Expired keys are cleaned up and reusable", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE idempotency_keys SET created_at = datetime('now', '-25 hours') WHERE idempotency_key = 'create-1'`)
+		require.NoError(t, err)
+		w := post("/api/events", token, "create-1", eventPayload("Tea Meetup"))
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		_, err = testDB.Exec(`UPDATE idempotency_keys SET created_at = datetime('now', '-25 hours')`)
+		require.NoError(t, err)
+		result, err := runCleanup(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), result.IdempotencyKeys) // create-1 for both users, join-1, create-concurrent
+	})
+}
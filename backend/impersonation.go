@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isActiveAdmin reports whether userID is an admin whose account isn't blocked
+func isActiveAdmin(userID int) bool {
+	var isAdmin, isBlocked bool
+	err := db.QueryRow("SELECT is_admin, is_blocked FROM users WHERE id = ?", userID).Scan(nullable(&isAdmin), nullable(&isBlocked))
+	return err == nil && isAdmin && !isBlocked
+}
+
+// denyWhenImpersonating blocks account-security actions (credentials, 2FA, account deletion)
+// for sessions where an admin is acting as the user
+func denyWhenImpersonating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonator, ok := c.Get("impersonated_by"); ok {
+			log.Printf("🚫 Admin %v attempted %s %s while impersonating user %d",
+				impersonator, c.Request.Method, c.Request.URL.Path, c.GetInt("user_id"))
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminImpersonateUser issues a 15 minute token to act as another (non-admin) user
+// (POST /api/admin/impersonate/:id)
+func adminImpersonateUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	adminID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	log.Printf("🎭 POST /api/admin/impersonate/%d - Admin %d starting impersonation", targetID, adminID)
+
+	// Nested impersonation would hide who is really acting
+	if _, ok := c.Get("impersonated_by"); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
+		return
+	}
+
+	if targetID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot impersonate yourself"})
+		return
+	}
+
+	var target User
+	err = db.QueryRowContext(ctx, `SELECT id, email, name, is_admin, is_blocked FROM users WHERE id = ?`, targetID).
+		Scan(&target.ID, &target.Email, &target.Name, nullable(&target.IsAdmin), nullable(&target.IsBlocked))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading user to impersonate: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		return
+	}
+
+	if target.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admins cannot impersonate other admins"})
+		return
+	}
+
+	token, expiresAt, err := generateImpersonationToken(target, adminID)
+	if err != nil {
+		log.Printf("❌ Error generating impersonation token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		return
+	}
+
+	log.Printf("✅ Admin %d is now impersonating user %d until %s", adminID, targetID, expiresAt.Format("15:04:05"))
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"user":       target,
+		"expires_at": expiresAt,
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImpersonationRouter() *gin.Engine {
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/auth/me", getCurrentUser)
+	protected.POST("/profile/2fa/setup", denyWhenImpersonating(), setupTwoFactor)
+	protected.DELETE("/profile/2fa", denyWhenImpersonating(), disableTwoFactor)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.POST("/impersonate/:id", adminImpersonateUser)
+	return router
+}
+
+func startImpersonation(t *testing.T, router *gin.Engine, adminToken string, targetID int64) string {
+	w := doJSON(router, "POST", fmt.Sprintf("/api/admin/impersonate/%d", targetID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Token)
+	return resp.Token
+}
+
+func TestAdminImpersonation(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupImpersonationRouter()
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	otherAdminID := createTestUser(t, testDB, "admin2@example.com", "Admin Two", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+
+	t.Run("Claims carry target and impersonator", func(t *testing.T) {
+		token := startImpersonation(t, router, adminToken, userID)
+
+		claims, err := validateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, int(userID), claims.UserID)
+		assert.Equal(t, int(adminID), claims.ImpersonatorID)
+		assert.False(t, claims.IsAdmin)
+		assert.WithinDuration(t, time.Now().Add(impersonationTTL), claims.ExpiresAt.Time, 5*time.Second)
+	})
+
+	t.Run("/auth/me reports impersonation", func(t *testing.T) {
+		token := startImpersonation(t, router, adminToken, userID)
+
+		w := doJSON(router, "GET", "/api/auth/me", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, true, resp["impersonating"])
+		assert.Equal(t, float64(adminID), resp["impersonator_id"])
+		assert.Equal(t, "user@example.com", resp["user"].(map[string]interface{})["email"])
+
+		w = doJSON(router, "GET", "/api/auth/me", userToken, nil)
+		var plain map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plain))
+		assert.Equal(t, false, plain["impersonating"])
+		assert.NotContains(t, plain, "impersonator_id")
+	})
+
+	t.Run("Account security actions refused while impersonating", func(t *testing.T) {
+		token := startImpersonation(t, router, adminToken, userID)
+
+		w := doJSON(router, "POST", "/api/profile/2fa/setup", token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(router, "DELETE", "/api/profile/2fa", token, gin.H{"password": "password123", "code": "000000"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// The real user is unaffected
+		w = doJSON(router, "POST", "/api/profile/2fa/setup", userToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Impersonation token cannot reach admin routes", func(t *testing.T) {
+		token := startImpersonation(t, router, adminToken, userID)
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/impersonate/%d", userID), token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Admins cannot impersonate other admins", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/impersonate/%d", otherAdminID), adminToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Non-admins cannot impersonate", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/impersonate/%d", otherAdminID), userToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/admin/impersonate/9999", adminToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Token revoked when impersonator loses admin rights", func(t *testing.T) {
+		token := startImpersonation(t, router, adminToken, userID)
+		_, err := testDB.Exec(`UPDATE users SET is_admin = 0 WHERE id = ?`, adminID)
+		require.NoError(t, err)
+
+		w := doJSON(router, "GET", "/api/auth/me", token, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
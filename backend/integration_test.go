@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routeParam matches the :name and *name segments of a registered route
+var routeParam = regexp.MustCompile(`[:*][a-z_]+`)
+
+// TestRouteTableWiring sends a request to every route setupRouter registers, so a newly added
+// route is covered without touching this file: it must resolve to a handler, and admin routes must
+// turn away anonymous visitors and regular users.
+func TestRouteTableWiring(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	captureModerationEmails(t)
+
+	router := newVersionedTestRouter()
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	routes := router.Routes()
+	require.Greater(t, len(routes), 100)
+	for _, route := range routes {
+		path := routeParam.ReplaceAllStringFunc(route.Path, func(param string) string {
+			if param == ":slug" {
+				return "missing-event"
+			}
+			return "999"
+		})
+
+		w := doJSON(router, route.Method, path, "", nil)
+		assert.NotEqual(t, "404 page not found", w.Body.String(), "%s %s is not routed", route.Method, route.Path)
+		assert.NotEqual(t, http.StatusMethodNotAllowed, w.Code, "%s %s", route.Method, route.Path)
+
+		if strings.Contains(route.Path, "/admin/") {
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s must require a login", route.Method, route.Path)
+			w = doJSON(router, route.Method, path, userToken, nil)
+			assert.Equal(t, http.StatusForbidden, w.Code, "%s %s must require an admin", route.Method, route.Path)
+		}
+	}
+}
+
+// TestIntegrationUserJourney walks two users and an admin through the real router with real JWTs:
+// register, verify, log in, create, join, comment, block, report and moderate
+func TestIntegrationUserJourney(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	sentEmails := captureModerationEmails(t)
+
+	router := newVersionedTestRouter()
+	call := func(method, path, token string, payload interface{}) *httptest.ResponseRecorder {
+		return doJSON(router, method, "/api/v1"+path, token, payload)
+	}
+	decode := func(w *httptest.ResponseRecorder, v interface{}) {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	// signUp registers, follows the verification link and logs in again so the JWT carries the
+	// verified state; it returns the user ID and token
+	signUp := func(email, name string) (int, string) {
+		w := call("POST", "/auth/register", "", map[string]string{"email": email, "password": "password123", "name": name})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var registered struct {
+			Token string `json:"token"`
+			User  User   `json:"user"`
+		}
+		decode(w, &registered)
+		assert.False(t, registered.User.EmailVerified)
+
+		w = call("GET", "/auth/me", registered.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// The link from the verification email (no mail service runs in tests)
+		verificationToken := "verify-" + email
+		_, err := testDB.Exec(`INSERT INTO email_verification_tokens (user_id, token, expires_at) VALUES (?, ?, ?)`,
+			registered.User.ID, verificationToken, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		w = call("GET", "/auth/verify-email?token="+verificationToken, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		return registered.User.ID, loginThroughAPI(t, router, email, "password123")
+	}
+
+	organizerID, organizerToken := signUp("organizer@example.com", "Organizer")
+	participantID, participantToken := signUp("participant@example.com", "Participant")
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken := loginThroughAPI(t, router, "admin@example.com", "password123")
+
+	var event Event
+	t.Run("Organizer creates an event", func(t *testing.T) {
+		w := call("POST", "/events", organizerToken, map[string]interface{}{
+			"title": "Coffee Meetup", "description": "Let's grab coffee and chat",
+			"category": "social_drinks", "latitude": 46.8805, "longitude": 8.6444,
+			"start_time": time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Organizer",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		decode(w, &event)
+		assert.False(t, event.Draft, "verified organizers publish right away")
+
+		w = call("GET", fmt.Sprintf("/events/%d", event.ID), "", nil)
+		assert.Equal(t, http.StatusOK, w.Code, "public routes work without a token")
+		w = call("POST", "/events", "", map[string]string{"title": "No token"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Participant joins and comments", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/events/%d/join", event.ID), participantToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = call("GET", fmt.Sprintf("/events/%d/participants", event.ID), organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var participants struct {
+			Items []ParticipantView `json:"items"`
+		}
+		decode(w, &participants)
+		require.Len(t, participants.Items, 1)
+		assert.Equal(t, participantID, participants.Items[0].ID)
+
+		w = call("POST", fmt.Sprintf("/events/%d/comments", event.ID), participantToken, map[string]string{"comment": "See you there!"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = call("GET", fmt.Sprintf("/events/%d/comments", event.ID), organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var comments []EventComment
+		decode(w, &comments)
+		require.Len(t, comments, 1)
+		assert.Equal(t, participantID, comments[0].UserID)
+	})
+
+	t.Run("Participant blocks the organizer", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/users/%d/block", organizerID), participantToken, map[string]string{})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = call("GET", "/events", participantToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var events []Event
+		decode(w, &events)
+		assert.Empty(t, events, "events of blocked users disappear from listings")
+
+		w = call("GET", "/events", "", nil)
+		decode(w, &events)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("Admin upholds a report and blocks the organizer", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/events/%d/report", event.ID), participantToken, map[string]string{"reason": "spam"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = call("GET", "/admin/reports", participantToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = call("GET", "/admin/reports", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var reports struct {
+			Reports []AdminEventReport `json:"reports"`
+		}
+		decode(w, &reports)
+		require.Len(t, reports.Reports, 1)
+		assert.Equal(t, event.ID, reports.Reports[0].EventID)
+
+		w = call("POST", fmt.Sprintf("/admin/events/%d/reports/resolve", event.ID), adminToken, map[string]string{"resolution": ReportStatusUpheld})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"cancelled":true`)
+		assert.NotEmpty(t, sentEmails(), "the organizer hears about the upheld report")
+
+		w = call("PUT", fmt.Sprintf("/admin/users/%d/block", organizerID), adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = call("GET", "/auth/me", organizerToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, "existing tokens stop working once blocked")
+
+		var reviewedBy int64
+		require.NoError(t, testDB.QueryRow(`SELECT reviewed_by FROM event_reports WHERE event_id = ?`, event.ID).Scan(&reviewedBy))
+		assert.Equal(t, adminID, reviewedBy)
+	})
+}
+
+// loginThroughAPI signs in through the API and returns the issued JWT
+func loginThroughAPI(t *testing.T, router http.Handler, email, password string) string {
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(fmt.Sprintf(`{"email":%q,"password":%q}`, email, password)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(t, response.Token)
+	return response.Token
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// IntegrityReport is what the startup integrity pass found
+type IntegrityReport struct {
+	Problems []string       // PRAGMA integrity_check messages other than "ok"
+	Orphans  map[string]int // Rows whose foreign key points at a missing row, keyed by "table -> parent"
+	Repaired int            // Orphans deleted (or unlinked, for ON DELETE SET NULL keys) because of REPAIR_ORPHANS
+	Flagged  int            // Events of missing organizers hidden pending review because of REPAIR_ORPHANS
+}
+
+// foreignKeyViolation is one row of PRAGMA foreign_key_check
+type foreignKeyViolation struct {
+	table  string
+	rowID  sql.NullInt64
+	parent string
+	fkID   int
+}
+
+// checkDatabaseIntegrity runs PRAGMA integrity_check and PRAGMA foreign_key_check and logs what they
+// report. Databases written before foreign keys were enforced can hold orphans (participants of
+// deleted events and the like); with repair they are removed the way the missing cascade would have.
+func checkDatabaseIntegrity(db *sql.DB, repair bool) IntegrityReport {
+	report := IntegrityReport{Orphans: map[string]int{}}
+
+	problems, err := integrityProblems(db)
+	if err != nil {
+		log.Printf("⚠️  Could not run integrity check: %v", err)
+	}
+	report.Problems = problems
+	if len(report.Problems) > 0 {
+		log.Printf("❌ Database integrity check found %d problems:", len(report.Problems))
+		for i, problem := range report.Problems {
+			if i == 10 {
+				log.Printf("   ... and %d more", len(report.Problems)-i)
+				break
+			}
+			log.Printf("   %s", problem)
+		}
+	}
+
+	violations, err := foreignKeyViolations(db)
+	if err != nil {
+		log.Printf("⚠️  Could not run foreign key check: %v", err)
+		return report
+	}
+	if len(violations) == 0 {
+		log.Println("✓ Database integrity check passed")
+		return report
+	}
+
+	for _, v := range violations {
+		report.Orphans[v.table+" -> "+v.parent]++
+	}
+	keys := make([]string, 0, len(report.Orphans))
+	for key := range report.Orphans {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log.Printf("⚠️  Found %d rows referencing missing rows:", len(violations))
+	for _, key := range keys {
+		log.Printf("   %s: %d", key, report.Orphans[key])
+	}
+
+	if !repair {
+		log.Println("ℹ️  Set REPAIR_ORPHANS=true to remove them on the next start")
+		return report
+	}
+	plan := planOrphanRepairs(db, violations)
+	classes := summarizeOrphans(plan)
+	repairOrphans(db, plan, classes)
+	for _, class := range classes {
+		if class.Action == OrphanFlagForReview {
+			report.Flagged += class.Repaired
+		} else {
+			report.Repaired += class.Repaired
+		}
+	}
+	log.Printf("🧹 Repaired %d orphaned rows, hid %d events of missing organizers for review", report.Repaired, report.Flagged)
+	return report
+}
+
+// integrityProblems returns the PRAGMA integrity_check messages other than "ok"
+func integrityProblems(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return problems, err
+		}
+		if message != "ok" {
+			problems = append(problems, message)
+		}
+	}
+	return problems, rows.Err()
+}
+
+func foreignKeyViolations(db *sql.DB) ([]foreignKeyViolation, error) {
+	rows, err := db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var violations []foreignKeyViolation
+	for rows.Next() {
+		var v foreignKeyViolation
+		if err := rows.Scan(&v.table, &v.rowID, &v.parent, &v.fkID); err != nil {
+			return nil, err
+		}
+		violations = append(violations, v)
+	}
+	return violations, rows.Err()
+}
+
+// Orphan repair actions, by the rules repairOrphans applies
+const (
+	OrphanDelete        = "delete"          // the row is removed, as the missing ON DELETE CASCADE would have
+	OrphanSetNull       = "set_null"        // the key is cleared, for ON DELETE SET NULL keys
+	OrphanFlagForReview = "flag_for_review" // events of missing organizers are hidden for an admin to decide
+)
+
+// orphanRepair is a foreign key violation and what repairing it does
+type orphanRepair struct {
+	foreignKeyViolation
+	action  string
+	columns []string // keys cleared by OrphanSetNull
+}
+
+// OrphanClass groups the orphans of one foreign key relationship
+type OrphanClass struct {
+	Table     string  `json:"table"`
+	Parent    string  `json:"parent"`
+	Action    string  `json:"action"`
+	Count     int     `json:"count"`
+	SampleIDs []int64 `json:"sample_ids"` // rowids of the first few orphans
+	Repaired  int     `json:"repaired"`   // rows deleted, unlinked or flagged by this run
+}
+
+// maxOrphanSamples caps the rowids listed per orphan class
+const maxOrphanSamples = 10
+
+// planOrphanRepairs decides what repairing each violation does. Events whose organizer is gone are
+// never deleted: they may still have participants, so they are hidden pending review instead.
+func planOrphanRepairs(db *sql.DB, violations []foreignKeyViolation) []orphanRepair {
+	setNull := map[string]map[int][]string{} // table -> fk id -> columns of ON DELETE SET NULL keys
+	plan := make([]orphanRepair, 0, len(violations))
+	for _, v := range violations {
+		if _, ok := setNull[v.table]; !ok {
+			columns, err := setNullForeignKeys(db, v.table)
+			if err != nil {
+				log.Printf("⚠️  Could not read foreign keys of %s: %v", v.table, err)
+			}
+			setNull[v.table] = columns
+		}
+
+		repair := orphanRepair{foreignKeyViolation: v, action: OrphanDelete}
+		if columns := setNull[v.table][v.fkID]; len(columns) > 0 {
+			repair.action, repair.columns = OrphanSetNull, columns
+		} else if v.table == "events" && v.parent == "users" {
+			repair.action = OrphanFlagForReview
+		}
+		plan = append(plan, repair)
+	}
+	return plan
+}
+
+// summarizeOrphans groups a plan into classes, sorted by table and parent
+func summarizeOrphans(plan []orphanRepair) []OrphanClass {
+	classes := []OrphanClass{}
+	index := map[string]int{}
+	for _, r := range plan {
+		key := r.table + " -> " + r.parent + " " + r.action
+		i, ok := index[key]
+		if !ok {
+			i = len(classes)
+			index[key] = i
+			classes = append(classes, OrphanClass{Table: r.table, Parent: r.parent, Action: r.action, SampleIDs: []int64{}})
+		}
+		classes[i].Count++
+		if r.rowID.Valid && len(classes[i].SampleIDs) < maxOrphanSamples {
+			classes[i].SampleIDs = append(classes[i].SampleIDs, r.rowID.Int64)
+		}
+	}
+	sort.SliceStable(classes, func(i, j int) bool {
+		if classes[i].Table != classes[j].Table {
+			return classes[i].Table < classes[j].Table
+		}
+		return classes[i].Parent < classes[j].Parent
+	})
+	return classes
+}
+
+// repairOrphans applies the plan and records in classes how many rows each class repaired.
+// Deletes cascade, so rows hanging off an orphan may already be gone when their turn comes.
+// Flagged events stay orphans (and in the report) until an admin deletes them. Deleted participants
+// and interest are taken off their events' denormalized counts.
+func repairOrphans(db *sql.DB, plan []orphanRepair, classes []OrphanClass) {
+	index := map[string]int{}
+	for i, class := range classes {
+		index[class.Table+" -> "+class.Parent+" "+class.Action] = i
+	}
+	flagged, recount := false, false
+	for _, r := range plan {
+		if !r.rowID.Valid {
+			continue
+		}
+		table := quoteIdentifier(r.table)
+		var result sql.Result
+		var err error
+		var n int64
+		switch r.action {
+		case OrphanSetNull:
+			assignments := make([]string, len(r.columns))
+			for i, column := range r.columns {
+				assignments[i] = quoteIdentifier(column) + " = NULL"
+			}
+			result, err = db.Exec(fmt.Sprintf(`UPDATE %s SET %s WHERE rowid = ?`, table, strings.Join(assignments, ", ")), r.rowID.Int64)
+		case OrphanFlagForReview:
+			// An event's rowid is its id
+			var held bool
+			if held, err = holdEvent(db, r.rowID.Int64, HideSourceIntegrity); held {
+				n = 1
+				flagged = true
+			}
+		default:
+			result, err = db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE rowid = ?`, table), r.rowID.Int64)
+		}
+		if err != nil {
+			log.Printf("⚠️  Could not repair %s row %d: %v", r.table, r.rowID.Int64, err)
+			continue
+		}
+		if result != nil {
+			n, _ = result.RowsAffected()
+		}
+		if n > 0 {
+			if i, ok := index[r.table+" -> "+r.parent+" "+r.action]; ok {
+				classes[i].Repaired++
+			}
+			if r.table == "event_participants" || r.table == "event_interest" {
+				recount = true
+			}
+		}
+	}
+	if recount {
+		if err := recountEventTallies(db); err != nil {
+			log.Printf("⚠️  Could not recount participants and interest after repair: %v", err)
+		}
+	}
+	if flagged || recount {
+		eventListCache.Invalidate()
+		publicSitemap.Invalidate()
+	}
+}
+
+// recountEventTallies rebuilds participant_count and interested_count on the events where they drifted
+func recountEventTallies(db *sql.DB) error {
+	if _, err := db.Exec(`
+		UPDATE events SET participant_count = (SELECT COUNT(*) FROM event_participants WHERE event_id = events.id)
+		WHERE participant_count != (SELECT COUNT(*) FROM event_participants WHERE event_id = events.id)
+	`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		UPDATE events SET interested_count = (SELECT COUNT(*) FROM event_interest WHERE event_id = events.id)
+		WHERE interested_count != (SELECT COUNT(*) FROM event_interest WHERE event_id = events.id)
+	`)
+	return err
+}
+
+func setNullForeignKeys(db *sql.DB, table string) (map[int][]string, error) {
+	rows, err := db.Query(`SELECT id, "from", on_delete FROM pragma_foreign_key_list(?)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[int][]string{}
+	for rows.Next() {
+		var id int
+		var from, onDelete string
+		if err := rows.Scan(&id, &from, &onDelete); err != nil {
+			return nil, err
+		}
+		if onDelete == "SET NULL" {
+			columns[id] = append(columns[id], from)
+		}
+	}
+	return columns, rows.Err()
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openProductionSchema runs the production openDatabase path against a temporary file
+func openProductionSchema(t *testing.T) (*sql.DB, string) {
+	useTestConfig(t, func(cfg *Config) { cfg.AdminPassword = "admin-password-for-tests" })
+	previous := db
+	path := filepath.Join(t.TempDir(), "veidly.db")
+	openDatabase(path)
+	opened := db
+	t.Cleanup(func() {
+		opened.Close()
+		db = previous
+	})
+	return opened, path
+}
+
+func countRows(t *testing.T, conn *sql.DB, query string, args ...interface{}) int {
+	var n int
+	require.NoError(t, conn.QueryRow(query, args...).Scan(&n))
+	return n
+}
+
+func TestProductionSchemaCascadesEventDeletes(t *testing.T) {
+	conn, _ := openProductionSchema(t)
+
+	result, err := conn.Exec(`INSERT INTO users (email, password, name) VALUES ('organizer@example.com', 'x', 'Organizer')`)
+	require.NoError(t, err)
+	userID, _ := result.LastInsertId()
+	result, err = conn.Exec(`
+		INSERT INTO events (user_id, title, description, category, latitude, longitude, start_time, creator_name)
+		VALUES (?, 'Board games', 'Bring snacks', 'gaming_hobbies', 52.23, 21.01, '2030-01-01T18:00:00Z', 'Organizer')
+	`, userID)
+	require.NoError(t, err)
+	eventID, _ := result.LastInsertId()
+	_, err = conn.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, userID)
+	require.NoError(t, err)
+	_, err = conn.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, 'See you there')`, eventID, userID)
+	require.NoError(t, err)
+
+	// Every pooled connection enforces foreign keys, not just the one that ran a PRAGMA
+	conn.SetMaxIdleConns(0)
+	_, err = conn.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (9999, ?)`, userID)
+	assert.Error(t, err, "participants of missing events are rejected")
+
+	deleted, err := deleteEventRecord(context.Background(), conn, eventID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	assert.Zero(t, countRows(t, conn, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID))
+	assert.Zero(t, countRows(t, conn, `SELECT COUNT(*) FROM event_comments WHERE event_id = ?`, eventID))
+}
+
+func TestCheckDatabaseIntegrityRepairsOrphans(t *testing.T) {
+	conn, path := openProductionSchema(t)
+
+	report := checkDatabaseIntegrity(conn, false)
+	assert.Empty(t, report.Problems)
+	assert.Empty(t, report.Orphans)
+
+	// Rows written the way the server used to, without foreign key enforcement
+	legacy, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer legacy.Close()
+	result, err := legacy.Exec(`INSERT INTO users (email, password, name) VALUES ('user@example.com', 'x', 'User')`)
+	require.NoError(t, err)
+	userID, _ := result.LastInsertId()
+	_, err = legacy.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (9999, ?)`, userID)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (9999, ?, 'Orphan')`, userID)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES (8888, 'orphan-token', '2030-01-01')`)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`INSERT INTO webhooks (url, secret, event_types, created_by) VALUES ('https://example.com/hook', 's', '[]', 8888)`)
+	require.NoError(t, err)
+
+	report = checkDatabaseIntegrity(conn, false)
+	assert.Equal(t, map[string]int{
+		"event_comments -> events":       1,
+		"event_participants -> events":   1,
+		"password_reset_tokens -> users": 1,
+		"webhooks -> users":              1,
+	}, report.Orphans)
+	assert.Zero(t, report.Repaired)
+	assert.Equal(t, 1, countRows(t, conn, `SELECT COUNT(*) FROM event_participants`), "only logged without REPAIR_ORPHANS")
+
+	report = checkDatabaseIntegrity(conn, true)
+	assert.Equal(t, 4, report.Repaired)
+	assert.Zero(t, countRows(t, conn, `SELECT COUNT(*) FROM event_participants`))
+	assert.Zero(t, countRows(t, conn, `SELECT COUNT(*) FROM event_comments`))
+	assert.Zero(t, countRows(t, conn, `SELECT COUNT(*) FROM password_reset_tokens`))
+	assert.Equal(t, 1, countRows(t, conn, `SELECT COUNT(*) FROM webhooks WHERE created_by IS NULL`), "ON DELETE SET NULL keys are cleared")
+
+	assert.Empty(t, checkDatabaseIntegrity(conn, false).Orphans)
+}
+
+func TestRepairOrphansRecountsEvents(t *testing.T) {
+	conn, path := openProductionSchema(t)
+
+	result, err := conn.Exec(`INSERT INTO users (email, password, name) VALUES ('organizer@example.com', 'x', 'Organizer')`)
+	require.NoError(t, err)
+	organizerID, _ := result.LastInsertId()
+	result, err = conn.Exec(`
+		INSERT INTO events (user_id, title, description, category, latitude, longitude, start_time, creator_name)
+		VALUES (?, 'Board games', 'Bring snacks', 'gaming_hobbies', 52.23, 21.01, '2030-01-01T18:00:00Z', 'Organizer')
+	`, organizerID)
+	require.NoError(t, err)
+	eventID, _ := result.LastInsertId()
+	_, err = conn.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, organizerID)
+	require.NoError(t, err)
+
+	// A deleted account's participation and interest, counted on the event like any other
+	legacy, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer legacy.Close()
+	_, err = legacy.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, 8888)`, eventID)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`INSERT INTO event_interest (event_id, user_id) VALUES (?, 7777)`, eventID)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`UPDATE events SET participant_count = 2, interested_count = 1 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	report := checkDatabaseIntegrity(conn, true)
+	assert.Equal(t, 2, report.Repaired)
+	var participants, interested int
+	require.NoError(t, conn.QueryRow(`SELECT participant_count, interested_count FROM events WHERE id = ?`, eventID).Scan(&participants, &interested))
+	assert.Equal(t, 1, participants, "the organizer is still going")
+	assert.Zero(t, interested)
+}
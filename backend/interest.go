@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// spotsAvailableDelay batches the notice about freed spots: several people leaving a full event in
+// quick succession produce one job, which mails each interested user once
+const spotsAvailableDelay = 5 * time.Minute
+
+// spotsAvailablePayload is the payload of JobNotifyInterested
+type spotsAvailablePayload struct {
+	EventID int `json:"event_id"`
+}
+
+// markInterested records a non-binding interest in an event (POST /api/events/:id/interest).
+// Interested users are not participants: they don't take a spot and can't read participant-only
+// content, but hear about it once when a spot frees up on a full event. Repeating it is harmless.
+func markInterested(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("👀 POST /api/events/%d/interest - User %d interested", eventID, userID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	var organizerID int
+	var startTime string
+	var joinDeadline sql.NullString
+	var cancelled, draft, hidden, isParticipant bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, start_time, join_deadline, cancelled_at IS NOT NULL, published = 0, hidden_pending_review,
+		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = events.id AND user_id = ?)
+		FROM events WHERE id = ?
+	`, userID, eventID).Scan(&organizerID, &startTime, &joinDeadline, &cancelled, &draft, nullable(&hidden), &isParticipant)
+	// Drafts and events under review look deleted, as they do for joining
+	if err == sql.ErrNoRows || draft || (hidden && organizerID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error checking event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+		return
+	}
+
+	switch {
+	case organizerID == userID:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are organizing this event"})
+		return
+	case isParticipant:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Already joined this event"})
+		return
+	case cancelled:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This event has been cancelled"})
+		return
+	case joinWindowClosed(startTime, time.Now()):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This event has already started", "code": ErrCodeEventStarted})
+		return
+	case joinDeadlinePassed(joinDeadline.String, time.Now()):
+		// Interest waits for a spot to join, which can't happen any more
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Signups for this event have closed", "code": ErrCodeJoinClosed})
+		return
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO event_interest (event_id, user_id) VALUES (?, ?)`, eventID, userID)
+	if err != nil {
+		log.Printf("❌ Error marking interest: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		if err := adjustInterestedCount(tx, eventID, 1); err != nil {
+			log.Printf("❌ Error updating interested count: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+			return
+		}
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT interested_count FROM events WHERE id = ?`, eventID).Scan(&count); err != nil {
+		log.Printf("❌ Error reading interested count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark interest"})
+		return
+	}
+
+	eventListCache.Invalidate()
+	log.Printf("✅ User %d is interested in event %d", userID, eventID)
+	c.JSON(http.StatusOK, gin.H{"message": "Marked as interested", "is_interested": true, "interested_count": count})
+}
+
+// unmarkInterested withdraws the viewer's interest (DELETE /api/events/:id/interest)
+func unmarkInterested(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("👀 DELETE /api/events/%d/interest - User %d no longer interested", eventID, userID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove interest"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	removed, err := removeInterest(tx, eventID, userID)
+	if err != nil {
+		log.Printf("❌ Error removing interest: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove interest"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not marked as interested in this event"})
+		return
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT interested_count FROM events WHERE id = ?`, eventID).Scan(&count); err != nil {
+		log.Printf("❌ Error reading interested count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove interest"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove interest"})
+		return
+	}
+
+	eventListCache.Invalidate()
+	c.JSON(http.StatusOK, gin.H{"message": "Interest removed", "is_interested": false, "interested_count": count})
+}
+
+// adjustInterestedCount keeps events.interested_count in step with event_interest, like
+// adjustParticipantCount does for participants
+func adjustInterestedCount(exec sqlExecer, eventID interface{}, delta int) error {
+	_, err := exec.Exec(`UPDATE events SET interested_count = MAX(interested_count + ?, 0), updated_at = ? WHERE id = ?`,
+		delta, time.Now().UTC(), eventID)
+	return err
+}
+
+// removeInterest deletes userID's interest in eventID and reports whether there was one
+func removeInterest(exec sqlExecer, eventID interface{}, userID int) (bool, error) {
+	result, err := exec.Exec(`DELETE FROM event_interest WHERE event_id = ? AND user_id = ?`, eventID, userID)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return false, nil
+	}
+	return true, adjustInterestedCount(exec, eventID, -1)
+}
+
+// dropInterest turns an interest into a participation: call it in the transaction that adds
+// userID to the event
+func dropInterest(exec sqlExecer, eventID interface{}, userID int) error {
+	_, err := removeInterest(exec, eventID, userID)
+	return err
+}
+
+// attachInterest sets e.IsInterested for a signed-in viewer
+func attachInterest(e *Event, viewerID int) {
+	if viewerID == 0 {
+		return
+	}
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM event_interest WHERE event_id = ? AND user_id = ?)`, e.ID, viewerID).Scan(&e.IsInterested)
+	if err != nil {
+		log.Printf("⚠️  Failed to check interest in event %d: %v", e.ID, err)
+	}
+}
+
+// queueSpotsAvailable schedules the notice to interested users after a spot freed up on a full
+// event, unless one is already waiting for the event
+func queueSpotsAvailable(tx *sql.Tx, eventID int) error {
+	payload := spotsAvailablePayload{EventID: eventID}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var waiting bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM jobs WHERE type = ? AND status = ? AND payload = ?)`,
+		JobNotifyInterested, JobStatusPending, string(body)).Scan(&waiting); err != nil {
+		return err
+	}
+	if waiting {
+		return nil
+	}
+	_, err = enqueueJobAt(tx, JobNotifyInterested, payload, time.Now().Add(spotsAvailableDelay))
+	return err
+}
+
+// notifyInterestedJob mails the users interested in an event that has a free spot again. Each
+// user is told at most once per event; if the spot is gone again by the time the job runs,
+// nobody is told and they keep their chance for the next one.
+func notifyInterestedJob(ctx context.Context, payload json.RawMessage) error {
+	var p spotsAvailablePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	var title, startTime string
+	var slug, joinDeadline sql.NullString
+	var maxParticipants, reservedSpots, participantCount int
+	var cancelled, draft, hidden bool
+	err := db.QueryRowContext(ctx, `
+		SELECT title, slug, start_time, join_deadline, COALESCE(max_participants, 0), reserved_spots, participant_count,
+		       cancelled_at IS NOT NULL, published = 0, hidden_pending_review
+		FROM events WHERE id = ?
+	`, p.EventID).Scan(&title, &slug, &startTime, &joinDeadline, &maxParticipants, &reservedSpots, &participantCount, &cancelled, &draft, nullable(&hidden))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if cancelled || draft || hidden || joinWindowClosed(startTime, time.Now()) || joinDeadlinePassed(joinDeadline.String, time.Now()) ||
+		(maxParticipants > 0 && participantCount+reservedSpots >= maxParticipants) {
+		log.Printf("📭 Event %d has no free spot any more; not notifying interested users", p.EventID)
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.name
+		FROM event_interest ei JOIN users u ON u.id = ei.user_id
+		WHERE ei.event_id = ? AND ei.notified_at IS NULL
+		  AND u.email_verified = 1 AND COALESCE(u.is_blocked, 0) = 0
+		ORDER BY ei.created_at, ei.id
+	`, p.EventID)
+	if err != nil {
+		return err
+	}
+	type recipient struct {
+		id          int
+		email, name string
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.id, &r.email, &r.name); err == nil {
+			recipients = append(recipients, r)
+		}
+	}
+	rows.Close()
+
+	message := fmt.Sprintf("A spot opened up in \"%s\", which you marked as interested. Join soon if you'd like to go; spots go to whoever joins first.",
+		html.UnescapeString(title))
+	sent := 0
+	for _, r := range recipients {
+		// Mark first so a retry or a later freed spot can't mail the same user twice
+		if _, err := db.ExecContext(ctx, `
+			UPDATE event_interest SET notified_at = ? WHERE event_id = ? AND user_id = ?
+		`, time.Now().UTC(), p.EventID, r.id); err != nil {
+			return err
+		}
+		notify(db, r.id, NotificationSpotAvailable, eventNotification{EventID: p.EventID, Title: title, Slug: slug.String})
+		if err := sendModerationEmail(r.email, r.name, "A spot opened up", message, publicEventURL(slug.String)); err != nil {
+			log.Printf("⚠️  Spot notice for event %d to user %d failed: %v", p.EventID, r.id, err)
+			continue
+		}
+		sent++
+	}
+	log.Printf("📬 Told %d interested users about a free spot in event %d", sent, p.EventID)
+	return nil
+}
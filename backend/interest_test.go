@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventInterest(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+	sent := captureModerationEmails(t)
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.POST("/events/:id/interest", markInterested)
+	protected.DELETE("/events/:id/interest", unmarkInterested)
+
+	user := func(email string) string {
+		id := createTestUser(t, testDB, email, "User", "password123", false)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return token
+	}
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	eventID := createTestEvent(t, testDB, organizerID, "Boat trip")
+	_, err := testDB.Exec(`UPDATE events SET max_participants = 1 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/events/%d", eventID)
+
+	first, ann, ben := user("first@example.com"), user("ann@example.com"), user("ben@example.com")
+	getEventAs := func(token string) Event {
+		t.Helper()
+		w := doJSON(router, "GET", path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		return e
+	}
+
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", path+"/join", first, nil).Code)
+
+	t.Run("Interest is counted apart from participants", func(t *testing.T) {
+		for _, token := range []string{ann, ben, ann} {
+			w := doJSON(router, "POST", path+"/interest", token, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+		e := getEventAs(ann)
+		assert.Equal(t, 2, e.InterestedCount, "marking twice counts once")
+		assert.True(t, e.IsInterested)
+		assert.False(t, e.IsParticipant)
+		assert.Equal(t, 1, e.ParticipantCount)
+		assert.True(t, e.IsFull, "interest doesn't take a spot")
+		assert.False(t, getEventAs(first).IsInterested)
+
+		w := doJSON(router, "GET", "/api/events", ann, nil)
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, 2, events[0].InterestedCount)
+		assert.True(t, events[0].IsInterested)
+
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", path+"/interest", first, nil).Code, "participants are past interest")
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", path+"/interest", organizerToken, nil).Code)
+	})
+
+	t.Run("Leaving a full event notifies interested users once", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doJSON(router, "DELETE", path+"/leave", first, nil).Code)
+
+		var payload string
+		require.NoError(t, testDB.QueryRow(`SELECT payload FROM jobs WHERE type = ? AND status = ?`, JobNotifyInterested, JobStatusPending).Scan(&payload))
+		require.NoError(t, notifyInterestedJob(context.Background(), json.RawMessage(payload)))
+		emails := sent()
+		require.Len(t, emails, 2)
+		recipients := []string{emails[0].to, emails[1].to}
+		assert.ElementsMatch(t, []string{"ann@example.com", "ben@example.com"}, recipients)
+
+		// The spot fills and frees up again: nobody hears about it twice
+		require.Equal(t, http.StatusOK, doJSON(router, "POST", path+"/join", first, nil).Code)
+		require.Equal(t, http.StatusOK, doJSON(router, "DELETE", path+"/leave", first, nil).Code)
+		require.NoError(t, notifyInterestedJob(context.Background(), json.RawMessage(payload)))
+		assert.Len(t, sent(), 2)
+
+		var queued int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM jobs WHERE type = ?`, JobNotifyInterested).Scan(&queued))
+		assert.Equal(t, 1, queued, "leaves before the batch runs share one job")
+	})
+
+	t.Run("Joining turns interest into participation", func(t *testing.T) {
+		w := doJSON(router, "POST", path+"/join", ann, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		e := getEventAs(ann)
+		assert.True(t, e.IsParticipant)
+		assert.False(t, e.IsInterested)
+		assert.Equal(t, 1, e.InterestedCount)
+		assert.Equal(t, 1, e.ParticipantCount)
+	})
+
+	t.Run("Interest can be withdrawn", func(t *testing.T) {
+		w := doJSON(router, "DELETE", path+"/interest", ben, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, getEventAs(ben).InterestedCount)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "DELETE", path+"/interest", ben, nil).Code)
+	})
+
+	t.Run("Leaving an event that wasn't full queues nothing", func(t *testing.T) {
+		_, err := testDB.Exec(`DELETE FROM jobs`)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`UPDATE events SET max_participants = 5 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, doJSON(router, "DELETE", path+"/leave", ann, nil).Code)
+		var queued int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM jobs`).Scan(&queued))
+		assert.Zero(t, queued)
+	})
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Job statuses
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusDead    = "dead" // gave up after jobMaxAttempts; an admin can retry it
+)
+
+// Job types
+const (
+	JobSendVerificationEmail = "email.verification"
+	JobSendWelcomeEmail      = "email.welcome"
+	JobNotifyInterested      = "email.spots_available"   // Interested users of an event that has a free spot again
+	JobNotifyTransferred     = "email.event_transferred" // Participants of an event that has a new organizer
+)
+
+const (
+	jobMaxAttempts        = 5
+	jobWorkers            = 2
+	jobPollInterval       = 2 * time.Second
+	jobTimeout            = time.Minute    // per attempt; in-flight jobs are not cancelled by shutdown
+	jobLease              = 2 * jobTimeout // a job running longer than this was left behind by a stopped instance
+	defaultJobBaseBackoff = 30 * time.Second
+	maxJobBackoff         = 6 * time.Hour
+	jobRetention          = 7 * 24 * time.Hour // finished jobs are kept this long for support questions
+	jobListLimit          = 100
+)
+
+// Job is one row of the jobs table
+type Job struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	RunAt     time.Time       `json:"run_at"`
+	Attempts  int             `json:"attempts"`
+	Status    string          `json:"status"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// JobHandler performs one job; a returned error schedules a retry unless it is a *permanentJobError
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobGiveUpHandler runs once when a job of its type goes dead, with the last attempt's error
+type JobGiveUpHandler func(ctx context.Context, payload json.RawMessage, lastErr error) error
+
+// permanentJobError is a failure retrying can't fix; the job goes dead without further attempts
+type permanentJobError struct {
+	err error
+}
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+// jobHandlers maps job types to their handlers. A job whose type has no handler fails every
+// attempt and ends up dead, so an unknown type from a newer deployment isn't silently dropped.
+var jobHandlers = map[string]JobHandler{
+	JobSendVerificationEmail: sendVerificationEmailJob,
+	JobSendWelcomeEmail:      sendWelcomeEmailJob,
+	JobNotifyInterested:      notifyInterestedJob,
+	JobNotifyTransferred:     notifyTransferredJob,
+}
+
+// jobGiveUpHandlers tell someone about jobs that will never succeed
+var jobGiveUpHandlers = map[string]JobGiveUpHandler{
+	JobSendVerificationEmail: markVerificationEmailFailed,
+}
+
+// emailJobPayload is the payload of the email job types; Token is only set for verification emails
+type emailJobPayload struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty"`
+}
+
+// backgroundJobs is nil until main starts it; enqueued jobs then wait in the table for the next start
+var backgroundJobs *jobRunner
+
+// enqueueJob stores a job to run as soon as a worker is free. Pass the transaction that writes
+// the data the job is about, so the job exists exactly when that data does; call
+// backgroundJobs.Notify() after committing to skip the poll delay.
+func enqueueJob(exec sqlExecer, jobType string, payload interface{}) (int64, error) {
+	return enqueueJobAt(exec, jobType, payload, time.Now())
+}
+
+// enqueueJobAt stores a job that runs no earlier than runAt
+func enqueueJobAt(exec sqlExecer, jobType string, payload interface{}, runAt time.Time) (int64, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("encode %s payload: %w", jobType, err)
+	}
+	now := time.Now().UTC()
+	result, err := exec.Exec(`
+		INSERT INTO jobs (type, payload, run_at, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, jobType, string(body), runAt.UTC(), JobStatusPending, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", jobType, err)
+	}
+	return result.LastInsertId()
+}
+
+// jobBackoff is the delay before retrying after the given number of failed attempts:
+// base, 2x base, 4x base, ... capped at maxJobBackoff
+func jobBackoff(base time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 20 {
+		return maxJobBackoff
+	}
+	delay := base << (attempts - 1)
+	if delay > maxJobBackoff {
+		return maxJobBackoff
+	}
+	return delay
+}
+
+// jobRunner polls the jobs table from a fixed pool of workers until Shutdown
+type jobRunner struct {
+	workers      int
+	pollInterval time.Duration
+	baseBackoff  time.Duration
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newJobRunner(workers int, pollInterval, baseBackoff time.Duration) *jobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobRunner{
+		workers:      workers,
+		pollInterval: pollInterval,
+		baseBackoff:  baseBackoff,
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// startJobRunner requeues jobs a stopped instance left running and starts the workers
+func startJobRunner(workers int, pollInterval, baseBackoff time.Duration) *jobRunner {
+	r := newJobRunner(workers, pollInterval, baseBackoff)
+	requeueExpiredJobs(time.Now())
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	r.wg.Add(1)
+	go r.watchLeases()
+	return r
+}
+
+// watchLeases requeues jobs left behind by instances that stopped while this one keeps running
+func (r *jobRunner) watchLeases() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(jobLease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			if requeueExpiredJobs(time.Now()) > 0 {
+				r.Notify()
+			}
+		}
+	}
+}
+
+// requeueExpiredJobs hands jobs whose lease ran out back to the queue. Several instances may share
+// the database, so a running job is only considered interrupted once it outlived jobLease; jobs
+// another instance is still working on are left alone. It returns the number of requeued jobs.
+func requeueExpiredJobs(now time.Time) int64 {
+	result, err := db.Exec(`UPDATE jobs SET status = ?, updated_at = ? WHERE status = ? AND updated_at < ?`,
+		JobStatusPending, now.UTC(), JobStatusRunning, now.Add(-jobLease).UTC())
+	if err != nil {
+		log.Printf("⚠️  Failed to requeue interrupted jobs: %v", err)
+		return 0
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		log.Printf("🔁 Requeued %d interrupted jobs", n)
+	}
+	return n
+}
+
+// Notify wakes an idle worker, e.g. right after a handler commits a new job
+func (r *jobRunner) Notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown stops claiming new jobs and waits for in-flight ones to finish, or for ctx to expire.
+// Jobs still running at the deadline are requeued once their lease expires.
+func (r *jobRunner) Shutdown(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("🛑 Job runner stopped")
+	case <-ctx.Done():
+		log.Println("⚠️  Jobs still running at shutdown deadline")
+	}
+}
+
+func (r *jobRunner) work() {
+	defer r.wg.Done()
+	for {
+		// Drain the queue before sleeping
+		for r.ctx.Err() == nil {
+			ran, err := r.runNext()
+			if err != nil {
+				log.Printf("❌ Job runner: %v", err)
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.wake:
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// runNext claims and runs the oldest due job; it reports false when nothing is due
+func (r *jobRunner) runNext() (bool, error) {
+	job, err := claimJob(time.Now())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim job: %w", err)
+	}
+
+	runErr := runJob(job)
+	now := time.Now().UTC()
+	var permanent *permanentJobError
+	switch {
+	case runErr == nil:
+		_, err = db.Exec(`UPDATE jobs SET status = ?, last_error = NULL, updated_at = ? WHERE id = ?`,
+			JobStatusDone, now, job.ID)
+	case job.Attempts >= jobMaxAttempts || errors.As(runErr, &permanent):
+		log.Printf("❌ Job %d (%s) gave up after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+		_, err = db.Exec(`UPDATE jobs SET status = ?, last_error = ?, updated_at = ? WHERE id = ?`,
+			JobStatusDead, runErr.Error(), now, job.ID)
+		if err == nil {
+			giveUpJob(job, runErr)
+		}
+	default:
+		delay := jobBackoff(r.baseBackoff, job.Attempts)
+		log.Printf("⚠️  Job %d (%s) attempt %d failed (%v), retrying in %v", job.ID, job.Type, job.Attempts, runErr, delay)
+		_, err = db.Exec(`UPDATE jobs SET status = ?, last_error = ?, run_at = ?, updated_at = ? WHERE id = ?`,
+			JobStatusPending, runErr.Error(), now.Add(delay), now, job.ID)
+	}
+	if err != nil {
+		return true, fmt.Errorf("record job %d result: %w", job.ID, err)
+	}
+	return true, nil
+}
+
+// claimJob marks the oldest due pending job running and counts the attempt. Workers may race for
+// the same row; the status check in the UPDATE lets exactly one of them win.
+func claimJob(now time.Time) (Job, error) {
+	for {
+		var job Job
+		var payload string
+		err := db.QueryRow(`
+			SELECT id, type, payload, attempts FROM jobs
+			WHERE status = ? AND run_at <= ?
+			ORDER BY run_at ASC, id ASC
+			LIMIT 1
+		`, JobStatusPending, now.UTC()).Scan(&job.ID, &job.Type, &payload, &job.Attempts)
+		if err != nil {
+			return job, err
+		}
+		result, err := db.Exec(`UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ? AND status = ?`,
+			JobStatusRunning, time.Now().UTC(), job.ID, JobStatusPending)
+		if err != nil {
+			return job, err
+		}
+		if n, _ := result.RowsAffected(); n == 1 {
+			job.Payload = json.RawMessage(payload)
+			job.Attempts++
+			job.Status = JobStatusRunning
+			return job, nil
+		}
+	}
+}
+
+// giveUpJob runs the give-up handler of a job that just went dead, if its type has one
+func giveUpJob(job Job, lastErr error) {
+	handler, ok := jobGiveUpHandlers[job.Type]
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+	if err := handler(ctx, job.Payload, lastErr); err != nil {
+		log.Printf("⚠️  Give-up handler for job %d (%s) failed: %v", job.ID, job.Type, err)
+	}
+}
+
+// runJob calls the job's handler, turning a panic into an error so one bad job can't kill a worker
+func runJob(job Job) (err error) {
+	handler, ok := jobHandlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %q", job.Type)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+	return handler(ctx, job.Payload)
+}
+
+// queueVerificationEmail stores a fresh verification token for user and queues the email, both in tx
+func queueVerificationEmail(ctx context.Context, tx *sql.Tx, user User) error {
+	token, err := generateEmailToken()
+	if err != nil {
+		return fmt.Errorf("generate token: %w", err)
+	}
+	expiresAt := time.Now().Add(appConfig.VerificationTokenTTL)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO email_verification_tokens (user_id, token, expires_at)
+		VALUES (?, ?, ?)
+	`, user.ID, token, expiresAt); err != nil {
+		return fmt.Errorf("store token: %w", err)
+	}
+	_, err = enqueueJob(tx, JobSendVerificationEmail, emailJobPayload{Email: user.Email, Name: user.Name, Token: token})
+	return err
+}
+
+func sendVerificationEmailJob(ctx context.Context, payload json.RawMessage) error {
+	var p emailJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	if emailService == nil {
+		return errors.New("email service not configured")
+	}
+	if err := emailService.SendVerificationEmail(p.Email, p.Name, p.Token); err != nil {
+		if emailRejected(err) {
+			return &permanentJobError{err: err}
+		}
+		return err
+	}
+	log.Printf("📧 Verification email sent to: %s", p.Email)
+	// An admin may have retried a job that had failed for good
+	if err := clearVerificationEmailFailed(ctx, p.Email); err != nil {
+		log.Printf("⚠️  Failed to clear verification_email_failed for %s: %v", p.Email, err)
+	}
+	return nil
+}
+
+func sendWelcomeEmailJob(ctx context.Context, payload json.RawMessage) error {
+	var p emailJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	if emailService == nil {
+		return errors.New("email service not configured")
+	}
+	if err := emailService.SendWelcomeEmail(p.Email, p.Name); err != nil {
+		if emailRejected(err) {
+			return &permanentJobError{err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// adminListJobs lists the most recent jobs, dead ones unless ?status= says otherwise
+// (GET /api/admin/jobs)
+func adminListJobs(c *gin.Context) {
+	ctx := c.Request.Context()
+	status := c.DefaultQuery("status", JobStatusDead)
+	switch status {
+	case JobStatusPending, JobStatusRunning, JobStatusDone, JobStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, type, payload, run_at, attempts, status, last_error, created_at, updated_at
+		FROM jobs
+		WHERE status = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, status, jobListLimit)
+	if err != nil {
+		log.Printf("❌ Failed to query jobs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve jobs"})
+		return
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		var job Job
+		var payload string
+		var lastError sql.NullString
+		if err := rows.Scan(&job.ID, &job.Type, &payload, &job.RunAt, &job.Attempts, &job.Status,
+			&lastError, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			log.Printf("❌ Error scanning job: %v", err)
+			continue
+		}
+		job.Payload = json.RawMessage(payload)
+		job.LastError = lastError.String
+		jobs = append(jobs, job)
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// adminRetryJob gives a dead job a fresh set of attempts, starting now (POST /api/admin/jobs/:id/retry)
+func adminRetryJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	now := time.Now().UTC()
+	result, err := db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, attempts = 0, run_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`, JobStatusPending, now, now, id, JobStatusDead)
+	if err != nil {
+		log.Printf("❌ Failed to retry job %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var status string
+		if err := db.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = ?`, id).Scan(&status); err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Only dead jobs can be retried", "status": status})
+		return
+	}
+	backgroundJobs.Notify()
+
+	log.Printf("🔁 Admin %d requeued job %d", c.GetInt("user_id"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "Job requeued"})
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useJobHandler registers handler for jobType for the duration of the test
+func useJobHandler(t *testing.T, jobType string, handler JobHandler) {
+	t.Helper()
+	jobHandlers[jobType] = handler
+	t.Cleanup(func() { delete(jobHandlers, jobType) })
+}
+
+func jobStatus(t *testing.T, id int64) (status string, attempts int, runAt time.Time, lastError string) {
+	t.Helper()
+	var errMsg *string
+	require.NoError(t, db.QueryRow(`SELECT status, attempts, run_at, last_error FROM jobs WHERE id = ?`, id).
+		Scan(&status, &attempts, &runAt, &errMsg))
+	if errMsg != nil {
+		lastError = *errMsg
+	}
+	return status, attempts, runAt, lastError
+}
+
+func TestJobBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, jobBackoff(time.Second, 1))
+	assert.Equal(t, 2*time.Second, jobBackoff(time.Second, 2))
+	assert.Equal(t, 8*time.Second, jobBackoff(time.Second, 4))
+	assert.Equal(t, maxJobBackoff, jobBackoff(time.Hour, 10))
+	assert.Equal(t, maxJobBackoff, jobBackoff(time.Second, 64))
+}
+
+func TestJobRunner(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	t.Run("Enqueued jobs run once", func(t *testing.T) {
+		got := make(chan string, 2)
+		useJobHandler(t, "test.echo", func(ctx context.Context, payload json.RawMessage) error {
+			var p struct{ Message string }
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			got <- p.Message
+			return nil
+		})
+
+		runner := startJobRunner(2, 10*time.Millisecond, time.Hour)
+		defer runner.Shutdown(context.Background())
+
+		id, err := enqueueJob(db, "test.echo", gin.H{"message": "hello"})
+		require.NoError(t, err)
+		runner.Notify()
+
+		select {
+		case msg := <-got:
+			assert.Equal(t, "hello", msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("job did not run")
+		}
+		require.Eventually(t, func() bool {
+			status, _, _, _ := jobStatus(t, id)
+			return status == JobStatusDone
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, attempts, _, _ := jobStatus(t, id)
+		assert.Equal(t, 1, attempts)
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, got, "a finished job is not picked up again")
+	})
+
+	t.Run("Failing jobs back off and end up dead", func(t *testing.T) {
+		calls := 0
+		useJobHandler(t, "test.fail", func(ctx context.Context, payload json.RawMessage) error {
+			calls++
+			return fmt.Errorf("mail provider down (call %d)", calls)
+		})
+		// No workers: the test drives the runner one job at a time
+		runner := newJobRunner(0, time.Hour, time.Minute)
+
+		id, err := enqueueJob(db, "test.fail", nil)
+		require.NoError(t, err)
+
+		ran, err := runner.runNext()
+		require.NoError(t, err)
+		assert.True(t, ran)
+		status, attempts, runAt, lastError := jobStatus(t, id)
+		assert.Equal(t, JobStatusPending, status)
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, "mail provider down (call 1)", lastError)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), runAt, 5*time.Second)
+
+		ran, err = runner.runNext()
+		require.NoError(t, err)
+		assert.False(t, ran, "the retry isn't due yet")
+
+		for i := 2; i <= jobMaxAttempts; i++ {
+			_, err := db.Exec(`UPDATE jobs SET run_at = ? WHERE id = ?`, time.Now().Add(-time.Second).UTC(), id)
+			require.NoError(t, err)
+			ran, err = runner.runNext()
+			require.NoError(t, err)
+			require.True(t, ran)
+
+			status, attempts, runAt, _ = jobStatus(t, id)
+			assert.Equal(t, i, attempts)
+			if i < jobMaxAttempts {
+				assert.Equal(t, JobStatusPending, status)
+				assert.WithinDuration(t, time.Now().Add(jobBackoff(time.Minute, i)), runAt, 5*time.Second)
+			}
+		}
+		assert.Equal(t, JobStatusDead, status)
+		assert.Equal(t, jobMaxAttempts, calls)
+
+		ran, err = runner.runNext()
+		require.NoError(t, err)
+		assert.False(t, ran, "dead jobs are not retried on their own")
+	})
+
+	t.Run("Panics and unknown types count as failures", func(t *testing.T) {
+		useJobHandler(t, "test.panic", func(ctx context.Context, payload json.RawMessage) error {
+			panic("boom")
+		})
+		runner := newJobRunner(0, time.Hour, time.Minute)
+
+		panicID, err := enqueueJob(db, "test.panic", nil)
+		require.NoError(t, err)
+		unknownID, err := enqueueJob(db, "test.unknown", nil)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			ran, err := runner.runNext()
+			require.NoError(t, err)
+			require.True(t, ran)
+		}
+		_, _, _, lastError := jobStatus(t, panicID)
+		assert.Equal(t, "panic: boom", lastError)
+		_, _, _, lastError = jobStatus(t, unknownID)
+		assert.Contains(t, lastError, "no handler")
+	})
+
+	t.Run("Jobs left running by a crash are requeued once their lease expires", func(t *testing.T) {
+		got := make(chan struct{}, 1)
+		useJobHandler(t, "test.crash", func(ctx context.Context, payload json.RawMessage) error {
+			got <- struct{}{}
+			return nil
+		})
+		id, err := enqueueJob(db, "test.crash", nil)
+		require.NoError(t, err)
+		_, err = db.Exec(`UPDATE jobs SET status = ?, attempts = 1, updated_at = ? WHERE id = ?`,
+			JobStatusRunning, time.Now().Add(-jobLease-time.Minute).UTC(), id)
+		require.NoError(t, err)
+
+		runner := startJobRunner(1, 10*time.Millisecond, time.Hour)
+		defer runner.Shutdown(context.Background())
+		select {
+		case <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("interrupted job was not requeued")
+		}
+	})
+}
+
+func TestJobRunnerShutdownWaitsForInFlightJobs(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	useJobHandler(t, "test.slow", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	runner := startJobRunner(1, 10*time.Millisecond, time.Hour)
+	id, err := enqueueJob(db, "test.slow", nil)
+	require.NoError(t, err)
+	runner.Notify()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		runner.Shutdown(context.Background())
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Shutdown returned while a job was still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return after the job finished")
+	}
+
+	status, _, _, _ := jobStatus(t, id)
+	assert.Equal(t, JobStatusDone, status, "the in-flight job was recorded before shutdown returned")
+
+	// Nothing new is claimed after shutdown
+	laterID, err := enqueueJob(db, "test.slow", nil)
+	require.NoError(t, err)
+	runner.Notify()
+	time.Sleep(50 * time.Millisecond)
+	status, _, _, _ = jobStatus(t, laterID)
+	assert.Equal(t, JobStatusPending, status)
+}
+
+func TestAdminJobs(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/jobs", adminListJobs)
+	admin.POST("/jobs/:id/retry", adminRetryJob)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	deadID, err := enqueueJob(db, JobSendWelcomeEmail, emailJobPayload{Email: "x@example.com", Name: "X"})
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE jobs SET status = ?, attempts = ?, last_error = 'mailgun: 401' WHERE id = ?`, JobStatusDead, jobMaxAttempts, deadID)
+	require.NoError(t, err)
+	pendingID, err := enqueueJob(db, JobSendWelcomeEmail, emailJobPayload{Email: "y@example.com", Name: "Y"})
+	require.NoError(t, err)
+
+	t.Run("Lists dead jobs by default", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/admin/jobs", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var jobs []Job
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+		require.Len(t, jobs, 1)
+		assert.Equal(t, int(deadID), jobs[0].ID)
+		assert.Equal(t, "mailgun: 401", jobs[0].LastError)
+		assert.JSONEq(t, `{"email":"x@example.com","name":"X"}`, string(jobs[0].Payload))
+
+		w = doJSON(router, "GET", "/api/admin/jobs?status=pending", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+		require.Len(t, jobs, 1)
+		assert.Equal(t, int(pendingID), jobs[0].ID)
+
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/admin/jobs?status=failed", adminToken, nil).Code)
+		assert.Equal(t, http.StatusForbidden, doJSON(router, "GET", "/api/admin/jobs", userToken, nil).Code)
+	})
+
+	t.Run("Retry requeues only dead jobs", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/jobs/%d/retry", deadID), adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		status, attempts, runAt, lastError := jobStatus(t, deadID)
+		assert.Equal(t, JobStatusPending, status)
+		assert.Zero(t, attempts)
+		assert.WithinDuration(t, time.Now(), runAt, 5*time.Second)
+		assert.Equal(t, "mailgun: 401", lastError, "the last error stays visible until the next attempt")
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/admin/jobs/%d/retry", pendingID), adminToken, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = doJSON(router, "POST", "/api/admin/jobs/9999/retry", adminToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEmailsAreQueuedAsJobs(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MailgunDomain = "mg.example.com"
+		cfg.MailgunAPIKey = "test-api-key"
+		cfg.MailgunFromEmail = "noreply@example.com"
+	})
+	emailService = NewEmailService()
+	defer func() { emailService = nil }()
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+	router.GET("/api/auth/verify-email", VerifyEmail)
+
+	queued := func(jobType string) []emailJobPayload {
+		t.Helper()
+		rows, err := db.Query(`SELECT payload FROM jobs WHERE type = ? AND status = ?`, jobType, JobStatusPending)
+		require.NoError(t, err)
+		defer rows.Close()
+		var payloads []emailJobPayload
+		for rows.Next() {
+			var raw string
+			require.NoError(t, rows.Scan(&raw))
+			var p emailJobPayload
+			require.NoError(t, json.Unmarshal([]byte(raw), &p))
+			payloads = append(payloads, p)
+		}
+		return payloads
+	}
+
+	w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "new@example.com", "password": "password123", "name": "Newbie"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	verification := queued(JobSendVerificationEmail)
+	require.Len(t, verification, 1)
+	assert.Equal(t, "new@example.com", verification[0].Email)
+	assert.Equal(t, "Newbie", verification[0].Name)
+	var stored string
+	require.NoError(t, db.QueryRow(`SELECT token FROM email_verification_tokens`).Scan(&stored))
+	assert.Equal(t, stored, verification[0].Token, "the job carries the token committed with the user")
+
+	w = doJSON(router, "GET", "/api/auth/verify-email?token="+stored, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	welcome := queued(JobSendWelcomeEmail)
+	require.Len(t, welcome, 1)
+	assert.Equal(t, emailJobPayload{Email: "new@example.com", Name: "Newbie"}, welcome[0])
+
+	t.Run("A failed registration queues nothing", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/auth/register", "", gin.H{"email": "NEW@example.com", "password": "password123", "name": "Again"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Len(t, queued(JobSendVerificationEmail), 1)
+	})
+}
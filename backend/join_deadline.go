@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"time"
+)
+
+// ErrCodeJoinClosed is returned when joining or marking interest after the event's join deadline
+const ErrCodeJoinClosed = "JOIN_CLOSED"
+
+// joinDeadlineReminderBefore is how long before the join deadline interested users hear it is coming
+const joinDeadlineReminderBefore = 24 * time.Hour
+
+// parseJoinDeadline reads the client-supplied join_deadline like the start and end times and writes it
+// back onto the event as RFC3339 UTC. It returns nil when the event has no deadline.
+func parseJoinDeadline(event *Event, loc *time.Location) (*time.Time, error) {
+	if event.JoinDeadline == "" {
+		return nil, nil
+	}
+	deadline, err := parseEventTime(event.JoinDeadline, loc)
+	if err != nil {
+		return nil, err
+	}
+	event.JoinDeadline = deadline.Format(time.RFC3339)
+	return &deadline, nil
+}
+
+// joinDeadlineAt is the stored or serialized join deadline as a time; nil without one. Unparseable
+// values count as no deadline rather than locking people out on bad data.
+func joinDeadlineAt(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	deadline, err := parseEventTime(value, time.UTC)
+	if err != nil {
+		return nil
+	}
+	return &deadline
+}
+
+// joinDeadlinePassed reports whether signups closed at the event's join deadline; joins close at
+// the deadline itself. Events without one only close at the start (see joinWindowClosed).
+func joinDeadlinePassed(value string, now time.Time) bool {
+	deadline := joinDeadlineAt(value)
+	return deadline != nil && !now.Before(*deadline)
+}
+
+// formatJoinDeadline renders a deadline for emails and calendar files in the event's zone
+func formatJoinDeadline(deadline time.Time, timezone string) string {
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return deadline.In(loc).Format("Mon 2 Jan 2006, 15:04 MST")
+}
+
+// remindJoinDeadlines tells users interested in an event that its signups close within
+// joinDeadlineReminderBefore, once per deadline. Events nobody can join anyway (cancelled, paused,
+// full, hidden or drafts) are skipped.
+func remindJoinDeadlines(now time.Time) (int64, error) {
+	rows, err := db.Query(`
+		SELECT e.id, e.title, COALESCE(e.slug, ''), e.join_deadline, e.timezone
+		FROM events e
+		WHERE e.join_deadline > ? AND e.join_deadline <= ? AND e.deadline_reminded_at IS NULL
+		  AND e.published = 1 AND e.hidden_pending_review = 0 AND e.cancelled_at IS NULL AND e.joins_paused = 0
+		  AND (COALESCE(e.max_participants, 0) = 0 OR e.participant_count + e.reserved_spots < e.max_participants)
+	`, now.UTC(), now.Add(joinDeadlineReminderBefore).UTC())
+	if err != nil {
+		return 0, err
+	}
+	type reminder struct {
+		id                              int
+		title, slug, deadline, timezone string
+	}
+	var reminders []reminder
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.id, &r.title, &r.slug, &r.deadline, &r.timezone); err == nil {
+			reminders = append(reminders, r)
+		}
+	}
+	rows.Close()
+
+	var sent int64
+	for _, r := range reminders {
+		// Claimed first, like draft reminders, so a slow mail server or a second instance can't
+		// remind the same users twice
+		result, err := db.Exec(`UPDATE events SET deadline_reminded_at = ? WHERE id = ? AND deadline_reminded_at IS NULL`, now.UTC(), r.id)
+		if err != nil {
+			return sent, err
+		}
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+		deadline := joinDeadlineAt(r.deadline)
+		if deadline == nil {
+			continue
+		}
+		n, err := sendJoinDeadlineReminders(r.id, r.title, r.slug, formatJoinDeadline(*deadline, r.timezone))
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// sendJoinDeadlineReminders notifies and emails the verified, unblocked users interested in one event
+func sendJoinDeadlineReminders(eventID int, title, slug, deadline string) (int64, error) {
+	rows, err := db.Query(`
+		SELECT u.id, u.email, u.name
+		FROM event_interest ei JOIN users u ON u.id = ei.user_id
+		WHERE ei.event_id = ? AND u.email_verified = 1 AND COALESCE(u.is_blocked, 0) = 0
+		  AND NOT EXISTS (SELECT 1 FROM event_participants p WHERE p.event_id = ei.event_id AND p.user_id = ei.user_id)
+		ORDER BY ei.created_at, ei.id
+	`, eventID)
+	if err != nil {
+		return 0, err
+	}
+	type recipient struct {
+		id          int
+		email, name string
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.id, &r.email, &r.name); err == nil {
+			recipients = append(recipients, r)
+		}
+	}
+	rows.Close()
+
+	message := fmt.Sprintf("Signups for \"%s\", which you marked as interested, close on %s. Join before then if you'd like to go.",
+		html.UnescapeString(title), deadline)
+	var sent int64
+	for _, r := range recipients {
+		notify(db, r.id, NotificationJoinDeadline, eventNotification{EventID: eventID, Title: title, Slug: slug})
+		if err := sendModerationEmail(r.email, r.name, "Last chance to join", message, publicEventURL(slug)); err != nil {
+			log.Printf("⚠️  Join deadline reminder for event %d to user %d failed: %v", eventID, r.id, err)
+			continue
+		}
+		sent++
+	}
+	log.Printf("📬 Reminded %d interested users that signups for event %d close soon", sent, eventID)
+	return sent, nil
+}
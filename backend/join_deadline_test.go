@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinDeadlinePassed(t *testing.T) {
+	deadline := time.Date(2030, 5, 16, 18, 0, 0, 0, time.UTC)
+	value := deadline.Format(time.RFC3339)
+
+	assert.False(t, joinDeadlinePassed(value, deadline.Add(-time.Nanosecond)))
+	assert.True(t, joinDeadlinePassed(value, deadline), "joins close at the deadline itself")
+	assert.True(t, joinDeadlinePassed(value, deadline.Add(time.Second)))
+	assert.False(t, joinDeadlinePassed("", deadline.AddDate(1, 0, 0)), "no deadline, no cutoff")
+	assert.False(t, joinDeadlinePassed("next thursday", deadline.AddDate(1, 0, 0)), "unreadable deadlines don't lock people out")
+}
+
+func TestValidateJoinDeadline(t *testing.T) {
+	start := time.Now().Add(48 * time.Hour)
+	before := start.Add(-24 * time.Hour)
+	after := start.Add(time.Minute)
+	passed := time.Now().Add(-time.Hour)
+
+	assert.NoError(t, ValidateJoinDeadline(nil, start))
+	assert.NoError(t, ValidateJoinDeadline(&before, start))
+	assert.NoError(t, ValidateJoinDeadline(&passed, start), "a passed deadline closes signups right away")
+
+	for _, deadline := range []time.Time{start, after} {
+		err := ValidateJoinDeadline(&deadline, start)
+		var timeErr *EventTimeError
+		require.ErrorAs(t, err, &timeErr)
+		assert.Equal(t, "join_deadline", timeErr.Field)
+		assert.Equal(t, ErrCodeDeadlineAfterStart, timeErr.Code)
+	}
+}
+
+func TestJoinDeadlineEndpoints(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.JoinGracePeriod = 30 * time.Minute })
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/events/:id/join-eligibility", optionalAuthMiddleware(), getJoinEligibility)
+	router.GET("/api/public/events/:slug/ics", optionalAuthMiddleware(), downloadEventICS)
+	protected := router.Group("/api", authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	protected.POST("/events/:id/interest", markInterested)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+
+	payload := func(start time.Time, deadline string) gin.H {
+		return gin.H{
+			"title": "Pub quiz", "description": "Teams of four, the table is booked ahead",
+			"category": "social_drinks", "latitude": 47.3667, "longitude": 8.55,
+			"start_time": start.UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+			"timezone": "Europe/Zurich", "join_deadline": deadline,
+		}
+	}
+	refusedCode := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var out map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		code, _ := out["code"].(string)
+		return code
+	}
+	// setTimes stores start and deadline relative to now, the way the handlers store them
+	setTimes := func(eventID int64, start time.Duration, deadline *time.Duration) {
+		t.Helper()
+		var value interface{}
+		if deadline != nil {
+			value = time.Now().Add(*deadline).UTC()
+		}
+		_, err := testDB.Exec(`UPDATE events SET start_time = ?, join_deadline = ? WHERE id = ?`, time.Now().Add(start).UTC(), value, eventID)
+		require.NoError(t, err)
+	}
+	hours := func(h float64) *time.Duration {
+		d := time.Duration(h * float64(time.Hour))
+		return &d
+	}
+	reasons := func(eventID int64) []string {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/join-eligibility", eventID), aliceToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var eligibility JoinEligibility
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &eligibility))
+		return eligibility.Reasons
+	}
+	joinClosed := func(eventID int64) bool {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event.JoinClosed
+	}
+
+	t.Run("The deadline must come before the start", func(t *testing.T) {
+		start := time.Now().Add(72 * time.Hour).Truncate(time.Second)
+		for _, deadline := range []time.Time{start, start.Add(time.Hour)} {
+			w := doJSON(router, "POST", "/api/events", organizerToken, payload(start, deadline.UTC().Format(time.RFC3339)))
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.Equal(t, ErrCodeDeadlineAfterStart, refusedCode(w))
+			assert.Contains(t, w.Body.String(), `"field":"join_deadline"`)
+		}
+		w := doJSON(router, "POST", "/api/events", organizerToken, payload(start, "soon"))
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		// Wall-clock deadlines are read in the event's zone, like the start
+		zurich, err := time.LoadLocation("Europe/Zurich")
+		require.NoError(t, err)
+		wall := start.Add(-48 * time.Hour).In(zurich).Format("2006-01-02T15:04")
+		w = doJSON(router, "POST", "/api/events", organizerToken, payload(start, wall))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, start.Add(-48*time.Hour).Truncate(time.Minute).UTC().Format(time.RFC3339), created.JoinDeadline)
+		assert.False(t, created.JoinClosed)
+
+		// Moving the start before the deadline is refused as well
+		path := fmt.Sprintf("/api/events/%d", created.ID)
+		w = doJSON(router, "PUT", path, organizerToken, payload(start.Add(-60*time.Hour), created.JoinDeadline))
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Equal(t, ErrCodeDeadlineAfterStart, refusedCode(w))
+
+		// Sending no deadline removes it
+		w = doJSON(router, "PUT", path, organizerToken, payload(start, ""))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND join_deadline IS NULL`, created.ID))
+	})
+
+	t.Run("Joins close at the deadline with JOIN_CLOSED", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Closed quiz")
+		setTimes(eventID, 48*time.Hour, hours(-1))
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), aliceToken, nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Equal(t, ErrCodeJoinClosed, refusedCode(w))
+		assert.Equal(t, []string{ErrCodeJoinClosed}, reasons(eventID))
+		assert.True(t, joinClosed(eventID))
+
+		// The interest list waits for a spot to join, so it closes too
+		w = doJSON(router, "POST", fmt.Sprintf("/api/events/%d/interest", eventID), aliceToken, nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Equal(t, ErrCodeJoinClosed, refusedCode(w))
+
+		open := createTestEvent(t, testDB, organizerID, "Open quiz")
+		setTimes(open, 48*time.Hour, hours(1))
+		assert.Empty(t, reasons(open))
+		assert.False(t, joinClosed(open))
+		w = doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", open), aliceToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("The deadline and the started-event rule", func(t *testing.T) {
+		// Within the grace period without a deadline, joining still works
+		graced := createTestEvent(t, testDB, organizerID, "Late quiz")
+		setTimes(graced, -10*time.Minute, nil)
+		assert.Empty(t, reasons(graced))
+
+		// A deadline closes joins before the grace period would
+		deadlined := createTestEvent(t, testDB, organizerID, "Strict quiz")
+		setTimes(deadlined, -10*time.Minute, hours(-1))
+		assert.Equal(t, []string{ErrCodeJoinClosed}, reasons(deadlined))
+
+		// Once the event started, that is the one reason given
+		started := createTestEvent(t, testDB, organizerID, "Old quiz")
+		setTimes(started, -2*time.Hour, hours(-3))
+		assert.Equal(t, []string{ErrCodeEventStarted}, reasons(started))
+	})
+
+	t.Run("The calendar file names the deadline", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, organizerID, "Calendar quiz")
+		deadline := time.Date(2030, 5, 16, 16, 0, 0, 0, time.UTC)
+		_, err := testDB.Exec(`UPDATE events SET slug = 'calendar-quiz', start_time = ?, join_deadline = ?, timezone = 'Europe/Zurich' WHERE id = ?`,
+			deadline.Add(48*time.Hour), deadline, eventID)
+		require.NoError(t, err)
+
+		w := doJSON(router, "GET", "/api/public/events/calendar-quiz/ics", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		unfolded := strings.ReplaceAll(w.Body.String(), "\r\n ", "")
+		assert.Contains(t, unfolded, `Join by: Thu 16 May 2030\, 18:00 CEST`)
+	})
+}
+
+func TestJoinDeadlineReminders(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	sent := captureModerationEmails(t)
+
+	router := gin.New()
+	router.PUT("/api/events/:id", authMiddleware(), updateEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	joinedID := createTestUser(t, testDB, "joined@example.com", "Jo", "password123", false)
+	unverifiedID := createTestUser(t, testDB, "unverified@example.com", "Uma", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, unverifiedID)
+	require.NoError(t, err)
+
+	now := time.Now()
+	eventID := createTestEvent(t, testDB, organizerID, "Pub quiz")
+	_, err = testDB.Exec(`UPDATE events SET start_time = ?, join_deadline = ? WHERE id = ?`,
+		now.Add(72*time.Hour).UTC(), now.Add(48*time.Hour).UTC(), eventID)
+	require.NoError(t, err)
+	for _, userID := range []int64{aliceID, bobID, joinedID, unverifiedID} {
+		_, err := testDB.Exec(`INSERT INTO event_interest (event_id, user_id) VALUES (?, ?)`, eventID, userID)
+		require.NoError(t, err)
+	}
+	addParticipant(t, testDB, eventID, joinedID)
+	noDeadline := createTestEvent(t, testDB, organizerID, "Open quiz")
+	_, err = testDB.Exec(`INSERT INTO event_interest (event_id, user_id) VALUES (?, ?)`, noDeadline, aliceID)
+	require.NoError(t, err)
+
+	t.Run("Nothing before the last day", func(t *testing.T) {
+		count, err := remindJoinDeadlines(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+		assert.Empty(t, sent())
+	})
+
+	t.Run("Interested users hear a day ahead, once", func(t *testing.T) {
+		result, err := runCleanup(now.Add(30 * time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.DeadlineReminders, "verified interested users who haven't joined")
+		emails := sent()
+		require.Len(t, emails, 2)
+		assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, []string{emails[0].to, emails[1].to})
+		assert.Equal(t, "Last chance to join", emails[0].subject)
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT COUNT(*) FROM notifications WHERE type = ?`, NotificationJoinDeadline))
+
+		count, err := remindJoinDeadlines(now.Add(31 * time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Moving the deadline re-arms the reminder", func(t *testing.T) {
+		start := now.Add(72 * time.Hour).UTC().Truncate(time.Second)
+		body := gin.H{
+			"title": "Pub quiz", "description": "Teams of four, the table is booked ahead",
+			"category": "social_drinks", "latitude": 47.3667, "longitude": 8.55,
+			"start_time": start.Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+			"join_deadline": start.Add(-12 * time.Hour).Format(time.RFC3339),
+		}
+		path := fmt.Sprintf("/api/events/%d", eventID)
+		w := doJSON(router, "PUT", path, organizerToken, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND deadline_reminded_at IS NULL`, eventID))
+
+		// Saving it unchanged keeps the marker
+		_, err := testDB.Exec(`UPDATE events SET deadline_reminded_at = ? WHERE id = ?`, now.UTC(), eventID)
+		require.NoError(t, err)
+		w = doJSON(router, "PUT", path, organizerToken, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND deadline_reminded_at IS NULL`, eventID))
+	})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reasons a join is refused, next to the age, gender, account-age, cap and network codes the
+// shared checks already use
+const (
+	ErrCodeNeedsLogin       = "NEEDS_LOGIN"
+	ErrCodeEmailNotVerified = "EMAIL_NOT_VERIFIED"
+	ErrCodeEventCancelled   = "EVENT_CANCELLED"
+	ErrCodeEventFull        = "EVENT_FULL"
+	ErrCodeAlreadyJoined    = "ALREADY_JOINED"
+	ErrCodeUserBlocked      = "USER_BLOCKED"
+)
+
+// errJoinEventNotFound is returned by evaluateJoin for missing events, drafts and, except to their
+// organizer and admins, events hidden pending review
+var errJoinEventNotFound = errors.New("event not found")
+
+// joinViewer is who evaluateJoin checks; ID 0 is an anonymous visitor
+type joinViewer struct {
+	ID       int
+	Admin    bool
+	Verified bool
+}
+
+// joinQuerier is satisfied by both *sql.DB and *sql.Tx, so joinEvent can evaluate inside its transaction
+type joinQuerier interface {
+	sqlQueryRower
+	sqlQueryer
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// joinRefusal is one reason a join fails, with the response joinEvent sends for it
+type joinRefusal struct {
+	Code    string
+	respond func(c *gin.Context)
+}
+
+// joinEvaluation lists every reason the viewer can't join, in the order joinEvent reports them
+type joinEvaluation struct {
+	refusals []joinRefusal
+}
+
+func (e *joinEvaluation) refuse(status int, code, message string) {
+	e.refusals = append(e.refusals, joinRefusal{Code: code, respond: func(c *gin.Context) {
+		c.JSON(status, gin.H{"error": message, "code": code})
+	}})
+}
+
+// Reasons returns the refusal codes, never nil
+func (e *joinEvaluation) Reasons() []string {
+	reasons := []string{}
+	for _, r := range e.refusals {
+		reasons = append(reasons, r.Code)
+	}
+	return reasons
+}
+
+// evaluateJoin runs every check joinEvent applies before it inserts the participant. Anonymous
+// viewers get NEEDS_LOGIN plus the reasons that concern the event itself. Question answers are
+// checked separately since they come with the join request.
+func evaluateJoin(ctx context.Context, q joinQuerier, eventID int, viewer joinViewer) (*joinEvaluation, error) {
+	var maxParticipants, birthYear sql.NullInt64
+	var currentCount, reservedSpots, ageMin, ageMax int
+	var isCancelled, joinsPaused, requireBirthYear, isDraft, hidden, joined, blocked bool
+	var genderRestriction, gender, joinDeadline sql.NullString
+	var startTime string
+	var organizerID, networkJoinLimit int
+	err := q.QueryRowContext(ctx, `
+		SELECT e.user_id, COALESCE(e.max_joins_per_network, 0), e.max_participants, e.reserved_spots, e.start_time, e.join_deadline,
+		       (SELECT COUNT(*) FROM event_participants WHERE event_id = e.id),
+		       e.cancelled_at IS NOT NULL, e.joins_paused,
+		       COALESCE(e.age_min, 0), COALESCE(e.age_max, 99), e.require_birth_year, e.gender_restriction,
+		       (SELECT birth_year FROM users WHERE id = ?),
+		       (SELECT gender FROM users WHERE id = ?),
+		       e.published = 0, e.hidden_pending_review,
+		       EXISTS (SELECT 1 FROM event_participants WHERE event_id = e.id AND user_id = ?),
+		       EXISTS (SELECT 1 FROM user_blocks
+		               WHERE (blocker_id = e.user_id AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = e.user_id))
+		FROM events e WHERE e.id = ?
+	`, viewer.ID, viewer.ID, viewer.ID, viewer.ID, viewer.ID, eventID).Scan(&organizerID, &networkJoinLimit, &maxParticipants, &reservedSpots, &startTime, &joinDeadline, &currentCount, &isCancelled, &joinsPaused,
+		&ageMin, &ageMax, nullable(&requireBirthYear), &genderRestriction, &birthYear, &gender, &isDraft, nullable(&hidden), &joined, &blocked)
+
+	// Drafts can't be joined by anyone until they are published. Events under review look deleted
+	// to everyone but their organizer and admins, as they do everywhere else.
+	if err == sql.ErrNoRows || (err == nil && (isDraft || (hidden && viewer.ID != organizerID && !viewer.Admin))) {
+		return nil, errJoinEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &joinEvaluation{}
+	if viewer.ID == 0 {
+		eval.refuse(http.StatusUnauthorized, ErrCodeNeedsLogin, "Please log in to join events")
+	} else if !viewer.Verified && !viewer.Admin {
+		// Email verification is required for all event joins (except admins)
+		eval.refuse(http.StatusForbidden, ErrCodeEmailNotVerified, "You must verify your email address before joining events. Please check your email for the verification link.")
+	}
+
+	if isCancelled {
+		eval.refuse(http.StatusBadRequest, ErrCodeEventCancelled, "This event has been cancelled")
+	}
+	if joinsPaused {
+		eval.refuse(http.StatusForbidden, ErrCodeJoinsPaused, "The organizer has paused joining for now")
+	}
+	// A started event reports that rather than its (necessarily earlier) join deadline
+	if joinWindowClosed(startTime, time.Now()) {
+		eval.refuse(http.StatusBadRequest, ErrCodeEventStarted, "This event has already started")
+	} else if joinDeadlinePassed(joinDeadline.String, time.Now()) {
+		eval.refuse(http.StatusBadRequest, ErrCodeJoinClosed, "Signups for this event have closed")
+	}
+	// Check capacity (0 means unlimited, as in spotsLeft); the organizer's reserved spots are taken.
+	// A participant's own spot is already counted.
+	if maxParticipants.Valid && maxParticipants.Int64 > 0 && currentCount+reservedSpots >= int(maxParticipants.Int64) && !joined {
+		eval.refuse(http.StatusBadRequest, ErrCodeEventFull, "Event is full")
+	}
+	if viewer.ID == 0 {
+		return eval, nil
+	}
+
+	if joined {
+		eval.refuse(http.StatusBadRequest, ErrCodeAlreadyJoined, "Already joined this event")
+	}
+	if blocked && !viewer.Admin {
+		eval.refuse(http.StatusForbidden, ErrCodeUserBlocked, "You can't join this event")
+	}
+	if code, message := checkJoinAge(birthYear, ageMin, ageMax, requireBirthYear); code != "" {
+		eval.refuse(http.StatusForbidden, code, message)
+	}
+	if code, message := checkJoinGender(genderRestriction.String, gender.String); code != "" && !viewer.Admin {
+		eval.refuse(http.StatusForbidden, code, message)
+	}
+	// Admins skip the anti-abuse rules, and a participant already holds their spot
+	if viewer.Admin || joined {
+		return eval, nil
+	}
+
+	// Throwaway accounts wait MinAccountAgeToJoin; in joinEvent the caps are counted in the same
+	// transaction as the insert so parallel joins can't slip past them
+	var newAccountErr *NewAccountError
+	if err := checkNewAccountJoin(q, viewer.ID, time.Now()); errors.As(err, &newAccountErr) {
+		eval.refusals = append(eval.refusals, joinRefusal{Code: newAccountErr.Code, respond: newAccountErr.respond})
+	} else if err != nil {
+		return nil, err
+	}
+
+	var limitErr *LimitReachedError
+	if err := checkUpcomingJoinLimit(q, viewer.ID); errors.As(err, &limitErr) {
+		eval.refusals = append(eval.refusals, joinRefusal{Code: ErrCodeLimitReached, respond: limitErr.respond})
+	} else if err != nil {
+		return nil, err
+	}
+
+	// One person hoarding spots with several accounts shows up as several participants from one
+	// network or company domain; organizers can cap that per event
+	if networkJoinLimit > 0 && viewer.ID != organizerID {
+		var networkErr *NetworkLimitError
+		if err := checkNetworkJoinLimit(ctx, q, eventID, organizerID, viewer.ID, networkJoinLimit); errors.As(err, &networkErr) {
+			eval.refusals = append(eval.refusals, joinRefusal{Code: ErrCodeNetworkLimit, respond: networkErr.respond})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return eval, nil
+}
+
+// JoinEligibility tells the join button in advance whether joining would fail and why
+type JoinEligibility struct {
+	CanJoin bool     `json:"can_join"`
+	Reasons []string `json:"reasons"`
+}
+
+// getJoinEligibility answers whether the viewer could join an event right now
+// (GET /api/events/:id/join-eligibility, optional auth)
+func getJoinEligibility(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	viewer := joinViewer{ID: c.GetInt("user_id"), Admin: c.GetBool("is_admin"), Verified: c.GetBool("email_verified")}
+
+	eval, err := evaluateJoin(c.Request.Context(), db, eventID, viewer)
+	if errors.Is(err, errJoinEventNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to evaluate join eligibility for event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check eligibility"})
+		return
+	}
+
+	reasons := eval.Reasons()
+	c.JSON(http.StatusOK, JoinEligibility{CanJoin: len(reasons) == 0, Reasons: reasons})
+}
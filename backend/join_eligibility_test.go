@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinEligibility(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MaxUpcomingJoins = 10
+		cfg.MinAccountAgeToJoin = 0
+	})
+
+	router := gin.New()
+	router.GET("/api/events/:id/join-eligibility", optionalAuthMiddleware(), getJoinEligibility)
+	router.POST("/api/events/:id/join", authMiddleware(), joinEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	seq := 0
+	newUser := func() (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, fmt.Sprintf("User %d", seq), "password123", false)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	newEvent := func(changes string) int64 {
+		seq++
+		id := createTestEvent(t, testDB, organizerID, fmt.Sprintf("Event %d", seq))
+		if changes != "" {
+			_, err := testDB.Exec(`UPDATE events SET `+changes+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+		return id
+	}
+	exec := func(query string, args ...interface{}) {
+		_, err := testDB.Exec(query, args...)
+		require.NoError(t, err)
+	}
+	eligibility := func(token string, eventID int64) JoinEligibility {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/join-eligibility", eventID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result JoinEligibility
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+	// agrees joins after asking and checks joinEvent refuses with the first advertised reason
+	agrees := func(token string, eventID int64, reasons ...string) {
+		t.Helper()
+		if reasons == nil {
+			reasons = []string{}
+		}
+		result := eligibility(token, eventID)
+		assert.Equal(t, reasons, result.Reasons)
+		assert.Equal(t, len(reasons) == 0, result.CanJoin)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), token, nil)
+		if result.CanJoin {
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			return
+		}
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.GreaterOrEqual(t, w.Code, http.StatusBadRequest)
+		assert.Equal(t, reasons[0], body["code"], w.Body.String())
+	}
+
+	t.Run("Anonymous visitors need to log in", func(t *testing.T) {
+		eventID := newEvent("")
+		assert.Equal(t, JoinEligibility{CanJoin: false, Reasons: []string{ErrCodeNeedsLogin}}, eligibility("", eventID))
+
+		full := newEvent("max_participants = 1")
+		participantID, _ := newUser()
+		exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, full, participantID)
+		assert.Equal(t, []string{ErrCodeNeedsLogin, ErrCodeEventFull}, eligibility("", full).Reasons)
+	})
+
+	t.Run("An open event can be joined, once", func(t *testing.T) {
+		eventID := newEvent("")
+		_, token := newUser()
+		agrees(token, eventID)
+		agrees(token, eventID, ErrCodeAlreadyJoined)
+	})
+
+	t.Run("Each reason on its own", func(t *testing.T) {
+		userID, token := newUser()
+		exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, userID)
+		agrees(token, newEvent(""), ErrCodeEmailNotVerified)
+
+		_, token = newUser()
+		agrees(token, newEvent("cancelled_at = datetime('now')"), ErrCodeEventCancelled)
+
+		_, token = newUser()
+		agrees(token, newEvent(fmt.Sprintf("start_time = '%s'", time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339))), ErrCodeEventStarted)
+
+		full := newEvent("max_participants = 1")
+		participantID, _ := newUser()
+		exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, full, participantID)
+		_, token = newUser()
+		agrees(token, full, ErrCodeEventFull)
+
+		userID, token = newUser()
+		exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, organizerID, userID)
+		agrees(token, newEvent(""), ErrCodeUserBlocked)
+
+		userID, token = newUser()
+		exec(`UPDATE users SET birth_year = ? WHERE id = ?`, time.Now().UTC().Year()-20, userID)
+		agrees(token, newEvent("age_min = 30, age_max = 40"), ErrCodeAgeRestricted)
+
+		userID, token = newUser()
+		exec(`UPDATE users SET gender = 'male' WHERE id = ?`, userID)
+		agrees(token, newEvent("gender_restriction = 'female'"), ErrCodeGenderRestricted)
+
+		_, token = newUser()
+		agrees(token, newEvent("gender_restriction = 'female'"), ErrCodeGenderRequired)
+	})
+
+	t.Run("Caps on the joiner", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.MaxUpcomingJoins = 1 })
+		_, token := newUser()
+		agrees(token, newEvent(""))
+		agrees(token, newEvent(""), ErrCodeLimitReached)
+
+		useTestConfig(t, func(cfg *Config) { cfg.MinAccountAgeToJoin = 48 * time.Hour })
+		_, token = newUser()
+		agrees(token, newEvent(""), ErrCodeAccountTooNew)
+	})
+
+	t.Run("Reasons combine in the order joinEvent checks them", func(t *testing.T) {
+		full := newEvent("max_participants = 1, cancelled_at = datetime('now'), age_min = 30, age_max = 40")
+		participantID, _ := newUser()
+		exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, full, participantID)
+
+		userID, token := newUser()
+		exec(`UPDATE users SET email_verified = 0, birth_year = ? WHERE id = ?`, time.Now().UTC().Year()-20, userID)
+		exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, userID, organizerID)
+		agrees(token, full, ErrCodeEmailNotVerified, ErrCodeEventCancelled, ErrCodeEventFull, ErrCodeUserBlocked, ErrCodeAgeRestricted)
+	})
+
+	t.Run("Admins skip the account rules", func(t *testing.T) {
+		adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+		exec(`UPDATE users SET email_verified = 0, gender = 'male' WHERE id = ?`, adminID)
+		token, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+		agrees(token, newEvent("gender_restriction = 'female'"))
+	})
+
+	t.Run("Drafts and missing events are not found", func(t *testing.T) {
+		_, token := newUser()
+		for _, path := range []string{
+			fmt.Sprintf("/api/events/%d/join-eligibility", newEvent("published = 0")),
+			"/api/events/99999/join-eligibility",
+			"/api/events/abc/join-eligibility",
+		} {
+			assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", path, token, nil).Code, path)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCodeNetworkLimit is returned when an event's max_joins_per_network cap is reached for the joiner's network
+const ErrCodeNetworkLimit = "NETWORK_LIMIT_REACHED"
+
+// maxJoinsPerNetworkLimit bounds the per-event setting; above it the cap wouldn't deter anything
+const maxJoinsPerNetworkLimit = 50
+
+var ErrInvalidJoinsPerNetwork = fmt.Errorf("max_joins_per_network must be between 0 (off) and %d", maxJoinsPerNetworkLimit)
+
+// freeMailDomains are shared by unrelated people, so a common address domain there says nothing
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true, "live.com": true,
+	"yahoo.com": true, "icloud.com": true, "me.com": true, "aol.com": true, "mail.com": true,
+	"proton.me": true, "protonmail.com": true, "gmx.net": true, "gmx.de": true, "gmx.ch": true,
+	"web.de": true, "t-online.de": true, "bluewin.ch": true, "yandex.com": true,
+	"wp.pl": true, "o2.pl": true, "onet.pl": true, "interia.pl": true,
+}
+
+// ValidateNetworkJoinLimit checks the per-event network cap (0 turns it off)
+func ValidateNetworkJoinLimit(event *Event) error {
+	if event.NetworkJoinLimit < 0 || event.NetworkJoinLimit > maxJoinsPerNetworkLimit {
+		return ErrInvalidJoinsPerNetwork
+	}
+	return nil
+}
+
+// registrationNetwork reduces a registration IP to the network the join cap compares: the /24
+// for IPv4 and the /48 for IPv6. Unparseable or missing addresses give "".
+func registrationNetwork(ip string) string {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// sharedMailDomain returns the domain of a verified address when it is specific enough to tie
+// accounts together (not a free mail provider), or ""
+func sharedMailDomain(email string, verified bool) string {
+	at := strings.LastIndex(email, "@")
+	if !verified || at < 0 {
+		return ""
+	}
+	domain := strings.ToLower(email[at+1:])
+	if freeMailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
+// NetworkLimitError reports a join refused because the joiner's network already holds the event's cap
+type NetworkLimitError struct {
+	Count int
+	Limit int
+}
+
+func (e *NetworkLimitError) Error() string {
+	return fmt.Sprintf("At most %d people registered from the same network can join this event, and %d already have. Ask the organizer if you should be let in", e.Limit, e.Count)
+}
+
+func (e *NetworkLimitError) respond(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": e.Error(), "code": ErrCodeNetworkLimit, "count": e.Count, "limit": e.Limit})
+}
+
+// checkNetworkJoinLimit returns a *NetworkLimitError when limit participants of eventID (the
+// organizer aside) already share userID's registration network or a verified, non-free mail domain
+func checkNetworkJoinLimit(ctx context.Context, q sqlQueryer, eventID, organizerID, userID, limit int) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT u.id, COALESCE(u.registration_ip, ''), u.email, COALESCE(u.email_verified, 0)
+		FROM users u
+		WHERE u.id = ? OR u.id IN (SELECT user_id FROM event_participants WHERE event_id = ? AND user_id != ?)
+	`, userID, eventID, organizerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type account struct{ network, domain string }
+	var joiner *account
+	var participants []account
+	for rows.Next() {
+		var id int
+		var ip, email string
+		var verified bool
+		if err := rows.Scan(&id, &ip, &email, &verified); err != nil {
+			return err
+		}
+		a := account{network: registrationNetwork(ip), domain: sharedMailDomain(email, verified)}
+		if id == userID {
+			joiner = &a
+		} else {
+			participants = append(participants, a)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if joiner == nil {
+		return errors.New("joining user not found")
+	}
+
+	count := 0
+	for _, p := range participants {
+		if (joiner.network != "" && p.network == joiner.network) || (joiner.domain != "" && p.domain == joiner.domain) {
+			count++
+		}
+	}
+	if count >= limit {
+		return &NetworkLimitError{Count: count, Limit: limit}
+	}
+	return nil
+}
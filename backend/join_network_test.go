@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationNetwork(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", registrationNetwork("203.0.113.77"))
+	assert.Equal(t, "203.0.113.0/24", registrationNetwork("::ffff:203.0.113.5"), "IPv4-mapped addresses count as IPv4")
+	assert.Equal(t, "2001:db8:1::/48", registrationNetwork("2001:db8:1:2::10"))
+	assert.Empty(t, registrationNetwork(""))
+	assert.Empty(t, registrationNetwork("not-an-ip"))
+
+	assert.Equal(t, "acme.ch", sharedMailDomain("Jo@ACME.ch", true))
+	assert.Empty(t, sharedMailDomain("jo@acme.ch", false), "unverified addresses prove nothing")
+	assert.Empty(t, sharedMailDomain("jo@gmail.com", true))
+
+	assert.NoError(t, ValidateNetworkJoinLimit(&Event{NetworkJoinLimit: 0}))
+	assert.NoError(t, ValidateNetworkJoinLimit(&Event{NetworkJoinLimit: maxJoinsPerNetworkLimit}))
+	assert.ErrorIs(t, ValidateNetworkJoinLimit(&Event{NetworkJoinLimit: -1}), ErrInvalidJoinsPerNetwork)
+	assert.ErrorIs(t, ValidateNetworkJoinLimit(&Event{NetworkJoinLimit: maxJoinsPerNetworkLimit + 1}), ErrInvalidJoinsPerNetwork)
+}
+
+func TestNetworkJoinLimit(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.POST("/api/events/:id/join", authMiddleware(), joinEvent)
+
+	user := func(email, ip string, isAdmin bool) string {
+		t.Helper()
+		id := createTestUser(t, testDB, email, "User", "password123", isAdmin)
+		_, err := testDB.Exec(`UPDATE users SET registration_ip = ? WHERE id = ?`, ip, id)
+		require.NoError(t, err)
+		token, _ := generateToken(User{ID: int(id), Email: email, IsAdmin: isAdmin, EmailVerified: true})
+		return token
+	}
+	cappedEvent := func(organizerEmail, title string, limit int) string {
+		t.Helper()
+		organizerID := createTestUser(t, testDB, organizerEmail, "Organizer", "password123", false)
+		// The organizer registered from the same flat as everyone below
+		_, err := testDB.Exec(`UPDATE users SET registration_ip = '203.0.113.1' WHERE id = ?`, organizerID)
+		require.NoError(t, err)
+		eventID := createTestEvent(t, testDB, organizerID, title)
+		_, err = testDB.Exec(`UPDATE events SET max_joins_per_network = ? WHERE id = ?`, limit, eventID)
+		require.NoError(t, err)
+		return fmt.Sprint(eventID)
+	}
+	join := func(eventID, token string) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", "/api/events/"+eventID+"/join", token, nil)
+	}
+
+	t.Run("Accounts from one network stop at the cap", func(t *testing.T) {
+		eventID := cappedEvent("host@example.com", "Concert", 2)
+		require.Equal(t, http.StatusOK, join(eventID, user("one@gmail.com", "203.0.113.10", false)).Code)
+		require.Equal(t, http.StatusOK, join(eventID, user("two@gmail.com", "203.0.113.11", false)).Code)
+
+		w := join(eventID, user("three@gmail.com", "203.0.113.12", false))
+		require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, ErrCodeNetworkLimit, body["code"])
+		assert.Equal(t, float64(2), body["count"])
+		assert.Equal(t, float64(2), body["limit"])
+
+		assert.Equal(t, http.StatusOK, join(eventID, user("elsewhere@gmail.com", "198.51.100.5", false)).Code)
+		assert.Equal(t, http.StatusOK, join(eventID, user("admin@example.com", "203.0.113.13", true)).Code, "admins are exempt")
+	})
+
+	t.Run("A shared company domain counts, free mail doesn't", func(t *testing.T) {
+		eventID := cappedEvent("host2@example.com", "Workshop", 1)
+		require.Equal(t, http.StatusOK, join(eventID, user("ann@acme.ch", "192.0.2.1", false)).Code)
+		w := join(eventID, user("ben@acme.ch", "198.18.0.1", false))
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+		require.Equal(t, http.StatusOK, join(eventID, user("cat@gmail.com", "198.18.1.1", false)).Code)
+		assert.Equal(t, http.StatusOK, join(eventID, user("dan@gmail.com", "198.18.2.1", false)).Code)
+	})
+
+	t.Run("Events without a cap are unaffected", func(t *testing.T) {
+		eventID := cappedEvent("host3@example.com", "Picnic", 0)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, join(eventID, user(fmt.Sprintf("flat%d@gmail.com", i), "203.0.113.20", false)).Code)
+		}
+	})
+}
+
+func TestRegistrationIPIsAdminOnly(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { *cfg = *DefaultConfig() })
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+	router.GET("/api/auth/me", authMiddleware(), getCurrentUser)
+	router.GET("/api/admin/users", authMiddleware(), adminMiddleware(), adminGetUsers)
+
+	req := httptest.NewRequest("POST", "/api/auth/register", strings.NewReader(`{"email":"new@example.com","password":"password123","name":"Newbie"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.9:52000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var auth AuthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &auth))
+	assert.NotContains(t, w.Body.String(), "registration_ip")
+
+	me := doJSON(router, "GET", "/api/auth/me", auth.Token, nil)
+	require.Equal(t, http.StatusOK, me.Code)
+	assert.NotContains(t, me.Body.String(), "registration_ip")
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	w = doJSON(router, "GET", "/api/admin/users", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Users []User `json:"users"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	ips := map[string]string{}
+	for _, u := range list.Users {
+		ips[u.Email] = u.RegistrationIP
+	}
+	assert.Equal(t, map[string]string{"new@example.com": "203.0.113.9", "admin@example.com": ""}, ips)
+}
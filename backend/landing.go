@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// LandingEventLimit is how many upcoming events GET /api/public/landing lists
+	LandingEventLimit = 12
+	// landingCacheTTL is long compared to the listing cache: landing pages are crawled and shared,
+	// and any event write retires the entries anyway
+	landingCacheTTL        = 5 * time.Minute
+	maxLandingCacheEntries = 512
+	maxLandingCityLength   = 100
+)
+
+// LandingEvent is the lightweight event shape of landing pages; title and place are HTML-escaped
+// like everywhere else
+type LandingEvent struct {
+	ID               int     `json:"id"`
+	Title            string  `json:"title"`
+	Slug             string  `json:"slug"`
+	Category         string  `json:"category"`
+	StartTime        string  `json:"start_time"`
+	LocationName     string  `json:"location_name"`
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+	ParticipantCount int     `json:"participant_count"`
+}
+
+// LandingPage is the response of GET /api/public/landing. Total and Categories count every
+// upcoming event matching the filters, not just the listed ones; NextEventAt is nil when there is none.
+type LandingPage struct {
+	Events      []LandingEvent `json:"events"`
+	Total       int            `json:"total"`
+	Categories  map[string]int `json:"categories"`
+	NextEventAt *string        `json:"next_event_at"`
+	Featured    []LandingEvent `json:"featured"` // Featured events among the matching ones, at most maxFeaturedSlots
+}
+
+// landingFilter is what a landing page is about; its key is the cache key
+type landingFilter struct {
+	city     string
+	category string
+	bounds   *mapBounds
+}
+
+func (f landingFilter) key() string {
+	key := f.city + "|" + f.category
+	if f.bounds != nil {
+		key += fmt.Sprintf("|%g,%g,%g,%g", f.bounds.minLat, f.bounds.maxLat, f.bounds.minLng, f.bounds.maxLng)
+	}
+	return key
+}
+
+// loadLandingPage runs the landing queries; tests swap it to count database round trips
+var loadLandingPage = queryLandingPage
+
+// landingCacheEntry remembers the eventListCache generation it was computed in, like suggestCacheEntry
+type landingCacheEntry struct {
+	page       *LandingPage
+	generation uint64
+	expires    time.Time
+}
+
+type landingCache struct {
+	mu      sync.Mutex
+	entries map[string]landingCacheEntry
+}
+
+var eventLandingCache = &landingCache{entries: make(map[string]landingCacheEntry)}
+
+func (lc *landingCache) Get(key string, generation uint64) (*LandingPage, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	entry, ok := lc.entries[key]
+	if !ok || entry.generation != generation || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.page, true
+}
+
+func (lc *landingCache) Set(key string, generation uint64, page *LandingPage) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	now := time.Now()
+	if len(lc.entries) >= maxLandingCacheEntries {
+		for k, e := range lc.entries {
+			if e.generation != generation || now.After(e.expires) {
+				delete(lc.entries, k)
+			}
+		}
+		if len(lc.entries) >= maxLandingCacheEntries {
+			return
+		}
+	}
+	lc.entries[key] = landingCacheEntry{page: page, generation: generation, expires: now.Add(landingCacheTTL)}
+}
+
+func (lc *landingCache) Invalidate() {
+	lc.mu.Lock()
+	lc.entries = make(map[string]landingCacheEntry)
+	lc.mu.Unlock()
+}
+
+// parseLandingFilter reads city, category and an optional bounding box (min_lat, max_lat, min_lng,
+// max_lng, all or none). An unknown category is not an error: the page is just empty.
+func parseLandingFilter(c *gin.Context) (landingFilter, error) {
+	f := landingFilter{
+		city:     strings.Join(strings.Fields(c.Query("city")), " "),
+		category: strings.TrimSpace(c.Query("category")),
+	}
+	if utf8.RuneCountInString(f.city) > maxLandingCityLength {
+		return f, fmt.Errorf("city must be at most %d characters", maxLandingCityLength)
+	}
+	if c.Query("min_lat") != "" || c.Query("max_lat") != "" || c.Query("min_lng") != "" || c.Query("max_lng") != "" {
+		bounds, err := parseMapBounds(c)
+		if err != nil {
+			return f, err
+		}
+		f.bounds = &bounds
+	}
+	return f, nil
+}
+
+// queryLandingPage counts the upcoming public events matching f per category (which also yields
+// the total and the soonest start), then lists the first LandingEventLimit of them and the featured ones
+func queryLandingPage(ctx context.Context, f landingFilter) (*LandingPage, error) {
+	where := `
+		WHERE e.start_time >= datetime('now')
+		AND e.start_time <= datetime('now', ?)
+		AND ` + publicEventCondition
+	args := []interface{}{fmt.Sprintf("+%d days", appConfig.EventListWindowDays)}
+	if f.city != "" {
+		// Places are stored escaped, so match the escaped form
+		where += ` AND (e.location_name LIKE ? ESCAPE '\' OR e.address LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(html.EscapeString(f.city)) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if f.category != "" {
+		where += " AND e.category = ?"
+		args = append(args, f.category)
+	}
+	if f.bounds != nil {
+		where += " AND e.latitude BETWEEN ? AND ? AND e.longitude BETWEEN ? AND ?"
+		args = append(args, f.bounds.minLat, f.bounds.maxLat, f.bounds.minLng, f.bounds.maxLng)
+	}
+
+	page := &LandingPage{Events: []LandingEvent{}, Featured: []LandingEvent{}, Categories: map[string]int{}}
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.category, COUNT(*), MIN(e.start_time)
+		FROM events e`+where+`
+		GROUP BY e.category
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	var soonest string
+	for rows.Next() {
+		var category, first string
+		var count int
+		if err := rows.Scan(&category, &count, &first); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		page.Categories[category] = count
+		page.Total += count
+		if soonest == "" || first < soonest {
+			soonest = first
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if page.Total == 0 {
+		return page, nil
+	}
+	next := formatStoredTime(soonest)
+	page.NextEventAt = &next
+
+	if page.Events, err = queryLandingEvents(ctx, where, args, LandingEventLimit); err != nil {
+		return nil, err
+	}
+	page.Featured, err = queryLandingEvents(ctx, where+" AND "+featuredEventCondition, append(args, time.Now().UTC()), maxFeaturedSlots)
+	return page, err
+}
+
+// queryLandingEvents lists the first limit events matching where, soonest first
+func queryLandingEvents(ctx context.Context, where string, args []interface{}, limit int) ([]LandingEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.title, e.slug, e.category, e.start_time, COALESCE(e.location_name, ''),
+		       e.latitude, e.longitude, e.participant_count
+		FROM events e`+where+`
+		ORDER BY e.start_time ASC, e.id ASC
+		LIMIT ?
+	`, append(args[:len(args):len(args)], limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []LandingEvent{}
+	for rows.Next() {
+		var e LandingEvent
+		var startTime string
+		if err := rows.Scan(&e.ID, &e.Title, &e.Slug, &e.Category, &startTime, &e.LocationName,
+			&e.Latitude, &e.Longitude, &e.ParticipantCount); err != nil {
+			return nil, err
+		}
+		e.StartTime = formatStoredTime(startTime)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// getLandingPage returns the data behind marketing landing pages such as "Events in Zürich"
+// (GET /api/public/landing?city=&category=). Only events anonymous visitors may see are counted,
+// so the answer is the same for everyone and cached per filter.
+func getLandingPage(c *gin.Context) {
+	f, err := parseLandingFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	generation := eventListCache.Generation()
+	page, ok := eventLandingCache.Get(f.key(), generation)
+	if !ok {
+		log.Printf("🏙️  GET /api/public/landing - city %q, category %q", f.city, f.category)
+		page, err = loadLandingPage(c.Request.Context(), f)
+		if err != nil {
+			log.Printf("❌ Error querying landing page: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load landing page"})
+			return
+		}
+		eventLandingCache.Set(f.key(), generation, page)
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(landingCacheTTL.Seconds())))
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLandingPage(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	eventLandingCache.Invalidate()
+	defer eventLandingCache.Invalidate()
+
+	queries := 0
+	loadLandingPage = func(ctx context.Context, f landingFilter) (*LandingPage, error) {
+		queries++
+		return queryLandingPage(ctx, f)
+	}
+	defer func() { loadLandingPage = queryLandingPage }()
+
+	router := gin.New()
+	router.GET("/api/public/landing", getLandingPage)
+	router.POST("/api/events", authMiddleware(), createEvent)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "organizer@example.com", EmailVerified: true})
+	seq := 0
+	event := func(place, category string, startIn time.Duration, lat, lng float64, privacy string) {
+		t.Helper()
+		seq++
+		id := createTestEvent(t, testDB, userID, fmt.Sprintf("Event %d", seq))
+		_, err := testDB.Exec(`UPDATE events SET slug = ?, location_name = ?, category = ?, start_time = ?, latitude = ?, longitude = ? WHERE id = ?`,
+			fmt.Sprintf("event-%d", seq), place, category, time.Now().Add(startIn).UTC().Format(time.RFC3339), lat, lng, id)
+		require.NoError(t, err)
+		if privacy != "" {
+			_, err = testDB.Exec(`UPDATE events SET `+privacy+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+	}
+	const zLat, zLng = 47.3769, 8.5417
+	event("Letten, Zürich", "sports_fitness", 48*time.Hour, zLat, zLng, "")
+	event("Dolder Zürich", "sports_fitness", 6*time.Hour, zLat+0.01, zLng, "")
+	event("Rote Fabrik, Zürich", "food_dining", 72*time.Hour, zLat, zLng+0.01, "")
+	event("Kaserne Basel", "food_dining", 12*time.Hour, 47.5596, 7.5886, "")
+	event("Zürich HB", "sports_fitness", 3*time.Hour, zLat, zLng, "allow_unregistered_users = 0")
+	event("Zürich West", "sports_fitness", 3*time.Hour, zLat, zLng, "require_verified_to_view = 1")
+	event("Zürich Nord", "sports_fitness", 3*time.Hour, zLat, zLng, "hidden_pending_review = 1")
+	event("Zürich Süd", "sports_fitness", 3*time.Hour, zLat, zLng, "published = 0")
+	event("Zürich Ost", "sports_fitness", 3*time.Hour, zLat, zLng, "cancelled_at = datetime('now')")
+	event("Zürich Altstadt", "sports_fitness", -48*time.Hour, zLat, zLng, "")
+
+	landing := func(query string) LandingPage {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/public/landing?"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page LandingPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	slugs := func(page LandingPage) []string {
+		var slugs []string
+		for _, e := range page.Events {
+			slugs = append(slugs, e.Slug)
+		}
+		return slugs
+	}
+
+	t.Run("Counts cover the public upcoming events in the city", func(t *testing.T) {
+		page := landing("city=z%C3%BCrich")
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, map[string]int{"sports_fitness": 2, "food_dining": 1}, page.Categories)
+		assert.Equal(t, []string{"event-2", "event-1", "event-3"}, slugs(page))
+		require.NotNil(t, page.NextEventAt)
+		next, err := time.Parse(time.RFC3339, *page.NextEventAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(6*time.Hour), next, time.Minute)
+		assert.Equal(t, "Dolder Zürich", page.Events[0].LocationName)
+
+		page = landing("category=sports_fitness")
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, map[string]int{"sports_fitness": 2}, page.Categories)
+
+		page = landing("")
+		assert.Equal(t, 4, page.Total)
+		assert.Len(t, page.Events, 4)
+	})
+
+	t.Run("A bounding box works instead of a city", func(t *testing.T) {
+		page := landing("min_lat=47.3&max_lat=47.4&min_lng=8.5&max_lng=8.6")
+		assert.Equal(t, 3, page.Total)
+		page = landing("min_lat=47.5&max_lat=47.6&min_lng=7.5&max_lng=7.6&category=food_dining")
+		assert.Equal(t, []string{"event-4"}, slugs(page))
+
+		w := doJSON(router, "GET", "/api/public/landing?min_lat=47.3", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("An unknown category gives an empty page", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/landing?city=Z%C3%BCrich&category=underwater_chess", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"events": [], "total": 0, "categories": {}, "next_event_at": null, "featured": []}`, w.Body.String())
+	})
+
+	t.Run("Pages are cached until an event is created", func(t *testing.T) {
+		eventLandingCache.Invalidate()
+		before := queries
+		assert.Equal(t, 1, landing("city=Basel").Total)
+		assert.Equal(t, 1, landing("city=%20Basel%20").Total)
+		assert.Equal(t, before+1, queries, "the same filter is loaded once")
+
+		w := doJSON(router, "POST", "/api/events", token, gin.H{
+			"title": "Dinner at the Kaserne", "description": "A long table dinner by the Rhine",
+			"category": "food_dining", "latitude": 47.5596, "longitude": 7.5886, "location_name": "Kaserne Basel",
+			"start_time": time.Now().Add(96 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		page := landing("city=Basel")
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, map[string]int{"food_dining": 2}, page.Categories)
+		assert.Equal(t, before+2, queries)
+	})
+}
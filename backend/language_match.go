@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// SortRelevance orders GET /api/events by language_match, then start_time (signed-in viewers only)
+const SortRelevance = "relevance"
+
+// viewerLanguages reads the viewer's profile languages, normalized by ValidateLanguages so that
+// hand-typed "EN, de" matches events stored as "de,en". Unknown codes are dropped.
+func viewerLanguages(ctx context.Context, userID int) ([]string, error) {
+	var raw sql.NullString
+	if err := db.QueryRowContext(ctx, `SELECT languages FROM users WHERE id = ?`, userID).Scan(&raw); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	normalized, err := ValidateLanguages(raw.String)
+	if err != nil {
+		var codes []string
+		for _, code := range splitLanguages(raw.String) {
+			if IsValidLanguageCode(code) {
+				codes = append(codes, code)
+			}
+		}
+		return codes, nil
+	}
+	if normalized == "" {
+		return nil, nil
+	}
+	return strings.Split(normalized, ","), nil
+}
+
+// languageMatch scores how well an event's languages suit the viewer: the share of the viewer's
+// languages the event is held in, from 0 (none, or no profile languages) to 1
+func languageMatch(viewer []string, eventLanguages string) float64 {
+	if len(viewer) == 0 {
+		return 0
+	}
+	held := map[string]bool{}
+	for _, code := range splitLanguages(eventLanguages) {
+		held[code] = true
+	}
+	shared := 0
+	for _, code := range viewer {
+		if held[code] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(viewer))
+}
+
+// languageMatchOrder is the ORDER BY term for sort=relevance: the number of the viewer's languages
+// each event is held in, which orders events the same way as languageMatch
+func languageMatchOrder(viewer []string) (string, []interface{}) {
+	if len(viewer) == 0 {
+		return "", nil
+	}
+	terms := make([]string, len(viewer))
+	args := make([]interface{}, len(viewer))
+	for i, code := range viewer {
+		terms[i] = "(CASE WHEN (',' || COALESCE(e.event_languages, '') || ',') LIKE ? THEN 1 ELSE 0 END)"
+		args[i] = "%," + code + ",%"
+	}
+	return "(" + strings.Join(terms, " + ") + ") DESC, ", args
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageMatch(t *testing.T) {
+	viewer := []string{"de", "fr"}
+	assert.Equal(t, 0.5, languageMatch(viewer, "de"))
+	assert.Equal(t, 1.0, languageMatch(viewer, "fr,de,en"))
+	assert.Equal(t, 0.0, languageMatch(viewer, "it"))
+	assert.Equal(t, 0.0, languageMatch(nil, "de"), "no profile languages, no match")
+}
+
+func TestEventListRelevanceSort(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Viewer", "password123", false)
+	// Typed by hand before profile languages were validated
+	_, err := testDB.Exec(`UPDATE users SET languages = 'DE, fr' WHERE id = ?`, viewerID)
+	require.NoError(t, err)
+	token, _ := generateToken(User{ID: int(viewerID), Email: "viewer@example.com", EmailVerified: true})
+
+	event := func(title, languages string, startsIn time.Duration) {
+		id := createTestEvent(t, testDB, organizerID, title)
+		_, err := testDB.Exec(`UPDATE events SET event_languages = ?, start_time = ? WHERE id = ?`,
+			languages, time.Now().Add(startsIn).UTC().Format(time.RFC3339), id)
+		require.NoError(t, err)
+	}
+	event("Aperitivo", "it", 24*time.Hour)
+	event("Stammtisch", "de", 48*time.Hour)
+	event("Soirée bilingue", "de,fr", 72*time.Hour)
+
+	list := func(token, query string) []Event {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events"+query, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+	titles := func(events []Event) []string {
+		var titles []string
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	t.Run("sort=relevance ranks the viewer's languages first", func(t *testing.T) {
+		events := list(token, "?sort=relevance")
+		assert.Equal(t, []string{"Soirée bilingue", "Stammtisch", "Aperitivo"}, titles(events))
+		matches := map[string]float64{}
+		for _, e := range events {
+			require.NotNil(t, e.LanguageMatch, e.Title)
+			matches[e.Title] = *e.LanguageMatch
+		}
+		assert.Equal(t, map[string]float64{"Soirée bilingue": 1, "Stammtisch": 0.5, "Aperitivo": 0}, matches)
+	})
+
+	t.Run("Without sort the listing stays in start time order", func(t *testing.T) {
+		events := list(token, "")
+		assert.Equal(t, []string{"Aperitivo", "Stammtisch", "Soirée bilingue"}, titles(events))
+		require.NotNil(t, events[0].LanguageMatch)
+		assert.Contains(t, doJSON(router, "GET", "/api/events", token, nil).Body.String(), `"language_match":0,`)
+	})
+
+	t.Run("Anonymous requests are unaffected", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/events?sort=relevance", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "language_match")
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		assert.Equal(t, []string{"Aperitivo", "Stammtisch", "Soirée bilingue"}, titles(events))
+	})
+
+	t.Run("Unknown sort orders are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/events?sort=popular", token, nil).Code)
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LanguageCodes is the canonical list of language codes users and events can pick
+// (EU official languages, Romansh, and languages commonly spoken in the EU).
+// Keep in sync with frontend/src/languages.ts.
+var LanguageCodes = []string{
+	"bg", "hr", "cs", "da", "nl", "en", "et", "fi", "fr", "de", "el", "hu",
+	"ga", "it", "lv", "lt", "mt", "pl", "pt", "ro", "sk", "sl", "es", "sv",
+	"rm", "tr", "ar", "ru", "uk", "zh",
+}
+
+var languageCodeSet = func() map[string]bool {
+	set := make(map[string]bool, len(LanguageCodes))
+	for _, code := range LanguageCodes {
+		set[code] = true
+	}
+	return set
+}()
+
+// IsValidLanguageCode reports whether code is in LanguageCodes (exact, lowercase match)
+func IsValidLanguageCode(code string) bool {
+	return languageCodeSet[code]
+}
+
+// InvalidLanguagesError lists the codes ValidateLanguages didn't recognize
+type InvalidLanguagesError struct {
+	Codes []string
+}
+
+func (e *InvalidLanguagesError) Error() string {
+	return fmt.Sprintf("invalid language codes: %s (use two-letter codes such as en, de, fr)", strings.Join(e.Codes, ", "))
+}
+
+// splitLanguages trims, lowercases and deduplicates a comma-separated list, keeping the order
+func splitLanguages(raw string) []string {
+	seen := map[string]bool{}
+	codes := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		code := strings.ToLower(strings.TrimSpace(part))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// ValidateLanguages normalizes a comma-separated list of language codes ("EN, de,en" -> "en,de").
+// Returns an *InvalidLanguagesError naming every unknown code.
+func ValidateLanguages(raw string) (string, error) {
+	codes := splitLanguages(raw)
+	var invalid []string
+	for _, code := range codes {
+		if !IsValidLanguageCode(code) {
+			invalid = append(invalid, code)
+		}
+	}
+	if len(invalid) > 0 {
+		return "", &InvalidLanguagesError{Codes: invalid}
+	}
+	return strings.Join(codes, ","), nil
+}
+
+// languageNames maps English language names to codes for cleaning up values typed by hand
+// before languages were validated ("english, german" -> "en,de")
+var languageNames = map[string]string{
+	"bulgarian": "bg", "croatian": "hr", "czech": "cs", "danish": "da", "dutch": "nl",
+	"english": "en", "estonian": "et", "finnish": "fi", "french": "fr", "german": "de",
+	"greek": "el", "hungarian": "hu", "irish": "ga", "italian": "it", "latvian": "lv",
+	"lithuanian": "lt", "maltese": "mt", "polish": "pl", "portuguese": "pt", "romanian": "ro",
+	"slovak": "sk", "slovenian": "sl", "spanish": "es", "swedish": "sv", "romansh": "rm",
+	"turkish": "tr", "arabic": "ar", "russian": "ru", "ukrainian": "uk", "chinese": "zh",
+}
+
+// normalizeLanguagesBestEffort keeps the recognizable codes of a legacy value and drops the rest.
+// Returns false when nothing usable is left.
+func normalizeLanguagesBestEffort(raw string) (string, bool) {
+	var codes []string
+	seen := map[string]bool{}
+	for _, part := range splitLanguages(raw) {
+		code := part
+		if named, ok := languageNames[part]; ok {
+			code = named
+		}
+		if IsValidLanguageCode(code) && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, ","), len(codes) > 0
+}
+
+// normalizeStoredLanguages rewrites users.languages and events.event_languages written before
+// validation existed. Unusable values become NULL. Safe to run on every startup.
+func normalizeStoredLanguages(db *sql.DB) {
+	for _, target := range []struct{ table, column string }{
+		{"users", "languages"},
+		{"events", "event_languages"},
+	} {
+		rows, err := db.Query(fmt.Sprintf(`SELECT id, %s FROM %s WHERE %s IS NOT NULL`, target.column, target.table, target.column))
+		if err != nil {
+			log.Printf("⚠️  Could not read %s.%s for normalization: %v", target.table, target.column, err)
+			continue
+		}
+
+		updates := map[int]sql.NullString{}
+		for rows.Next() {
+			var id int
+			var raw string
+			if err := rows.Scan(&id, &raw); err != nil {
+				continue
+			}
+			normalized, ok := normalizeLanguagesBestEffort(raw)
+			if ok && normalized == raw {
+				continue
+			}
+			updates[id] = sql.NullString{String: normalized, Valid: ok}
+		}
+		rows.Close()
+
+		for id, value := range updates {
+			if _, err := db.Exec(fmt.Sprintf(`UPDATE %s SET %s = ? WHERE id = ?`, target.table, target.column), value, id); err != nil {
+				log.Printf("⚠️  Could not normalize %s.%s for id %d: %v", target.table, target.column, id, err)
+			}
+		}
+		if len(updates) > 0 {
+			log.Printf("✓ Normalized %s.%s on %d rows", target.table, target.column, len(updates))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLanguages(t *testing.T) {
+	normalized, err := ValidateLanguages(" EN, de,en ,,Fr")
+	require.NoError(t, err)
+	assert.Equal(t, "en,de,fr", normalized)
+
+	normalized, err = ValidateLanguages("")
+	require.NoError(t, err)
+	assert.Empty(t, normalized)
+
+	_, err = ValidateLanguages("en, english, xx")
+	var invalid *InvalidLanguagesError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []string{"english", "xx"}, invalid.Codes)
+}
+
+func TestNormalizeStoredLanguages(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+
+	values := map[string]interface{}{
+		"clean":   "en,de",
+		"names":   "English, German",
+		"mixed":   "EN,xx,fr,en",
+		"garbage": "lol, ???",
+		"empty":   "",
+		"null":    nil,
+	}
+	ids := map[string]int64{}
+	for name, languages := range values {
+		ids[name] = createTestUser(t, testDB, name+"@example.com", "User", "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET languages = ? WHERE id = ?`, languages, ids[name])
+		require.NoError(t, err)
+	}
+
+	normalizeStoredLanguages(testDB)
+
+	want := map[string]*string{
+		"clean":   ptr("en,de"),
+		"names":   ptr("en,de"),
+		"mixed":   ptr("en,fr"),
+		"garbage": nil,
+		"empty":   nil,
+		"null":    nil,
+	}
+	for name, expected := range want {
+		var got *string
+		require.NoError(t, testDB.QueryRow(`SELECT languages FROM users WHERE id = ?`, ids[name]).Scan(&got))
+		assert.Equal(t, expected, got, name)
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+func TestEventLanguagesThroughAPI(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.PUT("/profile", updateProfile)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET email_verified = 1 WHERE id = ?`, userID)
+	require.NoError(t, err)
+	token, _ := generateToken(User{ID: int(userID), Email: "organizer@example.com", EmailVerified: true})
+
+	event := gin.H{
+		"title":                    "Language Exchange",
+		"description":              "Practice languages over coffee",
+		"category":                 "social_drinks",
+		"latitude":                 52.52,
+		"longitude":                13.405,
+		"start_time":               time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		"creator_name":             "Organizer",
+		"gender_restriction":       "any",
+		"age_max":                  99,
+		"event_languages":          "EN, De",
+		"allow_unregistered_users": true,
+	}
+
+	t.Run("Create normalizes", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, event)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "en,de", created.EventLanguages)
+
+		// Exact code matches only; unknown codes in the filter are ignored
+		for query, want := range map[string]int{"de": 1, "DE": 1, "fr,en": 1, "es": 0, "e": 1} {
+			eventListCache.Invalidate()
+			w := doJSON(router, "GET", "/api/events?languages="+query, "", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var events []Event
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+			assert.Len(t, events, want, query)
+		}
+	})
+
+	t.Run("Unknown codes rejected", func(t *testing.T) {
+		event["event_languages"] = "english, de, klingon"
+		w := doJSON(router, "POST", "/api/events", token, event)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "english, klingon")
+
+		eventID := createTestEvent(t, testDB, userID, "Existing Event")
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", eventID), token, event)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "Organizer", "languages": "German"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Profile normalizes", func(t *testing.T) {
+		w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "Organizer", "languages": "PL , en,pl"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var user User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		assert.Equal(t, "pl,en", user.Languages)
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeaveReasons are the answers to "why are you leaving?", in the order clients list them
+var LeaveReasons = []string{"time_changed", "too_far", "other_plans", "cost", "not_a_fit", "other"}
+
+// maxLeaveNoteLength caps the optional free text next to a leave reason, in characters
+const maxLeaveNoteLength = 200
+
+var (
+	ErrInvalidLeaveReason = fmt.Errorf("reason must be one of: %s", strings.Join(LeaveReasons, ", "))
+	ErrLeaveNoteTooLong   = fmt.Errorf("note must be at most %d characters", maxLeaveNoteLength)
+)
+
+// LeaveEventRequest is the optional body of DELETE /api/events/:id/leave
+type LeaveEventRequest struct {
+	Reason        string `json:"reason"`
+	Note          string `json:"note"`
+	ShareIdentity bool   `json:"share_identity"` // Let the organizer see who left and what they wrote
+}
+
+// validate normalizes the note like a comment (markup dropped) and checks the reason. A note
+// without a reason counts as "other".
+func (r *LeaveEventRequest) validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	r.Note = stripHTMLTags(r.Note)
+	if r.Reason == "" && r.Note != "" {
+		r.Reason = "other"
+	}
+	if r.Reason != "" && !isLeaveReason(r.Reason) {
+		return ErrInvalidLeaveReason
+	}
+	if utf8.RuneCountInString(r.Note) > maxLeaveNoteLength {
+		return ErrLeaveNoteTooLong
+	}
+	return nil
+}
+
+func isLeaveReason(reason string) bool {
+	for _, r := range LeaveReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// moderateLeaveNote runs the note through the content filter. Rejected notes are answered with
+// 400 before anything changes; flagged ones are dropped, since exits have no review queue.
+func moderateLeaveNote(c *gin.Context, req *LeaveEventRequest) bool {
+	if req.Note == "" || c.GetBool("is_admin") {
+		return true
+	}
+	result, err := moderateContent(c.Request.Context(), req.Note)
+	if err != nil {
+		log.Printf("❌ Moderation check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check leave note"})
+		return false
+	}
+	if result.Rejected() {
+		log.Printf("🛡️  Rejected leave note from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		result.respondRejected(c)
+		return false
+	}
+	if result.Flagged() {
+		log.Printf("🛡️  Dropped flagged leave note from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		req.Note = ""
+	}
+	return true
+}
+
+// recordParticipationExit stores one leave, with or without a reason, in leaveEvent's transaction
+func recordParticipationExit(exec sqlExecer, eventID interface{}, userID int, req LeaveEventRequest) error {
+	var reason interface{}
+	if req.Reason != "" {
+		reason = req.Reason
+	}
+	_, err := exec.Exec(`
+		INSERT INTO participation_exits (event_id, user_id, reason, note, share_identity, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, eventID, userID, reason, req.Note, req.ShareIdentity, time.Now().UTC())
+	return err
+}
+
+// LeaveStats sums up why participants left an event
+type LeaveStats struct {
+	Total    int            `json:"total"`
+	Reasons  map[string]int `json:"reasons"`   // every reason in LeaveReasons, 0 included
+	NoReason int            `json:"no_reason"` // left without saying why
+	Shared   []SharedLeave  `json:"shared"`    // only leavers who chose share_identity, newest first
+}
+
+// SharedLeave is one leave whose author let the organizer see it
+type SharedLeave struct {
+	UserID int       `json:"user_id"`
+	Name   string    `json:"name"`
+	Reason string    `json:"reason"`
+	Note   string    `json:"note"`
+	LeftAt time.Time `json:"left_at"`
+}
+
+// EventStats is the organizer's view of an event's turnout (GET /api/events/:id/stats)
+type EventStats struct {
+	Participants int        `json:"participants"`
+	Interested   int        `json:"interested"`
+	Leaves       LeaveStats `json:"leaves"`
+}
+
+// loadLeaveStats aggregates an event's exits. Notes and names only come from shared exits, so
+// an organizer can't tell who gave which reason otherwise.
+func loadLeaveStats(ctx context.Context, eventID int) (LeaveStats, error) {
+	stats := LeaveStats{Reasons: map[string]int{}, Shared: []SharedLeave{}}
+	for _, reason := range LeaveReasons {
+		stats.Reasons[reason] = 0
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT COALESCE(reason, ''), COUNT(*) FROM participation_exits WHERE event_id = ? GROUP BY reason
+	`, eventID)
+	if err != nil {
+		return stats, err
+	}
+	for rows.Next() {
+		var reason string
+		var count int
+		if err := rows.Scan(&reason, &count); err != nil {
+			rows.Close()
+			return stats, err
+		}
+		stats.Total += count
+		if reason == "" {
+			stats.NoReason += count
+		} else {
+			stats.Reasons[reason] += count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT x.user_id, u.name, COALESCE(x.reason, ''), x.note, x.created_at
+		FROM participation_exits x
+		JOIN users u ON u.id = x.user_id
+		WHERE x.event_id = ? AND x.share_identity = 1
+		ORDER BY x.created_at DESC, x.id DESC
+	`, eventID)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s SharedLeave
+		if err := rows.Scan(&s.UserID, &s.Name, &s.Reason, &s.Note, &s.LeftAt); err != nil {
+			return stats, err
+		}
+		stats.Shared = append(stats.Shared, s)
+	}
+	return stats, rows.Err()
+}
+
+// getEventStats returns turnout and leave reasons to the organizer (GET /api/events/:id/stats)
+func getEventStats(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	log.Printf("📊 GET /api/events/%d/stats - User %d fetching event stats", eventID, c.GetInt("user_id"))
+
+	if !requireEventOrganizer(c, eventID, "view event stats") {
+		return
+	}
+
+	var stats EventStats
+	err = db.QueryRowContext(ctx, `SELECT participant_count, interested_count FROM events WHERE id = ?`, eventID).
+		Scan(&stats.Participants, &stats.Interested)
+	if err == nil {
+		stats.Leaves, err = loadLeaveStats(ctx, eventID)
+	}
+	if err != nil {
+		log.Printf("❌ Error loading stats of event %d: %v", eventID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load event stats"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaveReasons(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	moderationTerms.Invalidate()
+	defer moderationTerms.Invalidate()
+	_, err := testDB.Exec(`INSERT INTO moderation_terms (term, action) VALUES ('crypto signals', 'reject'), ('pyramid scheme', 'flag')`)
+	require.NoError(t, err)
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.GET("/events/:id/stats", getEventStats)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	eventID := createTestEvent(t, testDB, organizerID, "Picnic")
+	leavePath := fmt.Sprintf("/api/events/%d/leave", eventID)
+
+	seq := 0
+	participant := func(name string) (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, name, "password123", false)
+		addParticipant(t, testDB, eventID, id)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	stats := func(token string) (int, EventStats) {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/stats", eventID), token, nil)
+		var resp EventStats
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	t.Run("Leaving without a body still works", func(t *testing.T) {
+		_, token := participant("Quiet")
+		w := doJSON(router, "DELETE", leavePath, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Invalid bodies are refused and the participant stays", func(t *testing.T) {
+		userID, token := participant("Picky")
+		for _, body := range []gin.H{
+			{"reason": "bored"},
+			{"reason": "other", "note": strings.Repeat("ä", maxLeaveNoteLength+1)},
+			{"reason": "other", "note": "Join my Crypto-Signals group"},
+		} {
+			w := doJSON(router, "DELETE", leavePath, token, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE event_id = ? AND user_id = ?`, eventID, userID))
+
+		w := doJSON(router, "DELETE", leavePath, token, gin.H{"reason": "other", "note": strings.Repeat("ä", maxLeaveNoteLength)})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	// Three more leaves with reasons; two share who they are
+	_, token := participant("Anna")
+	require.Equal(t, http.StatusOK, doJSON(router, "DELETE", leavePath, token, gin.H{"reason": "time_changed", "note": "Can't make <b>Saturday</b>"}).Code)
+	benID, token := participant("Ben")
+	require.Equal(t, http.StatusOK, doJSON(router, "DELETE", leavePath, token,
+		gin.H{"reason": "time_changed", "note": "Moved to a workday", "share_identity": true}).Code)
+	_, token = participant("Cleo")
+	require.Equal(t, http.StatusOK, doJSON(router, "DELETE", leavePath, token,
+		gin.H{"note": "Heard it's a pyramid scheme pitch", "share_identity": true}).Code)
+	_, stayingToken := participant("Staying")
+
+	t.Run("Organizers see counts, and names only where shared", func(t *testing.T) {
+		code, got := stats(organizerToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, got.Participants)
+		assert.Equal(t, 5, got.Leaves.Total)
+		assert.Equal(t, 1, got.Leaves.NoReason)
+		assert.Equal(t, map[string]int{"time_changed": 2, "too_far": 0, "other_plans": 0, "cost": 0, "not_a_fit": 0, "other": 2}, got.Leaves.Reasons)
+
+		// Reason counts plus leaves without one add up to the total
+		sum := got.Leaves.NoReason
+		for _, n := range got.Leaves.Reasons {
+			sum += n
+		}
+		assert.Equal(t, got.Leaves.Total, sum)
+
+		require.Len(t, got.Leaves.Shared, 2)
+		names := []string{got.Leaves.Shared[0].Name, got.Leaves.Shared[1].Name}
+		assert.ElementsMatch(t, []string{"Ben", "Cleo"}, names)
+		for _, s := range got.Leaves.Shared {
+			switch s.Name {
+			case "Ben":
+				assert.Equal(t, int(benID), s.UserID)
+				assert.Equal(t, "time_changed", s.Reason)
+				assert.Equal(t, "Moved to a workday", s.Note)
+			case "Cleo":
+				// A note without a reason counts as "other"; the flagged note itself is dropped
+				assert.Equal(t, "other", s.Reason)
+				assert.Empty(t, s.Note)
+			}
+		}
+		body, _ := json.Marshal(got)
+		assert.NotContains(t, string(body), "Anna")
+		assert.NotContains(t, string(body), "Saturday")
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM participation_exits WHERE note = 'Can''t make Saturday'`))
+	})
+
+	t.Run("Only the organizer sees the stats", func(t *testing.T) {
+		code, _ := stats(stayingToken)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
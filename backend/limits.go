@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCodeLimitReached is returned when a user already has as many upcoming events as their cap allows
+const ErrCodeLimitReached = "LIMIT_REACHED"
+
+// sqlQueryRower is satisfied by both *sql.DB and *sql.Tx so counts can be read inside a transaction
+type sqlQueryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// LimitReachedError reports a rejected join or creation because the user is at their cap
+type LimitReachedError struct {
+	Kind  string // "joined" or "created"
+	Count int
+	Limit int
+}
+
+func (e *LimitReachedError) Error() string {
+	return fmt.Sprintf("You can have at most %d upcoming %s events at a time", e.Limit, e.Kind)
+}
+
+func (e *LimitReachedError) respond(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": e.Error(), "code": ErrCodeLimitReached, "count": e.Count, "limit": e.Limit})
+}
+
+// countUpcomingJoins counts the upcoming, not cancelled events the user joined (their own events excluded)
+func countUpcomingJoins(q sqlQueryRower, userID int) (int, error) {
+	var count int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM event_participants ep
+		INNER JOIN events e ON e.id = ep.event_id
+		WHERE ep.user_id = ? AND e.user_id != ? AND e.cancelled_at IS NULL AND e.start_time > datetime('now')
+	`, userID, userID).Scan(&count)
+	return count, err
+}
+
+// countUpcomingCreated counts the upcoming, not cancelled events the user organizes
+func countUpcomingCreated(q sqlQueryRower, userID int) (int, error) {
+	var count int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM events
+		WHERE user_id = ? AND cancelled_at IS NULL AND start_time > datetime('now')
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// checkUpcomingJoinLimit returns a *LimitReachedError when the user can't join another upcoming event
+func checkUpcomingJoinLimit(q sqlQueryRower, userID int) error {
+	count, err := countUpcomingJoins(q, userID)
+	if err != nil {
+		return err
+	}
+	if count >= appConfig.MaxUpcomingJoins {
+		return &LimitReachedError{Kind: "joined", Count: count, Limit: appConfig.MaxUpcomingJoins}
+	}
+	return nil
+}
+
+// checkUpcomingCreateLimit returns a *LimitReachedError when the user can't create another upcoming event
+func checkUpcomingCreateLimit(q sqlQueryRower, userID int) error {
+	count, err := countUpcomingCreated(q, userID)
+	if err != nil {
+		return err
+	}
+	if count >= appConfig.MaxUpcomingCreated {
+		return &LimitReachedError{Kind: "created", Count: count, Limit: appConfig.MaxUpcomingCreated}
+	}
+	return nil
+}
+
+// upcomingAllowance summarizes the caps for the profile page so the UI can warn before a request fails.
+// Admins are uncapped and get nil.
+func upcomingAllowance(userID int, isAdmin bool) (gin.H, error) {
+	if isAdmin {
+		return nil, nil
+	}
+	joined, err := countUpcomingJoins(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	created, err := countUpcomingCreated(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"joined":  gin.H{"count": joined, "limit": appConfig.MaxUpcomingJoins, "remaining": max(appConfig.MaxUpcomingJoins-joined, 0)},
+		"created": gin.H{"count": created, "limit": appConfig.MaxUpcomingCreated, "remaining": max(appConfig.MaxUpcomingCreated-created, 0)},
+	}, nil
+}
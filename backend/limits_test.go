@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcomingEventLimits(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.MaxUpcomingJoins = 2
+		cfg.MaxUpcomingCreated = 2
+	})
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.GET("/profile", getOwnProfile)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	events := make([]int64, 3)
+	for i := range events {
+		events[i] = createTestEvent(t, testDB, organizerID, fmt.Sprintf("Event %d", i))
+	}
+	pastID := createPastEvent(t, testDB, organizerID, "past-event", time.Now().Add(-48*time.Hour), nil)
+	addParticipant(t, testDB, pastID, userID) // Past events don't count
+
+	join := func(token string, eventID int64) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), token, nil)
+	}
+	create := func(token, title string) *httptest.ResponseRecorder {
+		return doJSON(router, "POST", "/api/events", token, gin.H{
+			"title": title, "description": "A friendly meetup for the limit tests",
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		})
+	}
+	limitError := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, ErrCodeLimitReached, body["code"])
+		return body
+	}
+	limits := func() map[string]map[string]float64 {
+		w := doJSON(router, "GET", "/api/profile", userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Limits map[string]map[string]float64 `json:"limits"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Limits
+	}
+
+	t.Run("Joins up to the cap, then LIMIT_REACHED", func(t *testing.T) {
+		assert.Equal(t, float64(2), limits()["joined"]["remaining"])
+		require.Equal(t, http.StatusOK, join(userToken, events[0]).Code)
+		require.Equal(t, http.StatusOK, join(userToken, events[1]).Code, "exactly at the cap is allowed")
+		assert.Equal(t, float64(0), limits()["joined"]["remaining"])
+
+		body := limitError(join(userToken, events[2]))
+		assert.Equal(t, float64(2), body["count"])
+		assert.Equal(t, float64(2), body["limit"])
+	})
+
+	t.Run("Leaving frees a slot immediately", func(t *testing.T) {
+		w := doJSON(router, "DELETE", fmt.Sprintf("/api/events/%d/leave", events[0]), userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(1), limits()["joined"]["remaining"])
+		assert.Equal(t, http.StatusOK, join(userToken, events[2]).Code)
+	})
+
+	t.Run("Cancelled events don't count", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET cancelled_at = CURRENT_TIMESTAMP WHERE id = ?`, events[1])
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), limits()["joined"]["count"])
+	})
+
+	t.Run("Creations up to the cap, then LIMIT_REACHED", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, create(userToken, "First Meetup").Code)
+		require.Equal(t, http.StatusCreated, create(userToken, "Second Meetup").Code)
+		assert.Equal(t, float64(0), limits()["created"]["remaining"])
+
+		body := limitError(create(userToken, "Third Meetup"))
+		assert.Equal(t, float64(2), body["count"])
+	})
+
+	t.Run("Admins are exempt", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.MaxUpcomingJoins = 1 })
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusCreated, create(adminToken, fmt.Sprintf("Admin Meetup %d", i)).Code)
+		}
+		for _, id := range []int64{events[0], events[2]} {
+			assert.Equal(t, http.StatusOK, join(adminToken, id).Code)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultEventListCacheTTL is used when EVENT_LIST_CACHE_TTL is not set
+const defaultEventListCacheTTL = 30 * time.Second
+
+// maxEventListCacheEntries bounds memory use; further filter combinations are simply not cached
+const maxEventListCacheEntries = 256
+
+// eventListCache holds anonymous /api/events responses (see getEvents)
+var eventListCache listingStore = newListingCache(eventListCacheTTLFromEnv())
+
+// loadEventList runs the listing query; tests swap it to count database round trips
+var loadEventList = queryEventList
+
+// listingStore is what getEvents and the writes that change listings need from the cache.
+// listingCache keeps it in process memory: with several instances (see Config.multiInstance) an
+// Invalidate only reaches the instance that made the write, so the others may serve a listing up
+// to EVENT_LIST_CACHE_TTL old. Set it to 0 there if that matters, or swap in a shared store.
+type listingStore interface {
+	Get(key string) ([]Event, uint64, bool)
+	Set(key string, generation uint64, events []Event)
+	Generation() uint64
+	Invalidate()
+	Stats() gin.H
+}
+
+type listingCacheEntry struct {
+	events  []Event
+	expires time.Time
+}
+
+// listingCache is a small TTL cache for event listings. Every write that changes what a
+// listing shows calls Invalidate, which also bumps the generation so a query that started
+// before the write can't store its stale result afterwards.
+type listingCache struct {
+	mu         sync.RWMutex
+	entries    map[string]listingCacheEntry
+	ttl        time.Duration
+	generation uint64
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+func newListingCache(ttl time.Duration) *listingCache {
+	return &listingCache{
+		entries: make(map[string]listingCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// eventListCacheTTLFromEnv reads EVENT_LIST_CACHE_TTL as a Go duration ("30s", "2m"); "0" disables caching
+func eventListCacheTTLFromEnv() time.Duration {
+	value := strings.TrimSpace(os.Getenv("EVENT_LIST_CACHE_TTL"))
+	if value == "" {
+		return defaultEventListCacheTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		log.Printf("⚠️  Invalid EVENT_LIST_CACHE_TTL %q, using %s", value, defaultEventListCacheTTL)
+		return defaultEventListCacheTTL
+	}
+	return ttl
+}
+
+// Get returns the cached events for key and the generation to pass to Set on a miss
+func (lc *listingCache) Get(key string) ([]Event, uint64, bool) {
+	lc.mu.RLock()
+	entry, ok := lc.entries[key]
+	generation := lc.generation
+	lc.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		lc.hits.Add(1)
+		return entry.events, generation, true
+	}
+	lc.misses.Add(1)
+	return nil, generation, false
+}
+
+// Set stores events unless the cache was invalidated since generation was read
+func (lc *listingCache) Set(key string, generation uint64, events []Event) {
+	if lc.ttl <= 0 {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if generation != lc.generation {
+		return
+	}
+	now := time.Now()
+	if len(lc.entries) >= maxEventListCacheEntries {
+		for k, e := range lc.entries {
+			if now.After(e.expires) {
+				delete(lc.entries, k)
+			}
+		}
+		if len(lc.entries) >= maxEventListCacheEntries {
+			return
+		}
+	}
+	lc.entries[key] = listingCacheEntry{events: events, expires: now.Add(lc.ttl)}
+}
+
+// Generation changes on every Invalidate; caches derived from events (see suggestEvents) compare it
+// to drop their entries along with the listings
+func (lc *listingCache) Generation() uint64 {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.generation
+}
+
+// Invalidate drops every cached listing
+func (lc *listingCache) Invalidate() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.entries = make(map[string]listingCacheEntry)
+	lc.generation++
+}
+
+// Stats reports hit/miss counters for the metrics endpoint
+func (lc *listingCache) Stats() gin.H {
+	lc.mu.RLock()
+	entries := len(lc.entries)
+	lc.mu.RUnlock()
+
+	return gin.H{
+		"hits":        lc.hits.Load(),
+		"misses":      lc.misses.Load(),
+		"entries":     entries,
+		"ttl_seconds": lc.ttl.Seconds(),
+	}
+}
+
+// adminGetMetrics exposes in-process counters (GET /api/admin/metrics)
+func adminGetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"event_list_cache": eventListCache.Stats(),
+		"housekeeping":     housekeeping.Stats(),
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countEventListLoads wraps loadEventList so tests can see how often the listing hits the database
+func countEventListLoads(t *testing.T) *int {
+	calls := 0
+	original := loadEventList
+	loadEventList = func(ctx context.Context, filter EventFilter, userID int, isVerified, isAdmin bool) ([]Event, error) {
+		calls++
+		return original(ctx, filter, userID, isVerified, isAdmin)
+	}
+	t.Cleanup(func() { loadEventList = original })
+	return &calls
+}
+
+func TestEventListCache(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	createTestEvent(t, testDB, organizerID, "Cached Event")
+
+	listAnonymous := func(query string) []Event {
+		w := doJSON(router, "GET", "/api/events"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+
+	t.Run("Identical anonymous requests hit the database once", func(t *testing.T) {
+		eventListCache.Invalidate()
+		calls := countEventListLoads(t)
+
+		first := listAnonymous("?languages=en,pl")
+		second := listAnonymous("?languages=pl,%20en")
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("Free-text search is not cached", func(t *testing.T) {
+		calls := countEventListLoads(t)
+		listAnonymous("?keyword=Cached")
+		listAnonymous("?keyword=Cached")
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("createEvent invalidates", func(t *testing.T) {
+		eventListCache.Invalidate()
+		calls := countEventListLoads(t)
+		require.Len(t, listAnonymous(""), 1)
+
+		start := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+		w := doJSON(router, "POST", "/api/events", organizerToken, gin.H{
+			"title": "Fresh Event", "description": "A brand new event for the cache test",
+			"category": "social_drinks", "latitude": 52.23, "longitude": 21.01,
+			"start_time": start, "creator_name": "Organizer",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99,
+			"event_languages": "en", "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		assert.Len(t, listAnonymous(""), 2)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Authenticated requests bypass the cache", func(t *testing.T) {
+		listAnonymous("")
+		calls := countEventListLoads(t)
+		for i := 0; i < 2; i++ {
+			w := doJSON(router, "GET", "/api/events", organizerToken, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Stats count hits and misses", func(t *testing.T) {
+		eventListCache.Invalidate()
+		cache := eventListCache.(*listingCache)
+		hits, misses := cache.hits.Load(), cache.misses.Load()
+		listAnonymous("?category=sports")
+		listAnonymous("?category=sports")
+
+		stats := eventListCache.Stats()
+		assert.Equal(t, hits+1, stats["hits"])
+		assert.Equal(t, misses+1, stats["misses"])
+		assert.Equal(t, 1, stats["entries"])
+	})
+}
+
+func TestListingCacheIgnoresStaleWrites(t *testing.T) {
+	cache := newListingCache(time.Minute)
+
+	_, generation, hit := cache.Get("k")
+	require.False(t, hit)
+	cache.Invalidate()
+	cache.Set("k", generation, []Event{{ID: 1}})
+
+	_, _, hit = cache.Get("k")
+	assert.False(t, hit, "result computed before an invalidation must not be stored")
+
+	disabled := newListingCache(0)
+	_, generation, _ = disabled.Get("k")
+	disabled.Set("k", generation, []Event{{ID: 1}})
+	_, _, hit = disabled.Get("k")
+	assert.False(t, hit)
+}
+
+func TestEventListCacheKey(t *testing.T) {
+	key := func(query string) (string, bool) {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		filter, err := ParseEventFilter(values)
+		require.NoError(t, err)
+		return filter.cacheKey()
+	}
+
+	a, ok := key("gender=any&smoking=yes")
+	require.True(t, ok)
+	b, _ := key("")
+	assert.Equal(t, b, a, "values the listing ignores normalize away")
+
+	c, _ := key("category=sports")
+	assert.NotEqual(t, b, c)
+
+	_, ok = key("location=Warsaw")
+	assert.False(t, ok)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLocationName(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/public/events/:slug/ics", downloadEventICS)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	createTestEvent(t, testDB, organizerID, "Coordinates Only")
+
+	payload := gin.H{
+		"title": "Coffee Meetup", "description": "Morning coffee with new people",
+		"category": "social_drinks", "latitude": 47.3769, "longitude": 8.5417,
+		"location_name": "  Café Odeon  ", "address": "Limmatquai 2, 8001 Zürich",
+		"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Organizer",
+		"gender_restriction": "any", "age_min": 18, "age_max": 99,
+		"event_languages": "en", "allow_unregistered_users": true,
+	}
+	w := doJSON(router, "POST", "/api/events", organizerToken, payload)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Café Odeon", created.LocationName)
+
+	t.Run("Public event includes location name and address", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/"+created.Slug, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, "Café Odeon", event.LocationName)
+		assert.Equal(t, "Limmatquai 2, 8001 Zürich", event.Address)
+	})
+
+	t.Run("ICS LOCATION uses the place name", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/"+created.Slug+"/ics", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `LOCATION:Café Odeon\, Limmatquai 2\, 8001 Zürich`+"\r\n")
+		assert.Contains(t, w.Body.String(), "GEO:47.376900;8.541700\r\n")
+	})
+
+	t.Run("Location filter matches the place name", func(t *testing.T) {
+		for _, query := range []string{"?location=odeon", "?location=Limmatquai"} {
+			w := doJSON(router, "GET", "/api/events"+query, "", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var events []Event
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+			require.Len(t, events, 1, query)
+			assert.Equal(t, created.ID, events[0].ID)
+		}
+
+		// Titles and descriptions are no longer searched by the location filter
+		w := doJSON(router, "GET", "/api/events?location=Coffee", "", nil)
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		assert.Empty(t, events)
+	})
+
+	t.Run("Update validates length", func(t *testing.T) {
+		payload["location_name"] = strings.Repeat("x", 201)
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", created.ID), organizerToken, payload)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		payload["location_name"] = "Kronenhalle"
+		payload["address"] = ""
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", created.ID), organizerToken, payload)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var name, address string
+		require.NoError(t, testDB.QueryRow(`SELECT location_name, address FROM events WHERE id = ?`, created.ID).Scan(&name, &address))
+		assert.Equal(t, "Kronenhalle", name)
+		assert.Empty(t, address)
+	})
+}
+
+func TestICSLocationFallsBackToCoordinates(t *testing.T) {
+	event := &Event{ID: 1, Title: "Pin Only", Latitude: 52.52, Longitude: 13.405, StartTime: time.Now().Format(time.RFC3339)}
+	assert.Contains(t, GenerateICS(event), "LOCATION:52.520000,13.405000\r\n")
+}
@@ -12,7 +12,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -36,16 +35,7 @@ func parseDateTime(dateStr string) (time.Time, error) {
 		return time.Time{}, nil
 	}
 
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05Z07:00",
-		"2006-01-02T15:04:05",
-		"2006-01-02T15:04",
-		"2006-01-02 15:04:05",
-		"2006-01-02 15:04",
-	}
-
-	for _, format := range formats {
+	for _, format := range dateTimeFormats {
 		if t, err := time.Parse(format, dateStr); err == nil {
 			log.Printf("✓ Successfully parsed datetime: %s using format: %s", dateStr, format)
 			return t, nil
@@ -94,20 +84,33 @@ func initDB() {
 		return
 	}
 
+	openDatabase("./veidly.db")
+}
+
+// databaseDSN enables WAL mode for better concurrency and foreign keys on every pooled
+// connection, so the ON DELETE CASCADE clauses below actually apply. Writers queue for the lock
+// for up to DBBusyTimeout, and transactions take it when they begin (BEGIN IMMEDIATE): a deferred
+// transaction that reads first can't wait for it later and fails with SQLITE_BUSY straight away.
+func databaseDSN(path string) string {
+	return fmt.Sprintf("%s?_journal_mode=WAL&_foreign_keys=1&_busy_timeout=%d&_txlock=immediate",
+		path, appConfig.DBBusyTimeout.Milliseconds())
+}
+
+// openDatabase opens the database at path into db and brings its schema up to date
+func openDatabase(path string) {
 	var err error
-	// Enable WAL mode for better concurrency
-	db, err = sql.Open("sqlite3", "./veidly.db?_journal_mode=WAL")
+	db, err = sql.Open("sqlite3", databaseDSN(path))
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
+	db.SetMaxOpenConns(appConfig.DBMaxOpenConns)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	log.Println("📦 Database connection established")
-	log.Println("🔒 Production database: veidly.db")
+	log.Printf("🔒 Production database: %s", path)
 
 	// Users table
 	_, err = db.Exec(`
@@ -144,6 +147,38 @@ func initDB() {
 		}
 	}
 
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='totp_secret'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN totp_secret TEXT`); err != nil {
+			log.Printf("⚠️  add totp_secret failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='totp_enabled'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN totp_enabled INTEGER DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add totp_enabled failed: %v", err)
+		}
+	}
+
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='profile_visibility'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN profile_visibility TEXT DEFAULT 'public'`); err != nil {
+			log.Printf("⚠️  add profile_visibility failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='show_email'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN show_email INTEGER DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add show_email failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='gender'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN gender TEXT DEFAULT 'unspecified'`); err != nil {
+			log.Printf("⚠️  add gender failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='birth_year'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN birth_year INTEGER`); err != nil {
+			log.Printf("⚠️  add birth_year failed: %v", err)
+		}
+	}
+
 	// Optional data migration from telegram -> threema if telegram column exists
 	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='telegram'`).Scan(&exists); err == nil && exists == 1 {
 		if _, err := db.Exec(`UPDATE users SET threema = COALESCE(threema, telegram) WHERE telegram IS NOT NULL AND telegram != ''`); err != nil {
@@ -152,6 +187,15 @@ func initDB() {
 		// Do NOT drop columns here; SQLite drop column is version-dependent and risky.
 	}
 
+	// Emails are stored lower-cased; addresses differing only by case stay as they are for an admin to merge
+	if collisions, err := lowercaseUserEmails(db); err != nil {
+		log.Printf("⚠️  normalize user emails failed: %v", err)
+	} else {
+		for _, collision := range collisions {
+			log.Printf("⚠️  Accounts %v share the email %s ignoring case; merge them via /api/admin/users/:id/merge", collision.UserIDs, collision.Email)
+		}
+	}
+
 	// Events table (updated with user_id and category)
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS events (
@@ -243,6 +287,7 @@ func initDB() {
 		"require_verified_to_join":       "INTEGER DEFAULT 0",
 		"require_verified_to_view":       "INTEGER DEFAULT 0",
 		"allow_unregistered_users":       "INTEGER DEFAULT 1",
+		"require_birth_year":             "INTEGER DEFAULT 0",
 	}
 
 	// Whitelist of allowed column names to prevent SQL injection
@@ -252,6 +297,7 @@ func initDB() {
 		"require_verified_to_join":       true,
 		"require_verified_to_view":       true,
 		"allow_unregistered_users":       true,
+		"require_birth_year":             true,
 	}
 
 	for columnName, columnDef := range privacyColumns {
@@ -342,6 +388,22 @@ func initDB() {
 		log.Fatal(err)
 	}
 
+	// 2FA recovery codes table (only hashes are stored, each code is single-use)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS user_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON user_recovery_codes(user_id)`)
+
 	// Event participants table (tracks who's attending events)
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS event_participants (
@@ -357,6 +419,57 @@ func initDB() {
 		log.Fatal(err)
 	}
 
+	// Attendance tracking columns (set by the organizer after the event started)
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='attendance'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN attendance TEXT`); err != nil {
+			log.Printf("⚠️  add attendance failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='attendance_marked_at'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN attendance_marked_at DATETIME`); err != nil {
+			log.Printf("⚠️  add attendance_marked_at failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='attendance_disputed'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN attendance_disputed INTEGER DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add attendance_disputed failed: %v", err)
+		}
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='checked_in_at'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN checked_in_at DATETIME`); err != nil {
+			log.Printf("⚠️  add checked_in_at failed: %v", err)
+		}
+	}
+
+	// Contact sharing opt-in (set on join or later by the participant; off by default)
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='share_contact'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN share_contact INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add share_contact failed: %v", err)
+		}
+	}
+
+	// Per-event display alias shown to other participants instead of the profile name
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_participants') WHERE name='display_alias'`).Scan(&exists); err == nil && exists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_participants ADD COLUMN display_alias TEXT`); err != nil {
+			log.Printf("⚠️  add display_alias failed: %v", err)
+		}
+	}
+
+	// Check-in codes (short-lived, stored hashed; participants enter them to mark themselves attended)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_checkin_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_checkin_codes_event ON event_checkin_codes(event_id, code_hash)`)
+
 	// User blocking table (bidirectional blocking for privacy)
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS user_blocks (
@@ -397,6 +510,40 @@ func initDB() {
 	// Create indexes for event_comments
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_event ON event_comments(event_id, created_at)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_user ON event_comments(user_id)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_event_user ON event_comments(event_id, user_id, created_at)`)
+
+	// Per-user comment read markers (unread badge on events)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS comment_read_state (
+		user_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		last_read_comment_id INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, event_id),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Event feedback table (post-event ratings from participants)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_feedback (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		comment TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Event reports table (moderation system)
 	_, err = db.Exec(`
@@ -419,6 +566,7 @@ func initDB() {
 	}
 
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_reports_status ON event_reports(status, created_at)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_reports_event ON event_reports(event_id, status)`)
 
 	// Comment reports table (moderation system)
 	_, err = db.Exec(`
@@ -440,6 +588,369 @@ func initDB() {
 		log.Fatal(err)
 	}
 
+	// Outgoing webhooks (admin-managed) and their delivery log
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT NOT NULL,
+		created_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		succeeded BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id)`)
+
+	// Category -> chat routing for Telegram/Matrix broadcasts
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS broadcast_routes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		category TEXT NOT NULL,
+		notifier TEXT NOT NULL,
+		target TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(category, notifier, target)
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Per-user activity feed (GET /api/profile/activity), fanned out on write
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS activity_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		actor_id INTEGER NOT NULL,
+		verb TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_activity_user ON activity_log(user_id, created_at)`)
+
+	// In-app notification inbox (GET /api/notifications), written next to the matching emails
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL DEFAULT '{}',
+		read_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at)`)
+
+	// Idempotency-Key claims and stored responses for retried POSTs (kept 24h)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status_code INTEGER,
+		response TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, idempotency_key),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Admin-managed banned terms, stored normalized (see normalizeForMatching)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS moderation_terms (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		term TEXT UNIQUE NOT NULL,
+		action TEXT NOT NULL DEFAULT 'reject',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Events and comments held back by the moderation filter until an admin reviews them
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS moderation_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content_type TEXT NOT NULL,
+		content_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		violations TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reviewed_by INTEGER,
+		reviewed_at DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_moderation_queue_status ON moderation_queue(status, created_at)`)
+
+	// Privileged admin actions (role changes), kept even if the acting admin is deleted
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS admin_audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		admin_id INTEGER,
+		action TEXT NOT NULL,
+		target_user_id INTEGER,
+		details TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Monthly organizer digests already sent, so a rerun of the job doesn't mail anyone twice
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS organizer_digests (
+		user_id INTEGER NOT NULL,
+		month TEXT NOT NULL,
+		sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, month),
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Site-wide announcement banners; translations is a JSON object of language code -> message
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS announcements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message TEXT NOT NULL,
+		translations TEXT NOT NULL DEFAULT '{}',
+		level TEXT NOT NULL DEFAULT 'info',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME,
+		created_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Questions organizers ask joiners. Editing a question retires the row and adds a new one, so
+	// answers keep pointing at the wording they answered.
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_questions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		text TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT 'text',
+		required INTEGER NOT NULL DEFAULT 0,
+		retired_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_questions_event ON event_questions(event_id)`)
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_question_answers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		answer TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (question_id) REFERENCES event_questions (id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(question_id, user_id)
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_question_answers_event ON event_question_answers(event_id, user_id)`)
+
+	// "Interested" marks: a non-binding signal short of joining (see interest.go). notified_at is
+	// set once the user was told about a freed spot, so each user hears about an event at most once.
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_interest (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		notified_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_interest_user ON event_interest(user_id)`)
+
+	// Personal mutes: events a user hid from their own listings (see event_hides.go)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_hides (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+		UNIQUE(event_id, user_id)
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_hides_user ON event_hides(user_id, created_at)`)
+
+	// Persistent background jobs (see jobs.go); rows outlive a crash between commit and send
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		run_at DATETIME NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'pending',
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs(status, run_at)`)
+
+	// Every outgoing email attempt, so support can see why a mail never arrived (see email_log.go)
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS email_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER,
+		recipient TEXT NOT NULL,
+		email_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		provider_message_id TEXT,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_email_log_user ON email_log(user_id, id)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_email_log_status ON email_log(status, id)`)
+
+	// Groups: persistent communities that members follow and that own events
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		slug TEXT UNIQUE NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		owner_id INTEGER NOT NULL,
+		join_policy TEXT NOT NULL DEFAULT 'open',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME,
+		FOREIGN KEY (owner_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'member',
+		notify INTEGER NOT NULL DEFAULT 1,
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id),
+		FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_group_members_user ON group_members(user_id)`)
+
+	// Why participants left (leaveEvent); organizers only see counts unless the leaver shared who they are
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS participation_exits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		reason TEXT,
+		note TEXT NOT NULL DEFAULT '',
+		share_identity INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_participation_exits_event ON participation_exits(event_id)`)
+
+	// Handovers of an event to another organizer waiting for them to accept; one per event
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_transfers (
+		event_id INTEGER PRIMARY KEY,
+		from_user_id INTEGER NOT NULL,
+		to_user_id INTEGER NOT NULL,
+		keep_as_participant INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE,
+		FOREIGN KEY (from_user_id) REFERENCES users (id) ON DELETE CASCADE,
+		FOREIGN KEY (to_user_id) REFERENCES users (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_transfers_to_user ON event_transfers(to_user_id)`)
+
+	// Category-specific event fields (see CategoryAttributes), filtered on with attr.<key>=value
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS event_attributes (
+		event_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (event_id, key),
+		FOREIGN KEY (event_id) REFERENCES events (id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_attributes_key_value ON event_attributes(key, value)`)
+
 	// Add comments_enabled column to events table (migration)
 	var commentsEnabledExists int
 	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='comments_enabled'`).Scan(&commentsEnabledExists)
@@ -453,19 +964,324 @@ func initDB() {
 		}
 	}
 
-	// Create or update default admin user with secure password
-	adminEmail := os.Getenv("ADMIN_EMAIL")
-	if adminEmail == "" {
-		adminEmail = "admin@veidly.com" // Default fallback
+	// Add cancelled_at column to events table (soft cancel keeps the event but closes it)
+	var cancelledAtExists int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='cancelled_at'`).Scan(&cancelledAtExists)
+	if cancelledAtExists == 0 {
+		log.Println("📝 Adding cancelled_at column to events table...")
+		_, err = db.Exec(`ALTER TABLE events ADD COLUMN cancelled_at DATETIME`)
+		if err != nil {
+			log.Printf("⚠️  Warning: Could not add cancelled_at column: %v", err)
+		} else {
+			log.Println("✓ cancelled_at column added successfully")
+		}
+	}
+
+	// Add participant_count column to events table (denormalized so listings skip a count per row)
+	var participantCountExists int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='participant_count'`).Scan(&participantCountExists)
+	if participantCountExists == 0 {
+		log.Println("📝 Adding participant_count column to events table...")
+		_, err = db.Exec(`ALTER TABLE events ADD COLUMN participant_count INTEGER NOT NULL DEFAULT 0`)
+		if err != nil {
+			log.Printf("⚠️  Warning: Could not add participant_count column: %v", err)
+		} else {
+			log.Println("✓ participant_count column added successfully")
+		}
+	}
+	// Recount on every startup so the counter can't drift across deploys or manual DB edits
+	if _, err := db.Exec(`
+		UPDATE events SET participant_count = (SELECT COUNT(*) FROM event_participants WHERE event_id = events.id)
+	`); err != nil {
+		log.Printf("⚠️  Warning: Could not recount participants: %v", err)
+	}
+
+	// Add interested_count column to events table, denormalized like participant_count
+	var interestedCountExists int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='interested_count'`).Scan(&interestedCountExists)
+	if interestedCountExists == 0 {
+		log.Println("📝 Adding interested_count column to events table...")
+		_, err = db.Exec(`ALTER TABLE events ADD COLUMN interested_count INTEGER NOT NULL DEFAULT 0`)
+		if err != nil {
+			log.Printf("⚠️  Warning: Could not add interested_count column: %v", err)
+		} else {
+			log.Println("✓ interested_count column added successfully")
+		}
+	}
+	if _, err := db.Exec(`
+		UPDATE events SET interested_count = (SELECT COUNT(*) FROM event_interest WHERE event_id = events.id)
+	`); err != nil {
+		log.Printf("⚠️  Warning: Could not recount interested users: %v", err)
+	}
+
+	// Languages were free text before validation; keep only recognizable codes
+	normalizeStoredLanguages(db)
+
+	// Add hidden_pending_review column to events table (set when reports reach the takedown threshold)
+	var hiddenColumnExists int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='hidden_pending_review'`).Scan(&hiddenColumnExists)
+	if hiddenColumnExists == 0 {
+		log.Println("📝 Adding hidden_pending_review column to events table...")
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN hidden_pending_review INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  Warning: Could not add hidden_pending_review column: %v", err)
+		} else {
+			log.Println("✓ hidden_pending_review column added successfully")
+		}
+	}
+
+	// Add hidden_sources column to events table (which of moderation, reports and integrity repair
+	// hold a hidden event). Events hidden before it existed get the source the evidence points to.
+	var hiddenSourcesColumnExists int
+	db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='hidden_sources'`).Scan(&hiddenSourcesColumnExists)
+	if hiddenSourcesColumnExists == 0 {
+		log.Println("📝 Adding hidden_sources column to events table...")
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN hidden_sources INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  Warning: Could not add hidden_sources column: %v", err)
+		} else {
+			log.Println("✓ hidden_sources column added successfully")
+		}
+	}
+	if _, err := db.Exec(`
+		UPDATE events SET hidden_sources =
+			CASE WHEN EXISTS (SELECT 1 FROM moderation_queue q WHERE q.content_type = 'event' AND q.content_id = events.id AND q.status = ?) THEN ? ELSE 0 END
+			| CASE WHEN EXISTS (SELECT 1 FROM event_reports r WHERE r.event_id = events.id AND r.status IN (?, ?)) THEN ? ELSE 0 END
+			| CASE WHEN NOT EXISTS (SELECT 1 FROM users u WHERE u.id = events.user_id) THEN ? ELSE 0 END
+		WHERE hidden_pending_review = 1 AND hidden_sources = 0
+	`, ModerationPending, HideSourceModeration, ReportStatusPending, ReportStatusUpheld, HideSourceReports, HideSourceIntegrity); err != nil {
+		log.Printf("⚠️  Warning: Could not backfill hidden_sources: %v", err)
+	}
+	// Whatever hid the rest left no trace; count it as moderation so the event stays hidden
+	db.Exec(`UPDATE events SET hidden_sources = ? WHERE hidden_pending_review = 1 AND hidden_sources = 0`, HideSourceModeration)
+
+	// Add location_name and address columns to events table (older events stay empty until edited)
+	for _, column := range []string{"location_name", "address"} {
+		var columnExists int
+		db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name=?`, column).Scan(&columnExists)
+		if columnExists == 0 {
+			log.Printf("📝 Adding %s column to events table...", column)
+			_, err = db.Exec(`ALTER TABLE events ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`)
+			if err != nil {
+				log.Printf("⚠️  Warning: Could not add %s column: %v", column, err)
+			} else {
+				log.Printf("✓ %s column added successfully", column)
+			}
+		}
+	}
+
+	// Add anonymized_at column to events table (set by housekeeping once personal text is stripped)
+	var anonymizedAtExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='anonymized_at'`).Scan(&anonymizedAtExists); err == nil && anonymizedAtExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN anonymized_at DATETIME`); err != nil {
+			log.Printf("⚠️  add anonymized_at failed: %v", err)
+		}
 	}
 
+	// Add pending_review column to event_comments table (comments held by the moderation filter)
+	var commentPendingExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_comments') WHERE name='pending_review'`).Scan(&commentPendingExists); err == nil && commentPendingExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_comments ADD COLUMN pending_review INTEGER DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add pending_review failed: %v", err)
+		}
+	}
+
+	// Add revision column to event_comments (change sequence for polling clients); existing
+	// comments are numbered by id, which new writes continue from
+	var commentRevisionExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('event_comments') WHERE name='revision'`).Scan(&commentRevisionExists); err == nil && commentRevisionExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE event_comments ADD COLUMN revision INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add event_comments.revision failed: %v", err)
+		} else if _, err := db.Exec(`UPDATE event_comments SET revision = id`); err != nil {
+			log.Printf("⚠️  numbering comment revisions failed: %v", err)
+		}
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_revision ON event_comments(revision)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_event_revision ON event_comments(event_id, revision)`)
+
+	// Add description_format column to events table (existing descriptions are plain text)
+	var descriptionFormatExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='description_format'`).Scan(&descriptionFormatExists); err == nil && descriptionFormatExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN description_format TEXT NOT NULL DEFAULT 'plain'`); err != nil {
+			log.Printf("⚠️  add description_format failed: %v", err)
+		}
+	}
+
+	// Add timezone columns: events keep the IANA zone they were created in, users a default for
+	// new events. Stored start/end times become RFC3339 UTC at the same time.
+	var eventTimezoneExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='timezone'`).Scan(&eventTimezoneExists); err == nil && eventTimezoneExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC'`); err != nil {
+			log.Printf("⚠️  add events.timezone failed: %v", err)
+		} else {
+			normalizeStoredEventTimes(db)
+		}
+	}
+	var userTimezoneExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='timezone'`).Scan(&userTimezoneExists); err == nil && userTimezoneExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN timezone TEXT`); err != nil {
+			log.Printf("⚠️  add users.timezone failed: %v", err)
+		}
+	}
+
+	// Add digest_emails column to users table (monthly organizer digest, on unless the user opts out)
+	var digestEmailsExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='digest_emails'`).Scan(&digestEmailsExists); err == nil && digestEmailsExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN digest_emails INTEGER NOT NULL DEFAULT 1`); err != nil {
+			log.Printf("⚠️  add digest_emails failed: %v", err)
+		}
+	}
+
+	// Add verification_email_failed column to users table (set when the verification email bounces)
+	var verificationFailedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='verification_email_failed'`).Scan(&verificationFailedExists); err == nil && verificationFailedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN verification_email_failed INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add verification_email_failed failed: %v", err)
+		}
+	}
+
+	// Add published column to events table (drafts by unverified organizers) and the reminder marker
+	// housekeeping sets before deleting a stale draft
+	var publishedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='published'`).Scan(&publishedExists); err == nil && publishedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN published INTEGER NOT NULL DEFAULT 1`); err != nil {
+			log.Printf("⚠️  add published failed: %v", err)
+		}
+	}
+	var draftRemindedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='draft_reminded_at'`).Scan(&draftRemindedExists); err == nil && draftRemindedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN draft_reminded_at DATETIME`); err != nil {
+			log.Printf("⚠️  add draft_reminded_at failed: %v", err)
+		}
+	}
+
+	// Add fingerprint column to events table (normalized title, place and date for duplicate detection)
+	var fingerprintExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='fingerprint'`).Scan(&fingerprintExists); err == nil && fingerprintExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN fingerprint TEXT`); err != nil {
+			log.Printf("⚠️  add fingerprint failed: %v", err)
+		} else {
+			backfillEventFingerprints(db)
+		}
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_fingerprint ON events(fingerprint)`)
+
+	// Add group_id column to events table (events published in a group; standalone events stay NULL)
+	var groupIDExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='group_id'`).Scan(&groupIDExists); err == nil && groupIDExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN group_id INTEGER REFERENCES groups (id) ON DELETE SET NULL`); err != nil {
+			log.Printf("⚠️  add group_id failed: %v", err)
+		}
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_group ON events(group_id, start_time)`)
+
+	// Add price columns to events table (informational only; existing events have no price)
+	for column, definition := range map[string]string{
+		"price_amount":   "INTEGER",
+		"price_currency": "TEXT NOT NULL DEFAULT '" + DefaultPriceCurrency + "'",
+		"payment_note":   "TEXT NOT NULL DEFAULT ''",
+	} {
+		var columnExists int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name=?`, column).Scan(&columnExists); err == nil && columnExists == 0 {
+			if _, err := db.Exec(`ALTER TABLE events ADD COLUMN ` + column + ` ` + definition); err != nil {
+				log.Printf("⚠️  add %s failed: %v", column, err)
+			}
+		}
+	}
+
+	// Add max_joins_per_network to events and registration_ip to users (per-network join caps; the IP
+	// is only read by joinEvent and shown in the admin user list)
+	var joinsPerNetworkExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='max_joins_per_network'`).Scan(&joinsPerNetworkExists); err == nil && joinsPerNetworkExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN max_joins_per_network INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add max_joins_per_network failed: %v", err)
+		}
+	}
+	// Add reserved_spots to events table (spots the organizer holds for people who aren't on the platform)
+	var reservedSpotsExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='reserved_spots'`).Scan(&reservedSpotsExists); err == nil && reservedSpotsExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN reserved_spots INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add reserved_spots failed: %v", err)
+		}
+	}
+	// Add joins_paused to events and events_paused to users (organizer vacation mode; new events inherit the user's flag)
+	var joinsPausedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='joins_paused'`).Scan(&joinsPausedExists); err == nil && joinsPausedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN joins_paused INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add joins_paused failed: %v", err)
+		}
+	}
+	// Add is_featured and featured_until to events (admin pinning; a NULL featured_until pins until unfeatured)
+	var isFeaturedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='is_featured'`).Scan(&isFeaturedExists); err == nil && isFeaturedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN is_featured INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add is_featured failed: %v", err)
+		}
+	}
+	var featuredUntilExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name='featured_until'`).Scan(&featuredUntilExists); err == nil && featuredUntilExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE events ADD COLUMN featured_until DATETIME`); err != nil {
+			log.Printf("⚠️  add featured_until failed: %v", err)
+		}
+	}
+	// Add join_deadline to events (signups close there instead of at the start) and the marker of its reminder
+	for column, definition := range map[string]string{
+		"join_deadline":        "DATETIME",
+		"deadline_reminded_at": "DATETIME",
+	} {
+		var exists int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('events') WHERE name=?`, column).Scan(&exists); err == nil && exists == 0 {
+			if _, err := db.Exec(`ALTER TABLE events ADD COLUMN ` + column + ` ` + definition); err != nil {
+				log.Printf("⚠️  add %s failed: %v", column, err)
+			}
+		}
+	}
+	var eventsPausedExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='events_paused'`).Scan(&eventsPausedExists); err == nil && eventsPausedExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN events_paused INTEGER NOT NULL DEFAULT 0`); err != nil {
+			log.Printf("⚠️  add events_paused failed: %v", err)
+		}
+	}
+	var registrationIPExists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name='registration_ip'`).Scan(&registrationIPExists); err == nil && registrationIPExists == 0 {
+		if _, err := db.Exec(`ALTER TABLE users ADD COLUMN registration_ip TEXT`); err != nil {
+			log.Printf("⚠️  add registration_ip failed: %v", err)
+		}
+	}
+
+	// Add blocked_until and block_reason to users table (timed suspensions; NULL until means permanent)
+	for column, definition := range map[string]string{
+		"blocked_until": "DATETIME",
+		"block_reason":  "TEXT",
+	} {
+		var exists int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name=?`, column).Scan(&exists); err == nil && exists == 0 {
+			if _, err := db.Exec(`ALTER TABLE users ADD COLUMN ` + column + ` ` + definition); err != nil {
+				log.Printf("⚠️  add %s failed: %v", column, err)
+			}
+		}
+	}
+
+	// Add updated_at columns to events and users tables (existing rows start from created_at)
+	for _, table := range []string{"events", "users"} {
+		var updatedAtExists int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('` + table + `') WHERE name='updated_at'`).Scan(&updatedAtExists); err == nil && updatedAtExists == 0 {
+			if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN updated_at DATETIME`); err != nil {
+				log.Printf("⚠️  add %s.updated_at failed: %v", table, err)
+			} else if _, err := db.Exec(`UPDATE ` + table + ` SET updated_at = created_at`); err != nil {
+				log.Printf("⚠️  backfill %s.updated_at failed: %v", table, err)
+			}
+		}
+	}
+
+	// Create or update default admin user with secure password
+	adminEmail := normalizeEmail(appConfig.AdminEmail)
+
 	var adminCount int
 	db.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", adminEmail).Scan(&adminCount)
 
-	adminPass := os.Getenv("ADMIN_PASSWORD")
+	adminPass := appConfig.AdminPassword
 	if adminPass == "" {
 		// Generate random password only in development
-		if os.Getenv("ENVIRONMENT") == "development" {
+		if appConfig.Environment == "development" {
 			adminPass = generateRandomString(16)
 			log.Printf("⚠️  IMPORTANT: Generated admin password: %s", adminPass)
 			log.Println("⚠️  SAVE THIS PASSWORD - Set ADMIN_PASSWORD env var for custom password")
@@ -520,13 +1336,27 @@ func initDB() {
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_participants_event_id ON event_participants(event_id)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_participants_user_id ON event_participants(user_id)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_slug ON events(slug)`)
+	// Databases whose slug column was added by ALTER TABLE lack the UNIQUE constraint; this index
+	// adds it once their duplicates are fixed (see GET /api/admin/events/slug-issues)
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_events_slug_unique ON events(slug)`); err != nil {
+		log.Printf("⚠️  Event slugs are not unique yet, regenerate the duplicates: %v", err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_updated_at ON events(updated_at)`)
 	log.Println("✓ Indexes ready")
 
+	checkDatabaseIntegrity(db, appConfig.RepairOrphans)
+
 	log.Println("✓ Database schema ready")
 }
 
 func main() {
 	log.Println("🚀 Starting Veidly Server...")
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	appConfig = cfg
+
 	// Initialize JWT secret
 	if err := initJWTFromEnv(); err != nil {
 		log.Fatalf("JWT init error: %v", err)
@@ -536,159 +1366,77 @@ func main() {
 		defer db.Close()
 	}
 
+	// `go run . seed` fills a development database with fake data instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeedCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Seed failed: %v", err)
+		}
+		return
+	}
+
 	// Initialize email service
 	emailService = NewEmailService()
 
 	// Set Gin mode based on environment
-	if os.Getenv("ENVIRONMENT") == "production" {
+	if appConfig.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.New()
-
-	// Add custom middleware
-	router.Use(RequestIDMiddleware())
-	router.Use(LoggerMiddleware())
-	router.Use(gin.Recovery())
-	router.Use(ErrorHandlerMiddleware())
-	router.Use(SecurityHeadersMiddleware())
-	router.Use(RequestSizeLimitMiddleware(5 * 1024 * 1024)) // 5MB limit
-
-	// CORS middleware (origins from env CORS_ORIGINS, comma-separated)
-	originsEnv := os.Getenv("CORS_ORIGINS")
-	var allowedOrigins []string
-
-	if strings.TrimSpace(originsEnv) == "" {
-		// Development defaults
-		if os.Getenv("ENVIRONMENT") != "production" {
-			allowedOrigins = []string{"http://localhost:3000", "http://localhost:5173"}
-			log.Println("⚠️  Using default CORS origins (development mode)")
-		} else {
-			log.Fatal("CORS_ORIGINS must be set in production")
-		}
-	} else {
-		// Validate and parse CORS origins
-		if strings.Contains(originsEnv, "*") {
-			log.Fatal("Wildcard CORS origins (*) are not allowed for security")
-		}
-		parts := strings.Split(originsEnv, ",")
-		for _, p := range parts {
-			v := strings.TrimSpace(p)
-			if v != "" {
-				allowedOrigins = append(allowedOrigins, v)
-			}
+	// With REDIS_URL set, rate limits are shared between instances and survive restarts
+	if appConfig.RedisURL != "" {
+		if err := connectRateLimitRedis(appConfig.RedisURL); err != nil {
+			log.Fatalf("Redis config error: %v", err)
 		}
-		log.Printf("✓ CORS origins: %v", allowedOrigins)
+		checkinLimiter = newRateLimiter("checkin", checkinAttempts, checkinAttemptWindow)
+		log.Printf("ℹ️  Running as one of several instances: listing caches are per process and comment long-polls answer immediately")
 	}
 
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
 	// Rate limiters for different endpoints (increased for testing/seeding)
 	// Store limiter instances for graceful shutdown
-	authLimiterInstance, authLimiter := RateLimitMiddleware(20, time.Minute)             // 20 requests per minute for auth (5 in production)
-	apiLimiterInstance, apiLimiter := RateLimitMiddleware(200, time.Minute)              // 200 requests per minute for API (100 in production)
-	searchLimiterInstance, searchLimiter := RateLimitMiddleware(50, time.Minute)         // 50 searches per minute (30 in production)
-	createEventLimiterInstance, createEventLimiter := RateLimitMiddleware(100, time.Hour) // 100 events per hour (10 in production)
+	authLimiterInstance, authLimiter := RateLimitMiddleware("auth", appConfig.AuthRateLimit, time.Minute)
+	apiLimiterInstance, apiLimiter := RateLimitMiddleware("api", appConfig.APIRateLimit, time.Minute)
+	searchLimiterInstance, searchLimiter := RateLimitMiddleware("search", appConfig.SearchRateLimit, time.Minute)
+	createEventLimiterInstance, createEventLimiter := RateLimitMiddleware("create_event", appConfig.CreateEventRateLimit, time.Hour)
 
 	// Collect all limiters for shutdown
-	rateLimiters := []*rateLimiter{authLimiterInstance, apiLimiterInstance, searchLimiterInstance, createEventLimiterInstance}
+	rateLimiters := []*rateLimiter{authLimiterInstance, apiLimiterInstance, searchLimiterInstance, createEventLimiterInstance, checkinLimiter}
+
+	// Outgoing webhooks are delivered by a background worker, stopped after the server on shutdown
+	webhookDispatch = newWebhookDispatcher(&http.Client{Timeout: 10 * time.Second}, defaultWebhookBaseBackoff)
+	configureNotifiers()
+
+	// Expired tokens and (optionally) old events are cleaned up in the background
+	housekeeper := startHousekeeping(appConfig.CleanupInterval)
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "timestamp": time.Now()})
+	// Emails and other deferred work run from the persistent jobs table
+	backgroundJobs = startJobRunner(jobWorkers, jobPollInterval, defaultJobBaseBackoff)
+
+	router := setupRouter(routeLimiters{
+		auth:        authLimiter,
+		api:         apiLimiter,
+		search:      searchLimiter,
+		createEvent: createEventLimiter,
 	})
 
-	// Public routes with rate limiting
-	router.POST("/api/auth/register", authLimiter, register)
-	router.POST("/api/auth/login", authLimiter, login)
-	router.POST("/api/auth/logout", authLimiter, logout)                           // Logout (clears httpOnly cookie)
-	router.GET("/api/auth/verify-email", apiLimiter, VerifyEmail)                  // Email verification
-	router.POST("/api/auth/resend-verification", authLimiter, ResendVerificationEmail) // Resend verification
-	router.POST("/api/auth/forgot-password", authLimiter, ForgotPassword)          // Password reset request
-	router.POST("/api/auth/reset-password", authLimiter, ResetPassword)            // Password reset
-	router.GET("/api/events", apiLimiter, optionalAuthMiddleware(), getEvents)
-	router.GET("/api/events/:id", apiLimiter, optionalAuthMiddleware(), getEvent)
-	router.GET("/api/events/:id/participants", apiLimiter, optionalAuthMiddleware(), getEventParticipants)
-	router.GET("/api/public/events/:slug", apiLimiter, optionalAuthMiddleware(), getPublicEvent) // Public event access by slug
-	router.GET("/api/public/events/:slug/ics", apiLimiter, downloadEventICS)                  // Download ICS calendar file
-	router.GET("/api/search/places", searchLimiter, searchPlaces)
-	router.GET("/api/categories", getCategories)
-
-	// Protected routes (require authentication)
-	protected := router.Group("/api")
-	protected.Use(authMiddleware())
-	{
-		protected.POST("/events", createEventLimiter, createEvent)
-		protected.PUT("/events/:id", updateEvent)
-		protected.DELETE("/events/:id", deleteEvent)
-		protected.POST("/events/:id/join", joinEvent)
-		protected.DELETE("/events/:id/leave", leaveEvent)
-		protected.GET("/auth/me", getCurrentUser)
-		protected.GET("/profile", getOwnProfile)
-		protected.PUT("/profile", updateProfile)
-		protected.GET("/profile/:id", getUserProfile)
-
-		// Blocking routes
-		protected.POST("/users/:id/block", blockUser)
-		protected.DELETE("/users/:id/block", unblockUser)
-		protected.GET("/blocks", getBlockedUsers)
-
-		// Comment routes
-		protected.GET("/events/:id/comments", getEventComments)
-		protected.POST("/events/:id/comments", createEventComment)
-		protected.PUT("/comments/:id", updateEventComment)
-		protected.DELETE("/comments/:id", deleteEventComment)
-	}
-
-	// Admin routes
-	admin := router.Group("/api/admin")
-	admin.Use(authMiddleware(), adminMiddleware())
-	{
-		admin.GET("/users", adminGetUsers)
-		admin.PUT("/users/:id/block", adminBlockUser)
-		admin.PUT("/users/:id/unblock", adminUnblockUser)
-		admin.PUT("/users/:id/verify-email", adminVerifyUserEmail)
-		admin.GET("/events", adminGetAllEvents)
-		admin.DELETE("/events/:id", adminDeleteEvent)
-		admin.PUT("/events/:id", adminUpdateEvent)
-	}
-
-	port := os.Getenv("PORT")
-	if strings.TrimSpace(port) == "" {
-		port = os.Getenv("SERVER_PORT")
-	}
-	if strings.TrimSpace(port) == "" {
-		port = "8080"
-	}
+	port := appConfig.Port
 
 	// TLS/HTTPS support
-	useTLS := os.Getenv("USE_TLS") == "true"
+	useTLS := appConfig.UseTLS
 
 	// Create HTTP server with proper configuration
 	srv := &http.Server{
 		Addr:           ":" + port,
 		Handler:        router,
 		ReadTimeout:    15 * time.Second,
-		WriteTimeout:   15 * time.Second,
+		WriteTimeout:   serverWriteTimeout,
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
 	// Start server in a goroutine
 	go func() {
 		if useTLS {
-			certFile := os.Getenv("TLS_CERT")
-			keyFile := os.Getenv("TLS_KEY")
-
-			if certFile == "" || keyFile == "" {
-				log.Fatal("TLS_CERT and TLS_KEY must be set when USE_TLS=true")
-			}
+			certFile := appConfig.TLSCert
+			keyFile := appConfig.TLSKey
 
 			log.Printf("✓ Server running on https://localhost:%s (TLS enabled)", port)
 			log.Println("✓ API endpoints ready")
@@ -699,7 +1447,7 @@ func main() {
 			// When behind a reverse proxy (like nginx) that handles TLS termination,
 			// it's acceptable to run the backend on HTTP locally
 			log.Printf("✓ Server running on http://localhost:%s", port)
-			if os.Getenv("ENVIRONMENT") == "production" {
+			if appConfig.IsProduction() {
 				log.Println("⚠️  Running without TLS - ensure reverse proxy (nginx) handles HTTPS")
 			}
 			log.Println("✓ API endpoints ready")
@@ -721,6 +1469,7 @@ func main() {
 	for _, limiter := range rateLimiters {
 		limiter.Shutdown()
 	}
+	housekeeper.Shutdown()
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -730,6 +1479,23 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("⚠️  Server forced to shutdown:", err)
 	}
+	closeRateLimitRedis()
+
+	// Handlers can no longer enqueue, so let the worker finish what is already queued
+	webhookDispatch.Shutdown(ctx)
+	backgroundJobs.Shutdown(ctx)
+
+	broadcastsDone := make(chan struct{})
+	go func() {
+		pendingBroadcasts.Wait()
+		pendingModerationEmails.Wait()
+		close(broadcastsDone)
+	}()
+	select {
+	case <-broadcastsDone:
+	case <-ctx.Done():
+		log.Println("⚠️  Broadcasts or moderation emails still in flight at shutdown")
+	}
 
 	log.Println("✓ Server exited gracefully")
 }
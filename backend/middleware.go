@@ -5,14 +5,66 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
+// rateLimitStore counts requests per key within a limiter's window. Limiters keep their counts in
+// memory unless REDIS_URL is set, in which case they share them through Redis (see ratelimit_redis.go).
+type rateLimitStore interface {
+	allow(key string) (bool, error)
+	shutdown()
+}
+
 // Rate limiting implementation
 type rateLimiter struct {
+	store rateLimitStore
+
+	// Unix nanoseconds of the last fail-open log line, so an outage doesn't log every request
+	lastFailOpenLog atomic.Int64
+}
+
+// failOpenLogInterval throttles the "allowing request" log while the store is unreachable
+const failOpenLogInterval = time.Minute
+
+// rateLimitRedis is the client configured from REDIS_URL; nil keeps limiters in memory
+var rateLimitRedis *redis.Client
+
+// newRateLimiter allows rate requests per key within per. name identifies the limiter in the shared
+// Redis store, so two limiters with the same settings don't spend each other's budget.
+func newRateLimiter(name string, rate int, per time.Duration) *rateLimiter {
+	if rateLimitRedis != nil {
+		return &rateLimiter{store: newRedisRateLimitStore(rateLimitRedis, name, rate, per)}
+	}
+	return &rateLimiter{store: newMemoryRateLimitStore(rate, per)}
+}
+
+// Shutdown gracefully stops the rate limiter's background work
+func (rl *rateLimiter) Shutdown() {
+	rl.store.shutdown()
+}
+
+// allow reports whether a request for key is within the limit. A store error lets the request
+// through: an unreachable Redis must not take the API down with it.
+func (rl *rateLimiter) allow(key string) bool {
+	allowed, err := rl.store.allow(key)
+	if err != nil {
+		now := time.Now().UnixNano()
+		last := rl.lastFailOpenLog.Load()
+		if now-last >= int64(failOpenLogInterval) && rl.lastFailOpenLog.CompareAndSwap(last, now) {
+			log.Printf("⚠️  Rate limiter store unavailable, allowing requests: %v", err)
+		}
+		return true
+	}
+	return allowed
+}
+
+// memoryRateLimitStore keeps per-key counts in this process; they reset on restart
+type memoryRateLimitStore struct {
 	visitors map[string]*visitor
 	mu       sync.RWMutex
 	rate     int           // requests
@@ -26,9 +78,9 @@ type visitor struct {
 	count    int
 }
 
-func newRateLimiter(rate int, per time.Duration) *rateLimiter {
+func newMemoryRateLimitStore(rate int, per time.Duration) *memoryRateLimitStore {
 	ctx, cancel := context.WithCancel(context.Background())
-	rl := &rateLimiter{
+	ms := &memoryRateLimitStore{
 		visitors: make(map[string]*visitor),
 		rate:     rate,
 		per:      per,
@@ -37,26 +89,20 @@ func newRateLimiter(rate int, per time.Duration) *rateLimiter {
 	}
 
 	// Clean up old visitors every minute
-	go rl.cleanupVisitors()
+	go ms.cleanupVisitors()
 
-	return rl
+	return ms
 }
 
-func (rl *rateLimiter) cleanupVisitors() {
+func (ms *memoryRateLimitStore) cleanupVisitors() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			rl.mu.Lock()
-			for ip, v := range rl.visitors {
-				if time.Since(v.lastSeen) > rl.per {
-					delete(rl.visitors, ip)
-				}
-			}
-			rl.mu.Unlock()
-		case <-rl.ctx.Done():
+		case now := <-ticker.C:
+			ms.removeStale(now)
+		case <-ms.ctx.Done():
 			// Graceful shutdown: cleanup goroutine exits when context is cancelled
 			log.Println("🛑 Rate limiter cleanup goroutine shutting down")
 			return
@@ -64,43 +110,56 @@ func (rl *rateLimiter) cleanupVisitors() {
 	}
 }
 
-// Shutdown gracefully stops the rate limiter cleanup goroutine
-func (rl *rateLimiter) Shutdown() {
-	rl.cancel()
+// removeStale forgets visitors whose window has passed
+func (ms *memoryRateLimitStore) removeStale(now time.Time) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for ip, v := range ms.visitors {
+		if now.Sub(v.lastSeen) > ms.per {
+			delete(ms.visitors, ip)
+		}
+	}
 }
 
-func (rl *rateLimiter) allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// shutdown stops the cleanup goroutine
+func (ms *memoryRateLimitStore) shutdown() {
+	if ms.cancel != nil {
+		ms.cancel()
+	}
+}
+
+func (ms *memoryRateLimitStore) allow(ip string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
-	v, exists := rl.visitors[ip]
+	v, exists := ms.visitors[ip]
 	now := time.Now()
 
 	if !exists {
-		rl.visitors[ip] = &visitor{lastSeen: now, count: 1}
-		return true
+		ms.visitors[ip] = &visitor{lastSeen: now, count: 1}
+		return true, nil
 	}
 
 	// Reset count if time window has passed
-	if now.Sub(v.lastSeen) > rl.per {
+	if now.Sub(v.lastSeen) > ms.per {
 		v.count = 1
 		v.lastSeen = now
-		return true
+		return true, nil
 	}
 
 	// Check if rate limit exceeded
-	if v.count >= rl.rate {
-		return false
+	if v.count >= ms.rate {
+		return false, nil
 	}
 
 	v.count++
 	v.lastSeen = now
-	return true
+	return true, nil
 }
 
 // RateLimitMiddleware creates a rate limiting middleware and returns the limiter for shutdown
-func RateLimitMiddleware(rate int, per time.Duration) (*rateLimiter, gin.HandlerFunc) {
-	limiter := newRateLimiter(rate, per)
+func RateLimitMiddleware(name string, rate int, per time.Duration) (*rateLimiter, gin.HandlerFunc) {
+	limiter := newRateLimiter(name, rate, per)
 
 	handler := func(c *gin.Context) {
 		ip := c.ClientIP()
@@ -178,6 +237,13 @@ func LoggerMiddleware() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 		requestID, _ := c.Get("request_id")
 
+		// Actions performed during support impersonation are attributed to the admin
+		if impersonator, ok := c.Get("impersonated_by"); ok {
+			log.Printf("[%s] %s %s - Status: %d - Duration: %v - IP: %s - User: %d - Impersonated by admin: %v",
+				requestID, method, path, statusCode, duration, c.ClientIP(), c.GetInt("user_id"), impersonator)
+			return
+		}
+
 		log.Printf("[%s] %s %s - Status: %d - Duration: %v - IP: %s",
 			requestID, method, path, statusCode, duration, c.ClientIP())
 	}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -124,7 +127,7 @@ func TestErrorHandlerMiddleware(t *testing.T) {
 
 func TestRateLimitMiddleware(t *testing.T) {
 	router := gin.New()
-	limiter, middleware := RateLimitMiddleware(5, time.Minute) // 5 requests per minute
+	limiter, middleware := RateLimitMiddleware("test", 5, time.Minute) // 5 requests per minute
 	defer limiter.Shutdown()                                   // Clean up goroutine
 	router.Use(middleware)
 	router.GET("/test", func(c *gin.Context) {
@@ -163,7 +166,7 @@ func TestIsDevMode(t *testing.T) {
 func TestMiddlewareChaining(t *testing.T) {
 	// Test that all middleware can work together
 	router := gin.New()
-	limiter, rateLimitMiddleware := RateLimitMiddleware(100, time.Minute)
+	limiter, rateLimitMiddleware := RateLimitMiddleware("test", 100, time.Minute)
 	defer limiter.Shutdown() // Clean up goroutine
 	router.Use(
 		ErrorHandlerMiddleware(),
@@ -191,15 +194,16 @@ func TestMiddlewareChaining(t *testing.T) {
 
 func TestRateLimiterStructure(t *testing.T) {
 	// Test rate limiter structure directly without starting cleanup goroutine
-	limiter := &rateLimiter{
+	store := &memoryRateLimitStore{
 		visitors: make(map[string]*visitor),
 		rate:     10,
 		per:      time.Minute,
 	}
+	limiter := &rateLimiter{store: store}
 	assert.NotNil(t, limiter)
-	assert.NotNil(t, limiter.visitors)
-	assert.Equal(t, 10, limiter.rate)
-	assert.Equal(t, time.Minute, limiter.per)
+	assert.NotNil(t, store.visitors)
+	assert.Equal(t, 10, store.rate)
+	assert.Equal(t, time.Minute, store.per)
 
 	// Test allow function (basic check)
 	ip := "192.168.1.1"
@@ -210,11 +214,11 @@ func TestRateLimiterStructure(t *testing.T) {
 
 func TestRateLimiterAllow(t *testing.T) {
 	// Test the allow function directly without triggering cleanup goroutine
-	limiter := &rateLimiter{
+	limiter := &rateLimiter{store: &memoryRateLimitStore{
 		visitors: make(map[string]*visitor),
 		rate:     5,
 		per:      time.Minute,
-	}
+	}}
 
 	// Test 1: First request should be allowed
 	ip := "192.168.1.100"
@@ -231,3 +235,46 @@ func TestRateLimiterAllow(t *testing.T) {
 	// Test 4: Different IP should have separate limit
 	assert.True(t, limiter.allow("192.168.1.101"))
 }
+
+func TestMemoryRateLimitStoreWindowExpiry(t *testing.T) {
+	store := &memoryRateLimitStore{
+		visitors: make(map[string]*visitor),
+		rate:     2,
+		per:      50 * time.Millisecond,
+	}
+	limiter := &rateLimiter{store: store}
+
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.False(t, limiter.allow("10.0.0.1"))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, limiter.allow("10.0.0.1"), "a new window starts once the old one has passed")
+
+	store.removeStale(time.Now().Add(time.Second))
+	assert.Empty(t, store.visitors, "cleanup forgets visitors whose window has passed")
+}
+
+// Run with -race: many goroutines share one limiter's map
+func TestMemoryRateLimitStoreConcurrentAllow(t *testing.T) {
+	limiter := newRateLimiter("test", 50, time.Minute)
+	defer limiter.Shutdown()
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if limiter.allow("shared") {
+					allowed.Add(1)
+				}
+				limiter.allow(fmt.Sprintf("10.0.%d.%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), allowed.Load(), "exactly the limit gets through however requests interleave")
+}
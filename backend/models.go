@@ -3,22 +3,50 @@ package main
 import "time"
 
 type User struct {
-	ID             int       `json:"id"`
-	Email          string    `json:"email" binding:"required,email"`
-	Password       string    `json:"-" binding:"required,min=8"` // Never expose password in JSON responses
-	Name           string    `json:"name" binding:"required"`
-	Bio            string    `json:"bio"`
-	Languages      string    `json:"languages"` // Comma-separated language codes (e.g., "en,de,fr")
-	IsAdmin        bool      `json:"is_admin"`
-	IsBlocked      bool      `json:"is_blocked"`
-	EmailVerified  bool      `json:"email_verified"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID                int        `json:"id"`
+	Email             string     `json:"email" binding:"required,email"`
+	Password          string     `json:"-" binding:"required,min=8"` // Never expose password in JSON responses
+	Name              string     `json:"name" binding:"required"`
+	Bio               string     `json:"bio"`
+	Languages         string     `json:"languages"` // Comma-separated language codes (e.g., "en,de,fr")
+	IsAdmin           bool       `json:"is_admin"`
+	IsBlocked         bool       `json:"is_blocked"`
+	EmailVerified     bool       `json:"email_verified"`
+	VerifyEmailFailed bool       `json:"verification_email_failed,omitempty"` // The verification email couldn't be delivered; only shown to the user themselves
+	TwoFactorEnabled  bool       `json:"two_factor_enabled"`
+	ProfileVisibility string     `json:"profile_visibility,omitempty"` // public | registered | hidden
+	ShowEmail         bool       `json:"show_email"`                   // Show email to registered viewers of the public profile
+	BirthYear         *int       `json:"birth_year,omitempty"`         // Optional, only shown to the user themselves
+	Gender            string     `json:"gender,omitempty"`             // male | female | other | unspecified, only shown to the user themselves
+	Timezone          string     `json:"timezone,omitempty"`           // IANA zone new events default to, only shown to the user themselves
+	DigestEmails      *bool      `json:"digest_emails,omitempty"`      // Monthly organizer digest opt-in, only shown to the user themselves
+	EventsPaused      *bool      `json:"events_paused,omitempty"`      // Vacation mode: joins are paused on their events, only shown to the user themselves
+	Threema           string     `json:"threema,omitempty"`            // Threema ID, only shown to the user themselves (organizers and participants see it on events)
+	RegistrationIP    string     `json:"registration_ip,omitempty"`    // Only in the admin user list; never shown to anyone else
+	BlockedUntil      *time.Time `json:"blocked_until,omitempty"`      // End of a suspension (is_blocked without it is permanent); admin user list only
+	BlockReason       string     `json:"block_reason,omitempty"`       // Admin user list only; the user sees it when refused
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"` // Last profile change; only set on the user's own profile responses
 }
 
+// Profile visibility levels for GET /api/profile/:id
+const (
+	ProfileVisibilityPublic     = "public"     // Anyone, including anonymous visitors
+	ProfileVisibilityRegistered = "registered" // Logged-in users only
+	ProfileVisibilityHidden     = "hidden"     // Only the user themselves and admins
+)
+
 type ProfileUpdateRequest struct {
-	Name      string `json:"name"`
-	Bio       string `json:"bio"`
-	Languages string `json:"languages"`
+	Name              string  `json:"name"`
+	Bio               string  `json:"bio"`
+	Languages         string  `json:"languages"`
+	ProfileVisibility string  `json:"profile_visibility"` // Optional, unchanged when empty
+	ShowEmail         *bool   `json:"show_email"`         // Optional, unchanged when omitted
+	BirthYear         *int    `json:"birth_year"`         // Optional, unchanged when omitted; 0 clears it
+	Gender            string  `json:"gender"`             // Optional, unchanged when empty
+	Timezone          string  `json:"timezone"`           // Optional IANA zone, unchanged when empty
+	DigestEmails      *bool   `json:"digest_emails"`      // Optional, unchanged when omitted
+	Threema           *string `json:"threema"`            // Optional Threema ID, unchanged when omitted; "" clears it
 }
 
 type LoginRequest struct {
@@ -64,25 +92,40 @@ type ResetPasswordRequest struct {
 }
 
 type Event struct {
-	ID                int       `json:"id"`
-	UserID            int       `json:"user_id"`
-	Title             string    `json:"title" binding:"required"`
-	Description       string    `json:"description" binding:"required"`
-	Category          string    `json:"category" binding:"required"`
-	Latitude          float64   `json:"latitude" binding:"required"`
-	Longitude         float64   `json:"longitude" binding:"required"`
-	StartTime         string    `json:"start_time" binding:"required"`
-	EndTime           string    `json:"end_time"`
-	CreatorName       string    `json:"creator_name" binding:"required"`
-	MaxParticipants   int       `json:"max_participants"`
-	GenderRestriction string    `json:"gender_restriction"`
-	AgeMin            int       `json:"age_min"`
-	AgeMax            int       `json:"age_max"`
-	SmokingAllowed    bool      `json:"smoking_allowed"`
-	AlcoholAllowed    bool      `json:"alcohol_allowed"`
-	EventLanguages    string    `json:"event_languages"` // Comma-separated language codes for the event
-	Slug              string    `json:"slug"`
-	CreatedAt         time.Time `json:"created_at"`
+	ID                int               `json:"id"`
+	UserID            int               `json:"user_id"`
+	Title             string            `json:"title" binding:"required"`
+	Description       string            `json:"description" binding:"required"`
+	DescriptionFormat string            `json:"description_format"`         // plain (default) | markdown
+	DescriptionHTML   string            `json:"description_html,omitempty"` // Rendered from description, safe to insert as HTML
+	Category          string            `json:"category" binding:"required"`
+	Latitude          float64           `json:"latitude" binding:"required"`
+	Longitude         float64           `json:"longitude" binding:"required"`
+	LocationName      string            `json:"location_name"` // Human-readable place, e.g. "Café Odeon"
+	Address           string            `json:"address"`       // Optional street address
+	StartTime         string            `json:"start_time" binding:"required"`
+	EndTime           string            `json:"end_time"`
+	JoinDeadline      string            `json:"join_deadline"` // Signups close here instead of at the start; empty for none
+	CreatorName       string            `json:"creator_name" binding:"required"`
+	MaxParticipants   int               `json:"max_participants"`
+	ReservedSpots     int               `json:"reserved_spots"` // Spots the organizer holds for guests off the platform; count against max_participants
+	JoinsPaused       bool              `json:"joins_paused"`   // Nobody can join for now (organizer vacation mode); participants stay
+	PriceAmount       *int              `json:"price_amount"`   // Cost in cents; null when not stated, 0 for explicitly free
+	PriceCurrency     string            `json:"price_currency"` // ISO 4217 code, CHF by default
+	PaymentNote       string            `json:"payment_note"`   // e.g. "cash at the door"; informational, nothing is charged
+	GenderRestriction string            `json:"gender_restriction"`
+	AgeMin            int               `json:"age_min"`
+	AgeMax            int               `json:"age_max"`
+	NetworkJoinLimit  int               `json:"max_joins_per_network"` // Most participants registered from one network or company domain (0 = off)
+	SmokingAllowed    bool              `json:"smoking_allowed"`
+	AlcoholAllowed    bool              `json:"alcohol_allowed"`
+	EventLanguages    string            `json:"event_languages"` // Comma-separated language codes for the event
+	Slug              string            `json:"slug"`
+	Timezone          string            `json:"timezone"`             // IANA zone of the organizer's wall clock; start/end times are UTC instants
+	GroupID           *int              `json:"group_id"`             // Group the event is published in, null for standalone events
+	Attributes        map[string]string `json:"attributes,omitempty"` // Category-specific fields, see CategoryAttributes
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"` // Last edit, join/leave or cancellation; created_at until then
 
 	// Privacy controls
 	HideOrganizerUntilJoined    bool `json:"hide_organizer_until_joined"`
@@ -90,13 +133,56 @@ type Event struct {
 	RequireVerifiedToJoin       bool `json:"require_verified_to_join"`
 	RequireVerifiedToView       bool `json:"require_verified_to_view"`
 	AllowUnregisteredUsers      bool `json:"allow_unregistered_users"`
+	RequireBirthYear            bool `json:"require_birth_year"` // Age-restricted events turn away users without a birth year
+
+	// Moderation: set once enough users report the event; only the creator and admins still see it
+	HiddenPendingReview bool `json:"hidden_pending_review,omitempty"`
+
+	// Pinned to the top of listings by an admin (PUT /api/admin/events/:id/feature), never from
+	// the request; FeaturedUntil is nil when the event stays featured until unfeatured
+	IsFeatured    bool       `json:"is_featured"`
+	FeaturedUntil *time.Time `json:"featured_until,omitempty"`
+
+	// Events created before the organizer verified their email are drafts (stored as published = 0):
+	// only the creator and admins see them, and they are published once the email is verified
+	Draft  bool   `json:"draft,omitempty"`
+	Notice string `json:"notice,omitempty"` // Set on create responses, e.g. why the event is a draft
+
+	// Set by an admin on create or update to schedule beyond MaxEventLeadMonths; not stored
+	LongRange bool `json:"long_range,omitempty"`
+
+	// Organizer summary from the creator's account; null with organizer_hidden when the
+	// organizer is hidden until joining
+	Organizer       *EventOrganizer `json:"organizer"`
+	OrganizerHidden bool            `json:"organizer_hidden,omitempty"`
 
 	// Joined data
-	UserEmail        string `json:"user_email,omitempty"`
-	CreatorLanguages string `json:"creator_languages,omitempty"`
-	ParticipantCount int    `json:"participant_count"`
-	Participants     []User `json:"participants,omitempty"`
-	IsParticipant    bool   `json:"is_participant,omitempty"` // Whether current user is a participant
+	UserEmail        string          `json:"user_email,omitempty"`
+	CreatorLanguages string          `json:"creator_languages,omitempty"` // Deprecated: use organizer.languages
+	ParticipantCount int             `json:"participant_count"`
+	Participants     []User          `json:"participants,omitempty"`
+	IsParticipant    bool            `json:"is_participant,omitempty"` // Whether current user is a participant
+	InterestedCount  int             `json:"interested_count"`         // Users marked interested; not participants and not counted against capacity
+	IsInterested     bool            `json:"is_interested,omitempty"`  // Whether current user is marked interested
+	LanguageMatch    *float64        `json:"language_match,omitempty"` // Share of the viewer's languages the event is held in (listings, signed in)
+	SpotsLeft        *int            `json:"spots_left"`               // Remaining capacity, null when unlimited
+	IsFull           bool            `json:"is_full"`
+	JoinClosed       bool            `json:"join_closed"` // Past the join deadline, started (past the grace period), cancelled or full
+	Cancelled        bool            `json:"cancelled,omitempty"`
+	UnreadCount      *int            `json:"unread_count,omitempty"` // Comments the viewer hasn't fetched yet (participants only)
+	Questions        []EventQuestion `json:"questions,omitempty"`    // Asked when joining; set by getPublicEvent
+
+	// Feedback aggregate (only populated for past events)
+	Rating *RatingSummary `json:"rating,omitempty"`
+}
+
+// EventOrganizer is the public summary of an event's creator
+type EventOrganizer struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Languages   string    `json:"languages"`
+	MemberSince time.Time `json:"member_since"`
+	Threema     string    `json:"threema,omitempty"` // Only for participants of the event
 }
 
 type EventParticipant struct {
@@ -106,12 +192,34 @@ type EventParticipant struct {
 	JoinedAt time.Time `json:"joined_at"`
 }
 
+// ParticipantView is a row of GET /api/events/:id/participants. Attendance and check-in are only
+// filled in for the organizer and admins; account flags only for admins.
+type ParticipantView struct {
+	ID         int       `json:"id"` // 0 when an alias hides the participant from the viewer (see aliasHidesAccount)
+	Name       string    `json:"name"`
+	Bio        string    `json:"bio,omitempty"`
+	Languages  string    `json:"languages,omitempty"`
+	Email      string    `json:"email,omitempty"`   // Admins, opted in with show_email, or shared with the organizer on join
+	Threema    string    `json:"threema,omitempty"` // Organizer and admins only, when shared on join
+	JoinedAt   time.Time `json:"joined_at"`
+	Attendance string    `json:"attendance,omitempty"` // attended | no_show
+	CheckedIn  *bool     `json:"checked_in,omitempty"`
+	// Whether the participant shares their contact with the organizer; shown to the organizer,
+	// admins and the participant themselves
+	SharesContact *bool `json:"shares_contact,omitempty"`
+
+	IsAdmin       *bool `json:"is_admin,omitempty"`
+	IsBlocked     *bool `json:"is_blocked,omitempty"`
+	EmailVerified *bool `json:"email_verified,omitempty"`
+}
+
 type Place struct {
 	DisplayName string  `json:"display_name"`
 	Lat         string  `json:"lat"`
 	Lon         string  `json:"lon"`
 	Type        string  `json:"type"`
 	Importance  float64 `json:"importance"`
+	Source      string  `json:"source"` // Geocoding provider: photon or nominatim
 }
 
 var Categories = []string{
@@ -154,13 +262,17 @@ type BlockUserRequest struct {
 type EventComment struct {
 	ID        int       `json:"id"`
 	EventID   int       `json:"event_id"`
-	UserID    int       `json:"user_id"`
+	UserID    int       `json:"user_id"` // 0 when the author's alias hides them from the viewer
 	Comment   string    `json:"comment" binding:"required"`
 	UserName  string    `json:"user_name"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 	IsDeleted bool      `json:"is_deleted"`
 	IsOwn     bool      `json:"is_own"`
+	// Held by the moderation filter; only the author sees it until an admin approves it
+	PendingReview bool `json:"pending_review,omitempty"`
+	// Bumped by every change; pass the highest one seen as since_id to GET .../comments/updates
+	Revision int `json:"revision"`
 }
 
 // CreateCommentRequest represents the request to create a comment
@@ -200,3 +312,99 @@ type CommentReport struct {
 	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 }
+
+// EventFeedback represents a participant's rating of an event's organizer
+type EventFeedback struct {
+	ID        int       `json:"id"`
+	EventID   int       `json:"event_id"`
+	UserID    int       `json:"user_id"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// SubmitFeedbackRequest represents the request to rate an event after it ended
+type SubmitFeedbackRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// RatingSummary aggregates feedback ratings for an event or organizer
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// Attendance statuses organizers can record for participants after an event started
+const (
+	AttendanceAttended = "attended"
+	AttendanceNoShow   = "no_show"
+)
+
+// AttendanceMark is a single participant's attendance status
+type AttendanceMark struct {
+	UserID int    `json:"user_id" binding:"required"`
+	Status string `json:"status" binding:"required,oneof=attended no_show"`
+}
+
+// MarkAttendanceRequest represents the request to record attendance for (some of) an event's participants
+type MarkAttendanceRequest struct {
+	Attendance []AttendanceMark `json:"attendance" binding:"required,min=1,dive"`
+}
+
+// AttendanceEntry is a participant row in the organizer's attendance list
+type AttendanceEntry struct {
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	Status   string `json:"status,omitempty"`
+	Disputed bool   `json:"disputed"`
+}
+
+// JoinEventRequest is the optional body of POST /api/events/:id/join
+type JoinEventRequest struct {
+	ShareContact bool             `json:"share_contact"` // Let the organizer see this participant's email and threema
+	Answers      []QuestionAnswer `json:"answers"`       // To the event's questions; required ones must be answered
+	DisplayAlias string           `json:"display_alias"` // Shown to other participants instead of the profile name
+}
+
+// ParticipationUpdateRequest changes a participant's own settings for an event they joined; fields
+// left out stay as they are
+type ParticipationUpdateRequest struct {
+	ShareContact *bool   `json:"share_contact"`
+	DisplayAlias *string `json:"display_alias"` // "" goes back to the profile name
+}
+
+// DuplicateEventRequest represents the request to copy an existing event to a new date
+type DuplicateEventRequest struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time"`
+}
+
+// BulkUserActionRequest represents an admin bulk operation on users (max 100 IDs per batch)
+type BulkUserActionRequest struct {
+	IDs    []int  `json:"ids" binding:"required,min=1,max=100"`
+	Action string `json:"action" binding:"required,oneof=block unblock verify_email"`
+}
+
+// BulkEventActionRequest represents an admin bulk operation on events
+type BulkEventActionRequest struct {
+	IDs    []int  `json:"ids" binding:"required,min=1,max=100"`
+	Action string `json:"action" binding:"required,oneof=delete cancel"`
+}
+
+// Per-ID outcomes reported by bulk admin operations
+const (
+	BulkResultSuccess    = "success"
+	BulkResultNotFound   = "not_found"
+	BulkResultError      = "error"
+	BulkResultRolledBack = "rolled_back"
+	// Delete skipped while a transfer of the event waits to be accepted
+	BulkResultTransferPending = "transfer_pending"
+)
+
+// BulkResult is the outcome of a bulk operation for a single ID
+type BulkResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
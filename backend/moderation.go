@@ -0,0 +1,589 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// What a moderation rule does on a match. Terms carry their own action; the link cap and the
+// shouting heuristics take theirs from config.
+const (
+	ModerationReject = "reject" // Refuse the content with ErrCodeContentRejected
+	ModerationFlag   = "flag"   // Accept it hidden, pending admin review
+	ModerationOff    = "off"
+)
+
+// Moderation rules, as reported in violations
+const (
+	ModerationRuleTerm       = "banned_term"
+	ModerationRuleLinks      = "too_many_links"
+	ModerationRuleShouting   = "shouting"
+	ModerationRuleNewAccount = "new_account" // ReviewFirstEvent
+)
+
+// ErrCodeContentRejected marks content refused by the moderation filter
+const ErrCodeContentRejected = "CONTENT_REJECTED"
+
+// Moderation queue statuses
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRemoved  = "removed"
+)
+
+// Why an event is hidden pending review, stored as bits in events.hidden_sources.
+// hidden_pending_review stays set while any source still holds the event, so resolving one
+// source (approving a flagged edit, dismissing reports) doesn't unhide what another put away.
+const (
+	HideSourceModeration = 1 << iota // A flag rule matched the event's text
+	HideSourceReports                // Reports reached ReportTakedownThreshold
+	HideSourceIntegrity              // The organizer's account is gone (see repairOrphans)
+)
+
+const (
+	shoutingRepeatRun   = 6  // "!!!!!!" or "soooooo"
+	shoutingMinLetters  = 20 // Short all-caps text ("NYC BBQ") is fine
+	shoutingCapsPercent = 70
+)
+
+// linkPattern counts URLs, www. hosts and bare domains with a path (t.me/group)
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}/\S*`)
+
+// ModerationViolation is one rule a piece of content broke
+type ModerationViolation struct {
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	Detail string `json:"detail"`
+}
+
+// ModerationResult is the combined verdict over all checked fields
+type ModerationResult struct {
+	Violations []ModerationViolation
+}
+
+// Rejected reports whether any violation hard-rejects the content
+func (r ModerationResult) Rejected() bool {
+	return r.has(ModerationReject)
+}
+
+// Flagged reports whether the content is accepted but needs review
+func (r ModerationResult) Flagged() bool {
+	return !r.Rejected() && r.has(ModerationFlag)
+}
+
+func (r ModerationResult) has(action string) bool {
+	for _, v := range r.Violations {
+		if v.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// respondRejected sends the 400 for rejected content, listing only the rejecting violations
+func (r ModerationResult) respondRejected(c *gin.Context) {
+	var rejected []ModerationViolation
+	for _, v := range r.Violations {
+		if v.Action == ModerationReject {
+			rejected = append(rejected, v)
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "This content isn't allowed: " + rejected[0].Detail,
+		"code":       ErrCodeContentRejected,
+		"violations": rejected,
+	})
+}
+
+// ModerationTerm is one entry of the admin-managed banned-terms list
+type ModerationTerm struct {
+	Term   string `json:"term"`
+	Action string `json:"action"`
+}
+
+// moderationTermCache keeps the terms in memory, already normalized, until the list changes
+type moderationTermCache struct {
+	mu     sync.RWMutex
+	loaded bool
+	terms  []ModerationTerm
+}
+
+var moderationTerms moderationTermCache
+
+func (m *moderationTermCache) Get(ctx context.Context) ([]ModerationTerm, error) {
+	m.mu.RLock()
+	if m.loaded {
+		defer m.mu.RUnlock()
+		return m.terms, nil
+	}
+	m.mu.RUnlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.loaded {
+		return m.terms, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT term, action FROM moderation_terms ORDER BY term`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	terms := []ModerationTerm{}
+	for rows.Next() {
+		var t ModerationTerm
+		if err := rows.Scan(&t.Term, &t.Action); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	m.terms, m.loaded = terms, true
+	return terms, nil
+}
+
+func (m *moderationTermCache) Invalidate() {
+	m.mu.Lock()
+	m.loaded, m.terms = false, nil
+	m.mu.Unlock()
+}
+
+// foldReplacer handles letters that don't decompose into a base letter plus an accent
+var foldReplacer = strings.NewReplacer("ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ı", "i")
+
+// normalizeForMatching lowercases, strips diacritics and turns everything but letters and digits
+// into single spaces, padded on both ends so terms match whole words with strings.Contains
+func normalizeForMatching(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	folded = foldReplacer.Replace(folded)
+
+	var b strings.Builder
+	b.Grow(len(folded) + 2)
+	b.WriteByte(' ')
+	space := true
+	for _, r := range folded {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// moderateContent checks user-written text fields against the banned terms, the link cap and the
+// shouting heuristics. Each field is checked on its own; the link cap applies per field.
+func moderateContent(ctx context.Context, fields ...string) (ModerationResult, error) {
+	var result ModerationResult
+	terms, err := moderationTerms.Get(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	seen := map[string]bool{}
+	add := func(v ModerationViolation) {
+		if v.Action == ModerationOff || seen[v.Rule+v.Detail] {
+			return
+		}
+		seen[v.Rule+v.Detail] = true
+		result.Violations = append(result.Violations, v)
+	}
+
+	for _, text := range fields {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		normalized := normalizeForMatching(text)
+		for _, t := range terms {
+			if strings.Contains(normalized, " "+t.Term+" ") {
+				add(ModerationViolation{Rule: ModerationRuleTerm, Action: t.Action, Detail: fmt.Sprintf("contains %q", t.Term)})
+			}
+		}
+
+		if links := len(linkPattern.FindAllStringIndex(text, appConfig.ModerationMaxLinks+1)); links > appConfig.ModerationMaxLinks {
+			add(ModerationViolation{Rule: ModerationRuleLinks, Action: appConfig.ModerationLinkAction,
+				Detail: fmt.Sprintf("more than %d links", appConfig.ModerationMaxLinks)})
+		}
+
+		if reason := shoutingReason(text); reason != "" {
+			add(ModerationViolation{Rule: ModerationRuleShouting, Action: appConfig.ModerationShoutingAction, Detail: reason})
+		}
+	}
+	return result, nil
+}
+
+// moderateEventText runs the filter over an event's user-written fields (stored escaped, so they are
+// unescaped first). On rejection or failure it writes the response and returns ok=false. Admins are exempt.
+func moderateEventText(c *gin.Context, event *Event, isAdmin bool) (result ModerationResult, ok bool) {
+	if isAdmin {
+		return result, true
+	}
+	result, err := moderateContent(c.Request.Context(),
+		html.UnescapeString(event.Title), html.UnescapeString(event.Description),
+		html.UnescapeString(event.LocationName), html.UnescapeString(event.Address))
+	if err != nil {
+		log.Printf("❌ Moderation check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check event content"})
+		return result, false
+	}
+	if result.Rejected() {
+		log.Printf("🛡️  Rejected event text from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		result.respondRejected(c)
+		return result, false
+	}
+	return result, true
+}
+
+// moderateCommentText is moderateEventText for a comment body
+func moderateCommentText(c *gin.Context, comment string) (result ModerationResult, ok bool) {
+	if c.GetBool("is_admin") {
+		return result, true
+	}
+	result, err := moderateContent(c.Request.Context(), comment)
+	if err != nil {
+		log.Printf("❌ Moderation check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check comment content"})
+		return result, false
+	}
+	if result.Rejected() {
+		log.Printf("🛡️  Rejected comment from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		result.respondRejected(c)
+		return result, false
+	}
+	return result, true
+}
+
+// shoutingReason describes why text looks like shouting or filler, or returns ""
+func shoutingReason(text string) string {
+	var letters, upper, run int
+	var prev rune
+	for _, r := range text {
+		if r == prev && !unicode.IsSpace(r) {
+			run++
+			if run >= shoutingRepeatRun {
+				return fmt.Sprintf("repeats %q %d or more times", r, shoutingRepeatRun)
+			}
+		} else {
+			prev, run = r, 1
+		}
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= shoutingMinLetters && upper*100 >= letters*shoutingCapsPercent {
+		return "mostly capital letters"
+	}
+	return ""
+}
+
+// flagForReview queues content accepted under a flag rule for the admin moderation queue
+func flagForReview(exec sqlExecer, contentType string, contentID, userID int, result ModerationResult) {
+	var flagged []ModerationViolation
+	for _, v := range result.Violations {
+		if v.Action == ModerationFlag {
+			flagged = append(flagged, v)
+		}
+	}
+	violations, _ := json.Marshal(flagged)
+	if _, err := exec.Exec(`
+		INSERT INTO moderation_queue (content_type, content_id, user_id, violations) VALUES (?, ?, ?, ?)
+	`, contentType, contentID, userID, string(violations)); err != nil {
+		log.Printf("⚠️  Failed to queue %s %d for review: %v", contentType, contentID, err)
+		return
+	}
+	log.Printf("🛡️  %s %d by user %d held for review", contentType, contentID, userID)
+}
+
+// holdEvent hides an event on behalf of source. It reports whether the hold is new, so only the
+// first caller for a source announces the takedown.
+func holdEvent(exec sqlExecer, eventID interface{}, source int) (bool, error) {
+	result, err := exec.Exec(`
+		UPDATE events SET hidden_sources = hidden_sources | ?, hidden_pending_review = 1, updated_at = ?
+		WHERE id = ? AND hidden_sources & ? = 0
+	`, source, time.Now().UTC(), eventID, source)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// releaseEvent drops source's hold on an event; the event is shown again once no source holds it
+func releaseEvent(exec sqlExecer, eventID interface{}, source int) error {
+	_, err := exec.Exec(`
+		UPDATE events SET hidden_sources = hidden_sources & ~?, hidden_pending_review = (hidden_sources & ~? != 0), updated_at = ?
+		WHERE id = ? AND hidden_sources & ? != 0
+	`, source, source, time.Now().UTC(), eventID, source)
+	return err
+}
+
+// adminGetModerationTerms returns the banned-terms list (GET /api/admin/moderation/terms)
+func adminGetModerationTerms(c *gin.Context) {
+	terms, err := moderationTerms.Get(c.Request.Context())
+	if err != nil {
+		log.Printf("❌ Failed to load moderation terms: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load moderation terms"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"terms": terms})
+}
+
+// ModerationTermsRequest is the body of PUT /api/admin/moderation/terms
+type ModerationTermsRequest struct {
+	Terms []ModerationTerm `json:"terms"`
+}
+
+// adminPutModerationTerms replaces the banned-terms list (PUT /api/admin/moderation/terms).
+// Terms are stored normalized, so "Crypto-Signals" also catches "crypto signals" and "crÿpto signals".
+func adminPutModerationTerms(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req ModerationTermsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	terms := make([]ModerationTerm, 0, len(req.Terms))
+	seen := map[string]bool{}
+	for _, t := range req.Terms {
+		term := strings.TrimSpace(normalizeForMatching(t.Term))
+		if term == "" || utf8.RuneCountInString(term) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid term %q (1-100 letters or digits)", t.Term)})
+			return
+		}
+		if t.Action == "" {
+			t.Action = ModerationReject
+		}
+		if t.Action != ModerationReject && t.Action != ModerationFlag {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Action for %q must be reject or flag", t.Term)})
+			return
+		}
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, ModerationTerm{Term: term, Action: t.Action})
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save moderation terms"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM moderation_terms`); err != nil {
+		log.Printf("❌ Error clearing moderation terms: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save moderation terms"})
+		return
+	}
+	for _, t := range terms {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO moderation_terms (term, action) VALUES (?, ?)`, t.Term, t.Action); err != nil {
+			log.Printf("❌ Error saving moderation term: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save moderation terms"})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing moderation terms: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save moderation terms"})
+		return
+	}
+	moderationTerms.Invalidate()
+
+	log.Printf("🛡️  Admin %d set %d moderation terms", c.GetInt("user_id"), len(terms))
+	c.JSON(http.StatusOK, gin.H{"terms": terms})
+}
+
+// ModerationQueueItem is content held for review, with enough context to judge it
+type ModerationQueueItem struct {
+	ID          int                   `json:"id"`
+	ContentType string                `json:"content_type"` // event | comment
+	ContentID   int                   `json:"content_id"`
+	EventID     int                   `json:"event_id"`
+	EventTitle  string                `json:"event_title"`
+	Text        string                `json:"text"`
+	UserID      int                   `json:"user_id"`
+	UserName    string                `json:"user_name"`
+	Violations  []ModerationViolation `json:"violations"`
+	Status      string                `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// adminListModerationQueue lists flagged events and comments (GET /api/admin/moderation/queue?status=)
+func adminListModerationQueue(c *gin.Context) {
+	ctx := c.Request.Context()
+	status := c.DefaultQuery("status", ModerationPending)
+	if status != ModerationPending && status != ModerationApproved && status != ModerationRemoved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of: pending, approved, removed"})
+		return
+	}
+	page, perPage := parsePagination(c)
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM moderation_queue WHERE status = ?`, status).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count moderation queue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve moderation queue"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT q.id, q.content_type, q.content_id, q.user_id, COALESCE(u.name, ''), q.violations, q.status, q.created_at,
+		       COALESCE(e.id, 0), COALESCE(e.title, ''),
+		       CASE q.content_type WHEN 'comment' THEN COALESCE(cm.comment, '') ELSE COALESCE(e.description, '') END
+		FROM moderation_queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		LEFT JOIN event_comments cm ON q.content_type = 'comment' AND cm.id = q.content_id
+		LEFT JOIN events e ON e.id = CASE q.content_type WHEN 'comment' THEN cm.event_id ELSE q.content_id END
+		WHERE q.status = ?
+		ORDER BY q.created_at DESC, q.id DESC
+		LIMIT ? OFFSET ?
+	`, status, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Printf("❌ Failed to query moderation queue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve moderation queue"})
+		return
+	}
+	defer rows.Close()
+
+	items := []ModerationQueueItem{}
+	for rows.Next() {
+		var item ModerationQueueItem
+		var violations string
+		if err := rows.Scan(&item.ID, &item.ContentType, &item.ContentID, &item.UserID, &item.UserName, &violations,
+			&item.Status, &item.CreatedAt, &item.EventID, &item.EventTitle, &item.Text); err != nil {
+			log.Printf("❌ Error scanning moderation item: %v", err)
+			continue
+		}
+		json.Unmarshal([]byte(violations), &item.Violations)
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":    items,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// ResolveModerationRequest is the body of POST /api/admin/moderation/queue/:id/resolve
+type ResolveModerationRequest struct {
+	Resolution string `json:"resolution" binding:"required"` // approved | removed
+}
+
+// adminResolveModerationItem publishes held content or takes it down for good
+// (POST /api/admin/moderation/queue/:id/resolve). Removed events are cancelled, removed comments deleted.
+func adminResolveModerationItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid queue item ID"})
+		return
+	}
+	var req ResolveModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Resolution != ModerationApproved && req.Resolution != ModerationRemoved) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution must be approved or removed"})
+		return
+	}
+	adminID := c.GetInt("user_id")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve queue item"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	var contentType string
+	var contentID int
+	err = tx.QueryRowContext(ctx, `SELECT content_type, content_id FROM moderation_queue WHERE id = ? AND status = ?`,
+		itemID, ModerationPending).Scan(&contentType, &contentID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending queue item with this ID"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading queue item: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve queue item"})
+		return
+	}
+
+	approved := req.Resolution == ModerationApproved
+	cancelled := false
+	switch contentType {
+	case "event":
+		// Removed events keep the moderation hold; cancelled events drop out of listings anyway
+		if approved {
+			err = releaseEvent(tx, contentID, HideSourceModeration)
+		} else {
+			cancelled, err = cancelEventRecord(tx, contentID, 0)
+		}
+	case "comment":
+		if approved {
+			_, err = tx.ExecContext(ctx, `UPDATE event_comments SET pending_review = 0, revision = `+nextCommentRevision+` WHERE id = ?`, contentID)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE event_comments SET is_deleted = 1, revision = `+nextCommentRevision+` WHERE id = ?`, contentID)
+		}
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `UPDATE moderation_queue SET status = ?, reviewed_by = ?, reviewed_at = ? WHERE id = ?`,
+			req.Resolution, adminID, time.Now().UTC(), itemID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error resolving queue item %d: %v", itemID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve queue item"})
+		return
+	}
+
+	if contentType == "event" {
+		eventListCache.Invalidate()
+		publicSitemap.Invalidate()
+	}
+	if contentType == "comment" {
+		var eventID int
+		if db.QueryRowContext(ctx, `SELECT event_id FROM event_comments WHERE id = ?`, contentID).Scan(&eventID) == nil {
+			commentUpdates.Notify(eventID)
+		}
+	}
+	if cancelled {
+		webhookDispatch.Dispatch(WebhookEventCancelled, contentID, adminID)
+		broadcastEvent(WebhookEventCancelled, contentID)
+	}
+	log.Printf("🛡️  Admin %d %s %s %d", adminID, req.Resolution, contentType, contentID)
+	c.JSON(http.StatusOK, gin.H{"id": itemID, "resolution": req.Resolution})
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeForMatching(t *testing.T) {
+	assert.Equal(t, " zazolc gesla jazn ", normalizeForMatching("Zażółć  gęślą jaźń!"))
+	assert.Equal(t, " strasse cafe ", normalizeForMatching("STRASSE / Café"))
+	assert.Equal(t, " ", normalizeForMatching("!!!"))
+}
+
+func TestShoutingReason(t *testing.T) {
+	assert.Empty(t, shoutingReason("Board games at the NYC BBQ place"))
+	assert.Empty(t, shoutingReason("Looong walk"))
+	assert.NotEmpty(t, shoutingReason("Best party ever!!!!!!"))
+	assert.NotEmpty(t, shoutingReason("FREE DRINKS FOR EVERYONE TONIGHT"))
+}
+
+func TestContentModeration(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) {
+		cfg.ModerationMaxLinks = 2
+		cfg.ModerationLinkAction = ModerationReject
+		cfg.ModerationShoutingAction = ModerationFlag
+	})
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.GET("/events/:id/comments", getEventComments)
+	protected.POST("/events/:id/comments", createEventComment)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/moderation/terms", adminGetModerationTerms)
+	admin.PUT("/moderation/terms", adminPutModerationTerms)
+	admin.GET("/moderation/queue", adminListModerationQueue)
+	admin.POST("/moderation/queue/:id/resolve", adminResolveModerationItem)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+	otherToken, _ := generateToken(User{ID: int(otherID), Email: "other@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+
+	eventPayload := func(title, description string) gin.H {
+		return gin.H{
+			"title": title, "description": description,
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+	}
+	rejection := func(w *httptest.ResponseRecorder) (string, []ModerationViolation) {
+		var resp struct {
+			Code       string                `json:"code"`
+			Violations []ModerationViolation `json:"violations"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return resp.Code, resp.Violations
+	}
+	queue := func() []ModerationQueueItem {
+		w := doJSON(router, "GET", "/api/admin/moderation/queue", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Items []ModerationQueueItem `json:"items"`
+			Total int                   `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Items, resp.Total)
+		return resp.Items
+	}
+
+	t.Run("Admins manage the banned terms", func(t *testing.T) {
+		w := doJSON(router, "PUT", "/api/admin/moderation/terms", token, gin.H{"terms": []gin.H{{"term": "x"}}})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doJSON(router, "PUT", "/api/admin/moderation/terms", adminToken, gin.H{"terms": []gin.H{{"term": "x", "action": "ban"}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(router, "PUT", "/api/admin/moderation/terms", adminToken, gin.H{"terms": []gin.H{
+			{"term": "Crypto-Signals"},
+			{"term": "Pyramid Scheme", "action": "flag"},
+		}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(router, "GET", "/api/admin/moderation/terms", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Terms []ModerationTerm `json:"terms"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []ModerationTerm{
+			{Term: "crypto signals", Action: ModerationReject},
+			{Term: "pyramid scheme", Action: ModerationFlag},
+		}, resp.Terms)
+	})
+
+	t.Run("Banned term blocks creation regardless of case and accents", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, eventPayload("Meetup", "Learn about CRYPTO sígnals with us"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		code, violations := rejection(w)
+		assert.Equal(t, ErrCodeContentRejected, code)
+		require.Len(t, violations, 1)
+		assert.Equal(t, ModerationRuleTerm, violations[0].Rule)
+
+		// Whole words only
+		w = doJSON(router, "POST", "/api/events", token, eventPayload("Meetup", "Cryptosignalsgroup is not a word anyone uses"))
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("Link cap", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, eventPayload("Links",
+			"Tickets at https://a.example.com, photos on www.example.org and chat at t.me/somegroup"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		_, violations := rejection(w)
+		require.Len(t, violations, 1)
+		assert.Equal(t, ModerationRuleLinks, violations[0].Rule)
+
+		w = doJSON(router, "POST", "/api/events", token, eventPayload("Links", "Tickets at https://a.example.com, photos on www.example.org"))
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doJSON(router, "POST", "/api/events", adminToken, eventPayload("Links",
+			"https://a.example.com https://b.example.com https://c.example.com"))
+		assert.Equal(t, http.StatusCreated, w.Code, "admins are exempt")
+	})
+
+	var flaggedEventID int
+	t.Run("Flagged event is hidden and queued", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, eventPayload("Shouting", "JOIN THE BEST PARTY IN TOWN RIGHT NOW"))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.True(t, event.HiddenPendingReview)
+		flaggedEventID = event.ID
+
+		assert.NotContains(t, listedTitles(t, router, otherToken), "Shouting")
+
+		items := queue()
+		require.Len(t, items, 1)
+		assert.Equal(t, "event", items[0].ContentType)
+		assert.Equal(t, flaggedEventID, items[0].ContentID)
+		assert.Equal(t, "Shouting", items[0].EventTitle)
+		assert.Equal(t, "User", items[0].UserName)
+		require.Len(t, items[0].Violations, 1)
+		assert.Equal(t, ModerationRuleShouting, items[0].Violations[0].Rule)
+	})
+
+	t.Run("Approving publishes the event", func(t *testing.T) {
+		items := queue()
+		require.Len(t, items, 1)
+		path := fmt.Sprintf("/api/admin/moderation/queue/%d/resolve", items[0].ID)
+		w := doJSON(router, "POST", path, adminToken, gin.H{"resolution": ModerationApproved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Contains(t, listedTitles(t, router, otherToken), "Shouting")
+		assert.Empty(t, queue())
+		w = doJSON(router, "POST", path, adminToken, gin.H{"resolution": ModerationApproved})
+		assert.Equal(t, http.StatusNotFound, w.Code, "already resolved")
+	})
+
+	t.Run("Flagging edit hides the event again", func(t *testing.T) {
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", flaggedEventID), token,
+			eventPayload("Quiet evening", "Come and learn how our pyramid scheme works"))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, listedTitles(t, router, otherToken), "Quiet evening")
+
+		items := queue()
+		require.Len(t, items, 1)
+		assert.Equal(t, ModerationRuleTerm, items[0].Violations[0].Rule)
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/admin/moderation/queue/%d/resolve", items[0].ID), adminToken,
+			gin.H{"resolution": ModerationRemoved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var cancelled bool
+		require.NoError(t, testDB.QueryRow(`SELECT cancelled_at IS NOT NULL FROM events WHERE id = ?`, flaggedEventID).Scan(&cancelled))
+		assert.True(t, cancelled)
+	})
+
+	t.Run("Flagged comment is only visible to its author", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, otherID, "Book Club")
+		addParticipant(t, testDB, eventID, userID)
+		path := fmt.Sprintf("/api/events/%d/comments", eventID)
+
+		w := doJSON(router, "POST", path, token, gin.H{"comment": "Spam " + strings.Repeat("https://example.com ", 3)})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(router, "POST", path, token, gin.H{"comment": "SEE YOU ALL THERE, CANNOT WAIT AT ALL"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var comment EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+		assert.True(t, comment.PendingReview)
+
+		commentCount := func(token string) int {
+			w := doJSON(router, "GET", path, token, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var comments []EventComment
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comments))
+			return len(comments)
+		}
+		assert.Equal(t, 1, commentCount(token))
+		assert.Zero(t, commentCount(otherToken))
+
+		items := queue()
+		require.Len(t, items, 1)
+		assert.Equal(t, "comment", items[0].ContentType)
+		assert.Equal(t, "Book Club", items[0].EventTitle)
+		assert.Equal(t, "SEE YOU ALL THERE, CANNOT WAIT AT ALL", items[0].Text)
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/admin/moderation/queue/%d/resolve", items[0].ID), adminToken,
+			gin.H{"resolution": ModerationApproved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 1, commentCount(otherToken))
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMultiInstance runs two routers against one database file. Handlers share the package db,
+// but its pool hands concurrent requests separate SQLite connections, which contend for the
+// write lock just like separate processes on the same file do.
+func TestMultiInstance(t *testing.T) {
+	setupJWT()
+	conn, _ := openProductionSchema(t)
+	// Every creation here is a near-identical "Picnic", on purpose
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	instances := []*gin.Engine{newVersionedTestRouter(), newVersionedTestRouter()}
+	const clients = 12
+
+	var seq int
+	newUser := func() (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, conn, email, "User", "password123", false)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	// concurrently sends one request per token, alternating between the instances
+	concurrently := func(tokens []string, method string, path func(i int) string, payload func(i int) gin.H) []int {
+		codes := make([]int, len(tokens))
+		var start, done sync.WaitGroup
+		start.Add(1)
+		for i, token := range tokens {
+			done.Add(1)
+			go func(i int, token string) {
+				defer done.Done()
+				start.Wait()
+				var body gin.H
+				if payload != nil {
+					body = payload(i)
+				}
+				w := doJSON(instances[i%len(instances)], method, path(i), token, body)
+				codes[i] = w.Code
+			}(i, token)
+		}
+		start.Done()
+		done.Wait()
+		return codes
+	}
+
+	t.Run("Joins never overfill an event", func(t *testing.T) {
+		organizerID, _ := newUser()
+		eventID := createTestEvent(t, conn, organizerID, "Picnic")
+		const capacity = 5
+		_, err := conn.Exec(`UPDATE events SET max_participants = ? WHERE id = ?`, capacity, eventID)
+		require.NoError(t, err)
+
+		tokens := make([]string, clients)
+		for i := range tokens {
+			_, tokens[i] = newUser()
+		}
+		codes := concurrently(tokens, "POST", func(int) string { return fmt.Sprintf("/api/events/%d/join", eventID) }, nil)
+
+		joined := 0
+		for _, code := range codes {
+			if code == http.StatusOK {
+				joined++
+			} else {
+				assert.Equal(t, http.StatusBadRequest, code)
+			}
+		}
+		assert.Equal(t, capacity, joined)
+		assert.Equal(t, capacity, countRows(t, conn, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID))
+		assert.Equal(t, capacity, countRows(t, conn, `SELECT participant_count FROM events WHERE id = ?`, eventID))
+	})
+
+	t.Run("Colliding slugs are retried", func(t *testing.T) {
+		// Every request first tries the same slug, as if all had checked it free at once
+		var mu sync.Mutex
+		tried := map[string]bool{}
+		original := newEventSlug
+		newEventSlug = func(title string) (string, error) {
+			mu.Lock()
+			first := !tried[title]
+			tried[title] = true
+			mu.Unlock()
+			if first {
+				return "picnic-taken", nil
+			}
+			return original(title)
+		}
+		defer func() { newEventSlug = original }()
+
+		tokens := make([]string, clients)
+		for i := range tokens {
+			_, tokens[i] = newUser()
+		}
+		start := time.Now().Add(72 * time.Hour).Format(time.RFC3339)
+		codes := concurrently(tokens, "POST", func(int) string { return "/api/events" }, func(i int) gin.H {
+			return gin.H{
+				"title": fmt.Sprintf("Picnic %d", i), "description": "Bring a blanket and something to share",
+				"category": "food_dining", "latitude": 47.3769, "longitude": 8.5417,
+				"start_time": start, "creator_name": "User",
+				"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+			}
+		})
+		for i, code := range codes {
+			assert.Equal(t, http.StatusCreated, code, "client %d", i)
+		}
+
+		rows, err := conn.Query(`SELECT slug FROM events WHERE title LIKE 'Picnic %'`)
+		require.NoError(t, err)
+		defer rows.Close()
+		seen := map[string]bool{}
+		for rows.Next() {
+			var slug string
+			require.NoError(t, rows.Scan(&slug))
+			assert.False(t, seen[slug], "slug %s used twice", slug)
+			seen[slug] = true
+		}
+		require.NoError(t, rows.Err())
+		assert.Len(t, seen, clients)
+		assert.True(t, seen["picnic-taken"], "one request should keep the contested slug")
+	})
+
+	t.Run("Both instances see the same data", func(t *testing.T) {
+		organizerID, _ := newUser()
+		eventID := createTestEvent(t, conn, organizerID, "Board games")
+		for _, router := range instances {
+			w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), "", nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var event Event
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+			assert.Equal(t, "Board games", event.Title)
+		}
+	})
+
+	t.Run("A starting instance leaves jobs another one is running alone", func(t *testing.T) {
+		calls := make(chan struct{}, 2)
+		useJobHandler(t, "test.lease", func(ctx context.Context, payload json.RawMessage) error {
+			calls <- struct{}{}
+			return nil
+		})
+		// The other instance claimed the job a moment ago and is still sending it
+		id, err := enqueueJob(conn, "test.lease", nil)
+		require.NoError(t, err)
+		_, err = conn.Exec(`UPDATE jobs SET status = ?, attempts = 1, updated_at = ? WHERE id = ?`,
+			JobStatusRunning, time.Now().UTC(), id)
+		require.NoError(t, err)
+
+		runner := startJobRunner(1, 10*time.Millisecond, time.Hour)
+		defer runner.Shutdown(context.Background())
+		time.Sleep(100 * time.Millisecond)
+		assert.Empty(t, calls, "a job within its lease is not run twice")
+		status, attempts, _, _ := jobStatus(t, id)
+		assert.Equal(t, JobStatusRunning, status)
+		assert.Equal(t, 1, attempts)
+
+		// The other instance died; once the lease runs out the job is picked up here
+		_, err = conn.Exec(`UPDATE jobs SET updated_at = ? WHERE id = ?`, time.Now().Add(-jobLease-time.Minute).UTC(), id)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, requeueExpiredJobs(time.Now()))
+		runner.Notify()
+		select {
+		case <-calls:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expired job was not requeued")
+		}
+		require.Eventually(t, func() bool {
+			status, _, _, _ := jobStatus(t, id)
+			return status == JobStatusDone
+		}, 5*time.Second, 10*time.Millisecond)
+		_, attempts, _, _ = jobStatus(t, id)
+		assert.Equal(t, 2, attempts)
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notification types. Each is written where the matching email goes out (if one does), so users
+// whose mail bounces or lands in spam still see it in the app.
+const (
+	NotificationEventCancelled   = "event_cancelled"     // To participants; to the organizer when moderators cancel
+	NotificationSpotAvailable    = "spot_available"      // A full event the user is interested in has a free spot
+	NotificationJoinDeadline     = "join_deadline"       // Signups for an event the user is interested in close within a day
+	NotificationGroupApproved    = "group_join_approved" // The group owner accepted the user's join request
+	NotificationDraftExpiring    = "draft_expiring"      // An unverified draft is deleted tomorrow
+	NotificationAdminGranted     = "admin_granted"
+	NotificationTransferOffered  = "transfer_offered"  // An organizer wants to hand their event over to the user
+	NotificationEventTransferred = "event_transferred" // To participants, when the event has a new organizer
+)
+
+// notificationRetention is how long notifications are kept, read or not (see runCleanup)
+const notificationRetention = 90 * 24 * time.Hour
+
+// Notification is one entry of a user's inbox. Payload depends on the type; event notifications
+// carry event_id, title and slug as they were when it was written.
+type Notification struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// eventNotification is the payload of event-related notifications
+type eventNotification struct {
+	EventID int    `json:"event_id"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// notify adds a notification to userID's inbox. Pass the transaction the triggering change runs
+// in, if any. Like recordActivity it only logs failures: the inbox must never break the action.
+func notify(exec sqlExecer, userID int, kind string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("⚠️  Failed to encode %s notification for user %d: %v", kind, userID, err)
+		return
+	}
+	if _, err := exec.Exec(`INSERT INTO notifications (user_id, type, payload) VALUES (?, ?, ?)`, userID, kind, string(body)); err != nil {
+		log.Printf("⚠️  Failed to store %s notification for user %d: %v", kind, userID, err)
+	}
+}
+
+// notifyEventParticipants adds a notification for every participant of an event except exceptUserID,
+// in a single statement
+func notifyEventParticipants(exec sqlExecer, eventID interface{}, kind string, exceptUserID int) {
+	_, err := exec.Exec(`
+		INSERT INTO notifications (user_id, type, payload)
+		SELECT p.user_id, ?, json_object('event_id', e.id, 'title', e.title, 'slug', COALESCE(e.slug, ''))
+		FROM event_participants p JOIN events e ON e.id = p.event_id
+		WHERE p.event_id = ? AND p.user_id != ?
+	`, kind, eventID, exceptUserID)
+	if err != nil {
+		log.Printf("⚠️  Failed to store %s notifications for event %v: %v", kind, eventID, err)
+	}
+}
+
+// countUnreadNotifications is the badge number of getCurrentUser
+func countUnreadNotifications(q sqlQueryRower, userID int) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// getNotifications returns the viewer's inbox, newest first (GET /api/notifications?unread=true&page=)
+func getNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+	page, perPage := parsePagination(c)
+	unreadOnly, _ := parseBoolFilter(c, "unread")
+	log.Printf("🔔 GET /api/notifications - User %d fetching page %d", userID, page)
+
+	where := ` FROM notifications WHERE user_id = ?`
+	if unreadOnly {
+		where += ` AND read_at IS NULL`
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*)`+where, userID).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count notifications: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	unread, err := countUnreadNotifications(db, userID)
+	if err != nil {
+		log.Printf("❌ Failed to count unread notifications: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, type, payload, read_at, created_at`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		log.Printf("❌ Failed to fetch notifications: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var payload string
+		if err := rows.Scan(&n.ID, &n.Type, &payload, &n.ReadAt, &n.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning notification: %v", err)
+			continue
+		}
+		n.Payload = json.RawMessage(payload)
+		notifications = append(notifications, n)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"unread":        unread,
+		"page":          page,
+		"per_page":      perPage,
+	})
+}
+
+// markNotificationRead marks one of the viewer's notifications as read (PUT /api/notifications/:id/read).
+// Marking it again keeps the first read time.
+func markNotificationRead(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	result, err := db.ExecContext(c.Request.Context(), `
+		UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?
+	`, time.Now().UTC(), id, userID)
+	if err != nil {
+		log.Printf("❌ Failed to mark notification %d read: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	// Other users' notifications look missing
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// markAllNotificationsRead empties the viewer's unread badge (PUT /api/notifications/read-all)
+func markAllNotificationsRead(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	result, err := db.ExecContext(c.Request.Context(), `
+		UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL
+	`, time.Now().UTC(), userID)
+	if err != nil {
+		log.Printf("❌ Failed to mark notifications of user %d read: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	updated, _ := result.RowsAffected()
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read", "updated": updated})
+}
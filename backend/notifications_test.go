@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int            `json:"total"`
+	Unread        int            `json:"unread"`
+}
+
+func TestNotificationProducers(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	captureModerationEmails(t)
+
+	router := gin.New()
+	protected := router.Group("/api", authMiddleware())
+	protected.POST("/groups", createGroup)
+	protected.POST("/groups/:slug/join", joinGroup)
+	protected.POST("/groups/:slug/members/:user_id/approve", approveGroupMember)
+	admin := router.Group("/api/admin", authMiddleware(), adminMiddleware())
+	admin.POST("/events/bulk", adminBulkEvents)
+	admin.PUT("/users/:id/role", adminSetUserRole)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	annID := createTestUser(t, testDB, "ann@example.com", "Ann", "password123", false)
+	annToken, _ := generateToken(User{ID: int(annID), Email: "ann@example.com", EmailVerified: true})
+
+	inbox := func(userID int64) []Notification {
+		t.Helper()
+		rows, err := testDB.Query(`SELECT id, type, payload FROM notifications WHERE user_id = ? ORDER BY id`, userID)
+		require.NoError(t, err)
+		defer rows.Close()
+		var list []Notification
+		for rows.Next() {
+			var n Notification
+			var payload string
+			require.NoError(t, rows.Scan(&n.ID, &n.Type, &payload))
+			n.Payload = json.RawMessage(payload)
+			list = append(list, n)
+		}
+		return list
+	}
+	emptyInboxes := func() {
+		_, err := testDB.Exec(`DELETE FROM notifications`)
+		require.NoError(t, err)
+	}
+	eventPayload := func(n Notification) eventNotification {
+		var p eventNotification
+		require.NoError(t, json.Unmarshal(n.Payload, &p))
+		return p
+	}
+
+	t.Run("Cancelling an event notifies its participants", func(t *testing.T) {
+		emptyInboxes()
+		eventID := createTestEvent(t, testDB, organizerID, "Board games")
+		_, err := testDB.Exec(`UPDATE events SET slug = 'board-games' WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, annID)
+		require.NoError(t, err)
+
+		w := doJSON(router, "POST", "/api/admin/events/bulk", adminToken, gin.H{"ids": []int64{eventID}, "action": "cancel"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		list := inbox(annID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationEventCancelled, list[0].Type)
+		assert.Equal(t, eventNotification{EventID: int(eventID), Title: "Board games", Slug: "board-games"}, eventPayload(list[0]))
+		assert.Empty(t, inbox(organizerID))
+
+		notifyCreatorOfUpheldReports(int(eventID))
+		list = inbox(organizerID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationEventCancelled, list[0].Type)
+		assert.Equal(t, "moderation", eventPayload(list[0]).Reason)
+	})
+
+	t.Run("A freed spot notifies interested users", func(t *testing.T) {
+		emptyInboxes()
+		eventID := createTestEvent(t, testDB, organizerID, "Sold-out concert")
+		_, err := testDB.Exec(`INSERT INTO event_interest (event_id, user_id) VALUES (?, ?)`, eventID, annID)
+		require.NoError(t, err)
+		payload, _ := json.Marshal(spotsAvailablePayload{EventID: int(eventID)})
+		require.NoError(t, notifyInterestedJob(context.Background(), payload))
+
+		list := inbox(annID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationSpotAvailable, list[0].Type)
+		assert.Equal(t, int(eventID), eventPayload(list[0]).EventID)
+	})
+
+	t.Run("Approving a group request notifies the member", func(t *testing.T) {
+		emptyInboxes()
+		w := doJSON(router, "POST", "/api/groups", organizerToken, gin.H{"name": "Night Owls", "join_policy": "approval"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var group Group
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+		require.Equal(t, http.StatusOK, doJSON(router, "POST", "/api/groups/"+group.Slug+"/join", annToken, nil).Code)
+		assert.Empty(t, inbox(annID), "asking isn't news")
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/groups/%s/members/%d/approve", group.Slug, annID), organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		list := inbox(annID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationGroupApproved, list[0].Type)
+		assert.JSONEq(t, fmt.Sprintf(`{"group_id": %d, "name": "Night Owls", "slug": %q}`, group.ID, group.Slug), string(list[0].Payload))
+	})
+
+	t.Run("An expiring draft notifies its creator", func(t *testing.T) {
+		emptyInboxes()
+		eventID := createTestEvent(t, testDB, annID, "Unverified picnic")
+		_, err := testDB.Exec(`UPDATE events SET published = 0, created_at = ? WHERE id = ?`, time.Now().Add(-6*24*time.Hour-time.Hour).UTC(), eventID)
+		require.NoError(t, err)
+		_, err = remindStaleDrafts(time.Now())
+		require.NoError(t, err)
+
+		list := inbox(annID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationDraftExpiring, list[0].Type)
+		assert.Equal(t, int(eventID), eventPayload(list[0]).EventID)
+	})
+
+	t.Run("Promotion to admin notifies the user", func(t *testing.T) {
+		emptyInboxes()
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/admin/users/%d/role", organizerID), adminToken, gin.H{"is_admin": true, "password": "password123"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		list := inbox(organizerID)
+		require.Len(t, list, 1)
+		assert.Equal(t, NotificationAdminGranted, list[0].Type)
+
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/admin/users/%d/role", organizerID), adminToken, gin.H{"is_admin": false, "password": "password123"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Len(t, inbox(organizerID), 1, "demotion isn't announced")
+		pendingModerationEmails.Wait()
+	})
+
+	t.Run("A failing insert doesn't fail the caller", func(t *testing.T) {
+		tx, err := testDB.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+		_, err = tx.Exec(`DROP TABLE notifications`) // Undone by the rollback
+		require.NoError(t, err)
+		notify(tx, int(annID), NotificationAdminGranted, gin.H{})
+		notify(tx, int(annID), NotificationAdminGranted, func() {}) // Not encodable
+		_, err = tx.Exec(`UPDATE users SET name = 'Ann B' WHERE id = ?`, annID)
+		assert.NoError(t, err, "the transaction is still usable")
+	})
+}
+
+func TestNotificationInbox(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api", authMiddleware())
+	protected.GET("/auth/me", getCurrentUser)
+	protected.GET("/notifications", getNotifications)
+	protected.PUT("/notifications/read-all", markAllNotificationsRead)
+	protected.PUT("/notifications/:id/read", markNotificationRead)
+
+	userID := createTestUser(t, testDB, "ann@example.com", "Ann", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "ann@example.com", EmailVerified: true})
+	otherID := createTestUser(t, testDB, "ben@example.com", "Ben", "password123", false)
+
+	base := time.Now().Add(-time.Hour).UTC()
+	for i := 0; i < 5; i++ {
+		_, err := testDB.Exec(`INSERT INTO notifications (user_id, type, payload, created_at) VALUES (?, ?, ?, ?)`,
+			userID, NotificationEventCancelled, fmt.Sprintf(`{"event_id": %d}`, i+1), base.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+	notify(db, int(otherID), NotificationAdminGranted, gin.H{})
+
+	fetch := func(query string) notificationPage {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/notifications"+query, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page notificationPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	eventIDs := func(page notificationPage) []int {
+		ids := []int{}
+		for _, n := range page.Notifications {
+			var p eventNotification
+			require.NoError(t, json.Unmarshal(n.Payload, &p))
+			ids = append(ids, p.EventID)
+		}
+		return ids
+	}
+	badge := func() float64 {
+		w := doJSON(router, "GET", "/api/auth/me", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body["unread_notifications"].(float64)
+	}
+
+	t.Run("Pages run newest first", func(t *testing.T) {
+		page := fetch("?per_page=2")
+		assert.Equal(t, 5, page.Total)
+		assert.Equal(t, 5, page.Unread)
+		assert.Equal(t, []int{5, 4}, eventIDs(page))
+		assert.Equal(t, []int{3, 2}, eventIDs(fetch("?per_page=2&page=2")))
+		assert.Equal(t, []int{1}, eventIDs(fetch("?per_page=2&page=3")))
+		assert.Equal(t, float64(5), badge())
+	})
+
+	t.Run("Reading one keeps it in the inbox but not among the unread", func(t *testing.T) {
+		newest := fetch("").Notifications[0]
+		require.Equal(t, http.StatusOK, doJSON(router, "PUT", fmt.Sprintf("/api/notifications/%d/read", newest.ID), token, nil).Code)
+		require.Equal(t, http.StatusOK, doJSON(router, "PUT", fmt.Sprintf("/api/notifications/%d/read", newest.ID), token, nil).Code, "repeatable")
+
+		page := fetch("")
+		assert.Equal(t, 5, page.Total)
+		assert.Equal(t, 4, page.Unread)
+		assert.NotNil(t, page.Notifications[0].ReadAt)
+		assert.Nil(t, page.Notifications[1].ReadAt)
+		assert.Equal(t, []int{4, 3, 2, 1}, eventIDs(fetch("?unread=true")))
+		assert.Equal(t, float64(4), badge())
+	})
+
+	t.Run("Other users' notifications can't be touched", func(t *testing.T) {
+		var foreignID int
+		require.NoError(t, testDB.QueryRow(`SELECT id FROM notifications WHERE user_id = ?`, otherID).Scan(&foreignID))
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "PUT", fmt.Sprintf("/api/notifications/%d/read", foreignID), token, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "PUT", "/api/notifications/abc/read", token, nil).Code)
+	})
+
+	t.Run("Read-all clears the badge", func(t *testing.T) {
+		w := doJSON(router, "PUT", "/api/notifications/read-all", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(4), body["updated"])
+
+		assert.Empty(t, fetch("?unread=true").Notifications)
+		assert.Equal(t, 5, fetch("").Total)
+		assert.Equal(t, float64(0), badge())
+
+		var otherUnread int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, otherID).Scan(&otherUnread))
+		assert.Equal(t, 1, otherUnread, "only the viewer's inbox")
+	})
+
+	t.Run("Housekeeping drops notifications after 90 days", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE notifications SET created_at = ? WHERE user_id = ?`, time.Now().Add(-notificationRetention-time.Hour).UTC(), otherID)
+		require.NoError(t, err)
+		result, err := runCleanup(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Notifications)
+		assert.Equal(t, 5, fetch("").Total)
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import "database/sql"
+
+// Column representations. SQLite has no boolean type: flags are stored as INTEGER 0/1 and written
+// as Go bools (the driver stores true as 1). Columns declared with only a DEFAULT still accept an
+// explicit NULL, which older imports and hand-written fixes left behind, so reads never scan them
+// straight into a bool, int or string:
+//   - flags defaulting to 0 and plain counts/text are scanned through nullable(), so NULL is false, 0 or ""
+//   - flags defaulting to 1 (comments_enabled, hide_participants_until_joined, allow_unregistered_users)
+//     are read as COALESCE(column, 1), matching the column default
+//   - columns whose NULL means something (max_participants unlimited, gender_restriction "any",
+//     birth_year unknown, age bounds 0-99) are scanned into sql.Null types and mapped where read
+// Filters on flags compare COALESCE(column, 0) so NULL rows match "false".
+
+// nullable wraps a scan destination so a NULL column leaves dst at its zero value instead of
+// failing the whole row
+func nullable[T any](dst *T) sql.Scanner {
+	return nullableScanner[T]{dst}
+}
+
+type nullableScanner[T any] struct {
+	dst *T
+}
+
+func (n nullableScanner[T]) Scan(src interface{}) error {
+	var v sql.Null[T]
+	if err := v.Scan(src); err != nil {
+		return err
+	}
+	*n.dst = v.V
+	return nil
+}
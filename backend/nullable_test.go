@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullableScan(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+
+	var flag bool
+	var count int
+	var text string
+	require.NoError(t, testDB.QueryRow(`SELECT NULL, NULL, NULL`).Scan(nullable(&flag), nullable(&count), nullable(&text)))
+	assert.False(t, flag)
+	assert.Zero(t, count)
+	assert.Empty(t, text)
+
+	require.NoError(t, testDB.QueryRow(`SELECT 1, 42, 'x'`).Scan(nullable(&flag), nullable(&count), nullable(&text)))
+	assert.True(t, flag)
+	assert.Equal(t, 42, count)
+	assert.Equal(t, "x", text)
+
+	flag = true
+	require.NoError(t, testDB.QueryRow(`SELECT NULL`).Scan(nullable(&flag)))
+	assert.False(t, flag, "NULL resets a reused destination")
+}
+
+// TestReadEndpointsTolerateNullColumns stores a user, an event and a participation the way rows
+// written before later migrations look (every nullable column NULL) and reads them through every
+// GET route: none may answer 500.
+func TestReadEndpointsTolerateNullColumns(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+
+	legacyUser := func(email string) int64 {
+		result, err := testDB.Exec(`
+			INSERT INTO users (email, password, name, bio, phone, threema, languages, is_admin, is_blocked, email_verified,
+				totp_secret, totp_enabled, profile_visibility, show_email, birth_year, gender, timezone, created_at, updated_at)
+			VALUES (?, 'x', 'Legacy', NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)
+		`, email)
+		require.NoError(t, err)
+		id, _ := result.LastInsertId()
+		return id
+	}
+	organizerID := legacyUser("legacy-organizer@example.com")
+	participantID := legacyUser("legacy-participant@example.com")
+
+	// slug stays set: it is how the public endpoints find the event
+	result, err := testDB.Exec(`
+		INSERT INTO events (user_id, title, description, category, latitude, longitude, start_time, end_time, creator_name,
+			max_participants, gender_restriction, age_min, age_max, smoking_allowed, alcohol_allowed, event_languages, slug,
+			comments_enabled, created_at, hide_organizer_until_joined, hide_participants_until_joined,
+			require_verified_to_join, require_verified_to_view, allow_unregistered_users, require_birth_year,
+			cancelled_at, anonymized_at, draft_reminded_at, fingerprint, updated_at, group_id)
+		VALUES (?, 'Old Meetup', 'Written before most columns existed', 'social_drinks', 46.88, 8.64, ?, NULL, 'Legacy',
+			NULL, NULL, NULL, NULL, NULL, NULL, NULL, 'old-meetup',
+			NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)
+	`, organizerID, time.Now().Add(48*time.Hour).UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+	eventID, _ := result.LastInsertId()
+	_, err = testDB.Exec(`
+		INSERT INTO event_participants (event_id, user_id, joined_at, attendance, attendance_marked_at, attendance_disputed, checked_in_at)
+		VALUES (?, ?, NULL, NULL, NULL, NULL, NULL)
+	`, eventID, participantID)
+	require.NoError(t, err)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "legacy-organizer@example.com"})
+	participantToken, _ := generateToken(User{ID: int(participantID), Email: "legacy-participant@example.com"})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+
+	router := newVersionedTestRouter()
+	for _, route := range router.Routes() {
+		if route.Method != http.MethodGet || strings.HasPrefix(route.Path, "/api/v1/") || strings.HasPrefix(route.Path, "/api/search/") {
+			continue
+		}
+		path := routeParam.ReplaceAllStringFunc(route.Path, func(param string) string {
+			switch {
+			case param == ":slug":
+				return "old-meetup"
+			case strings.Contains(route.Path, "/events/:id"):
+				return fmt.Sprint(eventID)
+			default:
+				return fmt.Sprint(organizerID)
+			}
+		})
+		if strings.HasPrefix(route.Path, "/api/events/map") {
+			path += "?min_lat=40&max_lat=50&min_lng=0&max_lng=10&zoom=12"
+		}
+
+		for name, token := range map[string]string{"anonymous": "", "organizer": organizerToken, "participant": participantToken, "admin": adminToken} {
+			w := doJSON(router, http.MethodGet, path, token, nil)
+			assert.Less(t, w.Code, http.StatusInternalServerError, "GET %s as %s: %s", path, name, w.Body.String())
+		}
+	}
+
+	t.Run("Listings still include the legacy event", func(t *testing.T) {
+		w := doJSON(router, http.MethodGet, "/api/admin/events", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"title":"Old Meetup"`)
+		assert.Contains(t, w.Body.String(), `"gender_restriction":"any"`)
+
+		w = doJSON(router, http.MethodGet, "/api/admin/users", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "legacy-organizer@example.com")
+	})
+
+	var verified sql.NullBool
+	require.NoError(t, testDB.QueryRow(`SELECT email_verified FROM users WHERE id = ?`, organizerID).Scan(&verified))
+	assert.False(t, verified.Valid, "reads never backfill the legacy row")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql"
+)
+
+// organizerColumns selects the creator's summary from the users row joined as u.
+// Scan them into organizerRow.dest() and pass the row to serializeEvent.
+const organizerColumns = `u.id, u.name, u.languages, u.created_at, u.threema`
+
+// organizerRow holds the scanned organizerColumns (all NULL when the creator account is gone)
+type organizerRow struct {
+	id              sql.NullInt64
+	name, languages sql.NullString
+	createdAt       sql.NullTime
+	threema         sql.NullString
+}
+
+func (r *organizerRow) dest() []interface{} {
+	return []interface{}{&r.id, &r.name, &r.languages, &r.createdAt, &r.threema}
+}
+
+func (r *organizerRow) organizer() *EventOrganizer {
+	if !r.id.Valid {
+		return nil
+	}
+	return &EventOrganizer{
+		ID:          int(r.id.Int64),
+		Name:        r.name.String,
+		Languages:   r.languages.String,
+		MemberSince: r.createdAt.Time,
+		Threema:     r.threema.String,
+	}
+}
+
+// serializeEvent finishes a scanned event for an API response: it attaches the organizer
+// summary (and the deprecated flat creator_languages), derives the capacity fields and applies the viewer's privacy filters.
+// Every endpoint that returns events goes through here so the organizer is hidden consistently.
+func serializeEvent(e *Event, org organizerRow, viewerUserID int, viewerIsVerified, isAdmin bool) {
+	e.Organizer = org.organizer()
+	e.CreatorLanguages = org.languages.String
+	applyCapacityFields(e)
+	ApplyPrivacyFilters(e, viewerUserID, viewerIsVerified, isAdmin)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// organizerRevealedCondition drops events whose organizer is hidden from the viewer, so listing
+// by organizer can't reveal who runs them. Binds the viewer's user ID three times.
+const organizerRevealedCondition = `
+		AND (e.hide_organizer_until_joined = 0 OR e.user_id = ?
+			OR EXISTS (SELECT 1 FROM event_participants rp WHERE rp.event_id = e.id AND rp.user_id = ?)
+			OR EXISTS (SELECT 1 FROM users viewer WHERE viewer.id = ? AND viewer.is_admin = 1))`
+
+// organizerEventsAccess decides whether a viewer may list an organizer's events. found is false
+// for unknown users and hidden profiles (respond as if the user doesn't exist); visible is false
+// when the profile is limited for this viewer or either side blocked the other.
+func organizerEventsAccess(ctx context.Context, organizerID, viewerID int, isAdmin bool) (found, visible bool, err error) {
+	var visibility string
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(profile_visibility, 'public') FROM users WHERE id = ?`, organizerID).Scan(&visibility)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	if isAdmin || viewerID == organizerID {
+		return true, true, nil
+	}
+	switch ProfileAccessFor(visibility, viewerID) {
+	case ProfileAccessNone:
+		return false, false, nil
+	case ProfileAccessLimited:
+		return true, false, nil
+	}
+	if viewerID > 0 && AreUsersBlocked(viewerID, organizerID) {
+		return true, false, nil
+	}
+	return true, true, nil
+}
+
+// getUserEvents lists a user's upcoming events (GET /api/users/:id/events), honoring their
+// profile visibility, blocks and each event's view permissions and privacy filters
+func getUserEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	organizerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	viewerID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	isVerified := c.GetBool("email_verified")
+	page, perPage := parsePagination(c)
+	log.Printf("📋 GET /api/users/%d/events - Fetching organizer's events", organizerID)
+
+	found, visible, err := organizerEventsAccess(ctx, organizerID, viewerID, isAdmin)
+	if err != nil {
+		log.Printf("❌ Error checking access to user %d: %v", organizerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Organizers have few upcoming events (see the upcoming-event limit), so view permissions
+	// are checked in Go and the page is cut afterwards to keep total accurate
+	events := []Event{}
+	if visible {
+		rows, err := db.QueryContext(ctx, `
+			SELECT `+eventColumns+`
+			FROM events e
+			LEFT JOIN users u ON e.user_id = u.id
+			WHERE e.user_id = ? AND e.start_time >= datetime('now') AND e.cancelled_at IS NULL
+			`+visibleUnderReviewCondition+publishedEventCondition+organizerRevealedCondition+`
+			ORDER BY e.start_time ASC, e.id ASC
+		`, organizerID, viewerID, viewerID, viewerID, viewerID, viewerID)
+		if err != nil {
+			log.Printf("❌ Error querying events of user %d: %v", organizerID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			e, org, err := scanEventRow(rows)
+			if err != nil {
+				log.Printf("❌ Error scanning event: %v", err)
+				continue
+			}
+			if CheckEventViewPermission(&e, viewerID, isVerified, isAdmin) != nil {
+				continue
+			}
+			serializeEvent(&e, org, viewerID, isVerified, isAdmin)
+			events = append(events, e)
+		}
+		if err := rows.Err(); err != nil {
+			log.Printf("❌ Error reading events of user %d: %v", organizerID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+			return
+		}
+	}
+
+	total := len(events)
+	start := min((page-1)*perPage, total)
+	end := min(start+perPage, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":    events[start:end],
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsByOrganizer(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/users/:id/events", optionalAuthMiddleware(), getUserEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	otherID := createTestUser(t, testDB, "other@example.com", "Other", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Viewer", "password123", false)
+	blockedID := createTestUser(t, testDB, "blocked@example.com", "Blocked", "password123", false)
+	viewerToken, _ := generateToken(User{ID: int(viewerID), Email: "viewer@example.com", EmailVerified: true})
+	blockedToken, _ := generateToken(User{ID: int(blockedID), Email: "blocked@example.com", EmailVerified: true})
+
+	createTestEvent(t, testDB, organizerID, "Public One")
+	createTestEvent(t, testDB, organizerID, "Public Two")
+	createTestEvent(t, testDB, otherID, "Someone Else's")
+	membersOnly := createTestEvent(t, testDB, organizerID, "Members Only")
+	anonymousOrganizer := createTestEvent(t, testDB, organizerID, "Anonymous Organizer")
+	_, err := testDB.Exec(`UPDATE events SET allow_unregistered_users = 0 WHERE id = ?`, membersOnly)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`UPDATE events SET hide_organizer_until_joined = 1 WHERE id = ?`, anonymousOrganizer)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, organizerID, blockedID)
+	require.NoError(t, err)
+
+	userEvents := func(t *testing.T, path, token string) ([]string, int) {
+		w := doJSON(router, "GET", path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Items []Event `json:"items"`
+			Total int     `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		titles := []string{}
+		for _, e := range resp.Items {
+			titles = append(titles, e.Title)
+		}
+		return titles, resp.Total
+	}
+	filtered := func(t *testing.T, token string) []string {
+		eventListCache.Invalidate()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events?creator_id=%d", organizerID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		titles := []string{}
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+	path := fmt.Sprintf("/api/users/%d/events", organizerID)
+
+	t.Run("Filter returns only that organizer's events", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Public One", "Public Two", "Members Only"}, filtered(t, viewerToken))
+		titles, total := userEvents(t, path, viewerToken)
+		assert.ElementsMatch(t, []string{"Public One", "Public Two", "Members Only"}, titles)
+		assert.Equal(t, 3, total)
+	})
+
+	t.Run("Private events are excluded for strangers", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Public One", "Public Two"}, filtered(t, ""))
+		titles, total := userEvents(t, path, "")
+		assert.ElementsMatch(t, []string{"Public One", "Public Two"}, titles)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("Blocked viewer sees an empty list", func(t *testing.T) {
+		assert.Empty(t, filtered(t, blockedToken))
+		titles, total := userEvents(t, path, blockedToken)
+		assert.Empty(t, titles)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		titles, total := userEvents(t, path+"?per_page=2&page=2", viewerToken)
+		assert.Len(t, titles, 1)
+		assert.Equal(t, 3, total)
+	})
+
+	t.Run("Profile visibility", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET profile_visibility = ? WHERE id = ?`, ProfileVisibilityRegistered, organizerID)
+		require.NoError(t, err)
+		titles, _ := userEvents(t, path, "")
+		assert.Empty(t, titles, "registered-only profiles show nothing to anonymous visitors")
+		assert.Empty(t, filtered(t, ""))
+
+		_, err = testDB.Exec(`UPDATE users SET profile_visibility = ? WHERE id = ?`, ProfileVisibilityHidden, organizerID)
+		require.NoError(t, err)
+		w := doJSON(router, "GET", path, viewerToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid IDs", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/events?creator_id=abc", "", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/users/9999/events", "", nil).Code)
+	})
+}
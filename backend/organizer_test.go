@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventOrganizerObject(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/events", adminGetAllEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga Organizer", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET languages = 'de,en' WHERE id = ?`, organizerID)
+	require.NoError(t, err)
+	participantID := createTestUser(t, testDB, "participant@example.com", "Pat", "password123", false)
+	strangerID := createTestUser(t, testDB, "stranger@example.com", "Sam", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	participantToken, _ := generateToken(User{ID: int(participantID), Email: "participant@example.com", EmailVerified: true})
+	strangerToken, _ := generateToken(User{ID: int(strangerID), Email: "stranger@example.com", EmailVerified: true})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Secret Supper")
+	_, err = testDB.Exec(`UPDATE events SET slug = 'secret-supper', hide_organizer_until_joined = 1 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	addParticipant(t, testDB, eventID, participantID)
+
+	paths := map[string]string{
+		"list":   "/api/events",
+		"by id":  fmt.Sprintf("/api/events/%d", eventID),
+		"public": "/api/public/events/secret-supper",
+	}
+	fetch := func(path, token string) (map[string]interface{}, string) {
+		eventListCache.Invalidate()
+		w := doJSON(router, "GET", path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		body := w.Body.String()
+		if strings.HasPrefix(body, "[") {
+			var events []map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+			require.Len(t, events, 1)
+			return events[0], body
+		}
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event, body
+	}
+
+	t.Run("Hidden organizer is null and the ID never leaks", func(t *testing.T) {
+		for name, path := range paths {
+			for _, token := range []string{"", strangerToken} {
+				event, body := fetch(path, token)
+				assert.Contains(t, event, "organizer", name)
+				assert.Nil(t, event["organizer"], name)
+				assert.Equal(t, true, event["organizer_hidden"], name)
+				assert.Equal(t, float64(0), event["user_id"], name)
+				assert.Empty(t, event["creator_name"], name)
+				assert.NotContains(t, body, "Olga", name)
+				assert.NotContains(t, body, fmt.Sprintf(`"id":%d,"name"`, organizerID), name)
+			}
+		}
+	})
+
+	t.Run("Participants see the organizer", func(t *testing.T) {
+		for name, path := range paths {
+			event, _ := fetch(path, participantToken)
+			organizer, ok := event["organizer"].(map[string]interface{})
+			require.True(t, ok, name)
+			assert.Equal(t, float64(organizerID), organizer["id"], name)
+			assert.Equal(t, "Olga Organizer", organizer["name"], name)
+			assert.Equal(t, "de,en", organizer["languages"], name)
+			assert.NotEmpty(t, organizer["member_since"], name)
+			assert.NotContains(t, event, "organizer_hidden", name)
+			assert.Equal(t, "de,en", event["creator_languages"], "legacy field kept alongside")
+		}
+	})
+
+	t.Run("Admin list includes the organizer", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/admin/events", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Events, 1)
+		require.NotNil(t, body.Events[0].Organizer)
+		assert.Equal(t, int(organizerID), body.Events[0].Organizer.ID)
+		assert.False(t, body.Events[0].OrganizerHidden)
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDisplayAliasLength caps a participant's per-event alias, in characters
+const maxDisplayAliasLength = 50
+
+var (
+	ErrAliasTooShort = errors.New("display_alias must be at least 2 characters")
+	ErrAliasTooLong  = fmt.Errorf("display_alias too long (max %d characters)", maxDisplayAliasLength)
+)
+
+// ValidateDisplayAlias checks and escapes an alias like a profile name. An empty alias is valid
+// and means the profile name is shown.
+func ValidateDisplayAlias(alias *string) error {
+	*alias = strings.TrimSpace(*alias)
+	if *alias == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(*alias) < 2 {
+		return ErrAliasTooShort
+	}
+	if utf8.RuneCountInString(*alias) > maxDisplayAliasLength {
+		return ErrAliasTooLong
+	}
+	*alias = html.EscapeString(*alias)
+	return nil
+}
+
+// moderateDisplayAlias runs the alias (stored escaped) through the content filter. Aliases have no
+// review queue, so flagged ones are refused along with rejected ones. Admins are exempt.
+func moderateDisplayAlias(c *gin.Context, alias string) bool {
+	if alias == "" || c.GetBool("is_admin") {
+		return true
+	}
+	result, err := moderateContent(c.Request.Context(), html.UnescapeString(alias))
+	if err != nil {
+		log.Printf("❌ Moderation check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check display alias"})
+		return false
+	}
+	if result.Rejected() {
+		log.Printf("🛡️  Rejected display alias from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		result.respondRejected(c)
+		return false
+	}
+	if result.Flagged() {
+		log.Printf("🛡️  Refused flagged display alias from user %d: %+v", c.GetInt("user_id"), result.Violations)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "This alias isn't allowed: " + result.Violations[0].Detail,
+			"code":       ErrCodeContentRejected,
+			"violations": result.Violations,
+		})
+		return false
+	}
+	return true
+}
+
+// aliasedName is the name shown for a participant: their alias to everyone but the organizer and
+// admins, who see the profile name with the alias in parentheses
+func aliasedName(name, alias string, organizerView bool) string {
+	switch {
+	case alias == "":
+		return name
+	case organizerView:
+		return name + " (" + alias + ")"
+	default:
+		return alias
+	}
+}
+
+// aliasHidesAccount reports whether a participant's account (user ID, bio, languages, email) must
+// be withheld because their alias is in effect for the viewer. The ID alone would lead to the
+// profile and its real name, so only the organizer, admins and the participant themselves get it.
+func aliasHidesAccount(alias string, organizerView, isSelf bool) bool {
+	return alias != "" && !organizerView && !isSelf
+}
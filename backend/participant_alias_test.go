@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParticipantDisplayAlias(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	moderationTerms.Invalidate()
+	defer moderationTerms.Invalidate()
+	_, err := testDB.Exec(`INSERT INTO moderation_terms (term, action) VALUES ('crypto signals', 'reject'), ('pyramid scheme', 'flag')`)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/api/events/:id/participants", optionalAuthMiddleware(), getEventParticipants)
+	router.GET("/api/profile/:id", optionalAuthMiddleware(), getUserProfile)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.PUT("/events/:id/participation", updateParticipation)
+	protected.GET("/events/:id/comments", getEventComments)
+	protected.POST("/events/:id/comments", createEventComment)
+
+	seq := 0
+	newUser := func(name string) (int64, string) {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, name, "password123", false)
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return id, token
+	}
+	organizerID, organizerToken := newUser("Olga")
+	aliceID, aliceToken := newUser("Alice Smith")
+	_, bobToken := newUser("Bob")
+	_, err = testDB.Exec(`UPDATE users SET bio = 'Alice from Zurich', languages = 'de', show_email = 1 WHERE id = ?`, aliceID)
+	require.NoError(t, err)
+	eventID := createTestEvent(t, testDB, organizerID, "Hiking Trip")
+	joinPath := fmt.Sprintf("/api/events/%d/join", eventID)
+	participationPath := fmt.Sprintf("/api/events/%d/participation", eventID)
+	commentsPath := fmt.Sprintf("/api/events/%d/comments", eventID)
+
+	w := doJSON(router, "POST", joinPath, aliceToken, gin.H{"display_alias": "  Trail Fox "})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, http.StatusOK, doJSON(router, "POST", joinPath, bobToken, nil).Code)
+	w = doJSON(router, "POST", commentsPath, aliceToken, gin.H{"comment": "Looking forward to it"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"user_name":"Trail Fox"`)
+
+	// names returns the participant list and the comment authors as the token's owner sees them
+	names := func(token string) (participants map[int]string, authors map[int]string) {
+		t.Helper()
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/participants", eventID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list struct {
+			Items []ParticipantView `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		participants = map[int]string{}
+		for _, p := range list.Items {
+			participants[p.ID] = p.Name
+		}
+
+		w = doJSON(router, "GET", commentsPath, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var comments []EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comments))
+		authors = map[int]string{}
+		for _, comment := range comments {
+			authors[comment.UserID] = comment.UserName
+		}
+		return participants, authors
+	}
+
+	t.Run("Fellow participants see the alias", func(t *testing.T) {
+		participants, authors := names(bobToken)
+		assert.Equal(t, "Trail Fox", participants[0])
+		assert.Equal(t, "Trail Fox", authors[0])
+		assert.NotContains(t, participants, int(aliceID))
+		assert.NotContains(t, authors, int(aliceID))
+	})
+
+	t.Run("Fellow participants can't look the alias up", func(t *testing.T) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/participants", eventID), bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "Alice from Zurich")
+		assert.NotContains(t, w.Body.String(), "user2@example.com")
+		var list struct {
+			Items []ParticipantView `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		for _, p := range list.Items {
+			if p.ID == 0 {
+				continue
+			}
+			w := doJSON(router, "GET", fmt.Sprintf("/api/profile/%d", p.ID), bobToken, nil)
+			assert.NotContains(t, w.Body.String(), "Alice Smith", "participant %d leads to the real name", p.ID)
+		}
+
+		w = doJSON(router, "GET", commentsPath, bobToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var comments []EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comments))
+		require.Len(t, comments, 1)
+		assert.Zero(t, comments[0].UserID)
+
+		// The participant still finds themselves
+		participants, authors := names(aliceToken)
+		assert.Equal(t, "Trail Fox", participants[int(aliceID)])
+		assert.Equal(t, "Trail Fox", authors[int(aliceID)])
+	})
+
+	t.Run("The organizer sees the real name with the alias", func(t *testing.T) {
+		participants, authors := names(organizerToken)
+		assert.Equal(t, "Alice Smith (Trail Fox)", participants[int(aliceID)])
+		assert.Equal(t, "Alice Smith (Trail Fox)", authors[int(aliceID)])
+	})
+
+	t.Run("Aliases are validated and moderated", func(t *testing.T) {
+		for _, alias := range []string{"X", strings.Repeat("a", maxDisplayAliasLength+1)} {
+			w := doJSON(router, "PUT", participationPath, aliceToken, gin.H{"display_alias": alias})
+			assert.Equal(t, http.StatusBadRequest, w.Code, alias)
+		}
+		for _, alias := range []string{"Crypto Signals", "Pyramid scheme fan"} {
+			w := doJSON(router, "PUT", participationPath, aliceToken, gin.H{"display_alias": alias})
+			assert.Equal(t, http.StatusBadRequest, w.Code, alias)
+			assert.Contains(t, w.Body.String(), ErrCodeContentRejected)
+		}
+		_, lateToken := newUser("Carl")
+		w := doJSON(router, "POST", joinPath, lateToken, gin.H{"display_alias": "Z"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 2, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID), "a refused alias doesn't join")
+
+		// Escaped like a profile name; clearing it brings the profile name back
+		w = doJSON(router, "PUT", participationPath, aliceToken, gin.H{"display_alias": "Fox & Co"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		participants, _ := names(bobToken)
+		assert.Equal(t, "Fox &amp; Co", participants[0])
+		w = doJSON(router, "PUT", participationPath, aliceToken, gin.H{"display_alias": ""})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		participants, authors := names(bobToken)
+		assert.Equal(t, "Alice Smith", participants[int(aliceID)])
+		assert.Equal(t, "Alice Smith", authors[int(aliceID)])
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE share_contact = 1`), "share_contact untouched")
+	})
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// participantExportColumns is the header row of the participant export; a column per event
+// question follows (see exportQuestion)
+var participantExportColumns = []string{"name", "joined_at", "attendance", "email", "threema"}
+
+// exportQuestion is a question column of the export: the current questions plus earlier versions
+// that participants answered
+type exportQuestion struct {
+	EventQuestion
+	retired bool
+}
+
+func (q exportQuestion) header() string {
+	if q.retired {
+		return q.Text + " (earlier version)"
+	}
+	return q.Text
+}
+
+// ParticipantExportAnswer is a participant's answer to one event question
+type ParticipantExportAnswer struct {
+	QuestionID int    `json:"question_id"`
+	Question   string `json:"question"`
+	Answer     string `json:"answer"`
+}
+
+// ParticipantExportRow is a single participant in the organizer's export
+// Email is only filled in when the participant opted in via show_email or shared their contact on
+// join; threema only in the latter case
+type ParticipantExportRow struct {
+	Name       string `json:"name"`
+	JoinedAt   string `json:"joined_at"`
+	Attendance string `json:"attendance"`
+	Email      string `json:"email"`
+	Threema    string `json:"threema"`
+
+	Answers []ParticipantExportAnswer `json:"answers,omitempty"`
+}
+
+func (r ParticipantExportRow) record(questions []exportQuestion) []string {
+	record := []string{r.Name, r.JoinedAt, r.Attendance, r.Email, r.Threema}
+	for _, q := range questions {
+		answer := ""
+		for _, a := range r.Answers {
+			if a.QuestionID == q.ID {
+				answer = a.Answer
+			}
+		}
+		record = append(record, answer)
+	}
+	return record
+}
+
+// loadExportAnswers reads an event's question columns and every answer by participant
+func loadExportAnswers(ctx context.Context, eventID int) ([]exportQuestion, map[int][]ParticipantExportAnswer, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT q.id, q.text, q.type, q.required, q.retired_at IS NOT NULL
+		FROM event_questions q
+		WHERE q.event_id = ?
+		  AND (q.retired_at IS NULL OR EXISTS (SELECT 1 FROM event_question_answers a WHERE a.question_id = q.id))
+		ORDER BY q.retired_at IS NOT NULL, q.position, q.id
+	`, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var questions []exportQuestion
+	text := map[int]string{}
+	for rows.Next() {
+		var q exportQuestion
+		if err := rows.Scan(&q.ID, &q.Text, &q.Type, &q.Required, &q.retired); err != nil {
+			return nil, nil, err
+		}
+		questions = append(questions, q)
+		text[q.ID] = q.Text
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	answerRows, err := db.QueryContext(ctx, `
+		SELECT user_id, question_id, answer FROM event_question_answers WHERE event_id = ? ORDER BY question_id
+	`, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer answerRows.Close()
+	answers := map[int][]ParticipantExportAnswer{}
+	for answerRows.Next() {
+		var userID int
+		var a ParticipantExportAnswer
+		if err := answerRows.Scan(&userID, &a.QuestionID, &a.Answer); err != nil {
+			return nil, nil, err
+		}
+		a.Question = text[a.QuestionID]
+		answers[userID] = append(answers[userID], a)
+	}
+	return questions, answers, answerRows.Err()
+}
+
+// exportEventParticipants streams the participant list of an event (GET /api/events/:id/participants/export)
+// Creator and admins only; format=csv (default) or format=json.
+// Participants with a block relationship to the viewer are left out, like everywhere else.
+func exportEventParticipants(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	log.Printf("📤 GET /api/events/%d/participants/export - User %d exporting participants (%s)", eventID, userID, format)
+
+	var creatorID int
+	var slug sql.NullString
+	err = db.QueryRowContext(ctx, `SELECT user_id, slug FROM events WHERE id = ?`, eventID).Scan(&creatorID, &slug)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error loading event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export participants"})
+		return
+	}
+
+	if creatorID != userID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the organizer can export participants"})
+		return
+	}
+
+	questions, answers, err := loadExportAnswers(ctx, eventID)
+	if err != nil {
+		log.Printf("❌ Error fetching answers for export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export participants"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.name, ep.joined_at, COALESCE(ep.attendance, ''),
+		       CASE WHEN u.show_email = 1 OR ep.share_contact = 1 THEN u.email ELSE '' END,
+		       CASE WHEN ep.share_contact = 1 THEN COALESCE(u.threema, '') ELSE '' END
+		FROM event_participants ep
+		JOIN users u ON ep.user_id = u.id
+		WHERE ep.event_id = ?
+		  AND NOT EXISTS (
+		      SELECT 1 FROM user_blocks ub
+		      WHERE (ub.blocker_id = ? AND ub.blocked_id = u.id)
+		         OR (ub.blocker_id = u.id AND ub.blocked_id = ?)
+		  )
+		ORDER BY ep.joined_at ASC, ep.id ASC
+	`, eventID, userID, userID)
+	if err != nil {
+		log.Printf("❌ Error fetching participants for export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export participants"})
+		return
+	}
+	defer rows.Close()
+
+	next := func() (ParticipantExportRow, bool) {
+		for rows.Next() {
+			var row ParticipantExportRow
+			var participantID int
+			var joinedAt sql.NullTime
+			if err := rows.Scan(&participantID, &row.Name, &joinedAt, &row.Attendance, &row.Email, &row.Threema); err != nil {
+				log.Printf("❌ Error scanning participant for export: %v", err)
+				continue
+			}
+			if joinedAt.Valid {
+				row.JoinedAt = joinedAt.Time.UTC().Format(time.RFC3339)
+			}
+			row.Answers = answers[participantID]
+			return row, true
+		}
+		return ParticipantExportRow{}, false
+	}
+
+	if format == "json" {
+		participants := []ParticipantExportRow{}
+		for row, ok := next(); ok; row, ok = next() {
+			participants = append(participants, row)
+		}
+		c.JSON(http.StatusOK, participants)
+		return
+	}
+
+	filename := fmt.Sprintf("event-%d-participants.csv", eventID)
+	if slug.Valid && slug.String != "" {
+		filename = fmt.Sprintf("%s-participants.csv", slug.String)
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	header := append([]string{}, participantExportColumns...)
+	for _, q := range questions {
+		header = append(header, q.header())
+	}
+	writer.Write(header)
+	count := 0
+	for row, ok := next(); ok; row, ok = next() {
+		if err := writer.Write(row.record(questions)); err != nil {
+			log.Printf("❌ Error writing participant export: %v", err)
+			return
+		}
+		count++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("❌ Error flushing participant export: %v", err)
+		return
+	}
+
+	log.Printf("✅ Exported %d participants of event %d", count, eventID)
+}
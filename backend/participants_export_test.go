@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEventParticipants(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/events/:id/participants/export", exportEventParticipants)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob, Jr.", "password123", false)
+	maliceID := createTestUser(t, testDB, "malice@example.com", "Malice", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com"})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com"})
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Door Check")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'door-check' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	for _, id := range []int64{aliceID, bobID, maliceID} {
+		addParticipant(t, testDB, eventID, id)
+	}
+	_, err = testDB.Exec(`UPDATE users SET show_email = 1 WHERE id = ?`, aliceID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`UPDATE event_participants SET attendance = ? WHERE user_id = ?`, AttendanceAttended, aliceID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, maliceID, organizerID)
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/events/%d/participants/export", eventID)
+
+	t.Run("Creator gets the CSV", func(t *testing.T) {
+		w := doJSON(router, "GET", path, organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="door-check-participants.csv"`)
+
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3, "header plus two rows; the blocked participant is left out")
+		assert.Equal(t, participantExportColumns, records[0])
+		assert.Equal(t, "Alice", records[1][0])
+		assert.NotEmpty(t, records[1][1])
+		assert.Equal(t, AttendanceAttended, records[1][2])
+		assert.Equal(t, "alice@example.com", records[1][3])
+		assert.Equal(t, "Bob, Jr.", records[2][0], "names with commas are quoted")
+		assert.Empty(t, records[2][3], "hidden emails stay hidden")
+		assert.NotContains(t, w.Body.String(), "bob@example.com")
+	})
+
+	t.Run("JSON format", func(t *testing.T) {
+		w := doJSON(router, "GET", path+"?format=json", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var rows []ParticipantExportRow
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		require.Len(t, rows, 3, "admins have no blocks with anyone here")
+		assert.Equal(t, "alice@example.com", rows[0].Email)
+		assert.Empty(t, rows[1].Email)
+
+		w = doJSON(router, "GET", path+"?format=xml", adminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Participants can't export", func(t *testing.T) {
+		w := doJSON(router, "GET", path, aliceToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Missing event", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/events/9999/participants/export", organizerToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
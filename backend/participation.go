@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateParticipation changes the caller's own settings for an event they joined
+// (PUT /api/events/:id/participation): share_contact, which lets the organizer see the
+// participant's email and threema, and display_alias, the name other participants see.
+func updateParticipation(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	var req ParticipationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.ShareContact == nil && req.DisplayAlias == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share_contact or display_alias is required"})
+		return
+	}
+
+	sets := []string{}
+	args := []interface{}{}
+	if req.ShareContact != nil {
+		sets = append(sets, "share_contact = ?")
+		args = append(args, *req.ShareContact)
+	}
+	if req.DisplayAlias != nil {
+		if err := ValidateDisplayAlias(req.DisplayAlias); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !moderateDisplayAlias(c, *req.DisplayAlias) {
+			return
+		}
+		sets = append(sets, "display_alias = NULLIF(?, '')")
+		args = append(args, *req.DisplayAlias)
+	}
+	log.Printf("🤝 PUT /api/events/%d/participation - User %d updates %s", eventID, userID, strings.Join(sets, ", "))
+
+	result, err := db.ExecContext(ctx, `UPDATE event_participants SET `+strings.Join(sets, ", ")+` WHERE event_id = ? AND user_id = ?`,
+		append(args, eventID, userID)...)
+	if err != nil {
+		log.Printf("❌ Error updating participation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update participation"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a participant of this event"})
+		return
+	}
+
+	var shareContact bool
+	var alias sql.NullString
+	if err := db.QueryRowContext(ctx, `SELECT share_contact, display_alias FROM event_participants WHERE event_id = ? AND user_id = ?`,
+		eventID, userID).Scan(&shareContact, &alias); err != nil {
+		log.Printf("❌ Error reading participation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update participation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share_contact": shareContact, "display_alias": alias.String})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareContactWithOrganizer(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id/participants", optionalAuthMiddleware(), getEventParticipants)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/join", joinEvent)
+	protected.PUT("/events/:id/participation", updateParticipation)
+	protected.GET("/events/:id/participants/export", exportEventParticipants)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	aliceID := createTestUser(t, testDB, "alice@example.com", "Alice", "password123", false)
+	bobID := createTestUser(t, testDB, "bob@example.com", "Bob", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Outsider", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET threema = 'ABCD1234' WHERE id = ?`, aliceID)
+	require.NoError(t, err)
+	organizerToken, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	aliceToken, _ := generateToken(User{ID: int(aliceID), Email: "alice@example.com", EmailVerified: true})
+	bobToken, _ := generateToken(User{ID: int(bobID), Email: "bob@example.com", EmailVerified: true})
+	outsiderToken, _ := generateToken(User{ID: int(outsiderID), Email: "outsider@example.com", EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Hiking Trip")
+	participantsPath := fmt.Sprintf("/api/events/%d/participants", eventID)
+	participationPath := fmt.Sprintf("/api/events/%d/participation", eventID)
+
+	w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), aliceToken, gin.H{"share_contact": true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), bobToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	participants := func(token string) map[string]ParticipantView {
+		w := doJSON(router, "GET", participantsPath, token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Items []ParticipantView `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		byName := map[string]ParticipantView{}
+		for _, p := range body.Items {
+			byName[p.Name] = p
+		}
+		return byName
+	}
+
+	t.Run("Organizer sees contact only for opted-in participants", func(t *testing.T) {
+		list := participants(organizerToken)
+		assert.Equal(t, "alice@example.com", list["Alice"].Email)
+		assert.Equal(t, "ABCD1234", list["Alice"].Threema)
+		require.NotNil(t, list["Alice"].SharesContact)
+		assert.True(t, *list["Alice"].SharesContact)
+		assert.Empty(t, list["Bob"].Email, "private by default")
+		assert.Empty(t, list["Bob"].Threema)
+
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/participants/export", eventID), organizerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"Alice", "alice@example.com", "ABCD1234"}, []string{records[1][0], records[1][3], records[1][4]})
+		assert.Equal(t, []string{"Bob", "", ""}, []string{records[2][0], records[2][3], records[2][4]})
+	})
+
+	t.Run("Other participants and outsiders never see it", func(t *testing.T) {
+		for _, token := range []string{bobToken, outsiderToken, ""} {
+			list := participants(token)
+			assert.Empty(t, list["Alice"].Email)
+			assert.Empty(t, list["Alice"].Threema)
+			assert.Nil(t, list["Alice"].SharesContact)
+		}
+		assert.NotNil(t, participants(aliceToken)["Alice"].SharesContact, "participants see their own setting")
+	})
+
+	t.Run("Participant can revoke", func(t *testing.T) {
+		w := doJSON(router, "PUT", participationPath, aliceToken, gin.H{"share_contact": false})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		list := participants(organizerToken)
+		assert.Empty(t, list["Alice"].Email)
+		assert.Empty(t, list["Alice"].Threema)
+
+		w = doJSON(router, "PUT", participationPath, aliceToken, gin.H{})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "share_contact is required")
+		w = doJSON(router, "PUT", participationPath, outsiderToken, gin.H{"share_contact": true})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestProfileThreema(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.GET("/profile", getOwnProfile)
+	protected.PUT("/profile", updateProfile)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	ownThreema := func() string {
+		w := doJSON(router, "GET", "/api/profile", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var profile struct {
+			User User `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		return profile.User.Threema
+	}
+
+	for _, invalid := range []string{"ABC123", "ABCD12345", "ABCD-123", "ÄBCD1234"} {
+		w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "threema": invalid})
+		assert.Equal(t, http.StatusBadRequest, w.Code, invalid)
+	}
+
+	w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "threema": " abcd1234 "})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"threema":"ABCD1234"`)
+	assert.Equal(t, "ABCD1234", ownThreema())
+
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ABCD1234", ownThreema(), "omitting threema keeps it")
+
+	w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "threema": ""})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, ownThreema())
+}
+
+func TestOrganizerThreemaForParticipants(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	participantID := createTestUser(t, testDB, "participant@example.com", "Participant", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Outsider", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET threema = 'ORGA1234' WHERE id = ?`, organizerID)
+	require.NoError(t, err)
+	participantToken, _ := generateToken(User{ID: int(participantID), Email: "participant@example.com", EmailVerified: true})
+	outsiderToken, _ := generateToken(User{ID: int(outsiderID), Email: "outsider@example.com", EmailVerified: true})
+
+	eventID := createTestEvent(t, testDB, organizerID, "Hiking Trip")
+	addParticipant(t, testDB, eventID, participantID)
+
+	organizerThreema := func(token string) string {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		if e.Organizer == nil {
+			return ""
+		}
+		return e.Organizer.Threema
+	}
+
+	assert.Equal(t, "ORGA1234", organizerThreema(participantToken))
+	assert.Empty(t, organizerThreema(outsiderToken))
+	assert.Empty(t, organizerThreema(""))
+
+	_, err = testDB.Exec(`UPDATE events SET hide_organizer_until_joined = 1 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	assert.Equal(t, "ORGA1234", organizerThreema(participantToken))
+	assert.Empty(t, organizerThreema(outsiderToken))
+}
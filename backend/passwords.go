@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is bcrypt's input limit. It applies to every algorithm so switching
+// PASSWORD_HASH never changes which passwords are accepted.
+const maxPasswordBytes = 72
+
+// Password hash algorithms selectable with PASSWORD_HASH
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// PasswordHasher hashes and verifies passwords with one algorithm. Stored hashes are
+// "<Name()>:<hash>"; hashes without a prefix predate this and are plain bcrypt.
+type PasswordHasher interface {
+	Name() string
+	Hash(password string) (string, error)
+	Verify(password, hash string) bool
+	// Outdated reports whether hash was made with different parameters than this hasher uses
+	Outdated(hash string) bool
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func (h bcryptHasher) Name() string { return PasswordHashBcrypt }
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hash), err
+}
+
+func (h bcryptHasher) Verify(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h bcryptHasher) Outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
+
+// argon2idParams follow the RFC 9106 second recommended option (64 MiB, one pass)
+type argon2idParams struct {
+	memory  uint32 // KiB
+	time    uint32
+	threads uint8
+	keyLen  uint32
+	saltLen int
+}
+
+var defaultArgon2idParams = argon2idParams{memory: 64 * 1024, time: 1, threads: 4, keyLen: 32, saltLen: 16}
+
+type argon2idHasher struct {
+	params argon2idParams
+}
+
+func (h argon2idHasher) Name() string { return PasswordHashArgon2id }
+
+// Hash returns the PHC string format: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+func (h argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// decodeArgon2id parses a PHC string back into its parameters, salt and key
+func decodeArgon2id(hash string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2idParams{}, nil, nil, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2idParams{}, nil, nil, errors.New("unsupported argon2 version")
+	}
+	var p argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return argon2idParams{}, nil, nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2idParams{}, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return argon2idParams{}, nil, nil, err
+	}
+	p.saltLen = len(salt)
+	p.keyLen = uint32(len(key))
+	return p, salt, key, nil
+}
+
+func (h argon2idHasher) Verify(password, hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+func (h argon2idHasher) Outdated(hash string) bool {
+	p, _, _, err := decodeArgon2id(hash)
+	return err != nil || p != h.params
+}
+
+// currentPasswordHasher is the hasher new passwords are stored with, per PASSWORD_HASH and BCRYPT_COST
+func currentPasswordHasher() PasswordHasher {
+	if appConfig.PasswordHash == PasswordHashArgon2id {
+		return argon2idHasher{params: defaultArgon2idParams}
+	}
+	return bcryptHasher{cost: appConfig.BcryptCost}
+}
+
+// splitPasswordHash returns the hasher that made a stored hash and the hash without its prefix
+func splitPasswordHash(stored string) (PasswordHasher, string) {
+	if name, hash, ok := strings.Cut(stored, ":"); ok {
+		switch name {
+		case PasswordHashBcrypt:
+			return bcryptHasher{cost: appConfig.BcryptCost}, hash
+		case PasswordHashArgon2id:
+			return argon2idHasher{params: defaultArgon2idParams}, hash
+		}
+	}
+	return bcryptHasher{cost: appConfig.BcryptCost}, stored // Legacy, unprefixed bcrypt
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) > maxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
+	hasher := currentPasswordHasher()
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return hasher.Name() + ":" + hash, nil
+}
+
+func checkPasswordHash(password, stored string) bool {
+	hasher, hash := splitPasswordHash(stored)
+	return hasher.Verify(password, hash)
+}
+
+// passwordNeedsRehash reports whether a stored hash is legacy, from another algorithm,
+// or made with other parameters than currently configured
+func passwordNeedsRehash(stored string) bool {
+	_, hash := splitPasswordHash(stored)
+	current := currentPasswordHasher()
+	return !strings.HasPrefix(stored, current.Name()+":") || current.Outdated(hash)
+}
+
+// upgradePasswordHash rehashes a just-verified password with the configured algorithm when needed.
+// Called after a successful login; failures are logged and the old hash keeps working.
+func upgradePasswordHash(userID int, password, stored string) {
+	if !passwordNeedsRehash(stored) {
+		return
+	}
+	hashed, err := hashPassword(password)
+	if err != nil {
+		log.Printf("⚠️  Could not rehash password for user %d: %v", userID, err)
+		return
+	}
+	if _, err := db.Exec(`UPDATE users SET password = ? WHERE id = ? AND password = ?`, hashed, userID, stored); err != nil {
+		log.Printf("⚠️  Could not store rehashed password for user %d: %v", userID, err)
+		return
+	}
+	log.Printf("🔑 Upgraded password hash for user %d to %s", userID, currentPasswordHasher().Name())
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	t.Run("Prefixed bcrypt round-trip", func(t *testing.T) {
+		stored, err := hashPassword("password123")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stored, "bcrypt:$2a$"))
+		assert.True(t, checkPasswordHash("password123", stored))
+		assert.False(t, checkPasswordHash("password124", stored))
+		assert.False(t, passwordNeedsRehash(stored))
+	})
+
+	t.Run("Legacy unprefixed bcrypt still verifies", func(t *testing.T) {
+		legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), appConfig.BcryptCost)
+		require.NoError(t, err)
+		assert.True(t, checkPasswordHash("password123", string(legacy)))
+		assert.False(t, checkPasswordHash("wrong", string(legacy)))
+		assert.True(t, passwordNeedsRehash(string(legacy)))
+	})
+
+	t.Run("Argon2id round-trip", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.PasswordHash = PasswordHashArgon2id })
+		stored, err := hashPassword("password123")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stored, "argon2id:$argon2id$v=19$m=65536,t=1,p=4$"))
+		assert.True(t, checkPasswordHash("password123", stored))
+		assert.False(t, checkPasswordHash("password124", stored))
+		assert.False(t, passwordNeedsRehash(stored))
+
+		other, err := hashPassword("password123")
+		require.NoError(t, err)
+		assert.NotEqual(t, stored, other, "salted")
+	})
+
+	t.Run("Changed cost triggers rehash", func(t *testing.T) {
+		stored, err := hashPassword("password123")
+		require.NoError(t, err)
+		useTestConfig(t, func(cfg *Config) { cfg.BcryptCost = bcrypt.MinCost + 1 })
+		assert.True(t, checkPasswordHash("password123", stored))
+		assert.True(t, passwordNeedsRehash(stored))
+	})
+
+	t.Run("Over 72 bytes rejected", func(t *testing.T) {
+		_, err := hashPassword(strings.Repeat("a", 73))
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		_, err = hashPassword(strings.Repeat("ą", 37)) // 74 bytes, 37 characters
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		_, err = hashPassword(strings.Repeat("a", 72))
+		assert.NoError(t, err)
+	})
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.POST("/api/auth/register", register)
+	router.POST("/api/auth/login", login)
+
+	userID := createTestUser(t, testDB, "legacy@example.com", "Legacy", "password123", false)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), appConfig.BcryptCost)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`UPDATE users SET password = ? WHERE id = ?`, string(legacy), userID)
+	require.NoError(t, err)
+
+	storedHash := func() string {
+		var stored string
+		require.NoError(t, testDB.QueryRow(`SELECT password FROM users WHERE id = ?`, userID).Scan(&stored))
+		return stored
+	}
+	loginAs := func(password string) int {
+		return doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": "legacy@example.com", "password": password}).Code
+	}
+
+	t.Run("Failed login leaves the hash alone", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, loginAs("wrong-password"))
+		assert.Equal(t, string(legacy), storedHash())
+	})
+
+	t.Run("Legacy hash gains a prefix", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, loginAs("password123"))
+		assert.True(t, strings.HasPrefix(storedHash(), "bcrypt:"))
+	})
+
+	t.Run("Switching to argon2id upgrades on login", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.PasswordHash = PasswordHashArgon2id })
+		require.Equal(t, http.StatusOK, loginAs("password123"))
+		upgraded := storedHash()
+		assert.True(t, strings.HasPrefix(upgraded, "argon2id:"))
+
+		require.Equal(t, http.StatusOK, loginAs("password123"))
+		assert.Equal(t, upgraded, storedHash(), "up-to-date hashes are not rewritten")
+	})
+
+	t.Run("Bcrypt users still log in after switching back", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, loginAs("password123"))
+		assert.True(t, strings.HasPrefix(storedHash(), "bcrypt:"))
+	})
+
+	t.Run("Registration rejects passwords over 72 bytes", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/auth/register", "", gin.H{
+			"email":    "long@example.com",
+			"password": strings.Repeat("x", 73),
+			"name":     "Long",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "72 bytes")
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrCodeJoinsPaused refuses joins while the organizer has paused the event (vacation mode)
+const ErrCodeJoinsPaused = "JOINS_PAUSED"
+
+// PauseEventsResponse is the answer of POST and DELETE /api/profile/pause-events
+type PauseEventsResponse struct {
+	EventsPaused bool  `json:"events_paused"`
+	Events       int64 `json:"events"` // upcoming events whose joins_paused changed
+}
+
+// setEventsPaused turns the organizer's vacation mode on or off: the user-level flag new events
+// inherit, and joins_paused on every upcoming event they organize. Participants, interest and
+// comments are left alone; past and cancelled events keep their flag.
+func setEventsPaused(ctx context.Context, userID int, paused bool) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET events_paused = ?, updated_at = ? WHERE id = ?`, paused, now, userID); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE events SET joins_paused = ?, updated_at = ?
+		WHERE user_id = ? AND joins_paused != ? AND start_time >= datetime('now') AND cancelled_at IS NULL
+	`, paused, now, userID, paused)
+	if err != nil {
+		return 0, err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	if changed > 0 {
+		eventListCache.Invalidate()
+	}
+	return changed, nil
+}
+
+// pauseOwnEvents pauses joining on all of the user's upcoming events (POST /api/profile/pause-events)
+func pauseOwnEvents(c *gin.Context) {
+	respondEventsPaused(c, true)
+}
+
+// resumeOwnEvents lifts vacation mode again (DELETE /api/profile/pause-events). Events paused one by
+// one through updateEvent are resumed too.
+func resumeOwnEvents(c *gin.Context) {
+	respondEventsPaused(c, false)
+}
+
+func respondEventsPaused(c *gin.Context, paused bool) {
+	userID := c.GetInt("user_id")
+	changed, err := setEventsPaused(c.Request.Context(), userID, paused)
+	if err != nil {
+		log.Printf("❌ Failed to set vacation mode of user %d: %v", userID, err)
+		respondDBError(c, err, "Failed to update your events")
+		return
+	}
+	if paused {
+		log.Printf("🏖️ User %d paused joins on %d upcoming events", userID, changed)
+	} else {
+		log.Printf("🏖️ User %d resumed joins on %d upcoming events", userID, changed)
+	}
+	c.JSON(http.StatusOK, PauseEventsResponse{EventsPaused: paused, Events: changed})
+}
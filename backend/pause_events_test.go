@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseEvents(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	eventListCache.Invalidate()
+	defer eventListCache.Invalidate()
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	protected.GET("/profile", getOwnProfile)
+	protected.POST("/profile/pause-events", pauseOwnEvents)
+	protected.DELETE("/profile/pause-events", resumeOwnEvents)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	token, _ := generateToken(User{ID: int(organizerID), Email: "organizer@example.com", EmailVerified: true})
+	seq := 0
+	newUser := func() string {
+		seq++
+		email := fmt.Sprintf("user%d@example.com", seq)
+		id := createTestUser(t, testDB, email, "User", "password123", false)
+		userToken, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+		return userToken
+	}
+	payload := func(title string) gin.H {
+		return gin.H{
+			"title": title, "description": "Bring a blanket and something to share",
+			"category": "food_dining", "latitude": 47.3769, "longitude": 8.5417,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Olga",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+	}
+	create := func(title string) Event {
+		t.Helper()
+		w := doJSON(router, "POST", "/api/events", token, payload(title))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event
+	}
+	join := func(userToken string, eventID int) (int, interface{}) {
+		t.Helper()
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), userToken, nil)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp["code"]
+	}
+	setPaused := func(method string) PauseEventsResponse {
+		t.Helper()
+		w := doJSON(router, method, "/api/profile/pause-events", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp PauseEventsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	listed := func() map[int]bool {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/events", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		paused := map[int]bool{}
+		for _, e := range events {
+			paused[e.ID] = e.JoinsPaused
+		}
+		return paused
+	}
+
+	picnic, quiz := create("Picnic by the lake"), create("Pub quiz")
+	member := newUser()
+	code, _ := join(member, picnic.ID)
+	require.Equal(t, http.StatusOK, code)
+	past := createTestEvent(t, testDB, organizerID, "Last month's hike")
+	_, err := testDB.Exec(`UPDATE events SET start_time = datetime('now', '-30 days') WHERE id = ?`, past)
+	require.NoError(t, err)
+
+	t.Run("Pausing blocks new joins on every upcoming event", func(t *testing.T) {
+		resp := setPaused("POST")
+		assert.True(t, resp.EventsPaused)
+		assert.EqualValues(t, 2, resp.Events)
+
+		joiner := newUser()
+		for _, id := range []int{picnic.ID, quiz.ID} {
+			code, errCode := join(joiner, id)
+			assert.Equal(t, http.StatusForbidden, code)
+			assert.Equal(t, ErrCodeJoinsPaused, errCode)
+		}
+		assert.Equal(t, map[int]bool{picnic.ID: true, quiz.ID: true}, listed())
+
+		// Who already joined stays, and past events are left as they were
+		assert.Equal(t, 1, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, picnic.ID))
+		assert.Equal(t, 0, countRows(t, testDB, `SELECT COUNT(*) FROM events WHERE id = ? AND joins_paused = 1`, past))
+
+		w := doJSON(router, "GET", "/api/profile", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"events_paused":true`)
+	})
+
+	t.Run("New events inherit the pause", func(t *testing.T) {
+		bbq := create("Barbecue")
+		assert.True(t, bbq.JoinsPaused)
+		code, errCode := join(newUser(), bbq.ID)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, ErrCodeJoinsPaused, errCode)
+	})
+
+	t.Run("A single event can be resumed", func(t *testing.T) {
+		update := payload("Pub quiz")
+		update["joins_paused"] = false
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", quiz.ID), token, update)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		joiner := newUser()
+		code, _ := join(joiner, quiz.ID)
+		assert.Equal(t, http.StatusOK, code)
+		_, errCode := join(joiner, picnic.ID)
+		assert.Equal(t, ErrCodeJoinsPaused, errCode)
+	})
+
+	t.Run("Unpausing restores joining", func(t *testing.T) {
+		resp := setPaused("DELETE")
+		assert.False(t, resp.EventsPaused)
+		assert.EqualValues(t, 2, resp.Events) // the picnic and the barbecue; the quiz was already open
+
+		joiner := newUser()
+		code, _ := join(joiner, picnic.ID)
+		assert.Equal(t, http.StatusOK, code)
+		for _, paused := range listed() {
+			assert.False(t, paused)
+		}
+		assert.False(t, create("Board games").JoinsPaused)
+	})
+}
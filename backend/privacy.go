@@ -3,6 +3,9 @@ package main
 import (
 	"database/sql"
 	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
 )
 
 // ApplyPrivacyFilters applies privacy rules to an event based on viewer's status
@@ -33,15 +36,25 @@ func ApplyPrivacyFilters(event *Event, viewerUserID int, viewerIsVerified bool,
 		}
 	}
 
-	// Apply organizer privacy filter
+	// Apply organizer privacy filter: drop everything that identifies the creator, including
+	// user_id and the legacy flat fields, and flag it so clients can render a placeholder
 	if event.HideOrganizerUntilJoined && !isParticipant {
-		event.CreatorName = "🔒 Join to see organizer"
+		event.Organizer = nil
+		event.OrganizerHidden = true
+		event.UserID = 0
+		event.CreatorName = ""
+		event.CreatorLanguages = ""
 		event.UserEmail = ""
 	} else if !viewerIsVerified {
 		// Unverified users see limited organizer info
 		event.UserEmail = ""
 	}
 
+	// The organizer's Threema ID is for people who joined
+	if !isParticipant && event.Organizer != nil {
+		event.Organizer.Threema = ""
+	}
+
 	// Apply participants privacy filter
 	if event.HideParticipantsUntilJoined && !isParticipant {
 		// Clear participant list, only show count
@@ -53,33 +66,65 @@ func ApplyPrivacyFilters(event *Event, viewerUserID int, viewerIsVerified bool,
 		event.ID, viewerUserID, viewerIsVerified, isParticipant, event.HideOrganizerUntilJoined)
 }
 
-// CheckEventViewPermission checks if a user can view an event based on privacy settings
-// Returns error message if viewing is not allowed, empty string if allowed
+// Restriction is why a viewer is refused a resource. Resources the viewer isn't allowed to know
+// exist (drafts, events hidden pending review, registration-only events for anonymous visitors,
+// profiles of users who blocked the viewer) answer 404 exactly as a missing one would. Resources
+// whose existence is public but whose content is gated answer 403 with a code the client can act on.
+type Restriction struct {
+	NotFound bool
+	Code     string
+	Message  string
+}
+
+// notFoundRestriction hides a resource behind its usual not-found message
+func notFoundRestriction(message string) *Restriction {
+	return &Restriction{NotFound: true, Message: message}
+}
+
+// respondRestricted writes the response for a Restriction
+func respondRestricted(c *gin.Context, r *Restriction) {
+	if r.NotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": r.Message})
+		return
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": r.Message, "code": r.Code})
+}
+
+// CheckEventViewPermission checks if a user can view an event based on its state and privacy settings
+// Returns nil if viewing is allowed, otherwise the Restriction to answer with
 // viewerUserID: 0 for unregistered users, >0 for registered users
 // viewerIsVerified: email verification status (only relevant if viewerUserID > 0)
-func CheckEventViewPermission(event *Event, viewerUserID int, viewerIsVerified bool, isAdmin bool) string {
-	// Admins can always view
-	if isAdmin {
-		return ""
+func CheckEventViewPermission(event *Event, viewerUserID int, viewerIsVerified bool, isAdmin bool) *Restriction {
+	// Admins and the creator can always view
+	if isAdmin || (viewerUserID > 0 && event.UserID == viewerUserID) {
+		return nil
+	}
+
+	// Drafts and events hidden pending review look deleted
+	if event.Draft || event.HiddenPendingReview {
+		return notFoundRestriction("Event not found")
 	}
 
 	// Check if event allows unregistered users
 	// If unregistered users are allowed, anyone can view
 	if event.AllowUnregisteredUsers {
-		return ""
+		return nil
 	}
 
-	// If unregistered users are NOT allowed, require authentication
+	// If unregistered users are NOT allowed, the event is private to members
 	if viewerUserID == 0 {
-		return "This event requires registration to view. Please create an account or log in."
+		return notFoundRestriction("Event not found")
 	}
 
 	// User is registered - check if event requires verified email
 	if event.RequireVerifiedToView && !viewerIsVerified {
-		return "This event is only visible to users with verified email addresses. Please verify your email to view event details."
+		return &Restriction{
+			Code:    ErrCodeEmailNotVerified,
+			Message: "This event is only visible to users with verified email addresses. Please verify your email to view event details.",
+		}
 	}
 
-	return ""
+	return nil
 }
 
 // CheckEventJoinPermission checks if a user can join an event based on privacy settings
@@ -98,82 +143,155 @@ func CheckEventJoinPermission(event *Event, viewerIsVerified bool, isAdmin bool)
 	return ""
 }
 
-// GetParticipantsWithPrivacy retrieves event participants with privacy filtering
-func GetParticipantsWithPrivacy(eventID int, viewerUserID int, viewerIsVerified bool, isAdmin bool) ([]User, error) {
+// GetParticipantsWithPrivacy retrieves one page of event participants in join order, with
+// privacy filtering, and the total number the viewer may see. An event the viewer may not see at
+// all (CheckEventViewPermission) yields its Restriction instead.
+// The organizer and admins see attendance and check-in; only admins see account flags. Emails are
+// shown to admins, to verified viewers for participants who opted in via show_email, and to the
+// organizer (with threema) for participants who shared their contact on join. Participants with a
+// display alias are listed under it, except to the organizer and admins (see aliasedName), and
+// other viewers get neither their user ID nor their bio, languages or email (see aliasHidesAccount).
+func GetParticipantsWithPrivacy(eventID int, viewerUserID int, viewerIsVerified bool, isAdmin bool, page, perPage int) ([]ParticipantView, int, *Restriction, error) {
 	// First get the event to check privacy settings
+	var event Event
 	var hideParticipants bool
-	var creatorID int
 	var isParticipant bool
 
 	err := db.QueryRow(`
-		SELECT user_id, hide_participants_until_joined,
+		SELECT user_id, COALESCE(hide_participants_until_joined, 1),
+		       COALESCE(allow_unregistered_users, 1), require_verified_to_view, hidden_pending_review, published = 0,
 		       EXISTS(SELECT 1 FROM event_participants WHERE event_id = ? AND user_id = ?) as is_participant
 		FROM events WHERE id = ?
-	`, eventID, viewerUserID, eventID).Scan(&creatorID, &hideParticipants, &isParticipant)
+	`, eventID, viewerUserID, eventID).Scan(&event.UserID, &hideParticipants,
+		&event.AllowUnregisteredUsers, nullable(&event.RequireVerifiedToView), nullable(&event.HiddenPendingReview), &event.Draft,
+		&isParticipant)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, nil, err
 	}
-
-	// Admins, creators, and participants can always see the list
-	if isAdmin || creatorID == viewerUserID || isParticipant {
-		return getFullParticipantList(eventID)
+	if r := CheckEventViewPermission(&event, viewerUserID, viewerIsVerified, isAdmin); r != nil {
+		return nil, 0, r, nil
 	}
 
-	// If participants are hidden and viewer is not a participant, return empty list
-	if hideParticipants {
+	// Admins, creators, and participants can always see the list; others only when it isn't hidden
+	isOrganizer := isAdmin || event.UserID == viewerUserID
+	if hideParticipants && !isOrganizer && !isParticipant {
 		log.Printf("🔒 Participant list hidden for event %d: viewer %d is not a participant", eventID, viewerUserID)
-		return []User{}, nil
+		return []ParticipantView{}, 0, nil, nil
 	}
 
-	// Otherwise return the full list (but maybe with limited info for unverified users)
-	participants, err := getFullParticipantList(eventID)
-	if err != nil {
-		return nil, err
-	}
-
-	// If viewer is not verified, hide contact information
-	if !viewerIsVerified {
-		for i := range participants {
-			participants[i].Email = ""
-		}
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID).Scan(&total); err != nil {
+		return nil, 0, nil, err
 	}
 
-	return participants, nil
-}
-
-// getFullParticipantList retrieves all participants for an event (internal helper)
-func getFullParticipantList(eventID int) ([]User, error) {
+	// ep.id breaks ties between joins in the same second so pages don't overlap
 	rows, err := db.Query(`
-		SELECT u.id, u.name, u.email, u.bio, u.languages, ep.joined_at
+		SELECT u.id, u.name, u.email, u.show_email, u.bio, u.languages, u.is_admin, u.is_blocked, u.email_verified,
+		       ep.joined_at, COALESCE(ep.attendance, ''), ep.checked_in_at IS NOT NULL,
+		       ep.share_contact, COALESCE(u.threema, ''), COALESCE(ep.display_alias, '')
 		FROM event_participants ep
 		JOIN users u ON ep.user_id = u.id
 		WHERE ep.event_id = ?
-		ORDER BY ep.joined_at ASC
-	`, eventID)
-
+		ORDER BY ep.joined_at ASC, ep.id ASC
+		LIMIT ? OFFSET ?
+	`, eventID, perPage, (page-1)*perPage)
 	if err != nil {
-		return nil, err
+		return nil, 0, nil, err
 	}
 	defer rows.Close()
 
-	var participants []User
+	participants := []ParticipantView{}
 	for rows.Next() {
-		var u User
+		var p ParticipantView
+		var email, threema, alias string
 		var bio, languages sql.NullString
+		var showEmail, userIsAdmin, isBlocked, emailVerified, checkedIn, shareContact bool
 		var joinedAt sql.NullTime
-		err := rows.Scan(&u.ID, &u.Name, &u.Email, &bio, &languages, &joinedAt)
-		if err != nil {
-			continue
+		if err := rows.Scan(&p.ID, &p.Name, &email, nullable(&showEmail), &bio, &languages, nullable(&userIsAdmin), nullable(&isBlocked), nullable(&emailVerified),
+			&joinedAt, &p.Attendance, &checkedIn, &shareContact, &threema, &alias); err != nil {
+			return nil, 0, nil, err
+		}
+		isSelf := p.ID == viewerUserID
+		p.Name = aliasedName(p.Name, alias, isOrganizer)
+		p.JoinedAt = joinedAt.Time
+		if isOrganizer || isSelf {
+			p.SharesContact = &shareContact
+		}
+		if aliasHidesAccount(alias, isOrganizer, isSelf) {
+			p.ID = 0
+		} else {
+			p.Bio = bio.String
+			p.Languages = languages.String
+			if isAdmin || (showEmail && viewerIsVerified) || (isOrganizer && shareContact) {
+				p.Email = email
+			}
+		}
+		if isOrganizer && shareContact {
+			p.Threema = threema
 		}
-		if bio.Valid {
-			u.Bio = bio.String
+		if isOrganizer {
+			p.CheckedIn = &checkedIn
+		} else {
+			p.Attendance = ""
+		}
+		if isAdmin {
+			p.IsAdmin, p.IsBlocked, p.EmailVerified = &userIsAdmin, &isBlocked, &emailVerified
+		}
+		participants = append(participants, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, err
+	}
+
+	return participants, total, nil, nil
+}
+
+// ProfileAccess describes how much of another user's profile a viewer may see
+type ProfileAccess int
+
+const (
+	ProfileAccessNone    ProfileAccess = iota // Profile is hidden (respond as if the user doesn't exist)
+	ProfileAccessLimited                      // Only the display name
+	ProfileAccessFull                         // Public profile fields and upcoming events
+)
+
+// ProfileAccessFor resolves a profile_visibility setting for a non-admin viewer other than the owner
+// viewerUserID: 0 for unregistered users, >0 for registered users
+func ProfileAccessFor(visibility string, viewerUserID int) ProfileAccess {
+	switch visibility {
+	case ProfileVisibilityHidden:
+		return ProfileAccessNone
+	case ProfileVisibilityRegistered:
+		if viewerUserID == 0 {
+			return ProfileAccessLimited
 		}
-		if languages.Valid {
-			u.Languages = languages.String
+		return ProfileAccessFull
+	default:
+		return ProfileAccessFull
+	}
+}
+
+// PublicProfile builds the profile payload shown to other non-admin users.
+// Email is only included when the owner opted in via show_email AND the viewer is logged in;
+// is_blocked and account internals are never included.
+func PublicProfile(user User, viewerUserID int) map[string]interface{} {
+	if ProfileAccessFor(user.ProfileVisibility, viewerUserID) == ProfileAccessLimited {
+		return map[string]interface{}{
+			"id":   user.ID,
+			"name": user.Name,
 		}
-		participants = append(participants, u)
 	}
 
-	return participants, nil
+	profile := map[string]interface{}{
+		"id":         user.ID,
+		"name":       user.Name,
+		"bio":        user.Bio,
+		"languages":  user.Languages,
+		"created_at": user.CreatedAt,
+	}
+	if user.ShowEmail && viewerUserID > 0 {
+		profile["email"] = user.Email
+	}
+	return profile
 }
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCheckEventJoinPermission(t *testing.T) {
@@ -51,8 +59,11 @@ func TestApplyPrivacyFiltersEdgeCases(t *testing.T) {
 	}
 
 	ApplyPrivacyFilters(event, 200, true, false) // viewer ID 200, verified, not admin
-	assert.Contains(t, event.CreatorName, "Join to see")
+	assert.True(t, event.OrganizerHidden)
+	assert.Nil(t, event.Organizer)
+	assert.Empty(t, event.CreatorName)
 	assert.Empty(t, event.UserEmail)
+	assert.Zero(t, event.UserID)
 
 	// Test 2: Organizer always sees everything
 	event2 := &Event{
@@ -103,9 +114,10 @@ func TestCheckEventViewPermission(t *testing.T) {
 		ID:                     1,
 		AllowUnregisteredUsers: false,
 	}
+	// It answers as if the event didn't exist
 	reason := CheckEventViewPermission(event, 0, false, false) // userID=0 means unregistered
-	assert.NotEmpty(t, reason)
-	assert.Contains(t, reason, "registration")
+	require.NotNil(t, reason)
+	assert.True(t, reason.NotFound)
 
 	// Test 2: Registered unverified user cannot view event requiring verification
 	event2 := &Event{
@@ -113,17 +125,20 @@ func TestCheckEventViewPermission(t *testing.T) {
 		AllowUnregisteredUsers: false,
 		RequireVerifiedToView:  true,
 	}
+	// Its existence isn't secret, so it's refused with a code instead
 	reason = CheckEventViewPermission(event2, 123, false, false) // userID=123, not verified
-	assert.NotEmpty(t, reason)
-	assert.Contains(t, reason, "verified email")
+	require.NotNil(t, reason)
+	assert.False(t, reason.NotFound)
+	assert.Equal(t, ErrCodeEmailNotVerified, reason.Code)
+	assert.Contains(t, reason.Message, "verified email")
 
 	// Test 3: Registered verified user can view
 	reason = CheckEventViewPermission(event2, 123, true, false) // userID=123, verified
-	assert.Empty(t, reason)
+	assert.Nil(t, reason)
 
 	// Test 4: Admin can always view
 	reason = CheckEventViewPermission(event, 0, false, true) // unregistered but admin
-	assert.Empty(t, reason)
+	assert.Nil(t, reason)
 
 	// Test 5: Event allowing unregistered users - anyone can view
 	event3 := &Event{
@@ -132,7 +147,7 @@ func TestCheckEventViewPermission(t *testing.T) {
 		RequireVerifiedToView:  true, // This is ignored when allow_unregistered_users is true
 	}
 	reason = CheckEventViewPermission(event3, 0, false, false) // unregistered user
-	assert.Empty(t, reason)
+	assert.Nil(t, reason)
 
 	// Test 6: Registered user can view event not requiring verification
 	event4 := &Event{
@@ -141,7 +156,7 @@ func TestCheckEventViewPermission(t *testing.T) {
 		RequireVerifiedToView:  false,
 	}
 	reason = CheckEventViewPermission(event4, 123, false, false) // registered but unverified
-	assert.Empty(t, reason)
+	assert.Nil(t, reason)
 }
 
 func TestGetParticipantsWithPrivacyComprehensive(t *testing.T) {
@@ -163,25 +178,25 @@ func TestGetParticipantsWithPrivacyComprehensive(t *testing.T) {
 	testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID, user2ID)
 
 	t.Run("Creator can see participants", func(t *testing.T) {
-		participants, err := GetParticipantsWithPrivacy(int(eventID), int(user1ID), true, false)
+		participants, _, _, err := GetParticipantsWithPrivacy(int(eventID), int(user1ID), true, false, 1, 50)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, participants)
 	})
 
 	t.Run("Participant can see participants", func(t *testing.T) {
-		participants, err := GetParticipantsWithPrivacy(int(eventID), int(user2ID), true, false)
+		participants, _, _, err := GetParticipantsWithPrivacy(int(eventID), int(user2ID), true, false, 1, 50)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, participants)
 	})
 
 	t.Run("Non-participant viewer cannot see hidden participants", func(t *testing.T) {
-		participants, err := GetParticipantsWithPrivacy(int(eventID), int(user3ID), true, false)
+		participants, _, _, err := GetParticipantsWithPrivacy(int(eventID), int(user3ID), true, false, 1, 50)
 		assert.NoError(t, err)
 		assert.Empty(t, participants)
 	})
 
 	t.Run("Admin can always see participants", func(t *testing.T) {
-		participants, err := GetParticipantsWithPrivacy(int(eventID), int(user3ID), true, true)
+		participants, _, _, err := GetParticipantsWithPrivacy(int(eventID), int(user3ID), true, true, 1, 50)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, participants)
 	})
@@ -192,7 +207,7 @@ func TestGetParticipantsWithPrivacyComprehensive(t *testing.T) {
 		testDB.Exec(`UPDATE events SET hide_participants_until_joined = 0 WHERE id = ?`, eventID2)
 		testDB.Exec(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`, eventID2, user2ID)
 
-		participants, err := GetParticipantsWithPrivacy(int(eventID2), int(user4ID), false, false)
+		participants, _, _, err := GetParticipantsWithPrivacy(int(eventID2), int(user4ID), false, false, 1, 50)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, participants)
 		// Verify emails are hidden for unverified users
@@ -202,7 +217,7 @@ func TestGetParticipantsWithPrivacyComprehensive(t *testing.T) {
 	})
 
 	t.Run("Invalid event ID returns error", func(t *testing.T) {
-		_, err := GetParticipantsWithPrivacy(99999, int(user1ID), true, false)
+		_, _, _, err := GetParticipantsWithPrivacy(99999, int(user1ID), true, false, 1, 50)
 		assert.Error(t, err)
 	})
 }
@@ -232,3 +247,417 @@ func TestCheckEventJoinPermissionComprehensive(t *testing.T) {
 		assert.Empty(t, result)
 	})
 }
+
+func TestProfileAccessFor(t *testing.T) {
+	assert.Equal(t, ProfileAccessFull, ProfileAccessFor(ProfileVisibilityPublic, 0))
+	assert.Equal(t, ProfileAccessFull, ProfileAccessFor(ProfileVisibilityPublic, 5))
+	assert.Equal(t, ProfileAccessLimited, ProfileAccessFor(ProfileVisibilityRegistered, 0))
+	assert.Equal(t, ProfileAccessFull, ProfileAccessFor(ProfileVisibilityRegistered, 5))
+	assert.Equal(t, ProfileAccessNone, ProfileAccessFor(ProfileVisibilityHidden, 0))
+	assert.Equal(t, ProfileAccessNone, ProfileAccessFor(ProfileVisibilityHidden, 5))
+	// Unknown/legacy values behave like public
+	assert.Equal(t, ProfileAccessFull, ProfileAccessFor("", 0))
+}
+
+func TestGetUserProfileVisibility(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	ownerID := createTestUser(t, testDB, "owner@example.com", "Owner", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Viewer", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	eventID := createTestEvent(t, testDB, ownerID, "Owner Event")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'owner-event' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	// fetch simulates optionalAuthMiddleware: no context values for anonymous visitors
+	fetch := func(viewer int64, isAdmin bool) (int, map[string]interface{}) {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if viewer > 0 {
+				c.Set("user_id", int(viewer))
+				c.Set("is_admin", isAdmin)
+			}
+			c.Next()
+		})
+		router.GET("/api/profile/:id", getUserProfile)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/profile/%d", ownerID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	setVisibility := func(visibility string, showEmail bool) {
+		_, err := testDB.Exec(`UPDATE users SET profile_visibility = ?, show_email = ? WHERE id = ?`, visibility, showEmail, ownerID)
+		require.NoError(t, err)
+	}
+	userOf := func(response map[string]interface{}) map[string]interface{} {
+		user, ok := response["user"].(map[string]interface{})
+		require.True(t, ok, "user field should be present")
+		return user
+	}
+
+	t.Run("Public profile is visible to anonymous without email or is_blocked", func(t *testing.T) {
+		setVisibility(ProfileVisibilityPublic, false)
+		code, response := fetch(0, false)
+		assert.Equal(t, http.StatusOK, code)
+		user := userOf(response)
+		assert.Equal(t, "Owner", user["name"])
+		assert.NotContains(t, user, "email")
+		assert.NotContains(t, user, "is_blocked")
+		assert.NotContains(t, user, "email_verified")
+		assert.Len(t, response["created_events"], 1)
+	})
+
+	t.Run("show_email never leaks email to anonymous callers", func(t *testing.T) {
+		setVisibility(ProfileVisibilityPublic, true)
+		code, response := fetch(0, false)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, userOf(response), "email")
+
+		code, response = fetch(viewerID, false)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "owner@example.com", userOf(response)["email"])
+		assert.NotContains(t, userOf(response), "is_blocked")
+	})
+
+	t.Run("Registered profile is limited for anonymous", func(t *testing.T) {
+		setVisibility(ProfileVisibilityRegistered, true)
+		code, response := fetch(0, false)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, response["limited"])
+		user := userOf(response)
+		assert.Equal(t, "Owner", user["name"])
+		assert.NotContains(t, user, "email")
+		assert.NotContains(t, user, "bio")
+		assert.NotContains(t, response, "created_events")
+
+		code, response = fetch(viewerID, false)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, response, "limited")
+		assert.Len(t, response["created_events"], 1)
+	})
+
+	t.Run("Hidden profile is not found for others", func(t *testing.T) {
+		setVisibility(ProfileVisibilityHidden, true)
+		code, _ := fetch(0, false)
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = fetch(viewerID, false)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Owner and admin get the complete profile", func(t *testing.T) {
+		setVisibility(ProfileVisibilityHidden, false)
+		for _, viewer := range []struct {
+			id      int64
+			isAdmin bool
+		}{{ownerID, false}, {adminID, true}} {
+			code, response := fetch(viewer.id, viewer.isAdmin)
+			assert.Equal(t, http.StatusOK, code)
+			user := userOf(response)
+			assert.Equal(t, "owner@example.com", user["email"])
+			assert.Contains(t, user, "is_blocked")
+			assert.Equal(t, ProfileVisibilityHidden, user["profile_visibility"])
+		}
+	})
+}
+
+func TestUpdateProfilePrivacySettings(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", int(userID))
+		c.Next()
+	})
+	router.PUT("/api/profile", updateProfile)
+
+	put := func(payload map[string]interface{}) int {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("PUT", "/api/profile", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	current := func() (string, bool) {
+		var visibility string
+		var showEmail bool
+		testDB.QueryRow(`SELECT profile_visibility, show_email FROM users WHERE id = ?`, userID).Scan(&visibility, &showEmail)
+		return visibility, showEmail
+	}
+
+	// Defaults
+	visibility, showEmail := current()
+	assert.Equal(t, ProfileVisibilityPublic, visibility)
+	assert.False(t, showEmail)
+
+	assert.Equal(t, http.StatusOK, put(map[string]interface{}{"name": "User", "profile_visibility": "registered", "show_email": true}))
+	visibility, showEmail = current()
+	assert.Equal(t, ProfileVisibilityRegistered, visibility)
+	assert.True(t, showEmail)
+
+	// Omitted settings are left untouched
+	assert.Equal(t, http.StatusOK, put(map[string]interface{}{"name": "User", "bio": "New bio"}))
+	visibility, showEmail = current()
+	assert.Equal(t, ProfileVisibilityRegistered, visibility)
+	assert.True(t, showEmail)
+
+	assert.Equal(t, http.StatusBadRequest, put(map[string]interface{}{"name": "User", "profile_visibility": "friends"}))
+}
+
+func TestEventParticipantsView(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id/participants", optionalAuthMiddleware(), getEventParticipants)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	viewerID := createTestUser(t, testDB, "viewer@example.com", "Viewer", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	eventID := createTestEvent(t, testDB, organizerID, "Big Picnic")
+	_, err := testDB.Exec(`UPDATE events SET hide_participants_until_joined = 0 WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	// Several joins share a timestamp; the id keeps their order stable
+	joinedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	var names []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("Guest %d", i)
+		id := createTestUser(t, testDB, fmt.Sprintf("guest%d@example.com", i), name, "password123", false)
+		_, err := testDB.Exec(`INSERT INTO event_participants (event_id, user_id, joined_at, attendance) VALUES (?, ?, ?, ?)`,
+			eventID, id, joinedAt.Add(time.Duration(i/2)*time.Minute), AttendanceAttended)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	_, err = testDB.Exec(`UPDATE users SET show_email = 1 WHERE email = 'guest0@example.com'`)
+	require.NoError(t, err)
+
+	tokenFor := func(id int64, email string, isAdmin bool) string {
+		token, _ := generateToken(User{ID: int(id), Email: email, EmailVerified: true, IsAdmin: isAdmin})
+		return token
+	}
+	list := func(token, query string) ([]map[string]interface{}, int) {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/participants%s", eventID, query), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Items []map[string]interface{} `json:"items"`
+			Total int                      `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Items, resp.Total
+	}
+	namesOf := func(items []map[string]interface{}) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item["name"].(string))
+		}
+		return out
+	}
+
+	t.Run("Paginated in join order", func(t *testing.T) {
+		token := tokenFor(viewerID, "viewer@example.com", false)
+		first, total := list(token, "?per_page=2")
+		second, _ := list(token, "?per_page=2&page=2")
+		third, _ := list(token, "?per_page=2&page=3")
+		assert.Equal(t, 5, total)
+		assert.Equal(t, names, namesOf(append(append(first, second...), third...)))
+		assert.NotEmpty(t, first[0]["joined_at"])
+	})
+
+	t.Run("Account flags and private emails are gone for other viewers", func(t *testing.T) {
+		items, _ := list(tokenFor(viewerID, "viewer@example.com", false), "")
+		for _, item := range items {
+			for _, field := range []string{"is_admin", "is_blocked", "email_verified", "two_factor_enabled", "checked_in", "attendance"} {
+				assert.NotContains(t, item, field)
+			}
+		}
+		assert.Equal(t, "guest0@example.com", items[0]["email"], "opted in via show_email")
+		assert.NotContains(t, items[1], "email")
+
+		anonymous, _ := list("", "")
+		assert.NotContains(t, anonymous[0], "email", "anonymous viewers never see emails")
+	})
+
+	t.Run("Organizer sees attendance", func(t *testing.T) {
+		items, _ := list(tokenFor(organizerID, "organizer@example.com", false), "")
+		assert.Equal(t, AttendanceAttended, items[0]["attendance"])
+		assert.Contains(t, items[0], "checked_in")
+		assert.NotContains(t, items[0], "is_blocked")
+	})
+
+	t.Run("Admins see account flags", func(t *testing.T) {
+		items, _ := list(tokenFor(adminID, "admin@example.com", true), "")
+		assert.Equal(t, false, items[0]["is_blocked"])
+		assert.Equal(t, "guest1@example.com", items[1]["email"])
+	})
+
+	t.Run("Unknown event", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/events/99999/participants", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestRestrictedResourceMatrix pins the status every viewer gets for every event and profile
+// state: 404 for what the viewer may not know exists, 403 with a code for what is only gated
+func TestRestrictedResourceMatrix(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/public/events/:slug/ics", optionalAuthMiddleware(), downloadEventICS)
+	router.GET("/api/profile/:id", optionalAuthMiddleware(), getUserProfile)
+	router.GET("/api/events/:id/comments", authMiddleware(), getEventComments)
+	router.GET("/api/events/:id/participants", optionalAuthMiddleware(), getEventParticipants)
+
+	const (
+		ok           = http.StatusOK
+		unauthorized = http.StatusUnauthorized
+		forbidden    = http.StatusForbidden
+		notFound     = http.StatusNotFound
+	)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	unverifiedID := createTestUser(t, testDB, "unverified@example.com", "Uma", "password123", false)
+	outsiderID := createTestUser(t, testDB, "outsider@example.com", "Otto", "password123", false)
+	participantID := createTestUser(t, testDB, "participant@example.com", "Pia", "password123", false)
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	_, err := testDB.Exec(`UPDATE users SET email_verified = 0 WHERE id = ?`, unverifiedID)
+	require.NoError(t, err)
+
+	token := func(id int64, email string, verified, admin bool) string {
+		tok, err := generateToken(User{ID: int(id), Email: email, EmailVerified: verified, IsAdmin: admin})
+		require.NoError(t, err)
+		return tok
+	}
+	viewers := []struct {
+		name  string
+		token string
+	}{
+		{"anonymous", ""},
+		{"unverified", token(unverifiedID, "unverified@example.com", false, false)},
+		{"outsider", token(outsiderID, "outsider@example.com", true, false)},
+		{"participant", token(participantID, "participant@example.com", true, false)},
+		{"organizer", token(organizerID, "organizer@example.com", true, false)},
+		{"admin", token(adminID, "admin@example.com", true, true)},
+	}
+
+	// Statuses per viewer, in the order above. view covers GET /api/events/:id, its participant
+	// list, GET /api/public/events/:slug and its ICS file, which must always agree.
+	events := []struct {
+		state    string
+		set      string
+		view     []int
+		comments []int
+	}{
+		{"public", `allow_unregistered_users = 1`,
+			[]int{ok, ok, ok, ok, ok, ok},
+			[]int{unauthorized, forbidden, forbidden, ok, ok, forbidden}},
+		{"members only", `allow_unregistered_users = 0`,
+			[]int{notFound, ok, ok, ok, ok, ok},
+			[]int{unauthorized, forbidden, forbidden, ok, ok, forbidden}},
+		{"verified only", `allow_unregistered_users = 0, require_verified_to_view = 1`,
+			[]int{notFound, forbidden, ok, ok, ok, ok},
+			[]int{unauthorized, forbidden, forbidden, ok, ok, forbidden}},
+		{"draft", `published = 0`,
+			[]int{notFound, notFound, notFound, notFound, ok, ok},
+			[]int{unauthorized, notFound, notFound, notFound, ok, forbidden}},
+		{"under review", `hidden_pending_review = 1`,
+			[]int{notFound, notFound, notFound, notFound, ok, ok},
+			[]int{unauthorized, notFound, notFound, notFound, ok, forbidden}},
+	}
+
+	missing := doJSON(router, "GET", "/api/events/99999", "", nil)
+	require.Equal(t, notFound, missing.Code)
+
+	for i, ev := range events {
+		eventID := createTestEvent(t, testDB, organizerID, ev.state)
+		slug := fmt.Sprintf("event-%d", i)
+		_, err := testDB.Exec(`UPDATE events SET slug = ?, `+ev.set+` WHERE id = ?`, slug, eventID)
+		require.NoError(t, err)
+		addParticipant(t, testDB, eventID, participantID)
+
+		paths := []string{
+			fmt.Sprintf("/api/events/%d", eventID),
+			fmt.Sprintf("/api/events/%d/participants", eventID),
+			"/api/public/events/" + slug,
+			"/api/public/events/" + slug + "/ics",
+		}
+		for v, viewer := range viewers {
+			t.Run(ev.state+"/"+viewer.name, func(t *testing.T) {
+				for _, path := range paths {
+					w := doJSON(router, "GET", path, viewer.token, nil)
+					require.Equal(t, ev.view[v], w.Code, "%s: %s", path, w.Body.String())
+					assertRestrictedBody(t, w, missing)
+				}
+				w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d/comments", eventID), viewer.token, nil)
+				require.Equal(t, ev.comments[v], w.Code, "comments: %s", w.Body.String())
+				assertRestrictedBody(t, w, missing)
+			})
+		}
+	}
+
+	// Profiles: the owner's visibility and blocks, seen by an anonymous visitor, the outsider
+	// and an admin
+	hiddenID := createTestUser(t, testDB, "hidden@example.com", "Hana", "password123", false)
+	blockerID := createTestUser(t, testDB, "blocker@example.com", "Bea", "password123", false)
+	blockedID := createTestUser(t, testDB, "blocked@example.com", "Ben", "password123", false)
+	_, err = testDB.Exec(`UPDATE users SET profile_visibility = ? WHERE id = ?`, ProfileVisibilityHidden, hiddenID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?), (?, ?)`,
+		blockerID, outsiderID, outsiderID, blockedID)
+	require.NoError(t, err)
+
+	missingProfile := doJSON(router, "GET", "/api/profile/99999", "", nil)
+	require.Equal(t, notFound, missingProfile.Code)
+	profileViewers := []int{0, 2, 5}
+	profiles := []struct {
+		state  string
+		userID int64
+		want   []int
+	}{
+		{"public", organizerID, []int{ok, ok, ok}},
+		{"hidden", hiddenID, []int{notFound, notFound, ok}},
+		{"blocked the viewer", blockerID, []int{ok, notFound, ok}},
+		{"blocked by the viewer", blockedID, []int{ok, ok, ok}},
+	}
+	for _, p := range profiles {
+		for i, v := range profileViewers {
+			viewer := viewers[v]
+			t.Run("profile "+p.state+"/"+viewer.name, func(t *testing.T) {
+				w := doJSON(router, "GET", fmt.Sprintf("/api/profile/%d", p.userID), viewer.token, nil)
+				require.Equal(t, p.want[i], w.Code, w.Body.String())
+				assertRestrictedBody(t, w, missingProfile)
+			})
+		}
+	}
+}
+
+// assertRestrictedBody checks that a 404 can't be told apart from a missing resource and that a
+// 403 carries a code
+func assertRestrictedBody(t *testing.T, w, missing *httptest.ResponseRecorder) {
+	t.Helper()
+	switch w.Code {
+	case http.StatusNotFound:
+		assert.JSONEq(t, missing.Body.String(), w.Body.String())
+	case http.StatusForbidden:
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.NotEmpty(t, body["code"], w.Body.String())
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicStatsTTL is how long GET /api/public/stats reuses its counts; the landing page only needs them roughly fresh
+const publicStatsTTL = 10 * time.Minute
+
+// statsEventCondition counts every event that happened or will happen, private ones included,
+// but not drafts, cancelled events or events hidden pending review
+const statsEventCondition = `e.cancelled_at IS NULL AND e.hidden_pending_review = 0 AND e.published = 1`
+
+// PublicStats is the response of GET /api/public/stats. With PUBLIC_STATS_ROUNDED the counts are
+// rounded down (see roundStat) and Rounded is true, so the page can show "1,200+".
+type PublicStats struct {
+	Members              int    `json:"members"`
+	EventsOrganized      int    `json:"events_organized"`
+	UpcomingEvents       int    `json:"upcoming_events"`
+	EventsThisWeek       int    `json:"events_this_week"`
+	TopCategoryThisMonth string `json:"top_category_this_month"` // empty when no event starts this month
+	Rounded              bool   `json:"rounded"`
+	// Featured events anonymous visitors may see right now, at most maxFeaturedSlots
+	Featured []LandingEvent `json:"featured"`
+}
+
+// loadPublicStats runs the stats queries; tests swap it to count database round trips
+var loadPublicStats = queryPublicStats
+
+// publicStatsCache holds the last counts, like sitemapCache
+type publicStatsCache struct {
+	mu      sync.Mutex
+	stats   *PublicStats
+	expires time.Time
+}
+
+var publicStats = &publicStatsCache{}
+
+func (s *publicStatsCache) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = nil
+}
+
+func (s *publicStatsCache) get(ctx context.Context) (*PublicStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats != nil && time.Now().Before(s.expires) {
+		return s.stats, nil
+	}
+	stats, err := loadPublicStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.stats = stats
+	s.expires = time.Now().Add(publicStatsTTL)
+	return stats, nil
+}
+
+// roundStat rounds n down to two significant digits (1,234 -> 1,200, 42 -> 40), and counts under 10 to 0
+func roundStat(n int) int {
+	step := 1
+	for n/step >= 100 {
+		step *= 10
+	}
+	if step == 1 {
+		step = 10
+	}
+	return n / step * step
+}
+
+func queryPublicStats(ctx context.Context) (*PublicStats, error) {
+	stats := &PublicStats{}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE COALESCE(is_blocked, 0) = 0`).Scan(&stats.Members); err != nil {
+		return nil, err
+	}
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(SUM(e.start_time >= datetime('now')), 0),
+		       COALESCE(SUM(e.start_time >= datetime('now') AND e.start_time < datetime('now', '+7 days')), 0)
+		FROM events e
+		WHERE `+statsEventCondition).Scan(&stats.EventsOrganized, &stats.UpcomingEvents, &stats.EventsThisWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	// The most popular category is the one with the most events starting this calendar month
+	var category sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT e.category FROM events e
+		WHERE e.start_time >= date('now', 'start of month') AND e.start_time < date('now', 'start of month', '+1 month')
+		AND `+statsEventCondition+`
+		GROUP BY e.category
+		ORDER BY COUNT(*) DESC, e.category ASC
+		LIMIT 1
+	`).Scan(&category)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	stats.TopCategoryThisMonth = category.String
+
+	if stats.Featured, err = loadFeaturedEvents(ctx); err != nil {
+		return nil, err
+	}
+
+	if appConfig.PublicStatsRounded {
+		stats.Members = roundStat(stats.Members)
+		stats.EventsOrganized = roundStat(stats.EventsOrganized)
+		stats.UpcomingEvents = roundStat(stats.UpcomingEvents)
+		stats.EventsThisWeek = roundStat(stats.EventsThisWeek)
+		stats.Rounded = true
+	}
+	return stats, nil
+}
+
+// getPublicStats returns the counters of the marketing landing page (GET /api/public/stats).
+// The answer is the same for everyone, so it is computed at most once per publicStatsTTL.
+func getPublicStats(c *gin.Context) {
+	stats, err := publicStats.get(c.Request.Context())
+	if err != nil {
+		log.Printf("❌ Error computing public stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load statistics"})
+		return
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicStatsTTL.Seconds())))
+	c.JSON(http.StatusOK, stats)
+}
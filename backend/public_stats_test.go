@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundStat(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 7: 0, 10: 10, 42: 40, 99: 90, 100: 100, 1234: 1200, 5678: 5600, 98765: 98000} {
+		assert.Equal(t, want, roundStat(n), "roundStat(%d)", n)
+	}
+}
+
+func TestPublicStats(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	publicStats.Invalidate()
+	defer publicStats.Invalidate()
+
+	queries := 0
+	loadPublicStats = func(ctx context.Context) (*PublicStats, error) {
+		queries++
+		return queryPublicStats(ctx)
+	}
+	defer func() { loadPublicStats = queryPublicStats }()
+
+	router := gin.New()
+	router.GET("/api/public/stats", getPublicStats)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Olga", "password123", false)
+	createTestUser(t, testDB, "member@example.com", "Mia", "password123", false)
+	blockedID := createTestUser(t, testDB, "spammer@example.com", "Spam", "password123", false)
+	_, err := testDB.Exec(`UPDATE users SET is_blocked = 1 WHERE id = ?`, blockedID)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 1, 0, 0, time.UTC)
+	seq := 0
+	event := func(category string, start time.Time, changes string) {
+		t.Helper()
+		seq++
+		id := createTestEvent(t, testDB, organizerID, fmt.Sprintf("Event %d", seq))
+		_, err := testDB.Exec(`UPDATE events SET category = ?, start_time = ? WHERE id = ?`, category, start.Format(time.RFC3339), id)
+		require.NoError(t, err)
+		if changes != "" {
+			_, err = testDB.Exec(`UPDATE events SET `+changes+` WHERE id = ?`, id)
+			require.NoError(t, err)
+		}
+	}
+	// Upcoming, in the next 7 days (a private event counts too)
+	event("sports_fitness", now.Add(48*time.Hour), "")
+	event("food_dining", now.Add(72*time.Hour), "")
+	event("travel_outdoors", now.Add(24*time.Hour), "allow_unregistered_users = 0")
+	// Upcoming, later
+	event("arts_culture", now.Add(20*24*time.Hour), "")
+	// Past
+	event("education", now.Add(-60*24*time.Hour), "")
+	// This month's favorite
+	for i := 0; i < 3; i++ {
+		event("music", monthStart, "")
+	}
+	// Never counted; they would win the month if they were
+	event("gaming_hobbies", monthStart, "cancelled_at = datetime('now')")
+	event("gaming_hobbies", monthStart, "hidden_pending_review = 1")
+	event("gaming_hobbies", monthStart, "published = 0")
+	event("gaming_hobbies", now.Add(48*time.Hour), "cancelled_at = datetime('now')")
+
+	stats := func() (PublicStats, http.Header) {
+		t.Helper()
+		w := doJSON(router, "GET", "/api/public/stats", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats PublicStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		return stats, w.Header()
+	}
+
+	t.Run("Counts match the seeded events", func(t *testing.T) {
+		got, header := stats()
+		assert.Equal(t, PublicStats{
+			Members:              2,
+			EventsOrganized:      8,
+			UpcomingEvents:       4,
+			EventsThisWeek:       3,
+			TopCategoryThisMonth: "music",
+			Featured:             []LandingEvent{},
+		}, got)
+		assert.Equal(t, "public, max-age=600", header.Get("Cache-Control"))
+	})
+
+	t.Run("The cache spares the database within the TTL", func(t *testing.T) {
+		publicStats.Invalidate()
+		queries = 0
+		first, _ := stats()
+		event("sports_fitness", now.Add(48*time.Hour), "")
+		second, _ := stats()
+		assert.Equal(t, 1, queries)
+		assert.Equal(t, first, second)
+
+		// Once the TTL is over the new event shows up
+		publicStats.mu.Lock()
+		publicStats.expires = time.Now().Add(-time.Second)
+		publicStats.mu.Unlock()
+		third, _ := stats()
+		assert.Equal(t, 2, queries)
+		assert.Equal(t, first.UpcomingEvents+1, third.UpcomingEvents)
+	})
+
+	t.Run("Counts can be published rounded", func(t *testing.T) {
+		useTestConfig(t, func(cfg *Config) { cfg.PublicStatsRounded = true })
+		publicStats.Invalidate()
+		for i := 0; i < 40; i++ {
+			createTestUser(t, testDB, fmt.Sprintf("user%d@example.com", i), "User", "password123", false)
+		}
+		got, _ := stats()
+		assert.True(t, got.Rounded)
+		assert.Equal(t, 40, got.Members) // 42 members
+		assert.Equal(t, 0, got.EventsThisWeek)
+		assert.Equal(t, "music", got.TopCategoryThisMonth)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QR code image bounds in pixels
+const (
+	qrSizeMin     = 128
+	qrSizeDefault = 512
+	qrSizeMax     = 1024
+)
+
+// parseQRSize reads the size query parameter, clamped to qrSizeMin..qrSizeMax
+func parseQRSize(raw string) (int, error) {
+	if raw == "" {
+		return qrSizeDefault, nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	return min(max(size, qrSizeMin), qrSizeMax), nil
+}
+
+// getEventQRCode renders a PNG QR code of the public event link (GET /api/public/events/:slug/qr.png)
+// Visibility follows getPublicEvent, except that events the viewer can't open 404 instead of 403
+func getEventQRCode(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+	userID := c.GetInt("user_id")
+	isAdmin := c.GetBool("is_admin")
+	isVerified := c.GetBool("email_verified")
+
+	size, err := parseQRSize(c.Query("size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be a number of pixels"})
+		return
+	}
+
+	var e Event
+	err = db.QueryRowContext(ctx, `
+		SELECT id, user_id, COALESCE(allow_unregistered_users, 1), require_verified_to_view, hidden_pending_review, published = 0
+		FROM events WHERE slug = ?
+	`, slug).Scan(&e.ID, &e.UserID, &e.AllowUnregisteredUsers, nullable(&e.RequireVerifiedToView), &e.HiddenPendingReview, &e.Draft)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching event for QR code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+
+	if CheckEventViewPermission(&e, userID, isVerified, isAdmin) != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	eventURL := publicEventURL(slug)
+	etag := weakETag(0, []byte(fmt.Sprintf("%s|%d", eventURL, size)))
+	c.Header("ETag", etag)
+	if e.AllowUnregisteredUsers && !e.HiddenPendingReview && !e.Draft {
+		c.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		c.Header("Cache-Control", "private, max-age=3600")
+	}
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	png, err := qrcode.Encode(eventURL, qrcode.Medium, size)
+	if err != nil {
+		log.Printf("❌ Error encoding QR code for %s: %v", slug, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
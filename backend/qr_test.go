@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/makiuchi-d/gozxing"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQRCode(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.BaseURL = "https://veidly.com" })
+
+	router := gin.New()
+	router.GET("/api/public/events/:slug/qr.png", optionalAuthMiddleware(), getEventQRCode)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "organizer@example.com"})
+	eventID := createTestEvent(t, testDB, userID, "Poster Party")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'poster-party' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+
+	t.Run("Encodes the public event URL", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/poster-party/qr.png", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+
+		img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, qrSizeDefault, img.Bounds().Dx())
+
+		bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+		require.NoError(t, err)
+		result, err := zxingqr.NewQRCodeReader().Decode(bitmap, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "https://veidly.com/event/poster-party", result.GetText())
+
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		req := httptest.NewRequest("GET", "/api/public/events/poster-party/qr.png", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Size is clamped", func(t *testing.T) {
+		for query, want := range map[string]int{"?size=300": 300, "?size=10": qrSizeMin, "?size=5000": qrSizeMax} {
+			w := doJSON(router, "GET", "/api/public/events/poster-party/qr.png"+query, "", nil)
+			require.Equal(t, http.StatusOK, w.Code, query)
+			img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, want, img.Bounds().Dx(), query)
+		}
+
+		w := doJSON(router, "GET", "/api/public/events/poster-party/qr.png?size=big", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown and non-public events 404", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/nope/qr.png", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		_, err := testDB.Exec(`UPDATE events SET allow_unregistered_users = 0 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		w = doJSON(router, "GET", "/api/public/events/poster-party/qr.png", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(router, "GET", "/api/public/events/poster-party/qr.png", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, max-age=3600", w.Header().Get("Cache-Control"))
+
+		_, err = testDB.Exec(`UPDATE events SET allow_unregistered_users = 1, hidden_pending_review = 1 WHERE id = ?`, eventID)
+		require.NoError(t, err)
+		w = doJSON(router, "GET", "/api/public/events/poster-party/qr.png", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestICSUsesPublicEventURL(t *testing.T) {
+	useTestConfig(t, func(cfg *Config) { cfg.BaseURL = "https://staging.veidly.com" })
+
+	ics := GenerateICS(&Event{ID: 1, Title: "Picnic", Slug: "picnic", StartTime: "2030-06-01T12:00:00Z"})
+	assert.Contains(t, ics, fmt.Sprintf("URL:%s\r\n", "https://staging.veidly.com/event/picnic"))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitTimeout bounds each limiter round trip; a slower Redis counts as unavailable
+const redisRateLimitTimeout = 250 * time.Millisecond
+
+// slidingWindowScript keeps one sorted-set member per allowed request, scored by its time in
+// milliseconds. Members older than the window are dropped before counting, so the limit applies to
+// any window-long stretch rather than to fixed buckets.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+if redis.call('ZCARD', key) >= limit then
+	return 0
+end
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, window)
+return 1
+`)
+
+// redisRateLimitStore shares a limiter's counts between instances and across restarts
+type redisRateLimitStore struct {
+	client *redis.Client
+	prefix string
+	rate   int
+	per    time.Duration
+	now    func() time.Time // tests move the clock instead of sleeping
+}
+
+// newRedisRateLimitStore namespaces keys by limiter name, rate and window, so each limiter keeps
+// its own counts while every instance running it with the same configuration shares them
+func newRedisRateLimitStore(client *redis.Client, name string, rate int, per time.Duration) *redisRateLimitStore {
+	return &redisRateLimitStore{
+		client: client,
+		prefix: fmt.Sprintf("veidly:ratelimit:%s:%d/%s:", name, rate, per),
+		rate:   rate,
+		per:    per,
+		now:    time.Now,
+	}
+}
+
+func (rs *redisRateLimitStore) allow(key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
+
+	allowed, err := slidingWindowScript.Run(ctx, rs.client, []string{rs.prefix + key},
+		rs.now().UnixMilli(), rs.per.Milliseconds(), rs.rate, uuid.NewString()).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+// shutdown is a no-op: the client is shared by all limiters and closed by closeRateLimitRedis
+func (rs *redisRateLimitStore) shutdown() {}
+
+// connectRateLimitRedis points new rate limiters at the Redis server in redisURL. An unreachable
+// server only logs a warning; limiters fail open until it comes back.
+func connectRateLimitRedis(redisURL string) error {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return err
+	}
+	opts.DialTimeout = redisRateLimitTimeout
+	opts.ReadTimeout = redisRateLimitTimeout
+	opts.WriteTimeout = redisRateLimitTimeout
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Printf("⚠️  Redis at %s is not reachable yet, rate limits fail open until it is: %v", opts.Addr, err)
+	} else {
+		log.Printf("✓ Rate limits shared through Redis at %s", opts.Addr)
+	}
+	rateLimitRedis = client
+	return nil
+}
+
+// closeRateLimitRedis closes the shared client once the limiters using it have shut down
+func closeRateLimitRedis() {
+	if rateLimitRedis == nil {
+		return
+	}
+	if err := rateLimitRedis.Close(); err != nil {
+		log.Printf("⚠️  Failed to close Redis client: %v", err)
+	}
+	rateLimitRedis = nil
+}
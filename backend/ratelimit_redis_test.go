@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisRateLimitStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newStore := func(rate int, per time.Duration) *redisRateLimitStore {
+		store := newRedisRateLimitStore(client, "test", rate, per)
+		store.now = func() time.Time { return clock }
+		return store
+	}
+
+	t.Run("Limit applies per key within the window", func(t *testing.T) {
+		limiter := &rateLimiter{store: newStore(3, time.Minute)}
+		for i := 0; i < 3; i++ {
+			assert.True(t, limiter.allow("1.2.3.4"), "request %d", i+1)
+		}
+		assert.False(t, limiter.allow("1.2.3.4"))
+		assert.True(t, limiter.allow("5.6.7.8"), "other keys have their own budget")
+	})
+
+	t.Run("Window slides", func(t *testing.T) {
+		limiter := &rateLimiter{store: newStore(2, time.Minute)}
+		assert.True(t, limiter.allow("slide"))
+		clock = clock.Add(30 * time.Second)
+		assert.True(t, limiter.allow("slide"))
+		assert.False(t, limiter.allow("slide"))
+
+		clock = clock.Add(31 * time.Second)
+		assert.True(t, limiter.allow("slide"), "the first request has left the window")
+		assert.False(t, limiter.allow("slide"), "the second one is still inside it")
+	})
+
+	t.Run("Instances with the same limits share counts", func(t *testing.T) {
+		first := &rateLimiter{store: newStore(2, time.Hour)}
+		second := &rateLimiter{store: newStore(2, time.Hour)}
+		assert.True(t, first.allow("shared"))
+		assert.True(t, second.allow("shared"))
+		assert.False(t, first.allow("shared"))
+
+		other := &rateLimiter{store: newStore(5, time.Hour)}
+		assert.True(t, other.allow("shared"), "a limiter with other limits has its own namespace")
+	})
+
+	t.Run("Limiters with the same limits but different names keep separate counts", func(t *testing.T) {
+		auth := &rateLimiter{store: newRedisRateLimitStore(client, "auth", 1, time.Hour)}
+		search := &rateLimiter{store: newRedisRateLimitStore(client, "search", 1, time.Hour)}
+		assert.True(t, auth.allow("9.9.9.9"))
+		assert.False(t, auth.allow("9.9.9.9"))
+		assert.True(t, search.allow("9.9.9.9"), "searching must not spend the login budget")
+	})
+
+	t.Run("Keys expire with the window", func(t *testing.T) {
+		limiter := &rateLimiter{store: newStore(1, time.Minute)}
+		require.True(t, limiter.allow("expiring"))
+		key := "veidly:ratelimit:test:1/1m0s:expiring"
+		assert.True(t, server.Exists(key))
+		server.FastForward(time.Minute + time.Second)
+		assert.False(t, server.Exists(key))
+	})
+
+	t.Run("Unreachable Redis fails open", func(t *testing.T) {
+		limiter := &rateLimiter{store: newStore(1, time.Minute)}
+		require.True(t, limiter.allow("outage"))
+		require.False(t, limiter.allow("outage"))
+
+		server.Close()
+		for i := 0; i < 3; i++ {
+			assert.True(t, limiter.allow("outage"))
+		}
+	})
+}
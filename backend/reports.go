@@ -0,0 +1,364 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Event report lifecycle: pending until an admin dismisses or upholds the event's reports
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusUpheld    = "upheld"
+)
+
+var validReportReasons = map[string]bool{
+	"spam": true, "inappropriate": true, "misleading": true, "dangerous": true, "other": true,
+}
+
+// visibleUnderReviewCondition hides events taken down pending review from everyone except their
+// creator and admins. Binds the viewer's user ID twice (0 for anonymous visitors).
+const visibleUnderReviewCondition = `
+		AND (e.hidden_pending_review = 0 OR e.user_id = ?
+			OR EXISTS (SELECT 1 FROM users viewer WHERE viewer.id = ? AND viewer.is_admin = 1))`
+
+// sendModerationEmail delivers takedown and resolution notices; tests swap it to capture them
+var sendModerationEmail = func(email, name, subject, message, link string) error {
+	return emailService.SendModerationEmail(email, name, subject, message, link)
+}
+
+// pendingModerationEmails tracks in-flight notices so shutdown (and tests) can wait for them
+var pendingModerationEmails sync.WaitGroup
+
+// reportEvent files a report against an event (POST /api/events/:id/report). Once enough
+// distinct users have pending reports the event is hidden until an admin reviews it.
+func reportEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	userID := c.GetInt("user_id")
+	log.Printf("🚩 POST /api/events/%d/report - User %d reporting event", eventID, userID)
+
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason is required"})
+		return
+	}
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	if !validReportReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason must be one of: spam, inappropriate, misleading, dangerous, other"})
+		return
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Description) > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Description too long (max 1000 characters)"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	// Insert first: the write takes SQLite's write lock, so the count below sees every
+	// report committed before ours and concurrent reports can't both miss the threshold
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO event_reports (event_id, reporter_id, reason, description)
+		SELECT id, ?, ?, ? FROM events
+		WHERE id = ? AND cancelled_at IS NULL AND (user_id IS NULL OR user_id != ?)
+		AND NOT EXISTS (SELECT 1 FROM event_reports WHERE event_id = ? AND reporter_id = ? AND status = ?)
+	`, userID, req.Reason, html.EscapeString(req.Description), eventID, userID, eventID, userID, ReportStatusPending)
+	if err != nil {
+		log.Printf("❌ Error inserting report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var ownerID sql.NullInt64
+		var cancelled bool
+		err := tx.QueryRowContext(ctx, `SELECT user_id, cancelled_at IS NOT NULL FROM events WHERE id = ?`, eventID).Scan(&ownerID, &cancelled)
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+		case cancelled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This event has been cancelled"})
+		case ownerID.Valid && int(ownerID.Int64) == userID:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own event"})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this event"})
+		}
+		return
+	}
+
+	var reporters int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT reporter_id) FROM event_reports WHERE event_id = ? AND status = ?
+	`, eventID, ReportStatusPending).Scan(&reporters); err != nil {
+		log.Printf("❌ Error counting reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+		return
+	}
+
+	takenDown := false
+	if reporters >= appConfig.ReportTakedownThreshold {
+		// Only the report that places the hold notifies admins
+		var err error
+		if takenDown, err = holdEvent(tx, eventID, HideSourceReports); err != nil {
+			log.Printf("❌ Error hiding reported event: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report event"})
+		return
+	}
+
+	if takenDown {
+		log.Printf("🚫 Event %d hidden pending review after %d reports", eventID, reporters)
+		eventListCache.Invalidate()
+		publicSitemap.Invalidate()
+		pendingModerationEmails.Add(1)
+		go func() {
+			defer pendingModerationEmails.Done()
+			notifyAdminsOfTakedown(eventID, reporters)
+		}()
+	}
+
+	log.Printf("✅ Event %d reported by user %d (%s)", eventID, userID, req.Reason)
+	c.JSON(http.StatusCreated, gin.H{"message": "Thank you, our moderators will review this event"})
+}
+
+func notifyAdminsOfTakedown(eventID, reporters int) {
+	var title string
+	if err := db.QueryRow(`SELECT title FROM events WHERE id = ?`, eventID).Scan(&title); err != nil {
+		log.Printf("⚠️  Failed to load taken down event %d: %v", eventID, err)
+		return
+	}
+	rows, err := db.Query(`SELECT email, name FROM users WHERE is_admin = 1 AND is_blocked = 0`)
+	if err != nil {
+		log.Printf("⚠️  Failed to load admins for takedown notice: %v", err)
+		return
+	}
+	type recipient struct{ email, name string }
+	var admins []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.email, &r.name); err == nil {
+			admins = append(admins, r)
+		}
+	}
+	rows.Close()
+
+	subject := "Event hidden pending review: " + html.UnescapeString(title)
+	message := fmt.Sprintf("\"%s\" received %d reports and has been hidden until a moderator reviews it.", html.UnescapeString(title), reporters)
+	link := frontendBaseURL() + "/admin"
+	for _, admin := range admins {
+		if err := sendModerationEmail(admin.email, admin.name, subject, message, link); err != nil {
+			log.Printf("⚠️  Failed to send takedown notice to %s: %v", admin.email, err)
+		}
+	}
+}
+
+// AdminEventReport is a report as listed for moderators
+type AdminEventReport struct {
+	EventReport
+	EventTitle          string `json:"event_title"`
+	EventSlug           string `json:"event_slug"`
+	HiddenPendingReview bool   `json:"hidden_pending_review"`
+	ReporterName        string `json:"reporter_name"`
+}
+
+// adminListReports lists event reports, pending ones by default (GET /api/admin/reports)
+func adminListReports(c *gin.Context) {
+	ctx := c.Request.Context()
+	status := c.DefaultQuery("status", ReportStatusPending)
+	log.Printf("🚩 GET /api/admin/reports - Admin listing %s reports", status)
+
+	where, args := "", []interface{}{}
+	switch status {
+	case ReportStatusPending, ReportStatusDismissed, ReportStatusUpheld:
+		where, args = " WHERE r.status = ?", append(args, status)
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of: pending, dismissed, upheld, all"})
+		return
+	}
+	if eventID := c.Query("event_id"); eventID != "" {
+		if where == "" {
+			where = " WHERE r.event_id = ?"
+		} else {
+			where += " AND r.event_id = ?"
+		}
+		args = append(args, eventID)
+	}
+
+	page, perPage := parsePagination(c)
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_reports r`+where, args...).Scan(&total); err != nil {
+		log.Printf("❌ Failed to count reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT r.id, r.event_id, r.reporter_id, r.reason, COALESCE(r.description, ''), COALESCE(r.status, 'pending'), r.created_at,
+		       e.title, COALESCE(e.slug, ''), e.hidden_pending_review, COALESCE(u.name, '')
+		FROM event_reports r
+		JOIN events e ON e.id = r.event_id
+		LEFT JOIN users u ON u.id = r.reporter_id`+where+`
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		log.Printf("❌ Failed to query reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+	defer rows.Close()
+
+	reports := []AdminEventReport{}
+	for rows.Next() {
+		var r AdminEventReport
+		if err := rows.Scan(&r.ID, &r.EventID, &r.ReporterID, &r.Reason, &r.Description, &r.Status, &r.CreatedAt,
+			&r.EventTitle, &r.EventSlug, &r.HiddenPendingReview, &r.ReporterName); err != nil {
+			log.Printf("❌ Error scanning report: %v", err)
+			continue
+		}
+		reports = append(reports, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":  reports,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// ResolveReportsRequest is the body of POST /api/admin/events/:id/reports/resolve
+type ResolveReportsRequest struct {
+	Resolution string `json:"resolution" binding:"required"` // dismissed | upheld
+}
+
+// adminResolveEventReports closes every pending report on an event. Dismissing brings a hidden
+// event back; upholding cancels it and tells the creator.
+func adminResolveEventReports(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	adminID := c.GetInt("user_id")
+
+	var req ResolveReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Resolution != ReportStatusDismissed && req.Resolution != ReportStatusUpheld) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution must be dismissed or upheld"})
+		return
+	}
+	log.Printf("🚩 POST /api/admin/events/%d/reports/resolve - Admin %d: %s", eventID, adminID, req.Resolution)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE event_reports SET status = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE event_id = ? AND status = ?
+	`, req.Resolution, adminID, time.Now().UTC(), eventID, ReportStatusPending)
+	if err != nil {
+		log.Printf("❌ Error resolving reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+	resolved, _ := result.RowsAffected()
+	if resolved == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending reports for this event"})
+		return
+	}
+
+	cancelled := false
+	if req.Resolution == ReportStatusUpheld {
+		if cancelled, err = cancelEventRecord(tx, eventID, 0); err != nil {
+			log.Printf("❌ Error cancelling reported event: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+			return
+		}
+	}
+	// Upheld events stay held by their reports; cancelled events drop out of listings anyway
+	if req.Resolution == ReportStatusUpheld {
+		_, err = holdEvent(tx, eventID, HideSourceReports)
+	} else {
+		err = releaseEvent(tx, eventID, HideSourceReports)
+	}
+	if err != nil {
+		log.Printf("❌ Error updating event review flag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing report resolution: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+
+	eventListCache.Invalidate()
+	publicSitemap.Invalidate()
+	if cancelled {
+		webhookDispatch.Dispatch(WebhookEventCancelled, eventID, adminID)
+		broadcastEvent(WebhookEventCancelled, eventID)
+		pendingModerationEmails.Add(1)
+		go func() {
+			defer pendingModerationEmails.Done()
+			notifyCreatorOfUpheldReports(eventID)
+		}()
+	}
+
+	log.Printf("✅ %d reports on event %d %s", resolved, eventID, req.Resolution)
+	c.JSON(http.StatusOK, gin.H{"resolved": resolved, "resolution": req.Resolution, "cancelled": cancelled})
+}
+
+func notifyCreatorOfUpheldReports(eventID int) {
+	var title, slug, email, name string
+	var creatorID int
+	err := db.QueryRow(`
+		SELECT e.title, COALESCE(e.slug, ''), u.id, u.email, u.name FROM events e JOIN users u ON u.id = e.user_id WHERE e.id = ?
+	`, eventID).Scan(&title, &slug, &creatorID, &email, &name)
+	if err != nil {
+		log.Printf("⚠️  Failed to load creator of event %d: %v", eventID, err)
+		return
+	}
+	notify(db, creatorID, NotificationEventCancelled, eventNotification{EventID: eventID, Title: title, Slug: slug, Reason: "moderation"})
+
+	subject := "Your event has been cancelled: " + html.UnescapeString(title)
+	message := fmt.Sprintf("After reviewing reports from other members, our moderators cancelled your event \"%s\" because it doesn't follow the community guidelines.", html.UnescapeString(title))
+	if err := sendModerationEmail(email, name, subject, message, frontendBaseURL()); err != nil {
+		log.Printf("⚠️  Failed to notify %s about cancelled event %d: %v", email, eventID, err)
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type moderationEmail struct {
+	to, subject string
+}
+
+// captureModerationEmails records moderation notices instead of sending them
+func captureModerationEmails(t *testing.T) func() []moderationEmail {
+	var mu sync.Mutex
+	sent := []moderationEmail{}
+	original := sendModerationEmail
+	sendModerationEmail = func(email, name, subject, message, link string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, moderationEmail{to: email, subject: subject})
+		return nil
+	}
+	// Emails still in flight read the globals the test is about to restore
+	t.Cleanup(func() {
+		pendingModerationEmails.Wait()
+		sendModerationEmail = original
+	})
+	return func() []moderationEmail {
+		pendingModerationEmails.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]moderationEmail(nil), sent...)
+	}
+}
+
+func setupReportRouter() *gin.Engine {
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/public/events/:slug", optionalAuthMiddleware(), getPublicEvent)
+	router.GET("/api/events/:id/join-eligibility", optionalAuthMiddleware(), getJoinEligibility)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events/:id/report", reportEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/reports", adminListReports)
+	admin.POST("/events/:id/reports/resolve", adminResolveEventReports)
+	return router
+}
+
+// createReporters creates n verified users and returns their tokens
+func createReporters(t *testing.T, testDB *sql.DB, n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		email := fmt.Sprintf("reporter%d@example.com", i)
+		id := createTestUser(t, testDB, email, "Reporter", "password123", false)
+		tokens[i], _ = generateToken(User{ID: int(id), Email: email, EmailVerified: true})
+	}
+	return tokens
+}
+
+func listedTitles(t *testing.T, router *gin.Engine, token string) []string {
+	eventListCache.Invalidate()
+	w := doJSON(router, "GET", "/api/events", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var events []Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	titles := []string{}
+	for _, e := range events {
+		titles = append(titles, e.Title)
+	}
+	return titles
+}
+
+func TestEventReportTakedown(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.ReportTakedownThreshold = 3 })
+	sentEmails := captureModerationEmails(t)
+	router := setupReportRouter()
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	creatorID := createTestUser(t, testDB, "creator@example.com", "Creator", "password123", false)
+	creatorToken, _ := generateToken(User{ID: int(creatorID), Email: "creator@example.com", EmailVerified: true})
+	eventID := createTestEvent(t, testDB, creatorID, "Suspicious Party")
+	_, err := testDB.Exec(`UPDATE events SET slug = 'suspicious-party' WHERE id = ?`, eventID)
+	require.NoError(t, err)
+	reporters := createReporters(t, testDB, 4)
+	reportPath := fmt.Sprintf("/api/events/%d/report", eventID)
+
+	t.Run("Validation", func(t *testing.T) {
+		w := doJSON(router, "POST", reportPath, reporters[0], gin.H{"reason": "boring"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "POST", reportPath, creatorToken, gin.H{"reason": "spam"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "creators can't report their own event")
+		w = doJSON(router, "POST", "/api/events/9999/report", reporters[0], gin.H{"reason": "spam"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Reports below the threshold keep the event visible", func(t *testing.T) {
+		for _, token := range reporters[:2] {
+			w := doJSON(router, "POST", reportPath, token, gin.H{"reason": "spam", "description": "Looks like a scam"})
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		}
+		w := doJSON(router, "POST", reportPath, reporters[0], gin.H{"reason": "misleading"})
+		assert.Equal(t, http.StatusConflict, w.Code, "one pending report per user")
+
+		assert.Contains(t, listedTitles(t, router, ""), "Suspicious Party")
+		assert.Empty(t, sentEmails())
+	})
+
+	t.Run("Reaching the threshold hides the event and emails admins", func(t *testing.T) {
+		w := doJSON(router, "POST", reportPath, reporters[2], gin.H{"reason": "dangerous"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = doJSON(router, "POST", reportPath, reporters[3], gin.H{"reason": "spam"})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		emails := sentEmails()
+		require.Len(t, emails, 1, "only the report that crosses the threshold notifies")
+		assert.Equal(t, "admin@example.com", emails[0].to)
+
+		assert.NotContains(t, listedTitles(t, router, ""), "Suspicious Party")
+		assert.NotContains(t, listedTitles(t, router, reporters[0]), "Suspicious Party")
+		w = doJSON(router, "GET", "/api/public/events/suspicious-party", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// Nobody else can join it, or learn that it exists by asking
+		joinPath := fmt.Sprintf("/api/events/%d/join", eventID)
+		eligibilityPath := fmt.Sprintf("/api/events/%d/join-eligibility", eventID)
+		joinerID := createTestUser(t, testDB, "joiner@example.com", "Joiner", "password123", false)
+		joiner, _ := generateToken(User{ID: int(joinerID), Email: "joiner@example.com", EmailVerified: true})
+		w = doJSON(router, "POST", joinPath, joiner, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", eligibilityPath, joiner, nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", eligibilityPath, "", nil).Code)
+		assert.Zero(t, countRows(t, testDB, `SELECT COUNT(*) FROM event_participants WHERE event_id = ?`, eventID))
+		assert.Equal(t, http.StatusOK, doJSON(router, "GET", eligibilityPath, adminToken, nil).Code)
+		assert.Equal(t, http.StatusOK, doJSON(router, "GET", eligibilityPath, creatorToken, nil).Code)
+
+		// The creator and admins still see it, flagged
+		assert.Contains(t, listedTitles(t, router, creatorToken), "Suspicious Party")
+		assert.Contains(t, listedTitles(t, router, adminToken), "Suspicious Party")
+		w = doJSON(router, "GET", "/api/public/events/suspicious-party", creatorToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.True(t, event.HiddenPendingReview)
+	})
+
+	t.Run("Admins list pending reports", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/admin/reports", reporters[0], nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doJSON(router, "GET", fmt.Sprintf("/api/admin/reports?event_id=%d", eventID), adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Reports []AdminEventReport `json:"reports"`
+			Total   int                `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 4, body.Total)
+		assert.Equal(t, "Suspicious Party", body.Reports[0].EventTitle)
+		assert.True(t, body.Reports[0].HiddenPendingReview)
+	})
+
+	t.Run("Dismissing unhides the event", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/reports/resolve", eventID), adminToken, gin.H{"resolution": "ignored"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/reports/resolve", eventID), adminToken, gin.H{"resolution": ReportStatusDismissed})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, listedTitles(t, router, ""), "Suspicious Party")
+
+		w = doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/reports/resolve", eventID), adminToken, gin.H{"resolution": ReportStatusDismissed})
+		assert.Equal(t, http.StatusNotFound, w.Code, "nothing left to resolve")
+
+		// Reporters may report again after a dismissal
+		w = doJSON(router, "POST", reportPath, reporters[0], gin.H{"reason": "spam"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Upholding cancels the event and notifies the creator", func(t *testing.T) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/reports/resolve", eventID), adminToken, gin.H{"resolution": ReportStatusUpheld})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var cancelled bool
+		require.NoError(t, testDB.QueryRow(`SELECT cancelled_at IS NOT NULL FROM events WHERE id = ?`, eventID).Scan(&cancelled))
+		assert.True(t, cancelled)
+
+		emails := sentEmails()
+		require.Len(t, emails, 2)
+		assert.Equal(t, "creator@example.com", emails[1].to)
+		assert.Contains(t, emails[1].subject, "cancelled")
+	})
+}
+
+func TestEventReportThresholdRace(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	const threshold = 5
+	useTestConfig(t, func(cfg *Config) { cfg.ReportTakedownThreshold = threshold })
+	sentEmails := captureModerationEmails(t)
+	router := setupReportRouter()
+
+	createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	creatorID := createTestUser(t, testDB, "creator@example.com", "Creator", "password123", false)
+	eventID := createTestEvent(t, testDB, creatorID, "Contested Event")
+	reporters := createReporters(t, testDB, threshold)
+
+	var wg sync.WaitGroup
+	codes := make([]int, threshold)
+	start := make(chan struct{})
+	for i, token := range reporters {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			<-start
+			codes[i] = doJSON(router, "POST", fmt.Sprintf("/api/events/%d/report", eventID), token, gin.H{"reason": "spam"}).Code
+		}(i, token)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusCreated, code, "reporter %d", i)
+	}
+	var hidden bool
+	require.NoError(t, testDB.QueryRow(`SELECT hidden_pending_review FROM events WHERE id = ?`, eventID).Scan(&hidden))
+	assert.True(t, hidden, "concurrent reports must not all miss the threshold")
+	assert.Len(t, sentEmails(), 1, "admins are notified exactly once")
+}
+
+func TestHiddenEventSources(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.ReportTakedownThreshold = 1 })
+	captureModerationEmails(t)
+	router := setupReportRouter()
+	router.POST("/api/admin/moderation/queue/:id/resolve", authMiddleware(), adminMiddleware(), adminResolveModerationItem)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	creatorID := createTestUser(t, testDB, "creator@example.com", "Creator", "password123", false)
+	reporters := createReporters(t, testDB, 2)
+
+	report := func(eventID int64, token string) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/report", eventID), token, gin.H{"reason": "spam"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	dismiss := func(eventID int64) {
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/events/%d/reports/resolve", eventID), adminToken, gin.H{"resolution": ReportStatusDismissed})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	sources := func(eventID int64) int {
+		var hidden bool
+		var sources int
+		require.NoError(t, testDB.QueryRow(`SELECT hidden_pending_review, hidden_sources FROM events WHERE id = ?`, eventID).Scan(&hidden, &sources))
+		assert.Equal(t, sources != 0, hidden, "hidden_pending_review follows hidden_sources")
+		return sources
+	}
+
+	t.Run("Dismissing reports keeps a flagged event hidden until it is approved", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, creatorID, "Flagged And Reported")
+		_, err := holdEvent(testDB, eventID, HideSourceModeration)
+		require.NoError(t, err)
+		flagForReview(testDB, "event", int(eventID), int(creatorID), ModerationResult{})
+		report(eventID, reporters[0])
+		assert.Equal(t, HideSourceModeration|HideSourceReports, sources(eventID))
+
+		dismiss(eventID)
+		assert.Equal(t, HideSourceModeration, sources(eventID))
+		assert.NotContains(t, listedTitles(t, router, ""), "Flagged And Reported")
+
+		var itemID int
+		require.NoError(t, testDB.QueryRow(`SELECT id FROM moderation_queue WHERE content_id = ?`, eventID).Scan(&itemID))
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/moderation/queue/%d/resolve", itemID), adminToken, gin.H{"resolution": ModerationApproved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Zero(t, sources(eventID))
+		assert.Contains(t, listedTitles(t, router, ""), "Flagged And Reported")
+	})
+
+	t.Run("Approving a flagged edit keeps a reported event hidden", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, creatorID, "Reported Then Flagged")
+		report(eventID, reporters[1])
+		_, err := holdEvent(testDB, eventID, HideSourceModeration)
+		require.NoError(t, err)
+		flagForReview(testDB, "event", int(eventID), int(creatorID), ModerationResult{})
+
+		var itemID int
+		require.NoError(t, testDB.QueryRow(`SELECT id FROM moderation_queue WHERE content_id = ? AND status = ?`, eventID, ModerationPending).Scan(&itemID))
+		w := doJSON(router, "POST", fmt.Sprintf("/api/admin/moderation/queue/%d/resolve", itemID), adminToken, gin.H{"resolution": ModerationApproved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, HideSourceReports, sources(eventID))
+		assert.NotContains(t, listedTitles(t, router, ""), "Reported Then Flagged")
+	})
+
+	t.Run("Dismissing reports keeps an event held by integrity repair hidden", func(t *testing.T) {
+		eventID := createTestEvent(t, testDB, creatorID, "Orphaned And Reported")
+		_, err := holdEvent(testDB, eventID, HideSourceIntegrity)
+		require.NoError(t, err)
+		report(eventID, reporters[0])
+
+		dismiss(eventID)
+		assert.Equal(t, HideSourceIntegrity, sources(eventID))
+		assert.NotContains(t, listedTitles(t, router, ""), "Orphaned And Reported")
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Event description formats
+const (
+	DescriptionFormatPlain    = "plain"
+	DescriptionFormatMarkdown = "markdown"
+)
+
+var (
+	// Inline markdown, matched against already-escaped source
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+	markdownBoldPattern = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	orderedItemPattern  = regexp.MustCompile(`^\d{1,3}\. `)
+
+	// Anything that looks like an HTML tag or comment; a lone "<" in prose is left alone
+	htmlTagPattern = regexp.MustCompile(`<!--[\s\S]*?-->|</?[a-zA-Z][^>]*>`)
+)
+
+// sanitizeEventDescription escapes HTML in a validated description and, for markdown, drops links
+// outside the allow-list, so the stored source is safe to render. Escaping is idempotent: clients
+// that send back a description they received don't get it escaped twice. It also fills the derived
+// description_html.
+func sanitizeEventDescription(event *Event) {
+	if event.DescriptionFormat == "" {
+		event.DescriptionFormat = DescriptionFormatPlain
+	}
+
+	event.Description = html.EscapeString(html.UnescapeString(event.Description))
+	if event.DescriptionFormat == DescriptionFormatMarkdown {
+		event.Description = markdownLinkPattern.ReplaceAllStringFunc(event.Description, func(link string) string {
+			m := markdownLinkPattern.FindStringSubmatch(link)
+			if !allowedLinkURL(m[2]) {
+				return m[1] // Keep the text, lose the link
+			}
+			return link
+		})
+	}
+	event.DescriptionHTML = renderDescriptionHTML(event.Description, event.DescriptionFormat)
+}
+
+// allowedLinkURL reports whether an escaped markdown link target is an absolute http(s) or mailto URL
+func allowedLinkURL(escaped string) bool {
+	u, err := url.Parse(html.UnescapeString(escaped))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+// renderDescriptionHTML turns a stored (escaped, sanitized) description into HTML. Plain text
+// gets paragraphs and line breaks; markdown additionally supports bold, links and lists.
+func renderDescriptionHTML(source, format string) string {
+	var b strings.Builder
+	var paragraph []string
+	list := "" // "ul" or "ol" while inside a list
+
+	inline := func(s string) string {
+		if format != DescriptionFormatMarkdown {
+			return s
+		}
+		s = markdownBoldPattern.ReplaceAllString(s, "<strong>$1</strong>")
+		return markdownLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+			m := markdownLinkPattern.FindStringSubmatch(link)
+			if !allowedLinkURL(m[2]) {
+				return m[1] // Rows stored before sanitization existed
+			}
+			return `<a href="` + m[2] + `" rel="nofollow noopener noreferrer">` + m[1] + `</a>`
+		})
+	}
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+
+		if format == DescriptionFormatMarkdown {
+			kind, item := "", ""
+			if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+				kind, item = "ul", line[2:]
+			} else if loc := orderedItemPattern.FindStringIndex(line); loc != nil {
+				kind, item = "ol", line[loc[1]:]
+			}
+			if kind != "" {
+				flushParagraph()
+				if list != kind {
+					closeList()
+					b.WriteString("<" + kind + ">")
+					list = kind
+				}
+				b.WriteString("<li>" + inline(strings.TrimSpace(item)) + "</li>")
+				continue
+			}
+		}
+
+		closeList()
+		paragraph = append(paragraph, inline(line))
+	}
+	flushParagraph()
+	closeList()
+	return b.String()
+}
+
+// stripHTMLTags removes markup from text that clients render verbatim (comments)
+func stripHTMLTags(s string) string {
+	return strings.TrimSpace(htmlTagPattern.ReplaceAllString(s, ""))
+}
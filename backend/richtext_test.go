@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDescriptionHTML(t *testing.T) {
+	sanitized := func(description, format string) Event {
+		e := Event{Description: description, DescriptionFormat: format}
+		sanitizeEventDescription(&e)
+		return e
+	}
+
+	t.Run("Plain text is escaped", func(t *testing.T) {
+		e := sanitized("Hi <script>alert(1)</script>\nsecond line\n\nnew paragraph", "")
+		assert.Equal(t, DescriptionFormatPlain, e.DescriptionFormat)
+		assert.Equal(t, "<p>Hi &lt;script&gt;alert(1)&lt;/script&gt;<br>second line</p><p>new paragraph</p>", e.DescriptionHTML)
+	})
+
+	t.Run("Plain text ignores markdown", func(t *testing.T) {
+		assert.Equal(t, "<p>**not bold** [x](https://example.com)</p>",
+			sanitized("**not bold** [x](https://example.com)", DescriptionFormatPlain).DescriptionHTML)
+	})
+
+	t.Run("Markdown allow-list", func(t *testing.T) {
+		e := sanitized("Bring **snacks**, see [the map](https://example.com/a?b=1&c=2)\n- one\n- two\n1. first\n2. second", DescriptionFormatMarkdown)
+		assert.Equal(t, `<p>Bring <strong>snacks</strong>, see <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">the map</a></p>`+
+			`<ul><li>one</li><li>two</li></ul><ol><li>first</li><li>second</li></ol>`, e.DescriptionHTML)
+	})
+
+	t.Run("Unsafe links lose their target in the stored source", func(t *testing.T) {
+		for _, link := range []string{
+			"javascript:alert(1)",
+			"JaVaScRiPt:alert(document.cookie)",
+			"data:text/html;base64,PHNjcmlwdD4=",
+			"//evil.example.com",
+			"/relative",
+		} {
+			e := sanitized("Click [here]("+link+") now", DescriptionFormatMarkdown)
+			assert.NotContains(t, e.Description, "](", link)
+			assert.NotContains(t, e.DescriptionHTML, "<a", link)
+		}
+		e := sanitized("Mail [us](mailto:hi@example.com)", DescriptionFormatMarkdown)
+		assert.Contains(t, e.DescriptionHTML, `<a href="mailto:hi@example.com"`)
+	})
+
+	t.Run("Attribute injection stays inside the href", func(t *testing.T) {
+		e := sanitized(`[x](https://example.com/"onmouseover="alert(1))`, DescriptionFormatMarkdown)
+		assert.NotContains(t, e.DescriptionHTML, `"onmouseover`)
+	})
+
+	t.Run("Escaping is idempotent", func(t *testing.T) {
+		first := sanitized("Tom & Jerry <3", DescriptionFormatPlain)
+		again := sanitized(first.Description, DescriptionFormatPlain)
+		assert.Equal(t, first.Description, again.Description)
+		assert.Equal(t, "Tom &amp; Jerry &lt;3", again.Description)
+	})
+}
+
+func TestStripHTMLTags(t *testing.T) {
+	assert.Equal(t, "Hello alert(1) world", stripHTMLTags("Hello <script>alert(1)</script> world"))
+	assert.Equal(t, "a < b and c > d", stripHTMLTags("a < b and c > d"))
+	assert.Equal(t, "", stripHTMLTags("<img src=x onerror=alert(1)><!-- hi -->"))
+}
+
+func TestEventDescriptionSanitization(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.POST("/events/:id/comments", createEventComment)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	payload := func(description, format string) gin.H {
+		return gin.H{
+			"title": "Board games", "description": description, "description_format": format,
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+	}
+
+	var eventID int
+	t.Run("Create stores sanitized markdown and returns HTML", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token,
+			payload("Join **us** <script>alert(1)</script> [now](javascript:alert(1))", DescriptionFormatMarkdown))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		eventID = e.ID
+		assert.Equal(t, "<p>Join <strong>us</strong> &lt;script&gt;alert(1)&lt;/script&gt; now)</p>", e.DescriptionHTML)
+
+		w = doJSON(router, "GET", fmt.Sprintf("/api/events/%d", eventID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var fetched Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+		assert.Equal(t, DescriptionFormatMarkdown, fetched.DescriptionFormat)
+		assert.Equal(t, e.DescriptionHTML, fetched.DescriptionHTML)
+		assert.NotContains(t, fetched.Description, "javascript:")
+	})
+
+	t.Run("Update sanitizes too", func(t *testing.T) {
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", eventID), token,
+			payload(`<img src=x onerror="alert(1)"> plain now`, ""))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var stored, format string
+		require.NoError(t, testDB.QueryRow(`SELECT description, description_format FROM events WHERE id = ?`, eventID).Scan(&stored, &format))
+		assert.Equal(t, DescriptionFormatPlain, format)
+		assert.NotContains(t, stored, "<img")
+	})
+
+	t.Run("Oversized and unknown formats are rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload(strings.Repeat("a", 5001), ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", eventID), token, payload(strings.Repeat("a", 5001), ""))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "POST", "/api/events", token, payload("A perfectly fine description", "html"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Comments are stripped of markup", func(t *testing.T) {
+		path := fmt.Sprintf("/api/events/%d/comments", eventID)
+		w := doJSON(router, "POST", path, token, gin.H{"comment": "See you <b>there</b><script>alert(1)</script>"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var comment EventComment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+		assert.Equal(t, "See you therealert(1)", comment.Comment)
+
+		w = doJSON(router, "POST", path, token, gin.H{"comment": "<p></p>"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "POST", path, token, gin.H{"comment": strings.Repeat("a", 1001)})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
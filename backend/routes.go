@@ -0,0 +1,273 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// API versions, stored in the gin context under apiVersionKey so serializers can branch while a
+// response shape changes
+const (
+	APIVersionLegacy = 0 // Unversioned /api paths, kept while clients move to /api/v1
+	APIVersion1      = 1
+
+	apiVersionKey = "api_version"
+)
+
+// routeLimiters are the per-IP rate limiters shared by every mount of the API routes
+type routeLimiters struct {
+	auth, api, search, createEvent gin.HandlerFunc
+}
+
+// setupRouter builds the server's handler: global middleware, /health, the versioned API and the
+// sitemap. main and the integration tests both use it, so tests run against the real route table.
+func setupRouter(limiters routeLimiters) *gin.Engine {
+	router := gin.New()
+
+	// Add custom middleware
+	router.Use(RequestIDMiddleware())
+	router.Use(LoggerMiddleware())
+	router.Use(gin.Recovery())
+	router.Use(ErrorHandlerMiddleware())
+	router.Use(SecurityHeadersMiddleware())
+	router.Use(RequestSizeLimitMiddleware(appConfig.MaxRequestBytes))
+
+	// Response compression (set DISABLE_COMPRESSION=true when a reverse proxy already compresses)
+	if !appConfig.DisableCompression {
+		router.Use(CompressionMiddleware())
+	} else {
+		log.Println("⚠️  Response compression disabled")
+	}
+
+	// Database work is cancelled when it runs past DB_TIMEOUT or the client disconnects
+	router.Use(DBTimeoutMiddleware(appConfig.DBTimeout))
+
+	// CORS middleware (origins from env CORS_ORIGINS, comma-separated; validated by LoadConfig)
+	allowedOrigins := appConfig.CORSOrigins
+	if len(allowedOrigins) == 0 {
+		// Development defaults
+		allowedOrigins = []string{"http://localhost:3000", "http://localhost:5173"}
+		log.Println("⚠️  Using default CORS origins (development mode)")
+	} else {
+		log.Printf("✓ CORS origins: %v", allowedOrigins)
+	}
+
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     allowedOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-None-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID", "ETag", "Idempotent-Replayed"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Health check endpoint
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "timestamp": time.Now()})
+	})
+
+	// Every API route is served under /api (deprecated once LEGACY_API_SUNSET is set) and /api/v1
+	registerVersionedAPI(router, limiters)
+	router.GET("/sitemap.xml", limiters.api, getSitemap)
+	return router
+}
+
+// registerVersionedAPI mounts the API under /api and /api/v1. Both serve the same handlers; once
+// LEGACY_API_SUNSET is set the unversioned paths announce their deprecation.
+func registerVersionedAPI(router *gin.Engine, limiters routeLimiters) {
+	registerAPIRoutes(router.Group("/api", apiVersionMiddleware(APIVersionLegacy), legacyAPIDeprecation(appConfig.LegacyAPISunset)), limiters)
+	registerAPIRoutes(router.Group("/api/v1", apiVersionMiddleware(APIVersion1)), limiters)
+}
+
+// apiVersionMiddleware records which API version the request came in on
+func apiVersionMiddleware(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// apiVersion returns the API version of the request (APIVersionLegacy outside the API groups)
+func apiVersion(c *gin.Context) int {
+	return c.GetInt(apiVersionKey)
+}
+
+// legacyAPIDeprecation marks unversioned responses as deprecated (RFC 9745 Deprecation, RFC 8594
+// Sunset) and points at the /api/v1 twin. A zero sunset leaves responses untouched.
+func legacyAPIDeprecation(sunset time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !sunset.IsZero() {
+			c.Header("Deprecation", "true")
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+			if path := c.Request.URL.Path; len(path) >= len("/api") {
+				c.Header("Link", "</api/v1"+path[len("/api"):]+`>; rel="successor-version"`)
+			}
+		}
+		c.Next()
+	}
+}
+
+// registerAPIRoutes defines every API route relative to api; registerVersionedAPI mounts it once per version
+func registerAPIRoutes(api *gin.RouterGroup, limiters routeLimiters) {
+	// Public routes with rate limiting
+	api.POST("/auth/register", limiters.auth, register)
+	api.POST("/auth/login", limiters.auth, login)
+	api.POST("/auth/logout", limiters.auth, logout)                               // Logout (clears httpOnly cookie)
+	api.GET("/auth/verify-email", limiters.api, VerifyEmail)                      // Email verification
+	api.POST("/auth/resend-verification", limiters.auth, ResendVerificationEmail) // Resend verification
+	api.POST("/auth/forgot-password", limiters.auth, ForgotPassword)              // Password reset request
+	api.POST("/auth/reset-password", limiters.auth, ResetPassword)                // Password reset
+	api.POST("/auth/2fa", limiters.auth, verifyTwoFactorLogin)                    // Second login step for 2FA accounts
+	api.GET("/events", limiters.api, optionalAuthMiddleware(), getEvents)
+	api.GET("/events/map", limiters.api, optionalAuthMiddleware(), getEventMap)       // Points or clusters inside a bounding box
+	api.GET("/events/suggest", limiters.search, suggestEvents)                        // Search-box title suggestions, ?q=
+	api.GET("/events/agenda", limiters.api, optionalAuthMiddleware(), getEventAgenda) // Upcoming days in the viewer's timezone, ?days=&tz=
+	api.GET("/events/:id", limiters.api, optionalAuthMiddleware(), getEvent)
+	api.GET("/events/:id/participants", limiters.api, optionalAuthMiddleware(), getEventParticipants)
+	api.GET("/events/:id/join-eligibility", limiters.api, optionalAuthMiddleware(), getJoinEligibility)
+	api.GET("/public/events/:slug", limiters.api, optionalAuthMiddleware(), getPublicEvent)        // Public event access by slug
+	api.GET("/public/events/:slug/ics", limiters.api, optionalAuthMiddleware(), downloadEventICS)  // Download ICS calendar file
+	api.HEAD("/public/events/:slug/ics", limiters.api, optionalAuthMiddleware(), downloadEventICS) // Calendar subscriptions check for changes
+	api.GET("/public/events/:slug/meta", limiters.api, getPublicEventMeta)                         // OpenGraph / JSON-LD metadata
+	api.GET("/public/events/:slug/qr.png", limiters.api, optionalAuthMiddleware(), getEventQRCode) // QR code of the public link for posters
+	api.GET("/public/landing", limiters.api, getLandingPage)                                       // Counts and next events for city/category pages
+	api.GET("/public/stats", limiters.api, getPublicStats)                                         // Site-wide counters for the marketing landing page
+	api.GET("/users/:id/events", limiters.api, optionalAuthMiddleware(), getUserEvents)            // Same access rules as the profile
+	api.GET("/profile/:id", limiters.api, optionalAuthMiddleware(), getUserProfile)                // Honors the user's profile_visibility
+	api.GET("/search/places", limiters.search, searchPlaces)
+	api.GET("/search/reverse", limiters.search, reverseGeocode)
+	api.GET("/categories", getCategories)
+	api.GET("/announcements/active", limiters.api, getActiveAnnouncements)      // Banners to show now, ?lang= or Accept-Language
+	api.GET("/bootstrap", limiters.api, optionalAuthMiddleware(), getBootstrap) // Everything the SPA loads on start, in one call
+	api.GET("/groups/:slug", limiters.api, optionalAuthMiddleware(), getGroup)  // Group profile and upcoming events
+
+	// Protected routes (require authentication)
+	protected := api.Group("")
+	protected.Use(authMiddleware())
+	{
+		protected.POST("/events", limiters.createEvent, idempotent(), createEvent)
+		protected.PUT("/events/:id", updateEvent)
+		protected.DELETE("/events/:id", deleteEvent)
+		protected.POST("/events/:id/duplicate", limiters.createEvent, duplicateEvent)
+		protected.POST("/events/import", limiters.createEvent, importEvents)         // ICS or JSON, up to MaxImportEvents
+		protected.GET("/events/:id/export", exportEvent)                             // Portable JSON document
+		protected.POST("/events/import-json", limiters.createEvent, importEventJSON) // One exported event
+		protected.POST("/events/:id/join", idempotent(), joinEvent)
+		protected.PUT("/events/:id/questions", setEventQuestions) // Up to MaxEventQuestions asked when joining
+		protected.GET("/events/:id/answers", getEventAnswers)     // Organizer and admins only
+		protected.POST("/events/:id/report", reportEvent)
+		protected.DELETE("/events/:id/leave", leaveEvent)                  // Optional body: reason, note, share_identity
+		protected.GET("/events/:id/stats", getEventStats)                  // Organizer and admins only; leave reasons are aggregated
+		protected.POST("/events/:id/transfer", transferEvent)              // Organizer and admins; body: user_id, keep_as_participant
+		protected.POST("/events/:id/transfer/accept", acceptEventTransfer) // The user it was offered to, within 7 days
+		protected.DELETE("/events/:id/transfer", cancelEventTransfer)      // Withdraw (organizer, admins) or decline (the user it was offered to)
+		protected.POST("/events/:id/interest", markInterested)             // Non-binding; doesn't take a spot
+		protected.DELETE("/events/:id/interest", unmarkInterested)
+		protected.POST("/events/:id/hide", hideEvent) // "Not interested": drops the event from my listings only
+		protected.DELETE("/events/:id/hide", unhideEvent)
+		protected.PUT("/events/:id/participation", updateParticipation) // share_contact opt-in/out
+		protected.GET("/auth/me", getCurrentUser)
+		protected.GET("/profile", getOwnProfile)
+		protected.GET("/profile/activity", getOwnActivity)
+		protected.GET("/profile/hidden-events", getHiddenEvents)
+		protected.GET("/profile/stats", getOwnStats) // ?month=YYYY-MM, defaults to last month
+		protected.PUT("/profile", updateProfile)
+		protected.POST("/profile/pause-events", pauseOwnEvents) // Vacation mode: nobody can join my upcoming events
+		protected.DELETE("/profile/pause-events", resumeOwnEvents)
+		protected.POST("/profile/2fa/setup", limiters.auth, denyWhenImpersonating(), setupTwoFactor)
+		protected.POST("/profile/2fa/enable", limiters.auth, denyWhenImpersonating(), enableTwoFactor)
+		protected.DELETE("/profile/2fa", limiters.auth, denyWhenImpersonating(), disableTwoFactor)
+
+		// Group routes (owners moderate membership)
+		protected.POST("/groups", limiters.createEvent, createGroup)
+		protected.POST("/groups/:slug/join", joinGroup)
+		protected.DELETE("/groups/:slug/leave", leaveGroup)
+		protected.PUT("/groups/:slug/membership", updateGroupMembership) // notify opt-in/out
+		protected.GET("/groups/:slug/members", getGroupMembers)
+		protected.POST("/groups/:slug/members/:user_id/approve", approveGroupMember)
+		protected.DELETE("/groups/:slug/members/:user_id", removeGroupMember)
+
+		// Notification inbox, newest first (?unread=true&page=)
+		protected.GET("/notifications", getNotifications)
+		protected.PUT("/notifications/read-all", markAllNotificationsRead)
+		protected.PUT("/notifications/:id/read", markNotificationRead)
+
+		// Blocking routes
+		protected.POST("/users/:id/block", blockUser)
+		protected.DELETE("/users/:id/block", unblockUser)
+		protected.GET("/blocks", getBlockedUsers)
+
+		// Comment routes
+		protected.GET("/events/:id/comments", getEventComments)
+		protected.GET("/events/:id/comments/updates", withDBTimeout(commentLongPollTimeout), getEventCommentUpdates)
+		protected.POST("/events/:id/comments", createEventComment)
+		protected.PUT("/comments/:id", updateEventComment)
+		protected.DELETE("/comments/:id", deleteEventComment)
+
+		// Event feedback routes (participants rate past events)
+		protected.POST("/events/:id/feedback", submitEventFeedback)
+
+		// Attendance routes (organizer marks who showed up, participants can dispute)
+		protected.GET("/events/:id/attendance", getEventAttendance)
+		protected.PUT("/events/:id/attendance", markEventAttendance)
+		protected.POST("/events/:id/attendance/dispute", disputeAttendance)
+		protected.POST("/events/:id/checkin-code", createCheckinCode)
+		protected.POST("/events/:id/checkin", checkInToEvent)
+
+		// Participant export for check-in at the door (organizer and admins)
+		protected.GET("/events/:id/participants/export", withDBTimeout(appConfig.DBExportTimeout), exportEventParticipants)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	{
+		admin.GET("/users", adminGetUsers)
+		admin.PUT("/users/:id/block", adminBlockUser)
+		admin.PUT("/users/:id/unblock", adminUnblockUser)
+		admin.PUT("/users/:id/verify-email", adminVerifyUserEmail)
+		admin.PUT("/users/:id/role", adminSetUserRole)
+		admin.POST("/users/:id/merge", adminMergeUsers)
+		admin.GET("/users/email-collisions", adminGetEmailCollisions) // Accounts whose emails differ only by case
+		admin.GET("/events", adminGetAllEvents)
+		admin.GET("/events/duplicates", adminGetDuplicateEvents) // Same fingerprint, different organizers
+		admin.GET("/events/slug-issues", adminGetSlugIssues)     // Missing, duplicate or reserved slugs
+		admin.POST("/events/:id/regenerate-slug", adminRegenerateSlug)
+		admin.PUT("/events/:id/feature", adminFeatureEvent) // {"featured": true, "featured_until": optional}
+		admin.DELETE("/events/:id", adminDeleteEvent)
+		admin.PUT("/events/:id", adminUpdateEvent)
+		admin.POST("/users/bulk", adminBulkUsers)
+		admin.POST("/events/bulk", adminBulkEvents)
+		admin.POST("/events/:id/participants", adminAddParticipant)
+		admin.POST("/impersonate/:id", adminImpersonateUser)
+		admin.GET("/metrics", adminGetMetrics)
+		admin.POST("/maintenance/cleanup", adminRunCleanup)
+		admin.GET("/integrity", adminGetIntegrity)            // Orphaned rows by relationship, with sample IDs
+		admin.POST("/integrity/repair", adminRepairIntegrity) // Optional {"dry_run": true}
+		admin.GET("/config", adminGetConfig)
+		admin.GET("/reports", adminListReports)
+		admin.POST("/events/:id/reports/resolve", adminResolveEventReports)
+		admin.GET("/moderation/terms", adminGetModerationTerms)
+		admin.PUT("/moderation/terms", adminPutModerationTerms)
+		admin.GET("/moderation/queue", adminListModerationQueue)
+		admin.POST("/moderation/queue/:id/resolve", adminResolveModerationItem)
+		admin.GET("/webhooks", adminListWebhooks)
+		admin.POST("/webhooks", adminCreateWebhook)
+		admin.DELETE("/webhooks/:id", adminDeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", adminGetWebhookDeliveries)
+		admin.GET("/jobs", adminListJobs)
+		admin.POST("/jobs/:id/retry", adminRetryJob)
+		admin.GET("/email-log", adminGetEmailLog)
+		admin.GET("/announcements", adminListAnnouncements)
+		admin.POST("/announcements", adminCreateAnnouncement)
+		admin.PUT("/announcements/:id", adminUpdateAnnouncement)
+		admin.DELETE("/announcements/:id", adminDeleteAnnouncement)
+		admin.GET("/broadcast-routes", adminListBroadcastRoutes)
+		admin.POST("/broadcast-routes", adminCreateBroadcastRoute)
+		admin.DELETE("/broadcast-routes/:id", adminDeleteBroadcastRoute)
+	}
+}
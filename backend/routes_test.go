@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVersionedTestRouter is the production router without rate limits
+func newVersionedTestRouter() *gin.Engine {
+	pass := func(c *gin.Context) { c.Next() }
+	return setupRouter(routeLimiters{auth: pass, api: pass, search: pass, createEvent: pass})
+}
+
+func TestEveryAPIRouteHasAV1Twin(t *testing.T) {
+	routes := newVersionedTestRouter().Routes()
+	registered := map[string]bool{}
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	legacy := 0
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") || strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		legacy++
+		twin := "/api/v1/" + strings.TrimPrefix(route.Path, "/api/")
+		assert.True(t, registered[route.Method+" "+twin], "%s %s has no %s twin", route.Method, route.Path, twin)
+	}
+	assert.Greater(t, legacy, 50, "expected the full API to be registered")
+}
+
+func TestAPIVersionAndDeprecation(t *testing.T) {
+	original := appConfig.LegacyAPISunset
+	t.Cleanup(func() { appConfig.LegacyAPISunset = original })
+
+	get := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("No headers until a sunset is configured", func(t *testing.T) {
+		appConfig.LegacyAPISunset = time.Time{}
+		w := get(newVersionedTestRouter(), "/api/categories")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("Legacy paths announce their successor", func(t *testing.T) {
+		appConfig.LegacyAPISunset = time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+		router := newVersionedTestRouter()
+
+		w := get(router, "/api/categories")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Tue, 30 Jun 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v1/categories>; rel="successor-version"`, w.Header().Get("Link"))
+
+		w = get(router, "/api/v1/categories")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+		assert.Empty(t, w.Header().Get("Sunset"))
+	})
+
+	t.Run("Version is available to handlers", func(t *testing.T) {
+		router := gin.New()
+		for path, version := range map[string]int{"/api": APIVersionLegacy, "/api/v1": APIVersion1} {
+			router.Group(path, apiVersionMiddleware(version)).GET("/version", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"version": apiVersion(c)})
+			})
+		}
+		assert.JSONEq(t, `{"version":0}`, get(router, "/api/version").Body.String())
+		assert.JSONEq(t, `{"version":1}`, get(router, "/api/v1/version").Body.String())
+	})
+}
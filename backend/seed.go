@@ -0,0 +1,285 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// seedPassword is the login of every seeded user
+const seedPassword = "password123"
+
+// SeedOptions controls how much fake data seedDatabase generates. The same Seed always produces
+// the same users, events, participants and comments; event dates are relative to Now's day.
+type SeedOptions struct {
+	Users                int
+	Events               int
+	ParticipantsPerEvent int // Upper bound; each event gets a random number up to this
+	CommentsPerEvent     int // Upper bound, drawn from the event's participants
+	Seed                 int64
+	Now                  time.Time
+}
+
+// DefaultSeedOptions gives a map that looks lived-in without being slow to generate
+func DefaultSeedOptions() SeedOptions {
+	return SeedOptions{Users: 50, Events: 200, ParticipantsPerEvent: 12, CommentsPerEvent: 4, Seed: 42, Now: time.Now()}
+}
+
+// SeedResult counts the rows seedDatabase inserted
+type SeedResult struct {
+	Users        int
+	Events       int
+	Participants int
+	Comments     int
+}
+
+// Swiss towns events are scattered around, with a few km of jitter
+var seedPlaces = []struct {
+	name     string
+	lat, lon float64
+}{
+	{"Zürich", 47.3769, 8.5417}, {"Geneva", 46.2044, 6.1432}, {"Basel", 47.5596, 7.5886},
+	{"Bern", 46.9480, 7.4474}, {"Lausanne", 46.5197, 6.6323}, {"Lucerne", 47.0502, 8.3093},
+	{"Lugano", 46.0037, 8.9511}, {"St. Gallen", 47.4245, 9.3767}, {"Interlaken", 46.6863, 7.8632},
+	{"Chur", 46.8508, 9.5320}, {"Zermatt", 46.0207, 7.7491}, {"Winterthur", 47.4988, 8.7237},
+}
+
+var (
+	seedFirstNames = []string{"Anna", "Luca", "Sofia", "Noah", "Mia", "Elias", "Lea", "Matteo", "Emma", "Leon",
+		"Chiara", "David", "Laura", "Jonas", "Sara", "Nico", "Julia", "Marco", "Nina", "Samuel"}
+	seedLastNames = []string{"Müller", "Meier", "Schmid", "Keller", "Weber", "Huber", "Rossi", "Favre",
+		"Bianchi", "Brunner", "Baumann", "Frei", "Gerber", "Moser", "Fischer"}
+	seedLanguages = []string{"de", "fr", "it", "en", "rm", "es", "pt"}
+
+	seedTitles = map[string][]string{
+		"social_drinks":       {"Afterwork Drinks", "Lakeside Apéro", "Wine Tasting Evening", "Pub Quiz Night"},
+		"sports_fitness":      {"Morning Run", "Beach Volleyball", "Bouldering Session", "Sunday Bike Ride"},
+		"food_dining":         {"Fondue Night", "Street Food Tour", "Cooking Together", "Brunch Club"},
+		"business_networking": {"Startup Breakfast", "Freelancer Meetup", "Tech Talk & Beers"},
+		"gaming_hobbies":      {"Board Games Night", "Photography Walk", "Chess in the Park", "Knitting Circle"},
+		"learning_skills":     {"Language Exchange", "Python for Beginners", "Sketching Workshop"},
+		"adventure_travel":    {"Alpine Hike", "Via Ferrata Day", "Lake Swim", "Snowshoe Tour"},
+		"parents_kids":        {"Playground Meetup", "Family Picnic", "Kids' Science Morning"},
+	}
+	seedDescriptions = []string{
+		"Everyone is welcome, no experience needed. Just show up and say hi!",
+		"A relaxed get-together to meet new people. I'll be wearing a red cap.",
+		"Bring good vibes and something to drink. We'll stay as long as the weather holds.",
+		"Small group, friendly crowd. Message me in the comments if you're running late.",
+	}
+	seedComments = []string{
+		"Looking forward to it!", "Can I bring a friend?", "Is there parking nearby?",
+		"I'll be 10 minutes late, save me a spot.", "What should I bring?", "See you there 👋",
+	}
+
+	seedSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// seedDatabase fills target with deterministic fake users, upcoming events, participants and
+// comments in a single transaction. Used by `go run . seed` and by tests needing large datasets.
+func seedDatabase(target *sql.DB, opts SeedOptions) (SeedResult, error) {
+	var result SeedResult
+	rng := rand.New(rand.NewSource(opts.Seed))
+	day := opts.Now.UTC().Truncate(24 * time.Hour)
+
+	// One hash for everyone: hashing per user would dominate the run time
+	password, err := hashPassword(seedPassword)
+	if err != nil {
+		return result, err
+	}
+
+	tx, err := target.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	// Numbering continues after earlier runs so -force never collides on emails or slugs
+	var emailOffset, slugOffset int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM users WHERE email LIKE '%@seed.veidly.local'`).Scan(&emailOffset); err != nil {
+		return result, err
+	}
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM events`).Scan(&slugOffset); err != nil {
+		return result, err
+	}
+
+	type seedUser struct {
+		id   int64
+		name string
+	}
+	users := make([]seedUser, 0, opts.Users)
+	for i := 0; i < opts.Users; i++ {
+		first := seedFirstNames[rng.Intn(len(seedFirstNames))]
+		last := seedLastNames[rng.Intn(len(seedLastNames))]
+		name := first + " " + last
+		email := fmt.Sprintf("%s.%d@seed.veidly.local", strings.ToLower(first), emailOffset+i+1)
+		languages := pickSeedLanguages(rng)
+		res, err := tx.Exec(`
+			INSERT INTO users (email, password, name, bio, languages, email_verified, birth_year, gender)
+			VALUES (?, ?, ?, ?, ?, 1, ?, ?)
+		`, email, password, name, fmt.Sprintf("Hi, I'm %s from %s.", first, seedPlaces[rng.Intn(len(seedPlaces))].name),
+			languages, 1960+rng.Intn(45), []string{"male", "female", "other", "unspecified"}[rng.Intn(4)])
+		if err != nil {
+			return result, fmt.Errorf("user %d: %w", i+1, err)
+		}
+		id, _ := res.LastInsertId()
+		users = append(users, seedUser{id: id, name: name})
+	}
+	result.Users = len(users)
+	if len(users) == 0 {
+		return result, tx.Commit()
+	}
+
+	insertEventStmt, err := tx.Prepare(`
+		INSERT INTO events (user_id, title, description, category, latitude, longitude, location_name,
+		                    start_time, end_time, creator_name, max_participants, gender_restriction,
+		                    age_min, age_max, event_languages, slug, participant_count,
+		                    allow_unregistered_users, require_verified_to_view, require_verified_to_join)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'any', 18, 99, ?, ?, ?, 1, 0, 0)
+	`)
+	if err != nil {
+		return result, err
+	}
+	defer insertEventStmt.Close()
+	joinStmt, err := tx.Prepare(`INSERT INTO event_participants (event_id, user_id) VALUES (?, ?)`)
+	if err != nil {
+		return result, err
+	}
+	defer joinStmt.Close()
+	commentStmt, err := tx.Prepare(`INSERT INTO event_comments (event_id, user_id, comment) VALUES (?, ?, ?)`)
+	if err != nil {
+		return result, err
+	}
+	defer commentStmt.Close()
+
+	for i := 0; i < opts.Events; i++ {
+		organizer := users[rng.Intn(len(users))]
+		category := Categories[rng.Intn(len(Categories))]
+		titles := seedTitles[category]
+		title := titles[rng.Intn(len(titles))]
+		place := seedPlaces[rng.Intn(len(seedPlaces))]
+
+		// Over the next 30 days, between 8:00 and 21:45, on the quarter hour
+		start := day.AddDate(0, 0, 1+rng.Intn(30)).Add(time.Duration(32+rng.Intn(56)) * 15 * time.Minute)
+		end := start.Add(time.Duration(1+rng.Intn(4)) * time.Hour)
+		maxParticipants := 0 // unlimited
+		if rng.Intn(3) > 0 {
+			maxParticipants = 4 + rng.Intn(20)
+		}
+
+		// Participants: distinct users other than the organizer, within capacity
+		want := 0
+		if opts.ParticipantsPerEvent > 0 {
+			want = rng.Intn(opts.ParticipantsPerEvent + 1)
+		}
+		if maxParticipants > 0 && want > maxParticipants {
+			want = maxParticipants
+		}
+		var participants []seedUser
+		for _, idx := range rng.Perm(len(users)) {
+			if len(participants) == want {
+				break
+			}
+			if users[idx].id != organizer.id {
+				participants = append(participants, users[idx])
+			}
+		}
+
+		slug := fmt.Sprintf("%s-%d", strings.Trim(seedSlugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-"), slugOffset+i+1)
+		res, err := insertEventStmt.Exec(organizer.id, title, seedDescriptions[rng.Intn(len(seedDescriptions))], category,
+			place.lat+(rng.Float64()-0.5)*0.05, place.lon+(rng.Float64()-0.5)*0.05, place.name,
+			start, end, organizer.name, maxParticipants, pickSeedLanguages(rng), slug, len(participants))
+		if err != nil {
+			return result, fmt.Errorf("event %d: %w", i+1, err)
+		}
+		eventID, _ := res.LastInsertId()
+		result.Events++
+
+		for _, p := range participants {
+			if _, err := joinStmt.Exec(eventID, p.id); err != nil {
+				return result, fmt.Errorf("participant of event %d: %w", eventID, err)
+			}
+		}
+		result.Participants += len(participants)
+
+		if opts.CommentsPerEvent > 0 && len(participants) > 0 {
+			for n := rng.Intn(opts.CommentsPerEvent + 1); n > 0; n-- {
+				author := participants[rng.Intn(len(participants))]
+				if _, err := commentStmt.Exec(eventID, author.id, seedComments[rng.Intn(len(seedComments))]); err != nil {
+					return result, fmt.Errorf("comment on event %d: %w", eventID, err)
+				}
+				result.Comments++
+			}
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// pickSeedLanguages returns one to three distinct language codes, comma-separated
+func pickSeedLanguages(rng *rand.Rand) string {
+	n := 1 + rng.Intn(3)
+	picked := make([]string, 0, n)
+	for _, idx := range rng.Perm(len(seedLanguages))[:n] {
+		picked = append(picked, seedLanguages[idx])
+	}
+	return strings.Join(picked, ",")
+}
+
+// runSeedCommand implements `go run . seed [-users N] [-events N] [-seed S] [-force]`.
+// It only runs with ENVIRONMENT=development and, unless -force is given, only on a database
+// without regular users, so it can't mix fake accounts into real data.
+func runSeedCommand(args []string) error {
+	opts := DefaultSeedOptions()
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.IntVar(&opts.Users, "users", opts.Users, "number of users to create")
+	fs.IntVar(&opts.Events, "events", opts.Events, "number of events to create")
+	fs.IntVar(&opts.ParticipantsPerEvent, "participants", opts.ParticipantsPerEvent, "maximum participants per event")
+	fs.IntVar(&opts.CommentsPerEvent, "comments", opts.CommentsPerEvent, "maximum comments per event")
+	fs.Int64Var(&opts.Seed, "seed", opts.Seed, "random seed; the same seed gives the same data")
+	force := fs.Bool("force", false, "seed even if the database already has non-admin users")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if opts.Users < 0 || opts.Events < 0 || opts.ParticipantsPerEvent < 0 || opts.CommentsPerEvent < 0 {
+		return errors.New("counts must not be negative")
+	}
+
+	if err := checkSeedAllowed(db, appConfig, *force); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	result, err := seedDatabase(db, opts)
+	if err != nil {
+		return err
+	}
+	log.Printf("🌱 Seeded %d users, %d events, %d participants and %d comments in %v (seed %d)",
+		result.Users, result.Events, result.Participants, result.Comments, time.Since(start).Round(time.Millisecond), opts.Seed)
+	log.Printf("🌱 Log in as any *@seed.veidly.local user with password %q", seedPassword)
+	return nil
+}
+
+// checkSeedAllowed refuses to seed outside development, or into a database with real users unless forced
+func checkSeedAllowed(target *sql.DB, cfg *Config, force bool) error {
+	if cfg.Environment != "development" {
+		return fmt.Errorf("seeding requires ENVIRONMENT=development (got %q)", cfg.Environment)
+	}
+	if force {
+		return nil
+	}
+	var users int
+	if err := target.QueryRow(`SELECT COUNT(*) FROM users WHERE is_admin = 0`).Scan(&users); err != nil {
+		return err
+	}
+	if users > 0 {
+		return fmt.Errorf("database already has %d non-admin users; pass -force to seed anyway", users)
+	}
+	return nil
+}
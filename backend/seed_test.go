@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedDatabase(t *testing.T) {
+	now := time.Now().UTC()
+	opts := SeedOptions{Users: 30, Events: 120, ParticipantsPerEvent: 10, CommentsPerEvent: 3, Seed: 7, Now: now}
+
+	snapshot := func(testDB *sql.DB) []string {
+		rows, err := testDB.Query(`
+			SELECT u.email || ' ' || e.title || ' ' || e.slug || ' ' || e.start_time || ' ' || e.participant_count
+			FROM events e JOIN users u ON u.id = e.user_id ORDER BY e.id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		var lines []string
+		for rows.Next() {
+			var line string
+			require.NoError(t, rows.Scan(&line))
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	testDB := setupTestDB(t)
+	result, err := seedDatabase(testDB, opts)
+	require.NoError(t, err)
+	first := snapshot(testDB)
+
+	t.Run("Counts and consistency", func(t *testing.T) {
+		assert.Equal(t, 30, result.Users)
+		assert.Equal(t, 120, result.Events)
+		assert.Positive(t, result.Participants)
+		assert.Positive(t, result.Comments)
+
+		var users, unverified, participants, comments, mismatched, overCapacity, selfJoined int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE email_verified = 0 OR languages = '') FROM users`).Scan(&users, &unverified))
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM event_participants`).Scan(&participants))
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM event_comments`).Scan(&comments))
+		require.NoError(t, testDB.QueryRow(`
+			SELECT COUNT(*) FROM events e
+			WHERE participant_count != (SELECT COUNT(*) FROM event_participants p WHERE p.event_id = e.id)
+		`).Scan(&mismatched))
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM events WHERE max_participants > 0 AND participant_count > max_participants`).Scan(&overCapacity))
+		require.NoError(t, testDB.QueryRow(`
+			SELECT COUNT(*) FROM event_participants p JOIN events e ON e.id = p.event_id WHERE p.user_id = e.user_id
+		`).Scan(&selfJoined))
+		assert.Equal(t, 30, users)
+		assert.Zero(t, unverified)
+		assert.Equal(t, result.Participants, participants)
+		assert.Equal(t, result.Comments, comments)
+		assert.Zero(t, mismatched, "participant_count matches the rows")
+		assert.Zero(t, overCapacity)
+		assert.Zero(t, selfJoined, "organizers don't join their own events")
+
+		var categories int
+		var earliest, latest string
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(DISTINCT category), MIN(start_time), MAX(start_time) FROM events`).Scan(&categories, &earliest, &latest))
+		assert.Equal(t, len(Categories), categories)
+		assert.Greater(t, earliest, now.Format("2006-01-02"))
+		assert.Less(t, latest, now.AddDate(0, 0, 32).Format("2006-01-02"))
+	})
+
+	t.Run("Browsable through the listing", func(t *testing.T) {
+		db = testDB
+		useTestConfig(t, func(cfg *Config) { cfg.EventListLimit = 25 })
+		events, err := queryEventList(context.Background(), EventFilter{}, 0, false, false)
+		require.NoError(t, err)
+		assert.NotEmpty(t, events)
+	})
+
+	t.Run("Forced second run doesn't collide", func(t *testing.T) {
+		again, err := seedDatabase(testDB, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 120, again.Events)
+	})
+	cleanupTestDB(testDB)
+
+	t.Run("Same seed gives the same data", func(t *testing.T) {
+		testDB := setupTestDB(t)
+		defer cleanupTestDB(testDB)
+		_, err := seedDatabase(testDB, opts)
+		require.NoError(t, err)
+		assert.Equal(t, first, snapshot(testDB))
+	})
+}
+
+func TestCheckSeedAllowed(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+
+	development := DefaultConfig()
+	development.Environment = "development"
+	production := DefaultConfig()
+	production.Environment = "production"
+
+	createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	assert.NoError(t, checkSeedAllowed(testDB, development, false), "admins alone don't count")
+	assert.ErrorContains(t, checkSeedAllowed(testDB, production, true), "ENVIRONMENT=development")
+	assert.ErrorContains(t, checkSeedAllowed(testDB, DefaultConfig(), false), "ENVIRONMENT=development")
+
+	createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	assert.ErrorContains(t, checkSeedAllowed(testDB, development, false), "-force")
+	assert.NoError(t, checkSeedAllowed(testDB, development, true))
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sitemapTTL            = 5 * time.Minute
+	sitemapMaxURLs        = 50000 // Limit per sitemap file in the sitemaps.org protocol
+	metaDescriptionLength = 160
+)
+
+// publicEventCondition restricts a query to events anonymous visitors (and crawlers) may see.
+// Cancelled, registration-only, verified-only and draft events never appear in search engines.
+const publicEventCondition = `e.slug IS NOT NULL AND e.slug != ''
+	AND e.cancelled_at IS NULL
+	AND e.allow_unregistered_users = 1
+	AND e.require_verified_to_view = 0
+	AND e.hidden_pending_review = 0
+	AND e.published = 1`
+
+// frontendBaseURL is the origin event pages are served from
+func frontendBaseURL() string {
+	return strings.TrimRight(appConfig.BaseURL, "/")
+}
+
+func publicEventURL(slug string) string {
+	return frontendBaseURL() + "/event/" + slug
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapCache holds the rendered sitemap; crawlers hit it often and it only needs to be roughly fresh
+type sitemapCache struct {
+	mu      sync.Mutex
+	body    []byte
+	expires time.Time
+}
+
+var publicSitemap = &sitemapCache{}
+
+func (s *sitemapCache) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = nil
+}
+
+func (s *sitemapCache) get(build func() ([]byte, error)) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.body != nil && time.Now().Before(s.expires) {
+		return s.body, nil
+	}
+	body, err := build()
+	if err != nil {
+		return nil, err
+	}
+	s.body = body
+	s.expires = time.Now().Add(sitemapTTL)
+	return body, nil
+}
+
+func buildSitemap() ([]byte, error) {
+	rows, err := db.Query(`
+		SELECT e.slug, e.created_at
+		FROM events e
+		WHERE e.start_time >= datetime('now')
+		AND `+publicEventCondition+`
+		ORDER BY e.start_time ASC
+		LIMIT ?
+	`, sitemapMaxURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urlSet := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: []sitemapURL{}}
+	for rows.Next() {
+		var slug string
+		var createdAt time.Time
+		if err := rows.Scan(&slug, &createdAt); err != nil {
+			return nil, err
+		}
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     publicEventURL(slug),
+			LastMod: createdAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	body, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// getSitemap serves /sitemap.xml listing every public upcoming event page
+func getSitemap(c *gin.Context) {
+	body, err := publicSitemap.get(buildSitemap)
+	if err != nil {
+		log.Printf("❌ Failed to build sitemap: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build sitemap"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// truncateText shortens text to at most limit runes on a word boundary, adding an ellipsis
+func truncateText(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)[:limit-1]
+	if cut := strings.LastIndex(string(runes), " "); cut > 0 {
+		return strings.TrimRight(string(runes)[:cut], " ,.;:") + "…"
+	}
+	return string(runes) + "…"
+}
+
+// getPublicEventMeta returns the data the SSR layer needs for OpenGraph tags and a schema.org
+// Event JSON-LD block. Only events that are public to anonymous visitors have metadata.
+func getPublicEventMeta(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+
+	var e Event
+	var startTime, endTime sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT e.title, e.description, e.latitude, e.longitude, e.start_time, e.end_time,
+		       e.creator_name, e.hide_organizer_until_joined, e.location_name, e.address
+		FROM events e
+		WHERE e.slug = ? AND `+publicEventCondition, slug).Scan(
+		&e.Title, &e.Description, &e.Latitude, &e.Longitude, &startTime, &endTime,
+		&e.CreatorName, nullable(&e.HideOrganizerUntilJoined), &e.LocationName, &e.Address,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching event meta for %s: %v", slug, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+
+	// Stored text is HTML-escaped; the SSR layer escapes again when rendering tags
+	title := html.UnescapeString(e.Title)
+	description := truncateText(html.UnescapeString(e.Description), metaDescriptionLength)
+	pageURL := publicEventURL(slug)
+
+	start, err := parseDateTime(startTime.String)
+	if err != nil {
+		log.Printf("❌ Invalid start_time on event %s: %v", slug, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+
+	// Organizers who hide themselves until joined stay hidden from crawlers too
+	organizerName := ""
+	if !e.HideOrganizerUntilJoined {
+		organizerName = html.UnescapeString(e.CreatorName)
+	}
+
+	place := gin.H{
+		"@type": "Place",
+		"geo": gin.H{
+			"@type":     "GeoCoordinates",
+			"latitude":  e.Latitude,
+			"longitude": e.Longitude,
+		},
+	}
+	if e.LocationName != "" {
+		place["name"] = html.UnescapeString(e.LocationName)
+	}
+	if e.Address != "" {
+		place["address"] = html.UnescapeString(e.Address)
+	}
+
+	jsonLD := gin.H{
+		"@context":            "https://schema.org",
+		"@type":               "Event",
+		"name":                title,
+		"description":         description,
+		"startDate":           start.Format(time.RFC3339),
+		"eventStatus":         "https://schema.org/EventScheduled",
+		"eventAttendanceMode": "https://schema.org/OfflineEventAttendanceMode",
+		"location":            place,
+		"url":                 pageURL,
+	}
+	if endTime.Valid && endTime.String != "" {
+		if end, err := parseDateTime(endTime.String); err == nil {
+			jsonLD["endDate"] = end.Format(time.RFC3339)
+		}
+	}
+	if organizerName != "" {
+		jsonLD["organizer"] = gin.H{"@type": "Person", "name": organizerName}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"title":          title,
+		"description":    description,
+		"url":            pageURL,
+		"latitude":       e.Latitude,
+		"longitude":      e.Longitude,
+		"start_time":     start.Format(time.RFC3339),
+		"organizer_name": organizerName,
+		"open_graph": gin.H{
+			"og:type":        "website",
+			"og:title":       title,
+			"og:description": description,
+			"og:url":         pageURL,
+			"og:site_name":   "Veidly",
+		},
+		"json_ld": jsonLD,
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemapAndEventMeta(t *testing.T) {
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestConfig(t, func(cfg *Config) { cfg.BaseURL = "https://veidly.com/" })
+
+	router := gin.New()
+	router.GET("/sitemap.xml", getSitemap)
+	router.GET("/api/public/events/:slug/meta", getPublicEventMeta)
+
+	organizerID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	setSlug := func(id int64, slug string) {
+		_, err := testDB.Exec(`UPDATE events SET slug = ? WHERE id = ?`, slug, id)
+		require.NoError(t, err)
+	}
+
+	public := createTestEvent(t, testDB, organizerID, "Public Picnic")
+	setSlug(public, "public-picnic")
+	_, err := testDB.Exec(`UPDATE events SET description = ?, location_name = 'Lindenhof', end_time = ? WHERE id = ?`,
+		strings.Repeat("Bring snacks &amp; a blanket. ", 20), time.Now().Add(27*time.Hour).Format(time.RFC3339), public)
+	require.NoError(t, err)
+
+	verifiedOnly := createTestEvent(t, testDB, organizerID, "Verified Only")
+	setSlug(verifiedOnly, "verified-only")
+	_, err = testDB.Exec(`UPDATE events SET require_verified_to_view = 1 WHERE id = ?`, verifiedOnly)
+	require.NoError(t, err)
+
+	membersOnly := createTestEvent(t, testDB, organizerID, "Members Only")
+	setSlug(membersOnly, "members-only")
+	_, err = testDB.Exec(`UPDATE events SET allow_unregistered_users = 0 WHERE id = ?`, membersOnly)
+	require.NoError(t, err)
+
+	createPastEvent(t, testDB, organizerID, "past-public", time.Now().Add(-48*time.Hour), nil)
+
+	t.Run("Sitemap lists only public upcoming events", func(t *testing.T) {
+		w := doJSON(router, "GET", "/sitemap.xml", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+
+		var urlSet sitemapURLSet
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &urlSet))
+		require.Len(t, urlSet.URLs, 1)
+		assert.Equal(t, "https://veidly.com/event/public-picnic", urlSet.URLs[0].Loc)
+		_, err := time.Parse(time.RFC3339, urlSet.URLs[0].LastMod)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Sitemap is cached", func(t *testing.T) {
+		hidden := createTestEvent(t, testDB, organizerID, "Added Later")
+		setSlug(hidden, "added-later")
+		w := doJSON(router, "GET", "/sitemap.xml", "", nil)
+		assert.NotContains(t, w.Body.String(), "added-later")
+
+		publicSitemap.Invalidate()
+		w = doJSON(router, "GET", "/sitemap.xml", "", nil)
+		assert.Contains(t, w.Body.String(), "added-later")
+	})
+
+	t.Run("Meta endpoint returns a schema.org Event", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/public-picnic/meta", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var meta struct {
+			Title         string                 `json:"title"`
+			Description   string                 `json:"description"`
+			OrganizerName string                 `json:"organizer_name"`
+			OpenGraph     map[string]string      `json:"open_graph"`
+			JSONLD        map[string]interface{} `json:"json_ld"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &meta))
+		assert.Equal(t, "Public Picnic", meta.Title)
+		assert.LessOrEqual(t, len([]rune(meta.Description)), metaDescriptionLength)
+		assert.True(t, strings.HasPrefix(meta.Description, "Bring snacks & a blanket."))
+		assert.Equal(t, "Test User", meta.OrganizerName)
+		assert.Equal(t, "https://veidly.com/event/public-picnic", meta.OpenGraph["og:url"])
+
+		ld := meta.JSONLD
+		assert.Equal(t, "https://schema.org", ld["@context"])
+		assert.Equal(t, "Event", ld["@type"])
+		assert.Equal(t, "Public Picnic", ld["name"])
+		for _, field := range []string{"startDate", "endDate"} {
+			_, err := time.Parse(time.RFC3339, ld[field].(string))
+			assert.NoError(t, err, field)
+		}
+		location := ld["location"].(map[string]interface{})
+		assert.Equal(t, "Place", location["@type"])
+		assert.Equal(t, "Lindenhof", location["name"])
+		geo := location["geo"].(map[string]interface{})
+		assert.Equal(t, "GeoCoordinates", geo["@type"])
+		assert.Equal(t, 46.8805, geo["latitude"])
+		organizer := ld["organizer"].(map[string]interface{})
+		assert.Equal(t, "Person", organizer["@type"])
+	})
+
+	t.Run("Hidden organizer is omitted", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE events SET hide_organizer_until_joined = 1 WHERE id = ?`, public)
+		require.NoError(t, err)
+		w := doJSON(router, "GET", "/api/public/events/public-picnic/meta", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "Test User")
+	})
+
+	t.Run("Non-public events have no metadata", func(t *testing.T) {
+		for _, slug := range []string{"verified-only", "members-only", "missing"} {
+			w := doJSON(router, "GET", "/api/public/events/"+slug+"/meta", "", nil)
+			assert.Equal(t, http.StatusNotFound, w.Code, slug)
+		}
+	})
+}
+
+func TestTruncateText(t *testing.T) {
+	assert.Equal(t, "short", truncateText("short", 10))
+	assert.Equal(t, "one two…", truncateText("one two three", 10))
+	assert.Equal(t, "abcdefghi…", truncateText("abcdefghijklmnop", 10))
+	assert.Equal(t, "a b", truncateText(" a \n b ", 10))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of the 403 a blocked or suspended user gets from login and authMiddleware
+const (
+	ErrCodeAccountBlocked   = "ACCOUNT_BLOCKED"
+	ErrCodeAccountSuspended = "ACCOUNT_SUSPENDED"
+)
+
+// Admin audit log actions for blocks
+const (
+	AuditUserBlocked   = "user_blocked"
+	AuditUserUnblocked = "user_unblocked"
+)
+
+const (
+	maxSuspensionDays    = 365
+	maxBlockReasonLength = 500
+)
+
+// SuspendUserRequest is the optional body of PUT /api/admin/users/:id/block. Without duration_days
+// the block is permanent; the reason is shown to the user when they are turned away.
+type SuspendUserRequest struct {
+	DurationDays int    `json:"duration_days"`
+	Reason       string `json:"reason"`
+}
+
+// suspensionEnd validates the request and returns when the block ends (nil for permanent)
+func (r *SuspendUserRequest) suspensionEnd(now time.Time) (*time.Time, error) {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if utf8.RuneCountInString(r.Reason) > maxBlockReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d characters", maxBlockReasonLength)
+	}
+	if r.DurationDays < 0 || r.DurationDays > maxSuspensionDays {
+		return nil, fmt.Errorf("duration_days must be between 1 and %d (omit it for a permanent block)", maxSuspensionDays)
+	}
+	if r.DurationDays == 0 {
+		return nil, nil
+	}
+	until := now.Add(time.Duration(r.DurationDays) * 24 * time.Hour).UTC()
+	return &until, nil
+}
+
+// accountBlock is a user's block state: permanent when is_blocked is set without blocked_until,
+// a suspension while now is before blocked_until
+type accountBlock struct {
+	blocked bool
+	until   sql.NullTime
+	reason  string
+}
+
+// active reports whether the user is turned away at now
+func (b accountBlock) active(now time.Time) bool {
+	return b.blocked && (!b.until.Valid || now.Before(b.until.Time))
+}
+
+// expired reports whether a suspension is over but hasn't been lifted in the database yet
+func (b accountBlock) expired(now time.Time) bool {
+	return b.blocked && b.until.Valid && !now.Before(b.until.Time)
+}
+
+// respond sends the 403 for an active block; message is used for permanent blocks
+func (b accountBlock) respond(c *gin.Context, message string) {
+	body := gin.H{"error": message, "code": ErrCodeAccountBlocked}
+	if b.until.Valid {
+		until := b.until.Time.UTC()
+		body["error"] = "Account is suspended until " + until.Format(time.RFC3339)
+		body["code"] = ErrCodeAccountSuspended
+		body["blocked_until"] = until
+	}
+	if b.reason != "" {
+		body["reason"] = b.reason
+	}
+	c.JSON(http.StatusForbidden, body)
+}
+
+// liftSuspension clears a suspension that has run out; housekeeping does the same for everyone
+func liftSuspension(ctx context.Context, userID int, now time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET is_blocked = 0, blocked_until = NULL, block_reason = NULL
+		WHERE id = ? AND is_blocked = 1 AND blocked_until IS NOT NULL AND blocked_until <= ?
+	`, userID, now.UTC())
+	return err
+}
+
+// liftExpiredSuspensions clears every suspension that has run out, so queries that filter on
+// is_blocked (digests, admin notices) see the user as active again
+func liftExpiredSuspensions(now time.Time) (int64, error) {
+	result, err := db.Exec(`
+		UPDATE users SET is_blocked = 0, blocked_until = NULL, block_reason = NULL
+		WHERE is_blocked = 1 AND blocked_until IS NOT NULL AND blocked_until <= ?
+	`, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// errUserNotFound is returned by setUserBlock when there is no such user
+var errUserNotFound = errors.New("user not found")
+
+// setUserBlock blocks (until nil: permanently) or unblocks a user and audit-logs it in one transaction
+func setUserBlock(ctx context.Context, adminID, userID int, block bool, until *time.Time, reason string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	if err := applyUserBlock(tx, adminID, userID, block, until, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyUserBlock writes the block state and its audit entry using exec
+func applyUserBlock(exec sqlExecer, adminID, userID int, block bool, until *time.Time, reason string) error {
+	var result sql.Result
+	var err error
+	if block {
+		result, err = exec.Exec(`
+			UPDATE users SET is_blocked = 1, blocked_until = ?, block_reason = NULLIF(?, '') WHERE id = ?
+		`, until, reason, userID)
+	} else {
+		result, err = exec.Exec(`
+			UPDATE users SET is_blocked = 0, blocked_until = NULL, block_reason = NULL WHERE id = ?
+		`, userID)
+	}
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errUserNotFound
+	}
+
+	action, details := AuditUserUnblocked, gin.H{}
+	if block {
+		action = AuditUserBlocked
+		details = gin.H{"blocked_until": until, "reason": reason}
+	}
+	return recordAdminAudit(exec, adminID, action, userID, details)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSuspension(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.POST("/api/auth/login", login)
+	router.GET("/api/auth/me", authMiddleware(), getCurrentUser)
+	admin := router.Group("/api/admin", authMiddleware(), adminMiddleware())
+	admin.GET("/users", adminGetUsers)
+	admin.PUT("/users/:id/block", adminBlockUser)
+	admin.PUT("/users/:id/unblock", adminUnblockUser)
+
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	userID := createTestUser(t, testDB, "troll@example.com", "Troll", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "troll@example.com", EmailVerified: true})
+	blockPath := fmt.Sprintf("/api/admin/users/%d/block", userID)
+
+	refusal := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		t.Helper()
+		require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	me := func() (int, map[string]interface{}) {
+		w := doJSON(router, "GET", "/api/auth/me", token, nil)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("A suspended user is told why and until when", func(t *testing.T) {
+		w := doJSON(router, "PUT", blockPath, adminToken, gin.H{"duration_days": 7, "reason": "Spamming comments"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(router, "GET", "/api/auth/me", token, nil)
+		resp := refusal(w)
+		assert.Equal(t, ErrCodeAccountSuspended, resp["code"])
+		assert.Equal(t, "Spamming comments", resp["reason"])
+		until, err := time.Parse(time.RFC3339, resp["blocked_until"].(string))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), until, time.Minute)
+
+		w = doJSON(router, "POST", "/api/auth/login", "", gin.H{"email": "troll@example.com", "password": "password123"})
+		resp = refusal(w)
+		assert.Equal(t, ErrCodeAccountSuspended, resp["code"])
+		assert.Equal(t, "Spamming comments", resp["reason"])
+
+		var action, details string
+		require.NoError(t, testDB.QueryRow(`SELECT action, details FROM admin_audit_log WHERE target_user_id = ?`, userID).Scan(&action, &details))
+		assert.Equal(t, AuditUserBlocked, action)
+		assert.Contains(t, details, "Spamming comments")
+	})
+
+	t.Run("The admin user list shows the suspension", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/admin/users", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list struct {
+			Users []User `json:"users"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		for _, u := range list.Users {
+			if u.ID == int(userID) {
+				assert.True(t, u.IsBlocked)
+				require.NotNil(t, u.BlockedUntil)
+				assert.Equal(t, "Spamming comments", u.BlockReason)
+				return
+			}
+		}
+		t.Fatal("suspended user missing from the list")
+	})
+
+	t.Run("The suspension ends on its own", func(t *testing.T) {
+		_, err := testDB.Exec(`UPDATE users SET blocked_until = ? WHERE id = ?`, time.Now().Add(-time.Minute).UTC(), userID)
+		require.NoError(t, err)
+
+		code, _ := me()
+		assert.Equal(t, http.StatusOK, code)
+		var isBlocked bool
+		var reason *string
+		require.NoError(t, testDB.QueryRow(`SELECT is_blocked, block_reason FROM users WHERE id = ?`, userID).Scan(&isBlocked, &reason))
+		assert.False(t, isBlocked, "lifted at the next request")
+		assert.Nil(t, reason)
+	})
+
+	t.Run("Housekeeping lifts suspensions nobody came back for", func(t *testing.T) {
+		otherID := createTestUser(t, testDB, "gone@example.com", "Gone", "password123", false)
+		_, err := testDB.Exec(`UPDATE users SET is_blocked = 1, blocked_until = ? WHERE id = ?`, time.Now().Add(-time.Hour).UTC(), otherID)
+		require.NoError(t, err)
+		lifted, err := liftExpiredSuspensions(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), lifted)
+	})
+
+	t.Run("Blocks without a duration stay permanent", func(t *testing.T) {
+		w := doJSON(router, "PUT", blockPath, adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		code, resp := me()
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, ErrCodeAccountBlocked, resp["code"])
+		assert.Equal(t, "User account is blocked", resp["error"])
+		assert.NotContains(t, resp, "blocked_until")
+
+		lifted, err := liftExpiredSuspensions(time.Now().Add(10 * 365 * 24 * time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, lifted)
+
+		require.Equal(t, http.StatusOK, doJSON(router, "PUT", fmt.Sprintf("/api/admin/users/%d/unblock", userID), adminToken, nil).Code)
+		code, _ = me()
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Invalid suspensions are rejected", func(t *testing.T) {
+		w := doJSON(router, "PUT", blockPath, adminToken, gin.H{"duration_days": maxSuspensionDays + 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "PUT", "/api/admin/users/99999/block", adminToken, gin.H{"duration_days": 1})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
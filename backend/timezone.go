@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // Bundle the zone database so LoadLocation works on minimal hosts
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultEventTimezone is used when neither the event nor its organizer's profile names a zone
+const DefaultEventTimezone = "UTC"
+
+// dateTimeFormats are the start/end time layouts accepted from clients and found in the database
+var dateTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05.999999999Z07:00", // How the sqlite driver stores time.Time values
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// loadTimezone resolves an IANA zone name; "Local" and empty names are rejected since they
+// depend on the server rather than the event
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// resolveEventTimezone fills event.Timezone when the client didn't send one, from the organizer's
+// profile and then DefaultEventTimezone, and returns the validated zone
+func resolveEventTimezone(ctx context.Context, event *Event, organizerID int) (*time.Location, error) {
+	event.Timezone = strings.TrimSpace(event.Timezone)
+	if event.Timezone == "" {
+		var profileZone sql.NullString
+		if err := db.QueryRowContext(ctx, `SELECT timezone FROM users WHERE id = ?`, organizerID).Scan(&profileZone); err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		event.Timezone = DefaultEventTimezone
+		if profileZone.String != "" {
+			event.Timezone = profileZone.String
+		}
+	}
+	return loadTimezone(event.Timezone)
+}
+
+// respondTimezoneError reports a resolveEventTimezone failure
+func respondTimezoneError(c *gin.Context, err error) {
+	if errors.Is(err, ErrInvalidTimezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("❌ Error resolving event timezone: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve event timezone"})
+}
+
+// parseEventTime reads a client-supplied start/end time. Values with an offset are instants;
+// wall-clock values ("2025-07-15T18:00") are read in the event's zone. The result is in UTC.
+func parseEventTime(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, format := range dateTimeFormats {
+		if strings.Contains(format, "Z07:00") {
+			if t, err := time.Parse(format, value); err == nil {
+				return t.UTC(), nil
+			}
+			continue
+		}
+		if t, err := time.ParseInLocation(format, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse datetime: %s", value)
+}
+
+// formatStoredTime renders a stored start/end time as an RFC3339 UTC instant; legacy values
+// without an offset are taken as UTC and unparseable ones are returned unchanged
+func formatStoredTime(value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, format := range dateTimeFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return value
+}
+
+// setEventTimes writes parsed start/end instants back onto the event as RFC3339 UTC, the
+// format every response uses
+func setEventTimes(event *Event, start time.Time, end *time.Time) {
+	event.StartTime = start.UTC().Format(time.RFC3339)
+	event.EndTime = ""
+	if end != nil {
+		event.EndTime = end.UTC().Format(time.RFC3339)
+	}
+}
+
+// normalizeStoredEventTimes rewrites legacy start/end times (client strings, read as UTC when
+// they have no offset) as UTC time values, the way new events are stored. Run once, when the
+// timezone column is added.
+func normalizeStoredEventTimes(db *sql.DB) {
+	rows, err := db.Query(`SELECT id, start_time, COALESCE(end_time, '') FROM events`)
+	if err != nil {
+		log.Printf("⚠️  Could not read event times for normalization: %v", err)
+		return
+	}
+
+	type eventTimes struct {
+		start time.Time
+		end   *time.Time
+	}
+	updates := map[int]eventTimes{}
+	for rows.Next() {
+		var id int
+		var start, end string
+		if err := rows.Scan(&id, &start, &end); err != nil {
+			continue
+		}
+		startTime, err := parseEventTime(start, time.UTC)
+		if err != nil {
+			continue
+		}
+		times := eventTimes{start: startTime}
+		if endTime, err := parseEventTime(end, time.UTC); err == nil && end != "" {
+			times.end = &endTime
+		}
+		updates[id] = times
+	}
+	rows.Close()
+
+	for id, times := range updates {
+		if _, err := db.Exec(`UPDATE events SET start_time = ?, end_time = ? WHERE id = ?`, times.start, times.end, id); err != nil {
+			log.Printf("⚠️  Could not normalize times of event %d: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventTime(t *testing.T) {
+	lisbon, err := loadTimezone("Europe/Lisbon")
+	require.NoError(t, err)
+
+	got, err := parseEventTime("2030-07-15T18:00", lisbon)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 7, 15, 17, 0, 0, 0, time.UTC), got, "summer time is UTC+1")
+
+	got, err = parseEventTime("2030-01-15T18:00:00", lisbon)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 1, 15, 18, 0, 0, 0, time.UTC), got, "winter time is UTC+0")
+
+	got, err = parseEventTime("2030-07-15T18:00:00+02:00", lisbon)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 7, 15, 16, 0, 0, 0, time.UTC), got, "explicit offsets win over the zone")
+
+	_, err = loadTimezone("Mars/Olympus")
+	assert.ErrorIs(t, err, ErrInvalidTimezone)
+	_, err = loadTimezone("Local")
+	assert.ErrorIs(t, err, ErrInvalidTimezone)
+
+	assert.Equal(t, "2030-07-15T18:00:00Z", formatStoredTime("2030-07-15T18:00"), "legacy values are read as UTC")
+}
+
+func TestEventTimezones(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	// Creates the same event in several zones
+	useTestConfig(t, func(cfg *Config) { cfg.SimilarEventThreshold = 0 })
+
+	router := gin.New()
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	router.GET("/api/public/events/:slug/ics", downloadEventICS)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.PUT("/events/:id", updateEvent)
+	protected.PUT("/profile", updateProfile)
+
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	year := time.Now().Year() + 1
+	payload := func(timezone string) gin.H {
+		return gin.H{
+			"title": "Sunset drinks", "description": "Drinks by the river", "timezone": timezone,
+			"category": "social_drinks", "latitude": 38.7223, "longitude": -9.1393,
+			"start_time": fmt.Sprintf("%d-07-15T18:00", year), "creator_name": "User",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		}
+	}
+	wantStart := fmt.Sprintf("%d-07-15T17:00:00Z", year)
+
+	var created Event
+	t.Run("Local wall clock is stored as UTC", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload("Europe/Lisbon"))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, wantStart, created.StartTime)
+		assert.Equal(t, "Europe/Lisbon", created.Timezone)
+
+		var stored, zone string
+		require.NoError(t, testDB.QueryRow(`SELECT start_time, timezone FROM events WHERE id = ?`, created.ID).Scan(&stored, &zone))
+		assert.Equal(t, wantStart, formatStoredTime(stored))
+		assert.Equal(t, "Europe/Lisbon", zone)
+
+		w = doJSON(router, "GET", fmt.Sprintf("/api/events/%d", created.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var fetched Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+		assert.Equal(t, wantStart, fetched.StartTime)
+		assert.Equal(t, "Europe/Lisbon", fetched.Timezone)
+	})
+
+	t.Run("ICS carries the UTC instant", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/public/events/"+created.Slug+"/ics", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("DTSTART:%d0715T170000Z\r\n", year))
+		assert.Contains(t, w.Body.String(), "X-WR-TIMEZONE:Europe/Lisbon\r\n")
+	})
+
+	t.Run("Update keeps the event's zone when none is sent", func(t *testing.T) {
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", created.ID), token, payload(""))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var stored, zone string
+		require.NoError(t, testDB.QueryRow(`SELECT start_time, timezone FROM events WHERE id = ?`, created.ID).Scan(&stored, &zone))
+		assert.Equal(t, wantStart, formatStoredTime(stored))
+		assert.Equal(t, "Europe/Lisbon", zone)
+	})
+
+	t.Run("Invalid zones are rejected", func(t *testing.T) {
+		w := doJSON(router, "POST", "/api/events", token, payload("Europe/Atlantis"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "timezone": "Nowhere"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Profile zone is the default for new events", func(t *testing.T) {
+		w := doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "User", "timezone": "America/New_York"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var user User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		assert.Equal(t, "America/New_York", user.Timezone)
+
+		w = doJSON(router, "POST", "/api/events", token, payload(""))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		assert.Equal(t, "America/New_York", e.Timezone)
+		assert.Equal(t, fmt.Sprintf("%d-07-15T22:00:00Z", year), e.StartTime)
+	})
+}
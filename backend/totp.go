@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, supported by all common authenticator apps)
+const (
+	totpPeriod        = 30 // seconds per step
+	totpDigits        = 6
+	totpSkewSteps     = 1 // accept codes from one step before/after to tolerate clock drift
+	totpIssuer        = "Veidly"
+	recoveryCodeCount = 10
+)
+
+// totpNow returns the current time used for TOTP verification (overridden in tests)
+var totpNow = time.Now
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret creates a new random base32-encoded TOTP secret (160 bits)
+func generateTOTPSecret() (string, error) {
+	bytes := make([]byte, 20)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(bytes), nil
+}
+
+// generateTOTPCode computes the TOTP code for a secret at the given time
+func generateTOTPCode(secret string, t time.Time) (string, error) {
+	return generateHOTPCode(secret, uint64(t.Unix()/totpPeriod))
+}
+
+// generateHOTPCode computes the HOTP code (RFC 4226) for a secret and counter
+func generateHOTPCode(secret string, counter uint64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// verifyTOTPCode checks a code against the secret, allowing ±totpSkewSteps of clock drift
+func verifyTOTPCode(secret, code string, t time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return false
+	}
+
+	counter := t.Unix() / totpPeriod
+	for skew := -totpSkewSteps; skew <= totpSkewSteps; skew++ {
+		expected, err := generateHOTPCode(secret, uint64(counter+int64(skew)))
+		if err != nil {
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOTPAuthURI builds the otpauth:// URI shown as a QR code by the frontend
+func buildOTPAuthURI(secret, accountName string) string {
+	label := url.PathEscape(totpIssuer + ":" + accountName)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// totpEncryptionKey derives the AES-256 key used to encrypt TOTP secrets at rest.
+// Uses TOTP_ENCRYPTION_KEY if set, otherwise falls back to the JWT secret.
+func totpEncryptionKey() ([]byte, error) {
+	material := strings.TrimSpace(os.Getenv("TOTP_ENCRYPTION_KEY"))
+	if material == "" {
+		if len(jwtSecret) == 0 {
+			return nil, errors.New("no encryption key available for TOTP secrets")
+		}
+		material = string(jwtSecret)
+	}
+	key := sha256.Sum256([]byte(material))
+	return key[:], nil
+}
+
+// encryptTOTPSecret encrypts a TOTP secret with AES-GCM for storage in the database
+func encryptTOTPSecret(secret string) (string, error) {
+	key, err := totpEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTOTPSecret reverses encryptTOTPSecret
+func decryptTOTPSecret(encrypted string) (string, error) {
+	key, err := totpEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted TOTP secret too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// generateRecoveryCodes creates a set of one-time recovery codes (format: xxxx-xxxx-xxxx-xxxx)
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		bytes := make([]byte, 8)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(bytes)
+		codes = append(codes, fmt.Sprintf("%s-%s-%s-%s", raw[0:4], raw[4:8], raw[8:12], raw[12:16]))
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode normalizes and hashes a recovery code for storage/lookup
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
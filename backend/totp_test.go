@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 test secret "12345678901234567890" in base32
+const rfcTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// RFC 6238 Appendix B vectors (SHA1), truncated to 6 digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, v := range vectors {
+		code, err := generateTOTPCode(rfcTestSecret, time.Unix(v.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "unix time %d", v.unix)
+	}
+
+	_, err := generateTOTPCode("not base32!", time.Now())
+	assert.Error(t, err)
+}
+
+func TestVerifyTOTPCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	code, err := generateTOTPCode(rfcTestSecret, now)
+	require.NoError(t, err)
+
+	assert.True(t, verifyTOTPCode(rfcTestSecret, code, now))
+	// ±1 step of clock skew is tolerated
+	assert.True(t, verifyTOTPCode(rfcTestSecret, code, now.Add(totpPeriod*time.Second)))
+	assert.True(t, verifyTOTPCode(rfcTestSecret, code, now.Add(-totpPeriod*time.Second)))
+	// ±2 steps is not
+	assert.False(t, verifyTOTPCode(rfcTestSecret, code, now.Add(2*totpPeriod*time.Second)))
+	assert.False(t, verifyTOTPCode(rfcTestSecret, code, now.Add(-2*totpPeriod*time.Second)))
+
+	assert.False(t, verifyTOTPCode(rfcTestSecret, "000000", now))
+	assert.False(t, verifyTOTPCode(rfcTestSecret, "12345", now))
+	assert.False(t, verifyTOTPCode(rfcTestSecret, "", now))
+}
+
+func TestBuildOTPAuthURI(t *testing.T) {
+	uri := buildOTPAuthURI(rfcTestSecret, "user@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Veidly:user@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcTestSecret)
+	assert.Contains(t, uri, "issuer=Veidly")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
+
+func TestEncryptTOTPSecret(t *testing.T) {
+	setupJWT()
+
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	encrypted, err := encryptTOTPSecret(secret)
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, secret, "secret must not be stored in plaintext")
+
+	// Each encryption uses a fresh nonce
+	encrypted2, err := encryptTOTPSecret(secret)
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, encrypted2)
+
+	decrypted, err := decryptTOTPSecret(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, secret, decrypted)
+
+	_, err = decryptTOTPSecret("garbage")
+	assert.Error(t, err)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Len(t, code, 19)
+		assert.False(t, seen[code], "recovery codes must be unique")
+		seen[code] = true
+	}
+
+	// Hashing ignores case, dashes and surrounding whitespace
+	assert.Equal(t, hashRecoveryCode("abcd-ef01-2345-6789"), hashRecoveryCode(" ABCDEF0123456789 "))
+	assert.NotEqual(t, hashRecoveryCode("abcd-ef01-2345-6789"), hashRecoveryCode("abcd-ef01-2345-6780"))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatedAtTracking(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := gin.New()
+	router.GET("/api/events", optionalAuthMiddleware(), getEvents)
+	router.GET("/api/events/:id", optionalAuthMiddleware(), getEvent)
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.PUT("/events/:id", updateEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	protected.DELETE("/events/:id/leave", leaveEvent)
+	protected.GET("/profile", getOwnProfile)
+	protected.PUT("/profile", updateProfile)
+
+	userID := createTestUser(t, testDB, "organizer@example.com", "Organizer", "password123", false)
+	joinerID := createTestUser(t, testDB, "joiner@example.com", "Joiner", "password123", false)
+	token, _ := generateToken(User{ID: int(userID), Email: "organizer@example.com", EmailVerified: true})
+	joinerToken, _ := generateToken(User{ID: int(joinerID), Email: "joiner@example.com", EmailVerified: true})
+
+	edited := createTestEvent(t, testDB, userID, "Edited")
+	joined := createTestEvent(t, testDB, userID, "Joined")
+	cancelled := createTestEvent(t, testDB, userID, "Cancelled")
+	untouched := createTestEvent(t, testDB, userID, "Untouched")
+
+	fetch := func(t *testing.T, id int64) Event {
+		w := doJSON(router, "GET", fmt.Sprintf("/api/events/%d", id), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var e Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		return e
+	}
+	delta := func(t *testing.T, since time.Time) []string {
+		w := doJSON(router, "GET", "/api/events?updated_since="+url.QueryEscape(since.Format(time.RFC3339Nano)), "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		titles := []string{}
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	// Rows from before the column existed report their creation time
+	before := fetch(t, untouched)
+	assert.Equal(t, before.CreatedAt, before.UpdatedAt)
+
+	since := time.Now()
+
+	t.Run("Editing bumps updated_at", func(t *testing.T) {
+		w := doJSON(router, "PUT", fmt.Sprintf("/api/events/%d", edited), token, gin.H{
+			"title": "Edited", "description": "Now with snacks",
+			"category": "social_drinks", "latitude": 52.2297, "longitude": 21.0122,
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Organizer",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.False(t, resp.UpdatedAt.Before(since))
+
+		e := fetch(t, edited)
+		assert.True(t, e.UpdatedAt.After(e.CreatedAt))
+		assert.False(t, e.UpdatedAt.Before(since))
+	})
+
+	t.Run("Joining and leaving bump updated_at", func(t *testing.T) {
+		path := fmt.Sprintf("/api/events/%d", joined)
+		w := doJSON(router, "POST", path+"/join", joinerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		afterJoin := fetch(t, joined).UpdatedAt
+		assert.False(t, afterJoin.Before(since))
+
+		w = doJSON(router, "DELETE", path+"/leave", joinerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.False(t, fetch(t, joined).UpdatedAt.Before(afterJoin))
+	})
+
+	t.Run("Delta sync returns only touched events", func(t *testing.T) {
+		ok, err := cancelEventRecord(testDB, cancelled, int(userID))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		assert.ElementsMatch(t, []string{"Edited", "Joined", "Cancelled"}, delta(t, since))
+		assert.Empty(t, delta(t, time.Now().Add(time.Minute)))
+		assert.NotContains(t, listedTitles(t, router, ""), "Cancelled", "the regular listing still hides cancelled events")
+	})
+
+	t.Run("Malformed updated_since", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/events?updated_since=yesterday", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Profile updates bump updated_at", func(t *testing.T) {
+		w := doJSON(router, "GET", "/api/profile", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var profile struct {
+			User User `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		require.NotNil(t, profile.User.UpdatedAt)
+		assert.Equal(t, profile.User.CreatedAt, *profile.User.UpdatedAt)
+
+		w = doJSON(router, "PUT", "/api/profile", token, gin.H{"name": "Organizer", "bio": "Hosts board game nights"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var user User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		require.NotNil(t, user.UpdatedAt)
+		assert.True(t, user.UpdatedAt.After(user.CreatedAt))
+	})
+}
@@ -30,6 +30,12 @@ func generateSlug(title string) string {
 		slug = strings.TrimRight(slug, "-")
 	}
 
+	// Titles with hardly any letters or digits (emoji, punctuation) get a random base instead of
+	// a bare "-<suffix>"
+	if len(slug) < minSlugBaseLength {
+		slug = strings.Trim(slug+"-"+generateRandomString(4), "-")
+	}
+
 	// Add random suffix to ensure uniqueness
 	suffix := generateRandomString(8)
 	slug = slug + "-" + suffix
@@ -37,6 +43,18 @@ func generateSlug(title string) string {
 	return slug
 }
 
+// spotsLeft returns the remaining capacity of an event, or nil when it has no participant limit
+func spotsLeft(maxParticipants, participantCount int) *int {
+	if maxParticipants <= 0 {
+		return nil
+	}
+	left := maxParticipants - participantCount
+	if left < 0 {
+		left = 0
+	}
+	return &left
+}
+
 // generateRandomString creates a random string of specified length
 func generateRandomString(length int) string {
 	bytes := make([]byte, length/2+1)
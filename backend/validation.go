@@ -12,28 +12,42 @@ import (
 
 // Validation errors
 var (
-	ErrTitleTooLong       = errors.New("title too long (max 200 characters)")
-	ErrTitleTooShort      = errors.New("title too short (min 3 characters)")
-	ErrDescriptionTooLong = errors.New("description too long (max 5000 characters)")
-	ErrDescriptionTooShort = errors.New("description too short (min 10 characters)")
-	ErrInvalidLatitude    = errors.New("invalid latitude (must be between -90 and 90)")
-	ErrInvalidLongitude   = errors.New("invalid longitude (must be between -180 and 180)")
-	ErrInvalidParticipants = errors.New("max_participants must be positive or zero")
-	ErrInvalidAgeRange    = errors.New("age_min must be less than or equal to age_max")
-	ErrInvalidAgeValues   = errors.New("age values must be between 0 and 150")
-	ErrEventInPast        = errors.New("event cannot start in the past (more than 1 hour ago)")
-	ErrEndBeforeStart     = errors.New("end time must be after start time")
-	ErrInvalidEmail       = errors.New("invalid email address")
-	ErrPasswordTooShort   = errors.New("password must be at least 8 characters")
-	ErrPasswordTooLong    = errors.New("password must be less than 100 characters")
-	ErrNameTooShort       = errors.New("name must be at least 2 characters")
-	ErrNameTooLong        = errors.New("name too long (max 100 characters)")
-	ErrInvalidContact     = errors.New("contact method too short (min 3 characters)")
+	ErrTitleTooLong             = errors.New("title too long (max 200 characters)")
+	ErrTitleTooShort            = errors.New("title too short (min 3 characters)")
+	ErrDescriptionTooLong       = errors.New("description too long (max 5000 characters)")
+	ErrDescriptionTooShort      = errors.New("description too short (min 10 characters)")
+	ErrInvalidDescriptionFormat = errors.New("description_format must be one of: plain, markdown")
+	ErrInvalidTimezone          = errors.New("timezone must be an IANA time zone name such as Europe/Lisbon")
+	ErrInvalidLatitude          = errors.New("invalid latitude (must be between -90 and 90)")
+	ErrInvalidLongitude         = errors.New("invalid longitude (must be between -180 and 180)")
+	ErrLocationNameTooLong      = errors.New("location_name too long (max 200 characters)")
+	ErrAddressTooLong           = errors.New("address too long (max 300 characters)")
+	ErrInvalidParticipants      = errors.New("max_participants must be positive or zero")
+	ErrInvalidAgeRange          = errors.New("age_min must be less than or equal to age_max")
+	ErrInvalidAgeValues         = errors.New("age values must be between 0 and 150")
+	ErrEventInPast              = errors.New("event must start at least 15 minutes from now")
+	ErrEventTooFarAhead         = errors.New("event starts too far in the future")
+	ErrEndBeforeStart           = errors.New("end time must be after start time")
+	ErrEventTooLong             = errors.New("event can last at most 7 days")
+	ErrDeadlineAfterStart       = errors.New("join deadline must be before start time")
+	ErrInvalidEmail             = errors.New("invalid email address")
+	ErrPasswordTooShort         = errors.New("password must be at least 8 characters")
+	ErrPasswordTooLong          = fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+	ErrNameTooShort             = errors.New("name must be at least 2 characters")
+	ErrNameTooLong              = errors.New("name too long (max 100 characters)")
+	ErrInvalidContact           = errors.New("contact method too short (min 3 characters)")
+	ErrInvalidProfileVisibility = errors.New("profile_visibility must be one of: public, registered, hidden")
+	ErrInvalidGender            = errors.New("gender must be one of: male, female, other, unspecified")
+	ErrInvalidThreema           = errors.New("threema must be an 8-character Threema ID (letters and digits)")
+	ErrInvalidBirthYear         = fmt.Errorf("birth_year must make you between %d and %d years old", MinUserAge, MaxUserAge)
 )
 
 // Email regex for basic validation
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
+// threemaIDRegex matches a Threema ID after upper-casing
+var threemaIDRegex = regexp.MustCompile(`^[A-Z0-9]{8}$`)
+
 // ValidateEvent validates event data before creation/update
 func ValidateEvent(event *Event, startTime, endTime *time.Time) error {
 	// Title validation
@@ -44,12 +58,8 @@ func ValidateEvent(event *Event, startTime, endTime *time.Time) error {
 		return ErrTitleTooLong
 	}
 
-	// Description validation
-	if utf8.RuneCountInString(event.Description) < 10 {
-		return ErrDescriptionTooShort
-	}
-	if utf8.RuneCountInString(event.Description) > 5000 {
-		return ErrDescriptionTooLong
+	if err := ValidateEventDescription(event); err != nil {
+		return err
 	}
 
 	// Coordinate validation
@@ -73,16 +83,10 @@ func ValidateEvent(event *Event, startTime, endTime *time.Time) error {
 		return ErrInvalidAgeRange
 	}
 
-	// Time validation
+	// Time validation; this checks new events, updates call ValidateEventSchedule with the stored start
 	if startTime != nil {
-		// Allow events starting up to 1 hour in the past (for timezone/clock differences)
-		if startTime.Before(time.Now().Add(-1 * time.Hour)) {
-			return ErrEventInPast
-		}
-
-		// Validate end time if provided
-		if endTime != nil && endTime.Before(*startTime) {
-			return ErrEndBeforeStart
+		if err := ValidateEventSchedule(*startTime, endTime, nil, event.LongRange); err != nil {
+			return err
 		}
 	}
 
@@ -97,6 +101,9 @@ func ValidateEvent(event *Event, startTime, endTime *time.Time) error {
 	if !validCategory {
 		return fmt.Errorf("invalid category: %s", event.Category)
 	}
+	if err := ValidateEventAttributes(event); err != nil {
+		return err
+	}
 
 	// Gender restriction validation
 	validGender := []string{"any", "male", "female", "non-binary"}
@@ -119,11 +126,66 @@ func ValidateEvent(event *Event, startTime, endTime *time.Time) error {
 		return ErrNameTooLong
 	}
 
+	if err := ValidateEventLocation(event); err != nil {
+		return err
+	}
+
+	if err := ValidateEventPrice(event); err != nil {
+		return err
+	}
+
+	if err := ValidateNetworkJoinLimit(event); err != nil {
+		return err
+	}
+
+	if err := ValidateReservedSpots(event); err != nil {
+		return err
+	}
+
+	languages, err := ValidateLanguages(event.EventLanguages)
+	if err != nil {
+		return err
+	}
+	event.EventLanguages = languages
+
 	// Sanitize HTML to prevent XSS
 	event.Title = html.EscapeString(event.Title)
-	event.Description = html.EscapeString(event.Description)
 	event.CreatorName = html.EscapeString(event.CreatorName)
+	sanitizeEventDescription(event)
+
+	return nil
+}
+
+// ValidateEventDescription checks the description length and format. Updates call it on its own;
+// pair it with sanitizeEventDescription before storing.
+func ValidateEventDescription(event *Event) error {
+	if utf8.RuneCountInString(event.Description) < 10 {
+		return ErrDescriptionTooShort
+	}
+	if utf8.RuneCountInString(event.Description) > 5000 {
+		return ErrDescriptionTooLong
+	}
+	switch event.DescriptionFormat {
+	case "", DescriptionFormatPlain, DescriptionFormatMarkdown:
+		return nil
+	}
+	return ErrInvalidDescriptionFormat
+}
 
+// ValidateEventLocation checks and sanitizes the optional place name and street address.
+// Both may be empty; the coordinates remain the source of truth for the map.
+func ValidateEventLocation(event *Event) error {
+	event.LocationName = strings.TrimSpace(event.LocationName)
+	event.Address = strings.TrimSpace(event.Address)
+	if utf8.RuneCountInString(event.LocationName) > 200 {
+		return ErrLocationNameTooLong
+	}
+	if utf8.RuneCountInString(event.Address) > 300 {
+		return ErrAddressTooLong
+	}
+
+	event.LocationName = html.EscapeString(event.LocationName)
+	event.Address = html.EscapeString(event.Address)
 	return nil
 }
 
@@ -140,7 +202,7 @@ func ValidateUser(user *User) error {
 	if len(user.Password) < 8 {
 		return ErrPasswordTooShort
 	}
-	if len(user.Password) > 100 {
+	if len(user.Password) > maxPasswordBytes {
 		return ErrPasswordTooLong
 	}
 
@@ -185,5 +247,54 @@ func ValidateProfileUpdate(req *ProfileUpdateRequest) error {
 		req.Bio = html.EscapeString(req.Bio)
 	}
 
+	languages, err := ValidateLanguages(req.Languages)
+	if err != nil {
+		return err
+	}
+	req.Languages = languages
+
+	// Visibility validation (empty keeps the current setting)
+	if req.ProfileVisibility != "" && !isValidProfileVisibility(req.ProfileVisibility) {
+		return ErrInvalidProfileVisibility
+	}
+
+	if req.Gender != "" && !isValidGender(req.Gender) {
+		return ErrInvalidGender
+	}
+
+	// Timezone validation (empty keeps the current zone)
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	if req.Timezone != "" {
+		if _, err := loadTimezone(req.Timezone); err != nil {
+			return err
+		}
+	}
+
+	// Birth year validation (0 clears it)
+	if req.BirthYear != nil && *req.BirthYear != 0 {
+		age := ageInYear(*req.BirthYear, time.Now().UTC().Year())
+		if age < MinUserAge || age > MaxUserAge {
+			return ErrInvalidBirthYear
+		}
+	}
+
+	// Threema ID validation (empty clears it); IDs are case-insensitive and stored upper-case
+	if req.Threema != nil {
+		threema := strings.ToUpper(strings.TrimSpace(*req.Threema))
+		if threema != "" && !threemaIDRegex.MatchString(threema) {
+			return ErrInvalidThreema
+		}
+		req.Threema = &threema
+	}
+
 	return nil
 }
+
+// isValidProfileVisibility checks a profile_visibility value against the allowed levels
+func isValidProfileVisibility(visibility string) bool {
+	switch visibility {
+	case ProfileVisibilityPublic, ProfileVisibilityRegistered, ProfileVisibilityHidden:
+		return true
+	}
+	return false
+}
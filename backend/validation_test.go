@@ -19,10 +19,19 @@ func TestValidateProfileUpdate(t *testing.T) {
 			req: ProfileUpdateRequest{
 				Name:      "John Doe",
 				Bio:       "Software developer interested in hiking",
-				Languages: "English,Spanish",
+				Languages: "en,es",
 			},
 			wantErr: false,
 		},
+		{
+			name: "Language names instead of codes",
+			req: ProfileUpdateRequest{
+				Name:      "John Doe",
+				Languages: "English,Spanish",
+			},
+			wantErr:     true,
+			errContains: "english, spanish",
+		},
 		{
 			name: "Name too short",
 			req: ProfileUpdateRequest{
@@ -92,9 +101,9 @@ func TestValidateProfileUpdate(t *testing.T) {
 }
 
 func TestValidateEventComprehensive(t *testing.T) {
-	startTime, _ := time.Parse(time.DateTime, "2025-12-01 10:00:00")
-	endTime, _ := time.Parse(time.DateTime, "2025-12-01 12:00:00")
-	invalidEndTime, _ := time.Parse(time.DateTime, "2025-12-01 09:00:00") // before start
+	startTime := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+	endTime := startTime.Add(2 * time.Hour)
+	invalidEndTime := startTime.Add(-time.Hour) // before start
 
 	tests := []struct {
 		name        string
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook event types
+const (
+	WebhookEventCreated       = "event.created"
+	WebhookEventUpdated       = "event.updated"
+	WebhookEventCancelled     = "event.cancelled"
+	WebhookParticipantJoined  = "participant.joined"
+	webhookSignatureHeader    = "X-Veidly-Signature"
+	webhookMaxAttempts        = 5
+	webhookQueueSize          = 256
+	webhookRecentDeliveries   = 50
+	webhookDeliveryBodyLimit  = 1024 // bytes of a failed response kept for the delivery log
+	defaultWebhookBaseBackoff = 2 * time.Second
+)
+
+var webhookEventTypes = map[string]bool{
+	WebhookEventCreated:      true,
+	WebhookEventUpdated:      true,
+	WebhookEventCancelled:    true,
+	WebhookParticipantJoined: true,
+}
+
+// Webhook is an admin-registered endpoint that receives signed lifecycle notifications
+type Webhook struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"` // Only returned once, on creation
+	EventTypes []string  `json:"event_types"`
+	CreatedBy  int       `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery records one POST attempt to a webhook
+type WebhookDelivery struct {
+	ID         int       `json:"id"`
+	WebhookID  int       `json:"webhook_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+}
+
+// webhookJob is either a fan-out of a new notification (webhookID == 0) or a retry of one delivery
+type webhookJob struct {
+	eventType string
+	eventID   int
+	userID    int // participant.joined only
+	webhookID int
+	body      []byte
+	attempt   int
+}
+
+// webhookDispatcher delivers notifications from a single background worker so request
+// handlers only ever do a non-blocking channel send
+type webhookDispatcher struct {
+	queue       chan webhookJob
+	client      *http.Client
+	baseBackoff time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// webhookDispatch is nil until main starts it; Dispatch on a nil dispatcher is a no-op
+var webhookDispatch *webhookDispatcher
+
+func newWebhookDispatcher(client *http.Client, baseBackoff time.Duration) *webhookDispatcher {
+	d := &webhookDispatcher{
+		queue:       make(chan webhookJob, webhookQueueSize),
+		client:      client,
+		baseBackoff: baseBackoff,
+		done:        make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Dispatch queues a notification about eventID without blocking the caller
+func (d *webhookDispatcher) Dispatch(eventType string, eventID, userID int) {
+	if d == nil {
+		return
+	}
+	d.enqueue(webhookJob{eventType: eventType, eventID: eventID, userID: userID})
+}
+
+func (d *webhookDispatcher) enqueue(job webhookJob) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		log.Printf("⚠️  Webhook dispatcher stopped, dropping %s for event %d", job.eventType, job.eventID)
+		return
+	}
+	select {
+	case d.queue <- job:
+	default:
+		log.Printf("⚠️  Webhook queue full, dropping %s for event %d", job.eventType, job.eventID)
+	}
+}
+
+// Shutdown stops accepting jobs (pending retries are dropped) and waits for the worker to
+// drain what is already queued, or for ctx to expire
+func (d *webhookDispatcher) Shutdown(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		log.Println("🛑 Webhook dispatcher stopped")
+	case <-ctx.Done():
+		log.Println("⚠️  Webhook dispatcher did not drain before shutdown deadline")
+	}
+}
+
+func (d *webhookDispatcher) run() {
+	defer close(d.done)
+	for job := range d.queue {
+		if job.webhookID == 0 {
+			d.fanOut(job)
+		} else {
+			d.retry(job)
+		}
+	}
+}
+
+// fanOut builds the payload once and makes the first delivery attempt to every subscriber
+func (d *webhookDispatcher) fanOut(job webhookJob) {
+	body, err := buildWebhookPayload(job.eventType, job.eventID, job.userID)
+	if err != nil {
+		log.Printf("❌ Failed to build webhook payload for %s event %d: %v", job.eventType, job.eventID, err)
+		return
+	}
+
+	hooks, err := subscribedWebhooks(job.eventType)
+	if err != nil {
+		log.Printf("❌ Failed to load webhooks: %v", err)
+		return
+	}
+	for _, hook := range hooks {
+		d.deliver(hook, webhookJob{eventType: job.eventType, eventID: job.eventID, webhookID: hook.ID, body: body, attempt: 1})
+	}
+}
+
+// retry re-reads the webhook so deliveries stop as soon as an admin deletes it
+func (d *webhookDispatcher) retry(job webhookJob) {
+	var hook Webhook
+	err := db.QueryRow(`SELECT id, url, secret FROM webhooks WHERE id = ?`, job.webhookID).Scan(&hook.ID, &hook.URL, &hook.Secret)
+	if err == sql.ErrNoRows {
+		log.Printf("🗑️ Webhook %d was deleted, abandoning %s delivery", job.webhookID, job.eventType)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load webhook %d: %v", job.webhookID, err)
+		return
+	}
+	d.deliver(hook, job)
+}
+
+func (d *webhookDispatcher) deliver(hook Webhook, job webhookJob) {
+	statusCode, deliveryErr := d.post(hook, job)
+	succeeded := deliveryErr == nil
+	errMsg := ""
+	if deliveryErr != nil {
+		errMsg = deliveryErr.Error()
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_type, attempt, status_code, error, succeeded)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, hook.ID, job.eventType, job.attempt, statusCode, errMsg, succeeded); err != nil {
+		log.Printf("⚠️  Failed to record webhook delivery: %v", err)
+	}
+
+	if succeeded {
+		log.Printf("📤 Webhook %d delivered %s (attempt %d)", hook.ID, job.eventType, job.attempt)
+		return
+	}
+	if job.attempt >= webhookMaxAttempts {
+		log.Printf("❌ Webhook %d gave up on %s after %d attempts: %s", hook.ID, job.eventType, job.attempt, errMsg)
+		return
+	}
+
+	// Exponential backoff: base, 2x base, 4x base, ...
+	delay := d.baseBackoff << (job.attempt - 1)
+	log.Printf("⚠️  Webhook %d attempt %d failed (%s), retrying in %v", hook.ID, job.attempt, errMsg, delay)
+	next := job
+	next.attempt++
+	time.AfterFunc(delay, func() { d.enqueue(next) })
+}
+
+// post sends one signed request; any non-2xx status counts as a failure
+func (d *webhookDispatcher) post(hook Webhook, job webhookJob) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Veidly-Webhooks/1.0")
+	req.Header.Set("X-Veidly-Event", job.eventType)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(hook.Secret, job.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookDeliveryBodyLimit))
+		return resp.StatusCode, fmt.Errorf("receiver returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload returns the X-Veidly-Signature value: "sha256=" + hex HMAC-SHA256 of the body
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func subscribedWebhooks(eventType string) ([]Webhook, error) {
+	rows, err := db.Query(`SELECT id, url, secret, event_types FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var hook Webhook
+		var eventTypes string
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.Secret, &eventTypes); err != nil {
+			return nil, err
+		}
+		for _, t := range strings.Split(eventTypes, ",") {
+			if t == eventType {
+				hooks = append(hooks, hook)
+				break
+			}
+		}
+	}
+	return hooks, rows.Err()
+}
+
+// buildWebhookPayload snapshots the event as it is now. Organizer identity and emails are left
+// out since receivers are typically public channels.
+func buildWebhookPayload(eventType string, eventID, userID int) ([]byte, error) {
+	var e Event
+	var slug, endTime sql.NullString
+	var maxParticipants sql.NullInt64
+	var cancelled bool
+	err := db.QueryRow(`
+		SELECT id, slug, title, description, category, latitude, longitude, location_name, address,
+		       start_time, end_time, max_participants, participant_count,
+		       COALESCE(allow_unregistered_users, 1), require_verified_to_view, cancelled_at IS NOT NULL
+		FROM events WHERE id = ?
+	`, eventID).Scan(
+		&e.ID, &slug, &e.Title, &e.Description, &e.Category, &e.Latitude, &e.Longitude, &e.LocationName, &e.Address,
+		&e.StartTime, &endTime, &maxParticipants, &e.ParticipantCount,
+		&e.AllowUnregisteredUsers, nullable(&e.RequireVerifiedToView), &cancelled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if maxParticipants.Valid {
+		e.MaxParticipants = int(maxParticipants.Int64)
+	}
+
+	data := gin.H{
+		"id":                e.ID,
+		"slug":              slug.String,
+		"title":             html.UnescapeString(e.Title),
+		"description":       html.UnescapeString(e.Description),
+		"category":          e.Category,
+		"latitude":          e.Latitude,
+		"longitude":         e.Longitude,
+		"location_name":     html.UnescapeString(e.LocationName),
+		"address":           html.UnescapeString(e.Address),
+		"start_time":        e.StartTime,
+		"end_time":          endTime.String,
+		"max_participants":  e.MaxParticipants,
+		"participant_count": e.ParticipantCount,
+		"public":            e.AllowUnregisteredUsers && !e.RequireVerifiedToView,
+		"cancelled":         cancelled,
+	}
+	if slug.Valid && slug.String != "" {
+		data["url"] = publicEventURL(slug.String)
+	}
+	if eventType == WebhookParticipantJoined {
+		data["participant_user_id"] = userID
+	}
+
+	return json.Marshal(gin.H{
+		"id":         generateRandomString(16),
+		"type":       eventType,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+		"data":       data,
+	})
+}
+
+// adminCreateWebhook registers a webhook (POST /api/admin/webhooks). The secret is generated
+// when omitted and is only shown in this response.
+func adminCreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url and at least one event type are required"})
+		return
+	}
+
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an absolute http(s) URL"})
+		return
+	}
+
+	seen := map[string]bool{}
+	eventTypes := []string{}
+	for _, t := range req.EventTypes {
+		if !webhookEventTypes[t] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown event type: %s", t)})
+			return
+		}
+		if !seen[t] {
+			seen[t] = true
+			eventTypes = append(eventTypes, t)
+		}
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		secret = generateRandomString(32)
+	} else if len(secret) < 16 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "secret must be at least 16 characters"})
+		return
+	}
+
+	adminID := c.GetInt("user_id")
+	result, err := db.ExecContext(ctx, `INSERT INTO webhooks (url, secret, event_types, created_by) VALUES (?, ?, ?, ?)`,
+		req.URL, secret, strings.Join(eventTypes, ","), adminID)
+	if err != nil {
+		log.Printf("❌ Failed to create webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	log.Printf("🪝 Admin %d created webhook %d for %s", adminID, id, strings.Join(eventTypes, ","))
+	c.JSON(http.StatusCreated, Webhook{
+		ID:         int(id),
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		CreatedBy:  adminID,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// adminListWebhooks returns all webhooks without their secrets (GET /api/admin/webhooks)
+func adminListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx, `SELECT id, url, event_types, created_by, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		log.Printf("❌ Failed to query webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhooks"})
+		return
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var hook Webhook
+		var eventTypes string
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&hook.ID, &hook.URL, &eventTypes, &createdBy, &hook.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning webhook: %v", err)
+			continue
+		}
+		hook.EventTypes = strings.Split(eventTypes, ",")
+		hook.CreatedBy = int(createdBy.Int64)
+		hooks = append(hooks, hook)
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// adminDeleteWebhook removes a webhook and its delivery log; queued retries are abandoned
+func adminDeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	defer tx.Rollback() // Will be no-op if tx.Commit() succeeds
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		log.Printf("❌ Failed to delete webhook %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		log.Printf("❌ Failed to delete deliveries of webhook %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Error committing webhook deletion: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	log.Printf("🗑️ Admin %d deleted webhook %s", c.GetInt("user_id"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// adminGetWebhookDeliveries lists the most recent delivery attempts (GET /api/admin/webhooks/:id/deliveries)
+func adminGetWebhookDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var exists int
+	db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhooks WHERE id = ?`, id).Scan(&exists)
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, webhook_id, event_type, attempt, status_code, error, succeeded, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, id, webhookRecentDeliveries)
+	if err != nil {
+		log.Printf("❌ Failed to query webhook deliveries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var delivery WebhookDelivery
+		var errMsg sql.NullString
+		if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.Attempt,
+			&delivery.StatusCode, &errMsg, &delivery.Succeeded, &delivery.CreatedAt); err != nil {
+			log.Printf("❌ Error scanning webhook delivery: %v", err)
+			continue
+		}
+		delivery.Error = errMsg.String
+		deliveries = append(deliveries, delivery)
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
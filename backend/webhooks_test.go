@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	eventType string
+	signature string
+	body      []byte
+}
+
+// webhookReceiver answers with statusFor(n) for the n-th request (1-based) and reports every request on the channel
+func webhookReceiver(t *testing.T, statusFor func(n int32) int) (*httptest.Server, <-chan receivedWebhook) {
+	received := make(chan receivedWebhook, 16)
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(statusFor(count.Add(1)))
+		received <- receivedWebhook{eventType: r.Header.Get("X-Veidly-Event"), signature: r.Header.Get(webhookSignatureHeader), body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func waitForWebhook(t *testing.T, received <-chan receivedWebhook) receivedWebhook {
+	select {
+	case hook := <-received:
+		return hook
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+		return receivedWebhook{}
+	}
+}
+
+// useTestWebhookDispatcher installs a dispatcher for the duration of t and restores the previous one
+func useTestWebhookDispatcher(t *testing.T, baseBackoff time.Duration) {
+	previous := webhookDispatch
+	dispatcher := newWebhookDispatcher(&http.Client{Timeout: time.Second}, baseBackoff)
+	webhookDispatch = dispatcher
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dispatcher.Shutdown(ctx)
+		webhookDispatch = previous
+	})
+}
+
+func setupWebhookRouter() *gin.Engine {
+	router := gin.New()
+	protected := router.Group("/api")
+	protected.Use(authMiddleware())
+	protected.POST("/events", createEvent)
+	protected.POST("/events/:id/join", joinEvent)
+	admin := router.Group("/api/admin")
+	admin.Use(authMiddleware(), adminMiddleware())
+	admin.GET("/webhooks", adminListWebhooks)
+	admin.POST("/webhooks", adminCreateWebhook)
+	admin.DELETE("/webhooks/:id", adminDeleteWebhook)
+	admin.GET("/webhooks/:id/deliveries", adminGetWebhookDeliveries)
+	admin.POST("/events/bulk", adminBulkEvents)
+	return router
+}
+
+func createWebhook(t *testing.T, router *gin.Engine, adminToken, url string, eventTypes ...string) Webhook {
+	w := doJSON(router, "POST", "/api/admin/webhooks", adminToken, gin.H{"url": url, "event_types": eventTypes})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var hook Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hook))
+	require.NotEmpty(t, hook.Secret)
+	return hook
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+	useTestWebhookDispatcher(t, 10*time.Millisecond)
+
+	router := setupWebhookRouter()
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true, EmailVerified: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com", EmailVerified: true})
+
+	t.Run("Signed payload for a new event", func(t *testing.T) {
+		server, received := webhookReceiver(t, func(int32) int { return http.StatusOK })
+		hook := createWebhook(t, router, adminToken, server.URL, WebhookEventCreated)
+
+		w := doJSON(router, "POST", "/api/events", adminToken, gin.H{
+			"title": "Board Games Night", "description": "Bring your favourite board game",
+			"category": "social_drinks", "latitude": 52.23, "longitude": 21.01, "location_name": "Cafe Plansza",
+			"start_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339), "creator_name": "Admin",
+			"gender_restriction": "any", "age_min": 18, "age_max": 99, "allow_unregistered_users": true,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		delivery := waitForWebhook(t, received)
+		assert.Equal(t, WebhookEventCreated, delivery.eventType)
+		assert.Equal(t, signWebhookPayload(hook.Secret, delivery.body), delivery.signature)
+		assert.NotEqual(t, signWebhookPayload("wrong-secret-value", delivery.body), delivery.signature)
+
+		var payload struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(delivery.body, &payload))
+		assert.Equal(t, WebhookEventCreated, payload.Type)
+		assert.Equal(t, "Board Games Night", payload.Data["title"])
+		assert.Equal(t, "Cafe Plansza", payload.Data["location_name"])
+		assert.Equal(t, true, payload.Data["public"])
+		assert.NotContains(t, string(delivery.body), "admin@example.com")
+
+		doJSON(router, "DELETE", fmt.Sprintf("/api/admin/webhooks/%d", hook.ID), adminToken, nil)
+	})
+
+	eventID := createTestEvent(t, testDB, adminID, "Retry Event")
+
+	t.Run("Non-2xx responses are retried", func(t *testing.T) {
+		server, received := webhookReceiver(t, func(n int32) int {
+			if n < 3 {
+				return http.StatusInternalServerError
+			}
+			return http.StatusNoContent
+		})
+		hook := createWebhook(t, router, adminToken, server.URL, WebhookParticipantJoined)
+
+		w := doJSON(router, "POST", fmt.Sprintf("/api/events/%d/join", eventID), userToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		first := waitForWebhook(t, received)
+		for i := 0; i < 2; i++ {
+			retry := waitForWebhook(t, received)
+			assert.Equal(t, first.body, retry.body, "retries resend the same payload")
+		}
+		assert.Contains(t, string(first.body), fmt.Sprintf(`"participant_user_id":%d`, userID))
+
+		var deliveries []WebhookDelivery
+		require.Eventually(t, func() bool {
+			w := doJSON(router, "GET", fmt.Sprintf("/api/admin/webhooks/%d/deliveries", hook.ID), adminToken, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			deliveries = nil
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+			return len(deliveries) == 3
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.True(t, deliveries[0].Succeeded)
+		assert.Equal(t, 3, deliveries[0].Attempt)
+		assert.False(t, deliveries[2].Succeeded)
+		assert.Equal(t, http.StatusInternalServerError, deliveries[2].StatusCode)
+
+		doJSON(router, "DELETE", fmt.Sprintf("/api/admin/webhooks/%d", hook.ID), adminToken, nil)
+	})
+
+	t.Run("Deleting a webhook stops deliveries", func(t *testing.T) {
+		useTestWebhookDispatcher(t, 100*time.Millisecond)
+		server, received := webhookReceiver(t, func(int32) int { return http.StatusBadGateway })
+		hook := createWebhook(t, router, adminToken, server.URL, WebhookEventCancelled)
+
+		w := doJSON(router, "POST", "/api/admin/events/bulk", adminToken, gin.H{"ids": []int64{eventID}, "action": "cancel"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, WebhookEventCancelled, waitForWebhook(t, received).eventType)
+
+		w = doJSON(router, "DELETE", fmt.Sprintf("/api/admin/webhooks/%d", hook.ID), adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		select {
+		case <-received:
+			t.Fatal("deleted webhook received a retry")
+		case <-time.After(300 * time.Millisecond):
+		}
+
+		w = doJSON(router, "GET", "/api/admin/webhooks", adminToken, nil)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+}
+
+func TestAdminCreateWebhookValidation(t *testing.T) {
+	setupJWT()
+	testDB := setupTestDB(t)
+	defer cleanupTestDB(testDB)
+	db = testDB
+
+	router := setupWebhookRouter()
+	adminID := createTestUser(t, testDB, "admin@example.com", "Admin", "password123", true)
+	userID := createTestUser(t, testDB, "user@example.com", "User", "password123", false)
+	adminToken, _ := generateToken(User{ID: int(adminID), Email: "admin@example.com", IsAdmin: true})
+	userToken, _ := generateToken(User{ID: int(userID), Email: "user@example.com"})
+
+	for name, payload := range map[string]gin.H{
+		"relative url":       {"url": "/hook", "event_types": []string{WebhookEventCreated}},
+		"unsupported scheme": {"url": "ftp://example.com", "event_types": []string{WebhookEventCreated}},
+		"unknown type":       {"url": "https://example.com", "event_types": []string{"event.deleted"}},
+		"no types":           {"url": "https://example.com", "event_types": []string{}},
+		"short secret":       {"url": "https://example.com", "event_types": []string{WebhookEventCreated}, "secret": "abc"},
+	} {
+		w := doJSON(router, "POST", "/api/admin/webhooks", adminToken, payload)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	w := doJSON(router, "POST", "/api/admin/webhooks", userToken, gin.H{"url": "https://example.com", "event_types": []string{WebhookEventCreated}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	createWebhook(t, router, adminToken, "https://example.com/hook", WebhookEventCreated, WebhookEventCreated)
+	w = doJSON(router, "GET", "/api/admin/webhooks", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var hooks []Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hooks))
+	require.Len(t, hooks, 1)
+	assert.Empty(t, hooks[0].Secret, "secrets are not listed")
+	assert.Equal(t, []string{WebhookEventCreated}, hooks[0].EventTypes)
+}
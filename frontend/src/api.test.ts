@@ -123,13 +123,13 @@ describe('API Module', () => {
 
   describe('getEventParticipants', () => {
     it('should fetch event participants', async () => {
-      const participants = [mockUser]
-      vi.mocked(axios.get).mockResolvedValue({ data: participants })
+      const page = { items: [{ id: 1, name: 'Test User', joined_at: '2024-01-01T00:00:00Z' }], total: 1, page: 1, per_page: 200 }
+      vi.mocked(axios.get).mockResolvedValue({ data: page })
 
       const result = await api.getEventParticipants(1)
 
-      expect(axios.get).toHaveBeenCalledWith(`${API_BASE_URL}/events/1/participants`)
-      expect(result).toEqual(participants)
+      expect(axios.get).toHaveBeenCalledWith(`${API_BASE_URL}/events/1/participants`, { params: { page: 1, per_page: 200 } })
+      expect(result).toEqual(page)
     })
   })
 })
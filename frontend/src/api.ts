@@ -1,5 +1,5 @@
 import axios from 'axios'
-import { Event, User } from './types'
+import { Announcement, Event, EventSuggestion, Paginated, Participant } from './types'
 import { API_BASE_URL } from './config'
 
 // Log API URL in development for debugging
@@ -13,6 +13,7 @@ interface Place {
   lon: string
   type: string
   importance: number
+  source?: 'photon' | 'nominatim'
 }
 
 export const api = {
@@ -21,6 +22,11 @@ export const api = {
     return response.data
   },
 
+  suggestEvents: async (q: string): Promise<EventSuggestion[]> => {
+    const response = await axios.get(`${API_BASE_URL}/events/suggest`, { params: { q } })
+    return response.data.suggestions
+  },
+
   getEvent: async (id: number): Promise<Event> => {
     const response = await axios.get(`${API_BASE_URL}/events/${id}`)
     return response.data
@@ -41,16 +47,18 @@ export const api = {
   },
 
   // Event participation
-  joinEvent: async (eventId: number): Promise<void> => {
-    await axios.post(`${API_BASE_URL}/events/${eventId}/join`)
+  joinEvent: async (eventId: number, answers?: { question_id: number; answer: string }[]): Promise<void> => {
+    await axios.post(`${API_BASE_URL}/events/${eventId}/join`, answers ? { answers } : undefined)
   },
 
   leaveEvent: async (eventId: number): Promise<void> => {
     await axios.delete(`${API_BASE_URL}/events/${eventId}/leave`)
   },
 
-  getEventParticipants: async (eventId: number): Promise<User[]> => {
-    const response = await axios.get(`${API_BASE_URL}/events/${eventId}/participants`)
+  getEventParticipants: async (eventId: number, page = 1, perPage = 200): Promise<Paginated<Participant>> => {
+    const response = await axios.get(`${API_BASE_URL}/events/${eventId}/participants`, {
+      params: { page, per_page: perPage },
+    })
     return response.data
   },
 
@@ -59,6 +67,12 @@ export const api = {
     return response.data
   },
 
+  // Site-wide banners (maintenance windows, policy changes)
+  getActiveAnnouncements: async (lang?: string): Promise<Announcement[]> => {
+    const response = await axios.get(`${API_BASE_URL}/announcements/active`, { params: lang ? { lang } : undefined })
+    return response.data.announcements
+  },
+
   // Place search
   searchPlaces: async (query: string): Promise<Place[]> => {
     const response = await axios.get(`${API_BASE_URL}/search/places`, {
@@ -67,6 +81,14 @@ export const api = {
     return response.data
   },
 
+  // Reverse geocoding for a dropped map pin
+  reverseGeocode: async (lat: number, lon: number): Promise<Place> => {
+    const response = await axios.get(`${API_BASE_URL}/search/reverse`, {
+      params: { lat, lon }
+    })
+    return response.data
+  },
+
   // Email verification
   verifyEmail: async (token: string): Promise<void> => {
     await axios.get(`${API_BASE_URL}/auth/verify-email`, {
@@ -49,7 +49,7 @@ describe('AdminPanel Component', () => {
 
   describe('Rendering', () => {
     it('should render admin panel', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -59,7 +59,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should render navbar with logo and navigation buttons', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -71,7 +71,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should display admin user name', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -81,7 +81,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should render tabs for users and events management', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -95,7 +95,7 @@ describe('AdminPanel Component', () => {
   describe('Users Tab', () => {
     it('should display users list in users tab by default', async () => {
       const users = [mockUser, mockAdmin]
-      vi.mocked(axios.get).mockResolvedValue({ data: users })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users, total: users.length } })
 
       render(<AdminPanel />)
 
@@ -106,7 +106,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should display user information in table', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -118,7 +118,7 @@ describe('AdminPanel Component', () => {
 
     it('should show block button for non-admin active users', async () => {
       const regularUser = { ...mockUser, is_blocked: false, is_admin: false }
-      vi.mocked(axios.get).mockResolvedValue({ data: [regularUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [regularUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -129,7 +129,7 @@ describe('AdminPanel Component', () => {
 
     it('should show unblock button for blocked users', async () => {
       const blockedUser = { ...mockUser, is_blocked: true, is_admin: false }
-      vi.mocked(axios.get).mockResolvedValue({ data: [blockedUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [blockedUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -139,7 +139,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should not show action buttons for admin users', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockAdmin] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockAdmin], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -151,7 +151,7 @@ describe('AdminPanel Component', () => {
 
     it('should call API to block user when block button is clicked', async () => {
       const regularUser = { ...mockUser, is_blocked: false, is_admin: false }
-      vi.mocked(axios.get).mockResolvedValue({ data: [regularUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [regularUser], total: 1 } })
       vi.mocked(axios.put).mockResolvedValue({})
 
       render(<AdminPanel />)
@@ -172,7 +172,7 @@ describe('AdminPanel Component', () => {
 
     it('should call API to unblock user when unblock button is clicked', async () => {
       const blockedUser = { ...mockUser, is_blocked: true, is_admin: false }
-      vi.mocked(axios.get).mockResolvedValue({ data: [blockedUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [blockedUser], total: 1 } })
       vi.mocked(axios.put).mockResolvedValue({})
 
       render(<AdminPanel />)
@@ -196,7 +196,7 @@ describe('AdminPanel Component', () => {
         { ...mockUser, is_blocked: false },
         { ...mockUser, id: 2, is_blocked: true },
       ]
-      vi.mocked(axios.get).mockResolvedValue({ data: users })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users, total: users.length } })
 
       render(<AdminPanel />)
 
@@ -209,7 +209,7 @@ describe('AdminPanel Component', () => {
 
   describe('Events Tab', () => {
     it('should switch to events tab when clicked', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -219,7 +219,7 @@ describe('AdminPanel Component', () => {
 
       const eventsTab = screen.getByRole('button', { name: /events management/i })
 
-      vi.mocked(axios.get).mockResolvedValue({ data: mockEvents })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: mockEvents, total: mockEvents.length } })
       fireEvent.click(eventsTab)
 
       await waitFor(() => {
@@ -228,7 +228,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should display events list in events tab', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: mockEvents })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: mockEvents, total: mockEvents.length } })
 
       render(<AdminPanel />)
 
@@ -243,7 +243,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should display event information in table', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -257,7 +257,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should show delete button for each event', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -271,7 +271,7 @@ describe('AdminPanel Component', () => {
 
     it('should show confirmation before deleting event', async () => {
       const confirmSpy = vi.spyOn(window, 'confirm').mockReturnValue(false)
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -291,7 +291,7 @@ describe('AdminPanel Component', () => {
 
     it('should call API to delete event when confirmed', async () => {
       const confirmSpy = vi.spyOn(window, 'confirm').mockReturnValue(true)
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
       vi.mocked(axios.delete).mockResolvedValue({})
 
       render(<AdminPanel />)
@@ -317,7 +317,7 @@ describe('AdminPanel Component', () => {
 
     it('should not call API to delete event when cancelled', async () => {
       const confirmSpy = vi.spyOn(window, 'confirm').mockReturnValue(false)
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -336,7 +336,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should display empty state when no events', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [], total: 0 } })
 
       render(<AdminPanel />)
 
@@ -351,7 +351,7 @@ describe('AdminPanel Component', () => {
 
   describe('Navigation', () => {
     it('should navigate to map when "Back to Map" is clicked', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -366,7 +366,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should navigate to home when logo is clicked', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -381,7 +381,7 @@ describe('AdminPanel Component', () => {
     })
 
     it('should call logout when logout button is clicked', async () => {
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [mockUser], total: 1 } })
 
       render(<AdminPanel />)
 
@@ -399,7 +399,7 @@ describe('AdminPanel Component', () => {
   describe('Loading State', () => {
     it('should show loading state while fetching data', async () => {
       vi.mocked(axios.get).mockImplementation(
-        () => new Promise(resolve => setTimeout(() => resolve({ data: [mockUser] }), 100))
+        () => new Promise(resolve => setTimeout(() => resolve({ data: { users: [mockUser], total: 1 } }), 100))
       )
 
       render(<AdminPanel />)
@@ -428,7 +428,7 @@ describe('AdminPanel Component', () => {
 
     it('should show toast on block user failure', async () => {
       const regularUser = { ...mockUser, is_blocked: false, is_admin: false }
-      vi.mocked(axios.get).mockResolvedValue({ data: [regularUser] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { users: [regularUser], total: 1 } })
       vi.mocked(axios.put).mockRejectedValue(new Error('API Error'))
 
       render(<AdminPanel />)
@@ -447,7 +447,7 @@ describe('AdminPanel Component', () => {
 
     it('should show toast on delete event failure', async () => {
       const confirmSpy = vi.spyOn(window, 'confirm').mockReturnValue(true)
-      vi.mocked(axios.get).mockResolvedValue({ data: [mockEvents[0]] })
+      vi.mocked(axios.get).mockResolvedValue({ data: { events: [mockEvents[0]], total: 1 } })
       vi.mocked(axios.delete).mockRejectedValue(new Error('API Error'))
 
       render(<AdminPanel />)
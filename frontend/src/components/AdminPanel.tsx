@@ -23,11 +23,11 @@ function AdminPanel() {
     setLoading(true)
     try {
       if (activeTab === 'users') {
-        const response = await axios.get<User[]>(`${API_BASE_URL}/admin/users`)
-        setUsers(response.data)
+        const response = await axios.get<{ users: User[]; total: number }>(`${API_BASE_URL}/admin/users`)
+        setUsers(response.data.users ?? [])
       } else {
-        const response = await axios.get<Event[]>(`${API_BASE_URL}/admin/events`)
-        setEvents(response.data)
+        const response = await axios.get<{ events: Event[]; total: number }>(`${API_BASE_URL}/admin/events`)
+        setEvents(response.data.events ?? [])
       }
     } catch (error) {
       console.error('Failed to load data:', error)
@@ -5,13 +5,14 @@ import './EventComments.css'
 interface Comment {
   id: number
   event_id: number
-  user_id: number
+  user_id: number  // 0 when the author's alias hides them from you
   comment: string
   user_name: string
   created_at: string
   updated_at?: string
   is_deleted: boolean
   is_own: boolean
+  pending_review?: boolean  // Held by moderation; only the author sees it
 }
 
 interface EventCommentsProps {
@@ -19,6 +19,14 @@ interface Place {
   lon: string
 }
 
+// Wall-clock value for a datetime-local input, showing a UTC instant in the event's time zone
+function toZoneInput(instant: string, timeZone: string): string {
+  const parts = new Intl.DateTimeFormat('sv-SE', {
+    timeZone, year: 'numeric', month: '2-digit', day: '2-digit', hour: '2-digit', minute: '2-digit', hourCycle: 'h23',
+  }).format(new Date(instant))
+  return parts.replace(' ', 'T')
+}
+
 // Component to update map view when coordinates change
 function MapUpdater({ center }: { center: [number, number] }) {
   const map = useMap()
@@ -33,14 +41,17 @@ function EventForm({ initialLocation, onClose, event }: EventFormProps) {
 
   const isEditMode = !!event
 
+  // Times are entered as wall clock in the event's zone; new events use the browser's zone
+  const timezone = event?.timezone || user?.timezone || Intl.DateTimeFormat().resolvedOptions().timeZone
+
   const [formData, setFormData] = useState({
     title: event?.title || '',
     description: event?.description || '',
     category: event?.category || 'social_drinks',
     latitude: event?.latitude || initialLocation?.lat || 0,
     longitude: event?.longitude || initialLocation?.lng || 0,
-    start_time: event?.start_time ? new Date(event.start_time).toISOString().slice(0, 16) : '',
-    end_time: event?.end_time ? new Date(event.end_time).toISOString().slice(0, 16) : '',
+    start_time: event?.start_time ? toZoneInput(event.start_time, timezone) : '',
+    end_time: event?.end_time ? toZoneInput(event.end_time, timezone) : '',
     creator_name: event?.creator_name || user?.name || '',
     max_participants: event?.max_participants?.toString() || '',
     gender_restriction: event?.gender_restriction || 'any',
@@ -163,6 +174,7 @@ function EventForm({ initialLocation, onClose, event }: EventFormProps) {
     try {
       const eventData: Event = {
         ...formData,
+        timezone,
         max_participants: formData.max_participants ? parseInt(formData.max_participants) : undefined,
       }
 
@@ -88,7 +88,7 @@ function EventsSidebar({ events, onEventClick }: EventsSidebarProps) {
                         }}
                         style={{ cursor: 'pointer', color: '#667eea', textDecoration: 'underline', fontWeight: 600 }}
                       >
-                        {event.creator_name}
+                        {event.organizer_hidden ? '🔒 Join to see organizer' : event.creator_name}
                       </span>
                     </span>
                   </div>
@@ -326,7 +326,7 @@ function MapView() {
                           if (event.user_id) navigate(`/profile/${event.user_id}`)
                         }}
                         style={{ cursor: 'pointer', color: '#667eea', textDecoration: 'underline' }}
-                        dangerouslySetInnerHTML={{ __html: sanitizeText(event.creator_name) }}
+                        dangerouslySetInnerHTML={{ __html: event.organizer_hidden ? '🔒 Join to see organizer' : sanitizeText(event.creator_name) }}
                       />
                     </p>
                     {event.max_participants && (
@@ -1,7 +1,7 @@
 import { describe, it, expect, vi, beforeEach } from 'vitest'
 import { render, screen, fireEvent, waitFor } from '../test/testUtils'
 import ParticipantModal from './ParticipantModal'
-import { mockUser } from '../test/mockData'
+import { mockParticipant, participantPage } from '../test/mockData'
 import * as api from '../api'
 
 vi.mock('../api', () => ({
@@ -19,7 +19,7 @@ describe('ParticipantModal Component', () => {
 
   describe('Rendering', () => {
     it('should render modal', () => {
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue([])
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage([]))
 
       render(
         <ParticipantModal
@@ -50,8 +50,8 @@ describe('ParticipantModal Component', () => {
     })
 
     it('should display participants after loading', async () => {
-      const participants = [mockUser]
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participants)
+      const participants = [mockParticipant]
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage(participants))
 
       render(
         <ParticipantModal
@@ -62,12 +62,12 @@ describe('ParticipantModal Component', () => {
       )
 
       await waitFor(() => {
-        expect(screen.getByText(mockUser.name)).toBeInTheDocument()
+        expect(screen.getByText(mockParticipant.name)).toBeInTheDocument()
       })
     })
 
     it('should show message when no participants', async () => {
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue([])
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage([]))
 
       render(
         <ParticipantModal
@@ -101,8 +101,8 @@ describe('ParticipantModal Component', () => {
 
   describe('Participant Display', () => {
     it('should display participant names', async () => {
-      const participants = [mockUser, { ...mockUser, id: 2, name: 'Jane Doe' }]
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participants)
+      const participants = [mockParticipant, { ...mockParticipant, id: 2, name: 'Jane Doe' }]
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage(participants))
 
       render(
         <ParticipantModal
@@ -119,8 +119,8 @@ describe('ParticipantModal Component', () => {
     })
 
     it('should display multiple participant names', async () => {
-      const participants = [mockUser, { ...mockUser, id: 2, name: 'Jane Doe' }]
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participants)
+      const participants = [mockParticipant, { ...mockParticipant, id: 2, name: 'Jane Doe' }]
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage(participants))
 
       render(
         <ParticipantModal
@@ -138,7 +138,7 @@ describe('ParticipantModal Component', () => {
 
   describe('Modal Interactions', () => {
     it('should call onClose when close button is clicked', async () => {
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue([])
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage([]))
 
       render(
         <ParticipantModal
@@ -160,7 +160,7 @@ describe('ParticipantModal Component', () => {
     })
 
     it('should call onClose when overlay is clicked', async () => {
-      vi.mocked(api.api.getEventParticipants).mockResolvedValue([])
+      vi.mocked(api.api.getEventParticipants).mockResolvedValue(participantPage([]))
 
       render(
         <ParticipantModal
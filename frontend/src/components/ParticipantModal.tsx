@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
 import { useNavigate } from 'react-router-dom'
-import { User } from '../types'
+import { Participant } from '../types'
 import { api } from '../api'
 import './ParticipantModal.css'
 
@@ -12,7 +12,7 @@ interface ParticipantModalProps {
 
 function ParticipantModal({ eventId, eventTitle, onClose }: ParticipantModalProps) {
   const navigate = useNavigate()
-  const [participants, setParticipants] = useState<User[]>([])
+  const [participants, setParticipants] = useState<Participant[]>([])
   const [loading, setLoading] = useState(true)
   const [error, setError] = useState('')
 
@@ -37,7 +37,7 @@ function ParticipantModal({ eventId, eventTitle, onClose }: ParticipantModalProp
     try {
       setLoading(true)
       const data = await api.getEventParticipants(eventId)
-      setParticipants(data)
+      setParticipants(data.items)
       setLoading(false)
     } catch (err: any) {
       setError(err.response?.data?.error || 'Failed to load participants')
@@ -82,11 +82,11 @@ function ParticipantModal({ eventId, eventTitle, onClose }: ParticipantModalProp
             </div>
           ) : (
             <div className="participants-list">
-              {participants.map((participant) => (
+              {participants.map((participant, index) => (
                 <div
-                  key={participant.id}
+                  key={participant.id || `alias-${index}`}
                   className="participant-card"
-                  onClick={() => handleProfileClick(participant.id)}
+                  onClick={participant.id ? () => handleProfileClick(participant.id) : undefined}
                 >
                   <div className="participant-avatar">
                     {participant.name.charAt(0).toUpperCase()}
@@ -103,7 +103,7 @@ function ParticipantModal({ eventId, eventTitle, onClose }: ParticipantModalProp
                       </div>
                     )}
                   </div>
-                  <div className="view-profile-arrow">→</div>
+                  {participant.id !== 0 && <div className="view-profile-arrow">→</div>}
                 </div>
               ))}
             </div>
@@ -177,7 +177,7 @@ export default function PublicEventPage() {
         <div className="event-info-grid">
           <div className="event-info-section">
             <h3>About</h3>
-            <p className="event-description" dangerouslySetInnerHTML={{ __html: sanitizeText(event.description) }} />
+            <div className="event-description" dangerouslySetInnerHTML={{ __html: event.description_html ?? sanitizeText(event.description) }} />
 
             <h3>When</h3>
             <p>
@@ -200,7 +200,7 @@ export default function PublicEventPage() {
             </a>
 
             <h3>Organizer</h3>
-            <p>{event.creator_name}</p>
+            <p>{event.organizer_hidden ? '🔒 Join to see organizer' : event.creator_name}</p>
 
             {event.event_languages && (
               <>
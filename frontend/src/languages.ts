@@ -1,5 +1,6 @@
 // Comprehensive list of all official EU languages plus Swiss languages
 // Includes flag emoji for each language
+// Keep in sync with LanguageCodes in backend/languages.go (the API rejects other codes)
 
 export interface Language {
   code: string
@@ -1,4 +1,4 @@
-import { Event, User } from '../types'
+import { Event, Paginated, Participant, User } from '../types'
 
 export const mockUser: User = {
   id: 1,
@@ -12,6 +12,21 @@ export const mockUser: User = {
   created_at: '2024-01-01T00:00:00Z',
 }
 
+export const mockParticipant: Participant = {
+  id: 1,
+  name: 'Test User',
+  bio: 'Test bio',
+  languages: 'en,de',
+  joined_at: '2024-01-02T00:00:00Z',
+}
+
+export const participantPage = (items: Participant[]): Paginated<Participant> => ({
+  items,
+  total: items.length,
+  page: 1,
+  per_page: 200,
+})
+
 export const mockAdmin: User = {
   ...mockUser,
   id: 2,
@@ -6,10 +6,100 @@ export interface User {
   gender?: string
   bio?: string
   languages?: string  // Comma-separated language codes (e.g., "en,de,fr")
+  birth_year?: number  // Only returned on the user's own profile
   is_admin: boolean
   is_blocked: boolean
   email_verified: boolean
+  verification_email_failed?: boolean  // The verification email bounced; only returned on the user's own profile
+  registration_ip?: string  // Only returned in the admin user list
+  blocked_until?: string  // End of a suspension; is_blocked without it is permanent. Admin user list only
+  block_reason?: string  // Admin user list only
   created_at: string
+  timezone?: string  // IANA zone new events default to; only returned on the user's own profile
+  digest_emails?: boolean  // Monthly organizer digest; only returned on the user's own profile
+  events_paused?: boolean  // Vacation mode; only returned on the user's own profile
+  threema?: string  // 8-character Threema ID; only returned on the user's own profile
+  updated_at?: string  // Only returned on the user's own profile
+}
+
+// Row of GET /api/events/:id/participants, in join order
+export interface Participant {
+  id: number  // 0 when the participant's alias hides them from you; bio, languages and email are left out too
+  name: string  // The participant's event alias if set; the organizer and admins see "Name (alias)"
+  bio?: string
+  languages?: string
+  email?: string  // Admins, when the participant shares it, or the organizer when shares_contact is set
+  threema?: string  // Organizer only, when shares_contact is set
+  shares_contact?: boolean  // Organizer, admins and the participant themselves
+  joined_at: string
+  attendance?: 'attended' | 'no_show'  // Organizer and admins only
+  checked_in?: boolean  // Organizer and admins only
+  is_admin?: boolean  // Admins only
+  is_blocked?: boolean
+  email_verified?: boolean
+}
+
+export interface Paginated<T> {
+  items: T[]
+  total: number
+  page: number
+  per_page: number
+}
+
+export interface EventOrganizer {
+  id: number
+  name: string
+  languages: string
+  member_since: string
+  threema?: string  // Only for participants of the event
+}
+
+// One entry of GET /api/profile/activity, read as "<actor> <verb> <event>"
+export interface ActivityEntry {
+  id: number
+  actor_id: number  // 0 for moderation actions
+  actor_name: string
+  is_self: boolean
+  verb: 'joined' | 'left' | 'commented' | 'cancelled' | 'took_over'
+  event_id: number
+  event_title: string
+  event_slug: string
+  created_at: string
+}
+
+// One entry of GET /api/profile/hidden-events; DELETE /api/events/:id/hide unhides it
+export interface HiddenEvent {
+  id: number
+  title: string
+  slug: string
+  start_time: string
+  cancelled?: boolean
+  hidden_at: string
+}
+
+// One entry of GET /api/notifications; event notifications carry event_id, title and slug
+export interface AppNotification {
+  id: number
+  type: 'event_cancelled' | 'spot_available' | 'join_deadline' | 'group_join_approved' | 'draft_expiring' | 'admin_granted' | 'transfer_offered' | 'event_transferred'
+  payload: {
+    event_id?: number
+    title?: string
+    slug?: string
+    reason?: 'moderation'
+    group_id?: number
+    name?: string
+  }
+  read_at: string | null
+  created_at: string
+}
+
+// A handover waiting for the new organizer (POST /api/events/:id/transfer)
+export interface EventTransfer {
+  event_id: number
+  from_user_id: number
+  to_user_id: number
+  keep_as_participant: boolean
+  expires_at: string
 }
 
 export interface AuthResponse {
@@ -17,29 +107,173 @@ export interface AuthResponse {
   user: User
 }
 
+// Site-wide banner from GET /api/announcements/active, already in the requested language
+export interface Announcement {
+  id: number
+  message: string
+  language?: string  // Empty when the default message was used
+  level: 'info' | 'warning'
+  starts_at: string
+  ends_at: string | null
+}
+
+// GET /api/bootstrap, everything the app loads on start; account fields only for signed-in users
+export interface Bootstrap {
+  categories: Record<string, string>
+  announcements?: Announcement[]
+  unavailable: string[]  // Sections that failed or ran out of time and were left out
+  user?: User
+  impersonating?: boolean
+  impersonator_id?: number
+  unread_notifications?: number
+  blocked_user_ids?: number[]
+  pending_invitations?: number  // Event handovers offered to the user
+  created_events?: EventRef[]  // Upcoming, soonest first
+  joined_events?: EventRef[]
+}
+
+export interface EventRef {
+  id: number
+  slug: string
+}
+
+// A category-specific event field from GET /api/categories: one of values, or free text up to max_length
+export interface AttributeSpec {
+  key: string
+  values?: string[]
+  max_length?: number
+}
+
+// Asked when joining an event; answers go in the join request
+export interface EventQuestion {
+  id: number
+  text: string
+  type: 'text' | 'yes_no'
+  required: boolean
+}
+
+// GET /api/events/:id/join-eligibility; reasons are the codes a join would be refused with, in order
+export interface JoinEligibility {
+  can_join: boolean
+  reasons: string[]  // e.g. NEEDS_LOGIN, EMAIL_NOT_VERIFIED, EVENT_FULL, ALREADY_JOINED, AGE_RESTRICTED
+}
+
+// Search-box suggestion from GET /api/events/suggest; title is HTML-escaped
+export interface EventSuggestion {
+  title: string
+  slug: string
+  start_time: string
+  category: string
+}
+
+// GET /api/events/agenda: consecutive days from today in `timezone`, empty days included
+export interface AgendaDay {
+  date: string  // YYYY-MM-DD
+  events: Event[]
+}
+
+export interface EventAgenda {
+  timezone: string
+  days: AgendaDay[]
+}
+
+// GET /api/public/landing: totals cover every matching upcoming event, events only the next 12
+export interface LandingEvent {
+  id: number
+  title: string
+  slug: string
+  category: string
+  start_time: string
+  location_name: string
+  latitude: number
+  longitude: number
+  participant_count: number
+}
+
+export interface LandingPage {
+  events: LandingEvent[]
+  total: number
+  categories: Record<string, number>
+  next_event_at: string | null
+  featured: LandingEvent[]  // At most 3, among the matching events
+}
+
+// GET /api/public/stats: with rounded set the counts are rounded down, shown as "1,200+"
+export interface PublicStats {
+  members: number
+  events_organized: number
+  upcoming_events: number
+  events_this_week: number
+  top_category_this_month: string
+  rounded: boolean
+  featured: LandingEvent[]  // Upcoming featured events, at most 3
+}
+
+// Optional body of DELETE /api/events/:id/leave
+export type LeaveReason = 'time_changed' | 'too_far' | 'other_plans' | 'cost' | 'not_a_fit' | 'other'
+
+export interface LeaveEventRequest {
+  reason?: LeaveReason
+  note?: string  // Up to 200 characters; a note without a reason counts as 'other'
+  share_identity?: boolean  // Let the organizer see who left and the note
+}
+
+// GET /api/events/:id/stats (organizer and admins); only leavers who shared their identity are named
+export interface EventStats {
+  participants: number
+  interested: number
+  leaves: {
+    total: number
+    reasons: Record<LeaveReason, number>
+    no_reason: number
+    shared: { user_id: number; name: string; reason: string; note: string; left_at: string }[]
+  }
+}
+
 export interface Event {
   id?: number
   user_id?: number
   title: string
   description: string
+  description_format?: 'plain' | 'markdown'
+  description_html?: string  // Rendered and sanitized by the server
   category: string
   latitude: number
   longitude: number
-  start_time: string
+  location_name?: string
+  address?: string
+  start_time: string  // UTC instant (RFC3339)
   end_time?: string
-  creator_name: string
+  join_deadline?: string  // UTC instant; signups close here instead of at the start
+  timezone?: string  // IANA zone the organizer entered the times in
+  creator_name: string  // Empty when organizer_hidden
   max_participants?: number
+  reserved_spots?: number  // Spots the organizer holds for guests off the platform; spots_left is net of them
+  joins_paused?: boolean  // The organizer paused joining (vacation mode); grey out the join button
+  is_featured?: boolean  // Pinned by an admin; listings put up to 3 of them first
+  featured_until?: string  // When the feature ends; absent when it runs until unfeatured
+  price_amount?: number | null  // Cents; 0 means free, null means not stated
+  price_currency?: string
+  payment_note?: string
   gender_restriction: 'any' | 'male' | 'female' | 'non-binary'
   age_min: number
   age_max: number
+  max_joins_per_network?: number  // Most participants from one network or company domain; 0 means no cap
   smoking_allowed: boolean
   alcohol_allowed: boolean
   event_languages?: string  // Comma-separated language codes for the event
   slug?: string
   created_at?: string
+  updated_at?: string
   user_email?: string
   creator_languages?: string  // Comma-separated language codes from creator's profile
   participant_count?: number  // Number of users who joined this event
+  interested_count?: number  // Users marked interested; they don't count against max_participants
+  spots_left?: number | null  // Remaining capacity, null when unlimited
+  is_full?: boolean
+  join_closed?: boolean  // Past the join deadline, started, cancelled or full: the join button should be disabled
+  cancelled?: boolean
+  group_id?: number | null  // Group the event is published in, null for standalone events
 
   // Privacy controls
   hide_organizer_until_joined: boolean
@@ -47,7 +281,19 @@ export interface Event {
   require_verified_to_join: boolean
   require_verified_to_view: boolean
   allow_unregistered_users: boolean
+  require_birth_year?: boolean  // Age-restricted events turn away users without a birth year
+  organizer?: EventOrganizer | null  // null when hidden until joining
+  organizer_hidden?: boolean
+  hidden_pending_review?: boolean  // Hidden after reports or by the moderation filter; only the creator and admins see it
+  draft?: boolean  // Created before the organizer verified their email; published on verification
+  notice?: string  // Only on create responses, e.g. why the event is a draft
+  long_range?: boolean  // Admins only: allow a start beyond the usual 18 months; not stored
+  unread_count?: number  // Comments posted since the viewer last opened the thread (participants only)
+  questions?: EventQuestion[]  // Asked when joining (public event only)
+  attributes?: Record<string, string>  // Category-specific fields, e.g. { skill_level: 'beginner' }
   is_participant?: boolean  // Whether current user is a participant
+  is_interested?: boolean  // Whether current user is marked interested
+  language_match?: number  // Share of the viewer's languages the event is held in (signed-in listings)
 }
 
 export const CATEGORIES = {